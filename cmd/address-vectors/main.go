@@ -0,0 +1,115 @@
+// address-vectors computes the addresses of contracts created with CREATE and
+// CREATE2 and generates edge case vectors of their derivation.
+//
+// Usage:
+//
+//	go run ./cmd/address-vectors --sender 0x... --nonce 1
+//	go run ./cmd/address-vectors --sender 0x... --salt 0x... (--initcode 0x... | --initcode-hash 0x...)
+//	go run ./cmd/address-vectors --eof --sender 0x... --salt 0x...
+//	go run ./cmd/address-vectors --vectors [--eof] [--output address_vectors]
+//
+// Without --salt the CREATE address of the sender and nonce is printed, the
+// last 20 bytes of the hash of their RLP list. With --salt the CREATE2
+// address of EIP-1014 is printed, the last 20 bytes of the hash of 0xff, the
+// sender, the salt and the hash of the initcode. With --eof it is instead the
+// address of the EOFCREATE instruction proposed by EIP-7620, which leaves the
+// initcontainer out of the hash and pads the sender to 32 bytes:
+// keccak256(0xff || sender32 || salt)[12:].
+//
+// With --vectors the edge cases of vectors.go are written to the output
+// directory: create.json with the nonces around the RLP length boundaries up
+// to the EIP-2681 limit, create2.json with empty, single byte and maximum
+// size initcode and extreme salts, and with --eof eofcreate.json. Every
+// vector lists its inputs, the encoding the address is hashed from and the
+// address.
+//
+// The derivations are implemented from the EIPs and checked against
+// go-ethereum's CREATE and CREATE2 addresses before anything is written or
+// printed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func main() {
+	var (
+		sender       = flag.String("sender", "", "address of the creating account")
+		nonce        = flag.Uint64("nonce", 0, "nonce of the sender (CREATE)")
+		salt         = flag.String("salt", "", "salt, up to 32 bytes (CREATE2 or EOFCREATE)")
+		initcode     = flag.String("initcode", "", "hex encoded initcode (CREATE2)")
+		initcodeHash = flag.String("initcode-hash", "", "hash of the initcode, instead of --initcode (CREATE2)")
+		eofCreate    = flag.Bool("eof", false, "derive the address of the proposed EOFCREATE (EIP-7620) instead of CREATE2")
+		vectors      = flag.Bool("vectors", false, "generate the edge case vectors")
+		output       = flag.String("output", "address_vectors", "directory the vectors are written to")
+	)
+	flag.Parse()
+	if *vectors {
+		if err := writeVectors(*output, *eofCreate); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if !common.IsHexAddress(*sender) {
+		fatalf("--sender must be an address")
+	}
+	from := common.HexToAddress(*sender)
+	if *salt == "" {
+		if *initcode != "" || *initcodeHash != "" || *eofCreate {
+			fatalf("--initcode, --initcode-hash and --eof require --salt")
+		}
+		addr, err := checkedCreateAddress(from, *nonce)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		fmt.Printf("CREATE address: %s\n", addr.Hex())
+		return
+	}
+	saltBytes, err := hexutil.Decode(*salt)
+	if err != nil || len(saltBytes) > common.HashLength {
+		fatalf("invalid --salt %q, want up to 32 hex encoded bytes", *salt)
+	}
+	s := common.BytesToHash(saltBytes)
+	if *eofCreate {
+		if *initcode != "" || *initcodeHash != "" {
+			fatalf("the EOFCREATE address does not depend on the initcontainer")
+		}
+		addr, _ := eofCreateAddress(from, s)
+		fmt.Printf("EOFCREATE address: %s\n", addr.Hex())
+		return
+	}
+	var hash common.Hash
+	switch {
+	case *initcode != "" && *initcodeHash != "":
+		fatalf("--initcode and --initcode-hash are mutually exclusive")
+	case *initcodeHash != "":
+		h, err := hexutil.Decode(*initcodeHash)
+		if err != nil || len(h) != common.HashLength {
+			fatalf("invalid --initcode-hash %q", *initcodeHash)
+		}
+		hash = common.BytesToHash(h)
+	default:
+		code, err := hexutil.Decode(*initcode)
+		if *initcode != "" && err != nil {
+			fatalf("invalid --initcode: %v", err)
+		}
+		hash = crypto.Keccak256Hash(code)
+	}
+	addr, err := checkedCreate2Address(from, s, hash)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Initcode hash:   %s\n", hash.Hex())
+	fmt.Printf("CREATE2 address: %s\n", addr.Hex())
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
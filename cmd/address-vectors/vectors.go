@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	zeroAddress = common.Address{}
+	maxAddress  = common.HexToAddress("0xffffffffffffffffffffffffffffffffffffffff")
+	sender      = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	precompile  = common.BytesToAddress([]byte{0x01})
+
+	maxSalt = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+)
+
+// createAddress derives the CREATE address, the last 20 bytes of the hash of
+// the RLP list of the sender and its nonce. It returns the encoding hashed.
+func createAddress(sender common.Address, nonce uint64) (common.Address, []byte) {
+	// The nonce is encoded as a single byte below 0x80 and as a string of its
+	// minimal big endian bytes otherwise, with 0x80 for zero.
+	var enc []byte
+	switch {
+	case nonce == 0:
+		enc = []byte{0x80}
+	case nonce < 0x80:
+		enc = []byte{byte(nonce)}
+	default:
+		var b []byte
+		for n := nonce; n > 0; n >>= 8 {
+			b = append([]byte{byte(n)}, b...)
+		}
+		enc = append([]byte{0x80 + byte(len(b))}, b...)
+	}
+	payload := append(append([]byte{0x80 + common.AddressLength}, sender[:]...), enc...)
+	preimage := append([]byte{0xc0 + byte(len(payload))}, payload...)
+	return common.BytesToAddress(crypto.Keccak256(preimage)[12:]), preimage
+}
+
+// create2Address derives the EIP-1014 CREATE2 address. It returns the
+// encoding hashed.
+func create2Address(sender common.Address, salt, initcodeHash common.Hash) (common.Address, []byte) {
+	preimage := append(append(append([]byte{0xff}, sender[:]...), salt[:]...), initcodeHash[:]...)
+	return common.BytesToAddress(crypto.Keccak256(preimage)[12:]), preimage
+}
+
+// eofCreateAddress derives the address of EOFCREATE as proposed by EIP-7620,
+// with the sender padded to 32 bytes and without the initcontainer. It
+// returns the encoding hashed.
+func eofCreateAddress(sender common.Address, salt common.Hash) (common.Address, []byte) {
+	preimage := append(append([]byte{0xff}, common.BytesToHash(sender[:]).Bytes()...), salt[:]...)
+	return common.BytesToAddress(crypto.Keccak256(preimage)[12:]), preimage
+}
+
+// checkedCreateAddress derives the CREATE address and checks it against
+// go-ethereum's.
+func checkedCreateAddress(sender common.Address, nonce uint64) (common.Address, error) {
+	addr, _ := createAddress(sender, nonce)
+	if want := crypto.CreateAddress(sender, nonce); addr != want {
+		return addr, fmt.Errorf("CREATE address %s of %s at nonce %d, go-ethereum %s", addr.Hex(), sender.Hex(), nonce, want.Hex())
+	}
+	return addr, nil
+}
+
+// checkedCreate2Address derives the CREATE2 address and checks it against
+// go-ethereum's.
+func checkedCreate2Address(sender common.Address, salt, initcodeHash common.Hash) (common.Address, error) {
+	addr, _ := create2Address(sender, salt, initcodeHash)
+	if want := crypto.CreateAddress2(sender, salt, initcodeHash[:]); addr != want {
+		return addr, fmt.Errorf("CREATE2 address %s of %s with salt %s, go-ethereum %s", addr.Hex(), sender.Hex(), salt.Hex(), want.Hex())
+	}
+	return addr, nil
+}
+
+// createCase is a CREATE derivation edge case.
+type createCase struct {
+	name        string
+	description string
+	sender      common.Address
+	nonce       uint64
+}
+
+func createCases() []createCase {
+	cases := []createCase{
+		{"zero_sender", "the zero address as sender", zeroAddress, 0},
+		{"max_sender", "an all 0xff sender", maxAddress, 0},
+		{"precompile_sender", "the ecrecover precompile address as sender", precompile, 1},
+	}
+	// The nonces around the boundaries of the RLP encoding: zero is the empty
+	// string, below 0x80 a single byte, above it a string of 1 to 8 bytes.
+	nonces := []struct {
+		nonce       uint64
+		description string
+	}{
+		{0, "nonce zero, encoded as the empty string 0x80"},
+		{1, "nonce one, the first nonce of a contract since EIP-161"},
+		{0x7f, "the largest nonce encoded as a single byte"},
+		{0x80, "the smallest nonce encoded as a one byte string"},
+		{0xff, "the largest nonce encoded as a one byte string"},
+		{0x100, "the smallest nonce encoded as a two byte string"},
+		{0xffff, "the largest nonce encoded as a two byte string"},
+		{0x10000, "the smallest nonce encoded as a three byte string"},
+		{math.MaxUint32, "the largest 32 bit nonce"},
+		{math.MaxUint32 + 1, "the smallest nonce encoded as a five byte string"},
+		{1 << 56, "the smallest nonce encoded as an eight byte string"},
+		{math.MaxUint64 - 1, "the largest nonce an account can create at, the EIP-2681 limit is 2^64-1"},
+		{math.MaxUint64, "the nonce 2^64-1, at which EIP-2681 forbids creating contracts, for completeness"},
+	}
+	for _, n := range nonces {
+		cases = append(cases, createCase{fmt.Sprintf("nonce_%#x", n.nonce), n.description, sender, n.nonce})
+	}
+	return cases
+}
+
+// create2Case is a CREATE2 derivation edge case.
+type create2Case struct {
+	name        string
+	description string
+	sender      common.Address
+	salt        common.Hash
+	initcode    []byte
+	published   common.Address // address published with the case, if any
+}
+
+func create2Cases() []create2Case {
+	return []create2Case{
+		{"empty_initcode", "empty initcode, the hash of the empty string", sender, common.Hash{}, nil, common.Address{}},
+		{"single_byte_initcode", "a single STOP as initcode", sender, common.Hash{}, []byte{0x00}, common.Address{}},
+		{"eip1014_example_0", "example 0 of EIP-1014", zeroAddress, common.Hash{}, []byte{0x00}, common.HexToAddress("0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38")},
+		{"eip1014_example_5", "example 5 of EIP-1014", common.HexToAddress("0x00000000000000000000000000000000deadbeef"), common.HexToHash("0x00000000000000000000000000000000000000000000000000000000cafebabe"), common.FromHex("0xdeadbeef"), common.HexToAddress("0x60f3f640a8508fC6a86d45DF051962668E1e8AC7")},
+		{"max_salt", "the salt 2^256-1", sender, maxSalt, []byte{0x00}, common.Address{}},
+		{"zero_sender", "the zero address as sender", zeroAddress, common.Hash{}, nil, common.Address{}},
+		{"max_sender_max_salt", "an all 0xff sender and salt", maxAddress, maxSalt, nil, common.Address{}},
+		{"max_initcode_zero", "zero initcode of the maximum size of EIP-3860", sender, common.Hash{}, make([]byte, params.MaxInitCodeSize), common.Address{}},
+		{"max_initcode_nonzero", "0xff initcode of the maximum size of EIP-3860", sender, common.Hash{}, bytes.Repeat([]byte{0xff}, params.MaxInitCodeSize), common.Address{}},
+		{"initcode_too_large", "initcode one byte above the maximum size, the address is defined although the creation fails", sender, common.Hash{}, make([]byte, params.MaxInitCodeSize+1), common.Address{}},
+	}
+}
+
+// eofCreateCase is an EOFCREATE derivation edge case.
+type eofCreateCase struct {
+	name        string
+	description string
+	sender      common.Address
+	salt        common.Hash
+}
+
+func eofCreateCases() []eofCreateCase {
+	return []eofCreateCase{
+		{"zero_salt", "the zero salt", sender, common.Hash{}},
+		{"max_salt", "the salt 2^256-1", sender, maxSalt},
+		{"zero_sender", "the zero address as sender", zeroAddress, common.Hash{}},
+		{"max_sender_max_salt", "an all 0xff sender and salt", maxAddress, maxSalt},
+	}
+}
+
+// createVector is a CREATE derivation.
+type createVector struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Sender      common.Address `json:"sender"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Preimage    hexutil.Bytes  `json:"preimage"`
+	Address     common.Address `json:"address"`
+}
+
+// create2Vector is a CREATE2 derivation.
+type create2Vector struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description"`
+	Sender       common.Address `json:"sender"`
+	Salt         common.Hash    `json:"salt"`
+	Initcode     hexutil.Bytes  `json:"initcode"`
+	InitcodeHash common.Hash    `json:"initcodeHash"`
+	Preimage     hexutil.Bytes  `json:"preimage"`
+	Address      common.Address `json:"address"`
+}
+
+// eofCreateVector is an EOFCREATE derivation.
+type eofCreateVector struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Sender      common.Address `json:"sender"`
+	Salt        common.Hash    `json:"salt"`
+	Preimage    hexutil.Bytes  `json:"preimage"`
+	Address     common.Address `json:"address"`
+}
+
+// vectorFile is a vector file with the info of the tool filling it.
+type vectorFile struct {
+	Info    map[string]string `json:"_info"`
+	Vectors interface{}       `json:"vectors"`
+}
+
+// writeVectors derives the addresses of the cases, checks them against
+// go-ethereum and writes the vectors.
+func writeVectors(output string, withEOF bool) error {
+	var creates []createVector
+	for _, c := range createCases() {
+		addr, preimage := createAddress(c.sender, c.nonce)
+		if _, err := checkedCreateAddress(c.sender, c.nonce); err != nil {
+			return fmt.Errorf("case %s: %v", c.name, err)
+		}
+		creates = append(creates, createVector{c.name, c.description, c.sender, hexutil.Uint64(c.nonce), preimage, addr})
+	}
+	var creates2 []create2Vector
+	for _, c := range create2Cases() {
+		hash := crypto.Keccak256Hash(c.initcode)
+		addr, preimage := create2Address(c.sender, c.salt, hash)
+		if _, err := checkedCreate2Address(c.sender, c.salt, hash); err != nil {
+			return fmt.Errorf("case %s: %v", c.name, err)
+		}
+		if c.published != (common.Address{}) && addr != c.published {
+			return fmt.Errorf("case %s: address %s, published %s", c.name, addr.Hex(), c.published.Hex())
+		}
+		creates2 = append(creates2, create2Vector{c.name, c.description, c.sender, c.salt, c.initcode, hash, preimage, addr})
+	}
+	files := map[string]interface{}{
+		"create.json":  creates,
+		"create2.json": creates2,
+	}
+	var eofCreates []eofCreateVector
+	if withEOF {
+		for _, c := range eofCreateCases() {
+			addr, preimage := eofCreateAddress(c.sender, c.salt)
+			eofCreates = append(eofCreates, eofCreateVector{c.name, c.description, c.sender, c.salt, preimage, addr})
+		}
+		files["eofcreate.json"] = eofCreates
+	}
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return err
+	}
+	for name, vectors := range files {
+		data, err := json.MarshalIndent(vectorFile{Info: info(name), Vectors: vectors}, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(output, name), append(data, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Wrote %d CREATE, %d CREATE2 and %d EOFCREATE vectors to %s\n", len(creates), len(creates2), len(eofCreates), output)
+	return nil
+}
+
+func info(file string) map[string]string {
+	descriptions := map[string]string{
+		"create.json":    "CREATE addresses, keccak256(rlp([sender, nonce]))[12:]",
+		"create2.json":   "CREATE2 addresses of EIP-1014, keccak256(0xff || sender || salt || keccak256(initcode))[12:]",
+		"eofcreate.json": "EOFCREATE addresses as proposed by EIP-7620, keccak256(0xff || sender32 || salt)[12:]",
+	}
+	return statetest.Info("address-vectors", "handcrafted", descriptions[file])
+}
@@ -0,0 +1,234 @@
+// backend-diff compares backends, implementations of the state trie and the
+// EVM selected at runtime, on genesis states and state tests, for regression
+// hunting across go-ethereum versions and the execution specs.
+//
+// Usage:
+//
+//	go run ./cmd/backend-diff --backend go --backend old=/opt/geth-1.14/evm --backend eels --network mainnet
+//	go run ./cmd/backend-diff [--backend ...] (--genesis genesis.json | --alloc alloc.json)
+//	go run ./cmd/backend-diff [--backend ...] --fork Cancun tests.json
+//
+// A backend is "go" for the go-ethereum version of go.mod, "ref" for the
+// reference implementations of this repository, geth, eels or besu for the
+// evm tool of the client on the PATH, or NAME=COMMAND for an evm tool
+// elsewhere, such as the evm binary of another go-ethereum release. Without
+// --backend go and ref are compared.
+//
+// With --network or --genesis every backend computes the state root of the
+// genesis allocation, which the genesis block is assembled around, and the
+// block hashes are compared; with --alloc only the state roots are. State
+// tests given as arguments are executed on the fork, their first post state,
+// by every backend that runs state tests, and the post state roots and
+// EIP-3155 traces are compared as by evm-fuzz.
+//
+// Every result is compared with the one of the first backend. The tool exits
+// with a nonzero code if any backend diverges or fails.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/backend"
+	"github.com/ethereum/execution-specs/pkg/evmfuzz"
+	"github.com/ethereum/execution-specs/pkg/flags"
+	"github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/execution-specs/pkg/presets"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+func main() {
+	var (
+		defs        flags.Strings
+		network     = flag.String("network", "", "network whose genesis is compared ("+strings.Join(presets.Names(), ", ")+")")
+		genesisFile = flag.String("genesis", "", "genesis file in the geth format whose genesis is compared")
+		allocFile   = flag.String("alloc", "", "allocation whose state root is compared")
+		fork        = flag.String("fork", "Prague", "fork the state tests are executed on")
+		jobs        = flag.Int("jobs", runtime.NumCPU(), "number of jobs of the reference state root")
+		timeout     = flag.Duration("timeout", 10*time.Minute, "timeout of a single subprocess invocation")
+	)
+	flag.Var(&defs, "backend", "backend to compare: go, ref, geth, eels, besu or NAME=COMMAND (repeatable)")
+	flag.Parse()
+	if len(defs) == 0 {
+		defs = flags.Strings{"go", "ref"}
+	}
+	if len(defs) < 2 {
+		fatalf("at least two backends are needed")
+	}
+	var backends []backend.Backend
+	for _, def := range defs {
+		b, err := backend.Parse(def, *jobs, *timeout)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		backends = append(backends, b)
+	}
+	inputs := 0
+	for _, set := range []bool{*network != "", *genesisFile != "", *allocFile != ""} {
+		if set {
+			inputs++
+		}
+	}
+	if inputs > 1 {
+		fatalf("--network, --genesis and --alloc are mutually exclusive")
+	}
+	if inputs == 0 && flag.NArg() == 0 {
+		fatalf("nothing to compare, give --network, --genesis, --alloc or state test files")
+	}
+
+	var diverged bool
+	switch {
+	case *network != "":
+		g, err := presets.Get(*network)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		diverged = compareGenesis(*network, g, backends)
+	case *genesisFile != "":
+		g, err := genesis.Load(*genesisFile)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		diverged = compareGenesis(*genesisFile, g, backends)
+	case *allocFile != "":
+		a, warnings, err := alloc.Load(*allocFile)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", *allocFile, w)
+		}
+		diverged = compareRoots(*allocFile, backends, func(b backend.Backend) (common.Hash, error) {
+			return b.StateRoot(a)
+		})
+	}
+	var runners []evmfuzz.Backend
+	for _, b := range backends {
+		if flag.NArg() > 0 && !backend.RunsStateTests(b) {
+			fmt.Printf("Skipping %s for the state tests, it runs none\n", b.Name())
+			continue
+		}
+		runners = append(runners, b)
+	}
+	if flag.NArg() > 0 && len(runners) < 2 {
+		fatalf("fewer than two backends run state tests")
+	}
+	for _, file := range flag.Args() {
+		d, err := compareStateTests(file, *fork, runners)
+		if err != nil {
+			fatalf("%s: %v", file, err)
+		}
+		diverged = diverged || d
+	}
+	if diverged {
+		os.Exit(1)
+	}
+}
+
+// compareGenesis compares the genesis block hashes of the backends.
+func compareGenesis(name string, g *core.Genesis, backends []backend.Backend) bool {
+	return compareRoots(name, backends, func(b backend.Backend) (common.Hash, error) {
+		block, err := backend.GenesisBlock(b, g)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return block.Hash(), nil
+	})
+}
+
+// compareRoots computes a hash with every backend, prints them and reports
+// whether any differs from the first one or failed.
+func compareRoots(name string, backends []backend.Backend, hash func(backend.Backend) (common.Hash, error)) bool {
+	fmt.Println(name)
+	var (
+		first    common.Hash
+		diverged bool
+	)
+	for i, b := range backends {
+		h, err := hash(b)
+		switch {
+		case err != nil:
+			fmt.Printf("    %-10s FAIL %v\n", b.Name(), err)
+			diverged = true
+			continue
+		case i == 0:
+			first = h
+			fmt.Printf("    %-10s      %s\n", b.Name(), h.Hex())
+		case h != first:
+			fmt.Printf("    %-10s DIFF %s\n", b.Name(), h.Hex())
+			diverged = true
+		default:
+			fmt.Printf("    %-10s ok   %s\n", b.Name(), h.Hex())
+		}
+	}
+	return diverged
+}
+
+// compareStateTests compares the execution of every state test of the file by
+// the backends.
+func compareStateTests(file, fork string, runners []evmfuzz.Backend) (bool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	var tests map[string]json.RawMessage
+	if err := json.Unmarshal(data, &tests); err != nil {
+		return false, err
+	}
+	names := make([]string, 0, len(tests))
+	for name := range tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var diverged bool
+	for _, name := range names {
+		test, err := firstPost(tests[name], fork)
+		if err != nil {
+			return false, fmt.Errorf("%s: %v", name, err)
+		}
+		if test == nil {
+			fmt.Printf("%s skip no %s post state\n", name, fork)
+			continue
+		}
+		outcome := evmfuzz.Compare(test, fork, runners)
+		if f := outcome.Finding; f != nil {
+			fmt.Printf("%s FAIL %s\n    %s\n", name, f.Signature, f.Detail)
+			diverged = true
+			continue
+		}
+		fmt.Printf("%s ok   %s\n", name, outcome.Results[0].StateRoot.Hex())
+	}
+	return diverged, nil
+}
+
+// firstPost returns the state test with only the first post state of the
+// fork, the one compared, as the command backends run every post state of a
+// test. It returns nil if the test has no post state of the fork.
+func firstPost(test json.RawMessage, fork string) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(test, &fields); err != nil {
+		return nil, err
+	}
+	var post map[string][]json.RawMessage
+	if err := json.Unmarshal(fields["post"], &post); err != nil {
+		return nil, fmt.Errorf("not a state test: %v", err)
+	}
+	if len(post[fork]) == 0 {
+		return nil, nil
+	}
+	fields["post"], _ = json.Marshal(map[string][]json.RawMessage{fork: post[fork][:1]})
+	return json.Marshal(fields)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,150 @@
+// blobs generates EIP-4844 blob sidecars: the blobs, their KZG commitments and
+// proofs and the versioned hashes referencing them.
+//
+// Usage:
+//
+//	go run ./cmd/blobs [--raw] [--seed 1 --count 2] [--cell-proofs] [--output sidecar.json] [payload...]
+//
+// The blobs are made from payload files and from deterministic seeds. A
+// payload is packed 31 bytes per field element, each element starting with a
+// zero byte so that any content is a valid blob, and spread over as many
+// blobs as it needs. With --raw the payload is copied into the blobs as is and
+// has to consist of canonical field elements. A seed gives --count blobs of
+// pseudo-random field elements, which are the same for every run.
+//
+// The proofs are a proof per blob, or with --cell-proofs the cell proofs of
+// the sidecars since Osaka. The KZG trusted setup of the mainnet ceremony is
+// embedded in go-ethereum's kzg4844 package, no setup file is needed; it is
+// checked against the ceremony setup of pkg/kzg before any blob is committed
+// to, see go run ./cmd/kzg verify-setup. The
+// output is the blobs bundle of engine_getPayload together with the versioned
+// hashes:
+//
+//	{"commitments": [...], "proofs": [...], "blobs": [...], "versionedHashes": [...]}
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/txbuilder"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	fieldElementSize = 32
+	blobSize         = params.BlobTxFieldElementsPerBlob * fieldElementSize
+	// packedBlobSize is the payload a blob holds with 31 bytes per element.
+	packedBlobSize = params.BlobTxFieldElementsPerBlob * (fieldElementSize - 1)
+)
+
+// bundle is a blob sidecar in the engine API encoding.
+type bundle struct {
+	Commitments     []kzg4844.Commitment `json:"commitments"`
+	Proofs          []kzg4844.Proof      `json:"proofs"`
+	Blobs           []kzg4844.Blob       `json:"blobs"`
+	VersionedHashes []common.Hash        `json:"versionedHashes"`
+}
+
+func main() {
+	var (
+		raw        = flag.Bool("raw", false, "copy the payloads into the blobs without packing them")
+		seed       = flag.Uint64("seed", 0, "seed of the pseudo-random blobs")
+		count      = flag.Int("count", 0, "number of pseudo-random blobs")
+		cellProofs = flag.Bool("cell-proofs", false, "compute cell proofs (Osaka) instead of blob proofs")
+		output     = flag.String("output", "", "output file (default: stdout)")
+	)
+	flag.Parse()
+	if flag.NArg() == 0 && *count == 0 {
+		fatalf("usage: blobs [flags] [payload...], with payload files or --count")
+	}
+	var blobs []kzg4844.Blob
+	for _, path := range flag.Args() {
+		payload, err := os.ReadFile(path)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if *raw {
+			blobs = append(blobs, rawBlobs(payload)...)
+		} else {
+			blobs = append(blobs, packBlobs(payload)...)
+		}
+	}
+	for i := 0; i < *count; i++ {
+		blobs = append(blobs, seededBlob(*seed, uint64(i)))
+	}
+	sidecar, err := txbuilder.NewSidecar(blobs, *cellProofs)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	data, err := json.MarshalIndent(&bundle{
+		Commitments:     sidecar.Commitments,
+		Proofs:          sidecar.Proofs,
+		Blobs:           sidecar.Blobs,
+		VersionedHashes: sidecar.BlobHashes(),
+	}, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d blobs to %s\n", len(blobs), *output)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// packBlobs spreads the payload over blobs, 31 bytes per field element. The
+// last blob is zero padded. An empty payload gives a single zero blob.
+func packBlobs(payload []byte) []kzg4844.Blob {
+	blobs := make([]kzg4844.Blob, max(1, (len(payload)+packedBlobSize-1)/packedBlobSize))
+	for i := 0; len(payload) > 0; i++ {
+		blob := &blobs[i/params.BlobTxFieldElementsPerBlob]
+		offset := (i%params.BlobTxFieldElementsPerBlob)*fieldElementSize + 1
+		n := copy(blob[offset:offset+fieldElementSize-1], payload)
+		payload = payload[n:]
+	}
+	return blobs
+}
+
+// rawBlobs copies the payload into blobs as is, zero padding the last one.
+func rawBlobs(payload []byte) []kzg4844.Blob {
+	blobs := make([]kzg4844.Blob, max(1, (len(payload)+blobSize-1)/blobSize))
+	for i := range blobs {
+		copy(blobs[i][:], payload[min(i*blobSize, len(payload)):])
+	}
+	return blobs
+}
+
+// seededBlob derives the field elements of a blob from the seed and the index
+// of the blob. Every element is a hash with the first byte cleared, which
+// keeps it below the field modulus.
+func seededBlob(seed, index uint64) kzg4844.Blob {
+	var (
+		blob  kzg4844.Blob
+		input = make([]byte, 24)
+	)
+	binary.BigEndian.PutUint64(input[0:], seed)
+	binary.BigEndian.PutUint64(input[8:], index)
+	for i := 0; i < params.BlobTxFieldElementsPerBlob; i++ {
+		binary.BigEndian.PutUint64(input[16:], uint64(i))
+		element := blob[i*fieldElementSize : (i+1)*fieldElementSize]
+		copy(element, crypto.Keccak256(input))
+		element[0] = 0
+	}
+	return blob
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// blockhashWindow is the number of previous blocks BLOCKHASH returns the
+// hash of.
+const blockhashWindow = 256
+
+var (
+	// blockhashProbe stores the BLOCKHASH of the numbers it is called with.
+	blockhashProbe = common.HexToAddress("0x000000000000000000000000000000000000b10c")
+
+	// historyProbe stores what the EIP-2935 history contract returns for the
+	// numbers it is called with.
+	historyProbe = common.HexToAddress("0x0000000000000000000000000000000000002935")
+
+	// marker prefills the result slots of the probes.
+	marker = common.BigToHash(big.NewInt(0xdead))
+
+	// reverted is stored by the history probe when the call reverts.
+	reverted = common.MaxHash
+)
+
+// probe is a transaction querying the hashes of block numbers.
+type probe struct {
+	at      uint64     // block the transaction is included in
+	history bool       // query the history contract instead of BLOCKHASH
+	numbers []*big.Int // queried, 256 bit words
+}
+
+// testCase is a chain of empty blocks carrying the transactions of the
+// probes, ordered by their blocks.
+type testCase struct {
+	name        string
+	description string
+	since       string // first fork of the case, empty for every fork
+	transition  bool   // filled on the transition network instead of the forks
+	genesisTime uint64
+	probes      []probe
+}
+
+// address returns the probe contract called.
+func (p *probe) address() common.Address {
+	if p.history {
+		return historyProbe
+	}
+	return blockhashProbe
+}
+
+// resultSlot returns the slot the result of the j-th query of the i-th probe
+// of a case is stored at.
+func resultSlot(i, j int) common.Hash {
+	return common.BigToHash(big.NewInt(int64(i+1)<<8 | int64(j)))
+}
+
+// input returns the calldata of the i-th probe of a case, the pairs of the
+// result slot and the number of every query.
+func (p *probe) input(i int) []byte {
+	var data []byte
+	for j, number := range p.numbers {
+		word := common.BigToHash(number)
+		data = append(append(data, resultSlot(i, j).Bytes()...), word[:]...)
+	}
+	return data
+}
+
+// probeAccounts returns the probe contracts the case calls, with their result
+// slots prefilled with the marker.
+func (c *testCase) probeAccounts() types.GenesisAlloc {
+	accounts := make(types.GenesisAlloc)
+	for i, p := range c.probes {
+		account, ok := accounts[p.address()]
+		if !ok {
+			code := blockhashCode()
+			if p.history {
+				code = historyCode()
+			}
+			account = types.Account{Nonce: 1, Code: code, Balance: new(big.Int), Storage: make(map[common.Hash]common.Hash)}
+		}
+		for j := range p.numbers {
+			account.Storage[resultSlot(i, j)] = marker
+		}
+		accounts[p.address()] = account
+	}
+	return accounts
+}
+
+// probeLoop returns code storing a result for every pair of the calldata at
+// its slot. The body computes the result from the offset of the pair on the
+// stack, keeping it below the result. Its jump destinations are relative to
+// probeLoopStart.
+func probeLoop(body []byte) []byte {
+	code := []byte{
+		byte(vm.PUSH1), 0,
+		byte(vm.JUMPDEST),
+		byte(vm.DUP1), byte(vm.CALLDATASIZE), byte(vm.GT), byte(vm.ISZERO),
+		byte(vm.PUSH1), 0, // patched with the end of the loop
+		byte(vm.JUMPI),
+	}
+	code = append(code, body...)
+	code = append(code,
+		byte(vm.DUP2), byte(vm.CALLDATALOAD), byte(vm.SSTORE),
+		byte(vm.PUSH1), 64, byte(vm.ADD),
+		byte(vm.PUSH1), 2, byte(vm.JUMP),
+		byte(vm.JUMPDEST), byte(vm.STOP),
+	)
+	code[8] = byte(len(code) - 2)
+	return code
+}
+
+// probeLoopStart is the offset of the body in the code of probeLoop.
+const probeLoopStart = 10
+
+// loadNumber is the body part loading the number of the pair.
+var loadNumber = []byte{byte(vm.DUP1), byte(vm.PUSH1), 32, byte(vm.ADD), byte(vm.CALLDATALOAD)}
+
+// blockhashCode returns the code of the BLOCKHASH probe.
+func blockhashCode() []byte {
+	return probeLoop(append(loadNumber, byte(vm.BLOCKHASH)))
+}
+
+// historyCode returns the code of the history contract probe, which calls
+// the contract with the number as its 32 byte input and stores the returned
+// word, or reverted if the call fails.
+func historyCode() []byte {
+	body := append(loadNumber, byte(vm.PUSH1), 0, byte(vm.MSTORE),
+		byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.PUSH1), 32, byte(vm.PUSH1), 0,
+		byte(vm.PUSH20))
+	body = append(body, params.HistoryStorageAddress.Bytes()...)
+	body = append(body,
+		byte(vm.GAS), byte(vm.STATICCALL),
+		byte(vm.PUSH1), 0, byte(vm.MLOAD), byte(vm.SWAP1),
+		byte(vm.PUSH1), 0, // patched with the destination of a successful call
+		byte(vm.JUMPI),
+		byte(vm.POP), byte(vm.PUSH1), 0, byte(vm.NOT),
+		byte(vm.JUMPDEST),
+	)
+	body[len(body)-7] = byte(probeLoopStart + len(body) - 1)
+	return probeLoop(body)
+}
+
+// hashOf returns the hash of a block of the fixture chain.
+func hashOf(f *blocktest.Fixture, number uint64) common.Hash {
+	if number == 0 {
+		return f.Genesis.Hash
+	}
+	return f.Blocks[number-1].BlockHeader.Hash
+}
+
+// result returns what a query of the number at the block returns by the
+// rules of the fork the block is in. BLOCKHASH returns the hash of the 256
+// previous blocks and zero otherwise. The history contract reverts unless the
+// number is of one of the HistoryServeWindow previous blocks, whose hash it
+// holds if the block after it, whose system call stores it, is in Prague.
+func result(f *blocktest.Fixture, config *params.ChainConfig, at uint64, number *big.Int, history bool) common.Hash {
+	if !number.IsUint64() || number.Uint64() >= at {
+		if history {
+			return reverted
+		}
+		return common.Hash{}
+	}
+	n := number.Uint64()
+	if !history {
+		if at-n > blockhashWindow {
+			return common.Hash{}
+		}
+		return hashOf(f, n)
+	}
+	if at-n > params.HistoryServeWindow {
+		return reverted
+	}
+	next := f.Blocks[n].BlockHeader
+	if !config.IsPrague(new(big.Int).SetUint64(n+1), uint64(next.Timestamp)) {
+		return common.Hash{}
+	}
+	return hashOf(f, n)
+}
+
+// check verifies the results stored by the probes against those of result,
+// a zero result leaving its slot cleared.
+func (c *testCase) check(f *blocktest.Fixture, config *params.ChainConfig) error {
+	want := make(map[common.Address]map[common.Hash]common.Hash)
+	for i, p := range c.probes {
+		if want[p.address()] == nil {
+			want[p.address()] = make(map[common.Hash]common.Hash)
+		}
+		for j, number := range p.numbers {
+			if value := result(f, config, p.at, number, p.history); value != (common.Hash{}) {
+				want[p.address()][resultSlot(i, j)] = value
+			}
+		}
+	}
+	for addr, slots := range want {
+		account, ok := f.Post[addr]
+		if !ok {
+			return fmt.Errorf("account %s missing from the post-state", addr.Hex())
+		}
+		for slot, value := range slots {
+			if have := account.Storage[slot]; have != value {
+				return fmt.Errorf("account %s: storage slot %s is %s, expected %s", addr.Hex(), slot.Hex(), have.Hex(), value.Hex())
+			}
+		}
+		for slot, value := range account.Storage {
+			if _, ok := slots[slot]; !ok {
+				return fmt.Errorf("account %s: unexpected storage slot %s: %s", addr.Hex(), slot.Hex(), value.Hex())
+			}
+		}
+	}
+	return nil
+}
+
+// numbers returns block numbers as words.
+func numbers(ns ...uint64) []*big.Int {
+	words := make([]*big.Int, len(ns))
+	for i, n := range ns {
+		words[i] = new(big.Int).SetUint64(n)
+	}
+	return words
+}
+
+// cases returns the handcrafted test cases.
+func cases() []testCase {
+	var (
+		two64     = new(big.Int).Lsh(common.Big1, 64)
+		maxUint64 = new(big.Int).Sub(two64, common.Big1)
+		maxWord   = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+		beyond    = []*big.Int{maxUint64, two64, new(big.Int).Add(two64, big.NewInt(3)), maxWord}
+	)
+	// The chain of the transition cases reaches the Prague timestamp of the
+	// transition network at block 5.
+	const forkBlock = 5
+	transitionGenesis := uint64(15000 - 10*forkBlock)
+
+	return []testCase{
+		{
+			name:        "previous_blocks",
+			description: "BLOCKHASH of every previous block down to the genesis, all within the window",
+			probes:      []probe{{at: 8, numbers: numbers(7, 6, 5, 4, 3, 2, 1, 0)}},
+		},
+		{
+			name:        "current_and_future_blocks",
+			description: "BLOCKHASH of the current block, future blocks, the largest 64 bit number, numbers beyond 64 bits, one of which truncates to an existing block, and the largest word, all zero",
+			probes:      []probe{{at: 4, numbers: append(numbers(4, 5, 1000), beyond...)}},
+		},
+		{
+			name:        "window_edge",
+			description: "BLOCKHASH at the edge of the 256 block window: the genesis is the oldest block returned at block 256 and drops out at block 257, as block 1 does at block 258",
+			probes: []probe{
+				{at: 256, numbers: numbers(0, 1, 255)},
+				{at: 257, numbers: numbers(0, 1, 256)},
+				{at: 258, numbers: numbers(0, 1, 2, 257)},
+			},
+		},
+		{
+			name:        "same_block_repeated",
+			description: "BLOCKHASH of the same blocks queried by two transactions of one block and again in the next block",
+			probes: []probe{
+				{at: 3, numbers: numbers(2, 0)},
+				{at: 3, numbers: numbers(2, 0, 3)},
+				{at: 4, numbers: numbers(3, 2, 0, 4)},
+			},
+		},
+		{
+			name:        "history_contract",
+			description: "the EIP-2935 history contract serves the hashes of blocks beyond the BLOCKHASH window, queried side by side, and reverts for the current and future blocks",
+			since:       "Prague",
+			probes: []probe{
+				{at: 258, numbers: numbers(257, 2, 1, 0)},
+				{at: 258, history: true, numbers: append(numbers(257, 2, 1, 0, 258, 259, 1000), beyond...)},
+			},
+		},
+		{
+			name:        "history_transition",
+			description: fmt.Sprintf("the history contract deployed before the Prague transition at block %d is empty before it, and only holds the hashes from the parent of the fork block on, while BLOCKHASH keeps returning the older ones", forkBlock),
+			transition:  true,
+			genesisTime: transitionGenesis,
+			probes: []probe{
+				{at: forkBlock - 1, history: true, numbers: numbers(forkBlock-2, 0)},
+				{at: forkBlock - 1, numbers: numbers(forkBlock-2, 0)},
+				{at: forkBlock, history: true, numbers: numbers(forkBlock-1, forkBlock-2, 0, forkBlock)},
+				{at: forkBlock, numbers: numbers(forkBlock-1, forkBlock-2, 0)},
+				{at: forkBlock + 1, history: true, numbers: numbers(forkBlock, forkBlock-1, forkBlock-2, 0)},
+				{at: forkBlock + 1, numbers: numbers(forkBlock, forkBlock-1, forkBlock-2, 0)},
+			},
+		},
+	}
+}
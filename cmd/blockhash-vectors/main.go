@@ -0,0 +1,195 @@
+// blockhash-vectors generates vectors of the access to ancestor block hashes
+// across forks in the format of the blockchain_tests fixtures of the execution
+// spec tests, using go-ethereum to build and import the chains.
+//
+// Usage:
+//
+//	go run ./cmd/blockhash-vectors [--forks Frontier,...,Osaka] [--output blockhash_vectors.json]
+//
+// The vectors query BLOCKHASH for the previous blocks down to the genesis,
+// for the current block, future blocks and numbers beyond 64 bits, which all
+// return zero, and at the edge of the 256 block window, where the genesis
+// block drops out of it. From Prague they query the EIP-2935 history contract
+// as well, which serves the hashes BLOCKHASH no longer returns and reverts for
+// the current and future blocks. On the transition network
+// CancunToPragueAtTime15k, filled whenever Prague is among the forks, the
+// contract starts out empty: it only returns the hashes of the blocks from the
+// parent of the fork block on, while BLOCKHASH keeps returning the older ones.
+//
+// Every query runs in a transaction of the block it is made at. Each case only
+// declares the blocks and the numbers queried, the chain of empty blocks up to
+// the last query is produced by the blockchain test builder. The results are
+// stored by probe contracts at a slot per query, prefilled with a marker so
+// that a zero result clears its slot and a query which did not run is seen.
+// The expected results are computed from the hashes and timestamps of the
+// built chain and checked against the post-state go-ethereum imports the chain
+// into before anything is written.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// The parameters of the genesis and the transactions.
+const (
+	gasLimit   = 30000000
+	baseFee    = 7
+	difficulty = 0x20000 // of the genesis before the merge
+	gasPrice   = 10
+	txGas      = 1000000
+)
+
+// transitionNetwork is the network the fork transition cases are filled on.
+const transitionNetwork = "CancunToPragueAtTime15k"
+
+var (
+	senderKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	sender       = crypto.PubkeyToAddress(senderKey.PublicKey)
+)
+
+func main() {
+	var (
+		forkList = flag.String("forks", "Frontier,Homestead,Byzantium,Istanbul,London,Paris,Shanghai,Cancun,Prague,Osaka", "comma separated forks the vectors are built for")
+		output   = flag.String("output", "blockhash_vectors.json", "file the fixtures are written to")
+	)
+	flag.Parse()
+	forkNames := strings.Split(*forkList, ",")
+	for _, fork := range forkNames {
+		if _, ok := tests.Forks[fork]; !ok {
+			fatalf("unknown fork %q", fork)
+		}
+		if _, err := forks.Index(fork); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	fixtures := make(map[string]*blocktest.Fixture)
+	for _, c := range cases() {
+		networks := forkNames
+		if c.transition {
+			networks = nil
+			if slices.Contains(forkNames, "Prague") {
+				networks = []string{transitionNetwork}
+			}
+		}
+		for _, network := range networks {
+			if c.since != "" && !forks.Since(network, c.since) {
+				continue
+			}
+			f, err := fill(&c, network)
+			if err != nil {
+				fatalf("case %s: %s: %v", c.name, network, err)
+			}
+			fixtures["blockhash_vectors/"+c.name+"/"+network] = f
+		}
+	}
+	if len(fixtures) == 0 {
+		fatalf("no vectors for the forks %s", *forkList)
+	}
+	data, err := json.MarshalIndent(fixtures, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d fixtures to %s\n", len(fixtures), *output)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// fill builds the fixture of a test case on a network and verifies it.
+func fill(c *testCase, network string) (*blocktest.Fixture, error) {
+	config := tests.Forks[network]
+	genesis := &core.Genesis{
+		Config:    config,
+		Timestamp: c.genesisTime,
+		GasLimit:  gasLimit,
+		Alloc:     gen.SystemContractAlloc(config),
+	}
+	if config.IsLondon(new(big.Int)) {
+		genesis.BaseFee = big.NewInt(baseFee)
+	}
+	if config.TerminalTotalDifficulty == nil || config.TerminalTotalDifficulty.Sign() != 0 {
+		genesis.Difficulty = big.NewInt(difficulty)
+	} else {
+		genesis.Difficulty = new(big.Int)
+	}
+	genesis.Alloc[sender] = types.Account{Balance: big.NewInt(params.Ether)}
+	for addr, account := range c.probeAccounts() {
+		genesis.Alloc[addr] = account
+	}
+
+	blocks, err := c.blocks()
+	if err != nil {
+		return nil, err
+	}
+	f, err := blocktest.Build(genesis, network, blocks)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.check(f, config); err != nil {
+		return nil, err
+	}
+	f.Info = statetest.Info("blockhash-vectors", "handcrafted", c.description)
+	return f, nil
+}
+
+// blocks returns the chain of the case: a block per number up to the last
+// query, carrying the transactions of the probes made at it.
+func (c *testCase) blocks() ([]*blocktest.Block, error) {
+	var last uint64
+	for _, p := range c.probes {
+		last = max(last, p.at)
+	}
+	blocks := make([]*blocktest.Block, last)
+	for i := range blocks {
+		blocks[i] = new(blocktest.Block)
+	}
+	for i, p := range c.probes {
+		if p.at == 0 {
+			return nil, fmt.Errorf("probe %d at the genesis block", i)
+		}
+		if i > 0 && p.at < c.probes[i-1].at {
+			return nil, fmt.Errorf("probe %d at block %d before the previous one", i, p.at)
+		}
+		// Unprotected transactions are valid before EIP-155 as well.
+		to := p.address()
+		tx, err := types.SignNewTx(senderKey, types.HomesteadSigner{}, &types.LegacyTx{
+			Nonce:    uint64(i),
+			GasPrice: big.NewInt(gasPrice),
+			Gas:      txGas,
+			To:       &to,
+			Data:     p.input(i),
+		})
+		if err != nil {
+			return nil, err
+		}
+		enc, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		block := blocks[p.at-1]
+		block.Transactions = append(block.Transactions, hexutil.Bytes(enc))
+	}
+	return blocks, nil
+}
@@ -0,0 +1,103 @@
+// blocktest builds a blockchain test fixture from a genesis and a list of block
+// definitions.
+//
+// Usage:
+//
+//	go run ./cmd/blocktest --genesis genesis.json [--fork Prague] [--name test] [--output test.json] blocks.json
+//
+// The blocks file is a JSON list of blocks, each giving its signed
+// transactions, withdrawals and header overrides:
+//
+//	[{
+//	    "transactions": ["0x02f8..."],
+//	    "withdrawals": [{"validatorIndex": "0x0", "address": "0x...", "amount": "0x1"}],
+//	    "header": {"coinbase": "0x...", "timestamp": "0x64", "extraData": "0x"}
+//	}, {
+//	    "corrupt": {"field": "stateRoot"},
+//	    "expectException": "BlockException.INVALID_STATE_ROOT"
+//	}]
+//
+// A block with a corrupt entry is built like any other, then the named header
+// field is replaced by the given value (or perturbed if none is given). Such a
+// block is invalid and the chain continues on top of its parent. The field
+// "withdrawals" instead builds the block without its withdrawals and attaches
+// them afterwards, with their root, for withdrawals before Shanghai or ones
+// missing from the state.
+//
+// Withdrawals are numbered consecutively along the chain unless the block sets
+// "withdrawalIndices": true, which keeps the index fields as given.
+//
+// The chain configuration is that of the fork; from the genesis file only the
+// alloc and the genesis header fields are used. The fixture is checked by
+// importing it with go-ethereum before it is written.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+func main() {
+	var (
+		genesisFile = flag.String("genesis", "", "genesis file the chain starts from")
+		fork        = flag.String("fork", "Prague", "fork the test is built for")
+		name        = flag.String("name", "", "test name (default: name of the blocks file)")
+		output      = flag.String("output", "", "output file (default: stdout)")
+	)
+	flag.Parse()
+	if *genesisFile == "" || flag.NArg() != 1 {
+		fatalf("usage: blocktest --genesis genesis.json [flags] blocks.json")
+	}
+	genesis := new(core.Genesis)
+	if err := readJSON(*genesisFile, genesis); err != nil {
+		fatalf("%v", err)
+	}
+	var blocks []*blocktest.Block
+	if err := readJSON(flag.Arg(0), &blocks); err != nil {
+		fatalf("%v", err)
+	}
+	fixture, err := blocktest.Build(genesis, *fork, blocks)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	fixture.Info = statetest.Info("blocktest", flag.Arg(0), "")
+	if *name == "" {
+		*name = strings.TrimSuffix(filepath.Base(flag.Arg(0)), filepath.Ext(flag.Arg(0)))
+	}
+	data, err := json.MarshalIndent(map[string]*blocktest.Fixture{*name: fixture}, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
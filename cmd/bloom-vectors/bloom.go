@@ -0,0 +1,117 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// bloomBits returns the bits of the bloom set by an item, the low 11 bits of
+// each of the first three pairs of bytes of its hash, as by the M3:2048
+// function of the Yellow Paper. Bits are numbered from the least significant
+// bit of the bloom read as a big endian number.
+func bloomBits(item []byte) [3]uint {
+	hash := crypto.Keccak256(item)
+	var bits [3]uint
+	for i := range bits {
+		bits[i] = (uint(hash[2*i])<<8 | uint(hash[2*i+1])) & (types.BloomBitLength - 1)
+	}
+	return bits
+}
+
+// bloomAdd sets the bits of an item in the bloom.
+func bloomAdd(b *types.Bloom, item []byte) {
+	for _, bit := range bloomBits(item) {
+		b[types.BloomByteLength-1-bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// bloomContains reports whether all the bits of an item are set in the bloom.
+func bloomContains(b types.Bloom, item []byte) bool {
+	for _, bit := range bloomBits(item) {
+		if b[types.BloomByteLength-1-bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// logItems returns the items logs add to a bloom: the address and the topics
+// of every log, in order. The data of the logs is not part of the bloom.
+func logItems(logs []*types.Log) [][]byte {
+	var items [][]byte
+	for _, l := range logs {
+		items = append(items, l.Address.Bytes())
+		for _, topic := range l.Topics {
+			items = append(items, topic.Bytes())
+		}
+	}
+	return items
+}
+
+// logsBloom returns the bloom of the logs of a receipt.
+func logsBloom(logs []*types.Log) types.Bloom {
+	var b types.Bloom
+	for _, item := range logItems(logs) {
+		bloomAdd(&b, item)
+	}
+	return b
+}
+
+// mergeBlooms returns the bloom of a block, the union of those of its
+// receipts.
+func mergeBlooms(blooms []types.Bloom) types.Bloom {
+	var b types.Bloom
+	for _, r := range blooms {
+		for i := range b {
+			b[i] |= r[i]
+		}
+	}
+	return b
+}
+
+// bloomMatch reports whether a bloom may contain logs matching a query: one
+// of its addresses, unless it has none, and at every topic position one of
+// the topics, unless the position is a wildcard. The bloom does not record
+// which log an item belongs to, nor the position of a topic, nor whether a
+// log has as many topics as the query has positions.
+func bloomMatch(b types.Bloom, addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 && !slices.ContainsFunc(addresses, func(a common.Address) bool { return bloomContains(b, a.Bytes()) }) {
+		return false
+	}
+	for _, position := range topics {
+		if len(position) > 0 && !slices.ContainsFunc(position, func(t common.Hash) bool { return bloomContains(b, t.Bytes()) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchingLogs returns the indexes of the logs matching a query, as by
+// eth_getLogs: logs of one of its addresses, unless it has none, with at
+// least as many topics as the query has positions and at every position one
+// of the topics of the query, unless it is a wildcard.
+func matchingLogs(logs []*types.Log, addresses []common.Address, topics [][]common.Hash) []int {
+	matches := []int{}
+	for i, l := range logs {
+		if len(addresses) > 0 && !slices.Contains(addresses, l.Address) {
+			continue
+		}
+		if len(topics) > len(l.Topics) {
+			continue
+		}
+		matched := true
+		for j, position := range topics {
+			if len(position) > 0 && !slices.Contains(position, l.Topics[j]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
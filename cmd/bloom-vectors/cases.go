@@ -0,0 +1,213 @@
+package main
+
+import (
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	emitter = common.HexToAddress("0x000000000000000000000000000000000000b100")
+	other   = common.HexToAddress("0x000000000000000000000000000000000000b200")
+
+	transfer = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	approval = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+	from     = common.BytesToHash(emitter.Bytes())
+	to       = common.BytesToHash(other.Bytes())
+
+	topic1 = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000001")
+	topic2 = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000002")
+	topic4 = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+	word = common.HexToHash("0x00000000000000000000000000000000000000000000000000000000deadbeef").Bytes()
+)
+
+// testCase is a block of receipts, by their logs, and the queries run on it.
+type testCase struct {
+	name        string
+	description string
+	receipts    [][]*types.Log
+	queries     []testQuery
+}
+
+// testQuery is a log filter with its expected results: whether the bloom of
+// the block may hold matching logs and the indexes of the matching logs.
+type testQuery struct {
+	description string
+	addresses   []common.Address
+	topics      [][]common.Hash
+	bloomMatch  bool
+	matches     []int
+}
+
+// newLog returns a log of the address with the topics.
+func newLog(addr common.Address, data []byte, topics ...common.Hash) *types.Log {
+	return &types.Log{Address: addr, Topics: topics, Data: data}
+}
+
+// addrs and position shorten the criteria of queries. A nil position is a
+// wildcard.
+func addrs(a ...common.Address) []common.Address { return a }
+
+func position(t ...common.Hash) []common.Hash { return t }
+
+// search returns the first of the candidates n, n+1, ... for which found
+// holds, as a number.
+func search(n int64, found func(*big.Int) bool) *big.Int {
+	for i := big.NewInt(n); ; i.Add(i, common.Big1) {
+		if found(i) {
+			return i
+		}
+	}
+}
+
+// topicWithBit returns a topic setting the bit of the bloom.
+func topicWithBit(bit uint) common.Hash {
+	n := search(1, func(n *big.Int) bool {
+		bits := bloomBits(common.BigToHash(n).Bytes())
+		return slices.Contains(bits[:], bit)
+	})
+	return common.BigToHash(n)
+}
+
+// denseLogs returns 32 logs of distinct addresses with four distinct topics
+// each, 160 items setting about a fifth of the bits of the bloom.
+func denseLogs() []*types.Log {
+	logs := make([]*types.Log, 32)
+	for i := range logs {
+		n := int64(4*i + 1)
+		logs[i] = newLog(common.BigToAddress(big.NewInt(0xd000+int64(i))), nil,
+			common.BigToHash(big.NewInt(n)), common.BigToHash(big.NewInt(n+1)),
+			common.BigToHash(big.NewInt(n+2)), common.BigToHash(big.NewInt(n+3)))
+	}
+	return logs
+}
+
+func cases() []*testCase {
+	low, high := topicWithBit(0), topicWithBit(types.BloomBitLength-1)
+
+	// The false positives of the dense bloom are the first addresses and
+	// topics above those logged with all their bits set, and the negative
+	// the first topic with a bit unset.
+	dense := denseLogs()
+	denseBloom := logsBloom(dense)
+	fpAddress := common.BigToAddress(search(0x10000, func(n *big.Int) bool {
+		return bloomContains(denseBloom, common.BigToAddress(n).Bytes())
+	}))
+	fpTopic := common.BigToHash(search(0x10000, func(n *big.Int) bool {
+		return bloomContains(denseBloom, common.BigToHash(n).Bytes())
+	}))
+	absent := common.BigToHash(search(0x10000, func(n *big.Int) bool {
+		return !bloomContains(denseBloom, common.BigToHash(n).Bytes())
+	}))
+
+	return []*testCase{
+		{
+			name:        "no_logs",
+			description: "A receipt without logs has the empty bloom, which matches no address or topic. A query without criteria matches any bloom.",
+			receipts:    [][]*types.Log{{}},
+			queries: []testQuery{
+				{description: "address", addresses: addrs(emitter), matches: []int{}},
+				{description: "topic", topics: [][]common.Hash{position(transfer)}, matches: []int{}},
+				{description: "no criteria", bloomMatch: true, matches: []int{}},
+			},
+		},
+		{
+			name:        "log0",
+			description: "A log without topics adds only its address to the bloom.",
+			receipts:    [][]*types.Log{{newLog(emitter, word)}},
+			queries: []testQuery{
+				{description: "address", addresses: addrs(emitter), bloomMatch: true, matches: []int{0}},
+				{description: "other address", addresses: addrs(other), matches: []int{}},
+				{description: "either address", addresses: addrs(other, emitter), bloomMatch: true, matches: []int{0}},
+				{description: "address and topic", addresses: addrs(emitter), topics: [][]common.Hash{position(transfer)}, matches: []int{}},
+				{description: "wildcard position beyond the topics of the log", addresses: addrs(emitter), topics: [][]common.Hash{nil}, bloomMatch: true, matches: []int{}},
+			},
+		},
+		{
+			name:        "log4",
+			description: "A log with four topics adds its address and every topic to the bloom, which does not record their positions.",
+			receipts:    [][]*types.Log{{newLog(emitter, word, transfer, from, to, topic4)}},
+			queries: []testQuery{
+				{description: "every topic", addresses: addrs(emitter), topics: [][]common.Hash{position(transfer), position(from), position(to), position(topic4)}, bloomMatch: true, matches: []int{0}},
+				{description: "first topic", topics: [][]common.Hash{position(transfer)}, bloomMatch: true, matches: []int{0}},
+				{description: "last topic after wildcards", topics: [][]common.Hash{nil, nil, nil, position(topic4)}, bloomMatch: true, matches: []int{0}},
+				{description: "either topic", topics: [][]common.Hash{position(approval, transfer)}, bloomMatch: true, matches: []int{0}},
+				{description: "topic not logged", topics: [][]common.Hash{position(approval)}, matches: []int{}},
+				{description: "topic at another position", topics: [][]common.Hash{position(from)}, bloomMatch: true, matches: []int{}},
+				{description: "swapped topics", topics: [][]common.Hash{nil, position(to), position(from)}, bloomMatch: true, matches: []int{}},
+				{description: "five positions", topics: [][]common.Hash{nil, nil, nil, nil, nil}, bloomMatch: true, matches: []int{}},
+				{description: "topic of another address", addresses: addrs(other), topics: [][]common.Hash{position(transfer)}, matches: []int{}},
+			},
+		},
+		{
+			name:        "duplicate_items",
+			description: "Items added twice, a repeated topic and two logs of one address, set the same bits once.",
+			receipts:    [][]*types.Log{{newLog(emitter, nil, topic1, topic1), newLog(emitter, nil, topic1)}},
+			queries: []testQuery{
+				{description: "repeated topic", topics: [][]common.Hash{position(topic1), position(topic1)}, bloomMatch: true, matches: []int{0}},
+				{description: "first position", addresses: addrs(emitter), topics: [][]common.Hash{position(topic1)}, bloomMatch: true, matches: []int{0, 1}},
+				{description: "topic not logged", topics: [][]common.Hash{position(topic1), position(topic2)}, matches: []int{}},
+			},
+		},
+		{
+			name:        "data_ignored",
+			description: "The data of logs is not part of the bloom: logs differing only in their data, empty, a word and large, add the same items.",
+			receipts:    [][]*types.Log{{newLog(emitter, nil, transfer), newLog(emitter, word, transfer), newLog(emitter, make([]byte, 1024), transfer)}},
+			queries: []testQuery{
+				{description: "address and topic", addresses: addrs(emitter), topics: [][]common.Hash{position(transfer)}, bloomMatch: true, matches: []int{0, 1, 2}},
+				{description: "data as a topic", topics: [][]common.Hash{position(common.BytesToHash(word))}, matches: []int{}},
+			},
+		},
+		{
+			name:        "zero_values",
+			description: "The zero address and the zero topic are hashed like any other item.",
+			receipts:    [][]*types.Log{{newLog(common.Address{}, nil, common.Hash{})}},
+			queries: []testQuery{
+				{description: "zero address", addresses: addrs(common.Address{}), bloomMatch: true, matches: []int{0}},
+				{description: "zero topic", topics: [][]common.Hash{position(common.Hash{})}, bloomMatch: true, matches: []int{0}},
+				{description: "other address", addresses: addrs(emitter), matches: []int{}},
+			},
+		},
+		{
+			name:        "bit_order",
+			description: "Bit 0 of the bloom is the lowest bit of its last byte and bit 2047 the highest bit of its first byte: the topics of the log set each of them.",
+			receipts:    [][]*types.Log{{newLog(emitter, nil, low, high)}},
+			queries: []testQuery{
+				{description: "both topics", topics: [][]common.Hash{position(low), position(high)}, bloomMatch: true, matches: []int{0}},
+			},
+		},
+		{
+			name:        "multiple_receipts",
+			description: "The bloom of a block is the union of those of its receipts, and matches addresses and topics logged by different receipts together.",
+			receipts: [][]*types.Log{
+				{newLog(emitter, nil, topic1)},
+				{newLog(other, nil, topic2)},
+				{},
+			},
+			queries: []testQuery{
+				{description: "log of the first receipt", addresses: addrs(emitter), topics: [][]common.Hash{position(topic1)}, bloomMatch: true, matches: []int{0}},
+				{description: "log of the second receipt", addresses: addrs(other), topics: [][]common.Hash{position(topic2)}, bloomMatch: true, matches: []int{1}},
+				{description: "address and topic of different receipts", addresses: addrs(emitter), topics: [][]common.Hash{position(topic2)}, bloomMatch: true, matches: []int{}},
+				{description: "either log", addresses: addrs(emitter, other), topics: [][]common.Hash{position(topic1, topic2)}, bloomMatch: true, matches: []int{0, 1}},
+			},
+		},
+		{
+			name:        "false_positives",
+			description: "32 logs with four topics each set about a fifth of the bits of the bloom, which then holds addresses and topics never logged.",
+			receipts:    [][]*types.Log{dense},
+			queries: []testQuery{
+				{description: "logged address", addresses: addrs(dense[7].Address), bloomMatch: true, matches: []int{7}},
+				{description: "logged topic", topics: [][]common.Hash{nil, position(dense[20].Topics[1])}, bloomMatch: true, matches: []int{20}},
+				{description: "address not logged", addresses: addrs(fpAddress), bloomMatch: true, matches: []int{}},
+				{description: "topic not logged", topics: [][]common.Hash{position(fpTopic)}, bloomMatch: true, matches: []int{}},
+				{description: "logged address and topic not logged", addresses: addrs(dense[0].Address), topics: [][]common.Hash{position(fpTopic)}, bloomMatch: true, matches: []int{}},
+				{description: "topic not in the bloom", topics: [][]common.Hash{position(absent)}, matches: []int{}},
+				{description: "topic not in the bloom or logged topic", topics: [][]common.Hash{position(absent, dense[3].Topics[0])}, bloomMatch: true, matches: []int{3}},
+			},
+		},
+	}
+}
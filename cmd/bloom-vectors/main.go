@@ -0,0 +1,303 @@
+// bloom-vectors generates vectors of the logs bloom of receipts and blocks
+// and of the queries of logs against it, and verifies vector files, for
+// implementations of log filtering and indexing.
+//
+// Usage:
+//
+//	go run ./cmd/bloom-vectors [--output bloom_vectors.json]
+//	go run ./cmd/bloom-vectors --verify bloom_vectors.json
+//
+// Every vector is a block of receipts given by their logs, with the bloom of
+// every receipt and of the block, the union of those of its receipts. The
+// items added to the bloom, the address and the topics of every log in
+// order, are listed with their hash and the three bits they set, the low 11
+// bits of each of the first three pairs of bytes of the hash, numbered from
+// the least significant bit of the last byte of the bloom. The queries of a
+// vector are eth_getLogs filters, a list of addresses and lists of topics by
+// position, null for a wildcard, with whether the bloom of the block may hold
+// matching logs and the indexes of the logs of the block which do match.
+//
+// The vectors cover receipts and blocks without logs, logs with no to four
+// topics, duplicate items, data not being part of the bloom, zero addresses
+// and topics, the lowest and the highest bit of the bloom, and false
+// positives: topics matched at another position, addresses and topics of
+// different logs, queries with more positions than the logs have topics, and
+// items not logged whose bits are all set in a dense bloom.
+//
+// The blooms are computed from the Yellow Paper and checked against
+// go-ethereum, and the results of the queries against those declared with
+// the cases, before anything is written. With --verify a vector file, such as
+// one written by another implementation, is checked instead: the blooms, the
+// items and the results of the queries are recomputed and every mismatch is
+// reported, exiting with a nonzero code.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// vector is a bloom vector.
+type vector struct {
+	Info     map[string]string `json:"_info"`
+	Receipts []*receipt        `json:"receipts"`
+	Items    []*item           `json:"items"`
+	Bloom    types.Bloom       `json:"logsBloom"` // of the block
+	Queries  []*query          `json:"queries"`
+}
+
+// receipt is the logs of a receipt and their bloom.
+type receipt struct {
+	Logs  []*log      `json:"logs"`
+	Bloom types.Bloom `json:"logsBloom"`
+}
+
+// log is the consensus part of a log.
+type log struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// item is an address or a topic added to a bloom and the bits it sets.
+type item struct {
+	Value hexutil.Bytes `json:"value"`
+	Hash  common.Hash   `json:"hash"`
+	Bits  [3]uint       `json:"bits"`
+}
+
+// query is a log filter and its results on a block: whether the bloom may
+// hold matching logs, and the indexes of the matching logs in the block.
+type query struct {
+	Description string           `json:"description"`
+	Addresses   []common.Address `json:"addresses"`
+	Topics      [][]common.Hash  `json:"topics"`
+	BloomMatch  bool             `json:"bloomMatch"`
+	Matches     []int            `json:"matches"`
+}
+
+func main() {
+	var (
+		output = flag.String("output", "bloom_vectors.json", "file the vectors are written to")
+		verify = flag.String("verify", "", "vector file to verify instead of generating the vectors")
+	)
+	flag.Parse()
+	if *verify != "" {
+		if err := verifyFile(*verify); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	vectors := make(map[string]*vector)
+	for _, c := range cases() {
+		v, err := fill(c)
+		if err != nil {
+			fatalf("case %s: %v", c.name, err)
+		}
+		if errs := check(v); len(errs) > 0 {
+			fatalf("case %s: %v", c.name, errs[0])
+		}
+		vectors["bloom_vectors/"+c.name] = v
+	}
+	data, err := json.MarshalIndent(vectors, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d vectors to %s\n", len(vectors), *output)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// fill computes the blooms of a test case and the results of its queries,
+// checking them against go-ethereum and the expectations of the case.
+func fill(c *testCase) (*vector, error) {
+	v := &vector{
+		Info: statetest.Info("bloom-vectors", "handcrafted", c.description),
+	}
+	var (
+		logs     []*types.Log
+		blooms   []types.Bloom
+		receipts types.Receipts
+	)
+	for i, rlogs := range c.receipts {
+		bloom := logsBloom(rlogs)
+		r := &types.Receipt{Logs: rlogs}
+		if geth := types.CreateBloom(r); bloom != geth {
+			return nil, fmt.Errorf("receipt %d: bloom %x, go-ethereum %x", i, bloom, geth)
+		}
+		r.Bloom = bloom
+		entry := &receipt{Logs: make([]*log, len(rlogs)), Bloom: bloom}
+		for j, l := range rlogs {
+			entry.Logs[j] = &log{Address: l.Address, Topics: l.Topics, Data: l.Data}
+			if entry.Logs[j].Topics == nil {
+				entry.Logs[j].Topics = []common.Hash{}
+			}
+		}
+		v.Receipts = append(v.Receipts, entry)
+		logs = append(logs, rlogs...)
+		blooms = append(blooms, bloom)
+		receipts = append(receipts, r)
+	}
+	for _, data := range logItems(logs) {
+		it := &item{Value: data, Hash: crypto.Keccak256Hash(data), Bits: bloomBits(data)}
+		var single, geth types.Bloom
+		bloomAdd(&single, data)
+		if geth.Add(data); single != geth {
+			return nil, fmt.Errorf("item %x: bits %v differ from go-ethereum", data, it.Bits)
+		}
+		v.Items = append(v.Items, it)
+	}
+	v.Bloom = mergeBlooms(blooms)
+	if geth := types.MergeBloom(receipts); v.Bloom != geth {
+		return nil, fmt.Errorf("block bloom %x, go-ethereum %x", v.Bloom, geth)
+	}
+	for _, q := range c.queries {
+		entry := &query{
+			Description: q.description,
+			Addresses:   q.addresses,
+			Topics:      q.topics,
+			BloomMatch:  bloomMatch(v.Bloom, q.addresses, q.topics),
+			Matches:     matchingLogs(logs, q.addresses, q.topics),
+		}
+		if entry.Addresses == nil {
+			entry.Addresses = []common.Address{}
+		}
+		if entry.Topics == nil {
+			entry.Topics = [][]common.Hash{}
+		}
+		if geth := gethBloomMatch(v.Bloom, q.addresses, q.topics); entry.BloomMatch != geth {
+			return nil, fmt.Errorf("query %q: bloom match %t, go-ethereum %t", q.description, entry.BloomMatch, geth)
+		}
+		if entry.BloomMatch != q.bloomMatch {
+			return nil, fmt.Errorf("query %q: bloom match %t, want %t", q.description, entry.BloomMatch, q.bloomMatch)
+		}
+		if !slices.Equal(entry.Matches, q.matches) {
+			return nil, fmt.Errorf("query %q: matching logs %v, want %v", q.description, entry.Matches, q.matches)
+		}
+		v.Queries = append(v.Queries, entry)
+	}
+	return v, nil
+}
+
+// gethBloomMatch tests a query against a bloom with go-ethereum's lookups, as
+// its log filters skip blocks.
+func gethBloomMatch(b types.Bloom, addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 && !slices.ContainsFunc(addresses, func(a common.Address) bool { return types.BloomLookup(b, a) }) {
+		return false
+	}
+	for _, position := range topics {
+		if len(position) > 0 && !slices.ContainsFunc(position, func(t common.Hash) bool { return types.BloomLookup(b, t) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyFile checks every vector of a vector file and reports the
+// mismatches.
+func verifyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var vectors map[string]*vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	names := make([]string, 0, len(vectors))
+	for name := range vectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var queries, failed int
+	for _, name := range names {
+		if vectors[name] == nil {
+			return fmt.Errorf("%s: vector %s is empty", path, name)
+		}
+		queries += len(vectors[name].Queries)
+		errs := check(vectors[name])
+		for _, err := range errs {
+			fmt.Printf("FAIL %s: %v\n", name, err)
+		}
+		if len(errs) > 0 {
+			failed++
+		}
+	}
+	fmt.Printf("%d vectors with %d queries verified, %d failed\n", len(vectors), queries, failed)
+	if failed > 0 {
+		return errors.New("vectors do not match")
+	}
+	return nil
+}
+
+// check recomputes the blooms, items and query results of a vector and
+// returns its mismatches.
+func check(v *vector) []error {
+	var (
+		errs   []error
+		logs   []*types.Log
+		blooms []types.Bloom
+	)
+	for i, r := range v.Receipts {
+		rlogs := make([]*types.Log, len(r.Logs))
+		for j, l := range r.Logs {
+			rlogs[j] = &types.Log{Address: l.Address, Topics: l.Topics, Data: l.Data}
+		}
+		bloom := logsBloom(rlogs)
+		if r.Bloom != bloom {
+			errs = append(errs, fmt.Errorf("receipt %d: bloom %x, want %x", i, r.Bloom, bloom))
+		}
+		logs = append(logs, rlogs...)
+		blooms = append(blooms, bloom)
+	}
+	items := logItems(logs)
+	if len(v.Items) != len(items) {
+		errs = append(errs, fmt.Errorf("%d items, want %d", len(v.Items), len(items)))
+	}
+	for i, data := range items {
+		if i >= len(v.Items) {
+			break
+		}
+		it := v.Items[i]
+		switch {
+		case !slices.Equal(it.Value, data):
+			errs = append(errs, fmt.Errorf("item %d: value %x, want %x", i, it.Value, data))
+		case it.Hash != crypto.Keccak256Hash(data):
+			errs = append(errs, fmt.Errorf("item %d: hash %x, want %x", i, it.Hash, crypto.Keccak256Hash(data)))
+		case it.Bits != bloomBits(data):
+			errs = append(errs, fmt.Errorf("item %d: bits %v, want %v", i, it.Bits, bloomBits(data)))
+		}
+	}
+	bloom := mergeBlooms(blooms)
+	if v.Bloom != bloom {
+		errs = append(errs, fmt.Errorf("block bloom %x, want %x", v.Bloom, bloom))
+	}
+	for i, q := range v.Queries {
+		// The results of the queries are recomputed on the bloom of the
+		// vector, so that they are checked even if the bloom is wrong.
+		if match := bloomMatch(v.Bloom, q.Addresses, q.Topics); q.BloomMatch != match {
+			errs = append(errs, fmt.Errorf("query %d (%s): bloom match %t, want %t", i, q.Description, q.BloomMatch, match))
+		}
+		if matches := matchingLogs(logs, q.Addresses, q.Topics); !slices.Equal(q.Matches, matches) {
+			errs = append(errs, fmt.Errorf("query %d (%s): matching logs %v, want %v", i, q.Description, q.Matches, matches))
+		}
+	}
+	return errs
+}
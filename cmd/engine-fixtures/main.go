@@ -0,0 +1,88 @@
+// engine-fixtures converts blockchain test fixtures of post-merge chains into
+// Engine API fixtures for EL/CL interop testing.
+//
+// Usage:
+//
+//	go run ./cmd/engine-fixtures [--test name] [--output engine_fixtures.json] fixtures.json
+//
+// Every block of a fixture becomes an engine_newPayload call, V1 to V4
+// depending on the fork of the block, with the status the execution client
+// must reply with: VALID for valid blocks and INVALID for those with an
+// expected exception. Every valid payload is followed by an
+// engine_forkchoiceUpdated call making it the head, safe and finalized block.
+// The pre-state, the genesis header and the post-state are those of the
+// blockchain fixture, so both are generated from the same source.
+//
+// The fixtures must use the NoProof seal engine, as those of cmd/blocktest,
+// cmd/fill and cmd/invalid-blocks do, and must not contain proof-of-work
+// blocks. Every converted fixture is replayed against the Engine API of an
+// in-process go-ethereum node, which must reply as expected, before anything
+// is written.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+)
+
+func main() {
+	var (
+		test   = flag.String("test", "", "only convert the named test of the fixtures file")
+		output = flag.String("output", "engine_fixtures.json", "file the fixtures are written to")
+	)
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fatalf("usage: engine-fixtures [flags] fixtures.json")
+	}
+	data, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	var fixtures map[string]*blocktest.Fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		fatalf("%s: %v", flag.Arg(0), err)
+	}
+	names := make([]string, 0, len(fixtures))
+	for name := range fixtures {
+		if *test == "" || name == *test {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		fatalf("%s: no test %q", flag.Arg(0), *test)
+	}
+	sort.Strings(names)
+
+	out := make(map[string]*blocktest.EngineFixture)
+	for _, name := range names {
+		f := fixtures[name]
+		if f.SealEngine != "NoProof" {
+			fatalf("%s: unsupported seal engine %q", name, f.SealEngine)
+		}
+		ef, err := f.Engine()
+		if err != nil {
+			fatalf("%s: %v", name, err)
+		}
+		ef.Info = statetest.Info("engine-fixtures", fmt.Sprintf("%s, test %s", flag.Arg(0), name), fmt.Sprintf("%d blocks as Engine API calls", len(f.Blocks)))
+		out["engine/"+name] = ef
+	}
+	data, err = json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d engine fixtures to %s\n", len(out), *output)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"github.com/ethereum/execution-specs/pkg/eof"
+)
+
+// testCase is a container and the error validating it has to fail with, or
+// nil if it is valid.
+type testCase struct {
+	name string
+	code []byte
+	kind eof.Kind
+	want error
+}
+
+// section is a code section together with its types entry.
+type section struct {
+	inputs, outputs  uint8
+	maxStackIncrease uint16
+	code             []byte
+}
+
+// entry returns a non-returning section without inputs, as the first section
+// of a container has to be.
+func entry(maxStackIncrease uint16, code ...byte) section {
+	return section{0, eof.NonReturning, maxStackIncrease, code}
+}
+
+// build encodes a container of the sections, subcontainers and data. The
+// declared data size is dataSize if it exceeds the length of the data.
+func build(sections []section, containers [][]byte, data []byte, dataSize int) []byte {
+	c := &eof.Container{Containers: containers, Data: data, DataSize: dataSize}
+	for _, s := range sections {
+		c.Types = append(c.Types, eof.FunctionMetadata{Inputs: s.inputs, Outputs: s.outputs, MaxStackIncrease: s.maxStackIncrease})
+		c.Code = append(c.Code, s.code)
+	}
+	b, err := c.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// simple encodes a container of a single section.
+func simple(maxStackIncrease uint16, code ...byte) []byte {
+	return build([]section{entry(maxStackIncrease, code...)}, nil, nil, 0)
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+func repeat(op byte, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = op
+	}
+	return b
+}
+
+// Opcodes used by the cases beyond the ones exported by pkg/eof.
+const (
+	PUSH0    = 0x5f
+	PUSH2    = 0x61
+	POP      = 0x50
+	NOP      = 0x5b
+	JUMP     = 0x56
+	CLZ      = 0x1e
+	DATASIZE = 0xd2
+)
+
+// cases returns the handcrafted containers, covering every validation rule
+// with at least one valid and one invalid container.
+func cases() []testCase {
+	var (
+		stop    = simple(0, eof.STOP)
+		data32  = repeat(0xaa, 32)
+		runtime = stop
+		// initcode deploying the runtime container.
+		initcode = build([]section{entry(2, PUSH0, PUSH0, eof.RETURNCODE, 0)}, [][]byte{runtime}, nil, 0)
+		// truncated is a runtime container missing its declared data, which
+		// is appended on deployment.
+		truncated = build([]section{entry(0, eof.STOP)}, nil, nil, 32)
+	)
+	returning := func(inputs, outputs uint8, maxStackIncrease uint16, code ...byte) section {
+		return section{inputs, outputs, maxStackIncrease, code}
+	}
+	callf1 := entry(1, eof.CALLF, 0, 1, eof.STOP)
+	// overflow pushes 1020 items before calling a section raising the stack
+	// by five more.
+	overflow := build([]section{
+		entry(1021, concat(repeat(PUSH0, 1020), []byte{eof.CALLF, 0, 1, eof.STOP})...),
+		returning(0, 1, 5, concat(repeat(PUSH0, 5), repeat(POP, 4), []byte{eof.RETF})...),
+	}, nil, nil, 0)
+
+	return []testCase{
+		// Valid containers.
+		{name: "valid/stop", code: stop},
+		{name: "valid/data", code: build([]section{entry(1, eof.DATALOADN, 0, 0, POP, eof.STOP)}, nil, data32, 0)},
+		{name: "valid/datasize", code: build([]section{entry(1, DATASIZE, POP, eof.STOP)}, nil, []byte{1, 2, 3}, 0)},
+		{name: "valid/rjump_backwards", code: simple(0, eof.RJUMP, 0xff, 0xfd)},
+		{name: "valid/rjumpi", code: simple(1, PUSH0, eof.RJUMPI, 0, 1, NOP, eof.STOP)},
+		{name: "valid/rjumpv", code: simple(1, PUSH0, eof.RJUMPV, 1, 0, 0, 0, 1, NOP, eof.STOP)},
+		{name: "valid/rjumpi_loop", code: simple(1, PUSH0, eof.RJUMPI, 0xff, 0xfc, eof.STOP)},
+		{name: "valid/callf", code: build([]section{callf1, returning(0, 1, 1, PUSH0, eof.RETF)}, nil, nil, 0)},
+		{name: "valid/callf_inputs", code: build([]section{
+			entry(2, PUSH0, PUSH0, eof.CALLF, 0, 1, eof.STOP),
+			returning(2, 1, 1, eof.DUP1+1, eof.SWAP1+1, POP, POP, eof.RETF),
+		}, nil, nil, 0)},
+		{name: "valid/jumpf_non_returning", code: build([]section{entry(0, eof.JUMPF, 0, 1), entry(0, eof.STOP)}, nil, nil, 0)},
+		{name: "valid/jumpf_returning", code: build([]section{
+			callf1,
+			returning(0, 1, 0, eof.JUMPF, 0, 2),
+			returning(0, 1, 1, PUSH0, eof.RETF),
+		}, nil, nil, 0)},
+		{name: "valid/dupn_swapn_exchange", code: simple(4, PUSH0, PUSH0, PUSH0, eof.DUPN, 0, eof.SWAPN, 0, eof.EXCHANGE, 0, eof.STOP)},
+		{name: "valid/clz", code: simple(1, PUSH0, CLZ, POP, eof.STOP)},
+		{name: "valid/varying_stack_height", code: simple(2, PUSH0, PUSH0, eof.RJUMPI, 0, 1, PUSH0, eof.STOP)},
+		{name: "valid/eofcreate", code: build([]section{entry(4, PUSH0, PUSH0, PUSH0, PUSH0, eof.EOFCREATE, 0, POP, eof.STOP)}, [][]byte{initcode}, nil, 0)},
+		{name: "valid/initcode", code: initcode, kind: eof.Initcode},
+		{name: "valid/initcode_revert", code: simple(2, PUSH0, PUSH0, eof.REVERT), kind: eof.Initcode},
+		{name: "valid/returncode_truncated_data", code: build([]section{entry(2, PUSH0, PUSH0, eof.RETURNCODE, 0)}, [][]byte{truncated}, nil, 0), kind: eof.Initcode},
+		{name: "valid/max_stack_height", code: simple(1023, concat(repeat(PUSH0, 1023), []byte{eof.STOP})...)},
+
+		// Header and body format.
+		{name: "invalid/magic", code: []byte{0xef, 0x01, 0x01}, want: eof.ErrInvalidMagic},
+		{name: "invalid/empty", code: nil, want: eof.ErrInvalidMagic},
+		{name: "invalid/version", code: concat([]byte{0xef, 0x00, 0x02}, stop[3:]), want: eof.ErrInvalidVersion},
+		{name: "invalid/missing_version", code: []byte{0xef, 0x00}, want: eof.ErrInvalidVersion},
+		{name: "invalid/missing_type_header", code: concat(stop[:3], stop[6:]), want: eof.ErrMissingTypeHeader},
+		{name: "invalid/incomplete_type_size", code: stop[:5], want: eof.ErrIncompleteSectionSize},
+		{name: "invalid/zero_type_size", code: []byte{0xef, 0x00, 0x01, 0x01, 0x00, 0x00}, want: eof.ErrZeroSectionSize},
+		{name: "invalid/missing_code_header", code: concat(stop[:6], stop[11:]), want: eof.ErrMissingCodeHeader},
+		{name: "invalid/incomplete_section_number", code: stop[:8], want: eof.ErrIncompleteSectionNumber},
+		{name: "invalid/incomplete_code_size", code: stop[:10], want: eof.ErrIncompleteSectionSize},
+		{name: "invalid/zero_code_sections", code: []byte{0xef, 0x00, 0x01, 0x01, 0x00, 0x04, 0x02, 0x00, 0x00}, want: eof.ErrZeroSectionSize},
+		{name: "invalid/zero_code_size", code: []byte{0xef, 0x00, 0x01, 0x01, 0x00, 0x04, 0x02, 0x00, 0x01, 0x00, 0x00}, want: eof.ErrZeroSectionSize},
+		{name: "invalid/too_many_code_sections", code: []byte{0xef, 0x00, 0x01, 0x01, 0x10, 0x04, 0x02, 0x04, 0x01}, want: eof.ErrTooManyCodeSections},
+		{name: "invalid/type_section_size", code: []byte{0xef, 0x00, 0x01, 0x01, 0x00, 0x08, 0x02, 0x00, 0x01, 0x00, 0x01}, want: eof.ErrInvalidTypeSectionSize},
+		{name: "invalid/too_many_containers", code: []byte{0xef, 0x00, 0x01, 0x01, 0x00, 0x04, 0x02, 0x00, 0x01, 0x00, 0x01, 0x03, 0x01, 0x01}, want: eof.ErrTooManyContainers},
+		{name: "invalid/missing_data_header", code: stop[:11], want: eof.ErrMissingDataHeader},
+		{name: "invalid/incomplete_data_size", code: stop[:13], want: eof.ErrIncompleteSectionSize},
+		{name: "invalid/missing_terminator", code: stop[:14], want: eof.ErrMissingTerminator},
+		{name: "invalid/missing_body", code: stop[:15], want: eof.ErrInvalidSectionBodiesSize},
+		{name: "invalid/trailing_bytes", code: concat(stop, []byte{0}), want: eof.ErrInvalidSectionBodiesSize},
+		{name: "invalid/toplevel_truncated", code: truncated, want: eof.ErrTopLevelTruncated},
+		{name: "invalid/container_size_above_limit", code: build([]section{entry(0, eof.STOP)}, nil, make([]byte, eof.MaxContainerSize), 0), want: eof.ErrContainerSizeAboveLimit},
+
+		// Types section.
+		{name: "invalid/first_section_returning", code: build([]section{returning(0, 0, 0, eof.STOP)}, nil, nil, 0), want: eof.ErrInvalidFirstSectionType},
+		{name: "invalid/first_section_inputs", code: build([]section{{1, eof.NonReturning, 0, []byte{eof.STOP}}}, nil, nil, 0), want: eof.ErrInvalidFirstSectionType},
+		{name: "invalid/inputs_above_limit", code: build([]section{callf1, returning(0x80, 1, 0, eof.RETF)}, nil, nil, 0), want: eof.ErrInputsOutputsAboveLimit},
+		{name: "invalid/max_stack_increase_above_limit", code: simple(0x400, eof.STOP), want: eof.ErrMaxStackIncreaseAboveLimit},
+
+		// Instructions.
+		{name: "invalid/undefined_instruction", code: simple(1, PUSH0, JUMP, eof.STOP), want: eof.ErrUndefinedInstruction},
+		{name: "invalid/truncated_push", code: simple(1, PUSH2, 0), want: eof.ErrTruncatedInstruction},
+		{name: "invalid/truncated_rjumpv", code: simple(1, PUSH0, eof.RJUMPV, 1, 0, 0), want: eof.ErrTruncatedInstruction},
+		{name: "invalid/rjump_into_immediate", code: simple(1, eof.RJUMP, 0, 1, eof.PUSH1, 0, eof.STOP), want: eof.ErrInvalidRjumpDestination},
+		{name: "invalid/rjump_out_of_bounds", code: simple(0, eof.RJUMP, 0, 1, eof.STOP), want: eof.ErrInvalidRjumpDestination},
+		{name: "invalid/rjumpv_before_start", code: simple(1, PUSH0, eof.RJUMPV, 0, 0xff, 0x00, eof.STOP), want: eof.ErrInvalidRjumpDestination},
+		{name: "invalid/callf_section_index", code: simple(0, eof.CALLF, 0, 1, eof.STOP), want: eof.ErrInvalidCodeSectionIndex},
+		{name: "invalid/jumpf_section_index", code: simple(0, eof.JUMPF, 0, 1), want: eof.ErrInvalidCodeSectionIndex},
+		{name: "invalid/callf_to_non_returning", code: build([]section{callf1, entry(0, eof.STOP)}, nil, nil, 0), want: eof.ErrCallfToNonReturning},
+		{name: "invalid/jumpf_incompatible_outputs", code: build([]section{
+			callf1,
+			returning(0, 1, 0, eof.JUMPF, 0, 2),
+			returning(0, 2, 2, PUSH0, PUSH0, eof.RETF),
+		}, nil, nil, 0), want: eof.ErrJumpfIncompatibleOutputs},
+		{name: "invalid/returning_without_retf", code: build([]section{callf1, returning(0, 1, 1, PUSH0, eof.STOP)}, nil, nil, 0), want: eof.ErrInvalidNonReturningFlag},
+		{name: "invalid/non_returning_with_retf", code: build([]section{entry(0, eof.JUMPF, 0, 1), entry(0, eof.RETF)}, nil, nil, 0), want: eof.ErrInvalidNonReturningFlag},
+		{name: "invalid/dataloadn_index", code: build([]section{entry(1, eof.DATALOADN, 0, 1, POP, eof.STOP)}, nil, data32, 0), want: eof.ErrInvalidDataloadnIndex},
+		{name: "invalid/eofcreate_container_index", code: simple(4, PUSH0, PUSH0, PUSH0, PUSH0, eof.EOFCREATE, 0, POP, eof.STOP), want: eof.ErrInvalidContainerSectionIndex},
+		{name: "invalid/missing_stop", code: simple(1, PUSH0, POP), want: eof.ErrMissingStopOpcode},
+		{name: "invalid/ends_with_rjumpi", code: simple(1, PUSH0, eof.RJUMPI, 0xff, 0xfc), want: eof.ErrMissingStopOpcode},
+		{name: "invalid/unreachable_instructions", code: simple(0, eof.STOP, eof.STOP), want: eof.ErrUnreachableInstructions},
+		{name: "invalid/unreachable_after_rjump", code: simple(0, eof.RJUMP, 0, 1, NOP, eof.STOP, NOP, eof.STOP), want: eof.ErrUnreachableInstructions},
+		{name: "invalid/unreachable_code_section", code: build([]section{entry(0, eof.STOP), entry(0, eof.STOP)}, nil, nil, 0), want: eof.ErrUnreachableCodeSections},
+
+		// Stack validation.
+		{name: "invalid/stack_underflow", code: simple(0, POP, eof.STOP), want: eof.ErrStackUnderflow},
+		{name: "invalid/stack_underflow_callf", code: build([]section{callf1, returning(1, 1, 0, eof.RETF)}, nil, nil, 0), want: eof.ErrStackUnderflow},
+		{name: "invalid/stack_underflow_varying", code: simple(2, PUSH0, PUSH0, eof.RJUMPI, 0, 1, PUSH0, POP, POP, eof.STOP), want: eof.ErrStackUnderflow},
+		{name: "invalid/stack_overflow_callf", code: overflow, want: eof.ErrStackOverflow},
+		{name: "invalid/stack_overflow", code: build([]section{
+			entry(2, PUSH0, PUSH0, eof.CALLF, 0, 1, eof.STOP),
+			returning(2, 1, 1023, concat(repeat(PUSH0, 1023), repeat(POP, 1024), []byte{eof.RETF})...),
+		}, nil, nil, 0), want: eof.ErrStackOverflow},
+		{name: "invalid/retf_stack_height", code: build([]section{callf1, returning(0, 1, 2, PUSH0, PUSH0, eof.RETF)}, nil, nil, 0), want: eof.ErrStackHeightMismatch},
+		{name: "invalid/backward_rjump_stack_height", code: simple(1, PUSH0, eof.RJUMP, 0xff, 0xfc), want: eof.ErrStackHeightMismatch},
+		{name: "invalid/jumpf_stack_height", code: build([]section{
+			callf1,
+			returning(0, 1, 1, PUSH0, eof.JUMPF, 0, 2),
+			returning(0, 1, 1, PUSH0, eof.RETF),
+		}, nil, nil, 0), want: eof.ErrStackHeightMismatch},
+		{name: "invalid/max_stack_increase_too_low", code: simple(0, PUSH0, POP, eof.STOP), want: eof.ErrInvalidMaxStackIncrease},
+		{name: "invalid/max_stack_increase_too_high", code: simple(2, PUSH0, POP, eof.STOP), want: eof.ErrInvalidMaxStackIncrease},
+
+		// Subcontainers.
+		{name: "invalid/orphan_subcontainer", code: build([]section{entry(0, eof.STOP)}, [][]byte{runtime}, nil, 0), want: eof.ErrOrphanSubcontainer},
+		{name: "invalid/returncode_in_runtime", code: build([]section{entry(2, PUSH0, PUSH0, eof.RETURNCODE, 0)}, [][]byte{runtime}, nil, 0), want: eof.ErrIncompatibleContainerKind},
+		{name: "invalid/stop_in_initcode", code: stop, kind: eof.Initcode, want: eof.ErrIncompatibleContainerKind},
+		{name: "invalid/eofcreate_runtime", code: build([]section{entry(4, PUSH0, PUSH0, PUSH0, PUSH0, eof.EOFCREATE, 0, POP, eof.STOP)}, [][]byte{runtime}, nil, 0), want: eof.ErrIncompatibleContainerKind},
+		{name: "invalid/eofcreate_and_returncode", code: build([]section{
+			entry(4, PUSH0, PUSH0, PUSH0, PUSH0, eof.EOFCREATE, 0, POP, PUSH0, PUSH0, eof.RETURNCODE, 0),
+		}, [][]byte{initcode}, nil, 0), kind: eof.Initcode, want: eof.ErrIncompatibleContainerKind},
+		{name: "invalid/eofcreate_truncated", code: build([]section{entry(4, PUSH0, PUSH0, PUSH0, PUSH0, eof.EOFCREATE, 0, POP, eof.STOP)}, [][]byte{truncatedInitcode(initcode)}, nil, 0), want: eof.ErrEofcreateTruncatedContainer},
+		{name: "invalid/nested_subcontainer", code: build([]section{entry(4, PUSH0, PUSH0, PUSH0, PUSH0, eof.EOFCREATE, 0, POP, eof.STOP)}, [][]byte{
+			build([]section{entry(2, PUSH0, PUSH0, eof.RETURNCODE, 0)}, [][]byte{simple(0, POP, eof.STOP)}, nil, 0),
+		}, nil, 0), want: eof.ErrStackUnderflow},
+	}
+}
+
+// truncatedInitcode returns the initcode with a data section declared but
+// missing.
+func truncatedInitcode(initcode []byte) []byte {
+	c, err := eof.Parse(initcode)
+	if err != nil {
+		panic(err)
+	}
+	c.DataSize = 16
+	b, err := c.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
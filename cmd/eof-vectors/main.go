@@ -0,0 +1,161 @@
+// eof-vectors generates EOF container validation vectors in the format of the
+// eof_tests fixtures of the execution spec tests, using the validator in
+// pkg/eof as the reference.
+//
+// Usage:
+//
+//	go run ./cmd/eof-vectors [--fork Osaka] [--random 200] [--seed 1] [--output eof_vectors.json]
+//	go run ./cmd/eof-vectors [--initcode] <hex container>...
+//
+// The vectors are made of handcrafted containers, one per validation rule and
+// each with the result the rule demands, and of randomly malformed variants of
+// the valid ones: flipped, inserted and removed bytes, truncations and
+// corrupted header fields. The handcrafted results are checked against the
+// validator before anything is written, so the two cannot drift apart. The
+// random variants are reproducible for a given seed.
+//
+// Given containers as arguments, the command validates them instead and
+// prints the result of each.
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/eof"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// fixture is an eof_tests fixture.
+type fixture struct {
+	Info    map[string]string  `json:"_info"`
+	Vectors map[string]*vector `json:"vectors"`
+}
+
+// vector is a container and its validation result per fork.
+type vector struct {
+	Code          hexutil.Bytes      `json:"code"`
+	ContainerKind string             `json:"containerKind,omitempty"`
+	Results       map[string]*result `json:"results"`
+}
+
+type result struct {
+	Exception string `json:"exception,omitempty"`
+	Valid     bool   `json:"result"`
+}
+
+func main() {
+	var (
+		fork     = flag.String("fork", "Osaka", "fork the results are recorded for")
+		random   = flag.Int("random", 200, "number of randomly malformed containers")
+		seed     = flag.Int64("seed", 1, "seed of the random containers")
+		output   = flag.String("output", "eof_vectors.json", "file the fixtures are written to")
+		initcode = flag.Bool("initcode", false, "validate the containers given as arguments as initcode")
+	)
+	flag.Parse()
+	if flag.NArg() > 0 {
+		kind := eof.Runtime
+		if *initcode {
+			kind = eof.Initcode
+		}
+		if !checkContainers(flag.Args(), kind) {
+			os.Exit(1)
+		}
+		return
+	}
+	fixtures, err := generate(*fork, *random, *seed)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	data, err := json.MarshalIndent(fixtures, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	var valid, invalid int
+	for _, f := range fixtures {
+		for _, v := range f.Vectors {
+			if v.Results[*fork].Valid {
+				valid++
+			} else {
+				invalid++
+			}
+		}
+	}
+	fmt.Printf("Wrote %d fixtures to %s: %d valid and %d invalid containers\n", len(fixtures), *output, valid, invalid)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// checkContainers validates hex encoded containers and prints the results. It
+// reports whether all of them could be decoded.
+func checkContainers(args []string, kind eof.Kind) bool {
+	ok := true
+	for _, arg := range args {
+		code, err := hex.DecodeString(strings.TrimPrefix(arg, "0x"))
+		if err != nil {
+			fmt.Printf("%s: invalid hex: %v\n", arg, err)
+			ok = false
+			continue
+		}
+		if err := eof.Validate(code, kind); err != nil {
+			fmt.Printf("%s: %s (%v)\n", arg, eof.Exception(err), err)
+		} else {
+			fmt.Printf("%s: valid\n", arg)
+		}
+	}
+	return ok
+}
+
+// newVector validates a container and records the result for the fork.
+func newVector(code []byte, kind eof.Kind, fork string) *vector {
+	v := &vector{Code: code, Results: map[string]*result{fork: {Valid: true}}}
+	if kind == eof.Initcode {
+		v.ContainerKind = kind.String()
+	}
+	if err := eof.Validate(code, kind); err != nil {
+		v.Results[fork] = &result{Exception: eof.Exception(err)}
+	}
+	return v
+}
+
+// generate builds the fixtures of the handcrafted and the random containers.
+func generate(fork string, random int, seed int64) (map[string]*fixture, error) {
+	fixtures := make(map[string]*fixture)
+	var valid []testCase
+	for _, c := range cases() {
+		err := eof.Validate(c.code, c.kind)
+		if !errors.Is(err, c.want) {
+			return nil, fmt.Errorf("case %s: validation result %v, want %v", c.name, err, c.want)
+		}
+		if err == nil {
+			valid = append(valid, c)
+		}
+		fixtures["eof_vectors/"+c.name] = &fixture{
+			Info:    statetest.Info("eof-vectors", "handcrafted", ""),
+			Vectors: map[string]*vector{"0": newVector(c.code, c.kind, fork)},
+		}
+	}
+	if random > 0 {
+		f := &fixture{
+			Info:    statetest.Info("eof-vectors", fmt.Sprintf("random, seed %d", seed), ""),
+			Vectors: make(map[string]*vector),
+		}
+		for i, c := range mutate(valid, random, seed) {
+			f.Vectors[fmt.Sprint(i)] = newVector(c.code, c.kind, fork)
+		}
+		fixtures["eof_vectors/random"] = f
+	}
+	return fixtures, nil
+}
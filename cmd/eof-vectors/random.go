@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math/rand"
+
+	"github.com/ethereum/execution-specs/pkg/eof"
+)
+
+// headerSize is the size of the header of a container without
+// subcontainers: magic, version, the types, code and data headers of a single
+// code section and the terminator.
+const headerSize = 15
+
+// mutations malform a container: they flip a bit, replace, insert or delete a
+// byte, truncate the container, corrupt the header or overwrite code with a
+// random EOF opcode.
+var mutations = []func(rng *rand.Rand, code []byte) []byte{
+	func(rng *rand.Rand, code []byte) []byte {
+		code[rng.Intn(len(code))] ^= byte(1 << rng.Intn(8))
+		return code
+	},
+	func(rng *rand.Rand, code []byte) []byte {
+		code[rng.Intn(len(code))] = byte(rng.Intn(256))
+		return code
+	},
+	func(rng *rand.Rand, code []byte) []byte {
+		i := rng.Intn(len(code) + 1)
+		return append(code[:i], append([]byte{byte(rng.Intn(256))}, code[i:]...)...)
+	},
+	func(rng *rand.Rand, code []byte) []byte {
+		i := rng.Intn(len(code))
+		return append(code[:i], code[i+1:]...)
+	},
+	func(rng *rand.Rand, code []byte) []byte {
+		return code[:rng.Intn(len(code))]
+	},
+	func(rng *rand.Rand, code []byte) []byte {
+		// Corrupt the header only, leaving the sections intact.
+		code[rng.Intn(min(len(code), headerSize))] = byte(rng.Intn(256))
+		return code
+	},
+	func(rng *rand.Rand, code []byte) []byte {
+		// Overwrite a byte of the sections with an opcode defined in EOF,
+		// which mostly breaks the stack or jump validation.
+		for {
+			op := byte(rng.Intn(256))
+			if eof.Valid(op) {
+				i := min(headerSize, len(code)-1) + rng.Intn(max(len(code)-headerSize, 1))
+				code[i] = op
+				return code
+			}
+		}
+	},
+}
+
+// mutate derives n randomly malformed containers from the valid cases. The
+// mutated containers are mostly, but not necessarily invalid, and duplicates
+// are skipped. The same seed yields the same containers.
+func mutate(valid []testCase, n int, seed int64) []testCase {
+	var (
+		rng  = rand.New(rand.NewSource(seed))
+		seen = make(map[string]bool)
+		out  []testCase
+	)
+	for _, c := range valid {
+		seen[string(c.code)] = true
+	}
+	for attempts := 0; len(out) < n && attempts < 100*n; attempts++ {
+		base := valid[rng.Intn(len(valid))]
+		code := append([]byte(nil), base.code...)
+		// Apply one to three mutations.
+		for i := rng.Intn(3); i >= 0; i-- {
+			if len(code) == 0 {
+				break
+			}
+			code = mutations[rng.Intn(len(mutations))](rng, code)
+		}
+		if seen[string(code)] {
+			continue
+		}
+		seen[string(code)] = true
+		out = append(out, testCase{name: base.name, code: code, kind: base.kind})
+	}
+	return out
+}
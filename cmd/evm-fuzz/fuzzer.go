@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/evmfuzz"
+	"github.com/ethereum/execution-specs/pkg/trace"
+)
+
+// corpus is the set of inputs mutations start from, with the coverage they
+// reached.
+type corpus struct {
+	dir      string
+	inputs   []*evmfuzz.Input
+	features map[uint32]bool
+}
+
+// loadCorpus reads the raw inputs of the corpus directory. A directory which
+// does not exist yet starts an empty corpus, which is seeded with an
+// empty program.
+func loadCorpus(dir string) (*corpus, error) {
+	c := &corpus{dir: dir, features: make(map[uint32]bool)}
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			c.inputs = append(c.inputs, evmfuzz.Decode(data))
+		}
+	}
+	if len(c.inputs) == 0 {
+		c.inputs = append(c.inputs, &evmfuzz.Input{Gas: 100000})
+	}
+	return c, nil
+}
+
+// coverage returns the features of a trace: the transitions between
+// consecutive opcodes of a call frame and the opcodes failing with an error.
+func coverage(steps []trace.Step) []uint32 {
+	var (
+		features []uint32
+		prev     = make(map[int]uint64) // last opcode by depth
+	)
+	for _, step := range steps {
+		features = append(features, uint32(prev[step.Depth])<<8|uint32(step.Op))
+		prev[step.Depth] = step.Op + 1
+		if step.Error != "" {
+			features = append(features, 1<<16|uint32(step.Op))
+		}
+	}
+	return features
+}
+
+// fuzzer is the state shared by the workers.
+type fuzzer struct {
+	fork        string
+	backends    []evmfuzz.Backend
+	findingsDir string
+	deadline    time.Time
+	iterations  int // inputs executed before stopping, 0 for no limit
+
+	mu         sync.Mutex
+	corpus     *corpus
+	execs      int
+	signatures map[string]int // number of findings by signature
+	err        error
+}
+
+// loop mutates and executes inputs until the fuzzer stops.
+func (f *fuzzer) loop(rng *rand.Rand) {
+	for {
+		in, ok := f.next(rng)
+		if !ok {
+			return
+		}
+		for n := 1 + rng.Intn(4); n > 0; n-- {
+			evmfuzz.Mutate(rng, in)
+		}
+		outcome, err := evmfuzz.Execute(in, f.fork, f.backends)
+		if err != nil {
+			f.fail(err)
+			return
+		}
+		if err := f.record(in, outcome); err != nil {
+			f.fail(err)
+			return
+		}
+	}
+}
+
+// next picks a copy of a corpus input to mutate, or reports that the fuzzer
+// stops.
+func (f *fuzzer) next(rng *rand.Rand) (*evmfuzz.Input, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case f.err != nil:
+		return nil, false
+	case !f.deadline.IsZero() && time.Now().After(f.deadline):
+		return nil, false
+	case f.iterations > 0 && f.execs >= f.iterations:
+		return nil, false
+	}
+	f.execs++
+	return f.corpus.inputs[rng.Intn(len(f.corpus.inputs))].Copy(), true
+}
+
+// record adds an input reaching new coverage to the corpus and reports the
+// first finding of every signature.
+func (f *fuzzer) record(in *evmfuzz.Input, outcome *evmfuzz.Outcome) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if finding := outcome.Finding; finding != nil {
+		f.signatures[finding.Signature]++
+		if f.signatures[finding.Signature] > 1 {
+			return nil
+		}
+		path, err := evmfuzz.WriteReproducer(f.findingsDir, finding)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("FINDING %s\n        %s\n        reproducer %s\n", finding.Signature, finding.Detail, path)
+		return nil
+	}
+	added := false
+	for _, feature := range coverage(outcome.Results[0].Steps) {
+		if !f.corpus.features[feature] {
+			f.corpus.features[feature] = true
+			added = true
+		}
+	}
+	if !added {
+		return nil
+	}
+	f.corpus.inputs = append(f.corpus.inputs, in)
+	if f.corpus.dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(f.corpus.dir, 0755); err != nil {
+		return err
+	}
+	data := in.Encode()
+	return os.WriteFile(filepath.Join(f.corpus.dir, fmt.Sprintf("%x", sha256.Sum256(data))), data, 0644)
+}
+
+func (f *fuzzer) fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err == nil {
+		f.err = err
+	}
+}
+
+func (f *fuzzer) printStats() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	findings := 0
+	for _, n := range f.signatures {
+		findings += n
+	}
+	fmt.Printf("%d inputs executed, corpus %d, coverage %d, %d findings with %d signatures\n", f.execs, len(f.corpus.inputs), len(f.corpus.features), findings, len(f.signatures))
+	if len(f.signatures) == 0 {
+		return
+	}
+	signatures := make([]string, 0, len(f.signatures))
+	for sig := range f.signatures {
+		signatures = append(signatures, sig)
+	}
+	sort.Strings(signatures)
+	for _, sig := range signatures {
+		fmt.Printf("%6d  %s\n", f.signatures[sig], sig)
+	}
+}
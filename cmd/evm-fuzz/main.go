@@ -0,0 +1,129 @@
+// evm-fuzz differentially fuzzes EVM implementations with the structure-aware
+// mutations of pkg/evmfuzz, without go-fuzz or libFuzzer.
+//
+// Usage:
+//
+//	go run ./cmd/evm-fuzz --backend go --backend eels --fork Prague --duration 1h
+//
+// Backends are given as go for go-ethereum in process, NAME for the statetest
+// command of a known client (eels, geth, besu) or NAME=COMMAND; the first one
+// is the reference the others are compared with. Every input is a program
+// and the gas, value and calldata of the transaction calling it, executed as
+// a state test on all backends. A difference of the post state roots or the
+// EIP-3155 traces is a finding.
+//
+// The fuzzer keeps a corpus of inputs, seeded from the raw inputs in
+// --corpus if given. Mutated inputs reaching a transition between two
+// opcodes, or an opcode failing with an error, that the reference trace did
+// not cover before are added to it and, with --corpus, saved there.
+//
+// The first finding of each signature (which backends diverge, at which
+// opcode and field) is written to --findings as a state test reproducing it,
+// with the post state computed by go-ethereum; later findings of the same
+// signature are counted. The fuzzer stops after --duration or --iterations
+// and exits with a nonzero code if anything was found.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/evmfuzz"
+	"github.com/ethereum/execution-specs/pkg/flags"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+func main() {
+	var (
+		backendDefs flags.Strings
+		fork        = flag.String("fork", "Prague", "fork the inputs are executed on")
+		jobs        = flag.Int("jobs", runtime.NumCPU(), "number of inputs executed in parallel")
+		duration    = flag.Duration("duration", 0, "stop after this duration (default unlimited)")
+		iterations  = flag.Int("iterations", 0, "stop after executing this many inputs (default unlimited)")
+		seed        = flag.Int64("seed", 0, "seed of the mutations (default random)")
+		corpusDir   = flag.String("corpus", "", "directory the corpus is loaded from and saved to")
+		findingsDir = flag.String("findings", "findings", "directory the reproducers are written to")
+		timeout     = flag.Duration("timeout", time.Minute, "timeout of a single backend invocation")
+	)
+	flag.Var(&backendDefs, "backend", "backend as go, NAME or NAME=COMMAND (repeatable, default go and eels)")
+	flag.Parse()
+
+	if len(backendDefs) == 0 {
+		backendDefs = flags.Strings{"go", "eels"}
+	}
+	if len(backendDefs) < 2 {
+		fatalf("at least two backends are needed")
+	}
+	var backends []evmfuzz.Backend
+	for _, def := range backendDefs {
+		b, err := evmfuzz.ParseBackend(def, *timeout)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		backends = append(backends, b)
+	}
+	if _, _, err := tests.GetChainConfig(*fork); err != nil {
+		fatalf("%v", err)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	c, err := loadCorpus(*corpusDir)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	f := &fuzzer{
+		fork:        *fork,
+		backends:    backends,
+		corpus:      c,
+		findingsDir: *findingsDir,
+		iterations:  *iterations,
+		signatures:  make(map[string]int),
+	}
+	if *duration > 0 {
+		f.deadline = time.Now().Add(*duration)
+	}
+	fmt.Printf("fuzzing %s on %s, seed %d, %d corpus inputs\n", *fork, backendDefs.String(), *seed, len(c.inputs))
+
+	var wg sync.WaitGroup
+	for i := 0; i < max(*jobs, 1); i++ {
+		wg.Add(1)
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			f.loop(rng)
+		}(rand.New(rand.NewSource(*seed + int64(i))))
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+wait:
+	for {
+		select {
+		case <-ticker.C:
+			f.printStats()
+		case <-done:
+			break wait
+		}
+	}
+	f.printStats()
+	if f.err != nil {
+		fatalf("%v", f.err)
+	}
+	if len(f.signatures) > 0 {
+		os.Exit(1)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// filledTest is an executable state test fixture.
+type filledTest struct {
+	Info        map[string]string       `json:"_info"`
+	Env         json.RawMessage         `json:"env"`
+	Pre         json.RawMessage         `json:"pre"`
+	Transaction json.RawMessage         `json:"transaction"`
+	Post        map[string][]*postState `json:"post"`
+}
+
+// postState is the expected outcome of one transaction variant on one fork.
+type postState struct {
+	Hash            common.Hash `json:"hash"`
+	Logs            common.Hash `json:"logs"`
+	Indexes         postIndexes `json:"indexes"`
+	ExpectException string      `json:"expectException,omitempty"`
+
+	expect *expectation
+}
+
+type postIndexes struct {
+	Data  int `json:"data"`
+	Gas   int `json:"gas"`
+	Value int `json:"value"`
+}
+
+// fillTest executes every transaction variant of a source test on each of the
+// given forks covered by its expectations, verifies the expectations and
+// records the resulting state roots and logs hashes.
+func fillTest(src *sourceTest, source string, forks []string) (*filledTest, error) {
+	var variants struct {
+		Data     []json.RawMessage `json:"data"`
+		GasLimit []json.RawMessage `json:"gasLimit"`
+		Value    []json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(src.Transaction, &variants); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %v", err)
+	}
+	filled := &filledTest{
+		Info:        statetest.Info("fill", source, ""),
+		Env:         src.Env,
+		Pre:         src.Pre,
+		Transaction: src.Transaction,
+		Post:        make(map[string][]*postState),
+	}
+	// Assign an expectation to every fork and variant, the first matching one
+	// wins. Variants without an expectation are not filled.
+	for _, fork := range forks {
+		for d := range variants.Data {
+			for g := range variants.GasLimit {
+				for v := range variants.Value {
+					expect, err := findExpectation(src.Expect, fork, d, g, v)
+					if err != nil {
+						return nil, err
+					}
+					if expect == nil {
+						continue
+					}
+					filled.Post[fork] = append(filled.Post[fork], &postState{
+						Indexes:         postIndexes{Data: d, Gas: g, Value: v},
+						ExpectException: expect.ExpectException,
+						expect:          expect,
+					})
+				}
+			}
+		}
+	}
+	if len(filled.Post) == 0 {
+		return nil, fmt.Errorf("no expectation covers the selected forks")
+	}
+	// Run the variants through the reference implementation.
+	data, err := json.Marshal(filled)
+	if err != nil {
+		return nil, err
+	}
+	var test tests.StateTest
+	if err := json.Unmarshal(data, &test); err != nil {
+		return nil, err
+	}
+	for fork, posts := range filled.Post {
+		for i, post := range posts {
+			if err := runVariant(&test, tests.StateSubtest{Fork: fork, Index: i}, post); err != nil {
+				return nil, fmt.Errorf("%s data %d gas %d value %d: %v", fork, post.Indexes.Data, post.Indexes.Gas, post.Indexes.Value, err)
+			}
+		}
+	}
+	return filled, nil
+}
+
+// findExpectation returns the first expectation applying to the variant.
+func findExpectation(expects []expectation, fork string, d, g, v int) (*expectation, error) {
+	for i := range expects {
+		expect := &expects[i]
+		covered := false
+		for _, network := range expect.Network {
+			forks, err := resolveForks(network)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range forks {
+				covered = covered || f == fork
+			}
+		}
+		if !covered {
+			continue
+		}
+		for _, index := range []struct {
+			selector interface{}
+			value    int
+		}{{expect.Indexes.Data, d}, {expect.Indexes.Gas, g}, {expect.Indexes.Value, v}} {
+			ok, err := matchesIndex(index.selector, index.value)
+			if err != nil {
+				return nil, err
+			}
+			covered = covered && ok
+		}
+		if covered {
+			return expect, nil
+		}
+	}
+	return nil, nil
+}
+
+// runVariant executes a single variant and fills in its post state. If the
+// transaction is rejected as expected, the post state is the pre-state.
+func runVariant(test *tests.StateTest, subtest tests.StateSubtest, post *postState) error {
+	st, root, _, err := test.RunNoVerify(subtest, vm.Config{}, false, rawdb.HashScheme)
+	defer st.Close()
+
+	switch {
+	case err != nil && post.ExpectException == "":
+		return fmt.Errorf("unexpected exception: %v", err)
+	case err == nil && post.ExpectException != "":
+		return fmt.Errorf("expected exception %s, transaction succeeded", post.ExpectException)
+	case err != nil:
+		config, _, _ := tests.GetChainConfig(subtest.Fork)
+		post.Hash = st.StateDB.IntermediateRoot(config.IsEIP158(new(big.Int)))
+		post.Logs = statetest.LogsHash(nil)
+		return nil
+	}
+	post.Hash = root
+	post.Logs = statetest.LogsHash(st.StateDB.Logs())
+
+	statedb, err := state.New(root, st.StateDB.Database())
+	if err != nil {
+		return err
+	}
+	return checkResult(statedb, post.expect.Result)
+}
+
+// checkResult verifies the expected account fields against the post-state.
+func checkResult(statedb *state.StateDB, result map[string]expectAccount) error {
+	for key, expect := range result {
+		if !common.IsHexAddress(key) {
+			return fmt.Errorf("invalid result address %q", key)
+		}
+		addr := common.HexToAddress(key)
+		if expect.ShouldNotExist {
+			if statedb.Exist(addr) {
+				return fmt.Errorf("account %s should not exist", addr.Hex())
+			}
+			continue
+		}
+		if expect.Balance != "" {
+			want, ok := math.ParseBig256(expect.Balance)
+			if !ok {
+				return fmt.Errorf("account %s: invalid expected balance %q", addr.Hex(), expect.Balance)
+			}
+			if have := statedb.GetBalance(addr).ToBig(); have.Cmp(want) != 0 {
+				return fmt.Errorf("account %s: balance %v, expected %v", addr.Hex(), have, want)
+			}
+		}
+		if expect.Nonce != "" {
+			want, ok := math.ParseUint64(expect.Nonce)
+			if !ok {
+				return fmt.Errorf("account %s: invalid expected nonce %q", addr.Hex(), expect.Nonce)
+			}
+			if have := statedb.GetNonce(addr); have != want {
+				return fmt.Errorf("account %s: nonce %d, expected %d", addr.Hex(), have, want)
+			}
+		}
+		if expect.Code != "" {
+			want, err := hexutil.Decode(expect.Code)
+			if err != nil {
+				return fmt.Errorf("account %s: invalid expected code: %v", addr.Hex(), err)
+			}
+			if have := statedb.GetCode(addr); !bytes.Equal(have, want) {
+				return fmt.Errorf("account %s: code %x, expected %x", addr.Hex(), have, want)
+			}
+		}
+		for slot, value := range expect.Storage {
+			k, ok1 := math.ParseBig256(slot)
+			v, ok2 := math.ParseBig256(value)
+			if !ok1 || !ok2 {
+				return fmt.Errorf("account %s: invalid expected storage %q: %q", addr.Hex(), slot, value)
+			}
+			want := common.BigToHash(v)
+			if have := statedb.GetState(addr, common.BigToHash(k)); have != want {
+				return fmt.Errorf("account %s: storage slot %s is %s, expected %s", addr.Hex(), common.BigToHash(k).Hex(), have.Hex(), want.Hex())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// fill fills state test definitions into executable state test fixtures,
+// using go-ethereum as the reference implementation.
+//
+// Usage:
+//
+//	go run ./cmd/fill [--forks ">=Shanghai"] [--output filled] tests/*.json
+//
+// A source test consists of the env, pre and transaction sections in the
+// fixture encoding (code given as hex) and a list of expectations:
+//
+//	"expect": [{
+//	    "indexes": {"data": -1, "gas": 0, "value": [0, 1]},
+//	    "network": [">=Berlin<Cancun", "Prague"],
+//	    "result": {"0x...": {"balance": "0x10", "storage": {"0x00": "0x01"}}},
+//	    "expectException": "..."
+//	}]
+//
+// Every combination of the transaction's data, gasLimit and value variants
+// is executed on each fork the first matching expectation applies to. The
+// expected account fields (balance, nonce, code, listed storage slots or
+// shouldnotexist) are verified, and the post state root and logs hash are
+// recorded in the post section of the fixture. Source files may also be
+// written in YAML.
+//
+// Each input file is written to the output directory under its own name with
+// a .json extension.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	var (
+		forkSpec = flag.String("forks", ">=Frontier", "forks to fill, e.g. Cancun or >=Berlin<Prague")
+		output   = flag.String("output", "filled", "directory the fixtures are written to")
+	)
+	flag.Parse()
+	if flag.NArg() == 0 {
+		fatalf("no source tests given")
+	}
+	forks, err := resolveForks(*forkSpec)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.MkdirAll(*output, 0755); err != nil {
+		fatalf("%v", err)
+	}
+	for _, path := range flag.Args() {
+		if err := fillFile(path, *output, forks); err != nil {
+			fatalf("%v", err)
+		}
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// fillFile fills all tests of a source file and writes the fixtures.
+func fillFile(path, output string, forks []string) error {
+	sources, err := loadSources(path)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fixtures := make(map[string]*filledTest, len(sources))
+	for _, name := range names {
+		filled, err := fillTest(sources[name], path, forks)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %v", path, name, err)
+		}
+		fixtures[name] = filled
+		variants := 0
+		for _, posts := range filled.Post {
+			variants += len(posts)
+		}
+		fmt.Printf("Filled %s: %d variants on %d forks\n", name, variants, len(filled.Post))
+	}
+	data, err := json.MarshalIndent(fixtures, "", "    ")
+	if err != nil {
+		return err
+	}
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return os.WriteFile(filepath.Join(output, base+".json"), append(data, '\n'), 0644)
+}
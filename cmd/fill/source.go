@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/tests"
+	"gopkg.in/yaml.v3"
+)
+
+// fillForks lists the forks tests are filled for, in activation order, using
+// the go-ethereum test fork names. Forks which only changed the difficulty
+// bomb are not listed since they don't affect state tests.
+var fillForks = []string{
+	"Frontier", "Homestead", "EIP150", "EIP158", "Byzantium", "Constantinople",
+	"ConstantinopleFix", "Istanbul", "Berlin", "London", "Paris", "Shanghai",
+	"Cancun", "Prague", "Osaka",
+}
+
+// forkAliases maps the execution specs fork names (and other common
+// spellings) onto the go-ethereum test fork names.
+var forkAliases = map[string]string{
+	"tangerinewhistle": "EIP150",
+	"spuriousdragon":   "EIP158",
+	"petersburg":       "ConstantinopleFix",
+	"merge":            "Paris",
+}
+
+// sourceTest is a state test definition to be filled. The environment,
+// pre-state and transaction use the fixture encoding and are copied into the
+// filled test verbatim.
+type sourceTest struct {
+	Env         json.RawMessage `json:"env"`
+	Pre         json.RawMessage `json:"pre"`
+	Transaction json.RawMessage `json:"transaction"`
+	Expect      []expectation   `json:"expect"`
+}
+
+// expectation selects a set of forks and transaction variants and describes
+// the post-state they must produce.
+type expectation struct {
+	Indexes         expectIndexes            `json:"indexes"`
+	Network         []string                 `json:"network"`
+	Result          map[string]expectAccount `json:"result"`
+	ExpectException string                   `json:"expectException"`
+}
+
+// expectIndexes selects the data, gas and value variants of the transaction
+// an expectation applies to. Each may be a single index, a list of indexes,
+// or -1 (the default) for all of them.
+type expectIndexes struct {
+	Data  interface{} `json:"data"`
+	Gas   interface{} `json:"gas"`
+	Value interface{} `json:"value"`
+}
+
+// expectAccount lists the expected fields of a post-state account. Fields
+// left empty and storage slots not listed are not checked.
+type expectAccount struct {
+	Balance        string            `json:"balance"`
+	Nonce          string            `json:"nonce"`
+	Code           string            `json:"code"`
+	Storage        map[string]string `json:"storage"`
+	ShouldNotExist bool              `json:"shouldnotexist"`
+}
+
+// loadSources reads a file of source tests. YAML files are accepted as well;
+// their integers are converted to hex strings, the encoding fixtures use.
+func loadSources(path string) (map[string]*sourceTest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yml" || ext == ".yaml" {
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if data, err = json.Marshal(hexIntegers(doc)); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	var sources map[string]*sourceTest
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return sources, nil
+}
+
+// hexIntegers replaces the integers of a decoded YAML document with their hex
+// encoding.
+func hexIntegers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = hexIntegers(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = hexIntegers(value)
+		}
+	case int:
+		if v >= 0 {
+			return hexutil.EncodeUint64(uint64(v))
+		}
+	}
+	return v
+}
+
+var forkConstraint = regexp.MustCompile(`(>=|<=|>|<)?([A-Za-z0-9]+)`)
+
+// resolveForks returns the forks matched by a network specification such as
+// "Cancun", ">=Berlin" or ">=Berlin<Cancun", in activation order.
+func resolveForks(spec string) ([]string, error) {
+	spec = strings.ReplaceAll(spec, " ", "")
+	matches := forkConstraint.FindAllStringSubmatch(spec, -1)
+	if len(matches) == 0 || strings.Join(flatten(matches), "") != spec {
+		return nil, fmt.Errorf("invalid network %q", spec)
+	}
+	var forks []string
+	for i, fork := range fillForks {
+		matched := true
+		for _, m := range matches {
+			index, err := forkIndex(m[2])
+			if err != nil {
+				return nil, err
+			}
+			switch m[1] {
+			case "":
+				matched = matched && i == index
+			case ">=":
+				matched = matched && i >= index
+			case ">":
+				matched = matched && i > index
+			case "<=":
+				matched = matched && i <= index
+			case "<":
+				matched = matched && i < index
+			}
+		}
+		if matched {
+			forks = append(forks, fork)
+		}
+	}
+	return forks, nil
+}
+
+func flatten(matches [][]string) []string {
+	parts := make([]string, len(matches))
+	for i, m := range matches {
+		parts[i] = m[0]
+	}
+	return parts
+}
+
+// forkIndex resolves a fork name to its position in fillForks.
+func forkIndex(name string) (int, error) {
+	if alias, ok := forkAliases[strings.ToLower(name)]; ok {
+		name = alias
+	}
+	for i, fork := range fillForks {
+		if strings.EqualFold(fork, name) {
+			if _, ok := tests.Forks[fork]; !ok {
+				return 0, fmt.Errorf("fork %s is not supported by the reference implementation", fork)
+			}
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown fork %q, supported forks: %s", name, strings.Join(fillForks, ", "))
+}
+
+// matchesIndex reports whether the index selector accepts index i.
+func matchesIndex(selector interface{}, i int) (bool, error) {
+	switch s := selector.(type) {
+	case nil:
+		return true, nil
+	case float64:
+		return s == -1 || int(s) == i, nil
+	case string:
+		n, ok := math.ParseUint64(s)
+		if !ok {
+			return s == "-1", nil
+		}
+		return int(n) == i, nil
+	case []interface{}:
+		for _, item := range s {
+			if ok, err := matchesIndex(item, i); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid index selector %v", selector)
+}
@@ -0,0 +1,128 @@
+// fuzz-run executes a corpus of generated state tests on several client
+// statetest runners and triages the failures.
+//
+// Usage:
+//
+//	go run ./cmd/fuzz-run --runner eels --runner geth --jobs 8 corpus/
+//
+// Runners are given as NAME=COMMAND, or just NAME for the known clients (eels,
+// geth, besu) to use their default command. Each command is invoked with the
+// test file appended and must print its results as JSON on stdout and, with
+// tracing enabled, EIP-3155 traces on stderr.
+//
+// Directories are searched for .json files recursively. With --shard K/N only
+// every N-th file starting at the K-th one (counting from zero) is run, which
+// splits a corpus across machines; --jobs controls the parallelism on each.
+//
+// For every file the post state roots of the runners are compared. Failing
+// files are assigned a signature describing the failure (which runners
+// disagree and at which opcode and field their traces first diverge), and
+// failures sharing a signature are reported together. The triage report is
+// written as JSON to --report and summarized on stdout.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/fixtures"
+	"github.com/ethereum/execution-specs/pkg/flags"
+)
+
+func main() {
+	var (
+		runnerDefs flags.Strings
+		jobs       = flag.Int("jobs", runtime.NumCPU(), "number of test files run in parallel")
+		shard      = flag.String("shard", "0/1", "run the K-th of N shards of the corpus, as K/N")
+		timeout    = flag.Duration("timeout", time.Minute, "timeout of a single runner invocation")
+		reportPath = flag.String("report", "triage.json", "path of the triage report")
+		examples   = flag.Int("examples", 5, "number of example files listed per signature")
+	)
+	flag.Var(&runnerDefs, "runner", "statetest runner as NAME or NAME=COMMAND (repeatable, default eels and geth)")
+	flag.Parse()
+
+	if len(runnerDefs) == 0 {
+		runnerDefs = flags.Strings{"eels", "geth"}
+	}
+	var runners []runner
+	for _, def := range runnerDefs {
+		r, err := parseRunner(def)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		runners = append(runners, r)
+	}
+	var k, n int
+	if _, err := fmt.Sscanf(*shard, "%d/%d", &k, &n); err != nil || n <= 0 || k < 0 || k >= n {
+		fatalf("invalid shard %q, want K/N with 0 <= K < N", *shard)
+	}
+	if flag.NArg() == 0 {
+		fatalf("no test files or directories given")
+	}
+	files, err := fixtures.Collect(flag.Args())
+	if err != nil {
+		fatalf("%v", err)
+	}
+	var sharded []string
+	for i, file := range files {
+		if i%n == k {
+			sharded = append(sharded, file)
+		}
+	}
+	results := runCorpus(sharded, runners, max(*jobs, 1), *timeout)
+	report := buildReport(runners, results, *examples)
+
+	data, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*reportPath, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("%d tests, %d passed, %d failed, %d distinct signatures\n", report.Tests, report.Passed, report.Failed, len(report.Signatures))
+	for _, sig := range report.Signatures {
+		fmt.Printf("%6d  %s\n        %s\n        e.g. %s\n", sig.Count, sig.Signature, sig.Detail, sig.Examples[0])
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// runCorpus runs every file on all runners using the given number of workers
+// and returns the triaged results in file order.
+func runCorpus(files []string, runners []runner, jobs int, timeout time.Duration) []testReport {
+	var (
+		results = make([]testReport, len(files))
+		next    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range next {
+				outcomes := make([]*runOutcome, len(runners))
+				for j, r := range runners {
+					outcomes[j] = r.run(files[index], timeout)
+				}
+				results[index] = triage(files[index], outcomes)
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/trace"
+)
+
+// defaultRunners are the statetest commands of the known clients. All of them
+// follow the goevmlab conventions: results on stdout, EIP-3155 traces on
+// stderr when invoked with --json.
+var defaultRunners = map[string]string{
+	"eels": "ethereum-spec-evm statetest --json",
+	"geth": "evm statetest --json",
+	"besu": "evmtool state-test --json",
+}
+
+// runner is a client statetest command.
+type runner struct {
+	name    string
+	command []string
+}
+
+// parseRunner parses a NAME or NAME=COMMAND runner definition.
+func parseRunner(def string) (runner, error) {
+	name, command, ok := strings.Cut(def, "=")
+	if !ok {
+		command, ok = defaultRunners[name]
+		if !ok {
+			return runner{}, fmt.Errorf("unknown runner %q, give its command as %s=COMMAND", name, name)
+		}
+	}
+	fields := strings.Fields(command)
+	if name == "" || len(fields) == 0 {
+		return runner{}, fmt.Errorf("invalid runner %q", def)
+	}
+	return runner{name: name, command: fields}, nil
+}
+
+// caseResult is the outcome of a single test case as reported by a runner.
+type caseResult struct {
+	Name      string `json:"name"`
+	Test      string `json:"test"` // besu names the test case here
+	Fork      string `json:"fork"`
+	Pass      bool   `json:"pass"`
+	StateRoot string `json:"stateRoot"`
+	PostHash  string `json:"postHash"` // besu reports the state root here
+	Error     string `json:"error"`
+}
+
+func (r caseResult) key() string {
+	name := r.Name
+	if name == "" {
+		name = r.Test
+	}
+	return name + "/" + r.Fork
+}
+
+func (r caseResult) root() string {
+	if r.StateRoot != "" {
+		return strings.ToLower(r.StateRoot)
+	}
+	return strings.ToLower(r.PostHash)
+}
+
+// runOutcome is everything a runner reported for one test file.
+type runOutcome struct {
+	Runner  string
+	Failed  string // set if the runner itself failed
+	Results []caseResult
+	Steps   []trace.Step
+}
+
+// passed reports whether the runner executed the file and all of its cases
+// matched the expected post state.
+func (o *runOutcome) passed() bool {
+	if o.Failed != "" || len(o.Results) == 0 {
+		return false
+	}
+	for _, r := range o.Results {
+		if !r.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// roots returns the reported state roots by test case.
+func (o *runOutcome) roots() map[string]string {
+	roots := make(map[string]string, len(o.Results))
+	for _, r := range o.Results {
+		roots[r.key()] = r.root()
+	}
+	return roots
+}
+
+// run executes the runner on a test file.
+func (r runner) run(path string, timeout time.Duration) *runOutcome {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.command[0], append(r.command[1:], path)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	outcome := &runOutcome{Runner: r.name}
+	err := cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		outcome.Failed = "timeout"
+	case err != nil:
+		outcome.Failed = fmt.Sprintf("%v: %s", err, lastLine(stderr.Bytes()))
+	}
+	parseOutput(outcome, &stdout)
+	parseOutput(outcome, &stderr)
+	return outcome
+}
+
+// parseOutput collects the results and trace steps from a runner output.
+// Results are either JSON arrays (possibly spanning several lines) or single
+// line objects carrying a pass field, trace steps are objects with an op
+// field. Log lines and other noise are skipped.
+func parseOutput(outcome *runOutcome, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+
+	var pending []byte // multi line result array being assembled
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(pending) > 0 || bytes.HasPrefix(line, []byte("[")) {
+			pending = append(pending, line...)
+			var results []caseResult
+			if json.Unmarshal(pending, &results) == nil {
+				outcome.Results = append(outcome.Results, results...)
+				pending = nil
+			}
+			continue
+		}
+		if !bytes.HasPrefix(line, []byte("{")) {
+			continue
+		}
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(line, &fields) != nil {
+			continue
+		}
+		switch {
+		case fields["op"] != nil:
+			if step, err := trace.ParseStep(line); err == nil {
+				outcome.Steps = append(outcome.Steps, step)
+			}
+		case fields["pass"] != nil:
+			var result caseResult
+			if json.Unmarshal(line, &result) == nil {
+				outcome.Results = append(outcome.Results, result)
+			}
+		}
+	}
+}
+
+func lastLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return lines[len(lines)-1]
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/trace"
+)
+
+// testReport is the triaged outcome of a test file across all runners.
+type testReport struct {
+	File      string          `json:"file"`
+	Pass      map[string]bool `json:"pass"`
+	Signature string          `json:"signature,omitempty"`
+	Detail    string          `json:"detail,omitempty"`
+}
+
+// signatureReport groups the failing test files sharing a divergence
+// signature.
+type signatureReport struct {
+	Signature string   `json:"signature"`
+	Count     int      `json:"count"`
+	Detail    string   `json:"detail"` // detail of the first example
+	Examples  []string `json:"examples"`
+}
+
+// triageReport is the report written at the end of a fuzzing run.
+type triageReport struct {
+	Runners    []string          `json:"runners"`
+	Tests      int               `json:"tests"`
+	Passed     int               `json:"passed"`
+	Failed     int               `json:"failed"`
+	Signatures []signatureReport `json:"signatures"`
+	Results    []testReport      `json:"results"`
+}
+
+// triage classifies the outcomes of a test file. The signature identifies the
+// kind of failure independently of the test, so that failures caused by the
+// same bug collapse into one entry of the report:
+//
+//   - "error <runners>" if runners crashed or timed out,
+//   - "<partition> at <op> <field>" if the post states differ, naming how the
+//     runners split up and where the first two disagreeing traces diverge,
+//   - "<partition> at stateRoot" if the post states differ with equal traces,
+//   - "expected <runners>" if all runners agree but not with the test.
+func triage(file string, outcomes []*runOutcome) testReport {
+	report := testReport{File: file, Pass: make(map[string]bool)}
+	var failed, ran []*runOutcome
+	for _, o := range outcomes {
+		report.Pass[o.Runner] = o.passed()
+		if o.Failed != "" {
+			failed = append(failed, o)
+		} else {
+			ran = append(ran, o)
+		}
+	}
+	if len(failed) > 0 {
+		report.Signature = "error " + runnerNames(failed)
+		report.Detail = fmt.Sprintf("%s: %s", failed[0].Runner, failed[0].Failed)
+		return report
+	}
+	// Partition the runners by the state roots they computed.
+	var groups [][]*runOutcome
+	for _, o := range ran {
+		placed := false
+		for i, group := range groups {
+			if sameRoots(group[0].roots(), o.roots()) {
+				groups[i] = append(group, o)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			groups = append(groups, []*runOutcome{o})
+		}
+	}
+	if len(groups) == 1 {
+		var wrong []*runOutcome
+		for _, o := range ran {
+			if !o.passed() {
+				wrong = append(wrong, o)
+			}
+		}
+		if len(wrong) > 0 {
+			report.Signature = "expected " + runnerNames(wrong)
+			report.Detail = firstError(wrong[0])
+		}
+		return report
+	}
+	partition := make([]string, len(groups))
+	for i, group := range groups {
+		partition[i] = runnerNames(group)
+	}
+	sort.Strings(partition)
+	signature := strings.Join(partition, " vs ")
+
+	a, b := groups[0][0], groups[1][0]
+	d := trace.Diff(a.Steps, b.Steps)
+	if d == nil {
+		report.Signature = signature + " at stateRoot"
+		report.Detail = fmt.Sprintf("%s and %s traces match, post states differ", a.Runner, b.Runner)
+		return report
+	}
+	op, aValue, bValue := "end", "end of trace", "end of trace"
+	if d.Index < len(a.Steps) {
+		op, aValue = a.Steps[d.Index].Name(), a.Steps[d.Index].Field(d.Field)
+	}
+	if d.Index < len(b.Steps) {
+		bValue = b.Steps[d.Index].Field(d.Field)
+		if op == "end" {
+			op = b.Steps[d.Index].Name()
+		}
+	}
+	report.Signature = fmt.Sprintf("%s at %s %s", signature, op, d.Field)
+	report.Detail = fmt.Sprintf("%s and %s diverge at step %d (%s): %s vs %s", a.Runner, b.Runner, d.Index, op, aValue, bValue)
+	return report
+}
+
+// normalizeHex strips leading zeros of a hex number, so that differently
+// padded encodings compare equal.
+func normalizeHex(s string) string {
+	s = strings.TrimLeft(strings.TrimPrefix(strings.ToLower(s), "0x"), "0")
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+func sameRoots(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, root := range a {
+		if normalizeHex(b[key]) != normalizeHex(root) {
+			return false
+		}
+	}
+	return true
+}
+
+func runnerNames(outcomes []*runOutcome) string {
+	names := make([]string, len(outcomes))
+	for i, o := range outcomes {
+		names[i] = o.Runner
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func firstError(o *runOutcome) string {
+	for _, r := range o.Results {
+		if !r.Pass {
+			return fmt.Sprintf("%s: %s: %s", o.Runner, r.key(), r.Error)
+		}
+	}
+	return o.Runner + ": no results"
+}
+
+// buildReport aggregates the per file reports, deduplicating failures by
+// signature. Signatures are ordered by decreasing frequency.
+func buildReport(runners []runner, results []testReport, examples int) *triageReport {
+	report := &triageReport{Tests: len(results), Results: results}
+	for _, r := range runners {
+		report.Runners = append(report.Runners, r.name)
+	}
+	index := make(map[string]int)
+	for _, result := range results {
+		if result.Signature == "" {
+			report.Passed++
+			continue
+		}
+		report.Failed++
+		i, ok := index[result.Signature]
+		if !ok {
+			i = len(report.Signatures)
+			index[result.Signature] = i
+			report.Signatures = append(report.Signatures, signatureReport{Signature: result.Signature, Detail: result.Detail})
+		}
+		sig := &report.Signatures[i]
+		sig.Count++
+		if len(sig.Examples) < examples {
+			sig.Examples = append(sig.Examples, result.File)
+		}
+	}
+	sort.SliceStable(report.Signatures, func(i, j int) bool {
+		return report.Signatures[i].Count > report.Signatures[j].Count
+	})
+	return report
+}
@@ -0,0 +1,316 @@
+// gas-estimate compares the eth_estimateGas answers of clients with the
+// minimal gas limit of every transaction of a corpus, found by binary
+// searching its reference execution, and writes the minimal gas limits as
+// estimation vectors.
+//
+// Usage:
+//
+//	go run ./cmd/gas-estimate --genesis genesis.json --txs txs.json [--client go] [--client nethermind=http://localhost:8545] [--output vectors.json]
+//
+// The corpus is a JSON list of eth_estimateGas transaction objects with the
+// from, to, gas, gasPrice, value, data or input and accessList fields. Every
+// transaction is executed with go-ethereum on top of the genesis state, in the
+// context of the genesis block, once with the highest allowed gas limit and
+// then at the midpoints of a binary search down to the smallest gas limit at
+// which it succeeds. Transactions failing at the highest limit, a revert, an
+// error or running out of gas, must be answered with an error. The minimum is
+// exact: a transaction tolerating a failed inner call, such as one forwarding
+// all its gas, succeeds with less gas than it uses when given plenty, which
+// clients bounding their search by the used gas overestimate.
+//
+// A client is "go" for an in-process go-ethereum node started from the genesis
+// or NAME=URL for the JSON-RPC endpoint of a client whose latest block is the
+// genesis block. Without --client the go node is compared. An estimate above
+// the minimum is an overestimation, reported if it exceeds the minimum by more
+// than the --tolerance fraction of the estimate: go-ethereum stops its search
+// within 1.5%. An estimate below the minimum is an underestimation, reported
+// unless the transaction succeeds with it after all, which only a transaction
+// whose success is not monotonic in the gas limit does.
+//
+// With --output the corpus is written with the reference estimate of every
+// transaction, its minimal gas limit and the gas used with it or the expected
+// error. The tool exits with a nonzero code if any client misestimates or
+// fails.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/flags"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// vector is an estimation vector, a transaction of the corpus with its
+// reference estimate.
+type vector struct {
+	Transaction *callArgs `json:"transaction"`
+	Expected    *estimate `json:"expected"`
+}
+
+// vectorFile is the output of the tool: the genesis the vectors hold for and
+// the vectors.
+type vectorFile struct {
+	GenesisHash common.Hash `json:"genesisHash"`
+	StateRoot   common.Hash `json:"stateRoot"`
+	Vectors     []*vector   `json:"vectors"`
+}
+
+// client is a JSON-RPC endpoint answering eth_estimateGas.
+type client struct {
+	name  string
+	rpc   *rpc.Client
+	close func()
+}
+
+func main() {
+	var (
+		clients     flags.Strings
+		genesisFile = flag.String("genesis", "", "genesis file of the state the transactions run on (required)")
+		txsFile     = flag.String("txs", "", "JSON list of the transactions (required)")
+		output      = flag.String("output", "", "file the estimation vectors are written to")
+		tolerance   = flag.Float64("tolerance", 0.015, "overestimation allowed, as a fraction of the estimate")
+		timeout     = flag.Duration("timeout", time.Minute, "timeout of a single eth_estimateGas request")
+	)
+	flag.Var(&clients, "client", "client to compare: go or NAME=URL (repeatable)")
+	flag.Parse()
+	if *genesisFile == "" || *txsFile == "" {
+		fatalf("--genesis and --txs are required")
+	}
+	if len(clients) == 0 {
+		clients = flags.Strings{"go"}
+	}
+	genesis, err := gen.Load(*genesisFile)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	txs, err := loadTransactions(*txsFile)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	ref, err := newReference(genesis)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	defer ref.close()
+	head := ref.chain.CurrentBlock()
+
+	var endpoints []*client
+	for _, def := range clients {
+		c, err := dialClient(def, genesis, head.Hash())
+		if err != nil {
+			fatalf("%v", err)
+		}
+		defer c.close()
+		endpoints = append(endpoints, c)
+	}
+
+	file := &vectorFile{GenesisHash: head.Hash(), StateRoot: head.Root}
+	var diverged bool
+	for i, tx := range txs {
+		want, err := ref.estimate(tx)
+		if err != nil {
+			fatalf("transaction %d: %v", i, err)
+		}
+		file.Vectors = append(file.Vectors, &vector{Transaction: tx, Expected: want})
+		fmt.Printf("transaction %d: %s\n", i, describe(tx))
+		if want.Error != "" {
+			fmt.Printf("    %-10s      error: %s\n", "ref", want.Error)
+		} else {
+			fmt.Printf("    %-10s      %d, using %d\n", "ref", want.Gas, want.GasUsed)
+		}
+		for _, c := range endpoints {
+			have, err := c.estimateGas(tx, *timeout)
+			result, ok := compareEstimate(ref, tx, want, have, err, *tolerance)
+			fmt.Printf("    %-10s %s\n", c.name, result)
+			diverged = diverged || !ok
+		}
+	}
+	if *output != "" {
+		data, err := json.MarshalIndent(file, "", "    ")
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+			fatalf("%v", err)
+		}
+		fmt.Printf("Wrote %d estimation vectors to %s\n", len(file.Vectors), *output)
+	}
+	if diverged {
+		os.Exit(1)
+	}
+}
+
+// loadTransactions reads the transaction corpus. Unknown fields are rejected,
+// as a client may use them while the reference would ignore them.
+func loadTransactions(path string) ([]*callArgs, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%s: no transactions", path)
+	}
+	txs := make([]*callArgs, len(raw))
+	for i, enc := range raw {
+		dec := json.NewDecoder(bytes.NewReader(enc))
+		dec.DisallowUnknownFields()
+		tx := new(callArgs)
+		if err := dec.Decode(tx); err != nil {
+			return nil, fmt.Errorf("%s: transaction %d: %v", path, i, err)
+		}
+		if tx.Data != nil && tx.Input != nil && !bytes.Equal(*tx.Data, *tx.Input) {
+			return nil, fmt.Errorf("%s: transaction %d: both data and input given, with different values", path, i)
+		}
+		txs[i] = tx
+	}
+	return txs, nil
+}
+
+// compareEstimate compares the answer of a client with the reference
+// estimate. It returns the result line and whether the answer is correct.
+func compareEstimate(ref *reference, tx *callArgs, want *estimate, have uint64, err error, tolerance float64) (string, bool) {
+	switch {
+	case want.Error != "" && err != nil:
+		return fmt.Sprintf("ok   error: %v", err), true
+	case want.Error != "":
+		return fmt.Sprintf("DIFF %d, expected error: %s", have, want.Error), false
+	case err != nil:
+		return fmt.Sprintf("FAIL %v", err), false
+	}
+	expected := uint64(want.Gas)
+	switch {
+	case have == expected:
+		return fmt.Sprintf("ok   %d", have), true
+	case have > expected:
+		over := have - expected
+		line := fmt.Sprintf("%d (+%d, %.2f%%)", have, over, 100*float64(over)/float64(have))
+		if float64(over) <= tolerance*float64(have) {
+			return "ok   " + line, true
+		}
+		return "OVER " + line, false
+	default:
+		line := fmt.Sprintf("%d (-%d)", have, expected-have)
+		if ref.succeeds(tx, have) {
+			return "ok   " + line + ", succeeds with the estimate", true
+		}
+		return "UNDER " + line, false
+	}
+}
+
+// describe summarizes a transaction for the report.
+func describe(tx *callArgs) string {
+	from, to := "0x0", "create"
+	if tx.From != nil {
+		from = tx.From.Hex()
+	}
+	if tx.To != nil {
+		to = tx.To.Hex()
+	}
+	var data []byte
+	switch {
+	case tx.Input != nil:
+		data = *tx.Input
+	case tx.Data != nil:
+		data = *tx.Data
+	}
+	return fmt.Sprintf("%s -> %s, %d bytes of data", from, to, len(data))
+}
+
+// dialClient connects to a client and checks that its latest block is the
+// genesis block, whose state the reference estimates against.
+func dialClient(def string, genesis *core.Genesis, genesisHash common.Hash) (*client, error) {
+	name, url, ok := strings.Cut(def, "=")
+	var c *client
+	switch {
+	case !ok && name == "go":
+		stack, err := startNode(genesis)
+		if err != nil {
+			return nil, fmt.Errorf("go: %v", err)
+		}
+		rpcClient := stack.Attach()
+		c = &client{name: name, rpc: rpcClient, close: func() { rpcClient.Close(); stack.Close() }}
+	case !ok || name == "" || url == "":
+		return nil, fmt.Errorf("invalid client %q, want go or NAME=URL", def)
+	default:
+		rpcClient, err := rpc.Dial(url)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		c = &client{name: name, rpc: rpcClient, close: rpcClient.Close}
+	}
+	var head *types.Header
+	if err := c.rpc.Call(&head, "eth_getBlockByNumber", "latest", false); err != nil {
+		c.close()
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if head == nil || head.Hash() != genesisHash {
+		c.close()
+		return nil, fmt.Errorf("%s: latest block is not the genesis block %s", name, genesisHash.Hex())
+	}
+	return c, nil
+}
+
+// startNode starts an in-process go-ethereum node from the genesis.
+func startNode(genesis *core.Genesis) (*node.Node, error) {
+	stack, err := node.New(&node.Config{P2P: p2p.Config{NoDiscovery: true, NoDial: true}})
+	if err != nil {
+		return nil, err
+	}
+	_, err = eth.New(stack, &ethconfig.Config{
+		Genesis:        genesis,
+		SyncMode:       ethconfig.FullSync,
+		TrieTimeout:    time.Minute,
+		TrieDirtyCache: 256,
+		TrieCleanCache: 256,
+		Miner:          miner.DefaultConfig,
+		RPCGasCap:      ethconfig.Defaults.RPCGasCap,
+	})
+	if err == nil {
+		err = stack.Start()
+	}
+	if err != nil {
+		stack.Close()
+		return nil, err
+	}
+	return stack, nil
+}
+
+// estimateGas requests the gas estimate of the transaction against the
+// latest block.
+func (c *client) estimateGas(tx *callArgs, timeout time.Duration) (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var gas hexutil.Uint64
+	err := c.rpc.CallContext(ctx, &gas, "eth_estimateGas", tx, "latest")
+	if errors.Is(err, context.DeadlineExceeded) {
+		return 0, fmt.Errorf("timed out after %v", timeout)
+	}
+	return uint64(gas), err
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// callArgs are the supported fields of the eth_estimateGas transaction
+// object. A gas limit caps the search instead of the block gas limit.
+type callArgs struct {
+	From       *common.Address   `json:"from,omitempty"`
+	To         *common.Address   `json:"to,omitempty"`
+	Gas        *hexutil.Uint64   `json:"gas,omitempty"`
+	GasPrice   *hexutil.Big      `json:"gasPrice,omitempty"`
+	Value      *hexutil.Big      `json:"value,omitempty"`
+	Data       *hexutil.Bytes    `json:"data,omitempty"`
+	Input      *hexutil.Bytes    `json:"input,omitempty"`
+	AccessList *types.AccessList `json:"accessList,omitempty"`
+}
+
+// estimate is the reference answer to an eth_estimateGas request: the
+// minimal gas limit and the gas the transaction uses with it, or the error a
+// client must reply with if the transaction fails at every allowed limit.
+type estimate struct {
+	Gas        hexutil.Uint64 `json:"gas,omitempty"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	RevertData hexutil.Bytes  `json:"revertData,omitempty"`
+}
+
+// reference executes transactions with go-ethereum on top of the genesis
+// state, in the context of the genesis block, as eth_estimateGas does
+// against the latest block of a chain at its genesis.
+type reference struct {
+	chain *core.BlockChain
+}
+
+func newReference(genesis *core.Genesis) (*reference, error) {
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), genesis, beacon.New(ethash.NewFaker()), core.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	return &reference{chain: chain}, nil
+}
+
+func (r *reference) close() {
+	r.chain.Stop()
+}
+
+// estimate binary searches the smallest gas limit at which the transaction
+// succeeds, assuming that success is monotonic in the gas limit, the
+// assumption every client estimator makes too. Unlike the client estimators
+// it takes no shortcuts and allows no error: it starts from a limit of zero,
+// which always fails, and the highest allowed one. That is the gas of the
+// transaction if given and the block gas limit otherwise, capped by the
+// EIP-7825 transaction gas limit from Osaka and by the gas the sender can pay
+// for at the gas price.
+func (r *reference) estimate(call *callArgs) (*estimate, error) {
+	head := r.chain.CurrentBlock()
+	hi := head.GasLimit
+	if call.Gas != nil && uint64(*call.Gas) >= params.TxGas {
+		hi = uint64(*call.Gas)
+	}
+	if r.chain.Config().IsOsaka(head.Number, head.Time) && hi > params.MaxTxGas {
+		hi = params.MaxTxGas
+	}
+	if call.GasPrice != nil && call.GasPrice.ToInt().Sign() > 0 {
+		statedb, err := r.chain.StateAt(head.Root)
+		if err != nil {
+			return nil, err
+		}
+		available := new(big.Int)
+		if call.From != nil {
+			available = statedb.GetBalance(*call.From).ToBig()
+		}
+		if call.Value != nil {
+			if call.Value.ToInt().Cmp(available) >= 0 {
+				return &estimate{Error: core.ErrInsufficientFundsForTransfer.Error()}, nil
+			}
+			available.Sub(available, call.Value.ToInt())
+		}
+		allowance := available.Div(available, call.GasPrice.ToInt())
+		if allowance.IsUint64() && allowance.Uint64() < hi {
+			hi = allowance.Uint64()
+		}
+	}
+
+	result, err := r.execute(call, hi)
+	switch {
+	case errors.Is(err, core.ErrIntrinsicGas) || errors.Is(err, core.ErrFloorDataGas):
+		return &estimate{Error: fmt.Sprintf("gas required exceeds allowance (%d)", hi)}, nil
+	case err != nil:
+		return &estimate{Error: err.Error()}, nil
+	case errors.Is(result.Err, vm.ErrExecutionReverted):
+		message := result.Err.Error()
+		if reason, err := abi.UnpackRevert(result.Revert()); err == nil {
+			message += ": " + reason
+		}
+		return &estimate{Error: message, RevertData: result.Revert()}, nil
+	case errors.Is(result.Err, vm.ErrOutOfGas):
+		return &estimate{Error: fmt.Sprintf("gas required exceeds allowance (%d)", hi)}, nil
+	case result.Err != nil:
+		return &estimate{Error: result.Err.Error()}, nil
+	}
+	lo := uint64(0)
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		res, err := r.execute(call, mid)
+		if err != nil || res.Failed() {
+			lo = mid
+			continue
+		}
+		hi, result = mid, res
+	}
+	return &estimate{Gas: hexutil.Uint64(hi), GasUsed: hexutil.Uint64(result.UsedGas)}, nil
+}
+
+// succeeds reports whether the transaction succeeds with the gas limit.
+func (r *reference) succeeds(call *callArgs, gas uint64) bool {
+	result, err := r.execute(call, gas)
+	return err == nil && !result.Failed()
+}
+
+// execute applies the transaction with the gas limit on top of the genesis
+// state. The nonce of the sender and the base fee are not checked; without a
+// gas price the transaction pays no fees.
+func (r *reference) execute(call *callArgs, gas uint64) (*core.ExecutionResult, error) {
+	head := r.chain.CurrentBlock()
+	statedb, err := r.chain.StateAt(head.Root)
+	if err != nil {
+		return nil, err
+	}
+	msg := &core.Message{
+		To:                    call.To,
+		Value:                 new(big.Int),
+		GasLimit:              gas,
+		GasPrice:              new(big.Int),
+		SkipNonceChecks:       true,
+		SkipTransactionChecks: true,
+	}
+	if call.From != nil {
+		msg.From = *call.From
+	}
+	if call.Value != nil {
+		msg.Value = call.Value.ToInt()
+	}
+	if call.GasPrice != nil {
+		msg.GasPrice = call.GasPrice.ToInt()
+	}
+	msg.GasFeeCap, msg.GasTipCap = msg.GasPrice, msg.GasPrice
+	switch {
+	case call.Input != nil:
+		msg.Data = *call.Input
+	case call.Data != nil:
+		msg.Data = *call.Data
+	}
+	if call.AccessList != nil {
+		msg.AccessList = *call.AccessList
+	}
+	blockCtx := core.NewEVMBlockContext(head, r.chain, nil)
+	if msg.GasPrice.Sign() == 0 {
+		blockCtx.BaseFee = new(big.Int)
+	}
+	evm := vm.NewEVM(blockCtx, statedb, r.chain.Config(), vm.Config{NoBaseFee: true})
+	return core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(gas))
+}
@@ -0,0 +1,159 @@
+// gas-schedule exports the gas cost schedule of each fork as JSON.
+//
+// Usage:
+//
+//	go run ./cmd/gas-schedule [--forks Berlin,London] [--output schedule.json]
+//
+// The schedule covers the instruction base costs, the constants of the
+// operand dependent instruction costs, refunds, transaction intrinsic gas,
+// access list costs, blob parameters, block gas accounting and precompile
+// prices. All values are generated from the canonical table in schedule.go,
+// which records for every value the fork introducing or changing it.
+//
+// The output lists the parameter descriptions once, followed by the complete
+// schedule of each fork in activation order:
+//
+//	{
+//	    "descriptions": {"dynamic.memoryWord": "...", ...},
+//	    "forks": [{
+//	        "fork": "Frontier",
+//	        "opcodes": [{"opcode": "0x00", "name": "STOP", "gas": 0}, ...],
+//	        "parameters": {"dynamic": {"memoryWord": 3, ...}, ...}
+//	    }, ...]
+//	}
+//
+// Parameters are omitted from the forks before the one introducing them.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// export is the exported schedule.
+type export struct {
+	Descriptions map[string]string `json:"descriptions"`
+	Forks        []*forkSchedule   `json:"forks"`
+}
+
+// forkSchedule holds the costs in effect at a fork.
+type forkSchedule struct {
+	Fork       string                       `json:"fork"`
+	Opcodes    []opcodeCost                 `json:"opcodes"`
+	Parameters map[string]map[string]uint64 `json:"parameters"`
+}
+
+type opcodeCost struct {
+	Opcode string `json:"opcode"`
+	Name   string `json:"name"`
+	Gas    uint64 `json:"gas"`
+}
+
+func main() {
+	var (
+		selected = flag.String("forks", "", "comma separated list of forks to export (default: all)")
+		output   = flag.String("output", "", "output file (default: stdout)")
+	)
+	flag.Parse()
+	names := forks
+	if *selected != "" {
+		names = strings.Split(*selected, ",")
+	}
+	out := &export{Descriptions: make(map[string]string)}
+	for _, p := range parameters {
+		out.Descriptions[p.group+"."+p.name] = p.description
+	}
+	for _, name := range names {
+		schedule, err := scheduleAt(strings.TrimSpace(name))
+		if err != nil {
+			fatalf("%v", err)
+		}
+		out.Forks = append(out.Forks, schedule)
+	}
+	data, err := json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// forkIndex resolves a (case insensitive) fork name to its position in forks.
+func forkIndex(name string) (int, error) {
+	for i, fork := range forks {
+		if strings.EqualFold(fork, name) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown fork %q, supported forks: %s", name, strings.Join(forks, ", "))
+}
+
+// valueAt returns the value of a history at a fork, and whether it has been
+// introduced yet.
+func valueAt(changes []change, fork int) (uint64, bool) {
+	var (
+		value  uint64
+		active bool
+	)
+	for _, c := range changes {
+		index, err := forkIndex(c.fork)
+		if err != nil {
+			panic(err) // the table only uses known forks
+		}
+		if index <= fork {
+			value, active = c.value, true
+		}
+	}
+	return value, active
+}
+
+// scheduleAt evaluates the tables at a fork.
+func scheduleAt(name string) (*forkSchedule, error) {
+	fork, err := forkIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	schedule := &forkSchedule{Fork: forks[fork], Parameters: make(map[string]map[string]uint64)}
+	for _, op := range opcodes {
+		gas, ok := valueAt(op.changes, fork)
+		if !ok {
+			continue
+		}
+		name := op.op.String()
+		if paris, _ := forkIndex("Paris"); op.op == vm.DIFFICULTY && fork >= paris {
+			name = "PREVRANDAO" // EIP-4399
+		}
+		schedule.Opcodes = append(schedule.Opcodes, opcodeCost{Opcode: fmt.Sprintf("0x%02x", byte(op.op)), Name: name, Gas: gas})
+	}
+	sort.Slice(schedule.Opcodes, func(i, j int) bool {
+		return schedule.Opcodes[i].Opcode < schedule.Opcodes[j].Opcode
+	})
+	for _, p := range parameters {
+		value, ok := valueAt(p.changes, fork)
+		if !ok {
+			continue
+		}
+		if schedule.Parameters[p.group] == nil {
+			schedule.Parameters[p.group] = make(map[string]uint64)
+		}
+		schedule.Parameters[p.group][p.name] = value
+	}
+	return schedule, nil
+}
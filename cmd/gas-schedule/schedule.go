@@ -0,0 +1,229 @@
+package main
+
+import "github.com/ethereum/go-ethereum/core/vm"
+
+// forks lists the forks the schedule is exported for, in activation order and
+// named as by the execution specs. Forks which only delayed the difficulty
+// bomb are left out, they did not change any costs.
+var forks = []string{
+	"Frontier", "Homestead", "TangerineWhistle", "SpuriousDragon", "Byzantium",
+	"Constantinople", "Istanbul", "Berlin", "London", "Paris", "Shanghai",
+	"Cancun", "Prague", "Osaka",
+}
+
+// change is the value a parameter takes from a fork on.
+type change struct {
+	fork  string
+	value uint64
+}
+
+func at(fork string, value uint64) change {
+	return change{fork, value}
+}
+
+// opcode is the base cost history of an instruction: the gas charged
+// regardless of its operands. Operand dependent costs are parameters of the
+// dynamic group. The first change introduces the instruction.
+type opcode struct {
+	op      vm.OpCode
+	changes []change
+}
+
+// parameter is the history of a gas constant. The first change introduces it.
+type parameter struct {
+	group       string
+	name        string
+	description string
+	changes     []change
+}
+
+// opcodes is the canonical table of instruction base costs. Since Berlin
+// (EIP-2929) the account and storage accessing instructions list their warm
+// access cost, the cold access surcharge is in the dynamic group.
+var opcodes = buildOpcodes()
+
+func buildOpcodes() []opcode {
+	ops := []opcode{
+		{vm.STOP, []change{at("Frontier", 0)}},
+		{vm.ADD, []change{at("Frontier", 3)}},
+		{vm.MUL, []change{at("Frontier", 5)}},
+		{vm.SUB, []change{at("Frontier", 3)}},
+		{vm.DIV, []change{at("Frontier", 5)}},
+		{vm.SDIV, []change{at("Frontier", 5)}},
+		{vm.MOD, []change{at("Frontier", 5)}},
+		{vm.SMOD, []change{at("Frontier", 5)}},
+		{vm.ADDMOD, []change{at("Frontier", 8)}},
+		{vm.MULMOD, []change{at("Frontier", 8)}},
+		{vm.EXP, []change{at("Frontier", 10)}},
+		{vm.SIGNEXTEND, []change{at("Frontier", 5)}},
+		{vm.LT, []change{at("Frontier", 3)}},
+		{vm.GT, []change{at("Frontier", 3)}},
+		{vm.SLT, []change{at("Frontier", 3)}},
+		{vm.SGT, []change{at("Frontier", 3)}},
+		{vm.EQ, []change{at("Frontier", 3)}},
+		{vm.ISZERO, []change{at("Frontier", 3)}},
+		{vm.AND, []change{at("Frontier", 3)}},
+		{vm.OR, []change{at("Frontier", 3)}},
+		{vm.XOR, []change{at("Frontier", 3)}},
+		{vm.NOT, []change{at("Frontier", 3)}},
+		{vm.BYTE, []change{at("Frontier", 3)}},
+		{vm.SHL, []change{at("Constantinople", 3)}},
+		{vm.SHR, []change{at("Constantinople", 3)}},
+		{vm.SAR, []change{at("Constantinople", 3)}},
+		{vm.CLZ, []change{at("Osaka", 5)}},
+		{vm.KECCAK256, []change{at("Frontier", 30)}},
+		{vm.ADDRESS, []change{at("Frontier", 2)}},
+		{vm.BALANCE, []change{at("Frontier", 20), at("TangerineWhistle", 400), at("Istanbul", 700), at("Berlin", 100)}},
+		{vm.ORIGIN, []change{at("Frontier", 2)}},
+		{vm.CALLER, []change{at("Frontier", 2)}},
+		{vm.CALLVALUE, []change{at("Frontier", 2)}},
+		{vm.CALLDATALOAD, []change{at("Frontier", 3)}},
+		{vm.CALLDATASIZE, []change{at("Frontier", 2)}},
+		{vm.CALLDATACOPY, []change{at("Frontier", 3)}},
+		{vm.CODESIZE, []change{at("Frontier", 2)}},
+		{vm.CODECOPY, []change{at("Frontier", 3)}},
+		{vm.GASPRICE, []change{at("Frontier", 2)}},
+		{vm.EXTCODESIZE, []change{at("Frontier", 20), at("TangerineWhistle", 700), at("Berlin", 100)}},
+		{vm.EXTCODECOPY, []change{at("Frontier", 20), at("TangerineWhistle", 700), at("Berlin", 100)}},
+		{vm.RETURNDATASIZE, []change{at("Byzantium", 2)}},
+		{vm.RETURNDATACOPY, []change{at("Byzantium", 3)}},
+		{vm.EXTCODEHASH, []change{at("Constantinople", 400), at("Istanbul", 700), at("Berlin", 100)}},
+		{vm.BLOCKHASH, []change{at("Frontier", 20)}},
+		{vm.COINBASE, []change{at("Frontier", 2)}},
+		{vm.TIMESTAMP, []change{at("Frontier", 2)}},
+		{vm.NUMBER, []change{at("Frontier", 2)}},
+		{vm.DIFFICULTY, []change{at("Frontier", 2)}},
+		{vm.GASLIMIT, []change{at("Frontier", 2)}},
+		{vm.CHAINID, []change{at("Istanbul", 2)}},
+		{vm.SELFBALANCE, []change{at("Istanbul", 5)}},
+		{vm.BASEFEE, []change{at("London", 2)}},
+		{vm.BLOBHASH, []change{at("Cancun", 3)}},
+		{vm.BLOBBASEFEE, []change{at("Cancun", 2)}},
+		{vm.POP, []change{at("Frontier", 2)}},
+		{vm.MLOAD, []change{at("Frontier", 3)}},
+		{vm.MSTORE, []change{at("Frontier", 3)}},
+		{vm.MSTORE8, []change{at("Frontier", 3)}},
+		{vm.SLOAD, []change{at("Frontier", 50), at("TangerineWhistle", 200), at("Istanbul", 800), at("Berlin", 100)}},
+		{vm.SSTORE, []change{at("Frontier", 0)}},
+		{vm.JUMP, []change{at("Frontier", 8)}},
+		{vm.JUMPI, []change{at("Frontier", 10)}},
+		{vm.PC, []change{at("Frontier", 2)}},
+		{vm.MSIZE, []change{at("Frontier", 2)}},
+		{vm.GAS, []change{at("Frontier", 2)}},
+		{vm.JUMPDEST, []change{at("Frontier", 1)}},
+		{vm.TLOAD, []change{at("Cancun", 100)}},
+		{vm.TSTORE, []change{at("Cancun", 100)}},
+		{vm.MCOPY, []change{at("Cancun", 3)}},
+		{vm.PUSH0, []change{at("Shanghai", 2)}},
+	}
+	for i := 0; i < 32; i++ {
+		ops = append(ops, opcode{vm.PUSH1 + vm.OpCode(i), []change{at("Frontier", 3)}})
+	}
+	for i := 0; i < 16; i++ {
+		ops = append(ops, opcode{vm.DUP1 + vm.OpCode(i), []change{at("Frontier", 3)}})
+	}
+	for i := 0; i < 16; i++ {
+		ops = append(ops, opcode{vm.SWAP1 + vm.OpCode(i), []change{at("Frontier", 3)}})
+	}
+	for i := 0; i <= 4; i++ {
+		// The log base cost and the cost of its topics.
+		ops = append(ops, opcode{vm.LOG0 + vm.OpCode(i), []change{at("Frontier", 375+375*uint64(i))}})
+	}
+	return append(ops,
+		opcode{vm.CREATE, []change{at("Frontier", 32000)}},
+		opcode{vm.CALL, []change{at("Frontier", 40), at("TangerineWhistle", 700), at("Berlin", 100)}},
+		opcode{vm.CALLCODE, []change{at("Frontier", 40), at("TangerineWhistle", 700), at("Berlin", 100)}},
+		opcode{vm.RETURN, []change{at("Frontier", 0)}},
+		opcode{vm.DELEGATECALL, []change{at("Homestead", 40), at("TangerineWhistle", 700), at("Berlin", 100)}},
+		opcode{vm.CREATE2, []change{at("Constantinople", 32000)}},
+		opcode{vm.STATICCALL, []change{at("Byzantium", 700), at("Berlin", 100)}},
+		opcode{vm.REVERT, []change{at("Byzantium", 0)}},
+		opcode{vm.SELFDESTRUCT, []change{at("Frontier", 0), at("TangerineWhistle", 5000)}},
+	)
+}
+
+// parameters is the canonical table of the gas constants besides the
+// instruction base costs.
+var parameters = []parameter{
+	// Operand dependent instruction costs.
+	{"dynamic", "memoryWord", "linear cost per word of memory expansion", []change{at("Frontier", 3)}},
+	{"dynamic", "memoryQuadDivisor", "divisor of the quadratic memory expansion cost words^2/divisor", []change{at("Frontier", 512)}},
+	{"dynamic", "copyWord", "cost per word copied by the *COPY instructions", []change{at("Frontier", 3)}},
+	{"dynamic", "keccak256Word", "cost per word hashed by KECCAK256 and CREATE2", []change{at("Frontier", 6)}},
+	{"dynamic", "expByte", "cost per byte of the EXP exponent (EIP-160)", []change{at("Frontier", 10), at("SpuriousDragon", 50)}},
+	{"dynamic", "logData", "cost per byte of log data", []change{at("Frontier", 8)}},
+	{"dynamic", "logTopic", "cost per log topic, included in the LOGn base costs", []change{at("Frontier", 375)}},
+	{"dynamic", "callValueTransfer", "surcharge for calls transferring value", []change{at("Frontier", 9000)}},
+	{"dynamic", "callStipend", "gas given to the callee of a value transferring call", []change{at("Frontier", 2300)}},
+	{"dynamic", "callNewAccount", "surcharge for calls creating an account (EIP-161: with value only)", []change{at("Frontier", 25000)}},
+	{"dynamic", "selfdestructNewAccount", "surcharge for SELFDESTRUCT creating the beneficiary (EIP-150)", []change{at("TangerineWhistle", 25000)}},
+	{"dynamic", "codeDepositByte", "cost per byte of deployed code", []change{at("Frontier", 200)}},
+	{"dynamic", "initcodeWord", "cost per word of initcode for CREATE, CREATE2 and creation transactions (EIP-3860)", []change{at("Shanghai", 2)}},
+	{"dynamic", "coldAccountAccess", "cost of the first access to an account in a transaction (EIP-2929)", []change{at("Berlin", 2600)}},
+	{"dynamic", "coldSload", "cost of the first access to a storage slot in a transaction (EIP-2929)", []change{at("Berlin", 2100)}},
+	{"dynamic", "warmStorageRead", "cost of accessing an already accessed account or slot (EIP-2929)", []change{at("Berlin", 100)}},
+	{"dynamic", "sstoreSet", "SSTORE of a nonzero value to a zero slot", []change{at("Frontier", 20000)}},
+	{"dynamic", "sstoreReset", "SSTORE changing a nonzero slot (EIP-2929 deducts the cold access)", []change{at("Frontier", 5000), at("Berlin", 2900)}},
+	{"dynamic", "sstoreNoop", "SSTORE not changing the current value or of a dirty slot (EIP-2200)", []change{at("Istanbul", 800), at("Berlin", 100)}},
+	{"dynamic", "sstoreSentry", "minimum gas left required for SSTORE (EIP-2200)", []change{at("Istanbul", 2300)}},
+
+	// Refunds.
+	{"refunds", "sstoreClears", "refund for clearing a storage slot (EIP-3529)", []change{at("Frontier", 15000), at("London", 4800)}},
+	{"refunds", "selfdestruct", "refund for SELFDESTRUCT, removed by EIP-3529", []change{at("Frontier", 24000), at("London", 0)}},
+	{"refunds", "refundQuotient", "the refund is capped at gasUsed/quotient (EIP-3529)", []change{at("Frontier", 2), at("London", 5)}},
+	{"refunds", "authorizationExistingAccount", "refund per authorization to an existing account (EIP-7702)", []change{at("Prague", 12500)}},
+
+	// Transaction intrinsic gas.
+	{"intrinsic", "transaction", "base cost of a transaction", []change{at("Frontier", 21000)}},
+	{"intrinsic", "contractCreation", "base cost of a contract creation transaction (EIP-2)", []change{at("Frontier", 21000), at("Homestead", 53000)}},
+	{"intrinsic", "dataZeroByte", "cost per zero byte of transaction data", []change{at("Frontier", 4)}},
+	{"intrinsic", "dataNonZeroByte", "cost per nonzero byte of transaction data (EIP-2028)", []change{at("Frontier", 68), at("Istanbul", 16)}},
+	{"intrinsic", "tokensPerNonZeroByte", "calldata tokens of a nonzero byte, zero bytes count as one (EIP-7623)", []change{at("Prague", 4)}},
+	{"intrinsic", "floorCostPerToken", "floor cost per calldata token (EIP-7623)", []change{at("Prague", 10)}},
+	{"intrinsic", "authorization", "cost per authorization, as for an empty account (EIP-7702)", []change{at("Prague", 25000)}},
+	{"intrinsic", "gasLimitCap", "maximum gas limit of a transaction (EIP-7825)", []change{at("Osaka", 1<<24)}},
+
+	// Access lists.
+	{"accessList", "address", "cost per address of the access list (EIP-2930)", []change{at("Berlin", 2400)}},
+	{"accessList", "storageKey", "cost per storage key of the access list (EIP-2930)", []change{at("Berlin", 1900)}},
+
+	// Blobs.
+	{"blobs", "gasPerBlob", "blob gas used by a blob (EIP-4844)", []change{at("Cancun", 1<<17)}},
+	{"blobs", "minBaseFee", "minimum blob base fee (EIP-4844)", []change{at("Cancun", 1)}},
+	{"blobs", "target", "target blobs per block (EIP-7691)", []change{at("Cancun", 3), at("Prague", 6)}},
+	{"blobs", "max", "maximum blobs per block (EIP-7691)", []change{at("Cancun", 6), at("Prague", 9)}},
+	{"blobs", "baseFeeUpdateFraction", "blob base fee update fraction (EIP-7691)", []change{at("Cancun", 3338477), at("Prague", 5007716)}},
+	{"blobs", "baseCost", "execution gas a blob is priced at as a floor for the blob base fee (EIP-7918)", []change{at("Osaka", 1<<13)}},
+
+	// Block gas accounting.
+	{"block", "gasLimitBoundDivisor", "maximum gas limit change per block is parent/divisor", []change{at("Frontier", 1024)}},
+	{"block", "elasticityMultiplier", "gas target is the gas limit divided by this (EIP-1559)", []change{at("London", 2)}},
+	{"block", "baseFeeChangeDenominator", "bounds the base fee change per block (EIP-1559)", []change{at("London", 8)}},
+	{"block", "initialBaseFee", "base fee of the first London block (EIP-1559)", []change{at("London", 1000000000)}},
+
+	// Precompiles.
+	{"precompiles", "ecrecover", "ECRECOVER", []change{at("Frontier", 3000)}},
+	{"precompiles", "sha256Base", "SHA256 base cost", []change{at("Frontier", 60)}},
+	{"precompiles", "sha256Word", "SHA256 cost per word", []change{at("Frontier", 12)}},
+	{"precompiles", "ripemd160Base", "RIPEMD160 base cost", []change{at("Frontier", 600)}},
+	{"precompiles", "ripemd160Word", "RIPEMD160 cost per word", []change{at("Frontier", 120)}},
+	{"precompiles", "identityBase", "IDENTITY base cost", []change{at("Frontier", 15)}},
+	{"precompiles", "identityWord", "IDENTITY cost per word", []change{at("Frontier", 3)}},
+	{"precompiles", "modexpMin", "MODEXP minimum cost (EIP-2565, EIP-7883)", []change{at("Byzantium", 0), at("Berlin", 200), at("Osaka", 500)}},
+	{"precompiles", "modexpDivisor", "MODEXP complexity divisor (EIP-198, EIP-2565), dropped by EIP-7883", []change{at("Byzantium", 20), at("Berlin", 3), at("Osaka", 1)}},
+	{"precompiles", "bn254Add", "BN254 ADD (EIP-1108)", []change{at("Byzantium", 500), at("Istanbul", 150)}},
+	{"precompiles", "bn254Mul", "BN254 MUL (EIP-1108)", []change{at("Byzantium", 40000), at("Istanbul", 6000)}},
+	{"precompiles", "bn254PairingBase", "BN254 pairing base cost (EIP-1108)", []change{at("Byzantium", 100000), at("Istanbul", 45000)}},
+	{"precompiles", "bn254PairingPoint", "BN254 pairing cost per pair (EIP-1108)", []change{at("Byzantium", 80000), at("Istanbul", 34000)}},
+	{"precompiles", "blake2fRound", "BLAKE2F cost per round (EIP-152)", []change{at("Istanbul", 1)}},
+	{"precompiles", "pointEvaluation", "KZG point evaluation (EIP-4844)", []change{at("Cancun", 50000)}},
+	{"precompiles", "bls12G1Add", "BLS12-381 G1 addition (EIP-2537)", []change{at("Prague", 375)}},
+	{"precompiles", "bls12G1Mul", "BLS12-381 G1 multiplication, the MSM cost per pair before discount (EIP-2537)", []change{at("Prague", 12000)}},
+	{"precompiles", "bls12G2Add", "BLS12-381 G2 addition (EIP-2537)", []change{at("Prague", 600)}},
+	{"precompiles", "bls12G2Mul", "BLS12-381 G2 multiplication, the MSM cost per pair before discount (EIP-2537)", []change{at("Prague", 22500)}},
+	{"precompiles", "bls12PairingBase", "BLS12-381 pairing base cost (EIP-2537)", []change{at("Prague", 37700)}},
+	{"precompiles", "bls12PairingPair", "BLS12-381 pairing cost per pair (EIP-2537)", []change{at("Prague", 32600)}},
+	{"precompiles", "bls12MapFpToG1", "BLS12-381 Fp to G1 mapping (EIP-2537)", []change{at("Prague", 5500)}},
+	{"precompiles", "bls12MapFp2ToG2", "BLS12-381 Fp2 to G2 mapping (EIP-2537)", []change{at("Prague", 23800)}},
+	{"precompiles", "p256Verify", "secp256r1 signature verification (EIP-7951)", []change{at("Osaka", 6900)}},
+}
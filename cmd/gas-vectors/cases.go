@@ -0,0 +1,368 @@
+package main
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// callee and callee2 are the contracts called by the code of the
+	// transaction target.
+	callee  = common.HexToAddress("0x00000000000000000000000000000000000ca11e")
+	callee2 = common.HexToAddress("0x00000000000000000000000000000000001ca11e")
+
+	one = common.BigToHash(big.NewInt(1))
+)
+
+// The execution gas of the cheap opcodes.
+const (
+	pushGas = vm.GasFastestStep // PUSH1 to PUSH32, MSTORE8, CALLDATACOPY
+	popGas  = vm.GasQuickStep   // POP, GAS
+)
+
+// clearGas is the gas of clearing a slot set before the transaction, a cold
+// SSTORE of zero into it: the cold access and the reset cost less the cold
+// access.
+const clearGas = params.SstoreResetGasEIP2200
+
+// callGas is the gas of a CALL of a cold account without value, not counting
+// the gas passed on.
+const callGas = params.ColdAccountAccessCostEIP2929
+
+// balanceGas is the gas of a cold BALANCE followed by a POP, with its push.
+const balanceGas = pushGas + params.ColdAccountAccessCostEIP2929 + popGas
+
+// testCase is a transaction calling contract code, together with the gas it
+// is expected to use.
+type testCase struct {
+	name        string
+	description string
+	code        []byte                      // code of the transaction target
+	storage     map[common.Hash]common.Hash // storage of the transaction target
+	pre         types.GenesisAlloc          // accounts besides the sender and the target
+	gasLimit    uint64                      // defaultGasLimit if zero
+
+	// gas returns the gas the execution uses on a fork on top of the
+	// intrinsic gas and the refund it earns, capped at a fifth of the gas
+	// used from London and at half of it before. An execution which halts
+	// exceptionally uses the whole gas limit and earns no refund.
+	gas   func(r params.Rules) (exec, refund uint64)
+	halts bool
+}
+
+// clearRefund returns the refund of clearing a slot set before the
+// transaction.
+func clearRefund(r params.Rules) uint64 {
+	if r.IsLondon {
+		return params.SstoreClearsScheduleRefundEIP3529
+	}
+	return params.SstoreClearsScheduleRefundEIP2200
+}
+
+// expectedGas returns the gas the transaction of the case is expected to use
+// on a fork.
+func (c *testCase) expectedGas(r params.Rules) uint64 {
+	if c.halts {
+		return c.limit()
+	}
+	exec, refund := c.gas(r)
+	used := params.TxGas + exec
+	quotient := params.RefundQuotient
+	if r.IsLondon {
+		quotient = params.RefundQuotientEIP3529
+	}
+	return used - min(refund, used/quotient)
+}
+
+func (c *testCase) limit() uint64 {
+	if c.gasLimit == 0 {
+		return defaultGasLimit
+	}
+	return c.gasLimit
+}
+
+// program concatenates code fragments.
+func program(parts ...[]byte) []byte {
+	var code []byte
+	for _, part := range parts {
+		code = append(code, part...)
+	}
+	return code
+}
+
+func op(ops ...vm.OpCode) []byte {
+	code := make([]byte, len(ops))
+	for i, o := range ops {
+		code[i] = byte(o)
+	}
+	return code
+}
+
+// push returns the PUSH of the bytes.
+func push(b ...byte) []byte {
+	return append([]byte{byte(vm.PUSH1) + byte(len(b)-1)}, b...)
+}
+
+// sstore returns the code storing value into slot, pushGas*2 plus the SSTORE.
+func sstore(slot, value byte) []byte {
+	return program(push(value), push(slot), op(vm.SSTORE))
+}
+
+// call returns the CALL of addr with gas and value and no input and output,
+// pushGas*7 plus the CALL. A gas of nil passes on all the gas, with a GAS
+// costing popGas instead of the push.
+func call(addr common.Address, gas []byte, value byte) []byte {
+	code := program(push(0), push(0), push(0), push(0), push(value), push(addr[:]...))
+	if gas == nil {
+		return program(code, op(vm.GAS, vm.CALL))
+	}
+	return program(code, push(gas...), op(vm.CALL))
+}
+
+// burn returns code using exactly the gas: BALANCE of cold accounts followed
+// by JUMPDESTs.
+func burn(gas uint64) []byte {
+	var code []byte
+	for i := byte(0); gas >= balanceGas; i++ {
+		code = program(code, push(0x20+i), op(vm.BALANCE, vm.POP))
+		gas -= balanceGas
+	}
+	for ; gas > 0; gas-- {
+		code = append(code, byte(vm.JUMPDEST))
+	}
+	return code
+}
+
+// memoryGas returns the gas of expanding the memory to the words.
+func memoryGas(words uint64) uint64 {
+	return params.MemoryGas*words + words*words/params.QuadCoeffDiv
+}
+
+// slots returns the storage with slots 0 to n-1 set to 1.
+func slots(n int) map[common.Hash]common.Hash {
+	storage := make(map[common.Hash]common.Hash, n)
+	for i := 0; i < n; i++ {
+		storage[common.BigToHash(big.NewInt(int64(i)))] = one
+	}
+	return storage
+}
+
+// capBoundaryBurn is the gas burnt by the refund cap boundary cases on top of
+// clearing two slots for the gas used of London to be exactly five times the
+// refund.
+const capBoundaryBurn = 5*2*params.SstoreClearsScheduleRefundEIP3529 - params.TxGas - 2*(2*pushGas+clearGas)
+
+// allBut64thLimit is the gas limit of the 63/64 cases which leaves 64000 gas
+// at the CALL, after its cost.
+const allBut64thLimit = params.TxGas + 5*pushGas + pushGas + popGas + callGas + 64000
+
+func cases() []testCase {
+	clearTwo := program(sstore(0, 0), sstore(1, 0))
+	return []testCase{
+		// SSTORE refunds and the refund cap of EIP-3529.
+		{
+			name:        "sstore_clear",
+			description: "Clearing a slot set before the transaction, the refund stays below the cap from London and is capped before.",
+			code:        program(sstore(0, 0), op(vm.STOP)),
+			storage:     slots(1),
+			gas: func(r params.Rules) (uint64, uint64) {
+				return 2*pushGas + clearGas, clearRefund(r)
+			},
+		},
+		{
+			name:        "sstore_clear_many",
+			description: "Clearing eight slots, the refund is capped at a fifth of the gas used.",
+			code:        program(clearTwo, sstore(2, 0), sstore(3, 0), sstore(4, 0), sstore(5, 0), sstore(6, 0), sstore(7, 0), op(vm.STOP)),
+			storage:     slots(8),
+			gas: func(r params.Rules) (uint64, uint64) {
+				return 8 * (2*pushGas + clearGas), 8 * clearRefund(r)
+			},
+		},
+		{
+			name:        "refund_cap_at_boundary",
+			description: "Clearing two slots with the gas used of London exactly five times the refund, which is not capped.",
+			code:        program(clearTwo, burn(capBoundaryBurn), op(vm.STOP)),
+			storage:     slots(2),
+			gas: func(r params.Rules) (uint64, uint64) {
+				return 2*(2*pushGas+clearGas) + capBoundaryBurn, 2 * clearRefund(r)
+			},
+		},
+		{
+			name:        "refund_cap_below_boundary",
+			description: "Clearing two slots with the gas used of London one below five times the refund, which is capped by one.",
+			code:        program(clearTwo, burn(capBoundaryBurn-1), op(vm.STOP)),
+			storage:     slots(2),
+			gas: func(r params.Rules) (uint64, uint64) {
+				return 2*(2*pushGas+clearGas) + capBoundaryBurn - 1, 2 * clearRefund(r)
+			},
+		},
+		{
+			name:        "sstore_set_clear",
+			description: "Setting an empty slot and clearing it again, refunding the set cost but the warm access.",
+			code:        program(sstore(0, 1), sstore(0, 0), op(vm.STOP)),
+			gas: func(r params.Rules) (uint64, uint64) {
+				exec := 4*pushGas + params.ColdSloadCostEIP2929 + params.SstoreSetGasEIP2200 + params.WarmStorageReadCostEIP2929
+				return exec, params.SstoreSetGasEIP2200 - params.WarmStorageReadCostEIP2929
+			},
+		},
+		{
+			name:        "sstore_clear_restore",
+			description: "Clearing a slot and restoring its value, the clear refund is taken back and the reset cost refunded.",
+			code:        program(sstore(0, 0), sstore(0, 1), op(vm.STOP)),
+			storage:     slots(1),
+			gas: func(r params.Rules) (uint64, uint64) {
+				exec := 4*pushGas + clearGas + params.WarmStorageReadCostEIP2929
+				return exec, params.SstoreResetGasEIP2200 - params.ColdSloadCostEIP2929 - params.WarmStorageReadCostEIP2929
+			},
+		},
+
+		// Refunds of reverted and failed executions.
+		{
+			name:        "refund_reverted_subcall",
+			description: "A call clearing a slot and reverting, its refund is dropped.",
+			code:        program(call(callee, nil, 0), op(vm.POP, vm.STOP)),
+			pre: types.GenesisAlloc{
+				callee: {Balance: new(big.Int), Code: program(sstore(0, 0), push(0), push(0), op(vm.REVERT)), Storage: slots(1)},
+			},
+			gas: func(r params.Rules) (uint64, uint64) {
+				inner := 4*pushGas + clearGas
+				return 5*pushGas + pushGas + popGas + callGas + inner + popGas, 0
+			},
+		},
+		{
+			name:        "refund_kept_subcall",
+			description: "A call clearing a slot and returning, its refund is kept.",
+			code:        program(call(callee, nil, 0), op(vm.POP, vm.STOP)),
+			pre: types.GenesisAlloc{
+				callee: {Balance: new(big.Int), Code: program(sstore(0, 0), op(vm.STOP)), Storage: slots(1)},
+			},
+			gas: func(r params.Rules) (uint64, uint64) {
+				inner := 2*pushGas + clearGas
+				return 5*pushGas + pushGas + popGas + callGas + inner + popGas, clearRefund(r)
+			},
+		},
+		{
+			name:        "refund_reverted_transaction",
+			description: "Clearing a slot and reverting the transaction, the refund is dropped.",
+			code:        program(sstore(0, 0), push(0), push(0), op(vm.REVERT)),
+			storage:     slots(1),
+			gas: func(r params.Rules) (uint64, uint64) {
+				return 4*pushGas + clearGas, 0
+			},
+		},
+		{
+			name:        "refund_halted_transaction",
+			description: "Clearing a slot and halting exceptionally, all gas is used and the refund dropped.",
+			code:        program(sstore(0, 0), op(vm.INVALID)),
+			storage:     slots(1),
+			halts:       true,
+		},
+
+		// The 63/64 rule of EIP-150 and the call stipend.
+		{
+			name:        "call_all_but_one_64th",
+			description: "A call passing on all gas to code halting exceptionally, the caller keeps a 64th of the gas it has, 64000.",
+			code:        program(call(callee, nil, 0), op(vm.STOP)),
+			pre:         types.GenesisAlloc{callee: {Balance: new(big.Int), Code: op(vm.INVALID)}},
+			gasLimit:    allBut64thLimit,
+			gas: func(r params.Rules) (uint64, uint64) {
+				return allBut64thLimit - params.TxGas - 64000/64, 0
+			},
+		},
+		{
+			name:        "call_all_but_one_64th_rounding",
+			description: "A call passing on all gas to code halting exceptionally, the caller keeps a 64th of the gas it has, 64063, rounded down.",
+			code:        program(call(callee, nil, 0), op(vm.STOP)),
+			pre:         types.GenesisAlloc{callee: {Balance: new(big.Int), Code: op(vm.INVALID)}},
+			gasLimit:    allBut64thLimit + 63,
+			gas: func(r params.Rules) (uint64, uint64) {
+				return allBut64thLimit + 63 - params.TxGas - 64063/64, 0
+			},
+		},
+		{
+			name:        "call_stipend_returned",
+			description: "A value transfer call without gas, the unused stipend is returned to the caller.",
+			code:        program(call(callee, []byte{0}, 1), op(vm.POP, vm.STOP)),
+			pre:         types.GenesisAlloc{callee: {Balance: new(big.Int), Code: op(vm.STOP)}},
+			gas: func(r params.Rules) (uint64, uint64) {
+				return 7*pushGas + callGas + params.CallValueTransferGas - params.CallStipend + popGas, 0
+			},
+		},
+		{
+			name:        "call_stipend_sstore_sentry",
+			description: "A value transfer call with 6 gas and the stipend, the SSTORE of the callee has 2300 gas left, not above the EIP-2200 sentry, and fails.",
+			code:        program(call(callee2, []byte{6}, 1), op(vm.POP, vm.STOP)),
+			pre:         types.GenesisAlloc{callee2: {Balance: new(big.Int), Code: program(sstore(0, 1), op(vm.STOP)), Storage: slots(1)}},
+			gas: func(r params.Rules) (uint64, uint64) {
+				return 7*pushGas + callGas + params.CallValueTransferGas + 6 + popGas, 0
+			},
+		},
+		{
+			name:        "call_stipend_sstore_above_sentry",
+			description: "A value transfer call with 7 gas and the stipend, the SSTORE of the callee has 2301 gas left, above the EIP-2200 sentry, and rewrites the value of the slot.",
+			code:        program(call(callee2, []byte{7}, 1), op(vm.POP, vm.STOP)),
+			pre:         types.GenesisAlloc{callee2: {Balance: new(big.Int), Code: program(sstore(0, 1), op(vm.STOP)), Storage: slots(1)}},
+			gas: func(r params.Rules) (uint64, uint64) {
+				// The gas left of the callee, 101, is returned to the caller.
+				inner := 2*pushGas + params.ColdSloadCostEIP2929 + params.WarmStorageReadCostEIP2929
+				return 7*pushGas + callGas + params.CallValueTransferGas + inner - params.CallStipend + popGas, 0
+			},
+		},
+
+		// Memory expansion.
+		{
+			name:        "memory_22_words",
+			description: "Expanding the memory to 22 words, the last size without quadratic cost.",
+			code:        program(push(0), push(0x02, 0xbf), op(vm.MSTORE8, vm.STOP)),
+			gas: func(r params.Rules) (uint64, uint64) {
+				return 3*pushGas + memoryGas(22), 0
+			},
+		},
+		{
+			name:        "memory_23_words",
+			description: "Expanding the memory to 23 words, the first size with quadratic cost.",
+			code:        program(push(0), push(0x02, 0xc0), op(vm.MSTORE8, vm.STOP)),
+			gas: func(r params.Rules) (uint64, uint64) {
+				return 3*pushGas + memoryGas(23), 0
+			},
+		},
+		{
+			name:        "memory_size_limit",
+			description: "Expanding the memory to 0x1fffffffe0 bytes, the largest size whose cost is computed, which exceeds any gas limit.",
+			code:        program(push(0), push(0x1f, 0xff, 0xff, 0xff, 0xdf), op(vm.MSTORE8, vm.STOP)),
+			halts:       true,
+		},
+		{
+			name:        "memory_size_overflow",
+			description: "Expanding the memory past 0x1fffffffe0 bytes, whose cost overflows.",
+			code:        program(push(0), push(0x1f, 0xff, 0xff, 0xff, 0xe0), op(vm.MSTORE8, vm.STOP)),
+			halts:       true,
+		},
+		{
+			name:        "memory_offset_2_64",
+			description: "Loading from offset 2^64, which overflows the memory size.",
+			code:        program(push(1, 0, 0, 0, 0, 0, 0, 0, 0), op(vm.MLOAD, vm.STOP)),
+			halts:       true,
+		},
+		{
+			name:        "memory_zero_size_return",
+			description: "Returning zero bytes at offset 2^255, which expands no memory.",
+			code:        program(push(0), push(common.BigToHash(new(big.Int).Lsh(big.NewInt(1), 255)).Bytes()...), op(vm.RETURN)),
+			gas: func(r params.Rules) (uint64, uint64) {
+				return 2 * pushGas, 0
+			},
+		},
+		{
+			name:        "memory_zero_size_copy",
+			description: "Copying zero bytes of calldata to offset 2^255, which expands no memory.",
+			code:        program(push(0), push(0), push(common.BigToHash(new(big.Int).Lsh(big.NewInt(1), 255)).Bytes()...), op(vm.CALLDATACOPY, vm.STOP)),
+			gas: func(r params.Rules) (uint64, uint64) {
+				return 3*pushGas + pushGas, 0
+			},
+		},
+	}
+}
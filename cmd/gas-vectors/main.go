@@ -0,0 +1,146 @@
+// gas-vectors generates gas accounting edge case vectors in the format of the
+// state_tests fixtures of the execution spec tests, using go-ethereum as the
+// reference implementation.
+//
+// Usage:
+//
+//	go run ./cmd/gas-vectors [--forks Berlin,London,...,Osaka] [--output gas_vectors.json]
+//
+// Every vector is a single legacy transaction calling contract code, covering
+// the edge cases where the gas used of clients most often diverges:
+//
+//   - SSTORE refunds and the EIP-3529 refund cap, at and one below the gas
+//     used where the refund equals the cap, and the refunds of slots set and
+//     cleared, or cleared and restored, within the transaction.
+//   - Refunds of reverted calls, reverted transactions and exceptional halts,
+//     which are dropped.
+//   - The EIP-150 63/64 rule, with the gas kept by the caller rounded down, and
+//     the call stipend: returned if unused, and the EIP-2200 SSTORE sentry the
+//     stipend alone does not pass.
+//   - Memory expansion: the first size with a quadratic cost, the largest size
+//     whose cost is computed, offsets overflowing the memory size and zero
+//     sized accesses at huge offsets, which expand nothing.
+//
+// The expected gas used of every vector is declared with it, derived from the
+// gas schedule of the fork, and checked against go-ethereum before anything
+// is written. Each post-state entry lists it alongside the state root, which
+// commits to it through the balances of the sender and the coinbase.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// The parameters of the test environment and transactions.
+const (
+	defaultGasLimit = 1000000
+	baseFee         = 7
+	gasPrice        = 10
+	number          = 1
+	timestamp       = 1000
+)
+
+var (
+	chainID  = big.NewInt(1)
+	coinbase = common.HexToAddress("0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba")
+	target   = common.HexToAddress("0x0000000000000000000000000000000000003529")
+
+	senderKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	sender       = crypto.PubkeyToAddress(senderKey.PublicKey)
+)
+
+func main() {
+	var (
+		forkList = flag.String("forks", "Berlin,London,Shanghai,Cancun,Prague,Osaka", "comma separated forks the vectors are filled for")
+		output   = flag.String("output", "gas_vectors.json", "file the fixtures are written to")
+	)
+	flag.Parse()
+	forks := strings.Split(*forkList, ",")
+	for _, fork := range forks {
+		config, _, err := tests.GetChainConfig(fork)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if !config.IsBerlin(new(big.Int)) {
+			fatalf("fork %s predates EIP-2929", fork)
+		}
+	}
+	fixtures := make(map[string]*statetest.Fixture)
+	for _, c := range cases() {
+		f, err := fill(c, forks)
+		if err != nil {
+			fatalf("case %s: %v", c.name, err)
+		}
+		fixtures["gas_vectors/"+c.name] = f
+	}
+	data, err := json.MarshalIndent(fixtures, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d fixtures to %s on %d forks\n", len(fixtures), *output, len(forks))
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// fill builds the fixture of a test case, executes it on each fork and
+// verifies the gas used.
+func fill(c testCase, forks []string) (*statetest.Fixture, error) {
+	pre := types.GenesisAlloc{
+		sender: {Balance: big.NewInt(params.Ether)},
+		target: {Balance: big.NewInt(1), Code: c.code, Storage: c.storage},
+	}
+	for addr, account := range c.pre {
+		pre[addr] = account
+	}
+	tx, err := types.SignNewTx(senderKey, types.LatestSignerForChainID(chainID), &types.LegacyTx{
+		GasPrice: big.NewInt(gasPrice),
+		Gas:      c.limit(),
+		To:       &target,
+		Value:    new(big.Int),
+	})
+	if err != nil {
+		return nil, err
+	}
+	env := statetest.DefaultEnv(coinbase, common.Hash{0x35, 0x29}, baseFee)
+	env.Number, env.Timestamp = number, timestamp
+	f, err := statetest.New(env, pre, tx, senderKey, forks)
+	if err != nil {
+		return nil, err
+	}
+	f.Info = statetest.Info("gas-vectors", "handcrafted", c.description)
+	if err := f.Fill(c.check); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// check verifies the gas used on a fork against the expectation of the case
+// and records it in the post-state.
+func (c *testCase) check(fork string, r *statetest.Result) error {
+	config, _, _ := tests.GetChainConfig(fork)
+	rules := config.Rules(big.NewInt(number), config.TerminalTotalDifficulty != nil, timestamp)
+	if want := c.expectedGas(rules); r.GasUsed != want {
+		return fmt.Errorf("gas used %d, expected %d", r.GasUsed, want)
+	}
+	r.Post.GasUsed = hexutil.Uint64(r.GasUsed)
+	return nil
+}
@@ -0,0 +1,170 @@
+// gen-state deterministically generates a synthetic allocation of a given
+// size and shape from a seed, for performance and repricing work which needs
+// large, reproducible states without copying mainnet data.
+//
+// Usage:
+//
+//	go run ./cmd/gen-state --seed 1 --accounts 1000000 --contracts 0.1 --slots zipf:1.5,100000 --code-size exp:4096 --output state.json
+//
+// --contracts is the share of the accounts which are contracts, with code and
+// storage; the others are externally owned accounts with only a balance and a
+// nonce. The code size and the number of storage slots of every contract are
+// drawn from the distributions given by --code-size and --slots: a constant
+// N, uniform:MIN-MAX, exp:MEAN or exp:MEAN,MAX, and zipf:S,MAX with exponent
+// S > 1 for the heavy tail of real states, where a few contracts hold most of
+// the storage. Code sizes are capped at the EIP-170 limit.
+//
+// The same seed and shape always yield the same allocation. It is written as
+// a JSON object with the allocation in the geth genesis format under "alloc",
+// accepted by the --alloc flag of the genesis tool, and its "stateRoot",
+// computed incrementally by --jobs workers, with the addresses in lowercase or
+// with their EIP-55 checksum given --checksum. The root and the size of the
+// allocation are printed as well.
+//
+// The allocation is held in memory unless --datadir is given, which backs it
+// with a pebble database in that directory instead, for states of a hundred
+// million accounts and more: the accounts are written to the database as they
+// are generated, the state root is computed by iterating them in the order of
+// their hashed addresses and the output is streamed from the database, so
+// that only the account at hand is held in memory. The directory must be
+// empty or not exist, and is removed once the output is written. --cache sets
+// the size of the database cache. Both modes yield the same allocation.
+//
+// For profiling, --pprof serves the net/http/pprof endpoints at the given
+// address while the tool runs, and --cpuprofile and --memprofile write a CPU
+// profile of the run and a heap profile at its end.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func main() {
+	var (
+		seed      = flag.Int64("seed", 1, "seed of the generator")
+		accounts  = flag.Int("accounts", 1000, "number of accounts")
+		contracts = flag.Float64("contracts", 0.1, "share of the accounts which are contracts")
+		codeSize  = flag.String("code-size", "exp:4096", "distribution of the code size of the contracts")
+		slots     = flag.String("slots", "zipf:1.5,100000", "distribution of the number of storage slots of the contracts")
+		output    = flag.String("output", "state.json", "file the allocation and state root are written to")
+		jobs      = flag.Int("jobs", runtime.NumCPU(), "number of workers computing the state root")
+		checksum  = flag.Bool("checksum", false, "write the addresses with their EIP-55 checksum instead of in lowercase")
+		datadir   = flag.String("datadir", "", "directory of a database backing the allocation instead of memory")
+		cache     = flag.Int("cache", 1024, "megabytes of database cache used with --datadir")
+		pprofAddr = flag.String("pprof", "", "address to serve the pprof endpoints at, such as localhost:6060")
+		cpuFile   = flag.String("cpuprofile", "", "file to write a CPU profile of the run to")
+		memFile   = flag.String("memprofile", "", "file to write a heap profile to at the end of the run")
+	)
+	flag.Parse()
+	stopProfiling, err := startProfiling(*pprofAddr, *cpuFile, *memFile)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	shape := alloc.Shape{Accounts: *accounts, Contracts: *contracts}
+	if shape.CodeSize, err = alloc.ParseDistribution(*codeSize); err != nil {
+		fatalf("--code-size: %v", err)
+	}
+	if shape.Slots, err = alloc.ParseDistribution(*slots); err != nil {
+		fatalf("--slots: %v", err)
+	}
+	head := map[string]interface{}{
+		"alloc":     struct{}{},
+		"stateRoot": nil,
+	}
+	var (
+		stream *gen.Stream
+		store  *alloc.Store
+		sum    summary
+	)
+	if *datadir != "" {
+		if store, err = openStore(*datadir, *cache); err != nil {
+			fatalf("%v", err)
+		}
+		err = alloc.GenerateFunc(shape, *seed, nil, func(addr common.Address, account types.Account) error {
+			sum.add(account)
+			return store.Put(addr, account)
+		})
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if head["stateRoot"], err = store.Root(*jobs); err != nil {
+			fatalf("%v", err)
+		}
+		stream = gen.StoreAllocStream(head, store)
+	} else {
+		state, err := alloc.Generate(shape, *seed)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		for _, account := range state {
+			sum.add(account)
+		}
+		if head["stateRoot"], err = alloc.Root(state, *jobs); err != nil {
+			fatalf("%v", err)
+		}
+		stream = gen.AllocStream(head, state)
+	}
+	if *checksum {
+		stream.Checksum()
+	}
+	if err := stream.WriteFile(*output); err != nil {
+		fatalf("%v", err)
+	}
+	if store != nil {
+		if err := store.Close(); err != nil {
+			fatalf("%v", err)
+		}
+		if err := os.RemoveAll(*datadir); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	if err := stopProfiling(); err != nil {
+		fatalf("%v", err)
+	}
+
+	fmt.Printf("Accounts:   %d (%d with code)\n", sum.accounts, sum.withCode)
+	fmt.Printf("Code:       %d bytes\n", sum.code)
+	fmt.Printf("Storage:    %d slots\n", sum.slots)
+	fmt.Printf("State root: %s\n", head["stateRoot"].(common.Hash).Hex())
+	fmt.Printf("Written to: %s\n", *output)
+}
+
+// summary counts the accounts, code and storage of an allocation.
+type summary struct {
+	accounts, withCode, code, slots int
+}
+
+func (s *summary) add(account types.Account) {
+	s.accounts++
+	if len(account.Code) > 0 {
+		s.withCode++
+	}
+	s.code += len(account.Code)
+	s.slots += len(account.Storage)
+}
+
+// openStore opens a store in the directory, which must be empty or not exist.
+func openStore(dir string, cache int) (*alloc.Store, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("--datadir %s is not empty", dir)
+	}
+	return alloc.OpenStore(dir, cache)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	_ "net/http/pprof" // registers the pprof endpoints on the default mux
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiling serves the pprof endpoints at the address and starts a CPU
+// profile written to cpuFile, unless they are empty. The returned function
+// stops the CPU profile and writes a heap profile to memFile, unless it is
+// empty.
+func startProfiling(addr, cpuFile, memFile string) (func() error, error) {
+	if addr != "" {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("--pprof: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Serving pprof at http://%s/debug/pprof\n", listener.Addr())
+		go http.Serve(listener, nil)
+	}
+	var cpu *os.File
+	if cpuFile != "" {
+		var err error
+		if cpu, err = os.Create(cpuFile); err != nil {
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(cpu); err != nil {
+			cpu.Close()
+			return nil, err
+		}
+	}
+	return func() error {
+		var errs []error
+		if cpu != nil {
+			pprof.StopCPUProfile()
+			errs = append(errs, cpu.Close())
+		}
+		if memFile != "" {
+			errs = append(errs, writeHeapProfile(memFile))
+		}
+		return errors.Join(errs...)
+	}, nil
+}
+
+// writeHeapProfile writes a heap profile of the live objects after a garbage
+// collection.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+)
+
+// codeReport is the machine readable output of the analyze-code command.
+type codeReport struct {
+	File  string `json:"file"`
+	Valid bool   `json:"valid"`
+	*gen.CodeAnalysis
+}
+
+// analyzeCodeCommand disassembles the contracts of a genesis allocation,
+// prints their opcode usage and the instructions the fork at genesis does not
+// support as JSON, and fails if the code is incompatible with the fork.
+func analyzeCodeCommand(args []string) error {
+	fs := flag.NewFlagSet("analyze-code", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: analyze-code [flags] <genesis.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one genesis file")
+	}
+	path := fs.Arg(0)
+	genesis, err := gen.Load(path)
+	if err != nil {
+		return err
+	}
+	analysis, err := gen.AnalyzeCode(genesis)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	report := codeReport{File: path, Valid: analysis.Findings.Errors() == 0, CodeAnalysis: analysis}
+	if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+		return err
+	}
+	if !report.Valid {
+		return fmt.Errorf("%s: %d code errors", path, analysis.Findings.Errors())
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ethereum/execution-specs/pkg/basefee"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// baseFeeParams are the fee market parameters the vectors are generated for:
+// mainnet, and the elasticity multipliers and denominators of common L2s.
+var baseFeeParams = []basefee.Params{
+	basefee.Mainnet,
+	{ElasticityMultiplier: 6, BaseFeeChangeDenominator: 50},
+	{ElasticityMultiplier: 6, BaseFeeChangeDenominator: 250},
+	{ElasticityMultiplier: 10, BaseFeeChangeDenominator: 8},
+}
+
+// baseFeeVectors are the vectors written by basefee --vectors.
+type baseFeeVectors struct {
+	BaseFee      []*basefee.Vector     `json:"baseFee"`
+	Trajectories []*basefee.Trajectory `json:"trajectories"`
+}
+
+// baseFeeCommand simulates the EIP-1559 base fee over a sequence of
+// per-block gas used, or generates boundary vectors of it.
+func baseFeeCommand(args []string) error {
+	fs := flag.NewFlagSet("basefee", flag.ExitOnError)
+	usage := fs.String("gas-used", "max*10,target*10,0*10", "comma separated gas used per block: an amount of gas, target or max with an optional +n or -n offset, each optionally repeated by *n")
+	gasLimit := fs.Uint64("gas-limit", 60000000, "gas limit of every block")
+	baseFee := fs.String("base-fee", "1000000000", "base fee per gas in wei of the first block")
+	number := fs.Uint64("number", 1, "number of the first block")
+	var p basefee.Params
+	fs.Uint64Var(&p.ElasticityMultiplier, "elasticity-multiplier", basefee.DefaultElasticityMultiplier, "EIP-1559 elasticity multiplier, the ratio of the gas limit to the gas target")
+	fs.Uint64Var(&p.BaseFeeChangeDenominator, "base-fee-change-denominator", basefee.DefaultBaseFeeChangeDenominator, "EIP-1559 base fee change denominator, bounding the base fee change per block")
+	asJSON := fs.Bool("json", false, "print the blocks as JSON")
+	vectors := fs.String("vectors", "", "write boundary vectors and trajectories of the mainnet and common L2 parameters to this file instead")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: basefee [flags]\n")
+		fmt.Fprintf(fs.Output(), "       basefee --vectors vectors.json\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("unexpected arguments")
+	}
+	if *vectors != "" {
+		out, err := buildBaseFeeVectors()
+		if err != nil {
+			return err
+		}
+		return writeJSON(*vectors, out)
+	}
+	fee, ok := math.ParseBig256(*baseFee)
+	if !ok || fee.Sign() < 0 {
+		return fmt.Errorf("invalid base fee %q", *baseFee)
+	}
+	gasUsed, err := basefee.ParseUsage(*usage)
+	if err != nil {
+		return err
+	}
+	blocks, err := basefee.Simulate(p, &basefee.Start{Number: *number, GasLimit: *gasLimit, BaseFee: fee}, gasUsed)
+	if err != nil {
+		return err
+	}
+	if err := checkBaseFees(p, blocks); err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(blocks)
+	}
+	printBaseFees(os.Stdout, blocks)
+	return nil
+}
+
+// checkBaseFees recomputes the base fee of the blocks with go-ethereum. Its
+// fee market parameters are built in, so only the mainnet ones are checked.
+func checkBaseFees(p basefee.Params, blocks []*basefee.Block) error {
+	if p != basefee.Mainnet {
+		return nil
+	}
+	for i := 1; i < len(blocks); i++ {
+		parent, b := blocks[i-1], blocks[i]
+		want := gethBaseFee(parent.GasLimit, parent.GasUsed, parent.BaseFee)
+		if want.Cmp(b.BaseFee) != 0 {
+			return fmt.Errorf("block %d: base fee %v, go-ethereum %v", b.Number, b.BaseFee, want)
+		}
+	}
+	return nil
+}
+
+// gethBaseFee returns go-ethereum's base fee of a post-London block.
+func gethBaseFee(parentGasLimit, parentGasUsed uint64, parentBaseFee *big.Int) *big.Int {
+	parent := &types.Header{Number: big.NewInt(1), GasLimit: parentGasLimit, GasUsed: parentGasUsed, BaseFee: parentBaseFee}
+	return eip1559.CalcBaseFee(params.MergedTestChainConfig, parent)
+}
+
+// buildBaseFeeVectors generates the vectors and trajectories of every set of
+// parameters and checks the mainnet ones against go-ethereum.
+func buildBaseFeeVectors() (*baseFeeVectors, error) {
+	out := new(baseFeeVectors)
+	for _, p := range baseFeeParams {
+		vectors := basefee.Vectors(p)
+		if p == basefee.Mainnet {
+			for _, v := range vectors {
+				if want := gethBaseFee(uint64(v.ParentGasLimit), uint64(v.ParentGasUsed), v.ParentBaseFee.ToInt()); want.Cmp(v.BaseFee.ToInt()) != 0 {
+					return nil, fmt.Errorf("%s: base fee %v, go-ethereum %v", v.Name, v.BaseFee.ToInt(), want)
+				}
+			}
+		}
+		trajectories, err := basefee.Trajectories(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range trajectories {
+			if err := checkBaseFees(p, t.Blocks); err != nil {
+				return nil, fmt.Errorf("%s: %v", t.Name, err)
+			}
+		}
+		out.BaseFee = append(out.BaseFee, vectors...)
+		out.Trajectories = append(out.Trajectories, trajectories...)
+	}
+	return out, nil
+}
+
+// printBaseFees prints the simulated blocks one per line with the change of
+// the base fee relative to the previous block.
+func printBaseFees(w io.Writer, blocks []*basefee.Block) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "block\tgas limit\tgas used\tbase fee\tchange\t")
+	for i, b := range blocks {
+		change := "-"
+		if i > 0 {
+			delta := new(big.Int).Sub(b.BaseFee, blocks[i-1].BaseFee)
+			change = delta.String()
+			if delta.Sign() > 0 {
+				change = "+" + change
+			}
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%v\t%s\t\n", b.Number, b.GasLimit, b.GasUsed, b.BaseFee, change)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/bench"
+)
+
+// benchCommand benchmarks the gas and execution time of opcodes over a
+// parameter matrix.
+func benchCommand(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	output := fs.String("output", "bench.csv", "results file, CSV if the extension is .csv and JSON otherwise")
+	matrixPath := fs.String("matrix", "", "YAML or JSON parameter matrix (default every opcode, cold and warm)")
+	opcodes := fs.String("opcodes", "", "comma separated opcodes to benchmark, replacing those of the matrix")
+	fork := fs.String("fork", "Prague", "fork the workloads are executed on")
+	t8n := fs.String("t8n", "", "t8n command executing the workloads, e.g. \"evm t8n\" (default the built-in go-ethereum EVM)")
+	runs := fs.Int("runs", 5, "executions per workload, the fastest one is reported")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: bench [flags]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("unexpected arguments")
+	}
+	matrix := bench.DefaultMatrix()
+	if *matrixPath != "" {
+		var err error
+		if matrix, err = bench.LoadMatrix(*matrixPath); err != nil {
+			return err
+		}
+	}
+	if *opcodes != "" {
+		matrix.Opcodes = strings.Split(*opcodes, ",")
+	}
+	workloads, err := matrix.Expand()
+	if err != nil {
+		return err
+	}
+	env, err := bench.NewEnv(*fork)
+	if err != nil {
+		return err
+	}
+	var runner bench.Runner = bench.InProcess{}
+	if *t8n != "" {
+		runner = &bench.T8n{Command: strings.Fields(*t8n)}
+	}
+	var results []*bench.Result
+	for _, opcode := range matrix.Opcodes {
+		for _, params := range workloads {
+			w, err := bench.Generate(opcode, params)
+			if err != nil {
+				return err
+			}
+			result, err := bench.Measure(runner, env, w, *runs)
+			if err != nil {
+				return fmt.Errorf("%s: %v", w.Opcode, err)
+			}
+			results = append(results, result)
+		}
+	}
+	if strings.EqualFold(filepath.Ext(*output), ".csv") {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := bench.WriteCSV(f, results); err != nil {
+			return err
+		}
+	} else if err := writeJSON(*output, results); err != nil {
+		return err
+	}
+	fmt.Printf("Measured %d workloads with %s, results written to %s\n", len(results), runner.Name(), *output)
+	return nil
+}
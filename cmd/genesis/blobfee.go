@@ -0,0 +1,244 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ethereum/execution-specs/pkg/blobfee"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// blobFeeVectors are the vectors written by blobfee --vectors.
+type blobFeeVectors struct {
+	ExcessBlobGas []*blobfee.ExcessVector `json:"excessBlobGas"`
+	BlobBaseFee   []*blobfee.FeeVector    `json:"blobBaseFee"`
+	Schedule      *blobfee.ScheduleVector `json:"schedule"`
+}
+
+// blobFeeCommand simulates the blob fee market of a chain config over a
+// sequence of per-block blob usage, or generates boundary vectors of it.
+func blobFeeCommand(args []string) error {
+	fs := flag.NewFlagSet("blobfee", flag.ExitOnError)
+	usage := fs.String("blobs", "max*10,target*10,0*10", "comma separated blobs per block: a count, target or max, each optionally repeated by *n")
+	fork := fs.String("fork", "", "start at the activation of the named fork (default the last fork changing the blob parameters)")
+	startTime := fs.String("time", "", "timestamp of the first block, instead of --fork")
+	blockTime := fs.Uint64("block-time", 12, "seconds between blocks")
+	excess := fs.Uint64("excess-blob-gas", 0, "excess blob gas of the first block")
+	baseFee := fs.String("base-fee", "1000000000", "execution base fee per gas in wei, constant over the simulation, for the EIP-7918 reserve price")
+	asJSON := fs.Bool("json", false, "print the blocks as JSON")
+	vectors := fs.String("vectors", "", "write boundary vectors of the mainnet blob forks to this file instead")
+	schedule := forks.RegisterFlags(fs)
+	var blobSchedule forks.BlobOverrides
+	fs.Var(&blobSchedule, "blob-schedule", "override the blob parameters of a fork as fork=target,max[,baseFeeUpdateFraction] (may be repeated)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: blobfee [flags] [network | genesis.json | config.json]\n")
+		fmt.Fprintf(fs.Output(), "       blobfee --vectors vectors.json\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *vectors != "" {
+		if fs.NArg() != 0 {
+			fs.Usage()
+			return errors.New("unexpected arguments")
+		}
+		out, err := buildBlobFeeVectors()
+		if err != nil {
+			return err
+		}
+		return writeJSON(*vectors, out)
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("expected at most one network name, genesis or chain config file")
+	}
+	source := "mainnet"
+	if fs.NArg() == 1 {
+		source = fs.Arg(0)
+	}
+	var (
+		genesis *core.Genesis
+		err     error
+	)
+	if makeGenesis, ok := gen.Networks[source]; ok {
+		genesis = makeGenesis()
+	} else if genesis, err = loadChainConfig(source); err != nil {
+		return err
+	}
+	if genesis.Config == nil {
+		return fmt.Errorf("%s: missing chain config", source)
+	}
+	config := genesis.Config
+	if err := schedule.Apply(config); err != nil {
+		return err
+	}
+	if err := blobSchedule.Apply(config); err != nil {
+		return err
+	}
+
+	start := &blobfee.Start{Number: 1, BlockTime: *blockTime, ExcessBlobGas: *excess}
+	fee, ok := math.ParseBig256(*baseFee)
+	if !ok || fee.Sign() < 0 {
+		return fmt.Errorf("invalid base fee %q", *baseFee)
+	}
+	start.BaseFee = fee
+	switch {
+	case *startTime != "":
+		t, ok := math.ParseUint64(*startTime)
+		if !ok {
+			return fmt.Errorf("invalid timestamp %q", *startTime)
+		}
+		start.Time = t
+	default:
+		if start.Time, err = blobForkTime(config, *fork); err != nil {
+			return err
+		}
+	}
+	blocks, err := blobSimulation(config, start, *usage)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(blocks)
+	}
+	printBlobFees(os.Stdout, blocks)
+	return nil
+}
+
+// blobForkTime returns the activation time of the named fork or, without a
+// name, of the last scheduled fork with blob parameters.
+func blobForkTime(config *params.ChainConfig, name string) (uint64, error) {
+	if name != "" {
+		a, err := forks.ActivationOf(config, name)
+		switch {
+		case err != nil:
+			return 0, err
+		case a == nil:
+			return 0, fmt.Errorf("%s is not scheduled", name)
+		case a.Time == nil:
+			return 0, fmt.Errorf("%s is not a timestamp fork", a.Fork)
+		}
+		return *a.Time, nil
+	}
+	var last *uint64
+	for _, a := range forks.Timeline(config) {
+		if a.Blob != nil {
+			last = a.Time
+		}
+	}
+	if last == nil {
+		return 0, errors.New("no fork with blob parameters is scheduled")
+	}
+	return *last, nil
+}
+
+// blobSimulation runs the simulation and checks every block against
+// go-ethereum's fee market.
+func blobSimulation(config *params.ChainConfig, start *blobfee.Start, spec string) ([]*blobfee.Block, error) {
+	usage, err := blobfee.ParseUsage(spec)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := blobfee.Simulate(config, start, usage)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBlobFees(config, blocks, start.BaseFee); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// checkBlobFees recomputes the excess blob gas, blob base fee and blob limit
+// of the blocks with go-ethereum.
+func checkBlobFees(config *params.ChainConfig, blocks []*blobfee.Block, baseFee *big.Int) error {
+	var parent *types.Header
+	for _, b := range blocks {
+		header := &types.Header{
+			Number:        new(big.Int).SetUint64(b.Number),
+			Time:          b.Time,
+			BaseFee:       baseFee,
+			ExcessBlobGas: &b.ExcessBlobGas,
+			BlobGasUsed:   &b.BlobGasUsed,
+		}
+		if parent != nil {
+			if want := eip4844.CalcExcessBlobGas(config, parent, b.Time); want != b.ExcessBlobGas {
+				return fmt.Errorf("block %d: excess blob gas %d, go-ethereum %d", b.Number, b.ExcessBlobGas, want)
+			}
+		}
+		if want := eip4844.CalcBlobFee(config, header); want.Cmp(b.BlobBaseFee) != 0 {
+			return fmt.Errorf("block %d: blob base fee %v, go-ethereum %v", b.Number, b.BlobBaseFee, want)
+		}
+		if want := eip4844.MaxBlobsPerBlock(config, b.Time); want != b.Params.Max {
+			return fmt.Errorf("block %d: maximum of %d blobs, go-ethereum %d", b.Number, b.Params.Max, want)
+		}
+		parent = header
+	}
+	return nil
+}
+
+// buildBlobFeeVectors generates the vectors of every mainnet blob fork and
+// checks them against go-ethereum.
+func buildBlobFeeVectors() (*blobFeeVectors, error) {
+	out := new(blobFeeVectors)
+	for _, fork := range blobfee.VectorForks {
+		excess, fees, err := blobfee.Vectors(fork)
+		if err != nil {
+			return nil, err
+		}
+		config, err := blobfee.ForkConfig(fork)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range excess {
+			parentExcess, parentUsed := uint64(v.ParentExcessBlobGas), uint64(v.ParentBlobGasUsed)
+			parent := &types.Header{Number: new(big.Int), BaseFee: v.ParentBaseFee.ToInt(), ExcessBlobGas: &parentExcess, BlobGasUsed: &parentUsed}
+			if want := eip4844.CalcExcessBlobGas(config, parent, 0); want != uint64(v.ExcessBlobGas) {
+				return nil, fmt.Errorf("%s %s: excess blob gas %d, go-ethereum %d", fork, v.Name, v.ExcessBlobGas, want)
+			}
+		}
+		for _, v := range fees {
+			excess := uint64(v.ExcessBlobGas)
+			if want := eip4844.CalcBlobFee(config, &types.Header{ExcessBlobGas: &excess}); want.Cmp(v.BlobBaseFee.ToInt()) != 0 {
+				return nil, fmt.Errorf("%s %s: blob base fee %v, go-ethereum %v", fork, v.Name, v.BlobBaseFee, want)
+			}
+		}
+		out.ExcessBlobGas = append(out.ExcessBlobGas, excess...)
+		out.BlobBaseFee = append(out.BlobBaseFee, fees...)
+	}
+	schedule, err := blobfee.Schedule()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBlobFees(schedule.Config, schedule.Blocks, schedule.BaseFee.ToInt()); err != nil {
+		return nil, err
+	}
+	out.Schedule = schedule
+	return out, nil
+}
+
+// printBlobFees prints the simulated blocks one per line, marking the blocks
+// whose excess blob gas was reduced by the EIP-7918 reserve price.
+func printBlobFees(w io.Writer, blocks []*blobfee.Block) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "block\ttimestamp\tfork\ttarget/max\tblobs\texcess blob gas\tblob base fee\t")
+	for _, b := range blocks {
+		var reserve string
+		if b.ReservePrice {
+			reserve = "reserve price"
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%d/%d\t%d\t%d\t%v\t%s\n", b.Number, b.Time, b.Params.Fork, b.Params.Target, b.Params.Max, b.Blobs, b.ExcessBlobGas, b.BlobBaseFee, reserve)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/chainbuilder"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// chainCommand extends a genesis with a chain of empty or lightly filled
+// blocks and exports it as RLP.
+func chainCommand(args []string) error {
+	fs := flag.NewFlagSet("chain", flag.ExitOnError)
+	output := fs.String("output", "chain.rlp", "output file of the concatenated block RLP, gzip compressed if it ends in .gz")
+	var opts chainbuilder.Options
+	fs.IntVar(&opts.Blocks, "blocks", 1024, "number of blocks after the genesis")
+	fs.Uint64Var(&opts.BlockTime, "block-time", 12, "seconds between blocks")
+	fs.Uint64Var(&opts.GasLimit, "gas-limit", 0, "gas limit the blocks move towards (default the genesis gas limit)")
+	coinbase := fs.String("coinbase", "", "fee recipient of every block (default the zero address)")
+	extra := fs.String("extra-data", "", "hex encoded extraData of every block")
+	fs.IntVar(&opts.Transfers, "transfers", 0, "value transfers of one wei to fresh accounts in every block")
+	key := fs.String("key", "45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8", "hex encoded private key of the transfer sender, which the genesis must fund")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: chain [flags] <genesis.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one genesis file")
+	}
+	genesis, err := gen.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *coinbase != "" {
+		if !common.IsHexAddress(*coinbase) {
+			return fmt.Errorf("invalid coinbase %q", *coinbase)
+		}
+		opts.Coinbase = common.HexToAddress(*coinbase)
+	}
+	if *extra != "" {
+		if opts.ExtraData, err = hexutil.Decode(*extra); err != nil {
+			return fmt.Errorf("invalid extra data: %v", err)
+		}
+	}
+	if opts.Transfers > 0 {
+		if opts.Key, err = crypto.HexToECDSA(strings.TrimPrefix(*key, "0x")); err != nil {
+			return fmt.Errorf("invalid sender key: %v", err)
+		}
+		sender := crypto.PubkeyToAddress(opts.Key.PublicKey)
+		if account, ok := genesis.Alloc[sender]; !ok || account.Balance == nil || account.Balance.Sign() == 0 {
+			return fmt.Errorf("transfer sender %s is not funded by the genesis", sender.Hex())
+		}
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	buf := bufio.NewWriter(f)
+	var w io.Writer = buf
+	var zw *gzip.Writer
+	if strings.HasSuffix(*output, ".gz") {
+		zw = gzip.NewWriter(buf)
+		w = zw
+	}
+	head, err := chainbuilder.Build(genesis, &opts, func(block *types.Block) error {
+		return rlp.Encode(w, block)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return err
+		}
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d blocks to %s\n", opts.Blocks, *output)
+	fmt.Printf("Head block hash:   %s\n", head.Hash().Hex())
+	fmt.Printf("Head state root:   %s\n", head.Root.Hex())
+	return nil
+}
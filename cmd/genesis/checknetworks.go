@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// knownGenesis lists the expected genesis block hash and state root of every
+// supported network. The values are the ones published for the networks and
+// must never change; a mismatch means the embedded presets or the generation
+// logic drifted.
+var knownGenesis = map[string]struct {
+	hash common.Hash
+	root common.Hash
+}{
+	"mainnet": {
+		hash: common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"),
+		root: common.HexToHash("0xd7f8974fb5ac78d9ac099b9ad5018bedc2ce0a72dad1827a1709da30580f0544"),
+	},
+	"sepolia": {
+		hash: common.HexToHash("0x25a5cc106eea7138acab33231d7160d69cb777ee0c2c553fcddf5138993e6dd9"),
+		root: common.HexToHash("0x5eb6e371a698b8d68f665192350ffcecbbbf322916f4b51bd79bb6887da3f494"),
+	},
+	"holesky": {
+		hash: common.HexToHash("0xb5f7f912443c940f21fd611f12828d75b534364ed9e95ca4e307729a4661bde4"),
+		root: common.HexToHash("0x69d8c9d72f6fa4ad42d4702b433707212f90db395eb54dc20bc85de253788783"),
+	},
+	"hoodi": {
+		hash: common.HexToHash("0xbbe312868b376a3001692a646dd2d7d1e4406380dfd86b98aa8a34d1557c971b"),
+		root: common.HexToHash("0xda87d7f5f91c51508791bbcbd4aa5baf04917830b86985eeb9ad3d5bfb657576"),
+	},
+}
+
+// checkNetworksCommand regenerates the genesis of every supported network and
+// compares it against the known values.
+func checkNetworksCommand(args []string) error {
+	fs := flag.NewFlagSet("check-networks", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: check-networks\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("unexpected arguments")
+	}
+	var failures int
+	for _, name := range gen.NetworkNames() {
+		block := gen.Networks[name]().ToBlock()
+		want, ok := knownGenesis[name]
+		switch {
+		case !ok:
+			fmt.Printf("%-8s FAIL no expected values, add them to knownGenesis\n", name)
+			failures++
+		case block.Hash() != want.hash || block.Root() != want.root:
+			fmt.Printf("%-8s FAIL\n", name)
+			fmt.Printf("    block hash: have %s, want %s\n", block.Hash().Hex(), want.hash.Hex())
+			fmt.Printf("    state root: have %s, want %s\n", block.Root().Hex(), want.root.Hex())
+			failures++
+		default:
+			fmt.Printf("%-8s ok   %s\n", name, block.Hash().Hex())
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d networks do not match their known genesis", failures, len(gen.Networks))
+	}
+	return nil
+}
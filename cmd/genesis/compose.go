@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+)
+
+// composeCommand applies overlay files in order on top of the allocation of
+// a base genesis, such as that of a devnet, keeping its chain config and
+// header.
+func composeCommand(args []string) error {
+	fs := flag.NewFlagSet("compose", flag.ExitOnError)
+	output := fs.String("output", "genesis.json", "path of the composed genesis file")
+	provenance := fs.String("provenance", "", "also write the provenance report of the composition as JSON to this path")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: compose [flags] <base genesis.json> <overlay.yaml>...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errors.New("expected a base genesis and at least one overlay file")
+	}
+	genesis, err := gen.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	overlays := make([]*alloc.Overlay, 0, fs.NArg()-1)
+	for _, path := range fs.Args()[1:] {
+		o, err := alloc.LoadOverlay(path)
+		if err != nil {
+			return err
+		}
+		overlays = append(overlays, o)
+	}
+	c := alloc.NewComposition(genesis.Alloc, fs.Arg(0), nil)
+	for _, o := range overlays {
+		c.Apply(o)
+	}
+	if err := writeJSON(*output, genesis); err != nil {
+		return err
+	}
+	prov := c.Provenance()
+	if *provenance != "" {
+		if err := writeJSON(*provenance, prov); err != nil {
+			return err
+		}
+	}
+	printLayers(prov)
+	fmt.Printf("Genesis hash: %s\n", genesis.ToBlock().Hash().Hex())
+	return nil
+}
+
+// printLayers prints what every layer of a composition changed.
+func printLayers(prov *alloc.CompositionProvenance) {
+	for _, l := range prov.Layers {
+		fmt.Printf("Layer %s: %d accounts created, %d patched, %d deleted\n", l.Name, l.Created, l.Patched, l.Deleted)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/backend"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// crossCheckTimeout bounds the state root computation of a single cross-check
+// backend.
+const crossCheckTimeout = 30 * time.Minute
+
+// crossCheck recomputes the state root of the genesis allocation with every
+// backend and fails unless all of them agree with the root of the generated
+// genesis block.
+func crossCheck(defs []string, genesis *core.Genesis, root common.Hash, jobs int) error {
+	if genesis.IsVerkle() {
+		return errors.New("cross-checking a verkle genesis is not supported")
+	}
+	for _, def := range defs {
+		b, err := backend.Parse(def, jobs, crossCheckTimeout)
+		if err != nil {
+			return err
+		}
+		have, err := b.StateRoot(genesis.Alloc)
+		if err != nil {
+			return err
+		}
+		if have != root {
+			return fmt.Errorf("state root mismatch: %s computes %s, expected %s", b.Name(), have.Hex(), root.Hex())
+		}
+		fmt.Printf("Cross-checked:     %s\n", b.Name())
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fieldChange is a JSON field whose value differs between two genesis files.
+// A missing value is represented by null.
+type fieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// slotChange is a storage slot whose value differs between two accounts.
+type slotChange struct {
+	Slot common.Hash  `json:"slot"`
+	Old  *common.Hash `json:"old"`
+	New  *common.Hash `json:"new"`
+}
+
+// accountDiff lists the differences of an account present in both genesis
+// allocations.
+type accountDiff struct {
+	Address common.Address `json:"address"`
+	Fields  []fieldChange  `json:"fields,omitempty"`
+	Storage []slotChange   `json:"storage,omitempty"`
+}
+
+// genesisDiff is the semantic difference between two genesis files.
+type genesisDiff struct {
+	Config       []fieldChange    `json:"config,omitempty"`
+	Header       []fieldChange    `json:"header,omitempty"`
+	Added        []common.Address `json:"added,omitempty"`
+	Removed      []common.Address `json:"removed,omitempty"`
+	Modified     []accountDiff    `json:"modified,omitempty"`
+	OldStateRoot common.Hash      `json:"oldStateRoot"`
+	NewStateRoot common.Hash      `json:"newStateRoot"`
+}
+
+// empty reports whether the two genesis files are semantically identical.
+func (d *genesisDiff) empty() bool {
+	return len(d.Config) == 0 && len(d.Header) == 0 && len(d.Added) == 0 &&
+		len(d.Removed) == 0 && len(d.Modified) == 0 && d.OldStateRoot == d.NewStateRoot
+}
+
+// diffCommand compares two genesis files and reports their differences.
+func diffCommand(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the differences as JSON")
+	exitCode := fs.Bool("exit-code", false, "exit with a nonzero code if the files differ")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: diff [flags] <old.json> <new.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("expected two genesis files")
+	}
+	oldGenesis, err := gen.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	newGenesis, err := gen.Load(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	diff, err := diffGenesis(oldGenesis, newGenesis)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diff); err != nil {
+			return err
+		}
+	} else {
+		printDiff(os.Stdout, diff)
+	}
+	if *exitCode && !diff.empty() {
+		return errors.New("genesis files differ")
+	}
+	return nil
+}
+
+// diffGenesis computes the semantic difference between two genesis specs.
+func diffGenesis(oldGenesis, newGenesis *core.Genesis) (*genesisDiff, error) {
+	diff := new(genesisDiff)
+
+	oldFields, err := gen.Fields(oldGenesis)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := gen.Fields(newGenesis)
+	if err != nil {
+		return nil, err
+	}
+	oldConfig, _ := oldFields["config"].(map[string]interface{})
+	newConfig, _ := newFields["config"].(map[string]interface{})
+	for _, key := range []string{"config", "alloc"} {
+		delete(oldFields, key)
+		delete(newFields, key)
+	}
+	diff.Config = diffFields("", oldConfig, newConfig)
+	diff.Header = diffFields("", oldFields, newFields)
+
+	for _, addr := range alloc.SortedAddresses(newGenesis.Alloc) {
+		if _, ok := oldGenesis.Alloc[addr]; !ok {
+			diff.Added = append(diff.Added, addr)
+		}
+	}
+	for _, addr := range alloc.SortedAddresses(oldGenesis.Alloc) {
+		newAccount, ok := newGenesis.Alloc[addr]
+		if !ok {
+			diff.Removed = append(diff.Removed, addr)
+			continue
+		}
+		if account := diffAccount(addr, oldGenesis.Alloc[addr], newAccount); account != nil {
+			diff.Modified = append(diff.Modified, *account)
+		}
+	}
+	diff.OldStateRoot = oldGenesis.ToBlock().Root()
+	diff.NewStateRoot = newGenesis.ToBlock().Root()
+	return diff, nil
+}
+
+// diffFields compares two decoded JSON objects recursively, reporting changed
+// leaves by their dotted path.
+func diffFields(prefix string, oldFields, newFields map[string]interface{}) []fieldChange {
+	keys := make(map[string]struct{})
+	for key := range oldFields {
+		keys[key] = struct{}{}
+	}
+	for key := range newFields {
+		keys[key] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var changes []fieldChange
+	for _, key := range sorted {
+		oldValue, newValue := oldFields[key], newFields[key]
+		oldObject, oldOk := oldValue.(map[string]interface{})
+		newObject, newOk := newValue.(map[string]interface{})
+		if oldOk && newOk {
+			changes = append(changes, diffFields(prefix+key+".", oldObject, newObject)...)
+			continue
+		}
+		oldJSON, _ := json.Marshal(oldValue)
+		newJSON, _ := json.Marshal(newValue)
+		if !bytes.Equal(oldJSON, newJSON) {
+			changes = append(changes, fieldChange{Field: prefix + key, Old: oldJSON, New: newJSON})
+		}
+	}
+	return changes
+}
+
+// diffAccount compares two versions of an account, returning nil if they are
+// identical.
+func diffAccount(addr common.Address, oldAccount, newAccount types.Account) *accountDiff {
+	diff := &accountDiff{Address: addr}
+	change := func(field string, oldValue, newValue interface{}) {
+		oldJSON, _ := json.Marshal(oldValue)
+		newJSON, _ := json.Marshal(newValue)
+		if !bytes.Equal(oldJSON, newJSON) {
+			diff.Fields = append(diff.Fields, fieldChange{Field: field, Old: oldJSON, New: newJSON})
+		}
+	}
+	change("balance", alloc.Balance(oldAccount), alloc.Balance(newAccount))
+	change("nonce", oldAccount.Nonce, newAccount.Nonce)
+	if !bytes.Equal(oldAccount.Code, newAccount.Code) {
+		change("codeHash", crypto.Keccak256Hash(oldAccount.Code), crypto.Keccak256Hash(newAccount.Code))
+		change("codeSize", len(oldAccount.Code), len(newAccount.Code))
+	}
+	diff.Storage = diffStorage(oldAccount.Storage, newAccount.Storage)
+
+	if len(diff.Fields) == 0 && len(diff.Storage) == 0 {
+		return nil
+	}
+	return diff
+}
+
+// diffStorage compares two storage maps. Zero values are treated as absent,
+// matching their effect on the storage trie.
+func diffStorage(oldStorage, newStorage map[common.Hash]common.Hash) []slotChange {
+	slots := make(map[common.Hash]struct{})
+	for slot := range oldStorage {
+		slots[slot] = struct{}{}
+	}
+	for slot := range newStorage {
+		slots[slot] = struct{}{}
+	}
+	sorted := make([]common.Hash, 0, len(slots))
+	for slot := range slots {
+		sorted = append(sorted, slot)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+	var changes []slotChange
+	for _, slot := range sorted {
+		oldValue, newValue := oldStorage[slot], newStorage[slot]
+		if oldValue == newValue {
+			continue
+		}
+		change := slotChange{Slot: slot}
+		if oldValue != (common.Hash{}) {
+			change.Old = &oldValue
+		}
+		if newValue != (common.Hash{}) {
+			change.New = &newValue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// printDiff writes a human readable rendering of the difference.
+func printDiff(w io.Writer, diff *genesisDiff) {
+	for _, c := range diff.Config {
+		fmt.Fprintf(w, "config.%s: %s -> %s\n", c.Field, c.Old, c.New)
+	}
+	for _, c := range diff.Header {
+		fmt.Fprintf(w, "%s: %s -> %s\n", c.Field, c.Old, c.New)
+	}
+	for _, addr := range diff.Added {
+		fmt.Fprintf(w, "+ %s\n", addr.Hex())
+	}
+	for _, addr := range diff.Removed {
+		fmt.Fprintf(w, "- %s\n", addr.Hex())
+	}
+	for _, account := range diff.Modified {
+		fmt.Fprintf(w, "~ %s\n", account.Address.Hex())
+		for _, c := range account.Fields {
+			fmt.Fprintf(w, "    %s: %s -> %s\n", c.Field, c.Old, c.New)
+		}
+		for _, s := range account.Storage {
+			fmt.Fprintf(w, "    storage[%s]: %s -> %s\n", s.Slot.Hex(), hashOrNone(s.Old), hashOrNone(s.New))
+		}
+	}
+	if diff.OldStateRoot != diff.NewStateRoot {
+		fmt.Fprintf(w, "state root: %s -> %s\n", diff.OldStateRoot.Hex(), diff.NewStateRoot.Hex())
+	} else {
+		fmt.Fprintf(w, "state root unchanged: %s\n", diff.OldStateRoot.Hex())
+	}
+}
+
+func hashOrNone(h *common.Hash) string {
+	if h == nil {
+		return "none"
+	}
+	return h.Hex()
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Constants of the proof-of-work difficulty adjustment.
+const (
+	minimumDifficulty      = 131072 // lower bound before the bomb is added
+	difficultyBoundDivisor = 2048   // parent difficulty fraction of one adjustment step
+	bombPeriod             = 100000 // blocks per doubling of the bomb
+)
+
+// bombDelays lists the forks delaying the difficulty bomb, each pushing the
+// bomb back by the given number of blocks. Forks in between keep the delay of
+// their predecessor.
+var bombDelays = []struct {
+	fork  string
+	delay uint64
+}{
+	{fork: "Byzantium", delay: 3000000},      // EIP-649
+	{fork: "Constantinople", delay: 5000000}, // EIP-1234
+	{fork: "MuirGlacier", delay: 9000000},    // EIP-2384
+	{fork: "London", delay: 9700000},         // EIP-3554
+	{fork: "ArrowGlacier", delay: 10700000},  // EIP-4345
+	{fork: "GrayGlacier", delay: 11400000},   // EIP-5133
+}
+
+// difficultyParent holds the parent header fields the difficulty of a block
+// depends on.
+type difficultyParent struct {
+	Difficulty *big.Int
+	Number     uint64
+	Timestamp  uint64
+	Uncles     bool
+}
+
+// difficultyVector is a difficulty test case in the format of the
+// DifficultyTests of ethereum/tests.
+type difficultyVector struct {
+	ParentTimestamp    math.HexOrDecimal64   `json:"parentTimestamp"`
+	ParentDifficulty   *math.HexOrDecimal256 `json:"parentDifficulty"`
+	ParentUncles       math.HexOrDecimal64   `json:"parentUncles"`
+	CurrentTimestamp   math.HexOrDecimal64   `json:"currentTimestamp"`
+	CurrentBlockNumber math.HexOrDecimal64   `json:"currentBlockNumber"`
+	CurrentDifficulty  *math.HexOrDecimal256 `json:"currentDifficulty"`
+}
+
+// difficultyCommand computes the proof-of-work difficulty of a block from its
+// parent, or generates test vectors around the bomb and adjustment boundaries.
+func difficultyCommand(args []string) error {
+	fs := flag.NewFlagSet("difficulty", flag.ExitOnError)
+	fork := fs.String("fork", "GrayGlacier", "execution specs fork of the block")
+	parentDifficulty := fs.String("parent-difficulty", "", "difficulty of the parent block")
+	parentNumber := fs.Uint64("parent-number", 0, "number of the parent block")
+	parentTimestamp := fs.Uint64("parent-timestamp", 0, "timestamp of the parent block")
+	parentUncles := fs.Bool("parent-uncles", false, "whether the parent block has uncles")
+	timestamp := fs.Uint64("timestamp", 0, "timestamp of the block")
+	vectors := fs.String("vectors", "", "write edge case vectors of every proof-of-work fork (or only --fork if given) to this file instead")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: difficulty [flags]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("unexpected arguments")
+	}
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	if *vectors != "" {
+		names := powForks()
+		if explicit["fork"] {
+			index, err := forks.Index(*fork)
+			if err != nil {
+				return err
+			}
+			names = []string{forks.Names()[index]}
+		}
+		tests := make(map[string]map[string]difficultyVector)
+		for _, name := range names {
+			cases, err := difficultyVectors(name)
+			if err != nil {
+				return err
+			}
+			tests[name] = cases
+		}
+		return writeJSON(*vectors, tests)
+	}
+	difficulty, ok := math.ParseBig256(*parentDifficulty)
+	if !ok {
+		return fmt.Errorf("invalid parent difficulty %q", *parentDifficulty)
+	}
+	index, err := forks.Index(*fork)
+	if err != nil {
+		return err
+	}
+	parent := difficultyParent{Difficulty: difficulty, Number: *parentNumber, Timestamp: *parentTimestamp, Uncles: *parentUncles}
+	diff, err := calcDifficulty(index, parent, *timestamp)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Difficulty: %s (%s)\n", diff, hexutil.EncodeBig(diff))
+	return nil
+}
+
+// calcDifficulty returns the difficulty of the block following the parent
+// under the rules of the fork with the given index. The merge sets the
+// difficulty to zero.
+func calcDifficulty(fork int, parent difficultyParent, timestamp uint64) (*big.Int, error) {
+	if timestamp <= parent.Timestamp {
+		return nil, fmt.Errorf("timestamp %d is not after the parent timestamp %d", timestamp, parent.Timestamp)
+	}
+	if parent.Difficulty.Sign() <= 0 {
+		return nil, errors.New("parent difficulty must be positive")
+	}
+	if paris, _ := forks.Index("Paris"); fork >= paris {
+		return new(big.Int), nil
+	}
+	var (
+		homestead, _ = forks.Index("Homestead")
+		byzantium, _ = forks.Index("Byzantium")
+		elapsed      = int64(timestamp - parent.Timestamp)
+		factor       int64
+	)
+	switch {
+	case fork >= byzantium: // EIP-100
+		factor = 1 - elapsed/9
+		if parent.Uncles {
+			factor++
+		}
+	case fork >= homestead: // EIP-2
+		factor = 1 - elapsed/10
+	case elapsed < 13:
+		factor = 1
+	default:
+		factor = -1
+	}
+	if factor < -99 {
+		factor = -99
+	}
+	step := new(big.Int).Div(parent.Difficulty, big.NewInt(difficultyBoundDivisor))
+	diff := new(big.Int).Add(parent.Difficulty, step.Mul(step, big.NewInt(factor)))
+	if diff.Cmp(big.NewInt(minimumDifficulty)) < 0 {
+		diff.SetInt64(minimumDifficulty)
+	}
+	// The bomb is computed from a fake block number, the real one less the
+	// delay of the fork.
+	number := parent.Number + 1
+	if delay := bombDelay(fork); number >= delay {
+		number -= delay
+	} else {
+		number = 0
+	}
+	if periods := number / bombPeriod; periods > 1 {
+		diff.Add(diff, new(big.Int).Lsh(big.NewInt(1), uint(periods-2)))
+	}
+	return diff, nil
+}
+
+// bombDelay returns the difficulty bomb delay in effect at the fork with the
+// given index.
+func bombDelay(fork int) uint64 {
+	var delay uint64
+	for _, d := range bombDelays {
+		if index, _ := forks.Index(d.fork); index <= fork {
+			delay = d.delay
+		}
+	}
+	return delay
+}
+
+// powForks returns the names of the forks before the merge, which have a
+// difficulty formula.
+func powForks() []string {
+	paris, _ := forks.Index("Paris")
+	return forks.Names()[:paris]
+}
+
+// difficultyVectors generates the edge case vectors of a fork: blocks right
+// before, at and after the start of the bomb and of its first doublings,
+// block times at the boundaries of the adjustment steps and of the -99 lower
+// bound, with and without parent uncles, and parents at the minimum
+// difficulty. Every vector is cross-checked against the go-ethereum ethash
+// implementation.
+func difficultyVectors(fork string) (map[string]difficultyVector, error) {
+	index, err := forks.Index(fork)
+	if err != nil {
+		return nil, err
+	}
+	config := new(params.ChainConfig)
+	if err := forks.Activate(config, fork); err != nil {
+		return nil, err
+	}
+	var (
+		delay   = bombDelay(index)
+		numbers = []uint64{1, 1000000}
+		elapsed = []uint64{1, 8, 9, 10, 12, 13, 18, 19, 20, 899, 900, 909, 990, 1000, 1010}
+		parents = []*big.Int{big.NewInt(minimumDifficulty), big.NewInt(1 << 34)}
+		uncles  = []bool{false}
+	)
+	for _, periods := range []uint64{0, 2, 3, 10, 40} {
+		start := delay + periods*bombPeriod
+		for _, number := range []uint64{start - 1, start, start + 1} {
+			// The genesis block has no parent to derive a difficulty from.
+			if start > 0 && number > 1 {
+				numbers = append(numbers, number)
+			}
+		}
+	}
+	// Uncles only affect the difficulty since Byzantium.
+	if byzantium, _ := forks.Index("Byzantium"); index >= byzantium {
+		uncles = append(uncles, true)
+	}
+	vectors := make(map[string]difficultyVector)
+	for _, number := range numbers {
+		for _, dt := range elapsed {
+			for _, difficulty := range parents {
+				for _, uncles := range uncles {
+					parent := difficultyParent{Difficulty: difficulty, Number: number - 1, Timestamp: 1000000, Uncles: uncles}
+					diff, err := calcDifficulty(index, parent, parent.Timestamp+dt)
+					if err != nil {
+						return nil, err
+					}
+					header := &types.Header{
+						Difficulty: difficulty,
+						Number:     new(big.Int).SetUint64(parent.Number),
+						Time:       parent.Timestamp,
+						UncleHash:  types.EmptyUncleHash,
+					}
+					if uncles {
+						header.UncleHash = types.CalcUncleHash([]*types.Header{{}})
+					}
+					if want := ethash.CalcDifficulty(config, parent.Timestamp+dt, header); want.Cmp(diff) != 0 {
+						return nil, fmt.Errorf("%s: difficulty of block %d after %ds: have %s, ethash has %s", fork, number, dt, diff, want)
+					}
+					var flag uint64
+					if uncles {
+						flag = 1
+					}
+					name := fmt.Sprintf("number%d_elapsed%d_parent%s_uncles%d", number, dt, difficulty, flag)
+					vectors[name] = difficultyVector{
+						ParentTimestamp:    math.HexOrDecimal64(parent.Timestamp),
+						ParentDifficulty:   (*math.HexOrDecimal256)(difficulty),
+						ParentUncles:       math.HexOrDecimal64(flag),
+						CurrentTimestamp:   math.HexOrDecimal64(parent.Timestamp + dt),
+						CurrentBlockNumber: math.HexOrDecimal64(number),
+						CurrentDifficulty:  (*math.HexOrDecimal256)(diff),
+					}
+				}
+			}
+		}
+	}
+	return vectors, nil
+}
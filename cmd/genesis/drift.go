@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/compress"
+	"github.com/ethereum/execution-specs/pkg/flags"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/version"
+)
+
+// embeddedConfigs are the chain configs of the public networks shipped by the
+// vendored go-ethereum.
+var embeddedConfigs = map[string]*params.ChainConfig{
+	"mainnet": params.MainnetChainConfig,
+	"sepolia": params.SepoliaChainConfig,
+	"holesky": params.HoleskyChainConfig,
+	"hoodi":   params.HoodiChainConfig,
+}
+
+// upstreamConfigs lists the locations of the genesis files the clients
+// defining their public networks in JSON ship in their repositories, by client
+// and network. Geth and Reth define them in code and are only covered by the
+// embedded go-ethereum configs and --client.
+var upstreamConfigs = map[string]map[string]string{
+	"besu": {
+		"mainnet": "https://raw.githubusercontent.com/hyperledger/besu/main/config/src/main/resources/mainnet.json",
+		"sepolia": "https://raw.githubusercontent.com/hyperledger/besu/main/config/src/main/resources/sepolia.json",
+		"holesky": "https://raw.githubusercontent.com/hyperledger/besu/main/config/src/main/resources/holesky.json",
+		"hoodi":   "https://raw.githubusercontent.com/hyperledger/besu/main/config/src/main/resources/hoodi.json",
+	},
+	"nethermind": {
+		"mainnet": "https://raw.githubusercontent.com/NethermindEth/nethermind/master/src/Nethermind/Chains/foundation.json",
+		"sepolia": "https://raw.githubusercontent.com/NethermindEth/nethermind/master/src/Nethermind/Chains/sepolia.json",
+		"holesky": "https://raw.githubusercontent.com/NethermindEth/nethermind/master/src/Nethermind/Chains/holesky.json",
+		"hoodi":   "https://raw.githubusercontent.com/NethermindEth/nethermind/master/src/Nethermind/Chains/hoodi.json",
+	},
+}
+
+// driftField is a chain config field whose upstream value differs from the
+// canonical one. A missing value is represented by null.
+type driftField struct {
+	Field     string          `json:"field"`
+	Canonical json.RawMessage `json:"canonical"`
+	Upstream  json.RawMessage `json:"upstream"`
+}
+
+// driftSource is the comparison of the chain config of a network shipped by
+// a client against the canonical one.
+type driftSource struct {
+	Client   string       `json:"client"`
+	Network  string       `json:"network"`
+	Location string       `json:"location"`
+	Error    string       `json:"error,omitempty"`
+	Drift    []driftField `json:"drift,omitempty"`
+}
+
+// driftReport is the machine readable output of the drift command.
+type driftReport struct {
+	Tool    string        `json:"tool"`
+	Sources []driftSource `json:"sources"`
+	Drifted int           `json:"drifted"` // sources with differing fields
+	Failed  int           `json:"failed"`  // sources which could not be read
+}
+
+// clientSource is a genesis file of a client given by --client.
+type clientSource struct {
+	client, network, location string
+}
+
+// driftCommand compares the chain configs of the public networks shipped by
+// the clients against the canonical ones of the presets.
+func driftCommand(args []string) error {
+	fs := flag.NewFlagSet("drift", flag.ExitOnError)
+	embedded := fs.Bool("embedded", true, "compare the configs of the vendored go-ethereum")
+	fetch := fs.Bool("fetch", false, "fetch and compare the genesis files of Besu and Nethermind from their repositories")
+	var clients flags.Strings
+	fs.Var(&clients, "client", "compare the genesis file of a client as CLIENT:NETWORK=PATH|URL, CLIENT being its format ("+strings.Join(gen.FormatNames(), ", ")+", may be repeated)")
+	var ignored flags.Strings
+	fs.Var(&ignored, "ignore", "chain config field not compared, e.g. muirGlacierBlock or blobSchedule.bpo1 (may be repeated)")
+	output := fs.String("output", "", "file the report is written to instead of stdout")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of every fetch")
+	exitCode := fs.Bool("exit-code", false, "exit with a nonzero code if any config drifted")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: drift [flags] [network...]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	networks := fs.Args()
+	if len(networks) == 0 {
+		networks = gen.NetworkNames()
+	}
+	for _, network := range networks {
+		if _, ok := gen.Networks[network]; !ok {
+			return fmt.Errorf("unknown network %q, supported networks: %s", network, strings.Join(gen.NetworkNames(), ", "))
+		}
+	}
+	var sources []clientSource
+	if *fetch {
+		for _, client := range []string{"besu", "nethermind"} {
+			for _, network := range networks {
+				sources = append(sources, clientSource{client, network, upstreamConfigs[client][network]})
+			}
+		}
+	}
+	for _, def := range clients {
+		spec, location, ok := strings.Cut(def, "=")
+		client, network, ok2 := strings.Cut(spec, ":")
+		if !ok || !ok2 || location == "" {
+			return fmt.Errorf("invalid --client %q, expected CLIENT:NETWORK=PATH|URL", def)
+		}
+		if _, ok := gen.Formats[client]; !ok {
+			return fmt.Errorf("--client %q: unknown client %q, supported clients: %s", def, client, strings.Join(gen.FormatNames(), ", "))
+		}
+		if _, ok := gen.Networks[network]; !ok {
+			return fmt.Errorf("--client %q: unknown network %q", def, network)
+		}
+		sources = append(sources, clientSource{client, network, location})
+	}
+	if !*embedded && len(sources) == 0 {
+		return errors.New("nothing to compare, enable --embedded, --fetch or give --client")
+	}
+	skip := make(map[string]bool)
+	for _, field := range ignored {
+		skip[field] = true
+	}
+
+	report := &driftReport{
+		Tool: fmt.Sprintf("execution-specs genesis drift, go-ethereum v%d.%d.%d", version.Major, version.Minor, version.Patch),
+	}
+	add := func(source driftSource, upstream *params.ChainConfig, err error) {
+		if err == nil {
+			source.Drift, err = driftConfig(gen.Networks[source.Network]().Config, upstream, skip)
+		}
+		if err != nil {
+			source.Error = err.Error()
+			report.Failed++
+		} else if len(source.Drift) > 0 {
+			report.Drifted++
+		}
+		report.Sources = append(report.Sources, source)
+	}
+	if *embedded {
+		location := fmt.Sprintf("go-ethereum v%d.%d.%d (embedded)", version.Major, version.Minor, version.Patch)
+		for _, network := range networks {
+			add(driftSource{Client: "geth", Network: network, Location: location}, embeddedConfigs[network], nil)
+		}
+	}
+	for _, s := range sources {
+		config, err := readClientConfig(s, *timeout)
+		add(driftSource{Client: s.client, Network: s.network, Location: s.location}, config, err)
+	}
+
+	if *output == "" {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		data, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("Compared %d configs, %d drifted, written to %s\n", len(report.Sources), report.Drifted, *output)
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d configs could not be read", report.Failed, len(report.Sources))
+	}
+	if *exitCode && report.Drifted > 0 {
+		return fmt.Errorf("%d of %d configs drifted", report.Drifted, len(report.Sources))
+	}
+	return nil
+}
+
+// readClientConfig reads the genesis file of a client, fetching it if its
+// location is a URL, and decodes its chain config.
+func readClientConfig(s clientSource, timeout time.Duration) (*params.ChainConfig, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if strings.HasPrefix(s.location, "http://") || strings.HasPrefix(s.location, "https://") {
+		data, err = fetchFile(s.location, timeout)
+	} else {
+		data, err = compress.ReadFile(s.location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	config, err := gen.DecodeConfig(s.client, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", s.location, err)
+	}
+	return config, nil
+}
+
+// fetchFile downloads the file at the URL.
+func fetchFile(url string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// driftConfig compares the upstream chain config field by field against the
+// canonical one, after normalizing both, skipping the ignored fields.
+func driftConfig(canonical, upstream *params.ChainConfig, ignored map[string]bool) ([]driftField, error) {
+	canonicalFields, err := configFields(canonical)
+	if err != nil {
+		return nil, err
+	}
+	upstreamFields, err := configFields(upstream)
+	if err != nil {
+		return nil, err
+	}
+	var drift []driftField
+	for _, c := range diffFields("", canonicalFields, upstreamFields) {
+		if ignored[c.Field] {
+			continue
+		}
+		drift = append(drift, driftField{Field: c.Field, Canonical: c.Old, Upstream: c.New})
+	}
+	return drift, nil
+}
+
+// configFields returns the geth JSON encoding of a normalized copy of the
+// chain config as a generic map. Normalizing drops what the client formats
+// cannot express: the support of a DAO fork which is not scheduled and the
+// blob schedule entries of forks which are not scheduled.
+func configFields(config *params.ChainConfig) (map[string]interface{}, error) {
+	normalized := *config
+	if normalized.DAOForkBlock == nil {
+		normalized.DAOForkSupport = false
+	}
+	if blobs := config.BlobScheduleConfig; blobs != nil {
+		schedule := *blobs
+		for _, field := range forks.Fields {
+			if field.Blob != nil && *field.Time(&normalized) == nil {
+				*field.Blob(&schedule) = nil
+			}
+		}
+		normalized.BlobScheduleConfig = &schedule
+	}
+	fields, err := gen.Fields(&core.Genesis{Config: &normalized})
+	if err != nil {
+		return nil, err
+	}
+	values, _ := fields["config"].(map[string]interface{})
+	return values, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// eelsForkCommand maps between a chain config and the execution specs forks:
+// it resolves the fork of a block, or with --fork the activation of a fork.
+func eelsForkCommand(args []string) error {
+	fs := flag.NewFlagSet("eels-fork", flag.ExitOnError)
+	number := fs.String("block", "", "number of the block the fork is resolved for (default the genesis block)")
+	timestamp := fs.String("timestamp", "", "timestamp of the block (default the genesis timestamp)")
+	td := fs.String("td", "", "total difficulty of the chain up to the parent of the block, deciding whether it is past the merge")
+	name := fs.String("fork", "", "print the activation of the named fork instead")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: eels-fork [flags] <network | genesis.json | config.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one network name, genesis or chain config file")
+	}
+	var (
+		genesis *core.Genesis
+		err     error
+	)
+	if makeGenesis, ok := gen.Networks[fs.Arg(0)]; ok {
+		genesis = makeGenesis()
+	} else if genesis, err = loadChainConfig(fs.Arg(0)); err != nil {
+		return err
+	}
+	if genesis.Config == nil {
+		return fmt.Errorf("%s: missing chain config", fs.Arg(0))
+	}
+	config := genesis.Config
+
+	if *name != "" {
+		activation, err := forks.ActivationOf(config, *name)
+		if err != nil {
+			return err
+		}
+		if activation == nil {
+			return fmt.Errorf("%s is not scheduled", *name)
+		}
+		entry := buildTimelineEntry(genesis, *activation)
+		if *asJSON {
+			return printJSON(entry)
+		}
+		printTimeline(os.Stdout, []timelineEntry{entry})
+		return nil
+	}
+
+	var (
+		block = new(big.Int).SetUint64(genesis.Number)
+		time  = genesis.Timestamp
+	)
+	if *number != "" {
+		n, ok := math.ParseBig256(*number)
+		if !ok || n.Sign() < 0 {
+			return fmt.Errorf("invalid block number %q", *number)
+		}
+		block = n
+	}
+	if *timestamp != "" {
+		t, ok := math.ParseUint64(*timestamp)
+		if !ok {
+			return fmt.Errorf("invalid timestamp %q", *timestamp)
+		}
+		time = t
+	}
+	var total *big.Int
+	if *td != "" {
+		var ok bool
+		if total, ok = math.ParseBig256(*td); !ok {
+			return fmt.Errorf("invalid total difficulty %q", *td)
+		}
+	}
+	merged, err := mergedAt(genesis, block, time, total)
+	if err != nil {
+		return err
+	}
+	fork := forks.At(config, block, time, merged)
+	if *asJSON {
+		return printJSON(map[string]interface{}{
+			"block":     block,
+			"timestamp": time,
+			"merged":    merged,
+			"fork":      fork,
+		})
+	}
+	fmt.Println(fork)
+	return nil
+}
+
+// mainnetMergeBlock is the first proof-of-stake block of mainnet, where the
+// execution specs activate Paris by block number.
+var mainnetMergeBlock = big.NewInt(15537394)
+
+// mergedAt decides whether the block of the genesis chain is past the merge,
+// by the total difficulty of its parent if given. Otherwise the block is past
+// the merge if the genesis block reaches the terminal total difficulty or, as
+// no valid config schedules them before the merge, a timestamp fork is
+// active, and it is past the mainnet merge block on mainnet or past the merge
+// netsplit block. Other blocks cannot be decided without the total
+// difficulty.
+func mergedAt(genesis *core.Genesis, block *big.Int, time uint64, total *big.Int) (bool, error) {
+	config := genesis.Config
+	ttd := config.TerminalTotalDifficulty
+	if ttd == nil {
+		return false, nil
+	}
+	if total != nil {
+		return total.Cmp(ttd) >= 0, nil
+	}
+	difficulty := genesis.Difficulty
+	if difficulty == nil {
+		difficulty = params.GenesisDifficulty
+	}
+	if difficulty.Cmp(ttd) >= 0 {
+		return true, nil
+	}
+	if block.Cmp(new(big.Int).SetUint64(genesis.Number)) <= 0 {
+		return false, nil
+	}
+	if config.ShanghaiTime != nil && *config.ShanghaiTime <= time {
+		return true, nil
+	}
+	if config.ChainID != nil && config.ChainID.Cmp(params.MainnetChainConfig.ChainID) == 0 && ttd.Cmp(params.MainnetChainConfig.TerminalTotalDifficulty) == 0 {
+		return block.Cmp(mainnetMergeBlock) >= 0, nil
+	}
+	if netsplit := config.MergeNetsplitBlock; netsplit != nil && block.Cmp(netsplit) >= 0 {
+		return true, nil
+	}
+	return false, fmt.Errorf("cannot decide whether block %v is past the merge, give the total difficulty of its parent with --td", block)
+}
+
+// printJSON prints a value as indented JSON to stdout.
+func printJSON(value interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(value)
+}
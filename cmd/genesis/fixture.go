@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// fixture is a test case of an execution-spec-tests fixture file, either a
+// state test, identified by its env section, or a blockchain test, identified
+// by its genesis block header. Only the fields describing the starting state
+// and the fork configuration are decoded.
+type fixture struct {
+	Pre    map[string]stateTestAccount `json:"pre"`
+	Config *fixtureConfig              `json:"config,omitempty"`
+
+	// State tests.
+	Env  *stateTestEnv              `json:"env,omitempty"`
+	Post map[string]json.RawMessage `json:"post,omitempty"`
+
+	// Blockchain tests.
+	Network string          `json:"network,omitempty"`
+	Header  *fixtureHeader  `json:"genesisBlockHeader,omitempty"`
+	Blocks  []*fixtureBlock `json:"blocks,omitempty"`
+}
+
+// fixtureBlock is a block of a blockchain test. Blocks the client has to
+// reject carry the expected exception.
+type fixtureBlock struct {
+	RLP             hexutil.Bytes `json:"rlp"`
+	ExpectException string        `json:"expectException,omitempty"`
+}
+
+// fixtureConfig is the chain configuration section of recent fixtures.
+type fixtureConfig struct {
+	Network      string                       `json:"network,omitempty"`
+	ChainID      *math.HexOrDecimal256        `json:"chainid,omitempty"`
+	BlobSchedule map[string]fixtureBlobConfig `json:"blobSchedule,omitempty"`
+}
+
+// fixtureBlobConfig is a blob schedule entry of a fixture, keyed by fork name.
+type fixtureBlobConfig struct {
+	Target         math.HexOrDecimal64 `json:"target"`
+	Max            math.HexOrDecimal64 `json:"max"`
+	UpdateFraction math.HexOrDecimal64 `json:"baseFeeUpdateFraction"`
+}
+
+// fixtureHeader is the genesis block header of a blockchain test.
+type fixtureHeader struct {
+	Coinbase      common.Address        `json:"coinbase"`
+	Difficulty    *math.HexOrDecimal256 `json:"difficulty"`
+	Number        math.HexOrDecimal64   `json:"number"`
+	GasLimit      math.HexOrDecimal64   `json:"gasLimit"`
+	Timestamp     math.HexOrDecimal64   `json:"timestamp"`
+	ExtraData     hexutil.Bytes         `json:"extraData"`
+	MixHash       common.Hash           `json:"mixHash"`
+	Nonce         types.BlockNonce      `json:"nonce"`
+	BaseFee       *math.HexOrDecimal256 `json:"baseFeePerGas,omitempty"`
+	BlobGasUsed   *math.HexOrDecimal64  `json:"blobGasUsed,omitempty"`
+	ExcessBlobGas *math.HexOrDecimal64  `json:"excessBlobGas,omitempty"`
+	Hash          common.Hash           `json:"hash"`
+}
+
+// fixtureCommand converts the pre-state of an execution-spec-tests fixture
+// into a client genesis.
+func fixtureCommand(args []string) error {
+	fs := flag.NewFlagSet("fixture", flag.ExitOnError)
+	output := fs.String("output", "genesis.json", "path of the generated genesis file")
+	format := fs.String("format", "geth", "comma separated output formats ("+strings.Join(gen.FormatNames(), ", ")+")")
+	name := fs.String("name", "", "name of the test case (default the only one of the fixture)")
+	fork := fs.String("fork", "", "network of the genesis, a fork or transition name (default the one of the test)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: fixture [flags] <fixture.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one fixture file")
+	}
+	path := fs.Arg(0)
+	test, err := loadFixture(path, name)
+	if err != nil {
+		return err
+	}
+	genesis, err := test.genesis(*fork)
+	if err != nil {
+		return fmt.Errorf("%s: test %s: %v", path, *name, err)
+	}
+	block, err := gen.ToBlock(genesis, runtime.NumCPU())
+	if err != nil {
+		return err
+	}
+	if test.Header != nil && block.Hash() != test.Header.Hash {
+		return fmt.Errorf("%s: test %s: genesis hash mismatch: have %s, fixture has %s", path, *name, block.Hash().Hex(), test.Header.Hash.Hex())
+	}
+	names := strings.Split(*format, ",")
+	for _, name := range names {
+		encode, ok := gen.Formats[name]
+		if !ok {
+			return fmt.Errorf("unknown format %q, supported formats: %s", name, strings.Join(gen.FormatNames(), ", "))
+		}
+		out, err := encode(genesis, nil)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s genesis: %v", name, err)
+		}
+		if err := writeJSON(formatOutput(*output, name, len(names) > 1), out); err != nil {
+			return err
+		}
+	}
+	printGenesisHeader(block.Header())
+	return nil
+}
+
+// loadFixture reads the test case of a fixture file with the given name. If
+// the name is empty the file must hold a single test, the name of which is
+// stored in name.
+func loadFixture(path string, name *string) (*fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tests map[string]*fixture
+	if err := json.Unmarshal(data, &tests); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if *name == "" {
+		if len(tests) != 1 {
+			names := make([]string, 0, len(tests))
+			for n := range tests {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("%s: holds %d tests, select one with --name:\n  %s", path, len(tests), strings.Join(names, "\n  "))
+		}
+		for n := range tests {
+			*name = n
+		}
+	}
+	test, ok := tests[*name]
+	if !ok {
+		return nil, fmt.Errorf("%s: no test named %q", path, *name)
+	}
+	return test, nil
+}
+
+// genesis builds the genesis of the test case. Blockchain tests carry their
+// genesis header and network, state tests their block environment and the
+// forks they have expectations for, of which the latest one is selected.
+func (test *fixture) genesis(network string) (*core.Genesis, error) {
+	if network == "" {
+		switch {
+		case test.Network != "":
+			network = test.Network
+		case test.Config != nil && test.Config.Network != "":
+			network = test.Config.Network
+		case test.Env != nil:
+			network = latestFork(test.Post)
+		}
+		if network == "" {
+			return nil, errors.New("names no known fork, select one with --fork")
+		}
+	}
+	var genesis *core.Genesis
+	switch {
+	case test.Header != nil:
+		genesis = test.Header.genesis()
+	case test.Env != nil:
+		genesis = (&stateTest{Env: *test.Env}).header()
+	default:
+		return nil, errors.New("neither a state nor a blockchain test")
+	}
+	genesis.Config = &params.ChainConfig{ChainID: big.NewInt(1)}
+	if err := forks.ActivateNetwork(genesis.Config, network); err != nil {
+		return nil, err
+	}
+	// The reference tests schedule the DAO fork without requiring its
+	// extra-data, except for the transition onto it.
+	if !strings.Contains(strings.ToLower(network), "todao") {
+		genesis.Config.DAOForkSupport = false
+	}
+	if test.Config != nil {
+		if err := test.Config.apply(genesis.Config); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if genesis.Alloc, err = parsePreState(test.Pre); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+// genesis returns the genesis with the fields of the header, without chain
+// config and allocation.
+func (h *fixtureHeader) genesis() *core.Genesis {
+	genesis := &core.Genesis{
+		Coinbase:   h.Coinbase,
+		Difficulty: new(big.Int),
+		Number:     uint64(h.Number),
+		GasLimit:   uint64(h.GasLimit),
+		Timestamp:  uint64(h.Timestamp),
+		ExtraData:  h.ExtraData,
+		Mixhash:    h.MixHash,
+		Nonce:      h.Nonce.Uint64(),
+		BaseFee:    (*big.Int)(h.BaseFee),
+	}
+	if h.Difficulty != nil {
+		genesis.Difficulty = (*big.Int)(h.Difficulty)
+	}
+	if h.BlobGasUsed != nil {
+		used := uint64(*h.BlobGasUsed)
+		genesis.BlobGasUsed = &used
+	}
+	if h.ExcessBlobGas != nil {
+		excess := uint64(*h.ExcessBlobGas)
+		genesis.ExcessBlobGas = &excess
+	}
+	return genesis
+}
+
+// apply writes the chain id and the blob schedule of the fixture into the
+// chain config.
+func (c *fixtureConfig) apply(config *params.ChainConfig) error {
+	if c.ChainID != nil {
+		config.ChainID = (*big.Int)(c.ChainID)
+	}
+	if len(c.BlobSchedule) == 0 {
+		return nil
+	}
+	if config.BlobScheduleConfig == nil {
+		config.BlobScheduleConfig = new(params.BlobScheduleConfig)
+	}
+	for fork, blob := range c.BlobSchedule {
+		field := forks.FindField(strings.ToLower(fork) + "-time")
+		if field == nil || field.Blob == nil {
+			return fmt.Errorf("blob schedule of unknown fork %q", fork)
+		}
+		*field.Blob(config.BlobScheduleConfig) = &params.BlobConfig{
+			Target:         int(blob.Target),
+			Max:            int(blob.Max),
+			UpdateFraction: uint64(blob.UpdateFraction),
+		}
+	}
+	return nil
+}
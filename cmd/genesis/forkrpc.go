@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// forkRPCTimeout bounds every request to the live node.
+const forkRPCTimeout = 30 * time.Second
+
+// forkRPCBatch is the number of requests sent in one batch.
+const forkRPCBatch = 100
+
+// prestateAccount is the part of a prestate tracer account that matters for
+// finding the touched state, the storage slots read or written.
+type prestateAccount struct {
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+// forkState pulls the state of the requested accounts and storage slots, and
+// of the accounts and slots touched by the given transactions, from a live
+// node and returns it as an allocation. The state is the one after the given
+// block, a number or "latest", which is resolved first so that every query
+// reads the same block; the transactions only determine which state is
+// pulled, their own position in their block is irrelevant. It returns the
+// number of the block.
+func forkState(url, block string, requests []proofRequest, txs []common.Hash) (types.GenesisAlloc, uint64, error) {
+	client, err := rpc.Dial(url)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), forkRPCTimeout)
+	defer cancel()
+	tag := block
+	if n, ok := math.ParseUint64(block); ok {
+		tag = hexutil.EncodeUint64(n)
+	}
+	var head *struct {
+		Number hexutil.Uint64 `json:"number"`
+	}
+	if err := client.CallContext(ctx, &head, "eth_getBlockByNumber", tag, false); err != nil {
+		return nil, 0, fmt.Errorf("block %s: %v", block, err)
+	}
+	if head == nil {
+		return nil, 0, fmt.Errorf("block %s not found", block)
+	}
+	number := uint64(head.Number)
+
+	// Collect the accounts and slots to pull, the explicitly requested ones
+	// and the ones the prestate tracer reports for the transactions.
+	slots := make(map[common.Address]map[common.Hash]bool)
+	for _, r := range requests {
+		if slots[r.address] == nil {
+			slots[r.address] = make(map[common.Hash]bool)
+		}
+		for _, slot := range r.slots {
+			slots[r.address][slot] = true
+		}
+	}
+	for _, hash := range txs {
+		var prestate map[common.Address]*prestateAccount
+		ctx, cancel := context.WithTimeout(context.Background(), forkRPCTimeout)
+		err := client.CallContext(ctx, &prestate, "debug_traceTransaction", hash, map[string]string{"tracer": "prestateTracer"})
+		cancel()
+		if err != nil {
+			return nil, 0, fmt.Errorf("tracing transaction %s: %v", hash.Hex(), err)
+		}
+		for addr, account := range prestate {
+			if slots[addr] == nil {
+				slots[addr] = make(map[common.Hash]bool)
+			}
+			if account == nil {
+				continue
+			}
+			for slot := range account.Storage {
+				slots[addr][slot] = true
+			}
+		}
+	}
+	if len(slots) == 0 {
+		return nil, 0, errors.New("no accounts to fork, give --fork-address or --fork-tx")
+	}
+
+	// Query the accounts in batches, one request per field and slot.
+	var (
+		at       = hexutil.EncodeUint64(number)
+		balances = make(map[common.Address]*hexutil.Big)
+		nonces   = make(map[common.Address]*hexutil.Uint64)
+		codes    = make(map[common.Address]*hexutil.Bytes)
+		values   = make(map[common.Address]map[common.Hash]*common.Hash)
+		batch    []rpc.BatchElem
+	)
+	addresses := make([]common.Address, 0, len(slots))
+	for addr := range slots {
+		addresses = append(addresses, addr)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].Cmp(addresses[j]) < 0 })
+	for _, addr := range addresses {
+		balances[addr], nonces[addr], codes[addr] = new(hexutil.Big), new(hexutil.Uint64), new(hexutil.Bytes)
+		batch = append(batch,
+			rpc.BatchElem{Method: "eth_getBalance", Args: []interface{}{addr, at}, Result: balances[addr]},
+			rpc.BatchElem{Method: "eth_getTransactionCount", Args: []interface{}{addr, at}, Result: nonces[addr]},
+			rpc.BatchElem{Method: "eth_getCode", Args: []interface{}{addr, at}, Result: codes[addr]},
+		)
+		values[addr] = make(map[common.Hash]*common.Hash, len(slots[addr]))
+		for slot := range slots[addr] {
+			values[addr][slot] = new(common.Hash)
+			batch = append(batch, rpc.BatchElem{Method: "eth_getStorageAt", Args: []interface{}{addr, slot, at}, Result: values[addr][slot]})
+		}
+	}
+	for len(batch) > 0 {
+		n := min(len(batch), forkRPCBatch)
+		ctx, cancel := context.WithTimeout(context.Background(), forkRPCTimeout)
+		err := client.BatchCallContext(ctx, batch[:n])
+		cancel()
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, elem := range batch[:n] {
+			if elem.Error != nil {
+				return nil, 0, fmt.Errorf("%s %v: %v", elem.Method, elem.Args[0], elem.Error)
+			}
+		}
+		batch = batch[n:]
+	}
+
+	// Assemble the allocation, leaving out the accounts which do not exist
+	// at the block and the empty slots.
+	allocation := make(types.GenesisAlloc)
+	for _, addr := range addresses {
+		account := types.Account{
+			Balance: (*big.Int)(balances[addr]),
+			Nonce:   uint64(*nonces[addr]),
+			Code:    *codes[addr],
+		}
+		for slot, value := range values[addr] {
+			if *value == (common.Hash{}) {
+				continue
+			}
+			if account.Storage == nil {
+				account.Storage = make(map[common.Hash]common.Hash)
+			}
+			account.Storage[slot] = *value
+		}
+		if account.Balance.Sign() == 0 && account.Nonce == 0 && len(account.Code) == 0 && len(account.Storage) == 0 {
+			continue
+		}
+		allocation[addr] = account
+	}
+	return allocation, number, nil
+}
+
+// parseForkTx parses a transaction hash of --fork-tx.
+func parseForkTx(arg string) (common.Hash, error) {
+	b, err := hexutil.Decode(arg)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid transaction hash %q", arg)
+	}
+	return common.BytesToHash(b), nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/header"
+)
+
+// headerCommand computes the logs bloom and the transactions, receipts and
+// withdrawals roots of raw block contents, or generates conformance vectors
+// of them.
+func headerCommand(args []string) error {
+	fs := flag.NewFlagSet("header", flag.ExitOnError)
+	fork := fs.String("fork", "Prague", "execution specs fork whose encoding rules apply")
+	vectors := fs.String("vectors", "", "write conformance vectors of every fork (or only --fork if given) to this file instead")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: header [flags] <inputs.json>\n")
+		fmt.Fprintf(fs.Output(), "       header [flags] --vectors vectors.json\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	if *vectors != "" {
+		if fs.NArg() != 0 {
+			fs.Usage()
+			return errors.New("unexpected arguments")
+		}
+		names := forks.Names()
+		if explicit["fork"] {
+			names = []string{*fork}
+		}
+		tests := make(map[string]*header.Vector)
+		for _, name := range names {
+			cases, err := header.Vectors(name)
+			if err != nil {
+				return err
+			}
+			for c, vector := range cases {
+				tests[vector.Fork+"_"+c] = vector
+			}
+		}
+		return writeJSON(*vectors, tests)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one inputs file")
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	in := new(header.Inputs)
+	if err := json.Unmarshal(data, in); err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	fields, err := header.Compute(*fork, in)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	out, err := json.MarshalIndent(fields, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
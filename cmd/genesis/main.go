@@ -0,0 +1,1173 @@
+// genesis generates the genesis configuration of the public Ethereum networks
+// from the chain definitions embedded by pkg/presets.
+//
+// Usage:
+//
+//	go run ./cmd/genesis --network sepolia --output sepolia.json
+//	go run ./cmd/genesis validate genesis.json
+//	go run ./cmd/genesis diff old.json new.json
+//	go run ./cmd/genesis upgrade --prague-time 1746612311 genesis.json
+//	go run ./cmd/genesis statetest genesis.json
+//	go run ./cmd/genesis minimize --txs txs.json genesis.json
+//	go run ./cmd/genesis snapshot genesis.json
+//	go run ./cmd/genesis shadow-fork --fork osaka=+2h --chain-id 7012 mainnet
+//	go run ./cmd/genesis check-networks
+//	go run ./cmd/genesis proof genesis.json 0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b=0x01
+//	go run ./cmd/genesis trie-nodes --storage genesis.json
+//	go run ./cmd/genesis merge-alloc --output alloc.json predeploys.json contracts.json funded.csv
+//	go run ./cmd/genesis serve --addr 127.0.0.1:8080
+//	go run ./cmd/genesis fixture --name test_case --format geth,besu fixture.json
+//	go run ./cmd/genesis difficulty --fork Byzantium --parent-difficulty 0x400000000 --parent-number 4369999 --parent-timestamp 1508131303 --timestamp 1508131331
+//	go run ./cmd/genesis bench --matrix matrix.yaml --t8n "evm t8n" --output bench.csv
+//	go run ./cmd/genesis witness --fixture fixture.json --number 2 --output witness.json
+//	go run ./cmd/genesis verify-witness witness.json
+//	go run ./cmd/genesis header --fork Cancun inputs.json
+//	go run ./cmd/genesis schema --output schemas genesis blockchain-test
+//	go run ./cmd/genesis timeline --json sepolia
+//	go run ./cmd/genesis eels-fork --block 19426587 --timestamp 1710338135 mainnet
+//	go run ./cmd/genesis blobfee --blobs max*100,0*100 --bpo3-time 1767000000 --blob-schedule bpo3=21,32 mainnet
+//	go run ./cmd/genesis basefee --gas-used max*20,target-1*5,0*20 --elasticity-multiplier 6 --base-fee-change-denominator 250
+//	go run ./cmd/genesis chain --blocks 100000 --transfers 2 --output chain.rlp.gz genesis.json
+//	go run ./cmd/genesis rpc --queries queries.json --output rpc_fixture.json genesis.json
+//	go run ./cmd/genesis analyze-code genesis.json
+//	go run ./cmd/genesis compose --provenance provenance.json devnet.json scenario.yaml
+//	go run ./cmd/genesis storage-slots --layout token.yaml --address 0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192 --code 0x6000 token.txt
+//	go run ./cmd/genesis token-balances --token 0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192 --slot 0 --supply-slot 2 --base alloc.json holders.csv
+//
+// Additional accounts can be merged into the generated allocation with one or
+// more --alloc files, either in the geth JSON alloc format or as CSV with an
+// address,balance,nonce,code,storage header. Accounts from later files replace
+// earlier ones; every replaced address is reported on stderr.
+//
+// Overlay files given with --overlay patch the resulting allocation in order,
+// for a scenario on top of a base devnet: they set the balance, nonce or code
+// of accounts, creating missing ones, set or remove (with a zero value)
+// storage slots, clear the storage or delete accounts, as documented by
+// alloc.Overlay. The layer which last set every field and slot of an account
+// is written as a provenance report next to the output, replacing its
+// extension with .provenance.json. The compose subcommand applies overlays
+// to an existing genesis file.
+//
+// The --fork flag rewrites the fork schedule so that the given fork, named as
+// in the execution specs (Frontier, ..., Cancun, Prague, Osaka), is active from
+// genesis. The fork schedule can additionally be overridden per fork, e.g.
+// --london-block 0 or --prague-time 1746612311. Passing "none" removes the
+// fork from the schedule. If london becomes active at genesis this way, the
+// initial base fee is written to the header.
+//
+// With --system-contracts the canonical system contract predeploys required
+// by the scheduled forks (EIP-4788, EIP-2935, EIP-7002 and EIP-7251) are added
+// to the allocation.
+//
+// Well-known infrastructure contracts are added with one or more --predeploy
+// flags naming them, currently only create2-deployer, the deterministic
+// deployment proxy at 0x4e59b44847b379578588920cA78FbF26c0B4956C.
+//
+// --deposit-contract embeds the beacon chain deposit contract of mainnet at
+// the given address, or "default" for its mainnet address, with the initial
+// storage of its deposit tree: the zero hashes of every level and, with one
+// or more --deposits files, the branch and count of the genesis deposits.
+// The files are the deposit_data files of the staking deposit CLI, inserted
+// in order, and the deposited ether is the balance of the contract. The
+// address becomes the depositContractAddress of the chain config and of the
+// --cl-config, so that the consensus layer genesis built from the same files
+// finds the deposit root and count the contract reports. The getters of the
+// contract are executed in the go-ethereum EVM and checked against the root
+// computed from the deposits before anything is written.
+//
+// A declarative --template (YAML or JSON) can describe the network, fork,
+// chain id, genesis time, header fields, fork offsets relative to the genesis
+// time and funded accounts. ${NAME} and ${NAME:-default} placeholders in the
+// template are expanded from --var NAME=VALUE flags and the environment.
+// Command line flags take precedence over the template.
+//
+// The --format flag selects the client flavour of the output (geth, besu,
+// erigon, reth or nethermind). Several comma separated formats can be given,
+// in which case each one is written next to --output with the format name
+// added before the file extension.
+//
+// The allocation is streamed to the output account by account and the state
+// root is computed incrementally with stack tries, so that allocations of
+// millions of accounts neither need their whole encoding nor a state database
+// in memory. The storage tries and the 16 subtries of the account trie are
+// built by --jobs concurrent workers.
+//
+// Before the genesis is written the header of the genesis block is checked
+// to carry exactly the fields of the forks active at genesis and the
+// commitments of an empty body, and its roots and hash are printed after
+// writing it. With --verify-hash the tool fails without writing the genesis
+// if the block hash differs from the expected one, turning it into a
+// regression check for known networks.
+//
+// The validate subcommand checks an existing genesis file against the spec
+// invariants (fork ordering, fee market and blob parameters, header fields
+// of forks not active at genesis or deviating from the empty body, EIP-170
+// code size, address checksums and required system contracts) and prints the
+// findings as JSON, exiting with a nonzero code if there are errors.
+//
+// The diff subcommand compares two genesis files semantically, listing the
+// changed chain config and header fields, the added, removed and modified
+// accounts including their storage slots, and the resulting state roots.
+//
+// The upgrade subcommand schedules newer forks in an existing genesis file
+// using the same per-fork flags, adding the blob schedule entries and system
+// contracts they require. Only the affected fields are rewritten, everything
+// else in the file is preserved byte for byte.
+//
+// The statetest subcommand exports the allocation and genesis header as the
+// pre and env sections of an execution spec state test, so that a devnet
+// genesis can serve as the starting state of test cases. With --import a
+// state test is converted back into a genesis activating the given --fork.
+//
+// The minimize subcommand executes a JSON list of signed transactions on top
+// of a genesis and prunes every account and storage slot they do not touch,
+// leaving the minimal pre-state of a test case. The pruned genesis is
+// executed again to check that the transactions still have the same outcome.
+//
+// The snapshot subcommand writes the genesis state as a flat dump in the
+// snapshot layout: account entries keyed by the hashed address with slim RLP
+// values, storage entries keyed by the hashed slot and the contract codes by
+// hash, all in key order. The tries rebuilt from the dump are checked against
+// the genesis state root, making it a reference for snap sync and healing.
+//
+// The shadow-fork subcommand takes the genesis of a live network, given by
+// name or as a file, and reschedules its upcoming forks with --fork
+// name=timestamp, name=+offset (relative to --base-time, default now) or
+// name=none, optionally changing the chain id. Upcoming forks of the live
+// network that are not rescheduled are dropped. The fork order is validated,
+// missing blob schedule entries are added and the changes to the live config
+// are summarized together with the activation dates.
+//
+// The check-networks subcommand regenerates the genesis of every supported
+// network and compares the block hash and state root against the published
+// values embedded in the tool, failing if regenerating the presets or a
+// go-ethereum update changed any of them.
+//
+// The proof subcommand generates Merkle Patricia proofs of accounts and their
+// storage slots in the eth_getProof response format, together with the state
+// root, for a genesis or with --alloc a bare allocation. Accounts and slots
+// which do not exist get proofs of absence.
+//
+// The trie-nodes subcommand writes the nodes of the genesis state trie, the
+// RLP encoding of every node keyed by its hash, so that trie implementations
+// can be checked node by node rather than only by their root. With --storage
+// the nodes of the storage tries are included, grouped by account. Nodes
+// shorter than 32 bytes are embedded in their parent and have no entry.
+//
+// The merge-alloc subcommand combines allocation files, in the formats
+// accepted by --alloc, into one. Later files take precedence. Every address
+// defined by more than one file is reported with the file it was taken from,
+// as a conflict if the accounts differ; with --strict any overlap fails the
+// merge. The source file of every address can be written with --provenance.
+//
+// The compose subcommand applies overlay files, in the format of --overlay, in
+// order on top of the allocation of a base genesis file, keeping its chain
+// config and header, and prints what every layer created, patched and
+// deleted. The provenance report is written with --provenance.
+//
+// The serve subcommand exposes the generator over HTTP for devnet orchestration
+// tools. A template POSTed to /genesis is answered with the genesis block
+// hash, state root and header and the genesis in the formats selected by the
+// format query parameter (default geth), e.g.
+//
+//	curl --data-binary @devnet.yaml 'http://127.0.0.1:8080/genesis?format=geth,nethermind&var=CHAIN_ID=7012'
+//
+// Template variables are taken from the var parameters only, never from the
+// environment of the server.
+//
+// The fixture subcommand turns a test case of an execution-spec-tests fixture,
+// in the state or blockchain test format, into a client genesis in the given
+// --format, so that a failing test can be replayed on a live devnet. The pre
+// section becomes the allocation and the network of the test, including the
+// transition networks such as ShanghaiToCancunAtTime15k, the fork schedule,
+// together with the chain id and blob schedule of the fixture config. The
+// genesis of a blockchain test must reproduce the hash of its genesis header.
+//
+// The difficulty subcommand computes the proof-of-work difficulty of a block
+// from the difficulty, number, timestamp and uncles of its parent, following
+// the formula of the given fork: the Frontier, Homestead (EIP-2) and Byzantium
+// (EIP-100) adjustments and the difficulty bomb with the delays from Byzantium
+// through Gray Glacier. With --vectors it instead writes edge case vectors in
+// the DifficultyTests format around the bomb activation and adjustment
+// boundaries of every proof-of-work fork, each checked against go-ethereum.
+//
+// The bench subcommand benchmarks opcodes over a --matrix of the standardized
+// workload parameters calldata_length, calldata_nonzero, memory_size,
+// warm_access and iterations. Every workload is executed together with a
+// baseline lacking the opcode, in the built-in go-ethereum EVM or with an
+// external --t8n tool such as "evm t8n", and the gas and time per operation
+// are written as CSV or JSON with one row per opcode and parameter set.
+//
+// The witness subcommand imports a JSON list of RLP encoded --blocks on top of
+// a genesis, or the valid blocks of a blockchain test --fixture up to the
+// block --number, and writes the execution witness of the last block: the
+// chain config, the block, the ancestor headers, codes and trie nodes its
+// execution reads, in the format documented in pkg/witness. The
+// verify-witness subcommand executes the block of a witness statelessly,
+// against the witness alone, and checks the resulting state and receipts
+// roots against the block header.
+//
+// The header subcommand computes the logs bloom and the transactions, receipts
+// and withdrawals roots from the raw transactions, receipts and withdrawals of
+// a block, as documented in pkg/header, rejecting contents the --fork cannot
+// encode: typed transactions and receipts before their fork, status codes
+// before Byzantium and withdrawals before Shanghai. With --vectors it instead
+// writes conformance vectors of these commitments for every fork.
+//
+// The schema subcommand writes JSON Schema documents of the genesis, chain
+// config and alloc formats and of the fixture and input formats of the tools,
+// derived from the Go types decoding them, as documented in pkg/schema.
+//
+// The timeline subcommand prints the fork activation schedule of a network,
+// genesis or bare chain config: the activation block, terminal total
+// difficulty or timestamp and date of every scheduled fork, named as in the
+// execution specs, together with the EIPs it enables and its blob parameters.
+// Forks active from the genesis block are marked. With --json the schedule is
+// printed as JSON.
+//
+// The eels-fork subcommand maps a chain config onto the forks of the execution
+// specs: it resolves the fork of the block given by --block and --timestamp,
+// by default the genesis block, and with --fork prints the activation of the
+// named fork instead. Whether the block is past the merge is decided by the
+// total difficulty of its parent given with --td, otherwise by the genesis
+// difficulty, the timestamp forks, the merge block of mainnet and the merge
+// netsplit block; blocks none of them decides require --td.
+//
+// The blobfee subcommand simulates the blob fee market of a network, genesis
+// or bare chain config, by default mainnet, as documented in pkg/blobfee: it
+// runs a block per entry of the --blobs usage, a number of blobs or the
+// target or maximum of the block's fork, from the activation of --fork (by
+// default the last fork changing the blob parameters) or from --time, and
+// prints the excess blob gas and blob base fee of every block. The execution
+// base fee of --base-fee only matters for the EIP-7918 reserve price since
+// Osaka. The fork schedule and blob parameter overrides evaluate schedule
+// changes, and every block is checked against go-ethereum. With --vectors it
+// instead writes boundary vectors of the excess blob gas, the blob base fee
+// and a schedule across the mainnet blob forks.
+//
+// The basefee subcommand simulates the EIP-1559 base fee, as documented in
+// pkg/basefee: it runs a block per entry of the --gas-used sequence, an amount
+// of gas or the target or gas limit of the block offset by some gas, at a
+// constant --gas-limit from the --base-fee of the first block, and prints the
+// base fee of every block. --elasticity-multiplier and
+// --base-fee-change-denominator evaluate fee markets deviating from mainnet;
+// with the mainnet parameters every block is checked against go-ethereum.
+// With --vectors it instead writes boundary vectors of the base fee update
+// and trajectories of the mainnet and common L2 parameters.
+//
+// The chain subcommand extends a post-merge genesis with --blocks valid
+// blocks, --block-time seconds apart, and writes them as concatenated RLP in
+// the format of geth import, gzip compressed if --output ends in .gz. The
+// header fields follow the fork rules at every block, including the system
+// calls, blob gas fields and requests hash, and each block is imported into
+// go-ethereum while building. The blocks are empty unless --transfers adds
+// value transfers to fresh accounts from the --key sender, growing the state
+// for pruning tests.
+//
+// The rpc subcommand answers a --queries file, a JSON list of eth_getBalance,
+// eth_getStorageAt, eth_call and eth_getProof requests against the genesis
+// block, from the genesis state and writes the requests and expected responses
+// as an RPC conformance fixture. Calls without a gas limit are given the
+// genesis gas limit in the written request, so the responses do not depend on
+// client defaults; failing calls are answered with an error object, reverts
+// with code 3 and the return data. Proofs of accounts which do not exist
+// carry the empty code hash and storage root. The responses are checked
+// against the RPC of an in-process go-ethereum node before the fixture is
+// written.
+//
+// The analyze-code subcommand disassembles every contract of the allocation
+// and prints, as JSON, its opcode usage and the instructions the fork active
+// at genesis does not define, naming the fork introducing them. Instructions
+// of a later scheduled fork are warnings, other invalid instructions, EOF and
+// other 0xEF prefixed code and code above the EIP-170 limit are errors, which
+// fail the command. Dead code after a terminating instruction, like compiler
+// metadata, is skipped.
+//
+// The storage-slots subcommand converts assignments to the state variables of
+// a contract, one "path = value" per line such as
+// balances[0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b] = 100, into the raw
+// storage slots of an allocation for the given --address, mergeable with
+// --alloc. The variables and structs are declared in Solidity syntax in the
+// --layout file and laid out as by the Solidity compiler, including packed
+// value types, mappings, dynamic arrays and strings.
+//
+// The token-balances subcommand writes an ERC-20 token holder snapshot, a CSV
+// of address and balance rows in the smallest unit of the token, into the
+// storage of the --token contract: the balance of every holder at its entry
+// of the Solidity balances mapping at --slot, and with --supply-slot the sum
+// of the balances as the total supply. The contract account is taken from
+// the --base allocation or genesis, keeping its code and other storage, or
+// built from --code, --nonce and --balance. The account is written as an
+// allocation mergeable with --alloc.
+//
+// The drift subcommand compares the chain configs of the public networks
+// shipped by the clients field by field against the canonical ones of the
+// presets, and prints the differing fields of every client and network as
+// JSON for release coordination. The configs of the vendored go-ethereum are
+// embedded, --fetch downloads the genesis files of Besu and Nethermind from
+// their repositories and --client compares a genesis file of any supported
+// format, a path or URL. With --exit-code any drift fails the command.
+//
+// The genesis header fields of the network can be overridden with
+// --number, --parent-hash, --extra-data, --nonce, --mix-hash, --coinbase,
+// --gas-limit, --timestamp and --base-fee, or the extraData, nonce, mixHash,
+// coinbase, gasLimit, genesisTime and baseFeePerGas of the template.
+// --genesis-time sets the timestamp as well, resolved from now or now+OFFSET,
+// where the offset is a duration such as 5m, 1h30m or 2d or a number of
+// seconds. Timestamp forks can be scheduled with offsets of the same form
+// relative to the genesis time, as --cancun-time +0 or --prague-time +1h, so
+// devnet launch scripts need not compute and splice in absolute timestamps.
+// The overridden fields are checked against the fork and engine at genesis:
+// the gas limit must be within the protocol bounds, a post-merge genesis must
+// have a zero nonce and at most 32 bytes of extraData, and a Clique genesis a
+// zero nonce, mixHash and coinbase and the extraData layout of its signers.
+//
+// The EIP-1559 fee market is configured with --base-fee, the initial base fee
+// of the genesis header, --elasticity-multiplier and
+// --base-fee-change-denominator for chains deviating from the mainnet gas
+// target and base fee adjustment, and --zero-base-fee for chains without
+// fees. All of them require london at genesis. Formats which cannot express a
+// fee market deviating from mainnet are rejected: geth, erigon and reth have
+// the mainnet parameters built in, besu only supports the zero base fee mode
+// and nethermind only the elasticity multiplier and change denominator.
+//
+// --experimental-aa rip7560 or eip7701 activates an experimental native
+// account abstraction proposal at --experimental-aa-time, the genesis
+// timestamp by default, for prototype devnets. As no fork schedules the
+// proposals, the activation is added to the encoded chain config under the
+// name the prototype clients read, rip7560Time or eip7701Time and
+// rip7560TransitionTimestamp or eip7701TransitionTimestamp in Nethermind
+// chainspecs, and every other client ignores it. RIP-7560 nodes additionally
+// expect the RIP-7712 nonce manager predeploy, inserted with
+// --experimental-aa-nonce-manager ADDRESS=FILE from the hex encoded runtime
+// code of the prototype. The configuration is experimental and changes with
+// the proposals; a warning is printed whenever it is used.
+//
+// Downstream chains, L2s and research forks, extend the generator with one
+// or more --extension files instead of maintaining a fork of it. An extension
+// adds fields to the chain config of every format, to the params of
+// Nethermind chainspecs, and predeploys to the catalog of --predeploy, as
+// declared in YAML or JSON in the layout of pkg/genesis.ExtensionFile.
+// Programs building on pkg/genesis register Go implementations of
+// pkg/genesis.Extension instead, for fields computed from the genesis.
+// Extensions cannot replace fields the tool itself writes.
+//
+// With --clique-signers the genesis is the one of a Clique proof-of-authority
+// network, as used by pre-merge style test chains. The extraData is assembled
+// from the --clique-vanity, the signers in ascending order and the empty seal
+// of the genesis block, and the --clique-period and --clique-epoch configure
+// the engine. The merge must not be active at genesis.
+//
+// With --cl-config the consensus layer parameters of the devnet are written
+// as YAML next to the genesis: the genesis time, the terminal total
+// difficulty, the fork epochs derived from the execution layer timestamps for
+// the --cl-preset and --seconds-per-slot, the blob limits and the deposit
+// contract address. Fork timestamps that do not fall on an epoch boundary are
+// rejected, so the two sides of a devnet cannot drift apart.
+//
+// The generated genesis is canonical: the keys of every object are sorted,
+// the hex strings and addresses are lowercase and numbers are plain integers,
+// so that generating the same genesis twice yields identical files.
+// --canonicalize rewrites an existing genesis, in any client format, into
+// this encoding, in place or to --output if given. With --checksum the alloc
+// addresses are written with their EIP-55 checksum instead.
+//
+// With --fork-rpc the state of a live node is baked into the allocation, to
+// reproduce mainnet incidents as fixtures. The accounts given with
+// --fork-address, each optionally followed by =SLOT,... naming the storage
+// slots to pull, and the accounts and slots touched by the --fork-tx
+// transactions, found with the prestate tracer of debug_traceTransaction,
+// are read as of the end of --fork-block. To replay a transaction on its
+// pre-state, fork the block before it, keeping in mind that the transactions
+// preceding it in its block are not applied. Accounts of --alloc files
+// replace forked ones.
+//
+// Warnings and errors are logged to stderr with log/slog, as text or with
+// --log-format json as JSON lines. A failed run exits with code 1, a genesis
+// failing --verify-hash or --cross-check with code 3, and invalid flags with
+// code 2. For unattended runs --report writes a JSON report of the command
+// line, the files read and written with their sizes and SHA-256 hashes, the
+// genesis block hash and state root, the warnings and the exit code with the
+// error of a failed run.
+//
+// Addresses of --alloc files must be exactly 20 hex encoded bytes. Mixed case
+// addresses failing their EIP-55 checksum and addresses given more than once
+// in different case are reported as warnings, as they usually point at a
+// mistyped or pasted address.
+//
+// With --fund-accounts N the allocation is extended with N test accounts
+// holding --balance wei each. The accounts are derived with BIP-32 along the
+// BIP-44 path m/44'/60'/0'/0/i from the hex --seed, or from the BIP-39 seed of
+// a --mnemonic, so the same accounts are recovered by wallets and development
+// nodes. Their addresses and private keys are written next to the output,
+// replacing its extension with .accounts.json. Accounts from --alloc files
+// replace funded ones.
+//
+// With --state-scheme verkle the allocation is additionally mapped onto the
+// leaves of an EIP-6800 verkle tree: the basic data and code hash leaves of
+// every account, the code chunks and the storage slots. The leaves, keyed by
+// their tree key, and the verkle root are written next to the output,
+// replacing its extension with .verkle.json, and the verkle root is printed
+// after the Merkle Patricia roots. The genesis itself is left unchanged.
+//
+// A genesis representing a chain already at block N, such as a shadow fork or
+// a devnet forked off an existing state, is numbered with --number and linked
+// to its parent with --parent-hash. --history then prefills the ring buffer
+// of the EIP-2935 history contract, which must be in the allocation, with the
+// hashes of the ancestor blocks of a JSON file mapping block numbers to
+// hashes, or with synthetic hashes, keccak256 of the 8 byte block number, for
+// the whole window with "synthetic". The contract so answers for the recent
+// ancestors from the first block on, and the parent hash defaults to the
+// hash of block N-1.
+//
+// With --python the genesis is also written as a Python module for the test
+// harness of the execution specs: the allocation as typed Account, Address,
+// Bytes32 and U256 values of the fork active at genesis, the chain id, header
+// fields, block hash and state root, and a genesis_state pytest fixture
+// building the state, so a single genesis definition backs both client
+// devnets and Python spec tests.
+//
+// With --cross-check eels the state root of the generated allocation is
+// recomputed by the trie of the execution specs, through a state transition
+// of ethereum-spec-evm without transactions, and the command fails unless it
+// matches, so every generated genesis doubles as a differential test of the
+// two trie implementations. Any backend of backend-diff is accepted, such as
+// ref or the evm tool of another client as NAME=COMMAND, and the flag may be
+// repeated.
+//
+// Genesis and allocation files of mainnet scale run into gigabytes of JSON.
+// Every genesis and --alloc file is read gzip or zstd compressed as well, and
+// the output is compressed if --output ends in .gz or .zst. With
+// --chunk-accounts N the allocation is split across chunk files of N
+// accounts each, in address order, named after the output with the chunk
+// number inserted, such as genesis.0000.json.gz. The output then is an index
+// holding the genesis with an empty allocation and the file, account count,
+// address range and SHA-256 hash of every chunk; the genesis tool reads it
+// like a genesis, and every chunk is a bare allocation on its own.
+//
+// With --rlp the RLP encoding of the genesis block is additionally written
+// next to the output, replacing its extension with .rlp.
+//
+// The command is a thin wrapper around the pkg/genesis, pkg/forks and
+// pkg/alloc packages, which other tooling can import to construct genesis
+// files directly.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/flags"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/execution-specs/pkg/ssz"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// reportOverrides logs the replaced accounts.
+func reportOverrides(overrides []alloc.Override) {
+	for _, o := range overrides {
+		logger.Info("Overriding account", "address", o.Address.Hex(), "previous", o.Previous, "source", o.Source)
+	}
+}
+
+// reportAddressWarnings reports the suspicious addresses of an allocation file.
+func reportAddressWarnings(path string, warnings []alloc.AddressWarning) {
+	for _, w := range warnings {
+		warnf("%s: %s", path, w)
+	}
+}
+
+// parseGenesisTime parses the genesis time: a unix timestamp, "now" or
+// "now+OFFSET" with an offset as accepted by relative fork times.
+func parseGenesisTime(s string, now time.Time) (uint64, error) {
+	if v, ok := math.ParseUint64(s); ok {
+		return v, nil
+	}
+	rest, ok := strings.CutPrefix(s, "now")
+	if !ok {
+		return 0, fmt.Errorf("invalid genesis time %q, want a unix timestamp, now or now+OFFSET", s)
+	}
+	at := uint64(now.Unix())
+	if rest == "" {
+		return at, nil
+	}
+	offset, ok := strings.CutPrefix(rest, "+")
+	if !ok {
+		return 0, fmt.Errorf("invalid genesis time %q, want a unix timestamp, now or now+OFFSET", s)
+	}
+	v, err := forks.ParseOffset(offset)
+	if err != nil {
+		return 0, fmt.Errorf("genesis time: %v", err)
+	}
+	return at + v, nil
+}
+
+// parseNonceManager parses the ADDRESS=FILE argument of the nonce manager
+// predeploy and reads its hex encoded runtime code.
+func parseNonceManager(arg string) (common.Address, []byte, error) {
+	addr, path, ok := strings.Cut(arg, "=")
+	if !ok || !common.IsHexAddress(addr) {
+		return common.Address{}, nil, fmt.Errorf("invalid argument %q, want ADDRESS=FILE", arg)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	code, err := hexutil.Decode(strings.TrimSpace(string(data)))
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(code) == 0 {
+		return common.Address{}, nil, fmt.Errorf("%s: empty code", path)
+	}
+	return common.HexToAddress(addr), code, nil
+}
+
+// fundedKeys is the companion file of the funded test accounts, holding the
+// keys of the accounts and the secret they are derived from.
+type fundedKeys struct {
+	Mnemonic string                 `json:"mnemonic,omitempty"`
+	Seed     hexutil.Bytes          `json:"seed"`
+	Accounts []alloc.DerivedAccount `json:"accounts"`
+}
+
+// fundingSeed returns the seed of the funded test accounts, given either hex
+// encoded or as a mnemonic.
+func fundingSeed(seedHex, mnemonic string) ([]byte, error) {
+	switch {
+	case seedHex != "" && mnemonic != "":
+		return nil, fmt.Errorf("--seed and --mnemonic are mutually exclusive")
+	case seedHex != "":
+		seed, err := hexutil.Decode(seedHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed %q: %v", seedHex, err)
+		}
+		return seed, nil
+	case mnemonic != "":
+		return alloc.MnemonicSeed(mnemonic, "")
+	default:
+		return nil, fmt.Errorf("--fund-accounts requires --seed or --mnemonic")
+	}
+}
+
+// printGenesisHeader prints the commitments and the hash of the genesis header.
+func printGenesisHeader(header *types.Header) {
+	fmt.Printf("Block hash:        %s\n", header.Hash().Hex())
+	fmt.Printf("State root:        %s\n", header.Root.Hex())
+	fmt.Printf("Transactions root: %s\n", header.TxHash.Hex())
+	fmt.Printf("Receipts root:     %s\n", header.ReceiptHash.Hex())
+	if header.WithdrawalsHash != nil {
+		fmt.Printf("Withdrawals root:  %s\n", header.WithdrawalsHash.Hex())
+	}
+	if header.RequestsHash != nil {
+		fmt.Printf("Requests hash:     %s\n", header.RequestsHash.Hex())
+	}
+}
+
+// headerFlags maps the flags overriding genesis header fields to the fields.
+var headerFlags = map[string]string{
+	"number":      "number",
+	"parent-hash": "parentHash",
+	"extra-data":  "extraData",
+	"nonce":       "nonce",
+	"mix-hash":    "mixHash",
+	"coinbase":    "coinbase",
+	"gas-limit":   "gasLimit",
+	"timestamp":   "timestamp",
+	"base-fee":    "baseFeePerGas",
+}
+
+// commands maps the subcommand names to their implementations. Without a
+// subcommand a genesis is generated.
+var commands = map[string]func(args []string) error{
+	"validate":       validateCommand,
+	"diff":           diffCommand,
+	"upgrade":        upgradeCommand,
+	"statetest":      statetestCommand,
+	"minimize":       minimizeCommand,
+	"snapshot":       snapshotCommand,
+	"shadow-fork":    shadowForkCommand,
+	"check-networks": checkNetworksCommand,
+	"proof":          proofCommand,
+	"merge-alloc":    mergeAllocCommand,
+	"compose":        composeCommand,
+	"trie-nodes":     trieNodesCommand,
+	"serve":          serveCommand,
+	"fixture":        fixtureCommand,
+	"difficulty":     difficultyCommand,
+	"bench":          benchCommand,
+	"witness":        witnessCommand,
+	"verify-witness": verifyWitnessCommand,
+	"header":         headerCommand,
+	"schema":         schemaCommand,
+	"timeline":       timelineCommand,
+	"eels-fork":      eelsForkCommand,
+	"blobfee":        blobFeeCommand,
+	"basefee":        baseFeeCommand,
+	"chain":          chainCommand,
+	"rpc":            rpcCommand,
+	"analyze-code":   analyzeCodeCommand,
+	"storage-slots":  storageSlotsCommand,
+	"token-balances": tokenBalancesCommand,
+	"drift":          driftCommand,
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				fatalf("%v", err)
+			}
+			return
+		}
+	}
+	generate()
+}
+
+// generate assembles a genesis as configured by the command line flags and
+// writes it in the requested formats.
+func generate() {
+	network := flag.String("network", "mainnet", "network to generate the genesis for ("+strings.Join(gen.NetworkNames(), ", ")+")")
+	output := flag.String("output", "genesis.json", "path of the generated genesis file")
+	format := flag.String("format", "geth", "comma separated output formats ("+strings.Join(gen.FormatNames(), ", ")+")")
+	verifyHash := flag.String("verify-hash", "", "expected genesis block hash, fail on mismatch")
+	exportRLP := flag.Bool("rlp", false, "also write the RLP encoded genesis block")
+	stateScheme := flag.String("state-scheme", "mpt", "state commitment scheme: \"mpt\", or \"verkle\" to also write the EIP-6800 verkle tree of the allocation")
+	jobs := flag.Int("jobs", runtime.NumCPU(), "number of workers computing the state root")
+	var crossChecks flags.Strings
+	flag.Var(&crossChecks, "cross-check", "recompute the state root with the given backend and fail on mismatch: eels, geth, besu, ref or NAME=COMMAND (may be repeated)")
+	withSystemContracts := flag.Bool("system-contracts", false, "insert the system contracts required by the scheduled forks")
+	var predeploys flags.Strings
+	flag.Var(&predeploys, "predeploy", "insert the named infrastructure predeploy ("+strings.Join(gen.PredeployNames(), ", ")+", may be repeated)")
+	depositContract := flag.String("deposit-contract", "", "embed the beacon chain deposit contract at ADDRESS, or \"default\" for the mainnet address")
+	var depositFiles flags.Strings
+	flag.Var(&depositFiles, "deposits", "deposit_data file of genesis validators whose deposits are pre-populated into --deposit-contract (may be repeated)")
+	var extensionFiles flags.Strings
+	flag.Var(&extensionFiles, "extension", "YAML or JSON extension file adding chain config fields and predeploys of a downstream chain (may be repeated)")
+	var allocFiles flags.Strings
+	flag.Var(&allocFiles, "alloc", "JSON or CSV allocation file merged into the genesis alloc (may be repeated)")
+	var overlayFiles flags.Strings
+	flag.Var(&overlayFiles, "overlay", "YAML or JSON overlay file patching the accounts and storage of the genesis alloc, applied in order after --alloc (may be repeated)")
+	forkRPC := flag.String("fork-rpc", "", "JSON-RPC endpoint of a live node to pull the --fork-address and --fork-tx state from")
+	forkBlock := flag.String("fork-block", "latest", "block of the live node whose post-state is pulled, a number or latest")
+	var forkAddresses, forkTxs flags.Strings
+	flag.Var(&forkAddresses, "fork-address", "pull the account ADDRESS[=SLOT,...] and the given storage slots from --fork-rpc (may be repeated)")
+	flag.Var(&forkTxs, "fork-tx", "pull the accounts and slots the transaction touches, as found by the prestate tracer, from --fork-rpc (may be repeated)")
+	forkName := flag.String("fork", "", "activate the given execution specs fork (and all prior forks) at genesis")
+	templatePath := flag.String("template", "", "YAML or JSON genesis template")
+	var templateVars flags.Strings
+	flag.Var(&templateVars, "var", "template variable as NAME=VALUE (may be repeated)")
+	schedule := forks.RegisterFlags(flag.CommandLine)
+	var blobSchedule forks.BlobOverrides
+	flag.Var(&blobSchedule, "blob-schedule", "override the blob parameters of a fork as fork=target,max[,baseFeeUpdateFraction] (may be repeated)")
+	excessBlobGas := flag.Uint64("excess-blob-gas", 0, "excessBlobGas of the genesis header (requires cancun at genesis)")
+	blobGasUsed := flag.Uint64("blob-gas-used", 0, "blobGasUsed of the genesis header (requires cancun at genesis)")
+	mergeMode := flag.String("merge", "", "merge mode of the genesis: \"transition\" (proof-of-work until --ttd) or \"genesis\" (post-merge at genesis)")
+	ttd := flag.String("ttd", "", "terminal total difficulty of the transition mode")
+	cliqueSignerList := flag.String("clique-signers", "", "comma separated signer addresses of a Clique proof-of-authority genesis")
+	cliqueVanity := flag.String("clique-vanity", "", "hex encoded vanity of the Clique extraData, up to 32 bytes")
+	cliquePeriod := flag.Uint64("clique-period", 15, "Clique block period in seconds")
+	cliqueEpoch := flag.Uint64("clique-epoch", 30000, "Clique epoch length in blocks, after which pending votes are reset")
+	flag.String("base-fee", "", "initial baseFeePerGas of the genesis header (requires london at genesis)")
+	flag.String("number", "", "number of the genesis block, for a chain already at block N")
+	flag.String("parent-hash", "", "parentHash of the genesis header")
+	flag.String("extra-data", "", "hex encoded extraData of the genesis header (at most 32 bytes after the merge)")
+	flag.String("nonce", "", "nonce of the genesis header (zero after the merge)")
+	flag.String("mix-hash", "", "mixHash of the genesis header")
+	flag.String("coinbase", "", "coinbase of the genesis header")
+	flag.String("gas-limit", "", "gasLimit of the genesis header")
+	timestamp := flag.String("timestamp", "", "timestamp of the genesis header, the genesisTime of the template")
+	genesisTime := flag.String("genesis-time", "", "genesis timestamp as a unix time, now or now+OFFSET, instead of --timestamp")
+	var market gen.FeeMarket
+	flag.Uint64Var(&market.ElasticityMultiplier, "elasticity-multiplier", params.DefaultElasticityMultiplier, "EIP-1559 elasticity multiplier, the ratio of the gas limit to the gas target")
+	flag.Uint64Var(&market.BaseFeeChangeDenominator, "base-fee-change-denominator", params.DefaultBaseFeeChangeDenominator, "EIP-1559 base fee change denominator, bounding the base fee change per block")
+	flag.BoolVar(&market.ZeroBaseFee, "zero-base-fee", false, "keep the base fee at zero (requires london at genesis)")
+	var aa gen.ExperimentalAA
+	flag.StringVar(&aa.Variant, "experimental-aa", "", "EXPERIMENTAL: activate a native account abstraction proposal on a prototype devnet ("+strings.Join(gen.AAVariantNames(), ", ")+")")
+	flag.Uint64Var(&aa.Time, "experimental-aa-time", 0, "EXPERIMENTAL: activation timestamp of the account abstraction proposal (default the genesis timestamp)")
+	nonceManager := flag.String("experimental-aa-nonce-manager", "", "EXPERIMENTAL: insert the RIP-7712 nonce manager predeploy as ADDRESS=FILE, FILE holding its hex encoded runtime code")
+	clConfig := flag.String("cl-config", "", "also write the matching consensus layer parameters as YAML to this path")
+	history := flag.String("history", "", "prefill the EIP-2935 history contract with the ancestor block hashes of a JSON file, or \"synthetic\" ones")
+	pythonModule := flag.String("python", "", "also write the genesis as a Python module with a pytest fixture for the execution specs to this path")
+	clPreset := flag.String("cl-preset", "mainnet", "consensus layer preset (mainnet or minimal)")
+	secondsPerSlot := flag.Uint64("seconds-per-slot", 12, "consensus layer slot duration")
+	clDepositContract := flag.String("cl-deposit-contract", "", "deposit contract address (default the one of the chain config)")
+	fundAccounts := flag.Int("fund-accounts", 0, "number of test accounts derived from --seed or --mnemonic and funded in the alloc")
+	seedHex := flag.String("seed", "", "hex encoded BIP-32 seed of the funded test accounts")
+	mnemonic := flag.String("mnemonic", "", "BIP-39 mnemonic of the funded test accounts, instead of --seed")
+	fundBalance := flag.String("balance", "1000000000000000000000", "balance in wei of every funded test account")
+	chunkAccounts := flag.Int("chunk-accounts", 0, "split the allocation into chunk files of this many accounts next to an index at --output")
+	checksum := flag.Bool("checksum", false, "write the alloc addresses with their EIP-55 checksum instead of in lowercase")
+	canonicalize := flag.String("canonicalize", "", "rewrite an existing genesis file into the canonical encoding instead of generating one")
+	logFormat := flag.String("log-format", "text", "format of the warnings and errors logged to stderr (text or json)")
+	reportPath := flag.String("report", "", "write a JSON report of the inputs, outputs, hashes and warnings of the run to this path, also on failure")
+	flag.Parse()
+
+	if err := setLogFormat(*logFormat); err != nil {
+		fatalf("%v", err)
+	}
+	if *reportPath != "" {
+		report = newRunReport(*reportPath)
+	}
+
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	if explicit["genesis-time"] {
+		if explicit["timestamp"] {
+			fatalf("--genesis-time and --timestamp are mutually exclusive")
+		}
+		at, err := parseGenesisTime(*genesisTime, time.Now())
+		if err != nil {
+			fatalf("%v", err)
+		}
+		flag.Set("timestamp", strconv.FormatUint(at, 10))
+		explicit["timestamp"] = true
+	}
+
+	if *canonicalize != "" {
+		path := *canonicalize
+		if explicit["output"] {
+			path = *output
+		}
+		report.input(*canonicalize)
+		if err := canonicalizeFile(*canonicalize, path); err != nil {
+			fatalf("failed to canonicalize %s: %v", *canonicalize, err)
+		}
+		report.output(path)
+		fmt.Printf("Canonical genesis written to %s\n", path)
+		report.finish(0, "")
+		return
+	}
+
+	for _, path := range extensionFiles {
+		if err := gen.LoadExtension(path); err != nil {
+			fatalf("failed to load extension: %v", err)
+		}
+		report.input(path)
+	}
+
+	if *stateScheme != "mpt" && *stateScheme != "verkle" {
+		fatalf("unknown state scheme %q, supported schemes: mpt, verkle", *stateScheme)
+	}
+
+	var tmpl *gen.Template
+	if *templatePath != "" {
+		vars, err := gen.ParseVars(templateVars)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if tmpl, err = gen.LoadTemplate(*templatePath, vars); err != nil {
+			fatalf("failed to load template: %v", err)
+		}
+		report.input(*templatePath)
+		if tmpl.Network != "" && !explicit["network"] {
+			*network = tmpl.Network
+		}
+		if tmpl.Fork != "" && !explicit["fork"] {
+			*forkName = tmpl.Fork
+		}
+		if tmpl.SystemContracts && !explicit["system-contracts"] {
+			*withSystemContracts = true
+		}
+		if len(tmpl.Predeploys) > 0 && !explicit["predeploy"] {
+			predeploys = tmpl.Predeploys
+		}
+		if explicit["timestamp"] {
+			tmpl.GenesisTime = *timestamp
+		}
+	}
+	fields := make(map[string]string)
+	if tmpl != nil {
+		fields = tmpl.Header()
+	}
+	for name, field := range headerFlags {
+		if explicit[name] {
+			fields[field] = flag.Lookup(name).Value.String()
+		}
+	}
+	header, err := gen.ParseHeaderOverrides(fields)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	genesis, err := gen.New(*network, *forkName)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if tmpl != nil {
+		if err := tmpl.Apply(genesis); err != nil {
+			fatalf("failed to apply template: %v", err)
+		}
+	}
+	if *mergeMode != "" {
+		var difficulty *big.Int
+		if *ttd != "" {
+			var ok bool
+			if difficulty, ok = math.ParseBig256(*ttd); !ok {
+				fatalf("invalid terminal total difficulty %q", *ttd)
+			}
+		}
+		if err := forks.ApplyMergeMode(genesis, *mergeMode, difficulty); err != nil {
+			fatalf("invalid merge mode: %v", err)
+		}
+	} else if *ttd != "" {
+		fatalf("--ttd requires --merge transition")
+	}
+	base := genesis.Timestamp
+	if header.Timestamp != nil {
+		base = *header.Timestamp
+	}
+	if err := schedule.Resolve(base); err != nil {
+		fatalf("invalid fork schedule: %v", err)
+	}
+	if err := schedule.Apply(genesis.Config); err != nil {
+		fatalf("invalid fork schedule: %v", err)
+	}
+	if *cliqueSignerList != "" {
+		p := gen.CliqueParams{Period: *cliquePeriod, Epoch: *cliqueEpoch}
+		var err error
+		if p.Signers, err = gen.ParseCliqueSigners(*cliqueSignerList); err != nil {
+			fatalf("invalid clique signers: %v", err)
+		}
+		if *cliqueVanity != "" {
+			if p.Vanity, err = hexutil.Decode(*cliqueVanity); err != nil {
+				fatalf("invalid clique vanity %q: %v", *cliqueVanity, err)
+			}
+		}
+		if ttd := genesis.Config.TerminalTotalDifficulty; ttd != nil && ttd.Sign() == 0 {
+			fatalf("clique requires a pre-merge genesis, the merge is active at genesis")
+		}
+		if err := gen.ApplyClique(genesis, p); err != nil {
+			fatalf("invalid clique config: %v", err)
+		}
+	} else if explicit["clique-vanity"] || explicit["clique-period"] || explicit["clique-epoch"] {
+		fatalf("--clique-vanity, --clique-period and --clique-epoch require --clique-signers")
+	}
+	header.Apply(genesis)
+	if *mergeMode == forks.MergeTransition {
+		if err := forks.CheckMergeTransition(genesis); err != nil {
+			fatalf("invalid merge transition: %v", err)
+		}
+	}
+	if err := blobSchedule.Apply(genesis.Config); err != nil {
+		fatalf("invalid blob schedule: %v", err)
+	}
+	if genesis.Config.IsCancun(new(big.Int).SetUint64(genesis.Number), genesis.Timestamp) {
+		// Write the blob fields explicitly rather than relying on the client
+		// defaults.
+		if genesis.ExcessBlobGas == nil || explicit["excess-blob-gas"] {
+			genesis.ExcessBlobGas = excessBlobGas
+		}
+		if genesis.BlobGasUsed == nil || explicit["blob-gas-used"] {
+			genesis.BlobGasUsed = blobGasUsed
+		}
+	} else if explicit["excess-blob-gas"] || explicit["blob-gas-used"] {
+		fatalf("--excess-blob-gas and --blob-gas-used require cancun to be active at genesis")
+	}
+	if number := new(big.Int).SetUint64(genesis.Number); genesis.BaseFee == nil && genesis.Config.IsLondon(number) {
+		// Write the initial base fee explicitly as well once the schedule
+		// activates london at genesis. The built-in networks launched with
+		// london keep the null of their published genesis.
+		if !gen.Networks[*network]().Config.IsLondon(number) {
+			genesis.BaseFee = new(big.Int).SetUint64(params.InitialBaseFee)
+		}
+	}
+	if market.ElasticityMultiplier == 0 || market.BaseFeeChangeDenominator == 0 {
+		fatalf("the elasticity multiplier and base fee change denominator must be positive")
+	}
+	if err := market.Apply(genesis, header.BaseFee); err != nil {
+		fatalf("invalid fee market: %v", err)
+	}
+	if err := header.Check(genesis); err != nil {
+		fatalf("invalid genesis header: %v", err)
+	}
+	if !explicit["experimental-aa-time"] {
+		aa.Time = genesis.Timestamp
+	}
+	if *nonceManager != "" {
+		addr, code, err := parseNonceManager(*nonceManager)
+		if err != nil {
+			fatalf("invalid --experimental-aa-nonce-manager: %v", err)
+		}
+		aa.NonceManager, aa.NonceManagerCode = addr, code
+		_, path, _ := strings.Cut(*nonceManager, "=")
+		report.input(path)
+	}
+	aaAlloc, err := aa.Apply(genesis)
+	if err != nil {
+		fatalf("invalid experimental account abstraction: %v", err)
+	}
+	if aa.Enabled() {
+		warnf("%s is experimental, only its prototype clients activate it at %d", gen.AAVariants[aa.Variant], aa.Time)
+	}
+
+	origins := make(map[common.Address]string)
+	if *withSystemContracts {
+		reportOverrides(alloc.Merge(genesis.Alloc, gen.SystemContractAlloc(genesis.Config), "system-contracts", origins))
+	}
+	if len(predeploys) > 0 {
+		accounts, err := gen.PredeployAlloc(predeploys)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		reportOverrides(alloc.Merge(genesis.Alloc, accounts, "predeploys", origins))
+	}
+	if *depositContract != "" {
+		addr := gen.DefaultDepositContractAddress
+		if *depositContract != "default" {
+			if !common.IsHexAddress(*depositContract) {
+				fatalf("invalid deposit contract address %q", *depositContract)
+			}
+			addr = common.HexToAddress(*depositContract)
+		}
+		var deposits []ssz.DepositData
+		for _, path := range depositFiles {
+			loaded, err := gen.LoadDeposits(path)
+			if err != nil {
+				fatalf("failed to load deposits: %v", err)
+			}
+			report.input(path)
+			deposits = append(deposits, loaded...)
+		}
+		account, err := gen.DepositContractAccount(deposits)
+		if err != nil {
+			fatalf("invalid deposits: %v", err)
+		}
+		if err := gen.CheckDepositContract(account, deposits); err != nil {
+			fatalf("deposit contract check failed: %v", err)
+		}
+		genesis.Config.DepositContractAddress = addr
+		reportOverrides(alloc.Merge(genesis.Alloc, types.GenesisAlloc{addr: account}, "deposit-contract", origins))
+		fmt.Printf("Deposit contract %s with %d deposits, deposit root %s\n", addr.Hex(), len(deposits), ssz.DepositsRoot(deposits).Hex())
+	} else if len(depositFiles) > 0 {
+		fatalf("--deposits requires --deposit-contract")
+	}
+	if len(aaAlloc) > 0 {
+		reportOverrides(alloc.Merge(genesis.Alloc, aaAlloc, "experimental-aa", origins))
+	}
+	if tmpl != nil {
+		accounts, err := tmpl.Alloc()
+		if err != nil {
+			fatalf("failed to apply template: %v", err)
+		}
+		reportOverrides(alloc.Merge(genesis.Alloc, accounts, *templatePath, origins))
+	}
+	// The keys of the funded accounts and the overlay provenance are written
+	// together with the genesis, once it passed the checks.
+	var (
+		keys       *fundedKeys
+		provenance *alloc.CompositionProvenance
+	)
+	if *fundAccounts > 0 {
+		seed, err := fundingSeed(*seedHex, *mnemonic)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		accounts, err := alloc.DeriveAccounts(seed, *fundAccounts)
+		if err != nil {
+			fatalf("failed to derive funded accounts: %v", err)
+		}
+		balance, ok := math.ParseBig256(*fundBalance)
+		if !ok {
+			fatalf("invalid balance %q", *fundBalance)
+		}
+		reportOverrides(alloc.Merge(genesis.Alloc, alloc.Fund(accounts, balance), "fund-accounts", origins))
+
+		keys = &fundedKeys{Mnemonic: *mnemonic, Seed: seed, Accounts: accounts}
+	} else if explicit["seed"] || explicit["mnemonic"] || explicit["balance"] {
+		fatalf("--seed, --mnemonic and --balance require --fund-accounts")
+	}
+	if *forkRPC != "" {
+		var (
+			requests []proofRequest
+			txs      []common.Hash
+		)
+		for _, arg := range forkAddresses {
+			request, err := parseProofRequest(arg)
+			if err != nil {
+				fatalf("invalid --fork-address: %v", err)
+			}
+			requests = append(requests, request)
+		}
+		for _, arg := range forkTxs {
+			hash, err := parseForkTx(arg)
+			if err != nil {
+				fatalf("invalid --fork-tx: %v", err)
+			}
+			txs = append(txs, hash)
+		}
+		accounts, number, err := forkState(*forkRPC, *forkBlock, requests, txs)
+		if err != nil {
+			fatalf("failed to fork the state of %s: %v", *forkRPC, err)
+		}
+		fmt.Printf("Forked %d accounts from block %d\n", len(accounts), number)
+		reportOverrides(alloc.Merge(genesis.Alloc, accounts, "fork-rpc", origins))
+	} else if len(forkAddresses) > 0 || len(forkTxs) > 0 || explicit["fork-block"] {
+		fatalf("--fork-address, --fork-tx and --fork-block require --fork-rpc")
+	}
+	for _, path := range allocFiles {
+		accounts, warnings, err := alloc.Load(path)
+		if err != nil {
+			fatalf("failed to load alloc: %v", err)
+		}
+		report.input(path)
+		reportAddressWarnings(path, warnings)
+		reportOverrides(alloc.Merge(genesis.Alloc, accounts, path, origins))
+	}
+	if len(overlayFiles) > 0 {
+		c := alloc.NewComposition(genesis.Alloc, "genesis", origins)
+		for _, path := range overlayFiles {
+			o, err := alloc.LoadOverlay(path)
+			if err != nil {
+				fatalf("failed to load overlay: %v", err)
+			}
+			report.input(path)
+			c.Apply(o)
+		}
+		provenance = c.Provenance()
+		printLayers(provenance)
+	}
+
+	if *history != "" {
+		var hashes map[uint64]common.Hash
+		if *history == "synthetic" {
+			hashes = gen.SyntheticHistory(genesis.Number)
+		} else {
+			var err error
+			if hashes, err = gen.LoadHistory(*history); err != nil {
+				fatalf("failed to load the block history: %v", err)
+			}
+			report.input(*history)
+		}
+		if err := gen.PrefillHistory(genesis, hashes); err != nil {
+			fatalf("failed to prefill the block history: %v", err)
+		}
+	}
+
+	// The genesis block is checked before the genesis is written, so that a
+	// failed check leaves no invalid genesis files behind.
+	block, err := gen.ToBlock(genesis, *jobs)
+	if err != nil {
+		fatalf("failed to compute the genesis state root: %v", err)
+	}
+	if err := gen.CheckHeader(genesis.Config, block.Header()); err != nil {
+		fatalf("invalid genesis header: %v", err)
+	}
+	if report != nil {
+		hash, root := block.Hash(), block.Root()
+		report.BlockHash, report.StateRoot = &hash, &root
+	}
+	if *verifyHash != "" {
+		want, err := hexutil.Decode(*verifyHash)
+		if err != nil || len(want) != common.HashLength {
+			fatalf("invalid expected genesis hash %q", *verifyHash)
+		}
+		if block.Hash() != common.BytesToHash(want) {
+			exitf(exitMismatch, "genesis hash mismatch: have %s, want %s", block.Hash().Hex(), *verifyHash)
+		}
+	}
+	if len(crossChecks) > 0 {
+		if err := crossCheck(crossChecks, genesis, block.Root(), *jobs); err != nil {
+			exitf(exitMismatch, "cross-check failed: %v", err)
+		}
+	}
+
+	if keys != nil {
+		if err := writeJSON(companionPath(*output, ".accounts.json"), keys); err != nil {
+			fatalf("failed to write funded account keys: %v", err)
+		}
+	}
+	if provenance != nil {
+		if err := writeJSON(companionPath(*output, ".provenance.json"), provenance); err != nil {
+			fatalf("failed to write the overlay provenance: %v", err)
+		}
+	}
+	names := strings.Split(*format, ",")
+	for _, name := range names {
+		encode, ok := gen.Formats[name]
+		if !ok {
+			fatalf("unknown format %q, supported formats: %s", name, strings.Join(gen.FormatNames(), ", "))
+		}
+		out, err := encode(genesis, &market)
+		if err != nil {
+			fatalf("failed to encode %s genesis: %v", name, err)
+		}
+		if err := aa.Extend(out, name); err != nil {
+			fatalf("failed to encode %s genesis: %v", name, err)
+		}
+		if err := gen.ExtendConfig(out, genesis, name); err != nil {
+			fatalf("failed to encode %s genesis: %v", name, err)
+		}
+		if *checksum {
+			out.Checksum()
+		}
+		path := formatOutput(*output, name, len(names) > 1)
+		if *chunkAccounts > 0 {
+			index, err := out.WriteChunks(path, *chunkAccounts)
+			if err != nil {
+				fatalf("failed to write %s genesis: %v", name, err)
+			}
+			report.output(path)
+			fmt.Printf("Wrote %d accounts in %d chunks indexed by %s\n", index.Accounts, len(index.Chunks), path)
+			continue
+		}
+		if err := writeJSON(path, out); err != nil {
+			fatalf("failed to write %s genesis: %v", name, err)
+		}
+	}
+
+	if *clConfig != "" {
+		cl := gen.CLParams{Preset: *clPreset, SecondsPerSlot: *secondsPerSlot, GenesisHash: block.Hash()}
+		if *clDepositContract != "" {
+			if !common.IsHexAddress(*clDepositContract) {
+				fatalf("invalid deposit contract address %q", *clDepositContract)
+			}
+			cl.DepositContract = common.HexToAddress(*clDepositContract)
+			if *depositContract != "" && cl.DepositContract != genesis.Config.DepositContractAddress {
+				fatalf("--cl-deposit-contract %s differs from the embedded deposit contract %s", cl.DepositContract.Hex(), genesis.Config.DepositContractAddress.Hex())
+			}
+		}
+		if err := gen.WriteCLConfig(*clConfig, genesis, cl); err != nil {
+			fatalf("failed to write consensus layer config: %v", err)
+		}
+		report.output(*clConfig)
+	}
+	if *pythonModule != "" {
+		if err := gen.WritePython(*pythonModule, genesis, block); err != nil {
+			fatalf("failed to write Python module: %v", err)
+		}
+		report.output(*pythonModule)
+	}
+	printGenesisHeader(block.Header())
+	if *stateScheme == "verkle" {
+		path := companionPath(*output, ".verkle.json")
+		root, err := writeVerkleState(path, genesis)
+		if err != nil {
+			fatalf("failed to convert the allocation to verkle: %v", err)
+		}
+		if report != nil {
+			report.VerkleRoot = &root
+		}
+		fmt.Printf("Verkle root:       %s\n", root.Hex())
+	}
+	if *exportRLP {
+		path := companionPath(*output, ".rlp")
+		if err := writeRLP(path, block); err != nil {
+			fatalf("failed to write RLP genesis: %v", err)
+		}
+		report.output(path)
+	}
+	report.finish(0, "")
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// mergeAllocCommand combines allocation files into one. Later files take
+// precedence over earlier ones.
+func mergeAllocCommand(args []string) error {
+	fs := flag.NewFlagSet("merge-alloc", flag.ExitOnError)
+	output := fs.String("output", "alloc.json", "output file of the merged allocation")
+	provenance := fs.String("provenance", "", "also write the source file of every address as JSON to this path")
+	strict := fs.Bool("strict", false, "fail if an address is defined by more than one file, even with identical accounts")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: merge-alloc [flags] <alloc.json | alloc.csv | genesis.json>...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("expected at least one allocation file")
+	}
+	allocs := make([]types.GenesisAlloc, fs.NArg())
+	for i, path := range fs.Args() {
+		accounts, warnings, err := alloc.Load(path)
+		if err != nil {
+			return err
+		}
+		reportAddressWarnings(path, warnings)
+		allocs[i] = accounts
+	}
+	merged, prov, err := alloc.MergeAll(fs.Args(), allocs)
+	if err != nil {
+		return err
+	}
+	var conflicts int
+	for _, o := range prov.Overlaps {
+		kind := "Duplicate"
+		if o.Conflict {
+			kind = "Conflict"
+			conflicts++
+		}
+		fmt.Fprintf(os.Stderr, "%s at %s in %s, taken from %s\n", kind, o.Address.Hex(), strings.Join(o.Sources, ", "), o.Winner)
+	}
+	if *strict && len(prov.Overlaps) > 0 {
+		return fmt.Errorf("%d addresses are defined by more than one file", len(prov.Overlaps))
+	}
+	if err := writeJSON(*output, merged); err != nil {
+		return err
+	}
+	if *provenance != "" {
+		if err := writeJSON(*provenance, prov); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Merged %d accounts from %d files (%d overlaps, %d conflicting)\n", len(merged), len(allocs), len(prov.Overlaps), conflicts)
+	return nil
+}
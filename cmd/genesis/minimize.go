@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/flags"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/triedb"
+
+	// Register the prestate tracer.
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+)
+
+// accessSet records the accounts and storage slots touched by an execution.
+type accessSet map[common.Address]map[common.Hash]struct{}
+
+// minimizeCommand prunes the allocation of a genesis down to the accounts and
+// storage slots touched by a list of transactions.
+func minimizeCommand(args []string) error {
+	fs := flag.NewFlagSet("minimize", flag.ExitOnError)
+	output := fs.String("output", "minimized.json", "output file")
+	txsPath := fs.String("txs", "", "JSON list of the RLP encoded signed transactions to execute (required)")
+	coinbase := fs.String("coinbase", "", "coinbase of the executing block (default the genesis coinbase)")
+	var keep flags.Strings
+	fs.Var(&keep, "keep", "address kept in full regardless of the execution (can be repeated)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: minimize [flags] --txs txs.json <genesis.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one genesis file")
+	}
+	if *txsPath == "" {
+		return errors.New("missing --txs")
+	}
+	genesis, err := gen.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if genesis.Config == nil {
+		return fmt.Errorf("%s: missing chain config", fs.Arg(0))
+	}
+	txs, err := loadTransactions(*txsPath)
+	if err != nil {
+		return fmt.Errorf("%s: %v", *txsPath, err)
+	}
+	miner := genesis.Coinbase
+	if *coinbase != "" {
+		if !common.IsHexAddress(*coinbase) {
+			return fmt.Errorf("invalid coinbase %q", *coinbase)
+		}
+		miner = common.HexToAddress(*coinbase)
+	}
+	var kept []common.Address
+	for _, addr := range keep {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid --keep address %q", addr)
+		}
+		kept = append(kept, common.HexToAddress(addr))
+	}
+	receipts, accessed, err := executeTransactions(genesis, miner, txs)
+	if err != nil {
+		return err
+	}
+	alloc := minimizeAlloc(genesis.Alloc, accessed, kept)
+
+	// Executing against the pruned allocation has to produce the same
+	// results, otherwise the tracer missed an access.
+	minimized := *genesis
+	minimized.Alloc = alloc
+	_, replayed, err := generateBlock(&minimized, miner, txs)
+	if err != nil {
+		return fmt.Errorf("minimized allocation: %v", err)
+	}
+	if err := compareReceipts(receipts, replayed); err != nil {
+		return fmt.Errorf("minimized allocation diverges: %v", err)
+	}
+	if err := writeJSON(*output, &minimized); err != nil {
+		return err
+	}
+	before, after := countSlots(genesis.Alloc), countSlots(alloc)
+	fmt.Printf("Kept %d of %d accounts and %d of %d storage slots\n", len(alloc), len(genesis.Alloc), after, before)
+	return nil
+}
+
+// loadTransactions reads a JSON list of RLP encoded signed transactions.
+func loadTransactions(path string) ([]*types.Transaction, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var encoded []hexutil.Bytes
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+	txs := make([]*types.Transaction, len(encoded))
+	for i, enc := range encoded {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		txs[i] = tx
+	}
+	return txs, nil
+}
+
+// generateBlock includes the transactions in a block on top of the genesis.
+func generateBlock(genesis *core.Genesis, coinbase common.Address, txs []*types.Transaction) (block *types.Block, receipts types.Receipts, err error) {
+	db := rawdb.NewMemoryDatabase()
+	gblock, err := genesis.Commit(db, triedb.NewDatabase(db, triedb.HashDefaults))
+	if err != nil {
+		return nil, nil, err
+	}
+	current := 0
+	defer func() {
+		// Block generation panics on transactions which cannot be included.
+		if r := recover(); r != nil {
+			err = fmt.Errorf("transaction %d: %v", current, r)
+		}
+	}()
+	blocks, generated := core.GenerateChain(genesis.Config, gblock, beacon.New(ethash.NewFaker()), db, 1, func(_ int, gen *core.BlockGen) {
+		gen.SetCoinbase(coinbase)
+		for i, tx := range txs {
+			current = i
+			gen.AddTx(tx)
+		}
+	})
+	return blocks[0], generated[0], nil
+}
+
+// executeTransactions includes the transactions in a block on top of the
+// genesis and returns their receipts along with the accounts and storage
+// slots they touched. The accesses are collected by importing the block with
+// a fresh prestate tracer attached to every transaction, system calls made
+// outside of transactions are not traced.
+func executeTransactions(genesis *core.Genesis, coinbase common.Address, txs []*types.Transaction) (types.Receipts, accessSet, error) {
+	block, receipts, err := generateBlock(genesis, coinbase, txs)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		accessed = make(accessSet)
+		tracer   *tracers.Tracer
+		failure  error
+	)
+	hooks := &tracing.Hooks{
+		OnTxStart: func(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+			ctx := &tracers.Context{BlockNumber: env.BlockNumber, TxHash: tx.Hash()}
+			t, err := tracers.DefaultDirectory.New("prestateTracer", ctx, json.RawMessage(`{"includeEmpty": true}`), genesis.Config)
+			if err != nil {
+				failure = err
+				return
+			}
+			tracer = t
+			tracer.OnTxStart(env, tx, from)
+		},
+		OnTxEnd: func(receipt *types.Receipt, err error) {
+			if tracer == nil {
+				return
+			}
+			tracer.OnTxEnd(receipt, err)
+			if err := collectAccesses(tracer, accessed); err != nil && failure == nil {
+				failure = err
+			}
+			tracer = nil
+		},
+		OnOpcode: func(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+			if tracer != nil {
+				tracer.OnOpcode(pc, op, gas, cost, scope, rData, depth, err)
+			}
+		},
+	}
+	config := core.DefaultConfig()
+	config.VmConfig = vm.Config{Tracer: hooks}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), genesis, beacon.New(ethash.NewFaker()), config)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+		return nil, nil, err
+	}
+	if failure != nil {
+		return nil, nil, failure
+	}
+	return receipts, accessed, nil
+}
+
+// collectAccesses adds the accounts and storage slots reported by a prestate
+// tracer to the access set.
+func collectAccesses(tracer *tracers.Tracer, accessed accessSet) error {
+	result, err := tracer.GetResult()
+	if err != nil {
+		return err
+	}
+	var pre map[common.Address]struct {
+		Storage map[common.Hash]common.Hash `json:"storage"`
+	}
+	if err := json.Unmarshal(result, &pre); err != nil {
+		return err
+	}
+	for addr, account := range pre {
+		if accessed[addr] == nil {
+			accessed[addr] = make(map[common.Hash]struct{})
+		}
+		for slot := range account.Storage {
+			accessed[addr][slot] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// minimizeAlloc returns the genesis accounts and storage slots in the access
+// set, with their original values. The kept accounts are retained in full.
+func minimizeAlloc(alloc types.GenesisAlloc, accessed accessSet, keep []common.Address) types.GenesisAlloc {
+	minimized := make(types.GenesisAlloc)
+	for addr, slots := range accessed {
+		account, ok := alloc[addr]
+		if !ok {
+			continue
+		}
+		storage := account.Storage
+		account.Storage = nil
+		for slot := range slots {
+			if value, ok := storage[slot]; ok {
+				if account.Storage == nil {
+					account.Storage = make(map[common.Hash]common.Hash)
+				}
+				account.Storage[slot] = value
+			}
+		}
+		minimized[addr] = account
+	}
+	for _, addr := range keep {
+		if account, ok := alloc[addr]; ok {
+			minimized[addr] = account
+		}
+	}
+	return minimized
+}
+
+// compareReceipts checks that two executions of the same transactions had the
+// same outcome.
+func compareReceipts(want, have types.Receipts) error {
+	for i := range want {
+		switch {
+		case want[i].Status != have[i].Status:
+			return fmt.Errorf("transaction %d: status %d, want %d", i, have[i].Status, want[i].Status)
+		case want[i].GasUsed != have[i].GasUsed:
+			return fmt.Errorf("transaction %d: gas used %d, want %d", i, have[i].GasUsed, want[i].GasUsed)
+		case want[i].Bloom != have[i].Bloom || len(want[i].Logs) != len(have[i].Logs):
+			return fmt.Errorf("transaction %d: logs differ", i)
+		}
+	}
+	return nil
+}
+
+func countSlots(alloc types.GenesisAlloc) int {
+	var n int
+	for _, account := range alloc {
+		n += len(account.Storage)
+	}
+	return n
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/compress"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// formatOutput returns the path the given format is written to. A single
+// format is written to the output path as is, multiple formats get the format
+// name inserted before the file extension.
+func formatOutput(output, format string, multiple bool) string {
+	if !multiple {
+		return output
+	}
+	ext := filepath.Ext(compress.TrimExt(output)) + compress.Ext(output)
+	return strings.TrimSuffix(output, ext) + "." + format + ext
+}
+
+// companionPath returns the path of a file written next to the output, with
+// the extension, including a compression extension, replaced.
+func companionPath(output, ext string) string {
+	base := compress.TrimExt(output)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ext
+}
+
+// writeJSON writes the indented JSON encoding of v to the given path, gzip or
+// zstd compressed if it ends in .gz or .zst. Streamed genesis encodings are
+// written account by account.
+func writeJSON(path string, v interface{}) error {
+	var err error
+	if s, ok := v.(*gen.Stream); ok {
+		err = s.WriteFile(path)
+	} else {
+		var data []byte
+		if data, err = json.MarshalIndent(v, "", "    "); err == nil {
+			err = compress.WriteFile(path, append(data, '\n'))
+		}
+	}
+	if err == nil {
+		report.output(path)
+	}
+	return err
+}
+
+// canonicalizeFile writes the canonical encoding of the JSON genesis at src
+// to dst, which may be the same path.
+func canonicalizeFile(src, dst string) error {
+	data, err := compress.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	canonical, err := gen.Canonicalize(data)
+	if err != nil {
+		return err
+	}
+	return compress.WriteFile(dst, canonical)
+}
+
+// writeRLP writes the RLP encoding of v to the given path.
+func writeRLP(path string, v interface{}) error {
+	data, err := rlp.EncodeToBytes(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// verkleState is the verkle tree of a genesis allocation, written next to the
+// genesis with --state-scheme verkle.
+type verkleState struct {
+	Root   common.Hash                 `json:"root"`
+	Leaves map[common.Hash]common.Hash `json:"leaves"`
+}
+
+// writeVerkleState writes the EIP-6800 verkle tree leaves of the genesis
+// allocation and their root to the given path. The root is cross-checked
+// against the one go-ethereum commits for a verkle genesis.
+func writeVerkleState(path string, genesis *core.Genesis) (common.Hash, error) {
+	leaves, err := alloc.VerkleLeaves(genesis.Alloc)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	root, err := alloc.VerkleRoot(leaves)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	config := *genesis.Config
+	config.EnableVerkleAtGenesis = true
+	config.VerkleTime = &genesis.Timestamp
+	verkle := *genesis
+	verkle.Config = &config
+	if want := verkle.ToBlock().Root(); want != root {
+		return common.Hash{}, fmt.Errorf("verkle root %s differs from go-ethereum's %s", root.Hex(), want.Hex())
+	}
+	return root, writeJSON(path, verkleState{Root: root, Leaves: leaves})
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+// proofResult is the output of the proof command: the state root and the
+// account proofs in the format of the eth_getProof response.
+type proofResult struct {
+	StateRoot common.Hash     `json:"stateRoot"`
+	Proofs    []*accountProof `json:"proofs"`
+}
+
+// accountProof is the eth_getProof result of an account.
+type accountProof struct {
+	Address      common.Address `json:"address"`
+	AccountProof []string       `json:"accountProof"`
+	Balance      *hexutil.Big   `json:"balance"`
+	CodeHash     common.Hash    `json:"codeHash"`
+	Nonce        hexutil.Uint64 `json:"nonce"`
+	StorageHash  common.Hash    `json:"storageHash"`
+	StorageProof []storageProof `json:"storageProof"`
+}
+
+// storageProof is the eth_getProof result of a storage slot.
+type storageProof struct {
+	Key   string       `json:"key"`
+	Value *hexutil.Big `json:"value"`
+	Proof []string     `json:"proof"`
+}
+
+// proofRequest is an account to prove and the storage slots of it.
+type proofRequest struct {
+	address common.Address
+	slots   []common.Hash
+}
+
+// proofList collects the nodes of a proof in root to leaf order.
+type proofList []string
+
+func (n *proofList) Put(key []byte, value []byte) error {
+	*n = append(*n, hexutil.Encode(value))
+	return nil
+}
+
+func (n *proofList) Delete(key []byte) error {
+	panic("not supported")
+}
+
+// proofCommand generates Merkle Patricia proofs of accounts and storage slots
+// of a genesis state.
+func proofCommand(args []string) error {
+	fs := flag.NewFlagSet("proof", flag.ExitOnError)
+	output := fs.String("output", "proof.json", "output file")
+	bareAlloc := fs.Bool("alloc", false, "the input is an allocation (JSON or CSV) rather than a genesis")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: proof [flags] <genesis.json> <address>[=slot,...] [<address>[=slot,...] ...]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return errors.New("expected an input file and at least one address")
+	}
+	var allocation types.GenesisAlloc
+	if *bareAlloc {
+		var (
+			warnings []alloc.AddressWarning
+			err      error
+		)
+		if allocation, warnings, err = alloc.Load(fs.Arg(0)); err != nil {
+			return err
+		}
+		reportAddressWarnings(fs.Arg(0), warnings)
+	} else {
+		genesis, err := gen.Load(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		allocation = genesis.Alloc
+	}
+	var requests []proofRequest
+	for _, arg := range fs.Args()[1:] {
+		request, err := parseProofRequest(arg)
+		if err != nil {
+			return err
+		}
+		requests = append(requests, request)
+	}
+	result, err := proveAlloc(allocation, requests)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(*output, result); err != nil {
+		return err
+	}
+	fmt.Printf("State root: %s (%d accounts proven)\n", result.StateRoot.Hex(), len(result.Proofs))
+	return nil
+}
+
+// parseProofRequest parses an address optionally followed by a comma
+// separated list of storage slots.
+func parseProofRequest(arg string) (proofRequest, error) {
+	addr, slots, _ := strings.Cut(arg, "=")
+	if !common.IsHexAddress(addr) {
+		return proofRequest{}, fmt.Errorf("invalid address %q", addr)
+	}
+	request := proofRequest{address: common.HexToAddress(addr)}
+	if slots == "" {
+		return request, nil
+	}
+	for _, slot := range strings.Split(slots, ",") {
+		hash, err := alloc.ParseHash(slot)
+		if err != nil {
+			return proofRequest{}, fmt.Errorf("invalid storage slot %q: %v", slot, err)
+		}
+		request.slots = append(request.slots, hash)
+	}
+	return request, nil
+}
+
+// proveAlloc builds the state tries of the allocation and proves the requested
+// accounts and slots. Every proof is verified against its root before it is
+// returned.
+func proveAlloc(allocation types.GenesisAlloc, requests []proofRequest) (*proofResult, error) {
+	tdb := triedb.NewDatabase(rawdb.NewMemoryDatabase(), triedb.HashDefaults)
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(tdb, nil))
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range alloc.SortedAddresses(allocation) {
+		account := allocation[addr]
+		balance, overflow := uint256.FromBig(alloc.Balance(account))
+		if overflow {
+			return nil, fmt.Errorf("account %s: balance overflows 256 bits", addr.Hex())
+		}
+		statedb.SetBalance(addr, balance, tracing.BalanceIncreaseGenesisBalance)
+		statedb.SetNonce(addr, account.Nonce, tracing.NonceChangeGenesis)
+		statedb.SetCode(addr, account.Code, tracing.CodeChangeGenesis)
+		for slot, value := range account.Storage {
+			statedb.SetState(addr, slot, value)
+		}
+	}
+	root, err := statedb.Commit(0, false, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := tdb.Commit(root, false); err != nil {
+		return nil, err
+	}
+	accounts, err := trie.NewStateTrie(trie.StateTrieID(root), tdb)
+	if err != nil {
+		return nil, err
+	}
+	result := &proofResult{StateRoot: root}
+	for _, request := range requests {
+		var nodes proofList
+		if err := proveKey(accounts, root, request.address.Bytes(), &nodes); err != nil {
+			return nil, fmt.Errorf("account %s: %v", request.address.Hex(), err)
+		}
+		proof := &accountProof{
+			Address:      request.address,
+			AccountProof: nodes,
+			Balance:      new(hexutil.Big),
+			CodeHash:     types.EmptyCodeHash,
+			StorageHash:  types.EmptyRootHash,
+			StorageProof: []storageProof{},
+		}
+		account, err := accounts.GetAccount(request.address)
+		if err != nil {
+			return nil, err
+		}
+		if account != nil {
+			proof.Balance = (*hexutil.Big)(account.Balance.ToBig())
+			proof.CodeHash = common.BytesToHash(account.CodeHash)
+			proof.Nonce = hexutil.Uint64(account.Nonce)
+			proof.StorageHash = account.Root
+		}
+		storage, err := trie.NewStateTrie(trie.StorageTrieID(root, crypto.Keccak256Hash(request.address.Bytes()), proof.StorageHash), tdb)
+		if err != nil {
+			return nil, err
+		}
+		for _, slot := range request.slots {
+			nodes := proofList{}
+			// The empty storage trie has no nodes, its absence proof is empty.
+			if proof.StorageHash == types.EmptyRootHash {
+				proof.StorageProof = append(proof.StorageProof, storageProof{Key: slot.Hex(), Value: new(hexutil.Big), Proof: nodes})
+				continue
+			}
+			if err := proveKey(storage, proof.StorageHash, slot.Bytes(), &nodes); err != nil {
+				return nil, fmt.Errorf("account %s slot %s: %v", request.address.Hex(), slot.Hex(), err)
+			}
+			value := allocation[request.address].Storage[slot]
+			proof.StorageProof = append(proof.StorageProof, storageProof{
+				Key:   slot.Hex(),
+				Value: (*hexutil.Big)(value.Big()),
+				Proof: nodes,
+			})
+		}
+		result.Proofs = append(result.Proofs, proof)
+	}
+	return result, nil
+}
+
+// proveKey proves the key in the trie and checks the proof against the root.
+// The key is hashed as in the secure state tries.
+func proveKey(tr *trie.StateTrie, root common.Hash, key []byte, nodes *proofList) error {
+	hashed := crypto.Keccak256(key)
+	if err := tr.Prove(hashed, nodes); err != nil {
+		return err
+	}
+	proofDb := memorydb.New()
+	for _, node := range *nodes {
+		blob := hexutil.MustDecode(node)
+		proofDb.Put(crypto.Keccak256(blob), blob)
+	}
+	_, err := trie.VerifyProof(root, hashed, proofDb)
+	return err
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// The exit codes of the genesis generation. Flag parse errors exit with 2,
+// the code of the flag package.
+const (
+	exitFailure  = 1 // the genesis could not be generated
+	exitMismatch = 3 // the genesis was generated but failed --verify-hash or --cross-check
+)
+
+// logger is the structured logger of the warnings and errors, writing text to
+// stderr unless --log-format selects JSON.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// setLogFormat replaces the logger with one writing the given format.
+func setLogFormat(format string) error {
+	switch format {
+	case "text":
+		logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	default:
+		return fmt.Errorf("invalid log format %q, want text or json", format)
+	}
+	return nil
+}
+
+// runReport is the machine-readable summary of a genesis generation written
+// with --report, meant for unattended runs: the command line, the files read
+// and written with their SHA-256 hashes, the commitments of the genesis
+// block, the warnings and, for failed runs, the error and exit code.
+type runReport struct {
+	path string
+
+	Args       []string      `json:"args"`
+	Started    time.Time     `json:"started"`
+	Duration   string        `json:"duration"`
+	Inputs     []*reportFile `json:"inputs"`
+	Outputs    []*reportFile `json:"outputs"`
+	BlockHash  *common.Hash  `json:"blockHash,omitempty"`
+	StateRoot  *common.Hash  `json:"stateRoot,omitempty"`
+	VerkleRoot *common.Hash  `json:"verkleRoot,omitempty"`
+	Warnings   []string      `json:"warnings"`
+	Error      string        `json:"error,omitempty"`
+	ExitCode   int           `json:"exitCode"`
+}
+
+// reportFile is a file read or written by the run.
+type reportFile struct {
+	Path   string        `json:"path"`
+	Size   int64         `json:"size"`
+	SHA256 hexutil.Bytes `json:"sha256"`
+}
+
+// report collects the run report if --report is given, nil otherwise. All its
+// methods are no-ops on nil.
+var report *runReport
+
+// newRunReport starts the report of the run, written to path once it ends.
+func newRunReport(path string) *runReport {
+	return &runReport{
+		path:     path,
+		Args:     os.Args[1:],
+		Started:  time.Now().UTC(),
+		Inputs:   []*reportFile{},
+		Outputs:  []*reportFile{},
+		Warnings: []string{},
+	}
+}
+
+// input records a file read by the run.
+func (r *runReport) input(path string) {
+	if r != nil {
+		r.Inputs = append(r.Inputs, hashFile(path))
+	}
+}
+
+// output records a file written by the run.
+func (r *runReport) output(path string) {
+	if r != nil {
+		r.Outputs = append(r.Outputs, hashFile(path))
+	}
+}
+
+// hashFile hashes a file for the report. A file that cannot be read is
+// recorded without its hash, the failure to read it is reported elsewhere.
+func hashFile(path string) *reportFile {
+	file := &reportFile{Path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		return file
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if file.Size, err = io.Copy(h, f); err == nil {
+		file.SHA256 = h.Sum(nil)
+	}
+	return file
+}
+
+// finish writes the report with the outcome of the run.
+func (r *runReport) finish(code int, err string) {
+	if r == nil {
+		return
+	}
+	r.Duration = time.Since(r.Started).Round(time.Millisecond).String()
+	r.ExitCode, r.Error = code, err
+	data, merr := json.MarshalIndent(r, "", "    ")
+	if merr == nil {
+		merr = os.WriteFile(r.path, append(data, '\n'), 0644)
+	}
+	if merr != nil {
+		logger.Error("failed to write the run report", "path", r.path, "err", merr)
+	}
+}
+
+// warnf logs a warning and adds it to the report.
+func warnf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	logger.Warn(msg)
+	if report != nil {
+		report.Warnings = append(report.Warnings, msg)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	exitf(exitFailure, format, args...)
+}
+
+// exitf logs the error, completes the report and exits with the code.
+func exitf(code int, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	logger.Error(msg)
+	report.finish(code, msg)
+	os.Exit(code)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// TestMain runs the command itself when re-executed by runGenesis.
+func TestMain(m *testing.M) {
+	if os.Getenv("GENESIS_TEST_MAIN") == "1" {
+		os.Args = append([]string{"genesis"}, os.Args[1:]...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runGenesis executes the command with the arguments in dir.
+func runGenesis(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GENESIS_TEST_MAIN=1")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("genesis %v: %v\n%s", args, err, out)
+	}
+}
+
+// TestReportOutputs checks that every file written by a run is listed
+// exactly once in its report.
+func TestReportOutputs(t *testing.T) {
+	dir := t.TempDir()
+	overlay := "accounts:\n  \"0x000000000000000000000000000000000000beef\":\n    balance: \"0x10\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "overlay.yaml"), []byte(overlay), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGenesis(t, dir,
+		"--network", "sepolia",
+		"--format", "geth,besu",
+		"--output", "genesis.json",
+		"--fund-accounts", "2",
+		"--seed", "0x000102030405060708090a0b0c0d0e0f",
+		"--overlay", "overlay.yaml",
+		"--rlp",
+		"--report", "report.json",
+	)
+	data, err := os.ReadFile(filepath.Join(dir, "report.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r runReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	var have []string
+	for _, file := range r.Outputs {
+		have = append(have, file.Path)
+	}
+	sort.Strings(have)
+	want := []string{
+		"genesis.accounts.json",
+		"genesis.besu.json",
+		"genesis.geth.json",
+		"genesis.provenance.json",
+		"genesis.rlp",
+	}
+	if len(have) != len(want) {
+		t.Fatalf("outputs %v, want %v", have, want)
+	}
+	for i := range want {
+		if have[i] != want[i] {
+			t.Fatalf("outputs %v, want %v", have, want)
+		}
+	}
+	for _, path := range want {
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			t.Errorf("output %s not written: %v", path, err)
+		}
+	}
+}
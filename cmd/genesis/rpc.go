@@ -0,0 +1,483 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcQuery is an entry of the queries file, a JSON-RPC request without the
+// envelope.
+type rpcQuery struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// rpcFixture is the output of the rpc command: the genesis the responses hold
+// for and the request and response of every query.
+type rpcFixture struct {
+	GenesisHash common.Hash `json:"genesisHash"`
+	StateRoot   common.Hash `json:"stateRoot"`
+	Tests       []*rpcTest  `json:"tests"`
+}
+
+// rpcTest is a JSON-RPC request and the response a client must reply with.
+type rpcTest struct {
+	Request  rpcRequest  `json:"request"`
+	Response rpcResponse `json:"response"`
+}
+
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      int               `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   *rpcError   `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error object. Only the code and the data are
+// checked, the message is up to the client.
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// The JSON-RPC error codes of eth_call failures.
+const (
+	rpcErrorCallFailed = -32000
+	rpcErrorReverted   = 3
+)
+
+// callArgs are the supported fields of the eth_call transaction object.
+type callArgs struct {
+	From     *common.Address `json:"from,omitempty"`
+	To       *common.Address `json:"to,omitempty"`
+	Gas      *hexutil.Uint64 `json:"gas,omitempty"`
+	GasPrice *hexutil.Big    `json:"gasPrice,omitempty"`
+	Value    *hexutil.Big    `json:"value,omitempty"`
+	Data     *hexutil.Bytes  `json:"data,omitempty"`
+	Input    *hexutil.Bytes  `json:"input,omitempty"`
+}
+
+// rpcCommand computes the responses to JSON-RPC state queries against the
+// genesis state and writes them as an RPC test fixture.
+func rpcCommand(args []string) error {
+	fs := flag.NewFlagSet("rpc", flag.ExitOnError)
+	queries := fs.String("queries", "", "JSON list of the queries, {\"method\": ..., \"params\": [...]} objects (required)")
+	output := fs.String("output", "rpc_fixture.json", "output file")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: rpc [flags] --queries queries.json <genesis.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || *queries == "" {
+		fs.Usage()
+		return errors.New("expected a genesis file and --queries")
+	}
+	genesis, err := gen.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(*queries)
+	if err != nil {
+		return err
+	}
+	var list []*rpcQuery
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("%s: %v", *queries, err)
+	}
+	if len(list) == 0 {
+		return fmt.Errorf("%s: no queries", *queries)
+	}
+	fixture, err := answerQueries(genesis, list)
+	if err != nil {
+		return err
+	}
+	if err := checkRPCFixture(genesis, fixture); err != nil {
+		return fmt.Errorf("go-ethereum: %v", err)
+	}
+	if err := writeJSON(*output, fixture); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d responses for genesis %s to %s\n", len(fixture.Tests), fixture.GenesisHash.Hex(), *output)
+	return nil
+}
+
+// answerQueries computes the response to every query from the genesis state.
+func answerQueries(genesis *core.Genesis, queries []*rpcQuery) (*rpcFixture, error) {
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), genesis, beacon.New(ethash.NewFaker()), core.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	defer chain.Stop()
+	head := chain.CurrentBlock()
+	fixture := &rpcFixture{GenesisHash: head.Hash(), StateRoot: head.Root}
+	for i, q := range queries {
+		test := &rpcTest{
+			Request:  rpcRequest{JSONRPC: "2.0", ID: i + 1, Method: q.Method, Params: q.Params},
+			Response: rpcResponse{JSONRPC: "2.0", ID: i + 1},
+		}
+		if err := answerQuery(chain, genesis, test); err != nil {
+			return nil, fmt.Errorf("query %d (%s): %v", i, q.Method, err)
+		}
+		fixture.Tests = append(fixture.Tests, test)
+	}
+	return fixture, nil
+}
+
+// answerQuery fills in the response of a test. Queries which are malformed or
+// not about the genesis block are rejected rather than answered with an error.
+func answerQuery(chain *core.BlockChain, genesis *core.Genesis, test *rpcTest) error {
+	params := test.Request.Params
+	want := map[string]int{"eth_getBalance": 2, "eth_getStorageAt": 3, "eth_call": 2, "eth_getProof": 3}
+	n, ok := want[test.Request.Method]
+	if !ok {
+		return errors.New("unsupported method, want eth_getBalance, eth_getStorageAt, eth_call or eth_getProof")
+	}
+	if len(params) != n {
+		return fmt.Errorf("%d params, want %d", len(params), n)
+	}
+	if err := checkBlockParam(params[n-1]); err != nil {
+		return err
+	}
+	switch test.Request.Method {
+	case "eth_getBalance":
+		var addr common.Address
+		if err := json.Unmarshal(params[0], &addr); err != nil {
+			return fmt.Errorf("address: %v", err)
+		}
+		balance := new(big.Int)
+		if account, ok := genesis.Alloc[addr]; ok && account.Balance != nil {
+			balance = account.Balance
+		}
+		test.Response.Result = (*hexutil.Big)(balance)
+
+	case "eth_getStorageAt":
+		var (
+			addr common.Address
+			key  string
+		)
+		if err := json.Unmarshal(params[0], &addr); err != nil {
+			return fmt.Errorf("address: %v", err)
+		}
+		if err := json.Unmarshal(params[1], &key); err != nil {
+			return fmt.Errorf("storage key: %v", err)
+		}
+		slot, _, err := parseStorageKey(key)
+		if err != nil {
+			return fmt.Errorf("storage key %q: %v", key, err)
+		}
+		value := genesis.Alloc[addr].Storage[slot]
+		test.Response.Result = hexutil.Bytes(value[:])
+
+	case "eth_call":
+		var call callArgs
+		dec := json.NewDecoder(bytes.NewReader(params[0]))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&call); err != nil {
+			return fmt.Errorf("transaction: %v", err)
+		}
+		if call.Gas == nil {
+			// Make the request independent of the default gas of the client.
+			gas := hexutil.Uint64(genesis.GasLimit)
+			call.Gas = &gas
+			enc, err := json.Marshal(call)
+			if err != nil {
+				return err
+			}
+			test.Request.Params = append([]json.RawMessage{enc}, params[1:]...)
+		}
+		result, rpcErr, err := doCall(chain, &call)
+		if err != nil {
+			return err
+		}
+		test.Response.Result, test.Response.Error = result, rpcErr
+
+	case "eth_getProof":
+		var (
+			addr common.Address
+			keys []string
+		)
+		if err := json.Unmarshal(params[0], &addr); err != nil {
+			return fmt.Errorf("address: %v", err)
+		}
+		if err := json.Unmarshal(params[1], &keys); err != nil {
+			return fmt.Errorf("storage keys: %v", err)
+		}
+		request := proofRequest{address: addr}
+		outputKeys := make([]string, len(keys))
+		for i, key := range keys {
+			slot, length, err := parseStorageKey(key)
+			if err != nil {
+				return fmt.Errorf("storage key %q: %v", key, err)
+			}
+			request.slots = append(request.slots, slot)
+			// Keys given as 32 byte hashes are returned as such, all others
+			// as quantities.
+			outputKeys[i] = hexutil.EncodeBig(slot.Big())
+			if length == common.HashLength {
+				outputKeys[i] = slot.Hex()
+			}
+		}
+		result, err := proveAlloc(genesis.Alloc, []proofRequest{request})
+		if err != nil {
+			return err
+		}
+		proof := result.Proofs[0]
+		for i := range proof.StorageProof {
+			proof.StorageProof[i].Key = outputKeys[i]
+		}
+		test.Response.Result = proof
+	}
+	return nil
+}
+
+// checkBlockParam checks that a block parameter selects the genesis block.
+func checkBlockParam(param json.RawMessage) error {
+	var block string
+	if err := json.Unmarshal(param, &block); err != nil {
+		return fmt.Errorf("block: %v", err)
+	}
+	switch block {
+	case "latest", "earliest", "0x0":
+		return nil
+	}
+	return fmt.Errorf("block %q, only the genesis block (latest, earliest or 0x0) is available", block)
+}
+
+// parseStorageKey decodes a storage key, a hex string of at most 32 bytes,
+// and returns it with its length in bytes.
+func parseStorageKey(key string) (common.Hash, int, error) {
+	s := key
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+	}
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	if len(s) > 2*common.HashLength {
+		return common.Hash{}, 0, errors.New("longer than 32 bytes")
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return common.Hash{}, 0, errors.New("invalid hex string")
+	}
+	return common.BytesToHash(b), len(b), nil
+}
+
+// doCall executes an eth_call on top of the genesis state in the context of
+// the genesis block. The nonce of the sender and the base fee are not
+// checked; without a gas price the call pays no fees. Failed calls are
+// answered with an error object, a revert with its reason and return data.
+func doCall(chain *core.BlockChain, call *callArgs) (interface{}, *rpcError, error) {
+	if call.Data != nil && call.Input != nil && !bytes.Equal(*call.Data, *call.Input) {
+		return nil, nil, errors.New("transaction: both data and input given, with different values")
+	}
+	head := chain.CurrentBlock()
+	statedb, err := chain.StateAt(head.Root)
+	if err != nil {
+		return nil, nil, err
+	}
+	msg := &core.Message{
+		To:                    call.To,
+		Value:                 new(big.Int),
+		GasLimit:              uint64(*call.Gas),
+		GasPrice:              new(big.Int),
+		SkipNonceChecks:       true,
+		SkipTransactionChecks: true,
+	}
+	if call.From != nil {
+		msg.From = *call.From
+	}
+	if call.Value != nil {
+		msg.Value = call.Value.ToInt()
+	}
+	if call.GasPrice != nil {
+		msg.GasPrice = call.GasPrice.ToInt()
+	}
+	msg.GasFeeCap, msg.GasTipCap = msg.GasPrice, msg.GasPrice
+	switch {
+	case call.Input != nil:
+		msg.Data = *call.Input
+	case call.Data != nil:
+		msg.Data = *call.Data
+	}
+	blockCtx := core.NewEVMBlockContext(head, chain, nil)
+	if msg.GasPrice.Sign() == 0 {
+		blockCtx.BaseFee = new(big.Int)
+	}
+	evm := vm.NewEVM(blockCtx, statedb, chain.Config(), vm.Config{NoBaseFee: true})
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(msg.GasLimit))
+	switch {
+	case err != nil:
+		return nil, &rpcError{Code: rpcErrorCallFailed, Message: err.Error()}, nil
+	case errors.Is(result.Err, vm.ErrExecutionReverted):
+		message := result.Err.Error()
+		if reason, err := abi.UnpackRevert(result.Revert()); err == nil {
+			message += ": " + reason
+		}
+		return nil, &rpcError{Code: rpcErrorReverted, Message: message, Data: hexutil.Bytes(result.Revert())}, nil
+	case result.Err != nil:
+		return nil, &rpcError{Code: rpcErrorCallFailed, Message: result.Err.Error()}, nil
+	}
+	return hexutil.Bytes(result.Return()), nil, nil
+}
+
+// checkRPCFixture sends the requests of the fixture to an in-process
+// go-ethereum node started from the genesis and compares its replies with the
+// expected responses.
+func checkRPCFixture(genesis *core.Genesis, fixture *rpcFixture) error {
+	stack, err := node.New(&node.Config{P2P: p2p.Config{NoDiscovery: true, NoDial: true}})
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+	backend, err := eth.New(stack, &ethconfig.Config{
+		Genesis:        genesis,
+		SyncMode:       ethconfig.FullSync,
+		TrieTimeout:    time.Minute,
+		TrieDirtyCache: 256,
+		TrieCleanCache: 256,
+		Miner:          miner.DefaultConfig,
+		RPCGasCap:      ethconfig.Defaults.RPCGasCap,
+	})
+	if err != nil {
+		return err
+	}
+	if err := stack.Start(); err != nil {
+		return err
+	}
+	if hash := backend.BlockChain().Genesis().Hash(); hash != fixture.GenesisHash {
+		return fmt.Errorf("genesis %x, expected %x", hash, fixture.GenesisHash)
+	}
+	client := stack.Attach()
+	defer client.Close()
+
+	for i, test := range fixture.Tests {
+		params := make([]interface{}, len(test.Request.Params))
+		for j, p := range test.Request.Params {
+			params[j] = p
+		}
+		var result json.RawMessage
+		err := client.Call(&result, test.Request.Method, params...)
+		if err == nil && test.Request.Method == "eth_getProof" {
+			if result, err = normalizeAbsentProof(result); err != nil {
+				return err
+			}
+		}
+		if err := compareRPCResponse(&test.Response, result, err); err != nil {
+			return fmt.Errorf("query %d (%s): %v", i, test.Request.Method, err)
+		}
+	}
+	return nil
+}
+
+// normalizeAbsentProof replaces the all-zero code hash and storage root
+// go-ethereum reports for accounts which do not exist by the hash of the empty
+// code and the empty trie root, as an empty account has.
+func normalizeAbsentProof(result json.RawMessage) (json.RawMessage, error) {
+	var account map[string]interface{}
+	if err := json.Unmarshal(result, &account); err != nil {
+		return nil, err
+	}
+	zero := common.Hash{}.Hex()
+	if account["codeHash"] == zero {
+		account["codeHash"] = types.EmptyCodeHash.Hex()
+	}
+	if account["storageHash"] == zero {
+		account["storageHash"] = types.EmptyRootHash.Hex()
+	}
+	return json.Marshal(account)
+}
+
+// compareRPCResponse compares the reply of a client with the expected
+// response, decoding both results into generic JSON values.
+func compareRPCResponse(want *rpcResponse, result json.RawMessage, err error) error {
+	if want.Error != nil {
+		var rpcErr rpc.Error
+		if !errors.As(err, &rpcErr) {
+			return fmt.Errorf("result %s, expected error %d (%s)", result, want.Error.Code, want.Error.Message)
+		}
+		if rpcErr.ErrorCode() != want.Error.Code {
+			return fmt.Errorf("error code %d (%v), expected %d (%s)", rpcErr.ErrorCode(), err, want.Error.Code, want.Error.Message)
+		}
+		var data interface{}
+		if dataErr, ok := err.(rpc.DataError); ok {
+			data = dataErr.ErrorData()
+		}
+		wantData, err := jsonValue(want.Error.Data)
+		if err != nil {
+			return err
+		}
+		if (data != nil || wantData != nil) && !reflect.DeepEqual(data, wantData) {
+			return fmt.Errorf("error data %v, expected %v", data, wantData)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var have interface{}
+	if err := json.Unmarshal(result, &have); err != nil {
+		return err
+	}
+	expected, err := jsonValue(want.Result)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(have, expected) {
+		return fmt.Errorf("result %s, expected %s", result, mustJSON(want.Result))
+	}
+	return nil
+}
+
+// jsonValue converts a value into its generic JSON form.
+func jsonValue(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	enc, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(enc, &value)
+	return value, err
+}
+
+func mustJSON(v interface{}) string {
+	enc, _ := json.Marshal(v)
+	return string(enc)
+}
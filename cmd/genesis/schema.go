@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/execution-specs/pkg/header"
+	"github.com/ethereum/execution-specs/pkg/schema"
+	"github.com/ethereum/execution-specs/pkg/txbuilder"
+	"github.com/ethereum/execution-specs/pkg/witness"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// schemaFormats maps the names of the file formats covered by the schema
+// subcommand onto their title and values of the Go types decoding them.
+var schemaFormats = map[string]struct {
+	title  string
+	values []interface{}
+}{
+	"genesis":         {"Genesis", []interface{}{core.Genesis{}}},
+	"chain-config":    {"Chain config", []interface{}{params.ChainConfig{}}},
+	"alloc":           {"Genesis allocation", []interface{}{types.GenesisAlloc{}}},
+	"blockchain-test": {"Blockchain test fixtures", []interface{}{map[string]*blocktest.Fixture{}}},
+	"blocks":          {"Block definitions of the blocktest tool", []interface{}{[]*blocktest.Block{}}},
+	"statetest":       {"State test of the statetest subcommand", []interface{}{map[string]*stateTest{}}},
+	"transactions":    {"Transaction definitions of the txbuild tool", []interface{}{txbuilder.Definition{}, []*txbuilder.Definition{}}},
+	"witness":         {"Execution witness", []interface{}{witness.Witness{}}},
+	"header-inputs":   {"Block contents of the header subcommand", []interface{}{header.Inputs{}}},
+	"header-vectors":  {"Header field vectors", []interface{}{map[string]*header.Vector{}}},
+}
+
+// schemaCommand writes the JSON Schema documents of the file formats, derived
+// from the Go types decoding them.
+func schemaCommand(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	output := fs.String("output", "schemas", "directory the schemas are written to")
+	names := make([]string, 0, len(schemaFormats))
+	for name := range schemaFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: schema [flags] [format...]\n")
+		fmt.Fprintf(fs.Output(), "Formats: %s (default all)\n", strings.Join(names, ", "))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		names = fs.Args()
+	}
+	if err := os.MkdirAll(*output, 0755); err != nil {
+		return err
+	}
+	for _, name := range names {
+		format, ok := schemaFormats[name]
+		if !ok {
+			fs.Usage()
+			return fmt.Errorf("unknown format %q", name)
+		}
+		doc, err := schema.Generate(format.title, format.values...)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if err := writeJSON(filepath.Join(*output, name+".schema.json"), doc); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Wrote %d schemas to %s\n", len(names), *output)
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// maxTemplateSize bounds the size of a template posted to the server.
+const maxTemplateSize = 64 << 20
+
+// serveResponse is the result of a genesis request: the genesis in every
+// requested format, keyed by the format name, and the genesis block header.
+type serveResponse struct {
+	BlockHash common.Hash                `json:"blockHash"`
+	StateRoot common.Hash                `json:"stateRoot"`
+	Header    *types.Header              `json:"header"`
+	Genesis   map[string]json.RawMessage `json:"genesis"`
+}
+
+// serveCommand exposes the genesis generation over HTTP.
+func serveCommand(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "listening address of the HTTP server")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "number of workers computing the state root")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: serve [flags]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("unexpected arguments")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/genesis", func(w http.ResponseWriter, r *http.Request) {
+		serveGenesis(w, r, *jobs)
+	})
+	fmt.Printf("Serving genesis generation on http://%s/genesis\n", *addr)
+	return http.ListenAndServe(*addr, mux)
+}
+
+// serveGenesis handles a genesis request. The body is a genesis template, the
+// query selects the comma separated output formats (default geth) and sets the
+// template variables as var=NAME=VALUE. Unlike on the command line, the
+// variables are not looked up in the environment of the server.
+func serveGenesis(w http.ResponseWriter, r *http.Request, jobs int) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		serveError(w, http.StatusMethodNotAllowed, errors.New("genesis templates have to be POSTed"))
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxTemplateSize))
+	if err != nil {
+		serveError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	query := r.URL.Query()
+	vars, err := gen.ParseVars(query["var"])
+	if err != nil {
+		serveError(w, http.StatusBadRequest, err)
+		return
+	}
+	names := []string{"geth"}
+	if format := query.Get("format"); format != "" {
+		names = strings.Split(format, ",")
+	}
+	tmpl, err := gen.ParseTemplate(data, func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	})
+	if err != nil {
+		serveError(w, http.StatusBadRequest, fmt.Errorf("invalid template: %v", err))
+		return
+	}
+	genesis, err := gen.FromTemplate(tmpl)
+	if err != nil {
+		serveError(w, http.StatusBadRequest, err)
+		return
+	}
+	block, err := gen.ToBlock(genesis, jobs)
+	if err != nil {
+		serveError(w, http.StatusBadRequest, err)
+		return
+	}
+	resp := &serveResponse{
+		BlockHash: block.Hash(),
+		StateRoot: block.Root(),
+		Header:    block.Header(),
+		Genesis:   make(map[string]json.RawMessage, len(names)),
+	}
+	for _, name := range names {
+		encode, ok := gen.Formats[name]
+		if !ok {
+			serveError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q, supported formats: %s", name, strings.Join(gen.FormatNames(), ", ")))
+			return
+		}
+		out, err := encode(genesis, nil)
+		if err != nil {
+			serveError(w, http.StatusBadRequest, fmt.Errorf("failed to encode %s genesis: %v", name, err))
+			return
+		}
+		var buf bytes.Buffer
+		if err := out.Encode(&buf); err != nil {
+			serveError(w, http.StatusInternalServerError, err)
+			return
+		}
+		resp.Genesis[name] = buf.Bytes()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// serveError replies with the error as a JSON object.
+func serveError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
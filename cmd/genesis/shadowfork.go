@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// shadowForkTime is a --fork flag value scheduling a fork of the shadow fork,
+// either at an absolute timestamp, relative to the base time or disabled.
+type shadowForkTime struct {
+	field    *forks.Field
+	disabled bool
+	relative bool
+	value    uint64 // timestamp, or seconds after the base time if relative
+}
+
+// shadowForkTimes is a flag.Value collecting --fork flags of the form
+// fork=timestamp, fork=+offset or fork=none.
+type shadowForkTimes []shadowForkTime
+
+func (t *shadowForkTimes) String() string {
+	entries := make([]string, len(*t))
+	for i, entry := range *t {
+		name := strings.TrimSuffix(entry.field.Flag, "-time")
+		switch {
+		case entry.disabled:
+			entries[i] = name + "=none"
+		case entry.relative:
+			entries[i] = fmt.Sprintf("%s=+%ds", name, entry.value)
+		default:
+			entries[i] = fmt.Sprintf("%s=%d", name, entry.value)
+		}
+	}
+	return strings.Join(entries, " ")
+}
+
+func (t *shadowForkTimes) Set(s string) error {
+	fork, when, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("invalid fork time %q, want fork=timestamp, fork=+offset or fork=none", s)
+	}
+	field := forks.FindField(strings.ToLower(fork) + "-time")
+	if field == nil {
+		return fmt.Errorf("fork %q is not scheduled by timestamp", fork)
+	}
+	entry := shadowForkTime{field: field}
+	switch {
+	case when == "none":
+		entry.disabled = true
+	case strings.HasPrefix(when, "+"):
+		offset, err := forks.ParseOffset(when[1:])
+		if err != nil {
+			return fmt.Errorf("fork %s: %v", fork, err)
+		}
+		entry.relative, entry.value = true, offset
+	default:
+		v, ok := math.ParseUint64(when)
+		if !ok {
+			return fmt.Errorf("fork %s: invalid timestamp %q", fork, when)
+		}
+		entry.value = v
+	}
+	*t = append(*t, entry)
+	return nil
+}
+
+// shadowForkCommand derives the configuration of a shadow fork from the
+// genesis of a live network.
+func shadowForkCommand(args []string) error {
+	fs := flag.NewFlagSet("shadow-fork", flag.ExitOnError)
+	output := fs.String("output", "shadow-genesis.json", "path of the shadow fork genesis file")
+	format := fs.String("format", "geth", "comma separated output formats ("+strings.Join(gen.FormatNames(), ", ")+")")
+	chainID := fs.String("chain-id", "", "chain id of the shadow fork (default the one of the network)")
+	baseTime := fs.Uint64("base-time", uint64(time.Now().Unix()), "timestamp relative fork times are counted from (default now)")
+	var schedule shadowForkTimes
+	fs.Var(&schedule, "fork", "schedule a fork as fork=timestamp, fork=+offset (e.g. +2h, +1d) or fork=none (may be repeated)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: shadow-fork [flags] <network | genesis.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one network name or genesis file")
+	}
+	var (
+		live *core.Genesis
+		err  error
+	)
+	if makeGenesis, ok := gen.Networks[fs.Arg(0)]; ok {
+		live = makeGenesis()
+	} else if live, err = gen.Load(fs.Arg(0)); err != nil {
+		return err
+	}
+	if live.Config == nil {
+		return fmt.Errorf("%s: missing chain config", fs.Arg(0))
+	}
+	shadow, err := shadowGenesis(live, *chainID, schedule, *baseTime)
+	if err != nil {
+		return err
+	}
+
+	names := strings.Split(*format, ",")
+	for _, name := range names {
+		encode, ok := gen.Formats[name]
+		if !ok {
+			return fmt.Errorf("unknown format %q, supported formats: %s", name, strings.Join(gen.FormatNames(), ", "))
+		}
+		out, err := encode(shadow, nil)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s genesis: %v", name, err)
+		}
+		if err := writeJSON(formatOutput(*output, name, len(names) > 1), out); err != nil {
+			return err
+		}
+	}
+	// Summarize the changes. The genesis block itself is unchanged, which is
+	// what lets the shadow fork follow the history of the live network.
+	diff, err := diffGenesis(live, shadow)
+	if err != nil {
+		return err
+	}
+	printDiff(os.Stdout, diff)
+	for _, field := range forks.Fields {
+		if field.Time == nil || *field.Time(shadow.Config) == nil {
+			continue
+		}
+		at := **field.Time(shadow.Config)
+		if at < *baseTime {
+			continue
+		}
+		fmt.Printf("%s: %s (in %v)\n", strings.TrimSuffix(field.Flag, "-time"),
+			time.Unix(int64(at), 0).UTC().Format(time.RFC3339), time.Duration(at-*baseTime)*time.Second)
+	}
+	fmt.Printf("Genesis hash: %s\n", shadow.ToBlock().Hash().Hex())
+	return nil
+}
+
+// shadowGenesis copies the live genesis with the chain id and fork schedule
+// of the shadow fork. Forks already active on the live network at the base
+// time are kept, upcoming ones are replaced by the given schedule. The result
+// is checked for the canonical fork order, and the blob schedule entries of
+// newly scheduled forks are added.
+func shadowGenesis(live *core.Genesis, chainID string, schedule shadowForkTimes, baseTime uint64) (*core.Genesis, error) {
+	shadow := *live
+	config := *live.Config
+	shadow.Config = &config
+	if chainID != "" {
+		id, ok := math.ParseBig256(chainID)
+		if !ok || id.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid chain id %q", chainID)
+		}
+		shadow.Config.ChainID = id
+	}
+	if shadow.Config.TerminalTotalDifficulty == nil && len(schedule) > 0 {
+		return nil, errors.New("network has not gone through the merge, timestamp forks cannot be scheduled")
+	}
+	overrides := make(forks.Overrides)
+	for _, fork := range schedule {
+		if live := *fork.field.Time(live.Config); live != nil && *live < baseTime {
+			return nil, fmt.Errorf("%s already activated on the live network at %d", fork.field.Flag, *live)
+		}
+		if fork.disabled {
+			overrides[fork.field.Flag] = forks.Disable()
+			continue
+		}
+		at := fork.value
+		if fork.relative {
+			at += baseTime
+		}
+		if at < baseTime {
+			warnf("%s scheduled at %d, before the base time %d", fork.field.Flag, at, baseTime)
+		}
+		overrides[fork.field.Flag] = forks.ActivateAt(at)
+	}
+	// Upcoming forks of the live network which are not rescheduled would
+	// easily end up out of order, they are left out of the shadow fork.
+	for _, field := range forks.Fields {
+		if field.Time == nil || overrides[field.Flag] != nil {
+			continue
+		}
+		if live := *field.Time(live.Config); live != nil && *live >= baseTime {
+			overrides[field.Flag] = forks.Disable()
+		}
+	}
+	if err := overrides.Apply(shadow.Config); err != nil {
+		return nil, fmt.Errorf("invalid fork schedule: %v", err)
+	}
+	return &shadow, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+)
+
+// snapshotDump is the genesis state in the flat layout of the state snapshot:
+// the accounts keyed by the hash of their address with their slim RLP
+// encoding as value, and the storage slots keyed by the hash of the slot with
+// the RLP encoding of the trimmed value. Entries are sorted by key, the order
+// in which the snap protocol serves them.
+type snapshotDump struct {
+	Root     common.Hash                     `json:"root"`
+	Accounts []snapshotAccount               `json:"accounts"`
+	Storage  map[common.Hash][]snapshotEntry `json:"storage"`
+	Codes    map[common.Hash]hexutil.Bytes   `json:"codes"`
+}
+
+// snapshotAccount is an account entry of the snapshot. The address is not part
+// of the snapshot layout, it is included for readability.
+type snapshotAccount struct {
+	Hash    common.Hash    `json:"hash"`
+	Address common.Address `json:"address"`
+	Value   hexutil.Bytes  `json:"value"`
+}
+
+// snapshotEntry is a storage entry of the snapshot.
+type snapshotEntry struct {
+	Hash  common.Hash   `json:"hash"`
+	Value hexutil.Bytes `json:"value"`
+}
+
+// snapshotCommand exports the state of a genesis as a flat snapshot dump.
+func snapshotCommand(args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	output := fs.String("output", "snapshot.json", "output file")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: snapshot [flags] <genesis.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one genesis file")
+	}
+	genesis, err := gen.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	dump, err := exportSnapshot(genesis)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	if err := writeJSON(*output, dump); err != nil {
+		return err
+	}
+	fmt.Printf("State root: %s (%d accounts)\n", dump.Root.Hex(), len(dump.Accounts))
+	return nil
+}
+
+// exportSnapshot flattens the genesis allocation into the snapshot layout. The
+// tries are rebuilt from the flat entries and checked against the root of the
+// genesis block, so the dump is guaranteed to describe the same state.
+func exportSnapshot(genesis *core.Genesis) (*snapshotDump, error) {
+	dump := &snapshotDump{
+		Storage: make(map[common.Hash][]snapshotEntry),
+		Codes:   make(map[common.Hash]hexutil.Bytes),
+	}
+	// The account trie holds the full RLP encoding, the snapshot the slim one.
+	type flatAccount struct {
+		snapshotAccount
+		full []byte
+	}
+	var accounts []flatAccount
+	for _, addr := range alloc.SortedAddresses(genesis.Alloc) {
+		account := genesis.Alloc[addr]
+		hash := crypto.Keccak256Hash(addr.Bytes())
+
+		var entries []snapshotEntry
+		for slot, value := range account.Storage {
+			if value == (common.Hash{}) {
+				continue // zero slots are not stored
+			}
+			enc, err := rlp.EncodeToBytes(common.TrimLeftZeroes(value[:]))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, snapshotEntry{Hash: crypto.Keccak256Hash(slot.Bytes()), Value: enc})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return bytes.Compare(entries[i].Hash[:], entries[j].Hash[:]) < 0
+		})
+		storage := trie.NewStackTrie(nil)
+		for _, entry := range entries {
+			if err := storage.Update(entry.Hash[:], entry.Value); err != nil {
+				return nil, err
+			}
+		}
+		if len(entries) > 0 {
+			dump.Storage[hash] = entries
+		}
+		balance, overflow := uint256.FromBig(alloc.Balance(account))
+		if overflow {
+			return nil, fmt.Errorf("account %s: balance overflows 256 bits", addr.Hex())
+		}
+		state := types.StateAccount{
+			Nonce:    account.Nonce,
+			Balance:  balance,
+			Root:     storage.Hash(),
+			CodeHash: types.EmptyCodeHash.Bytes(),
+		}
+		if len(account.Code) > 0 {
+			codeHash := crypto.Keccak256Hash(account.Code)
+			state.CodeHash = codeHash.Bytes()
+			dump.Codes[codeHash] = account.Code
+		}
+		value, err := rlp.EncodeToBytes(&state)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, flatAccount{snapshotAccount{Hash: hash, Address: addr, Value: types.SlimAccountRLP(state)}, value})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i].Hash[:], accounts[j].Hash[:]) < 0
+	})
+	tr := trie.NewStackTrie(nil)
+	for _, account := range accounts {
+		if err := tr.Update(account.Hash[:], account.full); err != nil {
+			return nil, err
+		}
+		dump.Accounts = append(dump.Accounts, account.snapshotAccount)
+	}
+	dump.Root = tr.Hash()
+	if want := genesis.ToBlock().Root(); dump.Root != want {
+		return nil, fmt.Errorf("snapshot root %s does not match the genesis state root %s", dump.Root.Hex(), want.Hex())
+	}
+	return dump, nil
+}
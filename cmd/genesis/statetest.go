@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// stateTest is the part of an execution spec state test describing the
+// starting state. The post section is only consulted for the names of the
+// forks the test covers, the remaining fixture fields are ignored.
+type stateTest struct {
+	Env  stateTestEnv                `json:"env"`
+	Pre  map[string]stateTestAccount `json:"pre"`
+	Post map[string]json.RawMessage  `json:"post,omitempty"`
+}
+
+// stateTestEnv is the block environment of a state test.
+type stateTestEnv struct {
+	Coinbase      common.Address        `json:"currentCoinbase"`
+	Difficulty    *math.HexOrDecimal256 `json:"currentDifficulty"`
+	GasLimit      math.HexOrDecimal64   `json:"currentGasLimit"`
+	Number        math.HexOrDecimal64   `json:"currentNumber"`
+	Timestamp     math.HexOrDecimal64   `json:"currentTimestamp"`
+	BaseFee       *math.HexOrDecimal256 `json:"currentBaseFee,omitempty"`
+	Random        *common.Hash          `json:"currentRandom,omitempty"`
+	ExcessBlobGas *math.HexOrDecimal64  `json:"currentExcessBlobGas,omitempty"`
+}
+
+// stateTestAccount is an account of the state test pre-state. All numbers are
+// hex encoded strings, the way the fixtures write them.
+type stateTestAccount struct {
+	Balance string            `json:"balance"`
+	Code    string            `json:"code"`
+	Nonce   string            `json:"nonce"`
+	Storage map[string]string `json:"storage"`
+}
+
+// statetestCommand converts between genesis files and the pre/env sections of
+// execution spec state tests.
+func statetestCommand(args []string) error {
+	fs := flag.NewFlagSet("statetest", flag.ExitOnError)
+	output := fs.String("output", "", "output file (default statetest.json, or genesis.json with --import)")
+	name := fs.String("name", "", "name of the test case (default the input file name)")
+	importTest := fs.Bool("import", false, "convert a state test into a genesis file instead")
+	fork := fs.String("fork", "", "fork the imported genesis activates (default the latest fork of the test's post section)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: statetest [flags] <genesis.json | --import statetest.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one input file")
+	}
+	path := fs.Arg(0)
+	if *importTest {
+		if *output == "" {
+			*output = "genesis.json"
+		}
+		genesis, err := importStateTest(path, *name, *fork)
+		if err != nil {
+			return err
+		}
+		return writeJSON(*output, genesis)
+	}
+	if *output == "" {
+		*output = "statetest.json"
+	}
+	if *name == "" {
+		*name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	genesis, err := gen.Load(path)
+	if err != nil {
+		return err
+	}
+	test, err := exportStateTest(genesis)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return writeJSON(*output, map[string]*stateTest{*name: test})
+}
+
+// exportStateTest converts a genesis into a state test starting state. The
+// environment is taken from the genesis block header, so the fork dependent
+// fields (base fee, randomness, excess blob gas) appear exactly when the
+// corresponding forks are active at genesis.
+func exportStateTest(genesis *core.Genesis) (*stateTest, error) {
+	if genesis.Config == nil {
+		return nil, errors.New("missing chain config")
+	}
+	header := genesis.ToBlock().Header()
+	env := stateTestEnv{
+		Coinbase:   header.Coinbase,
+		Difficulty: (*math.HexOrDecimal256)(header.Difficulty),
+		GasLimit:   math.HexOrDecimal64(header.GasLimit),
+		Number:     math.HexOrDecimal64(header.Number.Uint64()),
+		Timestamp:  math.HexOrDecimal64(header.Time),
+		BaseFee:    (*math.HexOrDecimal256)(header.BaseFee),
+	}
+	if genesis.Config.TerminalTotalDifficulty != nil && header.Difficulty.Sign() == 0 {
+		random := header.MixDigest
+		env.Random = &random
+	}
+	if header.ExcessBlobGas != nil {
+		excess := math.HexOrDecimal64(*header.ExcessBlobGas)
+		env.ExcessBlobGas = &excess
+	}
+	pre := make(map[string]stateTestAccount, len(genesis.Alloc))
+	for _, addr := range alloc.SortedAddresses(genesis.Alloc) {
+		account := genesis.Alloc[addr]
+		storage := make(map[string]string, len(account.Storage))
+		for slot, value := range account.Storage {
+			if value == (common.Hash{}) {
+				continue
+			}
+			storage[hexutil.EncodeBig(slot.Big())] = hexutil.EncodeBig(value.Big())
+		}
+		pre[addr.Hex()] = stateTestAccount{
+			Balance: hexutil.EncodeBig(alloc.Balance(account)),
+			Code:    hexutil.Encode(account.Code),
+			Nonce:   hexutil.EncodeUint64(account.Nonce),
+			Storage: storage,
+		}
+	}
+	return &stateTest{Env: env, Pre: pre}, nil
+}
+
+// importStateTest reads a state test fixture and converts the named test case
+// into a genesis. If the fixture holds a single test the name may be omitted.
+// The chain config activates the given fork from genesis, defaulting to the
+// latest fork the test has expectations for.
+func importStateTest(path, name, fork string) (*core.Genesis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tests map[string]*stateTest
+	if err := json.Unmarshal(data, &tests); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if name == "" {
+		if len(tests) != 1 {
+			return nil, fmt.Errorf("%s: holds %d tests, select one with --name", path, len(tests))
+		}
+		for n := range tests {
+			name = n
+		}
+	}
+	test, ok := tests[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: no test named %q", path, name)
+	}
+	if fork == "" {
+		if fork = latestFork(test.Post); fork == "" {
+			return nil, fmt.Errorf("%s: test %s names no known fork, select one with --fork", path, name)
+		}
+	}
+	genesis, err := test.genesis(fork)
+	if err != nil {
+		return nil, fmt.Errorf("%s: test %s: %v", path, name, err)
+	}
+	return genesis, nil
+}
+
+// latestFork returns the latest of the known forks a state test has post
+// state expectations for, or the empty string if there is none.
+func latestFork(post map[string]json.RawMessage) string {
+	fork, latest := "", -1
+	for candidate := range post {
+		if index, err := forks.Index(candidate); err == nil && index > latest {
+			latest, fork = index, forks.Names()[index]
+		}
+	}
+	return fork
+}
+
+// genesis builds a genesis from the state test environment and pre-state.
+// State tests run on chain id 1.
+func (test *stateTest) genesis(fork string) (*core.Genesis, error) {
+	genesis := test.header()
+	genesis.Config = &params.ChainConfig{ChainID: big.NewInt(1)}
+	if err := forks.Activate(genesis.Config, fork); err != nil {
+		return nil, err
+	}
+	var err error
+	if genesis.Alloc, err = parsePreState(test.Pre); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+// header returns the genesis with the header fields of the state test
+// environment, without chain config and allocation.
+func (test *stateTest) header() *core.Genesis {
+	env := test.Env
+	genesis := &core.Genesis{
+		Coinbase:  env.Coinbase,
+		GasLimit:  uint64(env.GasLimit),
+		Number:    uint64(env.Number),
+		Timestamp: uint64(env.Timestamp),
+		BaseFee:   (*big.Int)(env.BaseFee),
+	}
+	genesis.Difficulty = new(big.Int)
+	if env.Difficulty != nil {
+		genesis.Difficulty = (*big.Int)(env.Difficulty)
+	}
+	if env.Random != nil {
+		genesis.Mixhash = *env.Random
+	}
+	if env.ExcessBlobGas != nil {
+		excess := uint64(*env.ExcessBlobGas)
+		genesis.ExcessBlobGas = &excess
+	}
+	return genesis
+}
+
+// parsePreState converts the pre section of a test fixture into an
+// allocation.
+func parsePreState(pre map[string]stateTestAccount) (types.GenesisAlloc, error) {
+	accounts := make(types.GenesisAlloc, len(pre))
+	for key, account := range pre {
+		if !common.IsHexAddress(key) {
+			return nil, fmt.Errorf("invalid pre address %q", key)
+		}
+		parsed, err := alloc.ParseAccount(account.Balance, account.Nonce, account.Code, account.Storage)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %v", key, err)
+		}
+		accounts[common.HexToAddress(key)] = parsed
+	}
+	return accounts, nil
+}
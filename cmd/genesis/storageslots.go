@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/layout"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// storageSlotsCommand converts assignments to the Solidity state variables of
+// a contract into the raw storage slots of a genesis allocation.
+func storageSlotsCommand(args []string) error {
+	fs := flag.NewFlagSet("storage-slots", flag.ExitOnError)
+	layoutPath := fs.String("layout", "", "YAML or JSON storage layout of the contract (required)")
+	address := fs.String("address", "", "address of the contract account (required)")
+	code := fs.String("code", "", "hex encoded runtime code of the contract account")
+	nonce := fs.String("nonce", "", "nonce of the contract account")
+	balance := fs.String("balance", "", "balance in wei of the contract account")
+	output := fs.String("output", "alloc.json", "output file of the allocation")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: storage-slots [flags] --layout <layout.yaml> --address <address> <assignments.txt>...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 || *layoutPath == "" || *address == "" {
+		fs.Usage()
+		return errors.New("expected a layout, an address and at least one assignments file")
+	}
+	if !common.IsHexAddress(*address) {
+		return fmt.Errorf("invalid address %q", *address)
+	}
+	l, err := layout.Load(*layoutPath)
+	if err != nil {
+		return err
+	}
+	var assignments []layout.Assignment
+	for _, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		parsed, err := layout.ParseAssignments(f, path)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		assignments = append(assignments, parsed...)
+	}
+	storage, err := l.Storage(assignments)
+	if err != nil {
+		return err
+	}
+	account, err := alloc.ParseAccount(*balance, *nonce, *code, nil)
+	if err != nil {
+		return err
+	}
+	if len(storage) > 0 {
+		account.Storage = storage
+	}
+	if err := writeJSON(*output, types.GenesisAlloc{common.HexToAddress(*address): account}); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d storage slots from %d assignments to %s\n", len(storage), len(assignments), *output)
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// timelineEntry is a scheduled fork together with its activation date and
+// whether it is already active in the genesis block.
+type timelineEntry struct {
+	forks.Activation
+	Date            string `json:"date,omitempty"`
+	ActiveAtGenesis bool   `json:"activeAtGenesis,omitempty"`
+}
+
+// timelineCommand prints the fork activation schedule of a chain config.
+func timelineCommand(args []string) error {
+	fs := flag.NewFlagSet("timeline", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the schedule as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: timeline [flags] <network | genesis.json | config.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one network name, genesis or chain config file")
+	}
+	var (
+		genesis *core.Genesis
+		err     error
+	)
+	if makeGenesis, ok := gen.Networks[fs.Arg(0)]; ok {
+		genesis = makeGenesis()
+	} else if genesis, err = loadChainConfig(fs.Arg(0)); err != nil {
+		return err
+	}
+	if genesis.Config == nil {
+		return fmt.Errorf("%s: missing chain config", fs.Arg(0))
+	}
+	timeline := buildTimeline(genesis)
+	if *asJSON {
+		return printJSON(timeline)
+	}
+	printTimeline(os.Stdout, timeline)
+	return nil
+}
+
+// loadChainConfig reads a genesis file, or a bare chain config which is
+// returned as the config of an otherwise empty genesis.
+func loadChainConfig(path string) (*core.Genesis, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Config json.RawMessage `json:"config"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if probe.Config != nil {
+		return gen.Load(path)
+	}
+	config := new(params.ChainConfig)
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &core.Genesis{Config: config}, nil
+}
+
+// buildTimeline lists the forks scheduled by the genesis config with their
+// activation dates.
+func buildTimeline(genesis *core.Genesis) []timelineEntry {
+	var timeline []timelineEntry
+	for _, a := range forks.Timeline(genesis.Config) {
+		timeline = append(timeline, buildTimelineEntry(genesis, a))
+	}
+	return timeline
+}
+
+// buildTimelineEntry adds the activation date to a fork of the genesis config
+// and marks it if it is active from the genesis block.
+func buildTimelineEntry(genesis *core.Genesis, a forks.Activation) timelineEntry {
+	entry := timelineEntry{Activation: a}
+	switch {
+	case a.Time != nil:
+		entry.Date = time.Unix(int64(*a.Time), 0).UTC().Format(time.RFC3339)
+		entry.ActiveAtGenesis = *a.Time <= genesis.Timestamp
+	case a.Block != nil:
+		entry.ActiveAtGenesis = a.Block.Sign() == 0
+	case a.TerminalTotalDifficulty != nil:
+		entry.ActiveAtGenesis = genesis.Difficulty != nil && genesis.Difficulty.Cmp(a.TerminalTotalDifficulty) >= 0
+	}
+	return entry
+}
+
+// printTimeline prints the schedule one fork per line: the activation point,
+// the date of timestamp activations, the EIPs and the blob parameters.
+func printTimeline(w io.Writer, timeline []timelineEntry) {
+	for _, entry := range timeline {
+		var at string
+		switch {
+		case entry.Time != nil:
+			at = fmt.Sprintf("time %d  %s", *entry.Time, entry.Date)
+		case entry.Block != nil:
+			at = fmt.Sprintf("block %v", entry.Block)
+		default:
+			at = fmt.Sprintf("ttd %v", entry.TerminalTotalDifficulty)
+		}
+		if entry.ActiveAtGenesis {
+			at += " (genesis)"
+		}
+		eips := make([]string, len(entry.EIPs))
+		for i, eip := range entry.EIPs {
+			eips[i] = fmt.Sprintf("EIP-%d", eip)
+		}
+		line := fmt.Sprintf("%-16s %-40s %s", entry.Fork, at, strings.Join(eips, ", "))
+		if entry.Blob != nil {
+			line += fmt.Sprintf(" (blobs target %d, max %d, update fraction %d)", entry.Blob.Target, entry.Blob.Max, entry.Blob.UpdateFraction)
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
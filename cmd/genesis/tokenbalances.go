@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// tokenBalancesCommand writes the balances of an ERC-20 token holder snapshot
+// into the storage of the token contract.
+func tokenBalancesCommand(args []string) error {
+	fs := flag.NewFlagSet("token-balances", flag.ExitOnError)
+	token := fs.String("token", "", "address of the token contract (required)")
+	slot := fs.String("slot", "0", "storage slot of the balances mapping of the contract")
+	supplySlot := fs.String("supply-slot", "", "also write the sum of the balances to this storage slot, the total supply")
+	base := fs.String("base", "", "allocation or genesis file holding the token contract, whose account is extended")
+	code := fs.String("code", "", "hex encoded runtime code of the token account, without --base")
+	nonce := fs.String("nonce", "", "nonce of the token account, without --base")
+	balance := fs.String("balance", "", "balance in wei of the token account, without --base")
+	output := fs.String("output", "alloc.json", "output file of the allocation")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: token-balances [flags] --token <address> <snapshot.csv>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 || *token == "" {
+		fs.Usage()
+		return errors.New("expected a token address and one snapshot file")
+	}
+	address, err := alloc.ParseAddress(*token)
+	if err != nil {
+		return fmt.Errorf("--token: %v", err)
+	}
+	mapping, err := alloc.ParseHash(*slot)
+	if err != nil {
+		return fmt.Errorf("invalid --slot %q: %v", *slot, err)
+	}
+
+	var account types.Account
+	if *base != "" {
+		if *code != "" || *nonce != "" || *balance != "" {
+			return errors.New("--code, --nonce and --balance cannot be combined with --base")
+		}
+		accounts, warnings, err := alloc.Load(*base)
+		if err != nil {
+			return err
+		}
+		reportAddressWarnings(*base, warnings)
+		var ok bool
+		if account, ok = accounts[address]; !ok {
+			return fmt.Errorf("%s: no account %s", *base, address.Hex())
+		}
+	} else if account, err = alloc.ParseAccount(*balance, *nonce, *code, nil); err != nil {
+		return err
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	balances, warnings, err := alloc.DecodeTokenBalances(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	reportAddressWarnings(fs.Arg(0), warnings)
+	slots, supply, err := alloc.TokenStorage(balances, mapping)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	written := len(slots)
+	if *supplySlot != "" {
+		at, err := alloc.ParseHash(*supplySlot)
+		if err != nil {
+			return fmt.Errorf("invalid --supply-slot %q: %v", *supplySlot, err)
+		}
+		if _, ok := slots[at]; ok {
+			return fmt.Errorf("--supply-slot %s collides with a balance", at.Hex())
+		}
+		slots[at] = common.BigToHash(supply)
+	}
+
+	storage := make(map[common.Hash]common.Hash, len(account.Storage)+len(slots))
+	for k, v := range account.Storage {
+		storage[k] = v
+	}
+	var replaced int
+	for k, v := range slots {
+		if _, ok := storage[k]; ok {
+			replaced++
+		}
+		storage[k] = v
+	}
+	if len(storage) > 0 {
+		account.Storage = storage
+	}
+	if err := writeJSON(*output, types.GenesisAlloc{address: account}); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d balance slots of %d holders, total supply %v, to %s (%d slots replaced)\n", written, len(balances), supply, *output, replaced)
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// trieNodes is the node set of a trie, mapping the hash of every node stored
+// by reference to its RLP encoding. Nodes shorter than 32 bytes are embedded
+// in their parent and not part of the set, except for the root.
+type trieNodes map[common.Hash]hexutil.Bytes
+
+// storageNodes is the node set of the storage trie of an account.
+type storageNodes struct {
+	Address common.Address `json:"address"`
+	Root    common.Hash    `json:"root"`
+	Nodes   trieNodes      `json:"nodes"`
+}
+
+// trieDump is the output of the trie-nodes command. The storage tries are
+// keyed by the hash of the account address, their path in the account trie.
+type trieDump struct {
+	Root    common.Hash                  `json:"root"`
+	Nodes   trieNodes                    `json:"nodes"`
+	Storage map[common.Hash]storageNodes `json:"storage,omitempty"`
+}
+
+// trieNodesCommand writes the trie nodes of the genesis state.
+func trieNodesCommand(args []string) error {
+	fs := flag.NewFlagSet("trie-nodes", flag.ExitOnError)
+	output := fs.String("output", "nodes.json", "output file")
+	withStorage := fs.Bool("storage", false, "include the nodes of the storage tries")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: trie-nodes [flags] <genesis.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one genesis file")
+	}
+	genesis, err := gen.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	dump, err := dumpTrieNodes(genesis, *withStorage)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	if err := writeJSON(*output, dump); err != nil {
+		return err
+	}
+	count := len(dump.Nodes)
+	for _, storage := range dump.Storage {
+		count += len(storage.Nodes)
+	}
+	fmt.Printf("State root: %s (%d nodes, %d storage tries)\n", dump.Root.Hex(), count, len(dump.Storage))
+	return nil
+}
+
+// dumpTrieNodes rebuilds the state trie of the genesis from its snapshot and
+// collects the nodes of the account trie and optionally the storage tries.
+// Every node is checked against its hash, and the roots the nodes belong to
+// are checked against the ones the snapshot was verified with.
+func dumpTrieNodes(genesis *core.Genesis, withStorage bool) (*trieDump, error) {
+	snapshot, err := exportSnapshot(genesis)
+	if err != nil {
+		return nil, err
+	}
+	dump := &trieDump{Nodes: make(trieNodes)}
+	if withStorage {
+		dump.Storage = make(map[common.Hash]storageNodes)
+	}
+	accounts := trie.NewStackTrie(collectNodes(dump.Nodes))
+	for _, account := range snapshot.Accounts {
+		full, err := types.FullAccountRLP(account.Value)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %v", account.Address.Hex(), err)
+		}
+		if err := accounts.Update(account.Hash[:], full); err != nil {
+			return nil, err
+		}
+		entries, ok := snapshot.Storage[account.Hash]
+		if !withStorage || !ok {
+			continue
+		}
+		nodes := make(trieNodes)
+		storage := trie.NewStackTrie(collectNodes(nodes))
+		for _, entry := range entries {
+			if err := storage.Update(entry.Hash[:], entry.Value); err != nil {
+				return nil, err
+			}
+		}
+		dump.Storage[account.Hash] = storageNodes{Address: account.Address, Root: storage.Hash(), Nodes: nodes}
+	}
+	dump.Root = accounts.Hash()
+	if dump.Root != snapshot.Root {
+		return nil, fmt.Errorf("account trie root %s does not match the state root %s", dump.Root.Hex(), snapshot.Root.Hex())
+	}
+	for _, storage := range dump.Storage {
+		if err := checkTrieNodes(storage.Root, storage.Nodes); err != nil {
+			return nil, fmt.Errorf("storage trie of %s: %v", storage.Address.Hex(), err)
+		}
+	}
+	if err := checkTrieNodes(dump.Root, dump.Nodes); err != nil {
+		return nil, fmt.Errorf("account trie: %v", err)
+	}
+	return dump, nil
+}
+
+// collectNodes returns a stack trie callback adding the committed nodes to
+// the set.
+func collectNodes(nodes trieNodes) trie.OnTrieNode {
+	return func(path []byte, hash common.Hash, blob []byte) {
+		nodes[hash] = common.CopyBytes(blob)
+	}
+}
+
+// checkTrieNodes verifies that the node set contains the root and that every
+// node hashes to its key.
+func checkTrieNodes(root common.Hash, nodes trieNodes) error {
+	if _, ok := nodes[root]; !ok {
+		return fmt.Errorf("root node %s missing", root.Hex())
+	}
+	for hash, blob := range nodes {
+		if have := crypto.Keccak256Hash(blob); have != hash {
+			return fmt.Errorf("node %s hashes to %s", hash.Hex(), have.Hex())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// upgradeCommand adds the fields required by newer forks to an existing
+// genesis file. Only the changed fields are touched, the rest of the file is
+// preserved byte for byte.
+func upgradeCommand(args []string) error {
+	fs := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	output := fs.String("output", "", "path of the upgraded genesis file (default stdout)")
+	withSystemContracts := fs.Bool("system-contracts", true, "insert missing system contracts required by the scheduled forks")
+	schedule := forks.RegisterFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: upgrade [flags] <genesis.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one genesis file")
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	upgraded, err := upgradeGenesis(data, schedule, *withSystemContracts)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(upgraded)
+		return err
+	}
+	return os.WriteFile(*output, upgraded, 0644)
+}
+
+// upgradeGenesis applies the schedule overrides to the raw genesis file and
+// inserts the missing system contracts.
+func upgradeGenesis(data []byte, schedule forks.Overrides, withSystemContracts bool) ([]byte, error) {
+	genesis := new(jsonGenesis)
+	if err := json.Unmarshal(data, genesis); err != nil {
+		return nil, err
+	}
+	if genesis.Config == nil {
+		return nil, errors.New("missing chain config")
+	}
+	oldConfig, err := json.Marshal(genesis.Config)
+	if err != nil {
+		return nil, err
+	}
+	if err := schedule.Resolve(uint64(genesis.Timestamp)); err != nil {
+		return nil, err
+	}
+	if err := schedule.Apply(genesis.Config); err != nil {
+		return nil, err
+	}
+	newConfig, err := json.Marshal(genesis.Config)
+	if err != nil {
+		return nil, err
+	}
+	var oldFields, newFields map[string]json.RawMessage
+	if err := json.Unmarshal(oldConfig, &oldFields); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(newConfig, &newFields); err != nil {
+		return nil, err
+	}
+	// Rewrite the changed config fields, descending into the blob schedule so
+	// that existing entries are left alone.
+	for _, key := range sortedKeys(newFields) {
+		if bytes.Equal(oldFields[key], newFields[key]) {
+			continue
+		}
+		if key == "blobSchedule" && oldFields[key] != nil {
+			var oldEntries, newEntries map[string]json.RawMessage
+			json.Unmarshal(oldFields[key], &oldEntries)
+			json.Unmarshal(newFields[key], &newEntries)
+			for _, fork := range sortedKeys(newEntries) {
+				if !bytes.Equal(oldEntries[fork], newEntries[fork]) {
+					if data, err = setJSONField(data, []string{"config", key, fork}, newEntries[fork]); err != nil {
+						return nil, err
+					}
+				}
+			}
+			continue
+		}
+		if data, err = setJSONField(data, []string{"config", key}, newFields[key]); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range sortedKeys(oldFields) {
+		if _, ok := newFields[key]; !ok {
+			if data, err = deleteJSONField(data, []string{"config", key}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !withSystemContracts {
+		return data, nil
+	}
+	// Insert the missing system contracts, matching the address key style of
+	// the existing allocation.
+	prefix := "0x"
+	existing := make(map[common.Address]bool)
+	for key := range genesis.Alloc {
+		if !strings.HasPrefix(key, "0x") {
+			prefix = ""
+		}
+		existing[common.HexToAddress(key)] = true
+	}
+	contracts := gen.SystemContractAlloc(genesis.Config)
+	for _, addr := range alloc.SortedAddresses(contracts) {
+		if existing[addr] {
+			continue
+		}
+		account, err := json.Marshal(contracts[addr])
+		if err != nil {
+			return nil, err
+		}
+		key := prefix + strings.ToLower(addr.Hex()[2:])
+		if data, err = setJSONField(data, []string{"alloc", key}, account); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// jsonGenesis is the part of a genesis file the upgrade needs to interpret.
+// The allocation is kept raw as its keys are rewritten verbatim.
+type jsonGenesis struct {
+	Config    *params.ChainConfig        `json:"config"`
+	Timestamp math.HexOrDecimal64        `json:"timestamp"`
+	Alloc     map[string]json.RawMessage `json:"alloc"`
+}
+
+// jsonField is a member of a JSON object together with the byte ranges of its
+// key and value in the document.
+type jsonField struct {
+	key                  string
+	keyStart             int
+	valueStart, valueEnd int
+}
+
+// jsonObject locates the members of the JSON object starting at offset start
+// and returns them along with the offset of the closing brace.
+func jsonObject(data []byte, start int) ([]jsonField, int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data[start:]))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, 0, fmt.Errorf("expected object at offset %d", start)
+	}
+	var fields []jsonField
+	for dec.More() {
+		keyStart := start + int(dec.InputOffset())
+		for keyStart < len(data) && data[keyStart] != '"' {
+			keyStart++
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, 0, err
+		}
+		key, _ := tok.(string)
+		valueStart := start + int(dec.InputOffset())
+		for valueStart < len(data) && (data[valueStart] == ':' || isJSONSpace(data[valueStart])) {
+			valueStart++
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, 0, err
+		}
+		fields = append(fields, jsonField{key: key, keyStart: keyStart, valueStart: valueStart, valueEnd: start + int(dec.InputOffset())})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, 0, err
+	}
+	return fields, start + int(dec.InputOffset()) - 1, nil
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// setJSONField sets the value of the member at the given path, replacing the
+// existing value in place or appending a new member to the innermost object.
+// Missing intermediate objects are created.
+func setJSONField(data []byte, path []string, value []byte) ([]byte, error) {
+	start := bytes.IndexByte(data, '{')
+	if start < 0 {
+		return nil, errors.New("document is not an object")
+	}
+	for depth, key := range path {
+		fields, end, err := jsonObject(data, start)
+		if err != nil {
+			return nil, err
+		}
+		var found *jsonField
+		for i := range fields {
+			if fields[i].key == key {
+				found = &fields[i]
+			}
+		}
+		if found != nil && depth < len(path)-1 {
+			start = found.valueStart
+			continue
+		}
+		if found != nil {
+			return splice(data, found.valueStart, found.valueEnd, value), nil
+		}
+		// Build the missing member, nesting the remaining path in new objects.
+		member := value
+		for i := len(path) - 1; i > depth; i-- {
+			member = []byte(fmt.Sprintf("{%q: %s}", path[i], member))
+		}
+		indent := jsonIndent(data, fields, start)
+		text := fmt.Sprintf("%q: %s", key, member)
+		if len(fields) == 0 {
+			return splice(data, start+1, end, []byte(text)), nil
+		}
+		last := fields[len(fields)-1]
+		return splice(data, last.valueEnd, last.valueEnd, []byte(","+indent+text)), nil
+	}
+	return data, nil
+}
+
+// deleteJSONField removes the member at the given path, if present.
+func deleteJSONField(data []byte, path []string) ([]byte, error) {
+	start := bytes.IndexByte(data, '{')
+	if start < 0 {
+		return nil, errors.New("document is not an object")
+	}
+	for depth, key := range path {
+		fields, _, err := jsonObject(data, start)
+		if err != nil {
+			return nil, err
+		}
+		for i, field := range fields {
+			if field.key != key {
+				continue
+			}
+			if depth < len(path)-1 {
+				start = field.valueStart
+				break
+			}
+			// Remove the member together with the separating comma.
+			if i > 0 {
+				return splice(data, fields[i-1].valueEnd, field.valueEnd, nil), nil
+			}
+			if len(fields) > 1 {
+				return splice(data, field.keyStart, fields[1].keyStart, nil), nil
+			}
+			return splice(data, field.keyStart, field.valueEnd, nil), nil
+		}
+	}
+	return data, nil
+}
+
+// jsonIndent returns the whitespace preceding the members of an object, so
+// that inserted members line up with the existing ones.
+func jsonIndent(data []byte, fields []jsonField, start int) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	last := fields[len(fields)-1].keyStart
+	i := last
+	for i > start && isJSONSpace(data[i-1]) {
+		i--
+	}
+	return string(data[i:last])
+}
+
+// splice replaces data[start:end] with insert.
+func splice(data []byte, start, end int, insert []byte) []byte {
+	out := make([]byte, 0, len(data)-(end-start)+len(insert))
+	out = append(out, data[:start]...)
+	out = append(out, insert...)
+	return append(out, data[end:]...)
+}
+
+// sortedKeys returns the keys of a raw JSON object in ascending order.
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
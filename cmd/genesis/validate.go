@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/compress"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+)
+
+// validationReport is the machine readable output of the validate command.
+type validationReport struct {
+	File     string       `json:"file"`
+	Valid    bool         `json:"valid"`
+	Findings gen.Findings `json:"findings"`
+}
+
+// validateCommand checks a genesis file against the spec invariants, prints
+// the findings as JSON and fails if any of them is an error.
+func validateCommand(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: validate [flags] <genesis.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one genesis file")
+	}
+	path := fs.Arg(0)
+	data, err := compress.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	report := validationReport{File: path}
+	if gen.IsChunkIndex(data) {
+		genesis, err := gen.LoadChunks(path, data)
+		if err != nil {
+			return err
+		}
+		report.Findings = gen.Validate(genesis)
+	} else {
+		report.Findings = gen.ValidateJSON(data)
+	}
+	report.Valid = report.Findings.Errors() == 0
+
+	if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+		return err
+	}
+	if !report.Valid {
+		return fmt.Errorf("%s: %d validation errors", path, report.Findings.Errors())
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/execution-specs/pkg/witness"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// witnessCommand executes a block on top of a genesis, or of the pre-state of
+// a blockchain test, and writes its execution witness.
+func witnessCommand(args []string) error {
+	fs := flag.NewFlagSet("witness", flag.ExitOnError)
+	output := fs.String("output", "witness.json", "output file")
+	blocksPath := fs.String("blocks", "", "JSON list of the RLP encoded blocks on top of the genesis, the witness is generated for the last one")
+	fixturePath := fs.String("fixture", "", "blockchain test fixture providing the genesis and the blocks instead")
+	name := fs.String("name", "", "name of the test case of --fixture (default the only one of the fixture)")
+	number := fs.Uint64("number", 0, "number of the block of --fixture to generate the witness of (default the last valid block)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: witness [flags] --blocks blocks.json <genesis.json>\n")
+		fmt.Fprintf(fs.Output(), "       witness [flags] --fixture fixture.json\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var (
+		genesis *core.Genesis
+		blocks  []*types.Block
+		err     error
+	)
+	switch {
+	case *fixturePath != "":
+		if fs.NArg() != 0 {
+			fs.Usage()
+			return errors.New("unexpected arguments")
+		}
+		genesis, blocks, err = fixtureChain(*fixturePath, *name, *number)
+	case *blocksPath != "":
+		if fs.NArg() != 1 {
+			fs.Usage()
+			return errors.New("expected exactly one genesis file")
+		}
+		if genesis, err = gen.Load(fs.Arg(0)); err == nil && genesis.Config == nil {
+			err = fmt.Errorf("%s: missing chain config", fs.Arg(0))
+		}
+		if err == nil {
+			blocks, err = loadBlocks(*blocksPath)
+		}
+	default:
+		fs.Usage()
+		return errors.New("missing --blocks or --fixture")
+	}
+	if err != nil {
+		return err
+	}
+	w, err := witness.Generate(genesis, blocks)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(*output, w); err != nil {
+		return err
+	}
+	block := blocks[len(blocks)-1]
+	fmt.Printf("Witness of block %d (%s): %d headers, %d codes, %d trie nodes\n", block.Number(), block.Hash().Hex(), len(w.Headers), len(w.Codes), len(w.State))
+	return nil
+}
+
+// verifyWitnessCommand re-executes the block of a witness against nothing but
+// the witness.
+func verifyWitnessCommand(args []string) error {
+	fs := flag.NewFlagSet("verify-witness", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: verify-witness <witness.json>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected exactly one witness file")
+	}
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	w := new(witness.Witness)
+	if err := json.Unmarshal(data, w); err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	if err := w.Verify(); err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	block, _, err := w.Decode()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Witness of block %d (%s) verified, state root %s\n", block.Number(), block.Hash().Hex(), block.Root().Hex())
+	return nil
+}
+
+// loadBlocks reads a JSON list of RLP encoded blocks.
+func loadBlocks(path string) ([]*types.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var encoded []hexutil.Bytes
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	blocks := make([]*types.Block, len(encoded))
+	for i, enc := range encoded {
+		blocks[i] = new(types.Block)
+		if err := rlp.DecodeBytes(enc, blocks[i]); err != nil {
+			return nil, fmt.Errorf("%s: block %d: %v", path, i, err)
+		}
+	}
+	return blocks, nil
+}
+
+// fixtureChain returns the genesis of a blockchain test and its valid blocks up
+// to the one with the given number, or all of them if number is zero.
+func fixtureChain(path, name string, number uint64) (*core.Genesis, []*types.Block, error) {
+	test, err := loadFixture(path, &name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if test.Header == nil {
+		return nil, nil, fmt.Errorf("%s: test %s is not a blockchain test", path, name)
+	}
+	genesis, err := test.genesis("")
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: test %s: %v", path, name, err)
+	}
+	var blocks []*types.Block
+	for i, fb := range test.Blocks {
+		if fb.ExpectException != "" {
+			continue
+		}
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(fb.RLP, block); err != nil {
+			return nil, nil, fmt.Errorf("%s: test %s: block %d: %v", path, name, i, err)
+		}
+		blocks = append(blocks, block)
+		if block.NumberU64() == number {
+			return genesis, blocks, nil
+		}
+	}
+	switch {
+	case number != 0:
+		return nil, nil, fmt.Errorf("%s: test %s has no valid block %d", path, name, number)
+	case len(blocks) == 0:
+		return nil, nil, fmt.Errorf("%s: test %s has no valid blocks", path, name)
+	}
+	return genesis, blocks, nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// The ways of obtaining the evm tool of a release.
+const (
+	fetchBuild    = "build"
+	fetchDownload = "download"
+)
+
+const (
+	// evmPackage is the package of the evm tool, built with go install.
+	evmPackage = "github.com/ethereum/go-ethereum/cmd/evm"
+
+	// tagURL is the GitHub API endpoint resolving a release tag.
+	tagURL = "https://api.github.com/repos/ethereum/go-ethereum/git/ref/tags/"
+
+	// archiveURL is the archive of the tools of a release on the geth
+	// download server, given the platform, the version without the leading
+	// v and the first 8 digits of the commit of the tag.
+	archiveURL = "https://gethstore.blob.core.windows.net/builds/geth-alltools-%s-%s-%s-%s.tar.gz"
+)
+
+// fetcher obtains the evm tools of go-ethereum releases, keeping them in a
+// cache directory with a subdirectory per release.
+type fetcher struct {
+	method  string
+	cache   string
+	timeout time.Duration
+}
+
+// evm returns the path of the evm tool of the release given by its tag,
+// building or downloading it unless it is cached.
+func (f *fetcher) evm(version string) (string, error) {
+	if !strings.HasPrefix(version, "v") || strings.ContainsAny(version, `/\`) {
+		return "", fmt.Errorf("invalid release %q, releases are tags such as v1.14.0", version)
+	}
+	dir := filepath.Join(f.cache, version)
+	tool := filepath.Join(dir, "evm")
+	if runtime.GOOS == "windows" {
+		tool += ".exe"
+	}
+	if _, err := os.Stat(tool); err == nil {
+		return tool, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	var err error
+	switch f.method {
+	case fetchBuild:
+		fmt.Printf("Building evm %s\n", version)
+		err = build(ctx, version, dir)
+	case fetchDownload:
+		fmt.Printf("Downloading evm %s\n", version)
+		err = download(ctx, version, tool)
+	default:
+		err = fmt.Errorf("unknown fetch method %q, supported methods: %s, %s", f.method, fetchBuild, fetchDownload)
+	}
+	if err != nil {
+		return "", fmt.Errorf("evm %s: %v", version, err)
+	}
+	return tool, nil
+}
+
+// build installs the evm tool of the release into the directory with go
+// install, outside of the module of this repository so that the release is
+// built with the dependencies of its own go.mod.
+func build(ctx context.Context, version, dir string) error {
+	cmd := exec.CommandContext(ctx, "go", "install", evmPackage+"@"+version)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOBIN="+dir, "GOFLAGS=", "GOWORK=off")
+	output, err := cmd.CombinedOutput()
+	switch {
+	case ctx.Err() != nil:
+		return errors.New("build timed out")
+	case err != nil && len(output) > 0:
+		return fmt.Errorf("%v: %s", err, lastLine(output))
+	}
+	return err
+}
+
+// download extracts the evm tool from the tools archive of the release for
+// the platform into the file.
+func download(ctx context.Context, version, file string) error {
+	if runtime.GOOS == "windows" {
+		return errors.New("the windows archives are not supported, build the release instead")
+	}
+	commit, err := tagCommit(ctx, version)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf(archiveURL, runtime.GOOS, runtime.GOARCH, strings.TrimPrefix(version, "v"), commit[:8])
+	body, err := get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return fmt.Errorf("%s: %v", url, err)
+	}
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%s: no evm tool in the archive", url)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", url, err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == "evm" {
+			return writeExecutable(file, archive)
+		}
+	}
+}
+
+// tagCommit resolves a release tag to the commit it points to, following
+// annotated tags to their target.
+func tagCommit(ctx context.Context, version string) (string, error) {
+	type object struct {
+		Object struct {
+			Sha  string `json:"sha"`
+			Type string `json:"type"`
+			URL  string `json:"url"`
+		} `json:"object"`
+	}
+	url := tagURL + version
+	for range 2 {
+		body, err := get(ctx, url)
+		if err != nil {
+			return "", err
+		}
+		var obj object
+		err = json.NewDecoder(body).Decode(&obj)
+		body.Close()
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", url, err)
+		}
+		switch obj.Object.Type {
+		case "commit":
+			if len(obj.Object.Sha) < 8 {
+				return "", fmt.Errorf("%s: invalid commit %q", url, obj.Object.Sha)
+			}
+			return obj.Object.Sha, nil
+		case "tag":
+			url = obj.Object.URL
+		default:
+			return "", fmt.Errorf("%s: tag points to a %q", url, obj.Object.Type)
+		}
+	}
+	return "", fmt.Errorf("tag %s does not point to a commit", version)
+}
+
+// get requests the URL and returns the body of a successful response.
+func get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// writeExecutable writes the executable to a temporary file first, so that
+// an interrupted download leaves no truncated tool in the cache.
+func writeExecutable(file string, r io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".evm-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0755)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func lastLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return lines[len(lines)-1]
+}
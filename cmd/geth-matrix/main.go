@@ -0,0 +1,159 @@
+// geth-matrix runs a state test corpus on the evm tools of several
+// go-ethereum releases and on the execution specs, and reports a matrix of
+// the divergences of every release from the specs, to pinpoint the release
+// in which a behavior changed.
+//
+// Usage:
+//
+//	go run ./cmd/geth-matrix --geth v1.13.15 --geth v1.14.0 --geth v1.14.11 fixtures/
+//	go run ./cmd/geth-matrix --fetch download --geth v1.14.0 --geth go --fork Cancun fixtures/
+//	go run ./cmd/geth-matrix --reference eels=/opt/eels/bin/ethereum-spec-evm \
+//	    --geth old=/opt/geth-1.13/evm --geth new=/opt/geth-1.14/evm tests.json
+//
+// A version is a release tag, whose evm tool is obtained with --fetch and
+// kept in --cache: "build" installs it with go install at the tag, which
+// needs a Go toolchain able to build the release, "download" extracts it
+// from the tools archive of the release on the geth download server for
+// this platform, resolving the commit of the tag on GitHub. A version is
+// also "go" for the go-ethereum version of go.mod, in process, geth for the
+// evm tool on the PATH, or NAME=COMMAND for an evm tool already at hand.
+// Versions are given oldest first, the order changes of behavior are
+// searched in.
+//
+// The reference is a backend of backend-diff, by default the eels evm tool
+// of the execution specs on the PATH. Directories are searched for .json
+// files recursively, skipping hidden ones; tests other than state tests are
+// skipped. Every post state of the forks selected with --fork is executed
+// once on the reference and on every version, and the post state roots and
+// EIP-3155 traces of a version are compared with those of the reference as
+// by evm-fuzz. Releases predating a fork fail to execute its post states,
+// which are reported as errors.
+//
+// The matrix of the test cases on which some version does not agree with the
+// reference is printed with a column per version, followed by the summary of
+// every version and the changes: the test cases whose verdict differs between
+// consecutive versions, a divergence appearing, disappearing or moving to
+// another operation or field. The full results are written as JSON to
+// --report. The tool exits with a nonzero code if any version diverges or
+// fails.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/backend"
+	"github.com/ethereum/execution-specs/pkg/fixtures"
+	"github.com/ethereum/execution-specs/pkg/flags"
+)
+
+func main() {
+	var (
+		defs         flags.Strings
+		reference    = flag.String("reference", "eels", "backend the versions are compared with")
+		fetch        = flag.String("fetch", fetchBuild, "how the evm tools of release tags are obtained ("+fetchBuild+" or "+fetchDownload+")")
+		cache        = flag.String("cache", defaultCache(), "directory the evm tools of release tags are kept in")
+		forkList     = flag.String("fork", "", "comma separated forks to run (default all)")
+		jobs         = flag.Int("jobs", runtime.NumCPU(), "number of test cases run in parallel")
+		timeout      = flag.Duration("timeout", 10*time.Minute, "timeout of a single subprocess invocation")
+		fetchTimeout = flag.Duration("fetch-timeout", 30*time.Minute, "timeout of building or downloading an evm tool")
+		reportPath   = flag.String("report", "geth_matrix.json", "path of the JSON report")
+	)
+	flag.Var(&defs, "geth", "version to compare: a release tag, go, geth or NAME=COMMAND (repeatable)")
+	flag.Parse()
+	if len(defs) == 0 {
+		fatalf("no versions given, give them with --geth")
+	}
+	if flag.NArg() == 0 {
+		fatalf("no fixture files or directories given")
+	}
+
+	ref, err := backend.Parse(*reference, 1, *timeout)
+	if err != nil {
+		fatalf("reference: %v", err)
+	}
+	if !backend.RunsStateTests(ref) {
+		fatalf("reference %s runs no state tests", ref.Name())
+	}
+	f := &fetcher{method: *fetch, cache: *cache, timeout: *fetchTimeout}
+	var (
+		versions []backend.Backend
+		names    []string
+		seen     = map[string]bool{ref.Name(): true}
+	)
+	for _, def := range defs {
+		if _, known := backend.Tools[def]; !known && def != "go" && !strings.Contains(def, "=") {
+			tool, err := f.evm(def)
+			if err != nil {
+				fatalf("%v", err)
+			}
+			def += "=" + tool
+		}
+		b, err := backend.Parse(def, 1, *timeout)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if seen[b.Name()] {
+			fatalf("duplicate version %s", b.Name())
+		}
+		seen[b.Name()] = true
+		versions = append(versions, b)
+		names = append(names, b.Name())
+	}
+
+	forks := make(map[string]bool)
+	if *forkList != "" {
+		for _, fork := range strings.Split(*forkList, ",") {
+			forks[strings.TrimSpace(fork)] = true
+		}
+	}
+	files, err := fixtures.Collect(flag.Args())
+	if err != nil {
+		fatalf("%v", err)
+	}
+	cases, skipped, err := collectCases(files, forks)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if len(cases) == 0 {
+		fatalf("no state test post states found")
+	}
+	fmt.Printf("Running %d test cases of %d files on %s and %d versions\n", len(cases), len(files), ref.Name(), len(versions))
+	start := time.Now()
+	results := runCases(cases, ref, versions, max(*jobs, 1))
+	report := newReport(ref.Name(), names, skipped, results, time.Since(start))
+
+	data, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*reportPath, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	printMatrix(os.Stdout, report)
+	fmt.Printf("%d test cases, %d changes of behavior, %d tests skipped in %v\n", len(cases), len(report.Changes), skipped, report.Duration)
+	if report.diverged() || len(report.Failed) > 0 {
+		os.Exit(1)
+	}
+}
+
+// defaultCache returns the directory of the evm tools in the user cache, or
+// in the working directory if there is none.
+func defaultCache() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "geth-matrix"
+	}
+	return filepath.Join(dir, "execution-specs", "geth-matrix")
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
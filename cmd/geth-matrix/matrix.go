@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/execution-specs/pkg/backend"
+	"github.com/ethereum/execution-specs/pkg/evmfuzz"
+)
+
+// The statuses of a test case on a version.
+const (
+	statusAgree   = "agree"
+	statusDiverge = "diverge"
+	statusError   = "error"
+)
+
+// testCase is a post state of a state test, as a state test holding only it.
+type testCase struct {
+	File  string `json:"file"`
+	Test  string `json:"test"`
+	Fork  string `json:"fork"`
+	Index string `json:"index"` // indexes of the post state, e.g. d0g1v0
+
+	test json.RawMessage
+}
+
+// id names the test case in the output.
+func (c *testCase) id() string {
+	return fmt.Sprintf("%s %s %s %s", c.File, c.Test, c.Fork, c.Index)
+}
+
+// verdict is the outcome of a test case on a version compared with the
+// reference.
+type verdict struct {
+	Status    string `json:"status"`
+	Signature string `json:"signature,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+}
+
+// caseResult holds the verdicts of a test case by version. A test case the
+// reference fails to execute has an error instead and no verdicts.
+type caseResult struct {
+	*testCase
+	Error    string              `json:"error,omitempty"`
+	Verdicts map[string]*verdict `json:"verdicts,omitempty"`
+}
+
+// change is a test case whose behavior changes between two consecutive
+// versions.
+type change struct {
+	Case string   `json:"case"`
+	From string   `json:"from"` // last version with the old behavior
+	To   string   `json:"to"`   // first version with the new behavior
+	Old  *verdict `json:"old"`
+	New  *verdict `json:"new"`
+}
+
+// collectCases loads the state tests of the files and returns a test case
+// for every post state of the selected forks, or of all forks if there are
+// none. Tests of other formats are skipped and counted.
+func collectCases(files []string, forks map[string]bool) (cases []*testCase, skipped int, err error) {
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, 0, err
+		}
+		var tests map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(data, &tests); err != nil {
+			return nil, 0, fmt.Errorf("%s: %v", file, err)
+		}
+		for _, name := range sortedKeys(tests) {
+			fields := tests[name]
+			var post map[string][]json.RawMessage
+			if fields["transaction"] == nil || json.Unmarshal(fields["post"], &post) != nil {
+				skipped++
+				continue
+			}
+			for _, fork := range sortedKeys(post) {
+				if len(forks) > 0 && !forks[fork] {
+					continue
+				}
+				for _, state := range post[fork] {
+					c, err := newCase(file, name, fork, fields, state)
+					if err != nil {
+						return nil, 0, fmt.Errorf("%s: %s: %v", file, name, err)
+					}
+					cases = append(cases, c)
+				}
+			}
+		}
+	}
+	return cases, skipped, nil
+}
+
+// newCase returns the test case of the post state of the test.
+func newCase(file, name, fork string, fields map[string]json.RawMessage, state json.RawMessage) (*testCase, error) {
+	var post struct {
+		Indexes struct {
+			Data  int `json:"data"`
+			Gas   int `json:"gas"`
+			Value int `json:"value"`
+		} `json:"indexes"`
+	}
+	if err := json.Unmarshal(state, &post); err != nil {
+		return nil, err
+	}
+	single := make(map[string]json.RawMessage, len(fields))
+	for key, value := range fields {
+		single[key] = value
+	}
+	single["post"], _ = json.Marshal(map[string][]json.RawMessage{fork: {state}})
+	test, err := json.Marshal(single)
+	if err != nil {
+		return nil, err
+	}
+	return &testCase{
+		File:  file,
+		Test:  name,
+		Fork:  fork,
+		Index: fmt.Sprintf("d%dg%dv%d", post.Indexes.Data, post.Indexes.Gas, post.Indexes.Value),
+		test:  test,
+	}, nil
+}
+
+// runCases runs every test case on the reference and the versions using the
+// given number of workers and returns the results in case order.
+func runCases(cases []*testCase, reference backend.Backend, versions []backend.Backend, jobs int) []*caseResult {
+	var (
+		results = make([]*caseResult, len(cases))
+		next    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range next {
+				results[index] = runCase(cases[index], reference, versions)
+			}
+		}()
+	}
+	for i := range cases {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// runCase executes the test case once on the reference and compares the
+// result of every version with it.
+func runCase(c *testCase, reference backend.Backend, versions []backend.Backend) *caseResult {
+	result := &caseResult{testCase: c}
+	want, err := reference.Run(c.test, c.Fork)
+	if err != nil {
+		result.Error = fmt.Sprintf("%s: %v", reference.Name(), err)
+		return result
+	}
+	result.Verdicts = make(map[string]*verdict, len(versions))
+	for _, b := range versions {
+		got, err := b.Run(c.test, c.Fork)
+		if err != nil {
+			result.Verdicts[b.Name()] = &verdict{Status: statusError, Signature: "error " + b.Name(), Detail: err.Error()}
+			continue
+		}
+		v := &verdict{Status: statusAgree}
+		if f := evmfuzz.CompareResults(want, got); f != nil {
+			v = &verdict{Status: statusDiverge, Signature: f.Signature, Detail: f.Detail}
+		}
+		result.Verdicts[b.Name()] = v
+	}
+	return result
+}
+
+// changes lists the changes of behavior between consecutive versions. Two
+// verdicts are the same behavior if they have the same status and the same
+// kind of divergence, the signature without the names of the backends.
+func changes(results []*caseResult, versions []string) []change {
+	var all []change
+	for _, r := range results {
+		if r.Error != "" {
+			continue
+		}
+		for i := 1; i < len(versions); i++ {
+			before, after := r.Verdicts[versions[i-1]], r.Verdicts[versions[i]]
+			if before.Status != after.Status || kind(before, versions[i-1]) != kind(after, versions[i]) {
+				all = append(all, change{Case: r.id(), From: versions[i-1], To: versions[i], Old: before, New: after})
+			}
+		}
+	}
+	return all
+}
+
+// kind returns the signature of a verdict of the version with the backend
+// names removed, "<reference> vs <version> at SUB" becoming "at SUB".
+func kind(v *verdict, version string) string {
+	if v.Status == statusError {
+		return ""
+	}
+	if _, rest, ok := strings.Cut(v.Signature, " vs "+version+" "); ok {
+		return rest
+	}
+	return v.Signature
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// report is the JSON report of a run.
+type report struct {
+	Reference string            `json:"reference"`
+	Versions  []string          `json:"versions"`
+	Skipped   int               `json:"skipped"` // tests other than state tests
+	Summary   map[string]*tally `json:"summary"`
+	Changes   []change          `json:"changes"`
+	Duration  time.Duration     `json:"duration"`
+	Results   []*caseResult     `json:"results"`
+	Failed    []string          `json:"failed,omitempty"` // cases the reference failed
+}
+
+// tally counts the verdicts of a version.
+type tally struct {
+	Agree   int `json:"agree"`
+	Diverge int `json:"diverge"`
+	Error   int `json:"error"`
+}
+
+func newReport(reference string, versions []string, skipped int, results []*caseResult, duration time.Duration) *report {
+	r := &report{
+		Reference: reference,
+		Versions:  versions,
+		Skipped:   skipped,
+		Summary:   make(map[string]*tally, len(versions)),
+		Changes:   changes(results, versions),
+		Duration:  duration.Round(time.Millisecond),
+		Results:   results,
+	}
+	for _, version := range versions {
+		r.Summary[version] = new(tally)
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			r.Failed = append(r.Failed, result.id())
+			continue
+		}
+		for version, v := range result.Verdicts {
+			switch v.Status {
+			case statusAgree:
+				r.Summary[version].Agree++
+			case statusDiverge:
+				r.Summary[version].Diverge++
+			default:
+				r.Summary[version].Error++
+			}
+		}
+	}
+	return r
+}
+
+// diverged reports whether any version diverged from the reference or failed.
+func (r *report) diverged() bool {
+	for _, t := range r.Summary {
+		if t.Diverge > 0 || t.Error > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// printMatrix prints the matrix of the test cases on which some version does
+// not agree with the reference, with a column per version, followed by the
+// summary of every version and the changes of behavior.
+func printMatrix(w io.Writer, r *report) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "case\t%s\n", strings.Join(r.Versions, "\t"))
+	for _, result := range r.Results {
+		if result.Error != "" || agreeing(result) {
+			continue
+		}
+		cells := make([]string, len(r.Versions))
+		for i, version := range r.Versions {
+			switch v := result.Verdicts[version]; v.Status {
+			case statusAgree:
+				cells[i] = "ok"
+			case statusDiverge:
+				cells[i] = "DIFF " + kind(v, version)
+			default:
+				cells[i] = "ERR"
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", result.id(), strings.Join(cells, "\t"))
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	for _, version := range r.Versions {
+		t := r.Summary[version]
+		fmt.Fprintf(w, "%s: %d agree, %d diverge, %d error\n", version, t.Agree, t.Diverge, t.Error)
+	}
+	for _, c := range r.Changes {
+		fmt.Fprintf(w, "CHANGE %s\n        %s %s, %s %s\n", c.Case, c.From, describe(c.Old, c.From), c.To, describe(c.New, c.To))
+	}
+	for _, id := range r.Failed {
+		fmt.Fprintf(w, "FAIL %s: not executed by %s\n", id, r.Reference)
+	}
+}
+
+// agreeing reports whether every version agrees with the reference.
+func agreeing(result *caseResult) bool {
+	for _, v := range result.Verdicts {
+		if v.Status != statusAgree {
+			return false
+		}
+	}
+	return true
+}
+
+// describe summarizes a verdict of the version.
+func describe(v *verdict, version string) string {
+	switch v.Status {
+	case statusAgree:
+		return "agrees"
+	case statusDiverge:
+		return "diverges " + kind(v, version)
+	}
+	return "fails: " + v.Detail
+}
@@ -0,0 +1,163 @@
+// intrinsic-gas computes the intrinsic gas of a transaction on each fork and
+// generates boundary case vectors of it.
+//
+// Usage:
+//
+//	go run ./cmd/intrinsic-gas [flags] [--data 0x... | --tx 0x...]
+//	go run ./cmd/intrinsic-gas --vectors [--forks Frontier,...,Osaka] [--output intrinsic_vectors]
+//
+// The calculator prints the intrinsic gas of the transaction itemized into
+// the base cost, the calldata cost, the EIP-3860 initcode word cost, the
+// access list and the authorization costs, together with the EIP-7623
+// calldata floor and the resulting minimum gas limit. The transaction is
+// described by --data, --create, the access list sizes and the number of
+// authorizations, or given as a signed transaction with --tx. With --gas the
+// gas limit is checked on every fork, including the EIP-3860 initcode size
+// limit and the EIP-7825 gas limit cap.
+//
+// With --vectors the boundary cases of vectors.go are written to the output
+// directory:
+//
+//   - intrinsic.json, the inputs of every case with the itemized intrinsic gas
+//     on each fork.
+//   - transaction_tests.json, transaction_tests fixtures of every case with a
+//     gas limit of exactly the minimum of a fork and one below it, with the
+//     intrinsic gas or the exception of every fork.
+//
+// The costs are checked against go-ethereum's, and the fixtures run through
+// go-ethereum's transaction test runner, before anything is written.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/intrinsic"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// defaultForks are the forks changing the intrinsic gas or its validity.
+const defaultForks = "Frontier,Homestead,Istanbul,Berlin,Shanghai,Prague,Osaka"
+
+func main() {
+	var (
+		forkList  = flag.String("forks", "", "comma separated forks (default: "+defaultForks+", with --vectors "+defaultVectorForks+")")
+		data      = flag.String("data", "", "hex encoded calldata or initcode")
+		create    = flag.Bool("create", false, "the transaction creates a contract")
+		addresses = flag.Int("access-list-addresses", 0, "number of access list addresses")
+		keys      = flag.Int("access-list-keys", 0, "number of access list storage keys")
+		auths     = flag.Int("authorizations", 0, "number of EIP-7702 authorizations")
+		rawTx     = flag.String("tx", "", "hex encoded signed transaction, instead of the flags describing it")
+		gasLimit  = flag.Uint64("gas", 0, "gas limit to check on every fork")
+		asJSON    = flag.Bool("json", false, "print the intrinsic gas as JSON")
+		vectors   = flag.Bool("vectors", false, "generate the boundary case vectors")
+		output    = flag.String("output", "intrinsic_vectors", "directory the vectors are written to")
+	)
+	flag.Parse()
+	if *vectors {
+		if *forkList == "" {
+			*forkList = defaultVectorForks
+		}
+		if err := writeVectors(strings.Split(*forkList, ","), *output); err != nil {
+			fatalf("%v", err)
+		}
+		return
+	}
+	if *forkList == "" {
+		*forkList = defaultForks
+	}
+	tx := &intrinsic.Tx{Create: *create, AccessListAddresses: *addresses, AccessListKeys: *keys, Authorizations: *auths}
+	if *rawTx != "" {
+		enc, err := hexutil.Decode(*rawTx)
+		if err != nil {
+			fatalf("invalid --tx: %v", err)
+		}
+		signed := new(types.Transaction)
+		if err := signed.UnmarshalBinary(enc); err != nil {
+			fatalf("invalid --tx: %v", err)
+		}
+		tx = intrinsic.FromTransaction(signed)
+		if *gasLimit == 0 {
+			*gasLimit = signed.Gas()
+		}
+	} else if *data != "" {
+		var err error
+		if tx.Data, err = hexutil.Decode(*data); err != nil {
+			fatalf("invalid --data: %v", err)
+		}
+	}
+	results, err := calculate(strings.Split(*forkList, ","), tx, *gasLimit)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if *asJSON {
+		out, _ := json.MarshalIndent(results, "", "    ")
+		fmt.Println(string(out))
+		return
+	}
+	printResults(results)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// result is the intrinsic gas of the transaction on a fork, or the reason it
+// is invalid there.
+type result struct {
+	Fork string `json:"fork"`
+	*intrinsic.Gas
+	Error string `json:"error,omitempty"`
+}
+
+// calculate computes the intrinsic gas on each fork and, with a nonzero gas
+// limit, checks the limit.
+func calculate(forkNames []string, tx *intrinsic.Tx, gasLimit uint64) ([]result, error) {
+	var results []result
+	for _, name := range forkNames {
+		index, err := forks.Index(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		fork := forks.Names()[index]
+		gas, err := intrinsic.Compute(fork, tx)
+		switch {
+		case errors.Is(err, intrinsic.ErrAccessList), errors.Is(err, intrinsic.ErrAuthorization), errors.Is(err, intrinsic.ErrCreateSetCode):
+			results = append(results, result{Fork: fork, Error: err.Error()})
+			continue
+		case err != nil:
+			return nil, err
+		}
+		r := result{Fork: fork, Gas: gas}
+		if gasLimit != 0 {
+			if err := intrinsic.Check(fork, tx, gasLimit); err != nil {
+				r.Error = err.Error()
+			}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// printResults prints a table of the itemized intrinsic gas, one fork per
+// line, followed by the reason the transaction is invalid on the fork.
+func printResults(results []result) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "fork\tbase\tcalldata\tinitcode\taccess list\tauthorizations\tintrinsic\tfloor\trequired\t")
+	for _, r := range results {
+		if r.Gas == nil {
+			fmt.Fprintf(w, "%s\t\t\t\t\t\t\t\t\t%s\n", r.Fork, r.Error)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\n", r.Fork, r.Base, r.Calldata, r.Initcode, r.AccessList, r.Authorizations, r.Intrinsic, r.Floor, r.Required, r.Error)
+	}
+	w.Flush()
+}
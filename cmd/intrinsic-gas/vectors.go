@@ -0,0 +1,418 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/intrinsic"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/holiman/uint256"
+)
+
+// defaultVectorForks are the forks the vectors are filled for, by the names
+// of go-ethereum's tests.
+const defaultVectorForks = "Frontier,Homestead,EIP150,EIP158,Byzantium,ConstantinopleFix,Istanbul,Berlin,London,Paris,Shanghai,Cancun,Prague,Osaka"
+
+// The exceptions of the transaction vectors.
+const (
+	exceptionIntrinsicGas = "TransactionException.INTRINSIC_GAS_TOO_LOW"
+	exceptionFloorGas     = "TransactionException.INTRINSIC_GAS_BELOW_FLOOR_GAS_COST"
+	exceptionInitcodeSize = "TransactionException.INITCODE_SIZE_EXCEEDED"
+	exceptionGasLimitCap  = "TransactionException.GAS_LIMIT_EXCEEDS_MAXIMUM"
+	exceptionType1PreFork = "TransactionException.TYPE_1_TX_PRE_FORK"
+	exceptionType4PreFork = "TransactionException.TYPE_4_TX_PRE_FORK"
+)
+
+var (
+	senderKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	recipient    = common.HexToAddress("0x0000000000000000000000000000000000001000")
+	delegate     = common.HexToAddress("0x0000000000000000000000000000000000002000")
+	chainID      = big.NewInt(1)
+)
+
+// gasCase is a boundary case of the intrinsic gas. Unless fixed gas limits
+// are given, the transaction vectors use the intrinsic gas and the minimum
+// gas limit of every fork, and one gas below each of them.
+type gasCase struct {
+	name        string
+	description string
+	txType      byte
+	tx          intrinsic.Tx
+	duplicate   bool     // the access list repeats its address and keys
+	gasLimits   []uint64 // fixed gas limits, replacing the boundaries
+}
+
+// typeFork is the fork introducing each transaction type.
+var typeFork = map[byte]string{
+	types.LegacyTxType:     "Frontier",
+	types.AccessListTxType: "Berlin",
+	types.SetCodeTxType:    "Prague",
+}
+
+// typeException is the exception of each transaction type before its fork.
+var typeException = map[byte]string{
+	types.AccessListTxType: exceptionType1PreFork,
+	types.SetCodeTxType:    exceptionType4PreFork,
+}
+
+func gasCases() []gasCase {
+	var (
+		nonZero = func(n int) []byte { return bytes.Repeat([]byte{0xff}, n) }
+		zero    = func(n int) []byte { return make([]byte, n) }
+	)
+	return []gasCase{
+		{name: "empty", description: "call without calldata, the base cost only",
+			txType: types.LegacyTxType},
+		{name: "zero_byte", description: "a single zero calldata byte",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Data: zero(1)}},
+		{name: "nonzero_byte", description: "a single nonzero calldata byte, 68 gas before Istanbul and 16 since (EIP-2028)",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Data: nonZero(1)}},
+		{name: "mixed_calldata", description: "a word of calldata, half of it zero bytes",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Data: append(zero(16), nonZero(16)...)}},
+		{name: "create_empty", description: "creation without initcode, 21000 gas before Homestead and 53000 since (EIP-2)",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Create: true}},
+		{name: "create_one_byte", description: "creation with a single initcode byte, a full initcode word since Shanghai (EIP-3860)",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Create: true, Data: nonZero(1)}},
+		{name: "create_one_word", description: "creation with exactly one word of initcode",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Create: true, Data: nonZero(32)}},
+		{name: "create_word_and_byte", description: "creation with one byte more than a word of initcode, two initcode words",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Create: true, Data: nonZero(33)}},
+		{name: "create_initcode_max", description: "creation with initcode of the maximum size (EIP-3860)",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Create: true, Data: zero(intrinsic.MaxInitcodeSize)}},
+		{name: "create_initcode_too_large", description: "creation with initcode one byte above the maximum size, invalid since Shanghai (EIP-3860)",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Create: true, Data: zero(intrinsic.MaxInitcodeSize + 1)}, gasLimits: []uint64{600000}},
+		{name: "create_floor", description: "creation whose calldata floor exceeds the intrinsic gas including the initcode words (EIP-7623)",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Create: true, Data: zero(10000)}},
+		{name: "create_no_floor", description: "creation whose intrinsic gas exceeds the calldata floor",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Create: true, Data: nonZero(1000)}},
+		{name: "access_list_empty", description: "access list transaction with an empty access list",
+			txType: types.AccessListTxType},
+		{name: "access_list_address", description: "access list with one address and no storage keys (EIP-2930)",
+			txType: types.AccessListTxType, tx: intrinsic.Tx{AccessListAddresses: 1}},
+		{name: "access_list_keys", description: "access list with one address and two storage keys",
+			txType: types.AccessListTxType, tx: intrinsic.Tx{AccessListAddresses: 1, AccessListKeys: 2}},
+		{name: "access_list_duplicates", description: "access list repeating an address and a storage key, every entry is charged",
+			txType: types.AccessListTxType, tx: intrinsic.Tx{AccessListAddresses: 2, AccessListKeys: 2}, duplicate: true},
+		{name: "floor_below_intrinsic", description: "99 nonzero calldata bytes and an access list address, the intrinsic gas exceeds the floor by 24",
+			txType: types.AccessListTxType, tx: intrinsic.Tx{Data: nonZero(99), AccessListAddresses: 1}},
+		{name: "floor_equals_intrinsic", description: "100 nonzero calldata bytes and an access list address, the floor equals the intrinsic gas",
+			txType: types.AccessListTxType, tx: intrinsic.Tx{Data: nonZero(100), AccessListAddresses: 1}},
+		{name: "floor_above_intrinsic", description: "101 nonzero calldata bytes and an access list address, the floor exceeds the intrinsic gas by 24",
+			txType: types.AccessListTxType, tx: intrinsic.Tx{Data: nonZero(101), AccessListAddresses: 1}},
+		{name: "floor_zero_bytes", description: "100 zero calldata bytes, a token each for the floor",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Data: zero(100)}},
+		{name: "floor_nonzero_bytes", description: "1000 nonzero calldata bytes, four tokens each for the floor",
+			txType: types.LegacyTxType, tx: intrinsic.Tx{Data: nonZero(1000)}},
+		{name: "authorization", description: "set code transaction with one authorization (EIP-7702)",
+			txType: types.SetCodeTxType, tx: intrinsic.Tx{Authorizations: 1}},
+		{name: "authorizations_access_list_calldata", description: "set code transaction with two authorizations, an access list and calldata",
+			txType: types.SetCodeTxType, tx: intrinsic.Tx{Data: nonZero(64), AccessListAddresses: 1, AccessListKeys: 1, Authorizations: 2}},
+		{name: "gas_limit_cap", description: "gas limits at and one above the transaction gas limit cap of Osaka (EIP-7825)",
+			txType: types.LegacyTxType, gasLimits: []uint64{intrinsic.MaxTransactionGasLimit, intrinsic.MaxTransactionGasLimit + 1}},
+	}
+}
+
+// gasVector is a case with its itemized intrinsic gas on the forks having
+// its transaction type.
+type gasVector struct {
+	Name                string                    `json:"name"`
+	Description         string                    `json:"description"`
+	Type                hexutil.Uint64            `json:"type"`
+	Data                hexutil.Bytes             `json:"data"`
+	Create              bool                      `json:"create"`
+	AccessListAddresses int                       `json:"accessListAddresses"`
+	AccessListKeys      int                       `json:"accessListKeys"`
+	Authorizations      int                       `json:"authorizations"`
+	Gas                 map[string]*intrinsic.Gas `json:"gas"`
+}
+
+// txFixture is a transaction_tests fixture.
+type txFixture struct {
+	Info    map[string]string    `json:"_info"`
+	TxBytes hexutil.Bytes        `json:"txbytes"`
+	Result  map[string]*txResult `json:"result"`
+}
+
+// txResult is the outcome of a transaction vector on one fork.
+type txResult struct {
+	Hash         *common.Hash    `json:"hash,omitempty"`
+	Sender       *common.Address `json:"sender,omitempty"`
+	IntrinsicGas hexutil.Uint64  `json:"intrinsicGas"`
+	Exception    string          `json:"exception,omitempty"`
+}
+
+// writeVectors fills the cases on the forks and writes the vectors.
+func writeVectors(forkNames []string, output string) error {
+	for i, fork := range forkNames {
+		forkNames[i] = strings.TrimSpace(fork)
+		if _, ok := tests.Forks[forkNames[i]]; !ok {
+			return fmt.Errorf("unknown fork %q", fork)
+		}
+		if _, err := forks.Index(forkNames[i]); err != nil {
+			return err
+		}
+	}
+	if intrinsic.MaxInitcodeSize != params.MaxInitCodeSize || intrinsic.MaxTransactionGasLimit != params.MaxTxGas {
+		return errors.New("the initcode size limit or the gas limit cap differs from go-ethereum's")
+	}
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return err
+	}
+	var (
+		vectors []gasVector
+		txTests = make(map[string]*txFixture)
+	)
+	for _, c := range gasCases() {
+		vector, err := fillGas(&c, forkNames)
+		if err != nil {
+			return fmt.Errorf("case %s: %v", c.name, err)
+		}
+		vectors = append(vectors, vector)
+		for _, gasLimit := range c.limits(vector) {
+			f, err := fillTx(&c, gasLimit, forkNames)
+			if err != nil {
+				return fmt.Errorf("case %s, gas limit %d: %v", c.name, gasLimit, err)
+			}
+			txTests[fmt.Sprintf("intrinsic_vectors/%s_gas_%d", c.name, gasLimit)] = f
+		}
+	}
+	for name, value := range map[string]interface{}{
+		"intrinsic.json":         vectors,
+		"transaction_tests.json": txTests,
+	} {
+		data, err := json.MarshalIndent(value, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(output, name), append(data, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Wrote %d intrinsic gas and %d transaction vectors to %s on %d forks\n", len(vectors), len(txTests), output, len(forkNames))
+	return nil
+}
+
+// supports reports whether the transaction type of the case exists on the
+// fork.
+func (c *gasCase) supports(fork string) bool {
+	index, _ := forks.Index(fork)
+	since, _ := forks.Index(typeFork[c.txType])
+	return index >= since
+}
+
+// limits returns the gas limits of the transaction vectors of the case.
+func (c *gasCase) limits(vector gasVector) []uint64 {
+	if c.gasLimits != nil {
+		return c.gasLimits
+	}
+	seen := make(map[uint64]bool)
+	for _, gas := range vector.Gas {
+		for _, limit := range []uint64{gas.Intrinsic - 1, gas.Intrinsic, gas.Required - 1, gas.Required} {
+			seen[limit] = true
+		}
+	}
+	limits := make([]uint64, 0, len(seen))
+	for limit := range seen {
+		limits = append(limits, limit)
+	}
+	sort.Slice(limits, func(i, j int) bool { return limits[i] < limits[j] })
+	return limits
+}
+
+// fillGas computes the intrinsic gas of the case on the forks having its
+// transaction type and checks it against go-ethereum.
+func fillGas(c *gasCase, forkNames []string) (gasVector, error) {
+	vector := gasVector{
+		Name:                c.name,
+		Description:         c.description,
+		Type:                hexutil.Uint64(c.txType),
+		Data:                c.tx.Data,
+		Create:              c.tx.Create,
+		AccessListAddresses: c.tx.AccessListAddresses,
+		AccessListKeys:      c.tx.AccessListKeys,
+		Authorizations:      c.tx.Authorizations,
+		Gas:                 make(map[string]*intrinsic.Gas),
+	}
+	tx, err := c.transaction(0)
+	if err != nil {
+		return vector, err
+	}
+	if have := intrinsic.FromTransaction(tx); !bytes.Equal(have.Data, c.tx.Data) || have.Create != c.tx.Create ||
+		have.AccessListAddresses != c.tx.AccessListAddresses || have.AccessListKeys != c.tx.AccessListKeys || have.Authorizations != c.tx.Authorizations {
+		return vector, errors.New("transaction does not match the case")
+	}
+	for _, fork := range forkNames {
+		if !c.supports(fork) {
+			continue
+		}
+		gas, err := intrinsic.Compute(fork, &c.tx)
+		if err != nil {
+			return vector, fmt.Errorf("%s: %v", fork, err)
+		}
+		rules := rulesOf(fork)
+		want, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.SetCodeAuthorizations(), tx.To() == nil, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
+		if err != nil {
+			return vector, err
+		}
+		if gas.Intrinsic != want {
+			return vector, fmt.Errorf("%s: intrinsic gas %d, go-ethereum %d", fork, gas.Intrinsic, want)
+		}
+		var floor uint64
+		if rules.IsPrague {
+			if floor, err = core.FloorDataGas(tx.Data()); err != nil {
+				return vector, err
+			}
+		}
+		if gas.Floor != floor {
+			return vector, fmt.Errorf("%s: calldata floor %d, go-ethereum %d", fork, gas.Floor, floor)
+		}
+		vector.Gas[fork] = gas
+	}
+	return vector, nil
+}
+
+// rulesOf returns the rules of a fork of go-ethereum's tests.
+func rulesOf(fork string) params.Rules {
+	index, _ := forks.Index(fork)
+	paris, _ := forks.Index("Paris")
+	return tests.Forks[fork].Rules(new(big.Int), index >= paris, 0)
+}
+
+// transaction builds and signs the transaction of the case.
+func (c *gasCase) transaction(gasLimit uint64) (*types.Transaction, error) {
+	to := &recipient
+	if c.tx.Create {
+		to = nil
+	}
+	var accessList types.AccessList
+	for i := 0; i < c.tx.AccessListAddresses; i++ {
+		address := common.BigToAddress(big.NewInt(int64(i + 1)))
+		if c.duplicate {
+			address = recipient
+		}
+		accessList = append(accessList, types.AccessTuple{Address: address, StorageKeys: []common.Hash{}})
+	}
+	for i := 0; i < c.tx.AccessListKeys; i++ {
+		key := common.BigToHash(big.NewInt(int64(i)))
+		if c.duplicate {
+			key = common.Hash{}
+		}
+		accessList[0].StorageKeys = append(accessList[0].StorageKeys, key)
+	}
+	switch c.txType {
+	case types.LegacyTxType:
+		return types.SignNewTx(senderKey, types.HomesteadSigner{}, &types.LegacyTx{
+			GasPrice: big.NewInt(10), Gas: gasLimit, To: to, Value: new(big.Int), Data: c.tx.Data,
+		})
+	case types.AccessListTxType:
+		return types.SignNewTx(senderKey, types.NewEIP2930Signer(chainID), &types.AccessListTx{
+			ChainID: chainID, GasPrice: big.NewInt(10), Gas: gasLimit, To: to, Value: new(big.Int), Data: c.tx.Data, AccessList: accessList,
+		})
+	case types.SetCodeTxType:
+		auths := make([]types.SetCodeAuthorization, c.tx.Authorizations)
+		for i := range auths {
+			auth, err := types.SignSetCode(senderKey, types.SetCodeAuthorization{
+				ChainID: *uint256.MustFromBig(chainID), Address: delegate, Nonce: uint64(i + 1),
+			})
+			if err != nil {
+				return nil, err
+			}
+			auths[i] = auth
+		}
+		return types.SignNewTx(senderKey, types.NewPragueSigner(chainID), &types.SetCodeTx{
+			ChainID: uint256.MustFromBig(chainID), GasTipCap: uint256.NewInt(1), GasFeeCap: uint256.NewInt(10), Gas: gasLimit,
+			To: recipient, Value: new(uint256.Int), Data: c.tx.Data, AccessList: accessList, AuthList: auths,
+		})
+	}
+	return nil, fmt.Errorf("unsupported transaction type %d", c.txType)
+}
+
+// expected returns the outcome of the case with the gas limit on a fork.
+func (c *gasCase) expected(fork string, gasLimit uint64) (string, error) {
+	if !c.supports(fork) {
+		return typeException[c.txType], nil
+	}
+	err := intrinsic.Check(fork, &c.tx, gasLimit)
+	switch {
+	case err == nil:
+		return "", nil
+	case errors.Is(err, intrinsic.ErrIntrinsicGas):
+		return exceptionIntrinsicGas, nil
+	case errors.Is(err, intrinsic.ErrFloorGas):
+		return exceptionFloorGas, nil
+	case errors.Is(err, intrinsic.ErrInitcodeSize):
+		return exceptionInitcodeSize, nil
+	case errors.Is(err, intrinsic.ErrGasLimitCap):
+		return exceptionGasLimitCap, nil
+	}
+	return "", err
+}
+
+// fillTx builds the transaction test fixture of a case with a gas limit and
+// runs it through go-ethereum's transaction tests.
+func fillTx(c *gasCase, gasLimit uint64, forkNames []string) (*txFixture, error) {
+	tx, err := c.transaction(gasLimit)
+	if err != nil {
+		return nil, err
+	}
+	txBytes, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	from := crypto.PubkeyToAddress(senderKey.PublicKey)
+	hash := tx.Hash()
+	f := &txFixture{
+		Info:    statetest.Info("intrinsic-gas", "handcrafted", fmt.Sprintf("%s, gas limit %d", c.description, gasLimit)),
+		TxBytes: txBytes,
+		Result:  make(map[string]*txResult),
+	}
+	// go-ethereum's transaction tests check the intrinsic gas and the floor
+	// only, the initcode size and the gas limit cap are left out of the run.
+	checked := &txFixture{TxBytes: txBytes, Result: make(map[string]*txResult)}
+	for _, fork := range forkNames {
+		exception, err := c.expected(fork, gasLimit)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fork, err)
+		}
+		result := &txResult{Exception: exception}
+		if exception == "" {
+			gas, err := intrinsic.Compute(fork, &c.tx)
+			if err != nil {
+				return nil, err
+			}
+			result = &txResult{Hash: &hash, Sender: &from, IntrinsicGas: hexutil.Uint64(gas.Intrinsic)}
+		}
+		f.Result[fork] = result
+		if exception != exceptionInitcodeSize && exception != exceptionGasLimitCap {
+			checked.Result[fork] = result
+		}
+	}
+
+	data, err := json.Marshal(checked)
+	if err != nil {
+		return nil, err
+	}
+	var test tests.TransactionTest
+	if err := json.Unmarshal(data, &test); err != nil {
+		return nil, err
+	}
+	if err := test.Run(); err != nil {
+		return nil, fmt.Errorf("transaction test: %v", err)
+	}
+	return f, nil
+}
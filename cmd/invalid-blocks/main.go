@@ -0,0 +1,104 @@
+// invalid-blocks derives a corpus of invalid blocks from valid blockchain test
+// fixtures for negative testing.
+//
+// Usage:
+//
+//	go run ./cmd/invalid-blocks [--test name] [--block n] [--mutations state_root,gas_used] [--output invalid_blocks.json] fixtures.json
+//
+// A block of each fixture, by default its last valid one, is systematically
+// mutated: its state root, gas used, base fee, excess blob gas, blob gas
+// used, withdrawals root, receipts root, logs bloom and requests hash are
+// made inconsistent with the block, its extraData exceeds 32 bytes, its gas
+// limit changes too much, its number skips one and its timestamp repeats the
+// parent's. Fields the fork of the block does not have are skipped. Every
+// mutation becomes a fixture of its own, keeping the blocks before the mutated
+// one and tagged with the exception the specs reject it with, such as
+// BlockException.INVALID_STATE_ROOT.
+//
+// The fixtures must use the NoProof seal engine, as those of cmd/blocktest
+// and cmd/fill do. Every variant is imported into go-ethereum, which must
+// reject the mutated block for the reason of the mutation, before anything
+// is written.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+)
+
+func main() {
+	var (
+		test      = flag.String("test", "", "only mutate the named test of the fixtures file")
+		block     = flag.Int("block", -1, "index of the block to mutate in the blocks of the fixture (default the last valid block)")
+		mutations = flag.String("mutations", "", "comma separated mutations to apply (default all): "+strings.Join(blocktest.MutationNames, ", "))
+		output    = flag.String("output", "invalid_blocks.json", "file the fixtures are written to")
+	)
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fatalf("usage: invalid-blocks [flags] fixtures.json")
+	}
+	data, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	var fixtures map[string]*blocktest.Fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		fatalf("%s: %v", flag.Arg(0), err)
+	}
+	var filter []string
+	if *mutations != "" {
+		filter = strings.Split(*mutations, ",")
+	}
+	names := make([]string, 0, len(fixtures))
+	for name := range fixtures {
+		if *test == "" || name == *test {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		fatalf("%s: no test %q", flag.Arg(0), *test)
+	}
+	sort.Strings(names)
+
+	out := make(map[string]*blocktest.Fixture)
+	for _, name := range names {
+		f := fixtures[name]
+		index := *block
+		if index < 0 {
+			if index = f.LastValid(); index < 0 && *test == "" {
+				fmt.Fprintf(os.Stderr, "Skipping %s without valid blocks\n", name)
+				continue
+			} else if index < 0 {
+				fatalf("%s: no valid block to mutate", name)
+			}
+		}
+		variants, err := blocktest.Invalid(f, index, filter)
+		if err != nil {
+			fatalf("%s: %v", name, err)
+		}
+		for _, v := range variants {
+			v.Fixture.Info = statetest.Info("invalid-blocks", fmt.Sprintf("%s, test %s, block %d", flag.Arg(0), name, index), v.Mutation.Description)
+			out["invalid_blocks/"+name+"/"+v.Mutation.Name] = v.Fixture
+		}
+	}
+	data, err = json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d invalid block fixtures from %d tests to %s\n", len(out), len(names), *output)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,101 @@
+// kzg inspects the KZG trusted setup of EIP-4844 the blob tooling is built
+// with.
+//
+// Usage:
+//
+//	go run ./cmd/kzg verify-setup [setup.json]
+//
+// The verify-setup subcommand checks a setup file in the JSON format of the
+// consensus specs, by default the ceremony setup embedded in the tooling: the
+// number of points, that every point lies in the prime order subgroup, that
+// the monomial points are successive powers of one secret in G1 and G2
+// starting at the generators, that the Lagrange points are the Lagrange basis
+// of the monomial ones, and that the digest of the points is the one of the
+// ceremony. It also confirms that go-ethereum's kzg4844 package, which
+// computes the commitments and proofs of the blob tooling, commits with the
+// ceremony setup. Any failed check exits with a nonzero code.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/kzg"
+)
+
+// commands are the subcommands of the tool.
+var commands = map[string]func(args []string) error{
+	"verify-setup": verifySetupCommand,
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fatalf("expected a subcommand: %s", strings.Join(names, ", "))
+	}
+	if err := commands[os.Args[1]](os.Args[2:]); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// verifySetupCommand verifies a setup file, or the embedded setup, and the
+// setup go-ethereum commits with.
+func verifySetupCommand(args []string) error {
+	fs := flag.NewFlagSet("verify-setup", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: verify-setup [setup.json]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("expected at most one setup file")
+	}
+	var (
+		name  = "embedded setup"
+		setup *kzg.Setup
+		err   error
+	)
+	if fs.NArg() == 1 {
+		name = fs.Arg(0)
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if setup, err = kzg.ParseSetup(data); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	} else if setup, err = kzg.Embedded(); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	fmt.Printf("%s: %d G1 monomial, %d G1 Lagrange and %d G2 monomial points in the subgroups\n",
+		name, len(setup.G1Monomial), len(setup.G1Lagrange), len(setup.G2Monomial))
+	if err := setup.Verify(); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	fmt.Println("Powers of the secret and Lagrange basis: consistent")
+	digest := setup.Digest()
+	fmt.Printf("Digest: %s\n", digest)
+	if digest != kzg.CeremonyDigest {
+		return fmt.Errorf("%s is not the ceremony setup, whose digest is %s", name, kzg.CeremonyDigest)
+	}
+	fmt.Println("Ceremony setup: yes")
+	if err := kzg.CheckLibrary(); err != nil {
+		return err
+	}
+	fmt.Println("go-ethereum kzg4844 commits with the ceremony setup: yes")
+	return nil
+}
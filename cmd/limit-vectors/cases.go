@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/intrinsic"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// The exceptions of the vectors.
+const (
+	exceptionNonceMax     = "TransactionException.NONCE_IS_MAX"
+	exceptionIntrinsicGas = "TransactionException.INTRINSIC_GAS_TOO_LOW"
+	exceptionFloorGas     = "TransactionException.INTRINSIC_GAS_BELOW_FLOOR_GAS_COST"
+	exceptionInitcodeSize = "TransactionException.INITCODE_SIZE_EXCEEDED"
+)
+
+// factoryGas is the gas limit of the transactions calling a factory, enough
+// for the creations from the largest initcode.
+const factoryGas = 200000
+
+var (
+	// factory is the account executing CREATE and CREATE2.
+	factory = common.HexToAddress("0x0000000000000000000000000000000000003860")
+
+	// sink is an empty account receiving the calls which execute no code.
+	sink = common.HexToAddress("0x000000000000000000000000000000000000dead")
+)
+
+// wantAccount is the expected post-state of an account.
+type wantAccount struct {
+	absent bool
+	nonce  uint64
+	slot   common.Hash // storage slot 0
+}
+
+// account returns the expected post-state in the form checked by statetest.
+func (w wantAccount) account() statetest.Account {
+	return statetest.Account{
+		Absent:  w.absent,
+		Nonce:   &w.nonce,
+		Storage: map[common.Hash]common.Hash{{}: w.slot},
+	}
+}
+
+// outcome is the expected outcome of a transaction on a fork: the exception
+// of an invalid transaction, or the gas used and the accounts.
+type outcome struct {
+	exception string
+	gasUsed   uint64
+	accounts  map[common.Address]wantAccount
+}
+
+// testCase is a transaction together with its expected outcome per fork.
+type testCase struct {
+	name        string
+	description string
+	nonce       uint64             // nonce of the sender and the transaction
+	pre         types.GenesisAlloc // accounts besides the sender
+	to          *common.Address    // nil for a creation
+	data        []byte
+	gas         uint64
+	expect      func(fork string) (*outcome, error)
+}
+
+// words returns the number of 32 byte words of n bytes.
+func words(n uint64) uint64 {
+	return (n + 31) / 32
+}
+
+// memoryGas is the cost of expanding the memory from zero to n bytes.
+func memoryGas(n uint64) uint64 {
+	w := words(n)
+	return w*params.MemoryGas + w*w/params.QuadCoeffDiv
+}
+
+// sstoreGas is the cost of storing a value into the cold empty slot 0.
+func sstoreGas(value common.Hash) uint64 {
+	if value == (common.Hash{}) {
+		return params.ColdSloadCostEIP2929 + params.WarmStorageReadCostEIP2929
+	}
+	return params.ColdSloadCostEIP2929 + params.SstoreSetGasEIP2200
+}
+
+// callCase is a call to an account without code from a sender at the nonce.
+func callCase(name, description string, nonce uint64) testCase {
+	return testCase{
+		name: name, description: description, nonce: nonce, to: &sink, gas: params.TxGas,
+		expect: func(fork string) (*outcome, error) {
+			if nonce == math.MaxUint64 {
+				return &outcome{exception: exceptionNonceMax, accounts: map[common.Address]wantAccount{sender: {nonce: nonce}}}, nil
+			}
+			return &outcome{gasUsed: params.TxGas, accounts: map[common.Address]wantAccount{
+				sender: {nonce: nonce + 1},
+				sink:   {absent: true},
+			}}, nil
+		},
+	}
+}
+
+// createTxCase is a creation transaction from a sender at the nonce with
+// initcode of size zero bytes, which stops right away and deploys no code.
+// The intrinsic gas, the calldata floor and the initcode size limit are
+// checked by pkg/intrinsic.
+func createTxCase(name, description string, nonce uint64, size int, gas uint64) testCase {
+	data := make([]byte, size)
+	created := crypto.CreateAddress(sender, nonce)
+	return testCase{
+		name: name, description: description, nonce: nonce, data: data, gas: gas,
+		expect: func(fork string) (*outcome, error) {
+			failed := map[common.Address]wantAccount{sender: {nonce: nonce}, created: {absent: true}}
+			if nonce == math.MaxUint64 {
+				return &outcome{exception: exceptionNonceMax, accounts: failed}, nil
+			}
+			tx := &intrinsic.Tx{Data: data, Create: true}
+			err := intrinsic.Check(fork, tx, gas)
+			switch {
+			case errors.Is(err, intrinsic.ErrIntrinsicGas):
+				return &outcome{exception: exceptionIntrinsicGas, accounts: failed}, nil
+			case errors.Is(err, intrinsic.ErrFloorGas):
+				return &outcome{exception: exceptionFloorGas, accounts: failed}, nil
+			case errors.Is(err, intrinsic.ErrInitcodeSize):
+				return &outcome{exception: exceptionInitcodeSize, accounts: failed}, nil
+			case err != nil:
+				return nil, err
+			}
+			cost, err := intrinsic.Compute(fork, tx)
+			if err != nil {
+				return nil, err
+			}
+			return &outcome{gasUsed: cost.Required, accounts: map[common.Address]wantAccount{
+				sender:  {nonce: nonce + 1},
+				created: {nonce: 1},
+			}}, nil
+		},
+	}
+}
+
+// factoryCase is a call to a factory at the nonce executing CREATE, or
+// CREATE2 with a zero salt, with size zero bytes of memory as initcode and
+// storing the address returned into slot 0.
+func factoryCase(name, description string, op vm.OpCode, nonce uint64, size uint64) testCase {
+	pushes := uint64(4)
+	code := []byte{byte(vm.PUSH3), byte(size >> 16), byte(size >> 8), byte(size), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0}
+	created := crypto.CreateAddress(factory, nonce)
+	if op == vm.CREATE2 {
+		code = append([]byte{byte(vm.PUSH1), 0}, code...)
+		pushes++
+		created = crypto.CreateAddress2(factory, common.Hash{}, crypto.Keccak256(make([]byte, size)))
+	} else if op != vm.CREATE {
+		panic(fmt.Sprintf("factory case with %v", op))
+	}
+	code = append(code, byte(op), byte(vm.PUSH1), 0, byte(vm.SSTORE), byte(vm.STOP))
+
+	return testCase{
+		name: name, description: description, to: &factory, gas: factoryGas,
+		pre: types.GenesisAlloc{factory: {Balance: new(big.Int), Nonce: nonce, Code: code}},
+		expect: func(fork string) (*outcome, error) {
+			// Beyond the limit the creation halts the factory, consuming
+			// all gas.
+			if forks.Since(fork, "Shanghai") && size > params.MaxInitCodeSize {
+				return &outcome{gasUsed: factoryGas, accounts: map[common.Address]wantAccount{
+					sender:  {nonce: 1},
+					factory: {nonce: nonce},
+					created: {absent: true},
+				}}, nil
+			}
+			// The pushes, the creation with its memory expansion, the
+			// initcode words of EIP-3860, the hashing of the initcode of
+			// CREATE2, and the store of the address. A factory whose nonce
+			// cannot be incremented returns the gas of the creation, leaving
+			// the nonce and pushing zero.
+			gas := params.TxGas + pushes*vm.GasFastestStep + params.CreateGas + memoryGas(size)
+			if forks.Since(fork, "Shanghai") {
+				gas += words(size) * params.InitCodeWordGas
+			}
+			if op == vm.CREATE2 {
+				gas += words(size) * params.Keccak256WordGas
+			}
+			accounts := map[common.Address]wantAccount{sender: {nonce: 1}}
+			if nonce == math.MaxUint64 {
+				accounts[factory] = wantAccount{nonce: nonce}
+				accounts[created] = wantAccount{absent: true}
+				gas += sstoreGas(common.Hash{})
+			} else {
+				slot := common.BytesToHash(created.Bytes())
+				accounts[factory] = wantAccount{nonce: nonce + 1, slot: slot}
+				accounts[created] = wantAccount{nonce: 1}
+				gas += sstoreGas(slot)
+			}
+			return &outcome{gasUsed: gas, accounts: accounts}, nil
+		},
+	}
+}
+
+// wordGas returns the Shanghai intrinsic gas of a creation transaction with
+// initcode of size zero bytes, including its initcode words.
+func wordGas(size int) uint64 {
+	cost, err := intrinsic.Compute("Shanghai", &intrinsic.Tx{Data: make([]byte, size), Create: true})
+	if err != nil {
+		panic(err)
+	}
+	return cost.Intrinsic
+}
+
+func cases() []testCase {
+	const (
+		maxNonce = math.MaxUint64
+		limit    = params.MaxInitCodeSize
+	)
+	return []testCase{
+		// EIP-2681 nonce cap of the sender.
+		callCase("call_sender_nonce_max_minus_one", "A call from a sender at nonce 2^64-2, raising it to the maximum.", maxNonce-1),
+		callCase("call_sender_nonce_max", "A call from a sender at nonce 2^64-1, which cannot be incremented.", maxNonce),
+		createTxCase("create_tx_sender_nonce_max_minus_one", "A creation transaction from a sender at nonce 2^64-2, the last address it can create.", maxNonce-1, 32, 100000),
+		createTxCase("create_tx_sender_nonce_max", "A creation transaction from a sender at nonce 2^64-1.", maxNonce, 32, 100000),
+
+		// EIP-2681 nonce cap of a creating contract.
+		factoryCase("create_factory_nonce_max_minus_one", "CREATE from a factory at nonce 2^64-2, raising it to the maximum.", vm.CREATE, maxNonce-1, 32),
+		factoryCase("create_factory_nonce_max", "CREATE from a factory at nonce 2^64-1, which fails returning the gas of the creation.", vm.CREATE, maxNonce, 32),
+		factoryCase("create2_factory_nonce_max_minus_one", "CREATE2 from a factory at nonce 2^64-2, raising it to the maximum.", vm.CREATE2, maxNonce-1, 32),
+		factoryCase("create2_factory_nonce_max", "CREATE2 from a factory at nonce 2^64-1, which fails returning the gas of the creation.", vm.CREATE2, maxNonce, 32),
+
+		// EIP-3860 initcode size limit.
+		createTxCase("create_tx_initcode_max", "A creation transaction with initcode of exactly 49152 bytes.", 0, limit, 1000000),
+		createTxCase("create_tx_initcode_max_plus_one", "A creation transaction with initcode of 49153 bytes, invalid from Shanghai.", 0, limit+1, 1000000),
+		factoryCase("create_initcode_max", "CREATE with initcode of exactly 49152 bytes.", vm.CREATE, 1, limit),
+		factoryCase("create_initcode_max_plus_one", "CREATE with initcode of 49153 bytes, halting exceptionally from Shanghai.", vm.CREATE, 1, limit+1),
+		factoryCase("create2_initcode_max", "CREATE2 with initcode of exactly 49152 bytes.", vm.CREATE2, 1, limit),
+		factoryCase("create2_initcode_max_plus_one", "CREATE2 with initcode of 49153 bytes, halting exceptionally from Shanghai.", vm.CREATE2, 1, limit+1),
+
+		// EIP-3860 initcode word gas.
+		createTxCase("create_tx_initcode_32_bytes_exact_gas", "A creation transaction with one word of initcode and the exact gas of Shanghai.", 0, 32, wordGas(32)),
+		createTxCase("create_tx_initcode_32_bytes_gas_minus_one", "A creation transaction with one word of initcode and one gas less than Shanghai requires.", 0, 32, wordGas(32)-1),
+		createTxCase("create_tx_initcode_33_bytes_exact_gas", "A creation transaction with initcode one byte into the second word and the exact gas of Shanghai.", 0, 33, wordGas(33)),
+		createTxCase("create_tx_initcode_33_bytes_gas_minus_one", "A creation transaction with initcode one byte into the second word and one gas less than Shanghai requires.", 0, 33, wordGas(33)-1),
+		factoryCase("create_initcode_32_bytes", "CREATE with one word of initcode.", vm.CREATE, 1, 32),
+		factoryCase("create_initcode_33_bytes", "CREATE with initcode one byte into the second word.", vm.CREATE, 1, 33),
+		factoryCase("create2_initcode_33_bytes", "CREATE2 with initcode one byte into the second word, hashed and charged per word.", vm.CREATE2, 1, 33),
+	}
+}
@@ -0,0 +1,166 @@
+// limit-vectors generates vectors of the account nonce cap of EIP-2681 and
+// the initcode size limit and word gas of EIP-3860 in the format of the
+// state_tests fixtures of the execution spec tests, using go-ethereum as the
+// reference implementation.
+//
+// Usage:
+//
+//	go run ./cmd/limit-vectors [--forks London,Shanghai,Cancun,Prague,Osaka] [--output limit_vectors.json]
+//
+// The nonce vectors send transactions from senders at nonce 2^64-2, which
+// are the last valid ones, and 2^64-1, which are invalid, both calls and
+// creations, and execute CREATE and CREATE2 from factories at these nonces,
+// which must fail without consuming the gas passed to the creation once the
+// nonce cannot be incremented.
+//
+// The initcode vectors create contracts from initcode of exactly 49152 bytes
+// and one byte more, with creation transactions, which are invalid beyond the
+// limit, and with CREATE and CREATE2, which halt exceptionally beyond it.
+// Further vectors give creation transactions the exact gas their initcode
+// words cost, and one gas less, at the boundary of a word.
+//
+// Every vector is filled for all forks, so that the activation of the
+// initcode limit at Shanghai and the EIP-7623 calldata floor from Prague show
+// up in the post-states of the forks. The expected outcome on each fork, the
+// exception or the gas used and the nonces, storage and existence of the
+// accounts, is computed from the EIPs, the intrinsic gas by pkg/intrinsic,
+// and checked against go-ethereum before anything is written.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// The parameters of the test environment and transactions.
+const (
+	baseFee = 7
+	feeCap  = 10
+	tipCap  = 1
+)
+
+var (
+	chainID  = big.NewInt(1)
+	coinbase = common.HexToAddress("0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba")
+
+	senderKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	sender       = crypto.PubkeyToAddress(senderKey.PublicKey)
+)
+
+func main() {
+	var (
+		forkList = flag.String("forks", "London,Shanghai,Cancun,Prague,Osaka", "comma separated forks the vectors are filled for")
+		output   = flag.String("output", "limit_vectors.json", "file the fixtures are written to")
+	)
+	flag.Parse()
+	forkNames := strings.Split(*forkList, ",")
+	for _, fork := range forkNames {
+		config, _, err := tests.GetChainConfig(fork)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if _, err := forks.Index(fork); err != nil {
+			fatalf("%v", err)
+		}
+		if !config.IsLondon(new(big.Int)) {
+			fatalf("fork %s predates the dynamic fee transactions of the vectors", fork)
+		}
+	}
+	fixtures := make(map[string]*statetest.Fixture)
+	for _, c := range cases() {
+		f, err := fill(&c, forkNames)
+		if err != nil {
+			fatalf("case %s: %v", c.name, err)
+		}
+		fixtures["limit_vectors/"+c.name] = f
+	}
+	data, err := json.MarshalIndent(fixtures, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d fixtures to %s on %d forks\n", len(fixtures), *output, len(forkNames))
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// fill builds the fixture of a test case, executes it on each fork and
+// verifies the expectations.
+func fill(c *testCase, forkNames []string) (*statetest.Fixture, error) {
+	pre := types.GenesisAlloc{
+		sender: {Balance: big.NewInt(1e18), Nonce: c.nonce},
+	}
+	for addr, account := range c.pre {
+		pre[addr] = account
+	}
+	tx, err := types.SignNewTx(senderKey, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     c.nonce,
+		GasTipCap: big.NewInt(tipCap),
+		GasFeeCap: big.NewInt(feeCap),
+		Gas:       c.gas,
+		To:        c.to,
+		Data:      c.data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	env := statetest.DefaultEnv(coinbase, common.Hash{0x26, 0x81}, baseFee)
+	f, err := statetest.New(env, pre, tx, senderKey, forkNames)
+	if err != nil {
+		return nil, err
+	}
+	f.Info = statetest.Info("limit-vectors", "handcrafted", c.description)
+	outcomes := make(map[string]*outcome, len(forkNames))
+	for _, fork := range forkNames {
+		want, err := c.expect(fork)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fork, err)
+		}
+		outcomes[fork] = want
+		f.Post[fork][0].ExpectException = want.exception
+	}
+	err = f.Fill(func(fork string, r *statetest.Result) error {
+		return c.check(outcomes[fork], r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// check verifies the outcome of the transaction on a fork against the
+// expected one and records the touched accounts.
+func (c *testCase) check(want *outcome, r *statetest.Result) error {
+	if want.exception == "" && r.GasUsed != want.gasUsed {
+		return fmt.Errorf("gas used %d, expected %d", r.GasUsed, want.gasUsed)
+	}
+	touched := []common.Address{sender, coinbase}
+	for addr := range c.pre {
+		touched = append(touched, addr)
+	}
+	for addr, account := range want.accounts {
+		if err := statetest.CheckAccount(r.State, addr, account.account()); err != nil {
+			return err
+		}
+		touched = append(touched, addr)
+	}
+	r.Post.State = statetest.DumpState(r.State, touched, common.Hash{})
+	return nil
+}
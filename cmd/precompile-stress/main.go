@@ -0,0 +1,179 @@
+// precompile-stress generates stress vectors for the precompiles with a
+// history of consensus bugs: MODEXP and the BN254 and BLS12-381 pairing
+// checks.
+//
+// Usage:
+//
+//	go run ./cmd/precompile-stress [--forks Byzantium,Istanbul,Berlin,Prague,Osaka] [--output stress]
+//
+// The MODEXP inputs cover extreme base, exponent and modulus lengths, up to
+// lengths beyond 64 bits, the boundaries of the multiplication complexity and
+// the minimum gas of the EIP-198, EIP-2565 and EIP-7883 gas formulas, the
+// adjusted exponent length of long exponents, exponent heads beyond the end
+// of the input and the EIP-7823 length limit. The pairing inputs cover points
+// at infinity paired with points outside the subgroup, which must be rejected
+// although they do not contribute to the product, G2 points on the twist but
+// outside the subgroup, coordinates equal to the field modulus, swapped G2
+// coordinate halves, nonzero padding and many pairs.
+//
+// Unlike precompile-vectors, the gas and the output of every input are not
+// taken from go-ethereum but computed from the formulas of the EIPs and the
+// construction of the input, and go-ethereum must agree before anything is
+// written. Gas too large for any block is only checked to be unpayable, as
+// implementations saturate their intermediate products at different points.
+//
+// The vectors are written to <output>/<fork>/<precompile>.json and, for the
+// rejected inputs, <output>/<fork>/fail-<precompile>.json, in the format of
+// go-ethereum's core/vm/testdata/precompiles.
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/precompiletest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// unpayable is the gas from which a call cannot be paid by any block, beyond
+// which the gas is not compared exactly.
+var unpayable = new(big.Int).Lsh(common.Big1, 40)
+
+// testCase is a stress input together with its expected gas and output on a
+// fork.
+type testCase struct {
+	name  string
+	fork  string // first fork of the case, all forks with the precompile if empty
+	input []byte
+
+	// expect returns the gas and the output of the input, or a nil output if
+	// the precompile rejects it.
+	expect func(rules params.Rules) (gas *big.Int, output []byte)
+}
+
+// precompile is a stressed precompile, identified by its address.
+type precompile struct {
+	file    string
+	address common.Address
+	cases   func() []testCase
+}
+
+var precompiles = []precompile{
+	{"modexp", common.BytesToAddress([]byte{0x05}), modexpCases},
+	{"bn256Pairing", common.BytesToAddress([]byte{0x08}), bnPairingCases},
+	{"blsPairing", common.BytesToAddress([]byte{0x0f}), blsPairingCases},
+}
+
+func main() {
+	var (
+		forks  = flag.String("forks", "Byzantium,Istanbul,Berlin,Prague,Osaka", "comma separated list of forks to generate vectors for")
+		output = flag.String("output", "stress", "directory the vectors are written to")
+	)
+	flag.Parse()
+	for _, fork := range strings.Split(*forks, ",") {
+		if err := generateFork(strings.TrimSpace(fork), *output); err != nil {
+			fatalf("%v", err)
+		}
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// generateFork runs the inputs of the stressed precompiles active in the fork,
+// checks go-ethereum against the expectations and writes the vectors.
+func generateFork(fork, output string) error {
+	config, ok := tests.Forks[fork]
+	if !ok {
+		return fmt.Errorf("unknown fork %q", fork)
+	}
+	rules := config.Rules(new(big.Int), config.TerminalTotalDifficulty != nil, 0)
+	contracts := vm.ActivePrecompiledContracts(rules)
+
+	dir := filepath.Join(output, fork)
+	var total, failing int
+	for _, p := range precompiles {
+		contract, ok := contracts[p.address]
+		if !ok {
+			continue
+		}
+		var (
+			vectors     = []precompiletest.Vector{}
+			failVectors = []precompiletest.FailVector{}
+		)
+		for _, c := range p.cases() {
+			if c.fork != "" && !forkActive(rules, c.fork) {
+				continue
+			}
+			wantGas, want := c.expect(rules)
+			gas := contract.RequiredGas(c.input)
+			if err := checkGas(gas, wantGas); err != nil {
+				return fmt.Errorf("%s: %s %s: %v", fork, p.file, c.name, err)
+			}
+			out, err := contract.Run(c.input)
+			switch {
+			case err != nil && want != nil:
+				return fmt.Errorf("%s: %s %s: rejected with %q, expected %x", fork, p.file, c.name, err, want)
+			case err != nil:
+				failVectors = append(failVectors, precompiletest.FailVector{Input: hex.EncodeToString(c.input), ExpectedError: err.Error(), Name: c.name})
+			case want == nil:
+				return fmt.Errorf("%s: %s %s: returned %x, expected a rejection", fork, p.file, c.name, out)
+			case !bytes.Equal(out, want):
+				return fmt.Errorf("%s: %s %s: returned %x, expected %x", fork, p.file, c.name, out, want)
+			default:
+				vectors = append(vectors, precompiletest.Vector{Input: hex.EncodeToString(c.input), Expected: hex.EncodeToString(out), Gas: gas, Name: c.name, NoBenchmark: wantGas.Cmp(unpayable) >= 0})
+			}
+		}
+		if err := precompiletest.Write(dir, p.file, vectors, failVectors); err != nil {
+			return err
+		}
+		total += len(vectors) + len(failVectors)
+		failing += len(failVectors)
+	}
+	fmt.Printf("%s: %d vectors (%d failing)\n", fork, total, failing)
+	return nil
+}
+
+// forkActive reports whether the rules include the named fork.
+func forkActive(rules params.Rules, fork string) bool {
+	switch fork {
+	case "Osaka":
+		return rules.IsOsaka
+	}
+	panic("unsupported fork " + fork)
+}
+
+// checkGas compares the gas of go-ethereum with the expected gas, which only
+// needs to be matched exactly if a block can pay it.
+func checkGas(have uint64, want *big.Int) error {
+	if want.Cmp(unpayable) >= 0 {
+		if new(big.Int).SetUint64(have).Cmp(unpayable) < 0 {
+			return fmt.Errorf("gas %d, expected an unpayable %v", have, want)
+		}
+		return nil
+	}
+	if !want.IsUint64() || have != want.Uint64() {
+		return fmt.Errorf("gas %d, expected %v", have, want)
+	}
+	return nil
+}
+
+// pattern returns n nonzero bytes counting down from 0xff.
+func pattern(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = 0xff - byte(i%0xff)
+	}
+	return b
+}
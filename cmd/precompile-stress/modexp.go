@@ -0,0 +1,225 @@
+package main
+
+import (
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/precompiletest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// modexpInputLimit is the largest base, exponent and modulus length EIP-7823
+// accepts.
+const modexpInputLimit = 1024
+
+var big8 = big.NewInt(8)
+
+// modexpInput encodes a MODEXP input with the given length header, which may
+// disagree with the length of the operands following it.
+func modexpInput(baseLen, expLen, modLen *big.Int, operands ...[]byte) []byte {
+	return precompiletest.Concat(precompiletest.WordBig(baseLen), precompiletest.WordBig(expLen), precompiletest.WordBig(modLen), precompiletest.Concat(operands...))
+}
+
+// modexpLens encodes a MODEXP input whose header holds the lengths of the
+// operands.
+func modexpLens(base, exp, mod []byte) []byte {
+	return modexpInput(big.NewInt(int64(len(base))), big.NewInt(int64(len(exp))), big.NewInt(int64(len(mod))), base, exp, mod)
+}
+
+// modexpCase returns a MODEXP case whose expectation is computed by
+// modexpExpect.
+func modexpCase(name, fork string, input []byte) testCase {
+	return testCase{name: name, fork: fork, input: input, expect: func(rules params.Rules) (*big.Int, []byte) {
+		return modexpExpect(rules, input)
+	}}
+}
+
+// modexpExpect computes the gas and the output of a MODEXP input following
+// EIP-198, as repriced by EIP-2565 from Berlin and by EIP-7883 from Osaka,
+// where EIP-7823 also rejects lengths above 1024 bytes. Operands extending
+// beyond the input are padded with zeros on the right.
+func modexpExpect(rules params.Rules, input []byte) (*big.Int, []byte) {
+	var (
+		baseLen = new(big.Int).SetBytes(readPadded(input, 0, 32))
+		expLen  = new(big.Int).SetBytes(readPadded(input, 32, 32))
+		modLen  = new(big.Int).SetBytes(readPadded(input, 64, 32))
+		x       = baseLen
+	)
+	if modLen.Cmp(x) > 0 {
+		x = modLen
+	}
+	// The iteration count is derived from the adjusted exponent length, the
+	// bit length of the first 32 bytes of the exponent, plus 8 bits, or 16 from
+	// Osaka, per byte beyond them.
+	var head *big.Int
+	if baseLen.IsUint64() && uint64(len(input)) > 96+baseLen.Uint64() {
+		n := uint64(32)
+		if expLen.Cmp(big.NewInt(32)) < 0 {
+			n = expLen.Uint64()
+		}
+		head = new(big.Int).SetBytes(readPadded(input, 96+baseLen.Uint64(), n))
+	} else {
+		head = new(big.Int)
+	}
+	iterations := new(big.Int)
+	if expLen.Cmp(big.NewInt(32)) > 0 {
+		multiplier := big8
+		if rules.IsOsaka {
+			multiplier = big.NewInt(16)
+		}
+		iterations.Mul(multiplier, new(big.Int).Sub(expLen, big.NewInt(32)))
+	}
+	if head.Sign() > 0 {
+		iterations.Add(iterations, big.NewInt(int64(head.BitLen()-1)))
+	}
+	if iterations.Sign() == 0 {
+		iterations.SetUint64(1)
+	}
+
+	// The multiplication complexity of the larger of the base and modulus.
+	var (
+		gas   = new(big.Int)
+		words = new(big.Int).Div(new(big.Int).Add(x, big.NewInt(7)), big8)
+	)
+	switch {
+	case rules.IsOsaka:
+		complexity := big.NewInt(16)
+		if x.Cmp(big.NewInt(32)) > 0 {
+			complexity.Mul(words, words).Lsh(complexity, 1)
+		}
+		gas.Mul(complexity, iterations)
+		gas = bigMax(gas, big.NewInt(500))
+	case rules.IsBerlin:
+		complexity := new(big.Int).Mul(words, words)
+		gas.Mul(complexity, iterations).Div(gas, big.NewInt(3))
+		gas = bigMax(gas, big.NewInt(200))
+	default:
+		sq := new(big.Int).Mul(x, x)
+		complexity := new(big.Int)
+		switch {
+		case x.Cmp(big.NewInt(64)) <= 0:
+			complexity.Set(sq)
+		case x.Cmp(big.NewInt(1024)) <= 0:
+			complexity.Div(sq, big.NewInt(4)).Add(complexity, new(big.Int).Mul(x, big.NewInt(96))).Sub(complexity, big.NewInt(3072))
+		default:
+			complexity.Div(sq, big.NewInt(16)).Add(complexity, new(big.Int).Mul(x, big.NewInt(480))).Sub(complexity, big.NewInt(199680))
+		}
+		gas.Mul(complexity, iterations).Div(gas, big.NewInt(20))
+	}
+
+	// The output, the modulus length bytes of the power, or zeros for a zero
+	// modulus.
+	if rules.IsOsaka {
+		limit := big.NewInt(modexpInputLimit)
+		if baseLen.Cmp(limit) > 0 || expLen.Cmp(limit) > 0 || modLen.Cmp(limit) > 0 {
+			return gas, nil
+		}
+	}
+	if baseLen.Sign() == 0 && modLen.Sign() == 0 {
+		return gas, []byte{}
+	}
+	var (
+		b, e, m = baseLen.Uint64(), expLen.Uint64(), modLen.Uint64()
+		base    = new(big.Int).SetBytes(readPadded(input, 96, b))
+		exp     = new(big.Int).SetBytes(readPadded(input, 96+b, e))
+		mod     = new(big.Int).SetBytes(readPadded(input, 96+b+e, m))
+	)
+	if mod.Sign() == 0 {
+		return gas, make([]byte, m)
+	}
+	return gas, common.LeftPadBytes(new(big.Int).Exp(base, exp, mod).Bytes(), int(m))
+}
+
+// readPadded reads n bytes of the input from the offset, padding with zeros
+// what lies beyond its end.
+func readPadded(input []byte, offset, n uint64) []byte {
+	out := make([]byte, n)
+	if offset < uint64(len(input)) {
+		copy(out, input[offset:])
+	}
+	return out
+}
+
+func bigMax(a, b *big.Int) *big.Int {
+	if a.Cmp(b) < 0 {
+		return b
+	}
+	return a
+}
+
+func modexpCases() []testCase {
+	var (
+		three  = []byte{0x03}
+		odd32  = precompiletest.Concat(pattern(31), []byte{0x0b})
+		maxU64 = new(big.Int).SetUint64(^uint64(0))
+		zero   = new(big.Int)
+	)
+	// An exponent of 40 bytes whose first 32 bytes have a bit length of 4,
+	// for an adjusted exponent length of 8*8+3, or 16*8+3 from Osaka.
+	head4 := precompiletest.Concat(make([]byte, 31), []byte{0x08}, pattern(8))
+	// A modulus of n bytes, odd unless even is set.
+	modulus := func(n int, even bool) []byte {
+		m := pattern(n)
+		m[n-1] |= 1
+		if even {
+			m[n-1] &^= 1
+		}
+		return m
+	}
+	return []testCase{
+		// Lengths the gas formulas treat specially.
+		modexpCase("zero_lengths", "", modexpLens(nil, nil, nil)),
+		modexpCase("exp_length_max_uint64_empty_base_mod", "", modexpInput(zero, maxU64, zero)),
+		modexpCase("exp_length_2_64_empty_base_mod", "", modexpInput(zero, new(big.Int).Lsh(common.Big1, 64), zero)),
+		modexpCase("exp_length_max_word_empty_base_mod", "", modexpInput(zero, precompiletest.MaxWord, zero)),
+		modexpCase("base_length_2_32", "Osaka", modexpInput(new(big.Int).Lsh(common.Big1, 32), zero, zero)),
+		modexpCase("mod_length_max_word", "Osaka", modexpInput(common.Big1, common.Big1, precompiletest.MaxWord, []byte{0x02, 0x03})),
+
+		// The steps of the multiplication complexity.
+		modexpCase("length_32", "", modexpLens(pattern(32), three, modulus(32, false))),
+		modexpCase("length_33", "", modexpLens(pattern(33), three, modulus(33, false))),
+		modexpCase("length_64", "", modexpLens(pattern(64), three, modulus(64, false))),
+		modexpCase("length_65", "", modexpLens(pattern(65), three, modulus(65, false))),
+		modexpCase("length_1024", "", modexpLens(pattern(1024), three, modulus(1024, false))),
+		modexpCase("length_1025", "", modexpLens(pattern(1025), three, modulus(1025, false))),
+		modexpCase("base_longer_than_mod", "", modexpLens(pattern(100), three, modulus(8, false))),
+		modexpCase("mod_longer_than_base", "", modexpLens(pattern(8), three, modulus(100, false))),
+
+		// The minimum gas of EIP-2565 and EIP-7883.
+		modexpCase("berlin_min_gas_exact", "", modexpLens(pattern(40), []byte{0x01, 0x00, 0x00, 0x00}, modulus(40, false))),
+		modexpCase("berlin_min_gas_plus_one", "", modexpLens(pattern(24), head4, modulus(24, false))),
+		modexpCase("osaka_min_gas_below", "", modexpLens(pattern(32), []byte{0x80, 0x00, 0x00, 0x00}, odd32)),
+		modexpCase("osaka_min_gas_above", "", modexpLens(pattern(32), []byte{0x01, 0x00, 0x00, 0x00, 0x00}, odd32)),
+
+		// The adjusted exponent length.
+		modexpCase("exp_zero_long", "", modexpLens(pattern(32), make([]byte, 64), odd32)),
+		modexpCase("exp_zero_head_nonzero_tail", "", modexpLens(pattern(32), precompiletest.Concat(make([]byte, 32), []byte{0x05}), odd32)),
+		modexpCase("exp_long_all_ones", "", modexpLens(pattern(32), bytesOf(0xff, 64), odd32)),
+		modexpCase("exp_head_beyond_input", "", modexpInput(common.Big1, big.NewInt(32), common.Big1, []byte{0x02})),
+		modexpCase("exp_partial_input", "", modexpInput(common.Big1, big.NewInt(4), common.Big1, []byte{0x02, 0x01})),
+		modexpCase("mod_partial_input", "", modexpInput(common.Big1, common.Big1, big.NewInt(4), []byte{0x02, 0x03, 0x01, 0x01})),
+
+		// The EIP-7823 length limit.
+		modexpCase("exp_length_1024", "", modexpLens(pattern(32), pattern(1024), odd32)),
+		modexpCase("exp_length_1025", "", modexpLens(pattern(32), pattern(1025), odd32)),
+		modexpCase("base_length_1025_mod_empty", "", modexpLens(pattern(1025), three, nil)),
+		modexpCase("mod_length_1025_base_empty", "", modexpLens(nil, three, modulus(1025, false))),
+
+		// Operands with special arithmetic.
+		modexpCase("mod_zero", "", modexpLens(pattern(32), three, make([]byte, 32))),
+		modexpCase("mod_one", "", modexpLens(pattern(32), three, []byte{0x01})),
+		modexpCase("mod_even", "", modexpLens(pattern(32), three, modulus(32, true))),
+		modexpCase("base_one", "", modexpLens([]byte{0x01}, pattern(32), odd32)),
+		modexpCase("base_zero_exp_zero", "", modexpLens([]byte{0x00}, []byte{0x00}, odd32)),
+		modexpCase("base_exceeds_mod", "", modexpLens(bytesOf(0xff, 64), three, odd32)),
+	}
+}
+
+// bytesOf returns n copies of b.
+func bytesOf(b byte, n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = b
+	}
+	return out
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"math/big"
+
+	bls "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	blsfp "github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	bnfp "github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/ethereum/execution-specs/pkg/precompiletest"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// pairingOutcome is the expected result of a pairing check input.
+type pairingOutcome int
+
+const (
+	pairingFalse  pairingOutcome = iota // the product of the pairings is not one
+	pairingTrue                         // the product of the pairings is one
+	pairingReject                       // the input is malformed
+)
+
+// pairingCase returns a pairing check case whose gas is a base cost plus a
+// cost per complete pair of the input, as given by gas for the rules.
+func pairingCase(name, fork string, input []byte, pairLen int, gas func(rules params.Rules) (base, perPair uint64), outcome pairingOutcome) testCase {
+	return testCase{name: name, fork: fork, input: input, expect: func(rules params.Rules) (*big.Int, []byte) {
+		base, perPair := gas(rules)
+		cost := new(big.Int).SetUint64(base + uint64(len(input)/pairLen)*perPair)
+		switch outcome {
+		case pairingTrue:
+			return cost, precompiletest.Word(1)
+		case pairingFalse:
+			return cost, precompiletest.Word(0)
+		}
+		return cost, nil
+	}}
+}
+
+// bnPairingGas is the EIP-197 gas of the BN254 pairing check, repriced by
+// EIP-1108 from Istanbul.
+func bnPairingGas(rules params.Rules) (uint64, uint64) {
+	if rules.IsIstanbul {
+		return 45000, 34000
+	}
+	return 100000, 80000
+}
+
+// blsPairingGas is the EIP-2537 gas of the BLS12-381 pairing check.
+func blsPairingGas(params.Rules) (uint64, uint64) {
+	return 37700, 32600
+}
+
+func bnPairingCases() []testCase {
+	_, _, g1, g2 := bn254.Generators()
+	var g1neg bn254.G1Affine
+	g1neg.Neg(&g1)
+	outside := precompiletest.BNG2NotInSubgroup()
+	p := bnfp.Modulus()
+
+	var (
+		single     = precompiletest.BNPair(&g1, &g2)
+		cancelling = precompiletest.BNCancellingPairs(&g1, &g2)
+		infinity1  = make([]byte, 64)
+		infinity2  = make([]byte, 128)
+	)
+	// Ten pairs of multiples of the G1 generator summing to zero.
+	var many []byte
+	for i := int64(1); i < 10; i++ {
+		var q bn254.G1Affine
+		q.ScalarMultiplication(&g1, big.NewInt(i))
+		many = precompiletest.Concat(many, precompiletest.BNPair(&q, &g2))
+	}
+	var sum bn254.G1Affine
+	sum.ScalarMultiplication(&g1neg, big.NewInt(45))
+	many = precompiletest.Concat(many, precompiletest.BNPair(&sum, &g2))
+
+	// The generators with a coordinate increased by the modulus, encoding the
+	// same field element out of range.
+	g1Wrapped := precompiletest.Concat(precompiletest.WordBig(new(big.Int).Add(p, big.NewInt(1))), precompiletest.Word(2), precompiletest.BNG2(&g2))
+	g2Wrapped := precompiletest.BNG2(&g2)
+	x0 := new(big.Int).SetBytes(g2Wrapped[32:64])
+	copy(g2Wrapped[32:64], precompiletest.WordBig(new(big.Int).Add(x0, p)))
+	g2Wrapped = precompiletest.Concat(precompiletest.BNG1(&g1), g2Wrapped)
+
+	// The G2 generator with the real part first, as EIP-2537 orders them.
+	swapped := precompiletest.BNG2(&g2)
+	swapped = precompiletest.Concat(swapped[32:64], swapped[:32], swapped[96:], swapped[64:96])
+
+	return []testCase{
+		pairingCase("empty", "", nil, 192, bnPairingGas, pairingTrue),
+		pairingCase("single_pair", "", single, 192, bnPairingGas, pairingFalse),
+		pairingCase("cancelling_pairs", "", cancelling, 192, bnPairingGas, pairingTrue),
+		pairingCase("ten_pairs", "", many, 192, bnPairingGas, pairingTrue),
+		pairingCase("g1_infinity", "", precompiletest.Concat(infinity1, precompiletest.BNG2(&g2)), 192, bnPairingGas, pairingTrue),
+		pairingCase("g2_infinity", "", precompiletest.Concat(precompiletest.BNG1(&g1), infinity2), 192, bnPairingGas, pairingTrue),
+		pairingCase("g2_not_in_subgroup", "", precompiletest.BNPair(&g1, &outside), 192, bnPairingGas, pairingReject),
+		pairingCase("g1_infinity_g2_not_in_subgroup", "", precompiletest.Concat(infinity1, precompiletest.BNG2(&outside)), 192, bnPairingGas, pairingReject),
+		pairingCase("g2_not_in_subgroup_after_valid_pairs", "", precompiletest.Concat(cancelling, precompiletest.BNPair(&g1, &outside)), 192, bnPairingGas, pairingReject),
+		pairingCase("g2_swapped_coordinates", "", precompiletest.Concat(precompiletest.BNG1(&g1), swapped), 192, bnPairingGas, pairingReject),
+		pairingCase("g1_coordinate_plus_modulus", "", g1Wrapped, 192, bnPairingGas, pairingReject),
+		pairingCase("g2_coordinate_plus_modulus", "", g2Wrapped, 192, bnPairingGas, pairingReject),
+		pairingCase("g1_not_on_curve", "", precompiletest.Concat(precompiletest.Word(1), precompiletest.Word(3), precompiletest.BNG2(&g2)), 192, bnPairingGas, pairingReject),
+		pairingCase("length_193", "", precompiletest.Concat(single, []byte{0x00}), 192, bnPairingGas, pairingReject),
+		pairingCase("length_288", "", cancelling[:288], 192, bnPairingGas, pairingReject),
+	}
+}
+
+func blsPairingCases() []testCase {
+	_, _, g1, g2 := bls.Generators()
+	var g1neg bls.G1Affine
+	g1neg.Neg(&g1)
+	outside1, outside2 := precompiletest.BLSG1NotInSubgroup(), precompiletest.BLSG2NotInSubgroup()
+	p := blsfp.Modulus()
+
+	var (
+		single     = precompiletest.BLSPair(&g1, &g2)
+		cancelling = precompiletest.BLSCancellingPairs(&g1, &g2)
+		infinity1  = make([]byte, 128)
+		infinity2  = make([]byte, 256)
+	)
+	var many []byte
+	for i := int64(1); i < 10; i++ {
+		var q bls.G1Affine
+		q.ScalarMultiplication(&g1, big.NewInt(i))
+		many = precompiletest.Concat(many, precompiletest.BLSPair(&q, &g2))
+	}
+	var sum bls.G1Affine
+	sum.ScalarMultiplication(&g1neg, big.NewInt(45))
+	many = precompiletest.Concat(many, precompiletest.BLSPair(&sum, &g2))
+
+	// The G1 generator with its x coordinate increased by the modulus, which
+	// still fits the 64 byte encoding.
+	x := new(big.Int).SetBytes(precompiletest.BLSG1(&g1)[:64])
+	wrapped := precompiletest.Concat(new(big.Int).Add(x, p).FillBytes(make([]byte, 64)), precompiletest.BLSG1(&g1)[64:], precompiletest.BLSG2(&g2))
+
+	// The G1 generator with a nonzero byte in the padding of a coordinate.
+	padded := append([]byte{}, single...)
+	padded[0] = 0x01
+
+	return []testCase{
+		pairingCase("empty", "", nil, 384, blsPairingGas, pairingReject),
+		pairingCase("single_pair", "", single, 384, blsPairingGas, pairingFalse),
+		pairingCase("cancelling_pairs", "", cancelling, 384, blsPairingGas, pairingTrue),
+		pairingCase("ten_pairs", "", many, 384, blsPairingGas, pairingTrue),
+		pairingCase("infinity_pair", "", precompiletest.Concat(infinity1, infinity2), 384, blsPairingGas, pairingTrue),
+		pairingCase("g1_infinity", "", precompiletest.Concat(infinity1, precompiletest.BLSG2(&g2)), 384, blsPairingGas, pairingTrue),
+		pairingCase("g2_infinity", "", precompiletest.Concat(precompiletest.BLSG1(&g1), infinity2), 384, blsPairingGas, pairingTrue),
+		pairingCase("g1_not_in_subgroup", "", precompiletest.BLSPair(&outside1, &g2), 384, blsPairingGas, pairingReject),
+		pairingCase("g2_not_in_subgroup", "", precompiletest.BLSPair(&g1, &outside2), 384, blsPairingGas, pairingReject),
+		pairingCase("g1_not_in_subgroup_g2_infinity", "", precompiletest.Concat(precompiletest.BLSG1(&outside1), infinity2), 384, blsPairingGas, pairingReject),
+		pairingCase("g1_infinity_g2_not_in_subgroup", "", precompiletest.Concat(infinity1, precompiletest.BLSG2(&outside2)), 384, blsPairingGas, pairingReject),
+		pairingCase("g2_not_in_subgroup_after_valid_pairs", "", precompiletest.Concat(cancelling, precompiletest.BLSPair(&g1, &outside2)), 384, blsPairingGas, pairingReject),
+		pairingCase("g1_coordinate_plus_modulus", "", wrapped, 384, blsPairingGas, pairingReject),
+		pairingCase("g1_nonzero_padding", "", padded, 384, blsPairingGas, pairingReject),
+		pairingCase("g1_not_on_curve", "", precompiletest.Concat(precompiletest.BLSFp(new(blsfp.Element).SetOne()), precompiletest.BLSFp(new(blsfp.Element).SetOne()), precompiletest.BLSG2(&g2)), 384, blsPairingGas, pairingReject),
+		pairingCase("length_383", "", single[:383], 384, blsPairingGas, pairingReject),
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/execution-specs/pkg/precompiletest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+// pattern returns n bytes counting up from 1.
+func pattern(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i + 1)
+	}
+	return b
+}
+
+func ecrecoverCases() []testCase {
+	key, _ := crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	hash := crypto.Keccak256([]byte("execution-specs"))
+	sig, _ := crypto.Sign(hash, key)
+	r, s, v := sig[:32], sig[32:64], uint64(sig[64])+27
+
+	n := crypto.S256().Params().N
+	highS := precompiletest.WordBig(new(big.Int).Sub(n, new(big.Int).SetBytes(s)))
+	wideV := precompiletest.Word(v)
+	wideV[0] = 1
+	return []testCase{
+		{"valid", precompiletest.Concat(hash, precompiletest.Word(v), r, s)},
+		{"valid_trailing_bytes", precompiletest.Concat(hash, precompiletest.Word(v), r, s, pattern(32))},
+		{"high_s", precompiletest.Concat(hash, precompiletest.Word(55-v), r, highS)},
+		{"v_0", precompiletest.Concat(hash, precompiletest.Word(v-27), r, s)},
+		{"v_29", precompiletest.Concat(hash, precompiletest.Word(29), r, s)},
+		{"v_high_bytes_set", precompiletest.Concat(hash, wideV, r, s)},
+		{"r_zero", precompiletest.Concat(hash, precompiletest.Word(v), precompiletest.Word(0), s)},
+		{"s_zero", precompiletest.Concat(hash, precompiletest.Word(v), r, precompiletest.Word(0))},
+		{"r_order", precompiletest.Concat(hash, precompiletest.Word(v), precompiletest.WordBig(n), s)},
+		{"s_order", precompiletest.Concat(hash, precompiletest.Word(v), r, precompiletest.WordBig(n))},
+		{"truncated", precompiletest.Concat(hash, precompiletest.Word(v), r, s[:16])},
+		{"empty", nil},
+	}
+}
+
+// hashCases cover the word boundaries of the per word gas cost.
+func hashCases() []testCase {
+	var cases []testCase
+	for _, n := range []int{0, 1, 31, 32, 33, 64, 65, 1024} {
+		cases = append(cases, testCase{name: "length_" + strconv.Itoa(n), input: pattern(n)})
+	}
+	return cases
+}
+
+// modexpInput encodes the base, exponent and modulus with their lengths.
+func modexpInput(base, exp, mod []byte) []byte {
+	return precompiletest.Concat(precompiletest.Word(uint64(len(base))), precompiletest.Word(uint64(len(exp))), precompiletest.Word(uint64(len(mod))), base, exp, mod)
+}
+
+func modexpCases() []testCase {
+	ones := func(n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = 0xff
+		}
+		return b
+	}
+	return []testCase{
+		{"simple", modexpInput([]byte{3}, []byte{5}, []byte{7})},
+		{"zero_base", modexpInput([]byte{0}, []byte{5}, []byte{7})},
+		{"zero_exponent", modexpInput([]byte{3}, []byte{0}, []byte{7})},
+		{"zero_modulus", modexpInput([]byte{3}, []byte{5}, []byte{0})},
+		{"modulus_one", modexpInput([]byte{3}, []byte{5}, []byte{1})},
+		{"empty_modulus", modexpInput([]byte{3}, []byte{5}, nil)},
+		{"empty_exponent", modexpInput([]byte{3}, nil, []byte{7})},
+		{"exponent_leading_zeros", modexpInput([]byte{3}, precompiletest.Concat(make([]byte, 32), []byte{5}), []byte{7})},
+		{"exponent_32_bytes", modexpInput(ones(32), ones(32), pattern(32))},
+		{"exponent_33_bytes", modexpInput(ones(32), ones(33), pattern(32))},
+		{"large_exponent", modexpInput(ones(64), ones(64), pattern(64))},
+		{"truncated_operands", modexpInput(ones(32), ones(32), pattern(32))[:96+40]},
+		{"empty", nil},
+		{"lengths_1024", modexpInput(ones(1024), []byte{3}, pattern(1024))},
+		{"base_length_1025", modexpInput(ones(1025), []byte{3}, pattern(1024))},
+		{"exponent_length_1025", modexpInput(ones(32), ones(1025), pattern(32))},
+		{"modulus_length_1025", modexpInput(ones(32), []byte{3}, pattern(1025))},
+		{"huge_exponent_length", precompiletest.Concat(precompiletest.Word(0), precompiletest.WordBig(precompiletest.MaxWord), precompiletest.Word(0))},
+	}
+}
+
+// blake2fInput encodes a compression of the message "abc" with the initial
+// state of an unkeyed BLAKE2b-512 hash.
+func blake2fInput(rounds uint32, final byte) []byte {
+	iv := []uint64{
+		0x6a09e667f3bcc908, 0xbb67ae8584caa73b, 0x3c6ef372fe94f82b, 0xa54ff53a5f1d36f1,
+		0x510e527fade682d1, 0x9b05688c2b3e6c1f, 0x1f83d9abfb41bd6b, 0x5be0cd19137e2179,
+	}
+	iv[0] ^= 0x01010040
+	input := binary.BigEndian.AppendUint32(nil, rounds)
+	for _, h := range iv {
+		input = binary.LittleEndian.AppendUint64(input, h)
+	}
+	m := make([]byte, 128)
+	copy(m, "abc")
+	input = append(input, m...)
+	input = binary.LittleEndian.AppendUint64(input, 3) // t0
+	input = binary.LittleEndian.AppendUint64(input, 0) // t1
+	return append(input, final)
+}
+
+func blake2fCases() []testCase {
+	return []testCase{
+		{"rounds_12", blake2fInput(12, 1)},
+		{"rounds_0", blake2fInput(0, 1)},
+		{"rounds_1", blake2fInput(1, 1)},
+		{"not_final", blake2fInput(12, 0)},
+		{"invalid_final_flag", blake2fInput(12, 2)},
+		{"length_212", blake2fInput(12, 1)[1:]},
+		{"length_214", append(blake2fInput(12, 1), 0)},
+		{"empty", nil},
+	}
+}
+
+// pointEvaluationInput encodes a point evaluation of the blob's polynomial at
+// z together with its versioned hash.
+func pointEvaluationInput(blob *kzg4844.Blob, z kzg4844.Point) []byte {
+	commitment, _ := kzg4844.BlobToCommitment(blob)
+	proof, claim, _ := kzg4844.ComputeProof(blob, z)
+	hash := kzg4844.CalcBlobHashV1(sha256.New(), &commitment)
+	return precompiletest.Concat(hash[:], z[:], claim[:], commitment[:], proof[:])
+}
+
+func pointEvaluationCases() []testCase {
+	var blob kzg4844.Blob
+	for i := 0; i < len(blob)/32; i++ {
+		// Field elements must be below the BLS modulus, keep the top byte zero.
+		binary.BigEndian.PutUint64(blob[i*32+24:], uint64(i+1))
+	}
+	var z kzg4844.Point
+	copy(z[1:], crypto.Keccak256([]byte("execution-specs"))[1:])
+
+	valid := pointEvaluationInput(&blob, z)
+	wrongClaim := common.CopyBytes(valid)
+	wrongClaim[95] ^= 1
+	wrongHash := common.CopyBytes(valid)
+	wrongHash[31] ^= 1
+	wrongVersion := common.CopyBytes(valid)
+	wrongVersion[0] = 0x02
+	return []testCase{
+		{"valid", valid},
+		{"valid_z_zero", pointEvaluationInput(&blob, kzg4844.Point{})},
+		{"valid_empty_blob", pointEvaluationInput(new(kzg4844.Blob), z)},
+		{"wrong_claim", wrongClaim},
+		{"wrong_versioned_hash", wrongHash},
+		{"wrong_version", wrongVersion},
+		{"length_191", valid[:191]},
+		{"length_193", append(common.CopyBytes(valid), 0)},
+		{"empty", nil},
+	}
+}
+
+// p256Sign signs hash with the secret d and nonce k, as the ECDSA signing
+// algorithm does with a fixed nonce.
+func p256Sign(d, k *big.Int, hash []byte) (r, s *big.Int) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	rx, _ := curve.ScalarBaseMult(k.Bytes())
+	r = new(big.Int).Mod(rx, n)
+	s = new(big.Int).Mul(r, d)
+	s.Add(s, new(big.Int).SetBytes(hash))
+	s.Mul(s, new(big.Int).ModInverse(k, n))
+	s.Mod(s, n)
+	return r, s
+}
+
+func p256VerifyCases() []testCase {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	d, k := big.NewInt(0x1234567890), big.NewInt(0xabcdef)
+	x, y := curve.ScalarBaseMult(d.Bytes())
+	hash := crypto.Keccak256([]byte("execution-specs"))
+	r, s := p256Sign(d, k, hash)
+
+	valid := precompiletest.Concat(hash, precompiletest.WordBig(r), precompiletest.WordBig(s), precompiletest.WordBig(x), precompiletest.WordBig(y))
+	wrongHash := common.CopyBytes(valid)
+	wrongHash[0] ^= 1
+	return []testCase{
+		{"valid", valid},
+		{"high_s", precompiletest.Concat(hash, precompiletest.WordBig(r), precompiletest.WordBig(new(big.Int).Sub(n, s)), precompiletest.WordBig(x), precompiletest.WordBig(y))},
+		{"wrong_hash", wrongHash},
+		{"r_zero", precompiletest.Concat(hash, precompiletest.Word(0), precompiletest.WordBig(s), precompiletest.WordBig(x), precompiletest.WordBig(y))},
+		{"s_zero", precompiletest.Concat(hash, precompiletest.WordBig(r), precompiletest.Word(0), precompiletest.WordBig(x), precompiletest.WordBig(y))},
+		{"r_order", precompiletest.Concat(hash, precompiletest.WordBig(n), precompiletest.WordBig(s), precompiletest.WordBig(x), precompiletest.WordBig(y))},
+		{"s_order", precompiletest.Concat(hash, precompiletest.WordBig(r), precompiletest.WordBig(n), precompiletest.WordBig(x), precompiletest.WordBig(y))},
+		{"key_not_on_curve", precompiletest.Concat(hash, precompiletest.WordBig(r), precompiletest.WordBig(s), precompiletest.WordBig(x), precompiletest.WordBig(new(big.Int).Add(y, common.Big1)))},
+		{"key_infinity", precompiletest.Concat(hash, precompiletest.WordBig(r), precompiletest.WordBig(s), precompiletest.Word(0), precompiletest.Word(0))},
+		{"length_159", valid[:159]},
+		{"length_161", append(common.CopyBytes(valid), 0)},
+		{"empty", nil},
+	}
+}
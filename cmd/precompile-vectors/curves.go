@@ -0,0 +1,221 @@
+package main
+
+import (
+	"math/big"
+
+	bls "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	blsfp "github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+	blsfr "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	bnfp "github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	bnfr "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/ethereum/execution-specs/pkg/precompiletest"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func bnPoints() (g1, g1x2, g1neg bn254.G1Affine, g2, g2x2 bn254.G2Affine) {
+	_, _, g1, g2 = bn254.Generators()
+	g1x2.ScalarMultiplication(&g1, big.NewInt(2))
+	g1neg.Neg(&g1)
+	g2x2.ScalarMultiplication(&g2, big.NewInt(2))
+	return
+}
+
+var bnInfinity = make([]byte, 64)
+
+func bn254AddCases() []testCase {
+	g1, g1x2, g1neg, _, _ := bnPoints()
+	p := bnfp.Modulus()
+	return []testCase{
+		{"generator_plus_generator", precompiletest.Concat(precompiletest.BNG1(&g1), precompiletest.BNG1(&g1))},
+		{"generator_plus_double", precompiletest.Concat(precompiletest.BNG1(&g1), precompiletest.BNG1(&g1x2))},
+		{"generator_plus_infinity", precompiletest.Concat(precompiletest.BNG1(&g1), bnInfinity)},
+		{"infinity_plus_infinity", precompiletest.Concat(bnInfinity, bnInfinity)},
+		{"point_plus_negation", precompiletest.Concat(precompiletest.BNG1(&g1), precompiletest.BNG1(&g1neg))},
+		{"single_point", precompiletest.BNG1(&g1)},
+		{"trailing_bytes", precompiletest.Concat(precompiletest.BNG1(&g1), precompiletest.BNG1(&g1), pattern(32))},
+		{"not_on_curve", precompiletest.Concat(precompiletest.Word(1), precompiletest.Word(3), precompiletest.BNG1(&g1))},
+		{"coordinate_modulus", precompiletest.Concat(precompiletest.WordBig(p), precompiletest.Word(2), precompiletest.BNG1(&g1))},
+		{"empty", nil},
+	}
+}
+
+func bn254MulCases() []testCase {
+	g1, _, _, _, _ := bnPoints()
+	n := bnfr.Modulus()
+	return []testCase{
+		{"generator_times_2", precompiletest.Concat(precompiletest.BNG1(&g1), precompiletest.Word(2))},
+		{"generator_times_0", precompiletest.Concat(precompiletest.BNG1(&g1), precompiletest.Word(0))},
+		{"generator_times_order", precompiletest.Concat(precompiletest.BNG1(&g1), precompiletest.WordBig(n))},
+		{"generator_times_order_minus_1", precompiletest.Concat(precompiletest.BNG1(&g1), precompiletest.WordBig(new(big.Int).Sub(n, common.Big1)))},
+		{"generator_times_max", precompiletest.Concat(precompiletest.BNG1(&g1), precompiletest.WordBig(precompiletest.MaxWord))},
+		{"infinity_times_2", precompiletest.Concat(bnInfinity, precompiletest.Word(2))},
+		{"missing_scalar", precompiletest.BNG1(&g1)},
+		{"not_on_curve", precompiletest.Concat(precompiletest.Word(1), precompiletest.Word(3), precompiletest.Word(2))},
+		{"empty", nil},
+	}
+}
+
+func bn254PairingCases() []testCase {
+	g1, g1x2, g1neg, g2, g2x2 := bnPoints()
+	badG2 := precompiletest.BNG2(&g2)
+	badG2[127] ^= 1
+	single := precompiletest.BNPair(&g1, &g2)
+	return []testCase{
+		{"empty", nil},
+		{"single_pair", single},
+		{"cancelling_pairs", precompiletest.BNCancellingPairs(&g1, &g2)},
+		{"bilinear_pairs", precompiletest.Concat(precompiletest.BNPair(&g1x2, &g2), precompiletest.BNPair(&g1neg, &g2x2))},
+		{"infinity_pair", precompiletest.Concat(bnInfinity, make([]byte, 128))},
+		{"length_191", single[:191]},
+		{"g1_not_on_curve", precompiletest.Concat(precompiletest.Word(1), precompiletest.Word(3), precompiletest.BNG2(&g2))},
+		{"g2_not_on_curve", precompiletest.Concat(precompiletest.BNG1(&g1), badG2)},
+	}
+}
+
+func blsPoints() (g1, g1x2, g1neg bls.G1Affine, g2, g2x2, g2neg bls.G2Affine) {
+	_, _, g1, g2 = bls.Generators()
+	g1x2.ScalarMultiplication(&g1, big.NewInt(2))
+	g1neg.Neg(&g1)
+	g2x2.ScalarMultiplication(&g2, big.NewInt(2))
+	g2neg.Neg(&g2)
+	return
+}
+
+var (
+	blsG1Infinity = make([]byte, 128)
+	blsG2Infinity = make([]byte, 256)
+)
+
+func blsG1AddCases() []testCase {
+	g1, g1x2, g1neg, _, _, _ := blsPoints()
+	outside := precompiletest.BLSG1NotInSubgroup()
+	notOnCurve := precompiletest.BLSG1(&g1)
+	notOnCurve[127] ^= 1
+	padding := precompiletest.BLSG1(&g1)
+	padding[0] = 1
+	return []testCase{
+		{"generator_plus_generator", precompiletest.Concat(precompiletest.BLSG1(&g1), precompiletest.BLSG1(&g1))},
+		{"generator_plus_double", precompiletest.Concat(precompiletest.BLSG1(&g1), precompiletest.BLSG1(&g1x2))},
+		{"generator_plus_infinity", precompiletest.Concat(precompiletest.BLSG1(&g1), blsG1Infinity)},
+		{"infinity_plus_infinity", precompiletest.Concat(blsG1Infinity, blsG1Infinity)},
+		{"point_plus_negation", precompiletest.Concat(precompiletest.BLSG1(&g1), precompiletest.BLSG1(&g1neg))},
+		{"point_not_in_subgroup", precompiletest.Concat(precompiletest.BLSG1(&outside), precompiletest.BLSG1(&g1))},
+		{"not_on_curve", precompiletest.Concat(notOnCurve, precompiletest.BLSG1(&g1))},
+		{"nonzero_padding", precompiletest.Concat(padding, precompiletest.BLSG1(&g1))},
+		{"coordinate_modulus", precompiletest.Concat(precompiletest.BLSFpBig(blsfp.Modulus()), precompiletest.BLSFp(&g1.Y), precompiletest.BLSG1(&g1))},
+		{"length_255", precompiletest.Concat(precompiletest.BLSG1(&g1), precompiletest.BLSG1(&g1))[:255]},
+		{"empty", nil},
+	}
+}
+
+func blsG1MSMCases() []testCase {
+	g1, g1x2, _, _, _, _ := blsPoints()
+	outside := precompiletest.BLSG1NotInSubgroup()
+	notOnCurve := precompiletest.BLSG1(&g1)
+	notOnCurve[127] ^= 1
+	r := blsfr.Modulus()
+	return []testCase{
+		{"generator_times_2", precompiletest.Concat(precompiletest.BLSG1(&g1), precompiletest.Word(2))},
+		{"generator_times_0", precompiletest.Concat(precompiletest.BLSG1(&g1), precompiletest.Word(0))},
+		{"generator_times_order", precompiletest.Concat(precompiletest.BLSG1(&g1), precompiletest.WordBig(r))},
+		{"generator_times_max", precompiletest.Concat(precompiletest.BLSG1(&g1), precompiletest.WordBig(precompiletest.MaxWord))},
+		{"infinity_times_2", precompiletest.Concat(blsG1Infinity, precompiletest.Word(2))},
+		{"two_pairs", precompiletest.Concat(precompiletest.BLSG1(&g1), precompiletest.Word(2), precompiletest.BLSG1(&g1x2), precompiletest.Word(3))},
+		{"point_not_in_subgroup", precompiletest.Concat(precompiletest.BLSG1(&outside), precompiletest.Word(2))},
+		{"not_on_curve", precompiletest.Concat(notOnCurve, precompiletest.Word(2))},
+		{"length_159", precompiletest.Concat(precompiletest.BLSG1(&g1), precompiletest.Word(2))[:159]},
+		{"empty", nil},
+	}
+}
+
+func blsG2AddCases() []testCase {
+	_, _, _, g2, g2x2, g2neg := blsPoints()
+	outside := precompiletest.BLSG2NotInSubgroup()
+	notOnCurve := precompiletest.BLSG2(&g2)
+	notOnCurve[255] ^= 1
+	padding := precompiletest.BLSG2(&g2)
+	padding[0] = 1
+	return []testCase{
+		{"generator_plus_generator", precompiletest.Concat(precompiletest.BLSG2(&g2), precompiletest.BLSG2(&g2))},
+		{"generator_plus_double", precompiletest.Concat(precompiletest.BLSG2(&g2), precompiletest.BLSG2(&g2x2))},
+		{"generator_plus_infinity", precompiletest.Concat(precompiletest.BLSG2(&g2), blsG2Infinity)},
+		{"infinity_plus_infinity", precompiletest.Concat(blsG2Infinity, blsG2Infinity)},
+		{"point_plus_negation", precompiletest.Concat(precompiletest.BLSG2(&g2), precompiletest.BLSG2(&g2neg))},
+		{"point_not_in_subgroup", precompiletest.Concat(precompiletest.BLSG2(&outside), precompiletest.BLSG2(&g2))},
+		{"not_on_curve", precompiletest.Concat(notOnCurve, precompiletest.BLSG2(&g2))},
+		{"nonzero_padding", precompiletest.Concat(padding, precompiletest.BLSG2(&g2))},
+		{"coordinate_modulus", precompiletest.Concat(precompiletest.BLSFpBig(blsfp.Modulus()), precompiletest.BLSG2(&g2)[64:], precompiletest.BLSG2(&g2))},
+		{"length_511", precompiletest.Concat(precompiletest.BLSG2(&g2), precompiletest.BLSG2(&g2))[:511]},
+		{"empty", nil},
+	}
+}
+
+func blsG2MSMCases() []testCase {
+	_, _, _, g2, g2x2, _ := blsPoints()
+	outside := precompiletest.BLSG2NotInSubgroup()
+	notOnCurve := precompiletest.BLSG2(&g2)
+	notOnCurve[255] ^= 1
+	r := blsfr.Modulus()
+	return []testCase{
+		{"generator_times_2", precompiletest.Concat(precompiletest.BLSG2(&g2), precompiletest.Word(2))},
+		{"generator_times_0", precompiletest.Concat(precompiletest.BLSG2(&g2), precompiletest.Word(0))},
+		{"generator_times_order", precompiletest.Concat(precompiletest.BLSG2(&g2), precompiletest.WordBig(r))},
+		{"generator_times_max", precompiletest.Concat(precompiletest.BLSG2(&g2), precompiletest.WordBig(precompiletest.MaxWord))},
+		{"infinity_times_2", precompiletest.Concat(blsG2Infinity, precompiletest.Word(2))},
+		{"two_pairs", precompiletest.Concat(precompiletest.BLSG2(&g2), precompiletest.Word(2), precompiletest.BLSG2(&g2x2), precompiletest.Word(3))},
+		{"point_not_in_subgroup", precompiletest.Concat(precompiletest.BLSG2(&outside), precompiletest.Word(2))},
+		{"not_on_curve", precompiletest.Concat(notOnCurve, precompiletest.Word(2))},
+		{"length_287", precompiletest.Concat(precompiletest.BLSG2(&g2), precompiletest.Word(2))[:287]},
+		{"empty", nil},
+	}
+}
+
+func blsPairingCases() []testCase {
+	g1, g1x2, g1neg, g2, g2x2, _ := blsPoints()
+	outside1, outside2 := precompiletest.BLSG1NotInSubgroup(), precompiletest.BLSG2NotInSubgroup()
+	single := precompiletest.BLSPair(&g1, &g2)
+	return []testCase{
+		{"single_pair", single},
+		{"cancelling_pairs", precompiletest.BLSCancellingPairs(&g1, &g2)},
+		{"bilinear_pairs", precompiletest.Concat(precompiletest.BLSPair(&g1x2, &g2), precompiletest.BLSPair(&g1neg, &g2x2))},
+		{"infinity_pair", precompiletest.Concat(blsG1Infinity, blsG2Infinity)},
+		{"g1_not_in_subgroup", precompiletest.BLSPair(&outside1, &g2)},
+		{"g2_not_in_subgroup", precompiletest.BLSPair(&g1, &outside2)},
+		{"length_383", single[:383]},
+		{"empty", nil},
+	}
+}
+
+func blsMapG1Cases() []testCase {
+	p := blsfp.Modulus()
+	padding := precompiletest.BLSFpBig(big.NewInt(1))
+	padding[0] = 1
+	return []testCase{
+		{"zero", precompiletest.BLSFpBig(new(big.Int))},
+		{"one", precompiletest.BLSFpBig(big.NewInt(1))},
+		{"modulus_minus_1", precompiletest.BLSFpBig(new(big.Int).Sub(p, common.Big1))},
+		{"modulus", precompiletest.BLSFpBig(p)},
+		{"nonzero_padding", padding},
+		{"length_63", precompiletest.BLSFpBig(big.NewInt(1))[1:]},
+		{"empty", nil},
+	}
+}
+
+func blsMapG2Cases() []testCase {
+	p := blsfp.Modulus()
+	one := precompiletest.BLSFpBig(big.NewInt(1))
+	maxFp := precompiletest.BLSFpBig(new(big.Int).Sub(p, common.Big1))
+	padding := precompiletest.BLSFpBig(big.NewInt(1))
+	padding[0] = 1
+	return []testCase{
+		{"zero", precompiletest.Concat(precompiletest.BLSFpBig(new(big.Int)), precompiletest.BLSFpBig(new(big.Int)))},
+		{"one_one", precompiletest.Concat(one, one)},
+		{"modulus_minus_1", precompiletest.Concat(maxFp, maxFp)},
+		{"c0_modulus", precompiletest.Concat(precompiletest.BLSFpBig(p), one)},
+		{"c1_modulus", precompiletest.Concat(one, precompiletest.BLSFpBig(p))},
+		{"nonzero_padding", precompiletest.Concat(padding, one)},
+		{"length_127", precompiletest.Concat(one, one)[1:]},
+		{"empty", nil},
+	}
+}
@@ -0,0 +1,134 @@
+// precompile-vectors generates input/output/gas test vectors for the
+// precompiled contracts of each fork, using go-ethereum as the reference
+// implementation.
+//
+// Usage:
+//
+//	go run ./cmd/precompile-vectors [--forks Homestead,Byzantium,...] [--output vectors]
+//
+// The inputs are generated per precompile and cover the regular cases as well
+// as the boundaries of the input encoding: empty and truncated inputs, points
+// at infinity, points off the curve or outside the subgroup, field elements
+// equal to the modulus, scalars equal to the group order and the gas schedule
+// steps. Every input is run against each fork the precompile is active in, so
+// repricings and new validity rules show up as differences between the forks.
+//
+// The vectors are written to <output>/<fork>/<precompile>.json and, for the
+// inputs the precompile rejects, <output>/<fork>/fail-<precompile>.json, in the
+// format of go-ethereum's core/vm/testdata/precompiles.
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/kzg"
+	"github.com/ethereum/execution-specs/pkg/precompiletest"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// testCase is a generated precompile input.
+type testCase struct {
+	name  string
+	input []byte
+}
+
+// precompile describes how to generate the vectors of a precompile, which is
+// identified by the name go-ethereum gives it.
+type precompile struct {
+	file  string
+	cases func() []testCase
+}
+
+var precompiles = map[string]precompile{
+	"ECREC":                {"ecRecover", ecrecoverCases},
+	"SHA256":               {"sha256", hashCases},
+	"RIPEMD160":            {"ripemd160", hashCases},
+	"ID":                   {"identity", hashCases},
+	"MODEXP":               {"modexp", modexpCases},
+	"BN254_ADD":            {"bn256Add", bn254AddCases},
+	"BN254_MUL":            {"bn256ScalarMul", bn254MulCases},
+	"BN254_PAIRING":        {"bn256Pairing", bn254PairingCases},
+	"BLAKE2F":              {"blake2F", blake2fCases},
+	"KZG_POINT_EVALUATION": {"pointEvaluation", pointEvaluationCases},
+	"BLS12_G1ADD":          {"blsG1Add", blsG1AddCases},
+	"BLS12_G1MSM":          {"blsG1MultiExp", blsG1MSMCases},
+	"BLS12_G2ADD":          {"blsG2Add", blsG2AddCases},
+	"BLS12_G2MSM":          {"blsG2MultiExp", blsG2MSMCases},
+	"BLS12_PAIRING_CHECK":  {"blsPairing", blsPairingCases},
+	"BLS12_MAP_FP_TO_G1":   {"blsMapG1", blsMapG1Cases},
+	"BLS12_MAP_FP2_TO_G2":  {"blsMapG2", blsMapG2Cases},
+	"P256VERIFY":           {"p256Verify", p256VerifyCases},
+}
+
+// defaultForks are the forks which introduced or repriced precompiles.
+const defaultForks = "Homestead,Byzantium,Istanbul,Berlin,Cancun,Prague,Osaka"
+
+func main() {
+	var (
+		forks  = flag.String("forks", defaultForks, "comma separated list of forks to generate vectors for")
+		output = flag.String("output", "vectors", "directory the vectors are written to")
+	)
+	flag.Parse()
+	// The point evaluation inputs are computed with kzg4844.
+	if err := kzg.CheckLibrary(); err != nil {
+		fatalf("%v", err)
+	}
+	for _, fork := range strings.Split(*forks, ",") {
+		if err := generateFork(strings.TrimSpace(fork), *output); err != nil {
+			fatalf("%v", err)
+		}
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// generateFork runs the inputs of all precompiles active in the fork and
+// writes the resulting vectors.
+func generateFork(fork, output string) error {
+	config, ok := tests.Forks[fork]
+	if !ok {
+		return fmt.Errorf("unknown fork %q", fork)
+	}
+	rules := config.Rules(new(big.Int), config.TerminalTotalDifficulty != nil, 0)
+	contracts := vm.ActivePrecompiledContracts(rules)
+
+	dir := filepath.Join(output, fork)
+	var total, failing int
+	for _, addr := range vm.ActivePrecompiles(rules) {
+		contract := contracts[addr]
+		p, ok := precompiles[contract.Name()]
+		if !ok {
+			return fmt.Errorf("%s: no vector generator for precompile %s at %s", fork, contract.Name(), addr.Hex())
+		}
+		var (
+			vectors     = []precompiletest.Vector{}
+			failVectors = []precompiletest.FailVector{}
+		)
+		for _, c := range p.cases() {
+			gas := contract.RequiredGas(c.input)
+			out, err := contract.Run(c.input)
+			if err != nil {
+				failVectors = append(failVectors, precompiletest.FailVector{Input: hex.EncodeToString(c.input), ExpectedError: err.Error(), Name: c.name})
+				continue
+			}
+			vectors = append(vectors, precompiletest.Vector{Input: hex.EncodeToString(c.input), Expected: hex.EncodeToString(out), Gas: gas, Name: c.name})
+		}
+		if err := precompiletest.Write(dir, p.file, vectors, failVectors); err != nil {
+			return err
+		}
+		total += len(vectors) + len(failVectors)
+		failing += len(failVectors)
+	}
+	fmt.Printf("%s: %d precompiles, %d vectors (%d failing)\n", fork, len(contracts), total, failing)
+	return nil
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/txbuilder"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// The fees and gas limit of the transactions, covering the base fee of the
+// genesis.
+const (
+	gasPrice = 10
+	tipCap   = 1
+	txGas    = 200000
+)
+
+var (
+	// The genesis funds the sender with 1 ether.
+	senderKey    = common.HexToHash("0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	sender       = address(senderKey)
+	authorityKey = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000a01")
+	authority    = address(authorityKey)
+
+	recipient = common.HexToAddress("0x000000000000000000000000000000000000dead")
+
+	// The contracts emitting logs. Their code only uses Frontier opcodes,
+	// except for the reverter.
+	emitter  = common.HexToAddress("0x00000000000000000000000000000000000010e1")
+	log0     = common.HexToAddress("0x00000000000000000000000000000000000010e0")
+	log4     = common.HexToAddress("0x00000000000000000000000000000000000010e4")
+	many     = common.HexToAddress("0x00000000000000000000000000000000000010e3")
+	large    = common.HexToAddress("0x00000000000000000000000000000000000010ee")
+	caller   = common.HexToAddress("0x00000000000000000000000000000000000010ca")
+	reverter = common.HexToAddress("0x00000000000000000000000000000000000010fd")
+	halter   = common.HexToAddress("0x00000000000000000000000000000000000010fe")
+
+	topic1 = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000001")
+	topic2 = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000002")
+	topic3 = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000003")
+	topic4 = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+	word = common.HexToHash("0x00000000000000000000000000000000000000000000000000000000deadbeef").Bytes()
+)
+
+// address returns the address of a secret key.
+func address(key common.Hash) common.Address {
+	k, err := crypto.ToECDSA(key[:])
+	if err != nil {
+		panic(err)
+	}
+	return crypto.PubkeyToAddress(k.PublicKey)
+}
+
+// push returns the smallest PUSH of a big endian number.
+func push(b []byte) []byte {
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	if len(b) == 0 {
+		b = []byte{0}
+	}
+	return append([]byte{byte(vm.PUSH1) + byte(len(b)-1)}, b...)
+}
+
+// pushUint returns the smallest PUSH of a number.
+func pushUint(v uint64) []byte {
+	return push(new(big.Int).SetUint64(v).Bytes())
+}
+
+// emit returns the code emitting a log of the topics and data, copying the
+// data to memory from offset zero first.
+func emit(topics []common.Hash, data []byte) []byte {
+	var code []byte
+	for offset := 0; offset < len(data); offset += 32 {
+		chunk := make([]byte, 32)
+		copy(chunk, data[offset:])
+		code = append(code, byte(vm.PUSH32))
+		code = append(code, chunk...)
+		code = append(code, pushUint(uint64(offset))...)
+		code = append(code, byte(vm.MSTORE))
+	}
+	for i := len(topics) - 1; i >= 0; i-- {
+		code = append(code, byte(vm.PUSH32))
+		code = append(code, topics[i].Bytes()...)
+	}
+	code = append(code, pushUint(uint64(len(data)))...)
+	code = append(code, pushUint(0)...)
+	return append(code, byte(vm.LOG0)+byte(len(topics)))
+}
+
+// call returns the code calling the address with all the remaining gas and
+// discarding the result.
+func call(addr common.Address) []byte {
+	code := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH20)}
+	code = append(code, addr.Bytes()...)
+	// Before EIP-150 a call cannot be passed more gas than available.
+	code = append(code, byte(vm.PUSH3), 0x01, 0x00, 0x00, byte(vm.CALL), byte(vm.POP))
+	return code
+}
+
+// concat joins code fragments.
+func concat(fragments ...[]byte) []byte {
+	var code []byte
+	for _, f := range fragments {
+		code = append(code, f...)
+	}
+	return code
+}
+
+// pattern returns n bytes counting up from zero.
+func pattern(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
+
+// contracts are the log emitting contracts of the genesis.
+var contracts = map[common.Address][]byte{
+	emitter:  emit([]common.Hash{topic1}, word),
+	log0:     emit(nil, nil),
+	log4:     emit([]common.Hash{topic1, topic2, topic3, topic4}, word),
+	many:     concat(emit([]common.Hash{topic1}, nil), emit([]common.Hash{topic1, topic2}, word), emit([]common.Hash{topic1, topic2, topic3}, pattern(33))),
+	large:    emit([]common.Hash{topic1}, pattern(1024)),
+	caller:   concat(emit([]common.Hash{topic2}, nil), call(emitter), emit([]common.Hash{topic3}, nil)),
+	reverter: concat(emit([]common.Hash{topic1}, word), []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)}),
+	halter:   concat(emit([]common.Hash{topic1}, word), []byte{byte(vm.INVALID)}),
+}
+
+// testCase is the sequence of transactions of the block following the
+// genesis.
+type testCase struct {
+	name        string
+	description string
+	fork        string             // first fork the case applies to, all if empty
+	alloc       types.GenesisAlloc // accounts besides the sender
+	txs         []receiptTx
+}
+
+// receiptTx is a transaction of a test case with the expected outcome: its
+// status from Byzantium and the emitters of the logs of its receipt.
+type receiptTx struct {
+	tx       *txbuilder.Definition
+	failed   bool
+	emitters []common.Address
+}
+
+// check compares a receipt with the expectation.
+func (t *receiptTx) check(r *types.Receipt) error {
+	if len(r.PostState) == 0 {
+		if failed := r.Status == types.ReceiptStatusFailed; failed != t.failed {
+			return fmt.Errorf("status %d, expected failure %t", r.Status, t.failed)
+		}
+	}
+	if len(r.Logs) != len(t.emitters) {
+		return fmt.Errorf("%d logs, expected %d", len(r.Logs), len(t.emitters))
+	}
+	for i, l := range r.Logs {
+		if l.Address != t.emitters[i] {
+			return fmt.Errorf("log %d emitted by %s, expected %s", i, l.Address.Hex(), t.emitters[i].Hex())
+		}
+	}
+	return nil
+}
+
+func big256(v int64) *math.HexOrDecimal256 {
+	return (*math.HexOrDecimal256)(big.NewInt(v))
+}
+
+// legacy returns a legacy transaction of the sender calling the address, or
+// creating a contract with the data if nil.
+func legacy(nonce uint64, to *common.Address, data []byte) *txbuilder.Definition {
+	return &txbuilder.Definition{
+		Nonce:     math.HexOrDecimal64(nonce),
+		GasPrice:  big256(gasPrice),
+		GasLimit:  txGas,
+		To:        to,
+		Data:      data,
+		SecretKey: senderKey,
+	}
+}
+
+// dynamic returns a dynamic fee transaction of the sender calling the
+// address.
+func dynamic(nonce uint64, to common.Address) *txbuilder.Definition {
+	return &txbuilder.Definition{
+		Nonce:                math.HexOrDecimal64(nonce),
+		MaxPriorityFeePerGas: big256(tipCap),
+		MaxFeePerGas:         big256(gasPrice),
+		GasLimit:             txGas,
+		To:                   &to,
+		SecretKey:            senderKey,
+	}
+}
+
+func cases() []testCase {
+	alloc := make(types.GenesisAlloc)
+	for addr, code := range contracts {
+		alloc[addr] = types.Account{Code: code, Balance: new(big.Int)}
+	}
+	transfer := legacy(0, &recipient, nil)
+	transfer.GasLimit, transfer.Value = math.HexOrDecimal64(params.TxGas), big256(1)
+
+	accessList := legacy(0, &emitter, nil)
+	accessList.AccessList = &types.AccessList{{Address: emitter, StorageKeys: []common.Hash{{}}}}
+
+	blob := dynamic(0, emitter)
+	blob.MaxFeePerBlobGas = big256(gasPrice)
+	blob.BlobVersionedHashes = []common.Hash{common.HexToHash("0x0100000000000000000000000000000000000000000000000000000000000001")}
+
+	setCode := dynamic(0, authority)
+	setCode.AuthorizationList = []*txbuilder.Authorization{{Address: emitter, SecretKey: authorityKey}}
+
+	halting := legacy(2, &halter, nil)
+
+	return []testCase{
+		{
+			name:        "transfer",
+			description: "A value transfer, with an empty bloom and no logs.",
+			txs:         []receiptTx{{tx: transfer}},
+		},
+		{
+			name:        "log0_empty",
+			description: "An empty LOG0, without topics and data, which sets no bloom bits.",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: legacy(0, &log0, nil), emitters: []common.Address{log0}}},
+		},
+		{
+			name:        "log1",
+			description: "A LOG1 with a word of data.",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: legacy(0, &emitter, nil), emitters: []common.Address{emitter}}},
+		},
+		{
+			name:        "log4",
+			description: "A LOG4, the most topics of a log.",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: legacy(0, &log4, nil), emitters: []common.Address{log4}}},
+		},
+		{
+			name:        "many_logs",
+			description: "Three logs of one contract with one to three topics and no, a word and more than a word of data.",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: legacy(0, &many, nil), emitters: []common.Address{many, many, many}}},
+		},
+		{
+			name:        "large_data",
+			description: "A log with 1024 bytes of data.",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: legacy(0, &large, nil), emitters: []common.Address{large}}},
+		},
+		{
+			name:        "nested_call",
+			description: "Logs of a contract before and after a call of another contract emitting a log, in execution order.",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: legacy(0, &caller, nil), emitters: []common.Address{caller, emitter, caller}}},
+		},
+		{
+			name:        "reverted",
+			description: "A call emitting a log and reverting, whose receipt fails without logs.",
+			fork:        "Byzantium",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: legacy(0, &reverter, nil), failed: true}},
+		},
+		{
+			name:        "exceptional_halt",
+			description: "A call emitting a log and halting exceptionally, using all its gas, whose receipt fails without logs.",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: legacy(0, &halter, nil), failed: true}},
+		},
+		{
+			name:        "create",
+			description: "A contract creation whose init code emits a log, from the address of the new contract.",
+			txs:         []receiptTx{{tx: legacy(0, nil, contracts[emitter]), emitters: []common.Address{crypto.CreateAddress(sender, 0)}}},
+		},
+		{
+			name:        "cumulative_gas",
+			description: "A transfer, a call emitting a log and a failing call in one block, their receipts accumulating the gas used.",
+			alloc:       alloc,
+			txs: []receiptTx{
+				{tx: transfer},
+				{tx: legacy(1, &emitter, nil), emitters: []common.Address{emitter}},
+				{tx: halting, failed: true},
+			},
+		},
+		{
+			name:        "access_list_tx",
+			description: "The receipt of an EIP-2930 access list transaction, encoded with type byte 0x01.",
+			fork:        "Berlin",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: accessList, emitters: []common.Address{emitter}}},
+		},
+		{
+			name:        "dynamic_fee_tx",
+			description: "The receipt of an EIP-1559 dynamic fee transaction, encoded with type byte 0x02.",
+			fork:        "London",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: dynamic(0, emitter), emitters: []common.Address{emitter}}},
+		},
+		{
+			name:        "blob_tx",
+			description: "The receipt of an EIP-4844 blob transaction, encoded with type byte 0x03 and without the blob gas.",
+			fork:        "Cancun",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: blob, emitters: []common.Address{emitter}}},
+		},
+		{
+			name:        "set_code_tx",
+			description: "The receipt of an EIP-7702 set code transaction, encoded with type byte 0x04, calling the authority delegated to a contract emitting a log from the address of the authority.",
+			fork:        "Prague",
+			alloc:       alloc,
+			txs:         []receiptTx{{tx: setCode, emitters: []common.Address{authority}}},
+		},
+	}
+}
@@ -0,0 +1,275 @@
+// receipt-vectors generates receipt vectors, executing transactions with
+// go-ethereum and recording the receipts they produce together with their
+// encodings.
+//
+// Usage:
+//
+//	go run ./cmd/receipt-vectors [--forks Frontier,Byzantium,Berlin,London,Cancun,Prague] [--output receipt_vectors.json]
+//
+// Every vector is a genesis and the transactions of the block following it,
+// in their network encoding, with the receipt of each transaction: its type,
+// the post-state root before Byzantium and the status from it, the
+// cumulative gas used, the bloom and the logs. Each receipt is given in its
+// consensus encoding, the RLP list of a legacy receipt and the type byte
+// followed by the RLP list of a typed one, which is also the value of the
+// receipt trie, and in the RLP encoding of an element of a receipts list,
+// which wraps a typed receipt into an RLP string. The receipts root of the
+// block completes the vector.
+//
+// The vectors cover transactions without logs, logs with no to four topics
+// and with empty and large data, several logs of one and of nested calls,
+// logs discarded by a revert or an exceptional halt, logs of contract
+// creations and of delegated EOAs, the cumulative gas of several
+// transactions, and the receipts of every transaction type.
+//
+// The expected status and the emitters of the logs of each receipt are
+// declared with the cases, and every receipt is decoded from both encodings
+// and compared with the original, with its bloom recomputed from the logs,
+// before anything is written.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/execution-specs/pkg/txbuilder"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// The parameters of the genesis.
+const (
+	gasLimit = 30000000
+	baseFee  = 7
+)
+
+// vector is a receipt vector.
+type vector struct {
+	Info         map[string]string `json:"_info"`
+	Network      string            `json:"network"`
+	Genesis      *core.Genesis     `json:"genesis"`
+	Transactions []*transaction    `json:"transactions"`
+	Receipts     []*receipt        `json:"receipts"`
+	ReceiptsRoot common.Hash       `json:"receiptsRoot"`
+}
+
+// transaction is a transaction of a vector in its network encoding.
+type transaction struct {
+	RLP  hexutil.Bytes `json:"rlp"`
+	Hash common.Hash   `json:"hash"`
+}
+
+// receipt holds the consensus fields of a receipt and its encodings. A
+// receipt has either a post-state root or a status.
+type receipt struct {
+	Type              hexutil.Uint64  `json:"type"`
+	Root              hexutil.Bytes   `json:"root,omitempty"`
+	Status            *hexutil.Uint64 `json:"status,omitempty"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+	Bloom             types.Bloom     `json:"logsBloom"`
+	Logs              []*log          `json:"logs"`
+	Encoded           hexutil.Bytes   `json:"encoded"` // consensus encoding
+	RLP               hexutil.Bytes   `json:"rlp"`     // encoding as a receipts list element
+}
+
+// log is the consensus part of a log.
+type log struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+func main() {
+	var (
+		forkList = flag.String("forks", "Frontier,Byzantium,Berlin,London,Cancun,Prague", "comma separated forks the vectors are generated for")
+		output   = flag.String("output", "receipt_vectors.json", "file the vectors are written to")
+	)
+	flag.Parse()
+	forkNames := strings.Split(*forkList, ",")
+	for _, fork := range forkNames {
+		if _, ok := tests.Forks[fork]; !ok {
+			fatalf("unknown fork %q", fork)
+		}
+		if _, err := forks.Index(fork); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	vectors := make(map[string]*vector)
+	for _, c := range cases() {
+		first := 0
+		if c.fork != "" {
+			first, _ = forks.Index(c.fork)
+		}
+		for _, fork := range forkNames {
+			if index, _ := forks.Index(fork); index < first {
+				continue
+			}
+			v, err := fill(&c, fork)
+			if err != nil {
+				fatalf("case %s: %s: %v", c.name, fork, err)
+			}
+			vectors["receipt_vectors/"+c.name+"/"+fork] = v
+		}
+	}
+	if len(vectors) == 0 {
+		fatalf("no vectors for the forks %s", *forkList)
+	}
+	data, err := json.MarshalIndent(vectors, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d vectors to %s\n", len(vectors), *output)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// fill executes the transactions of a test case on a fork and checks their
+// receipts against the expectations of the case.
+func fill(c *testCase, fork string) (*vector, error) {
+	config := tests.Forks[fork]
+	genesis := &core.Genesis{
+		Config:     config,
+		GasLimit:   gasLimit,
+		Difficulty: new(big.Int),
+		Alloc:      types.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+	}
+	if config.TerminalTotalDifficulty == nil {
+		genesis.Difficulty = big.NewInt(0x20000)
+	}
+	if config.LondonBlock != nil {
+		genesis.BaseFee = big.NewInt(baseFee)
+	}
+	if config.CancunTime != nil {
+		genesis.ExcessBlobGas, genesis.BlobGasUsed = new(uint64), new(uint64)
+	}
+	for addr, account := range c.alloc {
+		genesis.Alloc[addr] = account
+	}
+	exec, err := txbuilder.NewExecutor(genesis)
+	if err != nil {
+		return nil, err
+	}
+	defer exec.Close()
+
+	v := &vector{
+		Info:    statetest.Info("receipt-vectors", "handcrafted", c.description),
+		Network: fork,
+		Genesis: genesis,
+	}
+	var receipts types.Receipts
+	for i, t := range c.txs {
+		// Legacy transactions are replay protected from EIP-155.
+		def := *t.tx
+		def.Unprotected = !config.IsEIP155(new(big.Int))
+		tx, err := txbuilder.Build(&def)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		r, err := exec.Apply(tx)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		if err := t.check(r); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		entry, err := encodeReceipt(r)
+		if err != nil {
+			return nil, fmt.Errorf("receipt %d: %v", i, err)
+		}
+		enc, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		v.Transactions = append(v.Transactions, &transaction{RLP: enc, Hash: tx.Hash()})
+		v.Receipts = append(v.Receipts, entry)
+		receipts = append(receipts, r)
+	}
+	v.ReceiptsRoot = types.DeriveSha(receipts, trie.NewStackTrie(nil))
+	return v, nil
+}
+
+// encodeReceipt encodes a receipt and verifies that both encodings decode to
+// its consensus fields.
+func encodeReceipt(r *types.Receipt) (*receipt, error) {
+	if r.Bloom != types.CreateBloom(r) {
+		return nil, errors.New("bloom does not match the logs")
+	}
+	encoded, err := r.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	element, err := rlp.EncodeToBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.Type == types.LegacyTxType && !bytes.Equal(encoded, element) {
+		return nil, errors.New("legacy receipt encodings differ")
+	}
+	var fromBinary, fromRLP types.Receipt
+	if err := fromBinary.UnmarshalBinary(encoded); err != nil {
+		return nil, fmt.Errorf("decoding consensus encoding: %v", err)
+	}
+	if err := rlp.DecodeBytes(element, &fromRLP); err != nil {
+		return nil, fmt.Errorf("decoding list element: %v", err)
+	}
+	entry := &receipt{
+		Type:              hexutil.Uint64(r.Type),
+		Root:              r.PostState,
+		CumulativeGasUsed: hexutil.Uint64(r.CumulativeGasUsed),
+		Bloom:             r.Bloom,
+		Logs:              make([]*log, len(r.Logs)),
+		Encoded:           encoded,
+		RLP:               element,
+	}
+	if len(r.PostState) == 0 {
+		status := hexutil.Uint64(r.Status)
+		entry.Status = &status
+	}
+	for i, l := range r.Logs {
+		entry.Logs[i] = &log{Address: l.Address, Topics: l.Topics, Data: l.Data}
+		if entry.Logs[i].Topics == nil {
+			entry.Logs[i].Topics = []common.Hash{}
+		}
+	}
+	for name, decoded := range map[string]*types.Receipt{"consensus encoding": &fromBinary, "list element": &fromRLP} {
+		if !reflect.DeepEqual(consensusFields(decoded), consensusFields(r)) {
+			return nil, fmt.Errorf("%s does not decode to the receipt", name)
+		}
+	}
+	return entry, nil
+}
+
+// consensusFields returns the fields of a receipt covered by its encodings.
+// Receipts with a post-state root encode no status.
+func consensusFields(r *types.Receipt) interface{} {
+	status := r.Status
+	if len(r.PostState) > 0 {
+		status = 0
+	}
+	logs := make([][3]interface{}, len(r.Logs))
+	for i, l := range r.Logs {
+		logs[i] = [3]interface{}{l.Address, append([]common.Hash{}, l.Topics...), append([]byte{}, l.Data...)}
+	}
+	return []interface{}{r.Type, append([]byte{}, r.PostState...), status, r.CumulativeGasUsed, r.Bloom, logs}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// The balance of the destructed contracts, and of the beneficiary before.
+const (
+	value          = 1000
+	initialBalance = 1
+)
+
+var (
+	// victim is the pre-existing contract destructing itself.
+	victim = common.HexToAddress("0x0000000000000000000000000000000000006780")
+
+	// library is the contract whose code executes SELFDESTRUCT in the context
+	// of a DELEGATECALL.
+	library = common.HexToAddress("0x00000000000000000000000000000000000011b0")
+
+	// factory is the contract creating a child destructed in the same
+	// transaction.
+	factory = common.HexToAddress("0x000000000000000000000000000000000000fac7")
+
+	// child is the contract the factory creates, at its nonce 1.
+	child = crypto.CreateAddress(factory, 1)
+
+	// beneficiary is the existing account receiving the balance.
+	beneficiary = common.HexToAddress("0x000000000000000000000000000000000000be4e")
+
+	// fresh is an account which does not exist before it receives a balance.
+	fresh = common.HexToAddress("0x000000000000000000000000000000000000f4e5")
+)
+
+// wantAccount is the expected post-state of an account.
+type wantAccount struct {
+	absent  bool
+	balance uint64
+	code    bool        // whether the account has code
+	slot    common.Hash // storage slot 0
+}
+
+// account returns the expected post-state in the form checked by statetest.
+func (w wantAccount) account() statetest.Account {
+	account := statetest.Account{
+		Absent:  w.absent,
+		Balance: new(big.Int).SetUint64(w.balance),
+		AnyCode: w.code,
+		Storage: map[common.Hash]common.Hash{{}: w.slot},
+	}
+	if !w.code {
+		account.Code = []byte{}
+	}
+	return account
+}
+
+// outcome is the expected outcome of a transaction on a fork: the gas used,
+// checked unless zero, and the accounts.
+type outcome struct {
+	gasUsed  uint64
+	accounts map[common.Address]wantAccount
+}
+
+// testCase is a transaction calling a contract together with its expected
+// outcome per fork.
+type testCase struct {
+	name        string
+	description string
+	pre         types.GenesisAlloc // accounts besides the sender
+	to          common.Address
+	expect      func(fork string) *outcome
+}
+
+// selfdestruct returns code executing SELFDESTRUCT with the target, or with
+// the executing account itself for the zero address.
+func selfdestruct(target common.Address) []byte {
+	if target == (common.Address{}) {
+		return []byte{byte(vm.ADDRESS), byte(vm.SELFDESTRUCT)}
+	}
+	return append(append([]byte{byte(vm.PUSH20)}, target.Bytes()...), byte(vm.SELFDESTRUCT))
+}
+
+// delegate returns code delegating to the library with all gas.
+func delegate() []byte {
+	code := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH20)}
+	return append(append(code, library.Bytes()...), byte(vm.GAS), byte(vm.DELEGATECALL), byte(vm.STOP))
+}
+
+// storeMemory returns code writing the data into memory from offset zero in
+// words.
+func storeMemory(data []byte) []byte {
+	var code []byte
+	for offset := 0; offset < len(data); offset += 32 {
+		var word [32]byte
+		copy(word[:], data[offset:])
+		code = append(code, byte(vm.PUSH32))
+		code = append(code, word[:]...)
+		code = append(code, byte(vm.PUSH2), byte(offset>>8), byte(offset), byte(vm.MSTORE))
+	}
+	return code
+}
+
+// deploy returns initcode deploying the runtime code.
+func deploy(runtime []byte) []byte {
+	code := storeMemory(runtime)
+	return append(code, byte(vm.PUSH2), byte(len(runtime)>>8), byte(len(runtime)), byte(vm.PUSH1), 0, byte(vm.RETURN))
+}
+
+// factoryCode returns the code of a factory creating the child from the
+// initcode with the value, storing its address into slot 0 and, with call
+// set, calling it afterwards.
+func factoryCode(initcode []byte, call bool) []byte {
+	code := storeMemory(initcode)
+	code = append(code,
+		byte(vm.PUSH2), byte(len(initcode)>>8), byte(len(initcode)),
+		byte(vm.PUSH1), 0,
+		byte(vm.PUSH2), byte(value>>8), byte(value&0xff),
+		byte(vm.CREATE),
+		byte(vm.DUP1), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+	)
+	if call {
+		code = append(code,
+			byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0,
+			byte(vm.DUP6), byte(vm.GAS), byte(vm.CALL), byte(vm.POP),
+		)
+	}
+	return append(code, byte(vm.STOP))
+}
+
+// contract returns a pre-existing contract with the code, the balance and
+// slot 0 set to one.
+func contract(code []byte, balance int64) types.Account {
+	return types.Account{
+		Balance: big.NewInt(balance),
+		Nonce:   1,
+		Code:    code,
+		Storage: map[common.Hash]common.Hash{{}: {0x01}},
+	}
+}
+
+// refunded returns the gas used by a transaction consuming gas before the
+// refunds of a SELFDESTRUCT and, with clears set, of clearing a slot. Before
+// London the SELFDESTRUCT refund adds to the clearing refund and both are
+// capped at half the gas; EIP-3529 removes the former and caps the latter at
+// a fifth.
+func refunded(fork string, gas uint64, clears bool) uint64 {
+	if forks.Since(fork, "London") {
+		if !clears {
+			return gas
+		}
+		return gas - min(params.SstoreClearsScheduleRefundEIP3529, gas/params.RefundQuotientEIP3529)
+	}
+	refund := params.SelfdestructRefundGas
+	if clears {
+		refund += params.SstoreClearsScheduleRefundEIP2200
+	}
+	return gas - min(refund, gas/params.RefundQuotient)
+}
+
+// existingCase is a call to the victim with the code and the value, which
+// sends it to the target, the beneficiary or the fresh account, by
+// SELFDESTRUCT. Before Cancun the victim is
+// deleted, from Cancun on EIP-6780 only moves its balance and keeps its code
+// and storage. The gas consumed, unchecked if zero, is reduced by the refunds
+// of the fork; with clears the code clears slot 0 first.
+func existingCase(name, description string, target common.Address, code []byte, gas uint64, clears bool, extra types.GenesisAlloc) testCase {
+	pre := types.GenesisAlloc{
+		victim:      contract(code, value),
+		beneficiary: {Balance: big.NewInt(initialBalance)},
+	}
+	for addr, account := range extra {
+		pre[addr] = account
+	}
+	return testCase{
+		name: name, description: description, pre: pre, to: victim,
+		expect: func(fork string) *outcome {
+			want := &outcome{accounts: map[common.Address]wantAccount{
+				target: {balance: value},
+			}}
+			if target == beneficiary {
+				want.accounts[target] = wantAccount{balance: initialBalance + value}
+			}
+			if gas != 0 {
+				want.gasUsed = refunded(fork, gas, clears)
+			}
+			switch {
+			case forks.Since(fork, "Cancun") && clears:
+				want.accounts[victim] = wantAccount{code: true}
+			case forks.Since(fork, "Cancun"):
+				want.accounts[victim] = wantAccount{code: true, slot: common.Hash{0x01}}
+			default:
+				want.accounts[victim] = wantAccount{absent: true}
+			}
+			return want
+		},
+	}
+}
+
+// sameTxCase is a call to a factory creating the child from the initcode
+// with the value, optionally calling it, where the child destructs itself in
+// favor of the beneficiary. A contract created in the same transaction is
+// deleted by SELFDESTRUCT on every fork.
+func sameTxCase(name, description string, initcode []byte, call bool, extra types.GenesisAlloc) testCase {
+	pre := types.GenesisAlloc{
+		factory:     {Balance: big.NewInt(value), Nonce: 1, Code: factoryCode(initcode, call)},
+		beneficiary: {Balance: big.NewInt(initialBalance)},
+	}
+	for addr, account := range extra {
+		pre[addr] = account
+	}
+	return testCase{
+		name: name, description: description, pre: pre, to: factory,
+		expect: func(fork string) *outcome {
+			return &outcome{accounts: map[common.Address]wantAccount{
+				factory:     {balance: 0, code: true, slot: common.BytesToHash(child.Bytes())},
+				child:       {absent: true},
+				beneficiary: {balance: initialBalance + value},
+			}}
+		},
+	}
+}
+
+// Gas costs of the calls to the victim, all of whose beneficiaries are cold.
+var (
+	pushGas         = vm.GasFastestStep
+	selfdestructGas = params.SelfdestructGasEIP150 + params.ColdAccountAccessCostEIP2929
+)
+
+func cases() []testCase {
+	// The victim of the refund vector clears slot 0 before it destructs.
+	clearing := append([]byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.SSTORE)}, selfdestruct(beneficiary)...)
+	clearingGas := params.TxGas + 3*pushGas + params.SstoreResetGasEIP2200 + selfdestructGas
+
+	// The library destructing in favor of the beneficiary.
+	libraryAlloc := types.GenesisAlloc{library: {Balance: new(big.Int), Nonce: 1, Code: selfdestruct(beneficiary)}}
+
+	selfTarget := func(fork string) *outcome {
+		// SELFDESTRUCT to itself burns the balance of a deleted account
+		// and leaves that of a kept one.
+		if forks.Since(fork, "Cancun") {
+			return &outcome{accounts: map[common.Address]wantAccount{
+				victim: {balance: value, code: true, slot: common.Hash{0x01}},
+			}}
+		}
+		return &outcome{accounts: map[common.Address]wantAccount{victim: {absent: true}}}
+	}
+
+	return []testCase{
+		// A contract existing before the transaction.
+		existingCase("existing", "SELFDESTRUCT of a contract created before the transaction, deleting it before Cancun and only moving its balance from Cancun.",
+			beneficiary, selfdestruct(beneficiary), params.TxGas+pushGas+selfdestructGas, false, nil),
+		existingCase("existing_to_new_account", "SELFDESTRUCT of an existing contract in favor of an account that does not exist, charging its creation.",
+			fresh, selfdestruct(fresh), params.TxGas+pushGas+selfdestructGas+params.CreateBySelfdestructGas, false, nil),
+		{
+			name: "existing_to_self", description: "SELFDESTRUCT of an existing contract with itself as the beneficiary, burning its balance before Cancun and keeping it from Cancun.",
+			pre: types.GenesisAlloc{victim: contract(selfdestruct(common.Address{}), value)}, to: victim, expect: selfTarget,
+		},
+
+		// The refund of clearing a slot pending on SELFDESTRUCT.
+		existingCase("existing_with_pending_refund", "SELFDESTRUCT after clearing a storage slot, which refunds gas, with the SELFDESTRUCT refund until Berlin.",
+			beneficiary, clearing, clearingGas, true, nil),
+
+		// A contract created in the same transaction.
+		sameTxCase("same_tx_in_initcode", "SELFDESTRUCT in the initcode of a contract created with a balance, deleting it on every fork.",
+			selfdestruct(beneficiary), false, nil),
+		sameTxCase("same_tx_after_creation", "SELFDESTRUCT of a contract called after its creation in the same transaction, deleting it on every fork.",
+			deploy(selfdestruct(beneficiary)), true, nil),
+		{
+			name: "same_tx_to_self", description: "SELFDESTRUCT in the initcode of a contract with itself as the beneficiary, deleting it and burning its balance on every fork.",
+			pre: types.GenesisAlloc{factory: {Balance: big.NewInt(value), Nonce: 1, Code: factoryCode(selfdestruct(common.Address{}), false)}}, to: factory,
+			expect: func(fork string) *outcome {
+				return &outcome{accounts: map[common.Address]wantAccount{
+					factory: {balance: 0, code: true, slot: common.BytesToHash(child.Bytes())},
+					child:   {absent: true},
+				}}
+			},
+		},
+
+		// SELFDESTRUCT executed by DELEGATECALL destructs the caller.
+		existingCase("delegatecall_existing", "SELFDESTRUCT in a library called by DELEGATECALL from an existing contract, which is the one destructed.",
+			beneficiary, delegate(), 0, false, libraryAlloc).withAccount(library, wantAccount{code: true}),
+		sameTxCase("delegatecall_same_tx", "SELFDESTRUCT in a library called by DELEGATECALL from a contract created in the same transaction, deleting the caller on every fork.",
+			deploy(delegate()), true, libraryAlloc).withAccount(library, wantAccount{code: true}),
+	}
+}
+
+// withAccount adds an expected account to every fork.
+func (c testCase) withAccount(addr common.Address, want wantAccount) testCase {
+	expect := c.expect
+	c.expect = func(fork string) *outcome {
+		o := expect(fork)
+		o.accounts[addr] = want
+		return o
+	}
+	return c
+}
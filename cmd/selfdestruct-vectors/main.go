@@ -0,0 +1,150 @@
+// selfdestruct-vectors generates vectors of the SELFDESTRUCT edge cases
+// across forks in the format of the state_tests fixtures of the execution
+// spec tests, using go-ethereum as the reference implementation.
+//
+// Usage:
+//
+//	go run ./cmd/selfdestruct-vectors [--forks Berlin,London,Shanghai,Cancun,Prague,Osaka] [--output selfdestruct_vectors.json]
+//
+// The vectors destruct contracts which existed before the transaction, which
+// EIP-6780 keeps from Cancun on, only moving their balance, and contracts
+// created in the same transaction, which are deleted on every fork, both from
+// their initcode and when called after their creation. Further vectors make
+// the contract its own beneficiary, burning the balance of a deleted account,
+// send the balance to an account which does not exist yet, destruct after
+// clearing a storage slot, whose refund the SELFDESTRUCT refund adds to until
+// Berlin, and execute SELFDESTRUCT in a library called by DELEGATECALL, which
+// destructs the caller.
+//
+// Every vector is filled for all forks, using legacy transactions so that
+// forks before London are covered, and the post-states of the forks before
+// and after Cancun show both semantics. The expected outcome on each fork,
+// the existence, balance, code and storage of the accounts and, where the
+// gas is the point of the vector, the gas used, is computed from the EIPs
+// and checked against go-ethereum before anything is written.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// The parameters of the test environment and transactions.
+const (
+	baseFee  = 7
+	gasPrice = 10
+	gasLimit = 200000
+)
+
+var (
+	chainID  = big.NewInt(1)
+	coinbase = common.HexToAddress("0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba")
+
+	senderKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	sender       = crypto.PubkeyToAddress(senderKey.PublicKey)
+)
+
+func main() {
+	var (
+		forkList = flag.String("forks", "Berlin,London,Shanghai,Cancun,Prague,Osaka", "comma separated forks the vectors are filled for")
+		output   = flag.String("output", "selfdestruct_vectors.json", "file the fixtures are written to")
+	)
+	flag.Parse()
+	forkNames := strings.Split(*forkList, ",")
+	for _, fork := range forkNames {
+		config, _, err := tests.GetChainConfig(fork)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if _, err := forks.Index(fork); err != nil {
+			fatalf("%v", err)
+		}
+		if !config.IsBerlin(new(big.Int)) {
+			fatalf("fork %s predates the access list gas costs of the vectors", fork)
+		}
+	}
+	fixtures := make(map[string]*statetest.Fixture)
+	for _, c := range cases() {
+		f, err := fill(&c, forkNames)
+		if err != nil {
+			fatalf("case %s: %v", c.name, err)
+		}
+		fixtures["selfdestruct_vectors/"+c.name] = f
+	}
+	data, err := json.MarshalIndent(fixtures, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d fixtures to %s on %d forks\n", len(fixtures), *output, len(forkNames))
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// fill builds the fixture of a test case, executes it on each fork and
+// verifies the expectations.
+func fill(c *testCase, forkNames []string) (*statetest.Fixture, error) {
+	pre := types.GenesisAlloc{
+		sender: {Balance: big.NewInt(1e18)},
+	}
+	for addr, account := range c.pre {
+		pre[addr] = account
+	}
+	tx, err := types.SignNewTx(senderKey, types.LatestSignerForChainID(chainID), &types.LegacyTx{
+		GasPrice: big.NewInt(gasPrice),
+		Gas:      gasLimit,
+		To:       &c.to,
+	})
+	if err != nil {
+		return nil, err
+	}
+	env := statetest.DefaultEnv(coinbase, common.Hash{0x5d, 0x1f}, baseFee)
+	f, err := statetest.New(env, pre, tx, senderKey, forkNames)
+	if err != nil {
+		return nil, err
+	}
+	f.Info = statetest.Info("selfdestruct-vectors", "handcrafted", c.description)
+	err = f.Fill(func(fork string, r *statetest.Result) error {
+		return c.check(c.expect(fork), r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// check verifies the outcome of the transaction on a fork against the
+// expected one and records the touched accounts.
+func (c *testCase) check(want *outcome, r *statetest.Result) error {
+	if want.gasUsed != 0 && r.GasUsed != want.gasUsed {
+		return fmt.Errorf("gas used %d, expected %d", r.GasUsed, want.gasUsed)
+	}
+	touched := []common.Address{sender, coinbase}
+	for addr := range c.pre {
+		touched = append(touched, addr)
+	}
+	for addr, account := range want.accounts {
+		if err := statetest.CheckAccount(r.State, addr, account.account()); err != nil {
+			return err
+		}
+		touched = append(touched, addr)
+	}
+	r.Post.State = statetest.DumpState(r.State, touched, common.Hash{})
+	return nil
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Corruptions of an authorization signature.
+const (
+	corruptHighS  = "high-s"   // s replaced by n - s, with the y parity flipped to match
+	corruptParity = "parity-2" // y parity out of range
+	corruptRZero  = "r-zero"   // r of zero
+	corruptSZero  = "s-zero"   // s of zero
+)
+
+var (
+	// target and target2 are the delegation targets. Their code stores 1,
+	// respectively 2, into slot 0 of the account executing it.
+	target  = common.HexToAddress("0x0000000000000000000000000000000000007702")
+	target2 = common.HexToAddress("0x0000000000000000000000000000000000017702")
+
+	// sink is an empty account receiving the transactions which are not meant
+	// to execute delegated code.
+	sink = common.HexToAddress("0x000000000000000000000000000000000000dead")
+
+	// ecrecover is the first precompile.
+	ecrecover = common.BytesToAddress([]byte{1})
+)
+
+// storeCode returns the code storing value into slot 0.
+func storeCode(value byte) []byte {
+	return []byte{byte(vm.PUSH1), value, byte(vm.PUSH1), 0, byte(vm.SSTORE), byte(vm.STOP)}
+}
+
+// storeGas is the gas executing storeCode on an empty slot costs: two pushes
+// and a cold SSTORE setting a zero slot.
+const storeGas = 2*3 + params.ColdSloadCostEIP2929 + params.SstoreSetGasEIP2200
+
+// authSpec describes an authorization of a test case.
+type authSpec struct {
+	signer  int      // index of the authority key, or -1 for the sender
+	chainID *big.Int // nil for the chain id of the test
+	address common.Address
+	nonce   uint64
+	corrupt string // corruption applied to the signature
+}
+
+// wantAccount is the expected post-state of an account. Accounts which must
+// not exist have no entry in the post-state.
+type wantAccount struct {
+	absent  bool
+	code    []byte
+	nonce   uint64
+	storage map[common.Hash]common.Hash
+}
+
+// account returns the expected post-state in the form checked by statetest.
+// Slot 0 is the only one the vectors write.
+func (w wantAccount) account() statetest.Account {
+	code := w.code
+	if code == nil {
+		code = []byte{}
+	}
+	return statetest.Account{
+		Absent:  w.absent,
+		Nonce:   &w.nonce,
+		Code:    code,
+		Storage: map[common.Hash]common.Hash{{}: w.storage[common.Hash{}]},
+	}
+}
+
+// testCase is a set code transaction together with its expected outcome.
+type testCase struct {
+	name        string
+	description string
+	auths       []authSpec
+	pre         types.GenesisAlloc // accounts besides the sender and the targets
+	to          common.Address
+
+	// The expected gas: the intrinsic gas of the transaction and its
+	// authorizations, plus execGas, or all of the gas limit if the execution
+	// halts, less the refund of refunds authorizations of existing accounts,
+	// capped at a fifth of the gas used.
+	refunds   int
+	execGas   uint64
+	halts     bool
+	exception string
+
+	want map[common.Address]wantAccount
+}
+
+// delegated returns the expected state of an account delegated to addr.
+func delegated(addr common.Address, nonce uint64, storage ...byte) wantAccount {
+	w := wantAccount{code: types.AddressToDelegation(addr), nonce: nonce}
+	if len(storage) > 0 {
+		w.storage = map[common.Hash]common.Hash{{}: common.BytesToHash(storage)}
+	}
+	return w
+}
+
+// existing returns a pre-state account of authority i.
+func existing(i int, code []byte, nonce uint64) types.GenesisAlloc {
+	return types.GenesisAlloc{authority(i): {Balance: big.NewInt(params.Ether), Code: code, Nonce: nonce}}
+}
+
+// cases returns the handcrafted test cases.
+func cases() []testCase {
+	var (
+		a0     = authority(0)
+		a1     = authority(1)
+		absent = wantAccount{absent: true}
+	)
+	return []testCase{
+		{
+			name:        "valid_single",
+			description: "a new account delegates to the target and the transaction calls it",
+			auths:       []authSpec{{signer: 0, address: target}},
+			to:          a0, execGas: storeGas,
+			want: map[common.Address]wantAccount{a0: delegated(target, 1, 1)},
+		},
+		{
+			name:        "chain_id_zero",
+			description: "an authorization with chain id 0 is valid on every chain",
+			auths:       []authSpec{{signer: 0, chainID: new(big.Int), address: target}},
+			to:          a0, execGas: storeGas,
+			want: map[common.Address]wantAccount{a0: delegated(target, 1, 1)},
+		},
+		{
+			name:        "wrong_chain_id",
+			description: "an authorization for another chain is skipped",
+			auths:       []authSpec{{signer: 0, chainID: big.NewInt(2), address: target}},
+			to:          a0,
+			want:        map[common.Address]wantAccount{a0: absent},
+		},
+		{
+			name:        "nonce_too_high",
+			description: "an authorization with a nonce ahead of the account is skipped",
+			auths:       []authSpec{{signer: 0, address: target, nonce: 1}},
+			to:          a0,
+			want:        map[common.Address]wantAccount{a0: absent},
+		},
+		{
+			name:        "nonce_max",
+			description: "an authorization with the nonce 2^64-1 is skipped, the nonce could not be incremented",
+			auths:       []authSpec{{signer: 0, address: target, nonce: math.MaxUint64}},
+			to:          a0,
+			want:        map[common.Address]wantAccount{a0: absent},
+		},
+		{
+			name:        "existing_authority_refund_capped",
+			description: "the refund of an existing authority is capped at a fifth of the gas used",
+			auths:       []authSpec{{signer: 1, address: target}},
+			pre:         existing(1, nil, 0),
+			to:          sink, refunds: 1,
+			want: map[common.Address]wantAccount{a1: delegated(target, 1)},
+		},
+		{
+			name:        "existing_authority_call",
+			description: "an existing account delegates to the target and the transaction calls it",
+			auths:       []authSpec{{signer: 1, address: target}},
+			pre:         existing(1, nil, 0),
+			to:          a1, refunds: 1, execGas: storeGas,
+			want: map[common.Address]wantAccount{a1: delegated(target, 1, 1)},
+		},
+		{
+			name:        "self_sponsored",
+			description: "the sender authorizes with its nonce after the increment of the transaction",
+			auths:       []authSpec{{signer: -1, address: target, nonce: 1}},
+			to:          sender, refunds: 1, execGas: storeGas,
+			want: map[common.Address]wantAccount{sender: delegated(target, 2, 1)},
+		},
+		{
+			name:        "self_sponsored_transaction_nonce",
+			description: "the sender authorizing with the nonce of the transaction is skipped",
+			auths:       []authSpec{{signer: -1, address: target}},
+			to:          sink,
+			want:        map[common.Address]wantAccount{sender: {nonce: 1}},
+		},
+		{
+			name:        "self_delegation",
+			description: "an account delegating to itself executes the designation, halting on the 0xef byte",
+			auths:       []authSpec{{signer: 0, address: a0}},
+			to:          a0, halts: true,
+			want: map[common.Address]wantAccount{a0: delegated(a0, 1)},
+		},
+		{
+			name:        "self_delegation_no_call",
+			description: "an account delegating to itself keeps the designation",
+			auths:       []authSpec{{signer: 0, address: a0}},
+			to:          sink,
+			want:        map[common.Address]wantAccount{a0: delegated(a0, 1)},
+		},
+		{
+			name:        "delegation_to_precompile",
+			description: "a delegation to a precompile executes its empty code, not the precompile",
+			auths:       []authSpec{{signer: 0, address: ecrecover}},
+			to:          a0,
+			want:        map[common.Address]wantAccount{a0: delegated(ecrecover, 1)},
+		},
+		{
+			name:        "clear_delegation",
+			description: "a delegation to the zero address removes the designation",
+			auths:       []authSpec{{signer: 1, address: common.Address{}, nonce: 1}},
+			pre:         existing(1, types.AddressToDelegation(target), 1),
+			to:          sink, refunds: 1,
+			want: map[common.Address]wantAccount{a1: {nonce: 2}},
+		},
+		{
+			name:        "redelegate",
+			description: "a delegated account delegates to another target",
+			auths:       []authSpec{{signer: 1, address: target2, nonce: 1}},
+			pre:         existing(1, types.AddressToDelegation(target), 1),
+			to:          a1, refunds: 1, execGas: storeGas,
+			want: map[common.Address]wantAccount{a1: delegated(target2, 2, 2)},
+		},
+		{
+			name:        "authority_with_code",
+			description: "an account with code other than a designation cannot delegate",
+			auths:       []authSpec{{signer: 1, address: target}},
+			pre:         existing(1, []byte{byte(vm.STOP)}, 0),
+			to:          sink,
+			want:        map[common.Address]wantAccount{a1: {code: []byte{byte(vm.STOP)}}},
+		},
+		{
+			name:        "duplicate_authority",
+			description: "the last of two authorizations of an account wins, the second one is refunded",
+			auths:       []authSpec{{signer: 0, address: target}, {signer: 0, address: target2, nonce: 1}},
+			to:          a0, refunds: 1, execGas: storeGas,
+			want: map[common.Address]wantAccount{a0: delegated(target2, 2, 2)},
+		},
+		{
+			name:        "duplicate_authority_same_nonce",
+			description: "a second authorization reusing the nonce of the first is skipped",
+			auths:       []authSpec{{signer: 0, address: target}, {signer: 0, address: target2}},
+			to:          a0, execGas: storeGas,
+			want: map[common.Address]wantAccount{a0: delegated(target, 1, 1)},
+		},
+		{
+			name:        "signature_high_s",
+			description: "an authorization with s above n/2 is skipped",
+			auths:       []authSpec{{signer: 0, address: target, corrupt: corruptHighS}},
+			to:          a0,
+			want:        map[common.Address]wantAccount{a0: absent},
+		},
+		{
+			name:        "signature_parity_2",
+			description: "an authorization with a y parity other than 0 and 1 is skipped",
+			auths:       []authSpec{{signer: 0, address: target, corrupt: corruptParity}},
+			to:          a0,
+			want:        map[common.Address]wantAccount{a0: absent},
+		},
+		{
+			name:        "signature_r_zero",
+			description: "an authorization with r of zero is skipped",
+			auths:       []authSpec{{signer: 0, address: target, corrupt: corruptRZero}},
+			to:          a0,
+			want:        map[common.Address]wantAccount{a0: absent},
+		},
+		{
+			name:        "signature_s_zero",
+			description: "an authorization with s of zero is skipped",
+			auths:       []authSpec{{signer: 0, address: target, corrupt: corruptSZero}},
+			to:          a0,
+			want:        map[common.Address]wantAccount{a0: absent},
+		},
+		{
+			name:        "valid_and_invalid",
+			description: "an invalid authorization does not affect the valid one before it",
+			auths:       []authSpec{{signer: 0, address: target}, {signer: 1, chainID: big.NewInt(2), address: target}},
+			to:          a0, execGas: storeGas,
+			want: map[common.Address]wantAccount{a0: delegated(target, 1, 1), a1: absent},
+		},
+		{
+			name:        "empty_authorization_list",
+			description: "a set code transaction without authorizations is invalid",
+			auths:       []authSpec{},
+			to:          sink, exception: "TransactionException.TYPE_4_EMPTY_AUTHORIZATION_LIST",
+			want: map[common.Address]wantAccount{sender: {}},
+		},
+	}
+}
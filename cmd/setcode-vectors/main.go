@@ -0,0 +1,220 @@
+// setcode-vectors generates EIP-7702 set code transaction vectors in the format
+// of the state_tests fixtures of the execution spec tests, using go-ethereum
+// as the reference implementation.
+//
+// Usage:
+//
+//	go run ./cmd/setcode-vectors [--forks Prague,Osaka] [--output setcode_vectors.json]
+//
+// Every vector is a single set code transaction, covering valid authorizations
+// and the ones which must be skipped: a chain id other than zero or the one of
+// the chain, a nonce not matching the authority's, a nonce which cannot be
+// incremented, an authority with code, and malformed signatures (s above n/2,
+// a y parity other than 0 and 1, r or s of zero). Further vectors cover
+// sponsored and self-sponsored delegations, delegations to the authority
+// itself and to a precompile, clearing and replacing a designation, and
+// repeated authorities.
+//
+// The expected designations, nonces and storage of the authorities and the
+// expected gas used are declared with each vector: the intrinsic cost of the
+// authorizations, the refund of authorities which already existed, capped at
+// a fifth of the gas used, and the execution of the delegated code. They are
+// checked against go-ethereum before anything is written, and each post-state
+// entry lists the resulting accounts alongside its state root.
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/holiman/uint256"
+)
+
+// The parameters of the test environment and transactions.
+const (
+	gasLimit = 200000
+	baseFee  = 7
+	feeCap   = 10
+	tipCap   = 1
+)
+
+var (
+	chainID  = big.NewInt(1)
+	coinbase = common.HexToAddress("0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba")
+
+	senderKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	sender       = crypto.PubkeyToAddress(senderKey.PublicKey)
+)
+
+func main() {
+	var (
+		forkList = flag.String("forks", "Prague,Osaka", "comma separated forks the vectors are filled for")
+		output   = flag.String("output", "setcode_vectors.json", "file the fixtures are written to")
+	)
+	flag.Parse()
+	forks := strings.Split(*forkList, ",")
+	for _, fork := range forks {
+		config, _, err := tests.GetChainConfig(fork)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if !config.IsPrague(new(big.Int), 0) {
+			fatalf("fork %s predates EIP-7702", fork)
+		}
+	}
+	fixtures := make(map[string]*statetest.Fixture)
+	for _, c := range cases() {
+		f, err := fill(c, forks)
+		if err != nil {
+			fatalf("case %s: %v", c.name, err)
+		}
+		fixtures["setcode_vectors/"+c.name] = f
+	}
+	data, err := json.MarshalIndent(fixtures, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d fixtures to %s on %d forks\n", len(fixtures), *output, len(forks))
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// authorityKey returns the key of authority i, or the sender key for -1.
+func authorityKey(i int) *ecdsa.PrivateKey {
+	if i < 0 {
+		return senderKey
+	}
+	key, _ := crypto.ToECDSA(common.LeftPadBytes([]byte{byte(i + 1)}, 32))
+	return key
+}
+
+// authority returns the address of authority i, or the sender for -1.
+func authority(i int) common.Address {
+	return crypto.PubkeyToAddress(authorityKey(i).PublicKey)
+}
+
+// sign signs the authorization and applies its corruption.
+func (a authSpec) sign() (types.SetCodeAuthorization, error) {
+	id := a.chainID
+	if id == nil {
+		id = chainID
+	}
+	auth, err := types.SignSetCode(authorityKey(a.signer), types.SetCodeAuthorization{
+		ChainID: *uint256.MustFromBig(id),
+		Address: a.address,
+		Nonce:   a.nonce,
+	})
+	if err != nil {
+		return auth, err
+	}
+	switch a.corrupt {
+	case "":
+	case corruptHighS:
+		auth.S.Sub(uint256.MustFromBig(crypto.S256().Params().N), &auth.S)
+		auth.V ^= 1
+	case corruptParity:
+		auth.V = 2
+	case corruptRZero:
+		auth.R.Clear()
+	case corruptSZero:
+		auth.S.Clear()
+	default:
+		return auth, fmt.Errorf("unknown corruption %q", a.corrupt)
+	}
+	return auth, nil
+}
+
+// expectedGas returns the gas the transaction of the case is expected to use.
+func (c *testCase) expectedGas() uint64 {
+	used := params.TxGas + params.CallNewAccountGas*uint64(len(c.auths)) + c.execGas
+	if c.halts {
+		used = gasLimit
+	}
+	refund := uint64(c.refunds) * (params.CallNewAccountGas - params.TxAuthTupleGas)
+	return used - min(refund, used/params.RefundQuotientEIP3529)
+}
+
+// fill builds the fixture of a test case, executes it on each fork and
+// verifies the expectations.
+func fill(c testCase, forks []string) (*statetest.Fixture, error) {
+	pre := types.GenesisAlloc{
+		sender:  {Balance: big.NewInt(params.Ether)},
+		target:  {Balance: new(big.Int), Code: storeCode(1)},
+		target2: {Balance: new(big.Int), Code: storeCode(2)},
+	}
+	for addr, account := range c.pre {
+		pre[addr] = account
+	}
+	auths := make([]types.SetCodeAuthorization, len(c.auths))
+	for i, spec := range c.auths {
+		auth, err := spec.sign()
+		if err != nil {
+			return nil, err
+		}
+		auths[i] = auth
+	}
+	tx, err := types.SignNewTx(senderKey, types.LatestSignerForChainID(chainID), &types.SetCodeTx{
+		ChainID:   uint256.MustFromBig(chainID),
+		GasTipCap: uint256.NewInt(tipCap),
+		GasFeeCap: uint256.NewInt(feeCap),
+		Gas:       gasLimit,
+		To:        c.to,
+		Value:     new(uint256.Int),
+		AuthList:  auths,
+	})
+	if err != nil {
+		return nil, err
+	}
+	env := statetest.DefaultEnv(coinbase, common.Hash{0x77, 0x02}, baseFee)
+	f, err := statetest.New(env, pre, tx, senderKey, forks)
+	if err != nil {
+		return nil, err
+	}
+	f.Info = statetest.Info("setcode-vectors", "handcrafted", c.description)
+	for _, post := range f.Post {
+		post[0].ExpectException = c.exception
+	}
+	if err := f.Fill(c.check); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// check verifies the outcome of the transaction on a fork against the
+// expectations of the case and records the touched accounts.
+func (c *testCase) check(fork string, r *statetest.Result) error {
+	if want := c.expectedGas(); c.exception == "" && r.GasUsed != want {
+		return fmt.Errorf("gas used %d, expected %d", r.GasUsed, want)
+	}
+	for addr, want := range c.want {
+		if err := statetest.CheckAccount(r.State, addr, want.account()); err != nil {
+			return err
+		}
+	}
+	touched := []common.Address{sender, coinbase, c.to, target, target2}
+	for addr := range c.pre {
+		touched = append(touched, addr)
+	}
+	for addr := range c.want {
+		touched = append(touched, addr)
+	}
+	r.Post.State = statetest.DumpState(r.State, touched, common.Hash{})
+	return nil
+}
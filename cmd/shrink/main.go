@@ -0,0 +1,111 @@
+// shrink minimizes a state test on which EVM implementations diverge into a
+// small reproducer of the divergence.
+//
+// Usage:
+//
+//	go run ./cmd/shrink --backend go --backend eels [--test NAME] [--fork Prague] [--output shrunk.json] test.json
+//
+// Backends are given as for evm-fuzz: go for go-ethereum in process, NAME for
+// the statetest command of a known client (eels, geth, besu) or
+// NAME=COMMAND. The first post state of the test (or of the test named by
+// --test, on the fork given by --fork) on which the backends diverge is
+// selected, and the transaction variants it selects are the only ones kept.
+//
+// The test is then reduced step by step: accounts of the pre state are
+// removed, storage slots are zeroed, chunks of code and calldata are cut out,
+// from all of it down to single bytes, and the access list and value of the
+// transaction are dropped. After each reduction the test is executed on all
+// backends and the reduction is only kept if they still diverge with the
+// same signature (the backends and the opcode and field at which their
+// traces first diverge, see evmfuzz.Finding), or with any signature given
+// --any. The reductions are repeated until none is kept any more.
+//
+// The post state of the minimized test is recomputed with go-ethereum, and
+// its info records the signature and the state root of every backend.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/evmfuzz"
+	"github.com/ethereum/execution-specs/pkg/flags"
+	"github.com/ethereum/execution-specs/pkg/shrink"
+)
+
+func main() {
+	var (
+		backendDefs  flags.Strings
+		testName     = flag.String("test", "", "name of the test to shrink (default the first diverging one)")
+		fork         = flag.String("fork", "", "fork of the post state to shrink (default the first diverging one)")
+		anySignature = flag.Bool("any", false, "keep reductions changing the signature of the divergence")
+		output       = flag.String("output", "shrunk.json", "file the minimized test is written to")
+		timeout      = flag.Duration("timeout", time.Minute, "timeout of a single backend invocation")
+		verbose      = flag.Bool("verbose", false, "print every reduction kept")
+	)
+	flag.Var(&backendDefs, "backend", "backend as go, NAME or NAME=COMMAND (repeatable, default go and eels)")
+	flag.Parse()
+
+	if len(backendDefs) == 0 {
+		backendDefs = flags.Strings{"go", "eels"}
+	}
+	if len(backendDefs) < 2 {
+		fatalf("at least two backends are needed")
+	}
+	var backends []evmfuzz.Backend
+	for _, def := range backendDefs {
+		b, err := evmfuzz.ParseBackend(def, *timeout)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		backends = append(backends, b)
+	}
+	if flag.NArg() != 1 {
+		fatalf("expected a single state test file")
+	}
+	data, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(data, &file); err != nil {
+		fatalf("%s: %v", flag.Arg(0), err)
+	}
+	s := &shrink.Shrinker{Backends: backends}
+	if *verbose {
+		s.Log = func(step string, c *shrink.Case) { fmt.Printf("%s: %s\n", step, c.Size()) }
+	}
+	name, pos, finding, err := s.Select(file, *testName, *fork)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("shrinking %s, post state %d of %s: %s\n        %s\n", name, pos, s.Current.Fork(), finding.Signature, finding.Detail)
+	fmt.Printf("from %s\n", s.Current.Size())
+	if !*anySignature {
+		s.Signature = finding.Signature
+	}
+	s.Shrink()
+	fmt.Printf("to   %s\n", s.Current.Size())
+	fmt.Printf("%d reductions kept of %d tried\n", s.Kept, s.Runs)
+
+	result, err := s.Reproducer()
+	if err != nil {
+		fatalf("%v", err)
+	}
+	out, err := json.MarshalIndent(map[string]json.RawMessage{name: result}, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(out, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("reproducer written to %s\n", *output)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Exceptions of the transaction vectors.
+const (
+	exceptionVRS          = "TransactionException.INVALID_SIGNATURE_VRS"
+	exceptionChainID      = "TransactionException.INVALID_CHAINID"
+	exceptionType1PreFork = "TransactionException.TYPE_1_TX_PRE_FORK"
+	exceptionType2PreFork = "TransactionException.TYPE_2_TX_PRE_FORK"
+)
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+	secp256k1Gx    = crypto.S256().Params().Gx
+
+	// maxWord is 2^256-1.
+	maxWord = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+)
+
+// Off curve x coordinates: neither 5 nor n-1 has a point on secp256k1.
+var (
+	offCurveX      = big.NewInt(5)
+	offCurveOrderX = new(big.Int).Sub(secp256k1N, common.Big1)
+)
+
+// signature is a secp256k1 signature with an Ethereum recovery id.
+type signature struct {
+	v    uint64 // 0 or 1
+	r, s *big.Int
+}
+
+// signHash signs the hash with the sender key.
+func signHash(hash []byte) signature {
+	sig, _ := crypto.Sign(hash, senderKey)
+	return signature{uint64(sig[64]), new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])}
+}
+
+// messageHash returns the first hash of the message series whose signature by
+// the sender has the given recovery id.
+func messageHash(v uint64) []byte {
+	for i := 0; ; i++ {
+		hash := crypto.Keccak256([]byte("execution-specs/" + strconv.Itoa(i)))
+		if signHash(hash).v == v {
+			return hash
+		}
+	}
+}
+
+// highS returns the other valid signature of the same message: s mirrored
+// around n/2 with the recovery id flipped.
+func (sig signature) highS() signature {
+	return signature{sig.v ^ 1, sig.r, new(big.Int).Sub(secp256k1N, sig.s)}
+}
+
+// word encodes v as a 32 byte big endian word.
+func word(v uint64) []byte {
+	return wordBig(new(big.Int).SetUint64(v))
+}
+
+func wordBig(v *big.Int) []byte {
+	return math.U256Bytes(new(big.Int).Set(v))
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+// ecrecoverCase is an input of the ecrecover precompile. Valid inputs recover
+// an address, which is the sender if signer is set. Invalid ones return no
+// output.
+type ecrecoverCase struct {
+	name        string
+	description string
+	input       []byte
+	valid       bool
+	signer      bool
+}
+
+// ecrecoverInput encodes the hash, the v word and the signature values.
+func ecrecoverInput(hash []byte, v []byte, r, s *big.Int) []byte {
+	return concat(hash, v, wordBig(r), wordBig(s))
+}
+
+func ecrecoverCases() []ecrecoverCase {
+	var (
+		hash27 = messageHash(0)
+		hash28 = messageHash(1)
+		sig27  = signHash(hash27)
+		sig28  = signHash(hash28)
+		high   = sig27.highS()
+		valid  = ecrecoverInput(hash27, word(27), sig27.r, sig27.s)
+	)
+	wideV := word(27)
+	wideV[0] = 1
+	return []ecrecoverCase{
+		{"valid_v27", "signature with recovery id 0", valid, true, true},
+		{"valid_v28", "signature with recovery id 1", ecrecoverInput(hash28, word(28), sig28.r, sig28.s), true, true},
+		{"trailing_bytes", "input longer than 128 bytes, the excess is ignored", concat(valid, word(1)), true, true},
+		{"truncated", "input shorter than 128 bytes, right padded with zeros", valid[:100], true, false},
+		{"empty", "empty input, all values zero", nil, false, false},
+		{"high_s", "s above n/2, accepted unlike in transactions", ecrecoverInput(hash27, word(27+high.v), high.r, high.s), true, true},
+		{"s_half_n", "s equal to n/2", ecrecoverInput(hash27, word(27), sig27.r, secp256k1HalfN), true, false},
+		{"s_half_n_plus_1", "s one above n/2", ecrecoverInput(hash27, word(27), sig27.r, new(big.Int).Add(secp256k1HalfN, common.Big1)), true, false},
+		{"s_n_minus_1", "s equal to n-1", ecrecoverInput(hash27, word(27), sig27.r, new(big.Int).Sub(secp256k1N, common.Big1)), true, false},
+		{"s_zero", "s of zero", ecrecoverInput(hash27, word(27), sig27.r, new(big.Int)), false, false},
+		{"s_n", "s equal to the group order", ecrecoverInput(hash27, word(27), sig27.r, secp256k1N), false, false},
+		{"s_max", "s of 2^256-1", ecrecoverInput(hash27, word(27), sig27.r, maxWord), false, false},
+		{"r_one", "r of one, the x coordinate of a curve point", ecrecoverInput(hash27, word(27), common.Big1, sig27.s), true, false},
+		{"r_zero", "r of zero", ecrecoverInput(hash27, word(27), new(big.Int), sig27.s), false, false},
+		{"r_off_curve", "r without a curve point of that x coordinate", ecrecoverInput(hash27, word(27), offCurveX, sig27.s), false, false},
+		{"r_n_minus_1", "r equal to n-1, which has no curve point", ecrecoverInput(hash27, word(27), offCurveOrderX, sig27.s), false, false},
+		{"r_n", "r equal to the group order", ecrecoverInput(hash27, word(27), secp256k1N, sig27.s), false, false},
+		{"r_max", "r of 2^256-1", ecrecoverInput(hash27, word(27), maxWord, sig27.s), false, false},
+		{"v_0", "v of 0, the bare recovery id", ecrecoverInput(hash27, word(0), sig27.r, sig27.s), false, false},
+		{"v_1", "v of 1, the bare recovery id", ecrecoverInput(hash28, word(1), sig28.r, sig28.s), false, false},
+		{"v_26", "v of 26", ecrecoverInput(hash27, word(26), sig27.r, sig27.s), false, false},
+		{"v_29", "v of 29, recovery id 2", ecrecoverInput(hash27, word(29), sig27.r, sig27.s), false, false},
+		{"v_30", "v of 30, recovery id 3", ecrecoverInput(hash27, word(30), sig27.r, sig27.s), false, false},
+		{"v_37", "v of 37, the EIP-155 encoding of recovery id 0 on chain 1", ecrecoverInput(hash27, word(37), sig27.r, sig27.s), false, false},
+		{"v_high_bytes", "v of 27 with the high byte of the word set", ecrecoverInput(hash27, wideV, sig27.r, sig27.s), false, false},
+		{"v_max", "v of 2^256-1", ecrecoverInput(hash27, wordBig(maxWord), sig27.r, sig27.s), false, false},
+		{"hash_zero", "zero message hash", ecrecoverInput(make([]byte, 32), word(27), sig27.r, sig27.s), true, false},
+		{"hash_n", "message hash equal to the group order, recovering like the zero hash", ecrecoverInput(wordBig(secp256k1N), word(27), sig27.r, sig27.s), true, false},
+		{"hash_max", "message hash of 2^256-1", ecrecoverInput(wordBig(maxWord), word(27), sig27.r, sig27.s), true, false},
+		// With R = G, s = 1 and a hash of 1 the recovered point is
+		// r^-1 * (s*R - e*G), the point at infinity.
+		{"point_at_infinity", "signature recovering the point at infinity", ecrecoverInput(word(1), word(27), secp256k1Gx, common.Big1), false, false},
+	}
+}
+
+// outcome is the result of a transaction vector from a fork on: valid if the
+// exception is empty.
+type outcome struct {
+	fork      string
+	exception string
+}
+
+func at(fork, exception string) outcome {
+	return outcome{fork, exception}
+}
+
+// Outcomes shared by the transaction vectors.
+var (
+	alwaysValid   = []outcome{at("Frontier", "")}
+	neverValid    = []outcome{at("Frontier", exceptionVRS)}
+	protected     = []outcome{at("Frontier", exceptionVRS), at("EIP158", "")}
+	otherChain    = []outcome{at("Frontier", exceptionVRS), at("EIP158", exceptionChainID)}
+	frontierOnly  = []outcome{at("Frontier", ""), at("Homestead", exceptionVRS)}
+	accessListTx  = []outcome{at("Frontier", exceptionType1PreFork), at("Berlin", "")}
+	dynamicFeeTx  = []outcome{at("Frontier", exceptionType2PreFork), at("London", "")}
+	badAccessList = []outcome{at("Frontier", exceptionType1PreFork), at("Berlin", exceptionVRS)}
+	badDynamicFee = []outcome{at("Frontier", exceptionType2PreFork), at("London", exceptionVRS)}
+)
+
+// txCase is a transaction with an edge case signature, given by the raw
+// signature values, and its outcome per fork.
+type txCase struct {
+	name        string
+	description string
+	tx          *types.Transaction
+	outcomes    []outcome
+}
+
+// The parameters of the vector transactions.
+const (
+	txGas    = 21000
+	gasPrice = 10
+)
+
+var recipient = common.HexToAddress("0x0000000000000000000000000000000000000100")
+
+// legacyTx returns the unsigned legacy transaction of the given nonce.
+func legacyTx(nonce uint64) *types.LegacyTx {
+	return &types.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(gasPrice), Gas: txGas, To: &recipient, Value: new(big.Int)}
+}
+
+// withSignature returns the legacy transaction with the raw signature values.
+func withSignature(tx *types.LegacyTx, v, r, s *big.Int) *types.Transaction {
+	tx.V, tx.R, tx.S = v, r, s
+	return types.NewTx(tx)
+}
+
+// signedLegacy returns the first nonce and signature of the legacy transaction
+// signed by the signer whose recovery id matches.
+func signedLegacy(signer types.Signer, v uint64) (*types.LegacyTx, signature) {
+	for nonce := uint64(0); ; nonce++ {
+		tx := legacyTx(nonce)
+		sig := signHash(signer.Hash(types.NewTx(tx)).Bytes())
+		if sig.v == v {
+			return tx, sig
+		}
+	}
+}
+
+// eip155V returns the EIP-155 v value of a recovery id on a chain.
+func eip155V(chainID *big.Int, v uint64) *big.Int {
+	return new(big.Int).Add(new(big.Int).Lsh(chainID, 1), big.NewInt(int64(35+v)))
+}
+
+func txCases() []txCase {
+	var (
+		homestead = types.HomesteadSigner{}
+		mainnet   = types.NewEIP155Signer(big.NewInt(1))
+
+		tx27, sig27      = signedLegacy(homestead, 0)
+		tx28, sig28      = signedLegacy(homestead, 1)
+		tx37, sig37      = signedLegacy(mainnet, 0)
+		tx38, sig38      = signedLegacy(mainnet, 1)
+		high             = sig27.highS()
+		v27              = big.NewInt(27)
+		chain2           = big.NewInt(2)
+		chain2Tx, c2     = signedLegacy(types.NewEIP155Signer(chain2), 0)
+		chain110         = big.NewInt(110)
+		chain110Tx, c110 = signedLegacy(types.NewEIP155Signer(chain110), 1)
+		chainMax         = new(big.Int).SetUint64(1<<64 - 1)
+		chainMaxTx, cMax = signedLegacy(types.NewEIP155Signer(chainMax), 0)
+	)
+	copyTx := func(tx *types.LegacyTx) *types.LegacyTx {
+		cpy := *tx
+		return &cpy
+	}
+	typed := func(inner types.TxData, v uint64, r, s *big.Int) *types.Transaction {
+		v256 := new(big.Int).SetUint64(v)
+		switch inner := inner.(type) {
+		case *types.AccessListTx:
+			inner.V, inner.R, inner.S = v256, r, s
+		case *types.DynamicFeeTx:
+			inner.V, inner.R, inner.S = v256, r, s
+		}
+		return types.NewTx(inner)
+	}
+	london := types.NewLondonSigner(big.NewInt(1))
+	accessList := func() *types.AccessListTx {
+		return &types.AccessListTx{ChainID: big.NewInt(1), GasPrice: big.NewInt(gasPrice), Gas: txGas, To: &recipient, Value: new(big.Int)}
+	}
+	dynamicFee := func() *types.DynamicFeeTx {
+		return &types.DynamicFeeTx{ChainID: big.NewInt(1), GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(gasPrice), Gas: txGas, To: &recipient, Value: new(big.Int)}
+	}
+	alSig := signHash(london.Hash(types.NewTx(accessList())).Bytes())
+	dfSig := signHash(london.Hash(types.NewTx(dynamicFee())).Bytes())
+	alHigh, dfHigh := alSig.highS(), dfSig.highS()
+
+	return []txCase{
+		{"unprotected_v27", "unprotected legacy transaction with v of 27", withSignature(copyTx(tx27), v27, sig27.r, sig27.s), alwaysValid},
+		{"unprotected_v28", "unprotected legacy transaction with v of 28", withSignature(copyTx(tx28), big.NewInt(28), sig28.r, sig28.s), alwaysValid},
+		{"unprotected_high_s", "s above n/2, valid before EIP-2", withSignature(copyTx(tx27), big.NewInt(int64(27+high.v)), high.r, high.s), frontierOnly},
+		{"unprotected_s_half_n", "s equal to n/2, the largest valid since EIP-2", withSignature(copyTx(tx27), v27, sig27.r, secp256k1HalfN), alwaysValid},
+		{"unprotected_s_half_n_plus_1", "s one above n/2, valid before EIP-2", withSignature(copyTx(tx27), v27, sig27.r, new(big.Int).Add(secp256k1HalfN, common.Big1)), frontierOnly},
+		{"unprotected_s_n_minus_1", "s equal to n-1, valid before EIP-2", withSignature(copyTx(tx27), v27, sig27.r, new(big.Int).Sub(secp256k1N, common.Big1)), frontierOnly},
+		{"s_zero", "s of zero", withSignature(copyTx(tx27), v27, sig27.r, new(big.Int)), neverValid},
+		{"s_n", "s equal to the group order", withSignature(copyTx(tx27), v27, sig27.r, secp256k1N), neverValid},
+		{"r_zero", "r of zero", withSignature(copyTx(tx27), v27, new(big.Int), sig27.s), neverValid},
+		{"r_n", "r equal to the group order", withSignature(copyTx(tx27), v27, secp256k1N, sig27.s), neverValid},
+		{"r_off_curve", "r without a curve point of that x coordinate", withSignature(copyTx(tx27), v27, offCurveX, sig27.s), neverValid},
+		{"r_one", "r of one, the x coordinate of a curve point", withSignature(copyTx(tx27), v27, common.Big1, sig27.s), alwaysValid},
+		{"v_0", "v of 0, the bare recovery id", withSignature(copyTx(tx27), new(big.Int), sig27.r, sig27.s), neverValid},
+		{"v_1", "v of 1, the bare recovery id", withSignature(copyTx(tx28), common.Big1, sig28.r, sig28.s), neverValid},
+		{"v_26", "v of 26", withSignature(copyTx(tx27), big.NewInt(26), sig27.r, sig27.s), neverValid},
+		{"v_29", "v of 29, recovery id 2", withSignature(copyTx(tx27), big.NewInt(29), sig27.r, sig27.s), neverValid},
+		{"v_30", "v of 30, recovery id 3", withSignature(copyTx(tx27), big.NewInt(30), sig27.r, sig27.s), neverValid},
+		{"v_34", "v of 34, below the EIP-155 range", withSignature(copyTx(tx27), big.NewInt(34), sig27.r, sig27.s), neverValid},
+		{"eip155_v37", "EIP-155 transaction on chain 1 with recovery id 0", withSignature(copyTx(tx37), eip155V(common.Big1, 0), sig37.r, sig37.s), protected},
+		{"eip155_v38", "EIP-155 transaction on chain 1 with recovery id 1", withSignature(copyTx(tx38), eip155V(common.Big1, 1), sig38.r, sig38.s), protected},
+		{"eip155_high_s", "EIP-155 transaction on chain 1 with s above n/2", withSignature(copyTx(tx37), eip155V(common.Big1, sig37.highS().v), sig37.r, sig37.highS().s), neverValid},
+		{"eip155_wrong_parity", "EIP-155 transaction on chain 1 with the recovery id flipped, recovering another sender", withSignature(copyTx(tx37), eip155V(common.Big1, 1), sig37.r, sig37.s), protected},
+		{"eip155_chain_id_0", "EIP-155 v of 35, chain id 0", withSignature(copyTx(tx37), eip155V(new(big.Int), 0), sig37.r, sig37.s), otherChain},
+		{"eip155_chain_id_2", "EIP-155 transaction signed for chain 2", withSignature(copyTx(chain2Tx), eip155V(chain2, 0), c2.r, c2.s), otherChain},
+		{"eip155_v_256", "EIP-155 transaction signed for chain 110, the first v of two bytes", withSignature(copyTx(chain110Tx), eip155V(chain110, 1), c110.r, c110.s), otherChain},
+		{"eip155_chain_id_max_uint64", "EIP-155 transaction signed for chain 2^64-1, v overflowing 64 bits", withSignature(copyTx(chainMaxTx), eip155V(chainMax, 0), cMax.r, cMax.s), otherChain},
+		{"eip155_v_max", "v of 2^256-1", withSignature(copyTx(tx37), maxWord, sig37.r, sig37.s), otherChain},
+		{"access_list_valid", "access list transaction with y parity " + strconv.FormatUint(alSig.v, 10), typed(accessList(), alSig.v, alSig.r, alSig.s), accessListTx},
+		{"access_list_y_parity_2", "access list transaction with y parity 2", typed(accessList(), 2, alSig.r, alSig.s), badAccessList},
+		{"access_list_y_parity_27", "access list transaction with the legacy v of 27 as y parity", typed(accessList(), 27+alSig.v, alSig.r, alSig.s), badAccessList},
+		{"access_list_high_s", "access list transaction with s above n/2", typed(accessList(), alHigh.v, alHigh.r, alHigh.s), badAccessList},
+		{"dynamic_fee_valid", "dynamic fee transaction with y parity " + strconv.FormatUint(dfSig.v, 10), typed(dynamicFee(), dfSig.v, dfSig.r, dfSig.s), dynamicFeeTx},
+		{"dynamic_fee_y_parity_2", "dynamic fee transaction with y parity 2", typed(dynamicFee(), 2, dfSig.r, dfSig.s), badDynamicFee},
+		{"dynamic_fee_high_s", "dynamic fee transaction with s above n/2", typed(dynamicFee(), dfHigh.v, dfHigh.r, dfHigh.s), badDynamicFee},
+		{"dynamic_fee_s_zero", "dynamic fee transaction with s of zero", typed(dynamicFee(), dfSig.v, dfSig.r, new(big.Int)), badDynamicFee},
+	}
+}
+
+// eip155Values are the v values of the EIP-155 decoding vectors, covering the
+// unprotected values, the start of the EIP-155 range and the encoding
+// boundaries of v and the chain id.
+func eip155Values() []*big.Int {
+	values := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(27), big.NewInt(28), big.NewInt(29), big.NewInt(34), big.NewInt(35), big.NewInt(36), big.NewInt(37), big.NewInt(38), big.NewInt(255), big.NewInt(256)}
+	for _, bits := range []uint{31, 32, 63, 64, 255} {
+		chainID := new(big.Int).Sub(new(big.Int).Lsh(common.Big1, bits), common.Big1)
+		values = append(values, eip155V(chainID, 0), eip155V(chainID, 1))
+	}
+	return append(values, maxWord)
+}
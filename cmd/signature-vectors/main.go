@@ -0,0 +1,355 @@
+// signature-vectors generates secp256k1 signature edge case vectors of the
+// ecrecover precompile and of transaction signatures, using go-ethereum as the
+// reference implementation.
+//
+// Usage:
+//
+//	go run ./cmd/signature-vectors [--forks Frontier,...,Osaka] [--output signature_vectors]
+//
+// The vectors cover s above n/2 and the boundaries around it, r and s of zero,
+// of the group order and of 2^256-1, x coordinates without a curve point,
+// every v from the bare recovery ids to the EIP-155 range, v words with high
+// bytes set, message hashes at or above the group order and a signature
+// recovering the point at infinity. The transaction vectors add EIP-155
+// signatures for chain 1 and for other chains, including a chain id and v
+// overflowing 64 bits, and the y parity of access list and dynamic fee
+// transactions.
+//
+// Four files are written to the output directory:
+//
+//   - ecrecover.json, the ecrecover inputs in the format of go-ethereum's
+//     core/vm/testdata/precompiles. Invalid inputs have an empty output.
+//   - eip155.json, the decoding of v values into the chain id and y parity of
+//     EIP-155 and unprotected legacy signatures.
+//   - transaction_tests.json, the transaction vectors in the format of the
+//     transaction_tests fixtures, with the sender, hash and intrinsic gas or
+//     the exception of every fork.
+//   - state_tests.json, the ecrecover inputs as state_tests fixtures, a
+//     contract calling the precompile and storing the success flag and the
+//     recovered address. State tests sign their transaction from the secret
+//     key, so the transaction vectors have no state test counterpart.
+//
+// The validity of every vector, and whether it recovers the sender, is
+// declared with it and checked against go-ethereum, including go-ethereum's
+// transaction test runner, before anything is written.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+var (
+	senderKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	sender       = crypto.PubkeyToAddress(senderKey.PublicKey)
+
+	coinbase = common.HexToAddress("0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba")
+	caller   = common.HexToAddress("0x0000000000000000000000000000000000001000")
+)
+
+// ecrecoverGas is the gas limit of the state test transactions.
+const ecrecoverGas = 100000
+
+// callerCode copies the calldata to memory, calls ecrecover with it and
+// stores the recovered address in slot 0 and the success flag in slot 1.
+var callerCode = []byte{
+	byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATACOPY),
+	byte(vm.PUSH1), 32, byte(vm.PUSH2), 0x02, 0x00, // output at 0x200, past any input
+	byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 1,
+	byte(vm.PUSH2), 0x10, 0x00, byte(vm.CALL), // fixed gas, passing more fails before EIP-150
+	byte(vm.PUSH1), 1, byte(vm.SSTORE),
+	byte(vm.PUSH2), 0x02, 0x00, byte(vm.MLOAD), byte(vm.PUSH1), 0, byte(vm.SSTORE),
+	byte(vm.STOP),
+}
+
+// precompileVector is an ecrecover invocation in the precompile test format.
+type precompileVector struct {
+	Input       string
+	Expected    string
+	Gas         uint64
+	Name        string
+	NoBenchmark bool
+}
+
+// eip155Vector is the decoding of a legacy transaction v value. Protected
+// values carry a chain id, all valid ones a y parity.
+type eip155Vector struct {
+	V         *hexutil.Big    `json:"v"`
+	Valid     bool            `json:"valid"`
+	Protected bool            `json:"protected"`
+	ChainID   *hexutil.Big    `json:"chainId,omitempty"`
+	YParity   *hexutil.Uint64 `json:"yParity,omitempty"`
+}
+
+// txFixture is a transaction_tests fixture.
+type txFixture struct {
+	Info    map[string]string    `json:"_info"`
+	TxBytes hexutil.Bytes        `json:"txbytes"`
+	Result  map[string]*txResult `json:"result"`
+}
+
+// txResult is the outcome of a transaction vector on one fork.
+type txResult struct {
+	Hash         *common.Hash    `json:"hash,omitempty"`
+	Sender       *common.Address `json:"sender,omitempty"`
+	IntrinsicGas hexutil.Uint64  `json:"intrinsicGas"`
+	Exception    string          `json:"exception,omitempty"`
+}
+
+func main() {
+	var (
+		forkList = flag.String("forks", "Frontier,Homestead,EIP150,EIP158,Byzantium,ConstantinopleFix,Istanbul,Berlin,London,Paris,Shanghai,Cancun,Prague,Osaka", "comma separated forks the vectors are filled for")
+		output   = flag.String("output", "signature_vectors", "directory the vectors are written to")
+	)
+	flag.Parse()
+	forkNames := strings.Split(*forkList, ",")
+	for _, fork := range forkNames {
+		if _, ok := tests.Forks[fork]; !ok {
+			fatalf("unknown fork %q", fork)
+		}
+		if _, err := forks.Index(fork); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	if err := os.MkdirAll(*output, 0755); err != nil {
+		fatalf("%v", err)
+	}
+
+	var (
+		precompiles []precompileVector
+		stateTests  = make(map[string]*statetest.Fixture)
+	)
+	for _, c := range ecrecoverCases() {
+		v, err := ecrecoverVector(&c)
+		if err != nil {
+			fatalf("case %s: %v", c.name, err)
+		}
+		precompiles = append(precompiles, v)
+		f, err := fillState(&c, v, forkNames)
+		if err != nil {
+			fatalf("case %s: %v", c.name, err)
+		}
+		stateTests["signature_vectors/ecrecover_"+c.name] = f
+	}
+	var decodings []eip155Vector
+	for _, v := range eip155Values() {
+		vector, err := eip155Decoding(v)
+		if err != nil {
+			fatalf("v %d: %v", v, err)
+		}
+		decodings = append(decodings, vector)
+	}
+	txTests := make(map[string]*txFixture)
+	for _, c := range txCases() {
+		f, err := fillTx(&c, forkNames)
+		if err != nil {
+			fatalf("case %s: %v", c.name, err)
+		}
+		txTests["signature_vectors/"+c.name] = f
+	}
+
+	for name, value := range map[string]interface{}{
+		"ecrecover.json":         precompiles,
+		"eip155.json":            decodings,
+		"transaction_tests.json": txTests,
+		"state_tests.json":       stateTests,
+	} {
+		data, err := json.MarshalIndent(value, "", "    ")
+		if err != nil {
+			fatalf("%v", err)
+		}
+		if err := os.WriteFile(filepath.Join(*output, name), append(data, '\n'), 0644); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	fmt.Printf("Wrote %d ecrecover, %d EIP-155 and %d transaction vectors to %s on %d forks\n",
+		len(precompiles), len(decodings), len(txTests), *output, len(forkNames))
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// ecrecoverVector runs the input through the precompile and checks the
+// outcome against the expectations of the case.
+func ecrecoverVector(c *ecrecoverCase) (precompileVector, error) {
+	p := vm.PrecompiledContractsHomestead[common.BytesToAddress([]byte{1})]
+	out, err := p.Run(c.input)
+	if err != nil {
+		return precompileVector{}, err
+	}
+	switch {
+	case c.valid && len(out) == 0:
+		return precompileVector{}, errors.New("recovers no address")
+	case !c.valid && len(out) != 0:
+		return precompileVector{}, fmt.Errorf("recovers %x", out)
+	case c.signer && common.BytesToAddress(out) != sender:
+		return precompileVector{}, fmt.Errorf("recovers %x, expected the sender", out)
+	}
+	return precompileVector{
+		Input:    common.Bytes2Hex(c.input),
+		Expected: common.Bytes2Hex(out),
+		Gas:      p.RequiredGas(c.input),
+		Name:     c.name,
+	}, nil
+}
+
+// eip155Decoding decodes a v value, checking the chain id and protection
+// against a go-ethereum legacy transaction.
+func eip155Decoding(v *big.Int) (eip155Vector, error) {
+	vector := eip155Vector{V: (*hexutil.Big)(v)}
+	switch {
+	case v.Cmp(big.NewInt(27)) == 0 || v.Cmp(big.NewInt(28)) == 0:
+		parity := hexutil.Uint64(v.Uint64() - 27)
+		vector.Valid, vector.YParity = true, &parity
+	case v.Cmp(big.NewInt(35)) >= 0:
+		offset := new(big.Int).Sub(v, big.NewInt(35))
+		parity := hexutil.Uint64(offset.Bit(0))
+		vector.Valid, vector.Protected, vector.YParity = true, true, &parity
+		vector.ChainID = (*hexutil.Big)(offset.Rsh(offset, 1))
+	default:
+		return vector, nil
+	}
+	tx := types.NewTx(&types.LegacyTx{V: v, R: common.Big1, S: common.Big1})
+	if tx.Protected() != vector.Protected {
+		return vector, fmt.Errorf("go-ethereum decodes protected %t", tx.Protected())
+	}
+	if vector.Protected && tx.ChainId().Cmp(vector.ChainID.ToInt()) != 0 {
+		return vector, fmt.Errorf("go-ethereum decodes chain id %d", tx.ChainId())
+	}
+	return vector, nil
+}
+
+// expected returns the outcome of the transaction case on a fork.
+func (c *txCase) expected(fork string) (outcome, error) {
+	index, err := forks.Index(fork)
+	if err != nil {
+		return outcome{}, err
+	}
+	var result outcome
+	for _, o := range c.outcomes {
+		from, err := forks.Index(o.fork)
+		if err != nil {
+			return outcome{}, err
+		}
+		if from <= index {
+			result = o
+		}
+	}
+	return result, nil
+}
+
+// fillTx builds the transaction test fixture of a case, checks the outcome on
+// each fork and runs the fixture through go-ethereum's transaction tests.
+func fillTx(c *txCase, forkNames []string) (*txFixture, error) {
+	txBytes, err := c.tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	paris, _ := forks.Index("Paris")
+	f := &txFixture{Info: statetest.Info("signature-vectors", "handcrafted", c.description), TxBytes: txBytes, Result: make(map[string]*txResult)}
+	for _, fork := range forkNames {
+		want, err := c.expected(fork)
+		if err != nil {
+			return nil, err
+		}
+		index, _ := forks.Index(fork)
+		config := tests.Forks[fork]
+		rules := config.Rules(new(big.Int), index >= paris, 0)
+
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(txBytes); err != nil {
+			return nil, err
+		}
+		from, err := types.Sender(types.MakeSigner(config, new(big.Int), 0), tx)
+		switch {
+		case err != nil && want.exception == "":
+			return nil, fmt.Errorf("%s: unexpected error: %v", fork, err)
+		case err == nil && want.exception != "":
+			return nil, fmt.Errorf("%s: expected %s, recovered %s", fork, want.exception, from.Hex())
+		case err != nil:
+			f.Result[fork] = &txResult{Exception: want.exception}
+			continue
+		}
+		gas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.SetCodeAuthorizations(), tx.To() == nil, rules.IsHomestead, rules.IsIstanbul, rules.IsShanghai)
+		if err != nil {
+			return nil, err
+		}
+		hash := tx.Hash()
+		f.Result[fork] = &txResult{Hash: &hash, Sender: &from, IntrinsicGas: hexutil.Uint64(gas)}
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	var test tests.TransactionTest
+	if err := json.Unmarshal(data, &test); err != nil {
+		return nil, err
+	}
+	if err := test.Run(); err != nil {
+		return nil, fmt.Errorf("transaction test: %v", err)
+	}
+	return f, nil
+}
+
+// fillState builds the state test fixture of an ecrecover case, executes it
+// on each fork and checks that the contract stored the precompile output.
+func fillState(c *ecrecoverCase, v precompileVector, forkNames []string) (*statetest.Fixture, error) {
+	pre := types.GenesisAlloc{
+		sender: {Balance: big.NewInt(params.Ether)},
+		caller: {Balance: new(big.Int), Code: callerCode},
+	}
+	tx, err := types.SignNewTx(senderKey, types.HomesteadSigner{}, &types.LegacyTx{
+		GasPrice: big.NewInt(10),
+		Gas:      ecrecoverGas,
+		To:       &caller,
+		Value:    new(big.Int),
+		Data:     c.input,
+	})
+	if err != nil {
+		return nil, err
+	}
+	env := statetest.DefaultEnv(coinbase, common.Hash{0x5e, 0xc0}, 7)
+	f, err := statetest.New(env, pre, tx, senderKey, forkNames)
+	if err != nil {
+		return nil, err
+	}
+	f.Info = statetest.Info("signature-vectors", "handcrafted", "ecrecover called with "+c.description)
+	want := common.BytesToHash(common.FromHex(v.Expected))
+	if err := f.Fill(func(fork string, r *statetest.Result) error { return checkState(r, want) }); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// checkState checks the stored output of the state test on a fork and
+// records the calling contract in the post-state.
+func checkState(r *statetest.Result, want common.Hash) error {
+	if have := r.State.GetState(caller, common.Hash{}); have != want {
+		return fmt.Errorf("stored %s, expected %s", have.Hex(), want.Hex())
+	}
+	if r.State.GetState(caller, common.Hash{31: 1}) != (common.Hash{31: 1}) {
+		return errors.New("ecrecover call failed")
+	}
+	r.Post.State = statetest.DumpState(r.State, []common.Address{caller}, common.Hash{}, common.Hash{31: 1})
+	return nil
+}
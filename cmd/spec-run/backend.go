@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// backend executes the test cases of fixture files.
+type backend interface {
+	name() string
+	supports(kind string) bool
+	// run executes every test case of the file, which only contains tests of
+	// supported formats and post states of selected forks, and returns their
+	// results.
+	run(f *fixtureFile) []caseResult
+}
+
+// goBackend runs the tests with the go-ethereum test runners in process.
+type goBackend struct{}
+
+func (goBackend) name() string { return "go" }
+
+func (goBackend) supports(kind string) bool {
+	return kind == kindState || kind == kindBlockchain
+}
+
+func (goBackend) run(f *fixtureFile) []caseResult {
+	var results []caseResult
+	for _, t := range f.tests {
+		data, err := t.encode()
+		switch t.kind {
+		case kindState:
+			var st tests.StateTest
+			if err == nil {
+				err = json.Unmarshal(data, &st)
+			}
+			if err != nil {
+				for _, c := range t.cases() {
+					results = append(results, c.result(err, time.Now()))
+				}
+				continue
+			}
+			for _, c := range t.cases() {
+				start := time.Now()
+				err := recovered(func() error {
+					return st.Run(tests.StateSubtest{Fork: c.Fork, Index: c.pos}, vm.Config{}, false, rawdb.HashScheme, func(error, *tests.StateTestState) {})
+				})
+				results = append(results, goResult(c, err, start))
+			}
+		case kindBlockchain:
+			c, start := t.cases()[0], time.Now()
+			var bt tests.BlockTest
+			if err == nil {
+				err = json.Unmarshal(data, &bt)
+			}
+			if err == nil {
+				err = recovered(func() error {
+					return bt.Run(false, rawdb.HashScheme, false, nil, nil)
+				})
+			}
+			results = append(results, goResult(c, err, start))
+		}
+	}
+	return results
+}
+
+// goResult converts the outcome of a go-ethereum test runner, which skips
+// forks it does not know.
+func goResult(c testCase, err error, start time.Time) caseResult {
+	var unsupported tests.UnsupportedForkError
+	if errors.As(err, &unsupported) {
+		return c.skip(fmt.Sprintf("fork %s is not supported by go-ethereum", unsupported.Name))
+	}
+	return c.result(err, start)
+}
+
+// recovered runs fn, turning a panic into an error so that a single broken
+// fixture does not abort the run.
+func recovered(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// gethBackend runs the tests with the statetest and blocktest commands of the
+// geth evm tool, once per file and format.
+type gethBackend struct {
+	command []string
+	timeout time.Duration
+}
+
+// gethResult is a test result reported by the geth evm tool.
+type gethResult struct {
+	Name  string `json:"name"`
+	Pass  bool   `json:"pass"`
+	Fork  string `json:"fork"`
+	Error string `json:"error"`
+}
+
+func (b *gethBackend) name() string { return "geth" }
+
+func (b *gethBackend) supports(kind string) bool {
+	return kind == kindState || kind == kindBlockchain
+}
+
+func (b *gethBackend) run(f *fixtureFile) []caseResult {
+	var results []caseResult
+	for _, kind := range []string{kindState, kindBlockchain} {
+		var selected []*fixtureTest
+		for _, t := range f.tests {
+			if t.kind == kind {
+				selected = append(selected, t)
+			}
+		}
+		if len(selected) > 0 {
+			results = append(results, b.runTests(kind, selected)...)
+		}
+	}
+	return results
+}
+
+// runTests writes the tests to a temporary file, runs it and matches the
+// reported results to the test cases. The post states of a fork are reported
+// in order, so the n-th result of a test and fork belongs to its n-th post
+// state.
+func (b *gethBackend) runTests(kind string, selected []*fixtureTest) []caseResult {
+	var cases []testCase
+	for _, t := range selected {
+		cases = append(cases, t.cases()...)
+	}
+	start := time.Now()
+	fail := func(err error) []caseResult {
+		results := make([]caseResult, len(cases))
+		for i, c := range cases {
+			results[i] = c.result(err, start)
+		}
+		return results
+	}
+	reported, err := b.execute(kind, selected)
+	if err != nil {
+		return fail(err)
+	}
+	byCase := make(map[string][]gethResult)
+	for _, r := range reported {
+		key := r.Name + "/" + r.Fork
+		if kind == kindBlockchain {
+			key = r.Name // blocktest reports no fork
+		}
+		byCase[key] = append(byCase[key], r)
+	}
+	elapsed := time.Since(start) / time.Duration(len(cases))
+	results := make([]caseResult, len(cases))
+	for i, c := range cases {
+		key, n := c.Test+"/"+c.Fork, c.pos
+		if kind == kindBlockchain {
+			key = c.Test
+		}
+		switch rs := byCase[key]; {
+		case n >= len(rs):
+			results[i] = c.result(errors.New("no result reported"), start)
+		case rs[n].Pass:
+			results[i] = c.result(nil, start)
+		default:
+			results[i] = c.result(errors.New(rs[n].Error), start)
+		}
+		results[i].Duration = elapsed
+	}
+	return results
+}
+
+// execute runs the evm tool on a temporary file holding the tests and
+// decodes the reported results.
+func (b *gethBackend) execute(kind string, selected []*fixtureTest) ([]gethResult, error) {
+	fixtures := make(map[string]json.RawMessage, len(selected))
+	for _, t := range selected {
+		data, err := t.encode()
+		if err != nil {
+			return nil, err
+		}
+		fixtures[t.name] = data
+	}
+	data, err := json.Marshal(fixtures)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "spec-run")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tests.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, err
+	}
+	subcommand := "statetest"
+	if kind == kindBlockchain {
+		subcommand = "blocktest"
+	}
+	stdout, err := runCommand(b.command, []string{subcommand, path}, nil, b.timeout)
+	if err != nil {
+		return nil, err
+	}
+	// The results are the last value on stdout, after any log output.
+	start := bytes.LastIndex(stdout, []byte("\n["))
+	if start < 0 && !bytes.HasPrefix(stdout, []byte("[")) {
+		return nil, fmt.Errorf("no results in output: %s", lastLine(stdout))
+	}
+	var results []gethResult
+	if err := json.Unmarshal(stdout[start+1:], &results); err != nil {
+		return nil, fmt.Errorf("invalid results: %v", err)
+	}
+	return results, nil
+}
+
+// runCommand executes a command with the given arguments appended, feeding it
+// the stdin data, and returns its stdout. The command may contain arguments
+// of its own, e.g. "python -m ethereum_spec_tools.evm_tools".
+func runCommand(command, args []string, stdin []byte, timeout time.Duration) ([]byte, error) {
+	if len(command) == 0 {
+		return nil, errors.New("empty command")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], append(command[1:], args...)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("%s timed out after %v", command[0], timeout)
+	case err != nil:
+		return nil, fmt.Errorf("%s: %v: %s", command[0], err, lastLine(stderr.Bytes()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func lastLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return lines[len(lines)-1]
+}
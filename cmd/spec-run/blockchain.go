@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+)
+
+// errUnchecked marks a blockchain test whose invalid block the state
+// transition does not reject, as its exception is raised by the validation of
+// the header, which t8n does not perform.
+var errUnchecked = errors.New("unchecked exception")
+
+// blockchainTest is the part of a blockchain test run by the eels backend.
+type blockchainTest struct {
+	Network       string            `json:"network"`
+	GenesisRLP    hexutil.Bytes     `json:"genesisRLP"`
+	Pre           json.RawMessage   `json:"pre"`
+	Blocks        []blockchainBlock `json:"blocks"`
+	LastBlockHash common.Hash       `json:"lastblockhash"`
+}
+
+// blockchainBlock is a block of a blockchain test, with the exception it is
+// rejected with if it is invalid.
+type blockchainBlock struct {
+	RLP             hexutil.Bytes `json:"rlp"`
+	ExpectException string        `json:"expectException"`
+}
+
+// t8nBlockResult is the part of the t8n output compared with a block header,
+// together with the post-state the next block is executed on.
+type t8nBlockResult struct {
+	Alloc  json.RawMessage `json:"alloc"`
+	Result struct {
+		StateRoot       common.Hash     `json:"stateRoot"`
+		TxRoot          common.Hash     `json:"txRoot"`
+		ReceiptsRoot    common.Hash     `json:"receiptsRoot"`
+		LogsBloom       types.Bloom     `json:"logsBloom"`
+		GasUsed         hexutil.Uint64  `json:"gasUsed"`
+		WithdrawalsRoot *common.Hash    `json:"withdrawalsRoot"`
+		BlobGasUsed     *hexutil.Uint64 `json:"blobGasUsed"`
+		ExcessBlobGas   *hexutil.Uint64 `json:"currentExcessBlobGas"`
+		RequestsHash    *common.Hash    `json:"requestsHash"`
+		Rejected        []struct {
+			Index int    `json:"index"`
+			Error string `json:"error"`
+		} `json:"rejected"`
+	} `json:"result"`
+}
+
+// runBlockchain executes the blocks of a blockchain test one at a time as
+// state transitions, each on the post-state of the last valid block, and
+// checks the fields of their headers the transition computes. Invalid blocks
+// must not decode, have a rejected transaction or disagree with their
+// header. The last valid block must be the head of the test.
+func (b *eelsBackend) runBlockchain(t *fixtureTest) error {
+	data, err := t.encode()
+	if err != nil {
+		return err
+	}
+	var test blockchainTest
+	if err := json.Unmarshal(data, &test); err != nil {
+		return fmt.Errorf("invalid blockchain test: %v", err)
+	}
+	var genesis types.Block
+	if err := rlp.DecodeBytes(test.GenesisRLP, &genesis); err != nil {
+		return fmt.Errorf("invalid genesis RLP: %v", err)
+	}
+	var (
+		parent = genesis.Header()
+		alloc  = test.Pre
+		hashes = map[string]common.Hash{"0": parent.Hash()}
+	)
+	for i, block := range test.Blocks {
+		var decoded types.Block
+		if err := rlp.DecodeBytes(block.RLP, &decoded); err != nil {
+			if block.ExpectException != "" {
+				continue
+			}
+			return fmt.Errorf("block %d: invalid RLP: %v", i+1, err)
+		}
+		out, err := b.transition(t, &decoded, parent, alloc, hashes)
+		switch {
+		case err != nil && block.ExpectException != "":
+			continue
+		case err != nil:
+			return fmt.Errorf("block %d: %v", i+1, err)
+		}
+		mismatch := compareHeader(decoded.Header(), out)
+		switch {
+		case block.ExpectException == "" && mismatch != nil:
+			return fmt.Errorf("block %d: %v", i+1, mismatch)
+		case block.ExpectException != "" && mismatch == nil:
+			return fmt.Errorf("%w: block %d is accepted by the state transition, expected exception %s", errUnchecked, i+1, block.ExpectException)
+		case block.ExpectException != "":
+			continue
+		}
+		parent, alloc = decoded.Header(), out.Alloc
+		hashes[parent.Number.String()] = parent.Hash()
+	}
+	if head := parent.Hash(); head != test.LastBlockHash {
+		return fmt.Errorf("last block hash mismatch: got %x, want %x", head, test.LastBlockHash)
+	}
+	return nil
+}
+
+// transition executes a block on the post-state of its parent with the t8n
+// tool, on the fork of the network the block is in.
+func (b *eelsBackend) transition(t *fixtureTest, block *types.Block, parent *types.Header, alloc json.RawMessage, hashes map[string]common.Hash) (*t8nBlockResult, error) {
+	fork, err := blockFork(t.network, block.Header())
+	if err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if txs == nil {
+		txs = types.Transactions{}
+	}
+	input, err := json.Marshal(map[string]interface{}{
+		"env":   blockEnv(block, parent, hashes),
+		"alloc": alloc,
+		"txs":   txs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	reward, err := blockReward(fork, block.Header())
+	if err != nil {
+		return nil, err
+	}
+	args, err := t8nArgs(t, fork)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, "--state.reward", reward)
+	stdout, err := runCommand(b.command, args, input, b.timeout)
+	if err != nil {
+		return nil, err
+	}
+	out := new(t8nBlockResult)
+	if err := json.Unmarshal(stdout, out); err != nil {
+		return nil, fmt.Errorf("invalid t8n output: %v", err)
+	}
+	return out, nil
+}
+
+// blockEnv returns the t8n environment of a block: the fields of its header,
+// those of its parent the transition derives from, the hashes of its
+// ancestors, its withdrawals and the distances of its ommers.
+func blockEnv(block *types.Block, parent *types.Header, hashes map[string]common.Hash) map[string]interface{} {
+	h := block.Header()
+	env := map[string]interface{}{
+		"currentCoinbase":  h.Coinbase,
+		"currentGasLimit":  hexutil.Uint64(h.GasLimit),
+		"currentNumber":    hexutil.Uint64(h.Number.Uint64()),
+		"currentTimestamp": hexutil.Uint64(h.Time),
+		"parentTimestamp":  hexutil.Uint64(parent.Time),
+		"parentDifficulty": (*hexutil.Big)(parent.Difficulty),
+		"parentUncleHash":  parent.UncleHash,
+		"parentGasUsed":    hexutil.Uint64(parent.GasUsed),
+		"parentGasLimit":   hexutil.Uint64(parent.GasLimit),
+		"blockHashes":      hashes,
+	}
+	if h.Difficulty.Sign() > 0 {
+		env["currentDifficulty"] = (*hexutil.Big)(h.Difficulty)
+	} else {
+		env["currentRandom"] = h.MixDigest
+	}
+	if h.BaseFee != nil {
+		env["currentBaseFee"] = (*hexutil.Big)(h.BaseFee)
+	}
+	if parent.BaseFee != nil {
+		env["parentBaseFee"] = (*hexutil.Big)(parent.BaseFee)
+	}
+	if h.ExcessBlobGas != nil {
+		env["currentExcessBlobGas"] = hexutil.Uint64(*h.ExcessBlobGas)
+	}
+	if parent.ExcessBlobGas != nil {
+		env["parentExcessBlobGas"] = hexutil.Uint64(*parent.ExcessBlobGas)
+	}
+	if parent.BlobGasUsed != nil {
+		env["parentBlobGasUsed"] = hexutil.Uint64(*parent.BlobGasUsed)
+	}
+	if h.ParentBeaconRoot != nil {
+		env["parentBeaconBlockRoot"] = *h.ParentBeaconRoot
+	}
+	if w := block.Withdrawals(); w != nil {
+		env["withdrawals"] = w
+	}
+	type ommer struct {
+		Delta   uint64         `json:"delta"`
+		Address common.Address `json:"address"`
+	}
+	ommers := []ommer{}
+	for _, uncle := range block.Uncles() {
+		ommers = append(ommers, ommer{Delta: h.Number.Uint64() - uncle.Number.Uint64(), Address: uncle.Coinbase})
+	}
+	env["ommers"] = ommers
+	return env
+}
+
+// compareHeader checks the fields of the header the state transition
+// computes, which fails if a transaction is rejected.
+func compareHeader(h *types.Header, out *t8nBlockResult) error {
+	r := out.Result
+	if len(r.Rejected) > 0 {
+		return fmt.Errorf("transaction %d rejected: %s", r.Rejected[0].Index, r.Rejected[0].Error)
+	}
+	mismatch := func(field string, have, want interface{}) error {
+		return fmt.Errorf("%s mismatch: got %v, want %v", field, have, want)
+	}
+	switch {
+	case r.StateRoot != h.Root:
+		return mismatch("state root", r.StateRoot.Hex(), h.Root.Hex())
+	case r.TxRoot != h.TxHash:
+		return mismatch("transactions root", r.TxRoot.Hex(), h.TxHash.Hex())
+	case r.ReceiptsRoot != h.ReceiptHash:
+		return mismatch("receipts root", r.ReceiptsRoot.Hex(), h.ReceiptHash.Hex())
+	case r.LogsBloom != h.Bloom:
+		return errors.New("logs bloom mismatch")
+	case uint64(r.GasUsed) != h.GasUsed:
+		return mismatch("gas used", uint64(r.GasUsed), h.GasUsed)
+	case h.WithdrawalsHash != nil && (r.WithdrawalsRoot == nil || *r.WithdrawalsRoot != *h.WithdrawalsHash):
+		return mismatch("withdrawals root", r.WithdrawalsRoot, h.WithdrawalsHash.Hex())
+	case h.BlobGasUsed != nil && (r.BlobGasUsed == nil || uint64(*r.BlobGasUsed) != *h.BlobGasUsed):
+		return mismatch("blob gas used", r.BlobGasUsed, *h.BlobGasUsed)
+	case h.ExcessBlobGas != nil && r.ExcessBlobGas != nil && uint64(*r.ExcessBlobGas) != *h.ExcessBlobGas:
+		return mismatch("excess blob gas", *r.ExcessBlobGas, *h.ExcessBlobGas)
+	case h.RequestsHash != nil && (r.RequestsHash == nil || *r.RequestsHash != *h.RequestsHash):
+		return mismatch("requests hash", r.RequestsHash, h.RequestsHash.Hex())
+	}
+	return nil
+}
+
+// blockFork returns the fork a block of a blockchain test on the network is
+// in. Transition networks such as "ShanghaiToCancunAtTime15k" move to their
+// second fork at a block number, a timestamp or, for the merge, at the first
+// block without difficulty.
+func blockFork(network string, h *types.Header) (string, error) {
+	from, rest, ok := strings.Cut(network, "To")
+	if !ok {
+		return network, nil
+	}
+	to, at, ok := strings.Cut(rest, "At")
+	if !ok {
+		return "", fmt.Errorf("invalid transition network %q", network)
+	}
+	var after bool
+	switch {
+	case strings.HasPrefix(at, "Diff"):
+		after = h.Difficulty.Sign() == 0
+	case strings.HasPrefix(at, "Time"):
+		n, err := strconv.ParseUint(strings.Replace(at[len("Time"):], "k", "000", 1), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid transition network %q", network)
+		}
+		after = h.Time >= n
+	default:
+		n, err := strconv.ParseUint(at, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid transition network %q", network)
+		}
+		after = h.Number.Uint64() >= n
+	}
+	if after {
+		return to, nil
+	}
+	return from, nil
+}
+
+// blockReward returns the --state.reward of a block on the fork: the block
+// reward of proof of work blocks, disabled once the merge happened.
+func blockReward(fork string, h *types.Header) (string, error) {
+	if h.Difficulty.Sign() == 0 {
+		return "-1", nil
+	}
+	if _, err := forks.Index(fork); err != nil {
+		return "", err
+	}
+	reward := ethash.FrontierBlockReward
+	for _, step := range []struct {
+		fork   string
+		reward *uint256.Int
+	}{
+		{"Byzantium", ethash.ByzantiumBlockReward},
+		{"Constantinople", ethash.ConstantinopleBlockReward},
+	} {
+		if forks.Since(fork, step.fork) {
+			reward = step.reward
+		}
+	}
+	return reward.Dec(), nil
+}
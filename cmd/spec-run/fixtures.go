@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The fixture formats.
+const (
+	kindState      = "state"
+	kindBlockchain = "blockchain"
+	kindOther      = "other"
+)
+
+// The statuses of a test case.
+const (
+	statusPass = "pass"
+	statusFail = "fail"
+	statusSkip = "skip"
+)
+
+// caseResult is the outcome of a test case: a post state of a state test or a
+// blockchain test.
+type caseResult struct {
+	File     string        `json:"file"`
+	Test     string        `json:"test"`
+	Kind     string        `json:"kind,omitempty"`
+	Fork     string        `json:"fork,omitempty"`
+	Index    string        `json:"index,omitempty"` // indexes of the post state, e.g. d0g1v0
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// id names the test case in the summary.
+func (r caseResult) id() string {
+	id := r.File
+	for _, part := range []string{r.Test, r.Fork, r.Index} {
+		if part != "" {
+			id += " " + part
+		}
+	}
+	return id
+}
+
+// fixtureFile is a fixture file, or the part of it a backend runs.
+type fixtureFile struct {
+	path  string
+	tests []*fixtureTest
+}
+
+// fixtureTest is a test of a fixture file. Only the fields needed to
+// classify and select its test cases are decoded, the backends decode the
+// rest.
+type fixtureTest struct {
+	file   string
+	name   string
+	kind   string
+	fields map[string]json.RawMessage
+
+	post    map[string][]json.RawMessage // post states of state tests, by fork
+	network string                       // fork of blockchain tests
+}
+
+// statePost is a post state of a state test.
+type statePost struct {
+	Hash            common.Hash `json:"hash"`
+	Logs            common.Hash `json:"logs"`
+	ExpectException string      `json:"expectException"`
+	Indexes         struct {
+		Data  int `json:"data"`
+		Gas   int `json:"gas"`
+		Value int `json:"value"`
+	} `json:"indexes"`
+}
+
+// loadFixtures reads a fixture file and classifies its tests, in name order.
+func loadFixtures(path string) (*fixtureFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("not a fixture file: %v", err)
+	}
+	ff := &fixtureFile{path: path}
+	for _, name := range sortedNames(raw) {
+		t := &fixtureTest{file: path, name: name, kind: kindOther}
+		ff.tests = append(ff.tests, t)
+		if json.Unmarshal(raw[name], &t.fields) != nil {
+			continue
+		}
+		switch {
+		case t.fields["transaction"] != nil && t.fields["post"] != nil:
+			if err := json.Unmarshal(t.fields["post"], &t.post); err != nil {
+				return nil, fmt.Errorf("test %s: invalid post states: %v", name, err)
+			}
+			t.kind = kindState
+		case t.fields["blocks"] != nil && t.fields["genesisBlockHeader"] != nil:
+			if err := json.Unmarshal(t.fields["network"], &t.network); err != nil {
+				return nil, fmt.Errorf("test %s: invalid network: %v", name, err)
+			}
+			t.kind = kindBlockchain
+		}
+	}
+	return ff, nil
+}
+
+// testCase identifies a test case of a test. Pos is the position of the post
+// state in the list of its fork.
+type testCase struct {
+	caseResult
+	pos  int
+	post *statePost
+}
+
+func (c testCase) skip(reason string) caseResult {
+	r := c.caseResult
+	r.Status, r.Error = statusSkip, reason
+	return r
+}
+
+func (c testCase) result(err error, start time.Time) caseResult {
+	r := c.caseResult
+	r.Status, r.Duration = statusPass, time.Since(start)
+	if err != nil {
+		r.Status, r.Error = statusFail, err.Error()
+	}
+	return r
+}
+
+// cases lists the test cases of the test, the post states of state tests in
+// fork order.
+func (t *fixtureTest) cases() []testCase {
+	base := caseResult{File: t.file, Test: t.name, Kind: t.kind}
+	switch t.kind {
+	case kindBlockchain:
+		c := testCase{caseResult: base}
+		c.Fork = t.network
+		return []testCase{c}
+	case kindState:
+		var cases []testCase
+		for _, fork := range sortedNames(t.post) {
+			for i, raw := range t.post[fork] {
+				post := new(statePost)
+				json.Unmarshal(raw, post)
+				c := testCase{caseResult: base, pos: i, post: post}
+				c.Fork = fork
+				c.Index = fmt.Sprintf("d%dg%dv%d", post.Indexes.Data, post.Indexes.Gas, post.Indexes.Value)
+				cases = append(cases, c)
+			}
+		}
+		return cases
+	}
+	return []testCase{{caseResult: base}}
+}
+
+// only returns the test restricted to the given forks.
+func (t *fixtureTest) only(forks []string) *fixtureTest {
+	if t.kind != kindState {
+		return t
+	}
+	restricted := *t
+	restricted.post = make(map[string][]json.RawMessage)
+	for _, fork := range forks {
+		restricted.post[fork] = t.post[fork]
+	}
+	restricted.fields = make(map[string]json.RawMessage, len(t.fields))
+	for key, value := range t.fields {
+		restricted.fields[key] = value
+	}
+	restricted.fields["post"], _ = json.Marshal(restricted.post)
+	return &restricted
+}
+
+// encode returns the JSON encoding of the test.
+func (t *fixtureTest) encode() ([]byte, error) {
+	return json.Marshal(t.fields)
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
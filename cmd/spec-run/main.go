@@ -0,0 +1,189 @@
+// spec-run executes the state and blockchain test fixtures of a directory in
+// parallel on a pluggable backend and reports the results.
+//
+// Usage:
+//
+//	go run ./cmd/spec-run --backend go --fork Cancun,Prague --jobs 8 --junit junit.xml fixtures/
+//
+// The backends are
+//
+//   - go: the go-ethereum state and blockchain test runners, in process,
+//   - geth: the evm statetest and blocktest commands of go-ethereum, run as a
+//     subprocess given by --geth,
+//   - eels: the t8n tool of the execution specs (or any compatible t8n tool),
+//     run as a subprocess given by --eels for every test case. State tests
+//     are converted into t8n inputs as ethereum-spec-evm statetest does;
+//     blockchain tests are run block by block, each block a transition on
+//     the post-state of its parent whose result is checked against the
+//     header. t8n does not validate headers, so a blockchain test whose
+//     invalid block is only rejected by its header is skipped.
+//
+// Directories are searched for .json files recursively, skipping hidden ones
+// such as the .meta directory of the execution-spec-tests releases. Every
+// test of a file is classified by its format. Formats other than state and
+// blockchain tests are skipped, as are test cases of forks not selected with
+// --fork: the post states of other forks of state tests and blockchain tests
+// of another network. --jobs files are executed in parallel.
+//
+// Every test case, i.e. every post state of a state test and every
+// blockchain test, gets a result: pass, fail or skip. The results are
+// written as JSON to --report and, with --junit, as a JUnit XML report with a
+// test suite per file. A summary and the failures are printed on stdout; the
+// tool exits with a nonzero code if any test case failed.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/fixtures"
+)
+
+func main() {
+	var (
+		backendName = flag.String("backend", "go", "backend executing the tests (go, geth or eels)")
+		geth        = flag.String("geth", "evm", "command running the geth evm tool, for the geth backend")
+		eels        = flag.String("eels", "ethereum-spec-evm", "command running the EELS evm tool, for the eels backend")
+		forkList    = flag.String("fork", "", "comma separated forks to run (default all)")
+		jobs        = flag.Int("jobs", runtime.NumCPU(), "number of fixture files run in parallel")
+		timeout     = flag.Duration("timeout", 10*time.Minute, "timeout of a single subprocess invocation")
+		reportPath  = flag.String("report", "spec_run.json", "path of the JSON report")
+		junitPath   = flag.String("junit", "", "also write a JUnit XML report to this path")
+	)
+	flag.Parse()
+
+	var b backend
+	switch *backendName {
+	case "go":
+		b = goBackend{}
+	case "geth":
+		b = &gethBackend{command: strings.Fields(*geth), timeout: *timeout}
+	case "eels":
+		b = &eelsBackend{command: strings.Fields(*eels), timeout: *timeout}
+	default:
+		fatalf("unknown backend %q, supported backends: go, geth, eels", *backendName)
+	}
+	var filter forkFilter
+	if *forkList != "" {
+		filter = make(forkFilter)
+		for _, fork := range strings.Split(*forkList, ",") {
+			filter[strings.TrimSpace(fork)] = true
+		}
+	}
+	if flag.NArg() == 0 {
+		fatalf("no fixture files or directories given")
+	}
+	files, err := fixtures.Collect(flag.Args())
+	if err != nil {
+		fatalf("%v", err)
+	}
+	start := time.Now()
+	results := runFiles(files, b, filter, max(*jobs, 1))
+	report := newReport(*backendName, results, time.Since(start))
+
+	data, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*reportPath, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	if *junitPath != "" {
+		if err := writeJUnit(*junitPath, report); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	for _, r := range report.Results {
+		if r.Status == statusFail {
+			fmt.Printf("FAIL %s\n        %s\n", r.id(), r.Error)
+		}
+	}
+	fmt.Printf("%d test cases in %d files, %d passed, %d failed, %d skipped in %v\n", len(report.Results), len(files), report.Passed, report.Failed, report.Skipped, report.Duration)
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// forkFilter is the set of forks to run, nil selects all of them.
+type forkFilter map[string]bool
+
+func (f forkFilter) selects(fork string) bool {
+	return f == nil || f[fork]
+}
+
+// runFiles runs every file on the backend using the given number of workers
+// and returns the results in file order.
+func runFiles(files []string, b backend, filter forkFilter, jobs int) []caseResult {
+	var (
+		results = make([][]caseResult, len(files))
+		next    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range next {
+				results[index] = runFile(files[index], b, filter)
+			}
+		}()
+	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var all []caseResult
+	for _, r := range results {
+		all = append(all, r...)
+	}
+	return all
+}
+
+// runFile loads a fixture file, skips the test cases the backend or the fork
+// filter exclude and runs the others.
+func runFile(path string, b backend, filter forkFilter) []caseResult {
+	start := time.Now()
+	ff, err := loadFixtures(path)
+	if err != nil {
+		return []caseResult{{File: path, Status: statusFail, Error: err.Error(), Duration: time.Since(start)}}
+	}
+	var (
+		results []caseResult
+		run     = &fixtureFile{path: path}
+	)
+	for _, t := range ff.tests {
+		var selected []string
+		for _, c := range t.cases() {
+			switch {
+			case t.kind == kindOther:
+				results = append(results, c.skip("unsupported fixture format"))
+			case !filter.selects(c.Fork):
+				// Forks which are not selected are not reported at all.
+			case !b.supports(t.kind):
+				results = append(results, c.skip(fmt.Sprintf("%s tests are not supported by the %s backend", t.kind, b.name())))
+			default:
+				selected = append(selected, c.Fork)
+			}
+		}
+		if len(selected) > 0 {
+			run.tests = append(run.tests, t.only(selected))
+		}
+	}
+	if len(run.tests) > 0 {
+		results = append(results, b.run(run)...)
+	}
+	return results
+}
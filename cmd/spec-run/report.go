@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"time"
+)
+
+// report is the JSON report of a run.
+type report struct {
+	Backend  string        `json:"backend"`
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+	Skipped  int           `json:"skipped"`
+	Duration time.Duration `json:"duration"`
+	Results  []caseResult  `json:"results"`
+}
+
+func newReport(backend string, results []caseResult, duration time.Duration) *report {
+	r := &report{Backend: backend, Duration: duration.Round(time.Millisecond), Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case statusPass:
+			r.Passed++
+		case statusFail:
+			r.Failed++
+		default:
+			r.Skipped++
+		}
+	}
+	return r
+}
+
+// The JUnit XML report, in the dialect understood by the common CI systems.
+type (
+	junitSuites struct {
+		XMLName  xml.Name     `xml:"testsuites"`
+		Name     string       `xml:"name,attr"`
+		Tests    int          `xml:"tests,attr"`
+		Failures int          `xml:"failures,attr"`
+		Skipped  int          `xml:"skipped,attr"`
+		Time     string       `xml:"time,attr"`
+		Suites   []junitSuite `xml:"testsuite"`
+	}
+	junitSuite struct {
+		Name     string      `xml:"name,attr"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Skipped  int         `xml:"skipped,attr"`
+		Time     string      `xml:"time,attr"`
+		Cases    []junitCase `xml:"testcase"`
+	}
+	junitCase struct {
+		Name      string        `xml:"name,attr"`
+		Classname string        `xml:"classname,attr"`
+		Time      string        `xml:"time,attr"`
+		Failure   *junitMessage `xml:"failure,omitempty"`
+		Skipped   *junitMessage `xml:"skipped,omitempty"`
+	}
+	junitMessage struct {
+		Message string `xml:"message,attr"`
+	}
+)
+
+// writeJUnit writes the results as a JUnit XML report with a test suite per
+// fixture file, in file order.
+func writeJUnit(path string, r *report) error {
+	suites := junitSuites{
+		Name:     "spec-run " + r.Backend,
+		Tests:    len(r.Results),
+		Failures: r.Failed,
+		Skipped:  r.Skipped,
+		Time:     seconds(r.Duration),
+	}
+	var elapsed []time.Duration
+	for _, result := range r.Results {
+		if n := len(suites.Suites); n == 0 || suites.Suites[n-1].Name != result.File {
+			suites.Suites = append(suites.Suites, junitSuite{Name: result.File})
+			elapsed = append(elapsed, 0)
+		}
+		suite := &suites.Suites[len(suites.Suites)-1]
+		elapsed[len(elapsed)-1] += result.Duration
+		c := junitCase{Classname: result.File, Time: seconds(result.Duration)}
+		c.Name = result.Test
+		for _, part := range []string{result.Fork, result.Index} {
+			if part != "" {
+				c.Name += " " + part
+			}
+		}
+		suite.Tests++
+		switch result.Status {
+		case statusFail:
+			suite.Failures++
+			c.Failure = &junitMessage{Message: result.Error}
+		case statusSkip:
+			suite.Skipped++
+			c.Skipped = &junitMessage{Message: result.Error}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	for i := range suites.Suites {
+		suites.Suites[i].Time = seconds(elapsed[i])
+	}
+	data, err := xml.MarshalIndent(suites, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
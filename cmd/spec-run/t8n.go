@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// eelsBackend runs every post state of a state test as a state transition of
+// the t8n tool of the execution specs, and the blocks of a blockchain test as
+// one transition each.
+type eelsBackend struct {
+	command []string
+	timeout time.Duration
+}
+
+// t8nResult is the part of the t8n result compared with the post state.
+type t8nResult struct {
+	Result struct {
+		StateRoot common.Hash `json:"stateRoot"`
+		LogsHash  common.Hash `json:"logsHash"`
+		Rejected  []struct {
+			Index int    `json:"index"`
+			Error string `json:"error"`
+		} `json:"rejected"`
+	} `json:"result"`
+}
+
+func (b *eelsBackend) name() string { return "eels" }
+
+func (b *eelsBackend) supports(kind string) bool {
+	return kind == kindState || kind == kindBlockchain
+}
+
+func (b *eelsBackend) run(f *fixtureFile) []caseResult {
+	var results []caseResult
+	for _, t := range f.tests {
+		if t.kind == kindBlockchain {
+			c, start := t.cases()[0], time.Now()
+			err := b.runBlockchain(t)
+			if errors.Is(err, errUnchecked) {
+				results = append(results, c.skip(err.Error()))
+			} else {
+				results = append(results, c.result(err, start))
+			}
+			continue
+		}
+		for _, c := range t.cases() {
+			start := time.Now()
+			results = append(results, c.result(b.runCase(t, c), start))
+		}
+	}
+	return results
+}
+
+// runCase executes a post state of a state test and checks the state root,
+// the logs hash and whether the transaction is rejected against it.
+func (b *eelsBackend) runCase(t *fixtureTest, c testCase) error {
+	input, err := t8nInput(t, c.post)
+	if err != nil {
+		return err
+	}
+	args, err := t8nArgs(t, c.Fork)
+	if err != nil {
+		return err
+	}
+	stdout, err := runCommand(b.command, args, input, b.timeout)
+	if err != nil {
+		return err
+	}
+	var out t8nResult
+	if err := json.Unmarshal(stdout, &out); err != nil {
+		return fmt.Errorf("invalid t8n output: %v", err)
+	}
+	rejected := len(out.Result.Rejected) > 0
+	switch {
+	case c.post.ExpectException != "" && !rejected:
+		return fmt.Errorf("expected exception %s, transaction succeeded", c.post.ExpectException)
+	case c.post.ExpectException == "" && rejected:
+		return fmt.Errorf("unexpected exception: %s", out.Result.Rejected[0].Error)
+	case out.Result.StateRoot != c.post.Hash:
+		return fmt.Errorf("post state root mismatch: got %x, want %x", out.Result.StateRoot, c.post.Hash)
+	case !rejected && out.Result.LogsHash != c.post.Logs:
+		return fmt.Errorf("post state logs hash mismatch: got %x, want %x", out.Result.LogsHash, c.post.Logs)
+	}
+	return nil
+}
+
+// t8nArgs returns the arguments of a state transition on the fork reading
+// its input from stdin and writing the result and the post-state to stdout,
+// on the chain id of the test if it has one.
+func t8nArgs(t *fixtureTest, fork string) ([]string, error) {
+	args := []string{
+		"t8n",
+		"--input.alloc", "stdin",
+		"--input.env", "stdin",
+		"--input.txs", "stdin",
+		"--output.result", "stdout",
+		"--output.alloc", "stdout",
+		"--state.fork", fork,
+	}
+	var config struct {
+		ChainID *math.HexOrDecimal256 `json:"chainid"`
+	}
+	if raw, ok := t.fields["config"]; ok {
+		if err := json.Unmarshal(raw, &config); err != nil {
+			return nil, fmt.Errorf("invalid config: %v", err)
+		}
+	}
+	if config.ChainID != nil {
+		args = append(args, "--state.chainid", (*big.Int)(config.ChainID).String())
+	}
+	return args, nil
+}
+
+// t8nInput converts a post state of a state test into the combined t8n
+// input read from stdin, the way ethereum-spec-evm statetest does: the
+// transaction takes the data, gas limit, value and access list selected by
+// the indexes of the post state and is signed by the t8n tool with its
+// secretKey, the environment gains the previous block hash and no
+// withdrawals.
+func t8nInput(t *fixtureTest, post *statePost) ([]byte, error) {
+	var (
+		env map[string]json.RawMessage
+		tx  map[string]json.RawMessage
+	)
+	if err := json.Unmarshal(t.fields["env"], &env); err != nil {
+		return nil, fmt.Errorf("invalid env: %v", err)
+	}
+	if err := json.Unmarshal(t.fields["transaction"], &tx); err != nil {
+		return nil, fmt.Errorf("invalid transaction: %v", err)
+	}
+	blockHashes := map[string]json.RawMessage{}
+	if previous, ok := env["previousHash"]; ok {
+		blockHashes["0"] = previous
+	}
+	env["blockHashes"], _ = json.Marshal(blockHashes)
+	env["withdrawals"] = json.RawMessage("[]")
+
+	indexed := map[string]struct {
+		key   string
+		index int
+	}{
+		"data":        {"input", post.Indexes.Data},
+		"gasLimit":    {"gas", post.Indexes.Gas},
+		"value":       {"value", post.Indexes.Value},
+		"accessLists": {"accessList", post.Indexes.Data},
+	}
+	txs := make(map[string]json.RawMessage, len(tx))
+	for key, value := range tx {
+		ix, ok := indexed[key]
+		if !ok {
+			txs[key] = value
+			continue
+		}
+		var values []json.RawMessage
+		if err := json.Unmarshal(value, &values); err != nil {
+			return nil, fmt.Errorf("invalid transaction %s: %v", key, err)
+		}
+		if ix.index < 0 || ix.index >= len(values) {
+			return nil, fmt.Errorf("transaction %s index %d out of range", key, ix.index)
+		}
+		if key == "accessLists" && string(values[ix.index]) == "null" {
+			continue
+		}
+		txs[ix.key] = values[ix.index]
+	}
+	return json.Marshal(map[string]interface{}{
+		"env":   env,
+		"alloc": t.fields["pre"],
+		"txs":   []interface{}{txs},
+	})
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/ssz"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// transactionVector is a signed transaction in its RLP and EIP-6404 SSZ
+// forms.
+type transactionVector struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Type        hexutil.Uint64 `json:"type"`
+	RLP         hexutil.Bytes  `json:"rlp"`
+	SSZ         hexutil.Bytes  `json:"ssz"`
+	Root        common.Hash    `json:"root"`
+}
+
+// receiptVector is the receipt of a transaction in its RLP and EIP-6466 SSZ
+// forms, along with the type of the transaction and the cumulative gas used
+// of the RLP receipt, which the SSZ receipt does not hold.
+type receiptVector struct {
+	Name              string         `json:"name"`
+	Description       string         `json:"description"`
+	TxType            hexutil.Uint64 `json:"txType"`
+	CumulativeGasUsed hexutil.Uint64 `json:"cumulativeGasUsed"`
+	RLP               hexutil.Bytes  `json:"rlp"`
+	SSZ               hexutil.Bytes  `json:"ssz"`
+	Root              common.Hash    `json:"root"`
+}
+
+// transactionCase is a handcrafted transaction of each profile of the
+// TransactionPayload, signed by the sender.
+type transactionCase struct {
+	name        string
+	description string
+	signer      types.Signer
+	tx          types.TxData
+}
+
+func transactionCases() []transactionCase {
+	var (
+		call       = &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(10), Gas: 50000, To: &recipient, Value: big.NewInt(1), Data: []byte{0x01, 0x02}}
+		create     = &types.LegacyTx{Nonce: 2, GasPrice: big.NewInt(10), Gas: 100000, Data: common.FromHex("0x600160005260206000f3")}
+		accessList = types.AccessList{{Address: recipient, StorageKeys: []common.Hash{{0x01}, {0x02}}}, {Address: common.Address{0x03}, StorageKeys: []common.Hash{}}}
+	)
+	return []transactionCase{
+		{"legacy_unprotected", "legacy transaction without replay protection, with a chain id absent from its payload",
+			types.HomesteadSigner{}, call},
+		{"legacy_eip155", "EIP-155 replay protected legacy transaction, its chain id taken out of v",
+			signer, call},
+		{"legacy_create", "legacy contract creation, without recipient",
+			signer, create},
+		{"access_list", "EIP-2930 transaction with an access list of two addresses",
+			signer, &types.AccessListTx{ChainID: chainID, Nonce: 3, GasPrice: big.NewInt(10), Gas: 50000, To: &recipient, Value: big.NewInt(2), AccessList: accessList}},
+		{"access_list_create", "EIP-2930 contract creation with an empty access list",
+			signer, &types.AccessListTx{ChainID: chainID, Nonce: 4, GasPrice: big.NewInt(10), Gas: 100000, Data: create.Data, AccessList: types.AccessList{}}},
+		{"dynamic_fee", "EIP-1559 transaction with an access list",
+			signer, &types.DynamicFeeTx{ChainID: chainID, Nonce: 5, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10), Gas: 50000, To: &recipient, Data: []byte{0x03}, AccessList: accessList}},
+		{"blob", "EIP-4844 transaction of two blobs, with a blob fee and a zero blob priority fee",
+			signer, &types.BlobTx{ChainID: uint256.MustFromBig(chainID), Nonce: 6, GasTipCap: uint256.NewInt(1), GasFeeCap: uint256.NewInt(10), Gas: 50000, To: recipient,
+				BlobFeeCap: uint256.NewInt(3), BlobHashes: []common.Hash{blobHash(1), blobHash(2)}}},
+		{"set_code", "EIP-7702 transaction with an authorization for the chain and one for any chain, whose chain id is absent",
+			signer, &types.SetCodeTx{ChainID: uint256.MustFromBig(chainID), Nonce: 7, GasTipCap: uint256.NewInt(1), GasFeeCap: uint256.NewInt(10), Gas: 100000, To: recipient,
+				AuthList: []types.SetCodeAuthorization{authorization(recipient, 8), anyChainAuthorization(common.Address{0x04}, 9)}}},
+	}
+}
+
+// anyChainAuthorization returns an authorization of chain id zero, valid on
+// every chain.
+func anyChainAuthorization(address common.Address, nonce uint64) types.SetCodeAuthorization {
+	auth, err := types.SignSetCode(senderKey, types.SetCodeAuthorization{Address: address, Nonce: nonce})
+	if err != nil {
+		panic(err)
+	}
+	return auth
+}
+
+// receiptCase is the handcrafted receipt of a transaction of the named
+// transaction case.
+type receiptCase struct {
+	name        string
+	description string
+	tx          string
+	receipt     *types.Receipt
+}
+
+func receiptCases() []receiptCase {
+	logs := []*types.Log{
+		{Address: recipient, Topics: []common.Hash{{0x0a}, {0x0b}, {0x0c}, {0x0d}}, Data: bytes.Repeat([]byte{0xab}, 40)},
+		{Address: common.Address{0x03}, Topics: []common.Hash{}, Data: []byte{}},
+	}
+	return []receiptCase{
+		{"success_logs", "successful EIP-1559 transaction with a log of four topics and one without topics or data", "dynamic_fee",
+			&types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 60000, GasUsed: 39000, Logs: logs}},
+		{"failed", "failed EIP-2930 transaction", "access_list",
+			&types.Receipt{Status: types.ReceiptStatusFailed, CumulativeGasUsed: 50000, GasUsed: 50000}},
+		{"creation", "contract creation, with the address of the contract", "legacy_create",
+			&types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 53000, GasUsed: 53000}},
+		{"pre_byzantium", "legacy transaction before Byzantium, with the post-state root instead of the status", "legacy_unprotected",
+			&types.Receipt{PostState: common.Hash{0x0e}.Bytes(), CumulativeGasUsed: 21000, GasUsed: 21000, Logs: logs[:1]}},
+		{"blob", "EIP-4844 transaction", "blob",
+			&types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 100000, GasUsed: 21000}},
+		{"set_code", "EIP-7702 transaction, with the authorities of its authorizations", "set_code",
+			&types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 46000, GasUsed: 46000}},
+	}
+}
+
+// containerVectors builds the transaction and receipt vectors, checking
+// their round trip through SSZ back to RLP.
+func containerVectors() ([]*transactionVector, []*receiptVector, error) {
+	var (
+		txs          = make(map[string]*types.Transaction)
+		transactions []*transactionVector
+		receipts     []*receiptVector
+	)
+	for _, c := range transactionCases() {
+		tx, err := types.SignNewTx(senderKey, c.signer, c.tx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("transaction %s: %v", c.name, err)
+		}
+		v, err := newTransactionVector(tx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("transaction %s: %v", c.name, err)
+		}
+		v.Name, v.Description = c.name, c.description
+		txs[c.name] = tx
+		transactions = append(transactions, v)
+	}
+	for _, c := range receiptCases() {
+		tx := txs[c.tx]
+		if tx.To() == nil {
+			c.receipt.ContractAddress = crypto.CreateAddress(crypto.PubkeyToAddress(senderKey.PublicKey), tx.Nonce())
+		}
+		c.receipt.Type = tx.Type()
+		c.receipt.Bloom = types.CreateBloom(c.receipt)
+		v, err := newReceiptVector(tx, c.receipt)
+		if err != nil {
+			return nil, nil, fmt.Errorf("receipt %s: %v", c.name, err)
+		}
+		v.Name, v.Description = c.name, c.description
+		receipts = append(receipts, v)
+	}
+	return transactions, receipts, nil
+}
+
+// newTransactionVector converts the transaction to SSZ and checks that the
+// encoding decodes and encodes again to the same bytes and root, and converts
+// back to the same RLP transaction.
+func newTransactionVector(tx *types.Transaction) (*transactionVector, error) {
+	rlpEnc, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	t, err := ssz.NewTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	enc, root := ssz.EncodeTransaction(t), ssz.TransactionRoot(t)
+	decoded, err := ssz.DecodeTransaction(enc)
+	if err != nil {
+		return nil, fmt.Errorf("decoding the encoding: %v", err)
+	}
+	if !bytes.Equal(ssz.EncodeTransaction(decoded), enc) {
+		return nil, errors.New("decoded transaction encodes differently")
+	}
+	if decodedRoot := ssz.TransactionRoot(decoded); decodedRoot != root {
+		return nil, fmt.Errorf("decoded transaction root %s, want %s", decodedRoot.Hex(), root.Hex())
+	}
+	back, err := ssz.RLPTransaction(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("decoded transaction: %v", err)
+	}
+	if backEnc, _ := back.MarshalBinary(); !bytes.Equal(backEnc, rlpEnc) || back.Hash() != tx.Hash() {
+		return nil, fmt.Errorf("decoded transaction converts to %s, want %s", back.Hash().Hex(), tx.Hash().Hex())
+	}
+	return &transactionVector{Type: hexutil.Uint64(tx.Type()), RLP: rlpEnc, SSZ: enc, Root: root}, nil
+}
+
+// newReceiptVector converts the receipt to SSZ and checks its round trip as
+// newTransactionVector does.
+func newReceiptVector(tx *types.Transaction, receipt *types.Receipt) (*receiptVector, error) {
+	rlpEnc, err := receipt.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	r, err := ssz.NewReceipt(tx, receipt)
+	if err != nil {
+		return nil, err
+	}
+	enc, root := ssz.EncodeReceipt(r), ssz.ReceiptRoot(r)
+	decoded, err := ssz.DecodeReceipt(enc)
+	if err != nil {
+		return nil, fmt.Errorf("decoding the encoding: %v", err)
+	}
+	if !bytes.Equal(ssz.EncodeReceipt(decoded), enc) {
+		return nil, errors.New("decoded receipt encodes differently")
+	}
+	if decodedRoot := ssz.ReceiptRoot(decoded); decodedRoot != root {
+		return nil, fmt.Errorf("decoded receipt root %s, want %s", decodedRoot.Hex(), root.Hex())
+	}
+	back, err := ssz.RLPReceipt(decoded, tx.Type(), receipt.CumulativeGasUsed)
+	if err != nil {
+		return nil, fmt.Errorf("decoded receipt: %v", err)
+	}
+	if backEnc, _ := back.MarshalBinary(); !bytes.Equal(backEnc, rlpEnc) {
+		return nil, errors.New("decoded receipt converts to another RLP receipt")
+	}
+	return &receiptVector{TxType: hexutil.Uint64(tx.Type()), CumulativeGasUsed: hexutil.Uint64(receipt.CumulativeGasUsed), RLP: rlpEnc, SSZ: enc, Root: root}, nil
+}
@@ -0,0 +1,306 @@
+// ssz-vectors converts execution payloads between their RLP block and SSZ
+// forms and generates round-trip vectors of the SSZ encoding and hash tree
+// root for clients experimenting with SSZ in the execution layer.
+//
+// Usage:
+//
+//	go run ./cmd/ssz-vectors --vectors [--output ssz_vectors]
+//	go run ./cmd/ssz-vectors --fixtures fixtures.json [--test name] [--output ssz_vectors]
+//	go run ./cmd/ssz-vectors --encode block.rlp|payload.json
+//	go run ./cmd/ssz-vectors --decode payload.ssz [--beacon-root 0x...] [--requests 0x...,0x...]
+//
+// With --vectors the handcrafted blocks of vectors.go, from Paris to Prague,
+// are written to the output directory as payloads.json, and the EIP-6465
+// roots of withdrawal lists as withdrawals.json. Handcrafted transactions of
+// each type, from legacy to EIP-7702, and their receipts are written along
+// with their RLP encoding as transactions.json and receipts.json, in the
+// EIP-6404 and EIP-6466 containers of their StableContainer revision. Every
+// one of them is decoded again, re-encoded to the same bytes and root and
+// converted back to the same RLP encoding. With --fixtures the valid
+// post-merge blocks of blockchain test fixtures are written instead, to
+// fixtures.json. Every payload vector lists the SSZ layout, the payload in
+// its Engine API JSON form, the parent beacon block root and execution
+// requests completing it into a block, the RLP of that block, the SSZ
+// encoding of the payload and its hash tree root.
+//
+// --encode reads an RLP encoded block, or a payload in its JSON form, and
+// --decode an SSZ encoded payload, hex encoded or raw, and print the vector
+// of the payload. A decoded Deneb payload is only converted to a block with
+// the --beacon-root it was executed with and, for a Prague block, its
+// comma-separated --requests, empty for a block without requests.
+//
+// Every vector is decoded again, re-encoded to the same bytes and converted
+// back to a block of the same hash with go-ethereum's engine package before
+// anything is written or printed.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/ssz"
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func main() {
+	var (
+		vectors    = flag.Bool("vectors", false, "generate the handcrafted vectors")
+		fixtures   = flag.String("fixtures", "", "blockchain test fixtures to generate vectors from")
+		test       = flag.String("test", "", "only convert the named test of the fixtures file")
+		output     = flag.String("output", "ssz_vectors", "directory the vectors are written to")
+		encode     = flag.String("encode", "", "file of an RLP encoded block or a JSON payload to encode")
+		decode     = flag.String("decode", "", "file of an SSZ encoded payload to decode")
+		beaconRoot = flag.String("beacon-root", "", "parent beacon block root of a decoded Deneb payload")
+		requests   = flag.String("requests", "", "comma-separated execution requests of a decoded Prague payload, type byte included")
+	)
+	flag.Parse()
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	var err error
+	switch {
+	case *vectors:
+		err = writeVectors(*output)
+	case *fixtures != "":
+		err = writeFixtureVectors(*fixtures, *test, *output)
+	case *encode != "":
+		err = encodeFile(*encode)
+	case *decode != "":
+		var reqs []string
+		if explicit["requests"] {
+			reqs = []string{}
+			if *requests != "" {
+				reqs = strings.Split(*requests, ",")
+			}
+		}
+		err = decodeFile(*decode, *beaconRoot, reqs)
+	default:
+		fatalf("one of --vectors, --fixtures, --encode or --decode is required")
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+}
+
+// payloadVector is an execution payload in its JSON, block and SSZ forms.
+type payloadVector struct {
+	Name             string                 `json:"name,omitempty"`
+	Description      string                 `json:"description,omitempty"`
+	Layout           string                 `json:"layout"`
+	Payload          *engine.ExecutableData `json:"payload"`
+	BeaconRoot       *common.Hash           `json:"parentBeaconBlockRoot,omitempty"`
+	Requests         []hexutil.Bytes        `json:"executionRequests"` // null before Prague
+	Block            hexutil.Bytes          `json:"blockRLP,omitempty"`
+	SSZ              hexutil.Bytes          `json:"ssz"`
+	TransactionsRoot common.Hash            `json:"transactionsRoot"`
+	WithdrawalsRoot  *common.Hash           `json:"withdrawalsRoot,omitempty"`
+	Root             common.Hash            `json:"root"`
+}
+
+// newVector encodes the payload and checks its round trip. Unless complete is
+// false, which leaves the block out, the payload is converted to its block
+// with the beacon root and the requests, checking the block hash.
+func newVector(payload *engine.ExecutableData, beaconRoot *common.Hash, requests [][]byte, complete bool) (*payloadVector, error) {
+	layout, err := ssz.PayloadLayout(payload)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := ssz.EncodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	root, err := ssz.PayloadRoot(payload)
+	if err != nil {
+		return nil, err
+	}
+	decoded, decodedLayout, err := ssz.DecodePayload(enc)
+	if err != nil {
+		return nil, fmt.Errorf("decoding the encoding: %v", err)
+	}
+	if decodedLayout != layout {
+		return nil, fmt.Errorf("encoding decodes as %s, want %s", decodedLayout, layout)
+	}
+	if reenc, _ := ssz.EncodePayload(decoded); !bytes.Equal(reenc, enc) {
+		return nil, errors.New("decoded payload encodes differently")
+	}
+	if decodedRoot, _ := ssz.PayloadRoot(decoded); decodedRoot != root {
+		return nil, fmt.Errorf("decoded payload root %s, want %s", decodedRoot.Hex(), root.Hex())
+	}
+	v := &payloadVector{
+		Layout:           layout.String(),
+		Payload:          payload,
+		BeaconRoot:       beaconRoot,
+		SSZ:              enc,
+		TransactionsRoot: ssz.TransactionsRoot(payload.Transactions),
+		Root:             root,
+	}
+	if payload.Withdrawals != nil {
+		withdrawalsRoot := ssz.WithdrawalsRoot(payload.Withdrawals)
+		v.WithdrawalsRoot = &withdrawalsRoot
+	}
+	if requests != nil {
+		v.Requests = make([]hexutil.Bytes, len(requests))
+		for i, r := range requests {
+			v.Requests[i] = r
+		}
+	}
+	if !complete {
+		return v, nil
+	}
+	hashes, err := versionedHashes(payload)
+	if err != nil {
+		return nil, err
+	}
+	block, err := engine.ExecutableDataToBlock(*decoded, hashes, beaconRoot, requests)
+	if err != nil {
+		return nil, fmt.Errorf("decoded payload: %v", err)
+	}
+	if v.Block, err = rlp.EncodeToBytes(block); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// blockVector is the vector of the payload of a post-merge block, executed
+// with the requests.
+func blockVector(block *types.Block, requests [][]byte) (*payloadVector, error) {
+	if block.Difficulty().Sign() != 0 {
+		return nil, errors.New("proof-of-work block, payloads start at the merge")
+	}
+	if block.RequestsHash() != nil && types.CalcRequestsHash(requests) != *block.RequestsHash() {
+		return nil, errors.New("requests do not match the requests hash of the block")
+	}
+	payload := engine.BlockToExecutableData(block, nil, nil, nil).ExecutionPayload
+	return newVector(payload, block.BeaconRoot(), requests, true)
+}
+
+// versionedHashes returns the blob hashes of the transactions of a payload,
+// the versioned hashes the payload is checked against.
+func versionedHashes(payload *engine.ExecutableData) ([]common.Hash, error) {
+	hashes := []common.Hash{}
+	for i, enc := range payload.Transactions {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		hashes = append(hashes, tx.BlobHashes()...)
+	}
+	return hashes, nil
+}
+
+// encodeFile prints the vector of an RLP encoded block or a JSON payload.
+// The requests of a Prague block are unknown, so its payload is not checked
+// against its block, and neither is a Deneb JSON payload without its beacon
+// root.
+func encodeFile(file string) error {
+	data, err := readInput(file)
+	if err != nil {
+		return err
+	}
+	var v *payloadVector
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		payload := new(engine.ExecutableData)
+		if err := json.Unmarshal(trimmed, payload); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		layout, _ := ssz.PayloadLayout(payload)
+		v, err = newVector(payload, nil, nil, layout < ssz.Deneb)
+	} else {
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(data, block); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		if block.RequestsHash() == nil {
+			v, err = blockVector(block, nil)
+		} else {
+			payload := engine.BlockToExecutableData(block, nil, nil, nil).ExecutionPayload
+			if v, err = newVector(payload, block.BeaconRoot(), nil, false); err == nil {
+				v.Block = data
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	return printVector(v)
+}
+
+// decodeFile prints the vector of an SSZ encoded payload. Nil requests are
+// those of a block before Prague.
+func decodeFile(file, beaconRoot string, requests []string) error {
+	data, err := readInput(file)
+	if err != nil {
+		return err
+	}
+	payload, layout, err := ssz.DecodePayload(data)
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	var root *common.Hash
+	if beaconRoot != "" {
+		h, err := hexutil.Decode(beaconRoot)
+		if err != nil || len(h) != common.HashLength {
+			return fmt.Errorf("invalid --beacon-root %q", beaconRoot)
+		}
+		hash := common.BytesToHash(h)
+		root = &hash
+	}
+	var reqs [][]byte
+	if requests != nil {
+		reqs = [][]byte{}
+		for _, r := range requests {
+			req, err := hexutil.Decode(r)
+			if err != nil || len(req) < 2 {
+				return fmt.Errorf("invalid request %q, want a type byte and data", r)
+			}
+			reqs = append(reqs, req)
+		}
+	}
+	if reqs != nil && (layout < ssz.Deneb || root == nil) {
+		return errors.New("--requests requires a Deneb payload and --beacon-root")
+	}
+	complete := layout < ssz.Deneb || root != nil
+	v, err := newVector(payload, root, reqs, complete)
+	if err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	if !complete {
+		fmt.Fprintln(os.Stderr, "Deneb payload without --beacon-root, not converted to a block")
+	}
+	return printVector(v)
+}
+
+// readInput reads a file holding hex encoded or raw bytes, or JSON.
+func readInput(file string) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if text := strings.TrimSpace(string(data)); strings.HasPrefix(text, "0x") {
+		return hexutil.Decode(text)
+	}
+	return data, nil
+}
+
+func printVector(v *payloadVector) error {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
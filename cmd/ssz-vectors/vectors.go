@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/execution-specs/pkg/ssz"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+)
+
+var (
+	senderKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	recipient    = common.HexToAddress("0x0000000000000000000000000000000000001000")
+	chainID      = big.NewInt(1)
+	signer       = types.LatestSignerForChainID(chainID)
+)
+
+// payloadCase is a handcrafted block of the fork whose payload layout it
+// exercises. The header fields are distinct so that a misplaced field changes
+// the encoding.
+type payloadCase struct {
+	name        string
+	description string
+	fork        string // Paris, Shanghai, Cancun or Prague
+	txs         []types.TxData
+	withdrawals []*types.Withdrawal
+	extra       []byte
+	baseFee     *big.Int
+	requests    [][]byte // Prague
+	extreme     bool     // the maximum values of the integer fields
+}
+
+func payloadCases() []payloadCase {
+	var (
+		maxBaseFee = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+		deposit    = append([]byte{0x00}, bytes.Repeat([]byte{0xdd}, 192)...)
+		withdrawal = append([]byte{0x01}, bytes.Repeat([]byte{0xee}, 76)...)
+		call       = &types.LegacyTx{Nonce: 0, GasPrice: big.NewInt(10), Gas: 50000, To: &recipient, Value: big.NewInt(1), Data: []byte{0x01, 0x02}}
+		create     = &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(10), Gas: 100000, Data: common.FromHex("0x600160005260206000f3")}
+		accessList = &types.AccessListTx{ChainID: chainID, Nonce: 2, GasPrice: big.NewInt(10), Gas: 50000, To: &recipient,
+			AccessList: types.AccessList{{Address: recipient, StorageKeys: []common.Hash{{0x01}}}}}
+		dynamicFee = &types.DynamicFeeTx{ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10), Gas: 50000, To: &recipient}
+		large      = &types.DynamicFeeTx{ChainID: chainID, Nonce: 4, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10), Gas: 5000000, To: &recipient, Data: bytes.Repeat([]byte{0xab}, 4096)}
+		blob       = &types.BlobTx{ChainID: uint256.MustFromBig(chainID), Nonce: 5, GasTipCap: uint256.NewInt(1), GasFeeCap: uint256.NewInt(10), Gas: 50000, To: recipient,
+			BlobFeeCap: uint256.NewInt(1), BlobHashes: []common.Hash{blobHash(1), blobHash(2)}}
+		setCode = &types.SetCodeTx{ChainID: uint256.MustFromBig(chainID), Nonce: 6, GasTipCap: uint256.NewInt(1), GasFeeCap: uint256.NewInt(10), Gas: 100000, To: recipient,
+			AuthList: []types.SetCodeAuthorization{authorization(recipient, 7)}}
+	)
+	return []payloadCase{
+		{name: "paris_empty", description: "Bellatrix payload without transactions and extra data",
+			fork: "Paris"},
+		{name: "paris_transactions", description: "Bellatrix payload with legacy, contract creation, access list and dynamic fee transactions",
+			fork: "Paris", txs: []types.TxData{call, create, accessList, dynamicFee}, extra: []byte("ssz")},
+		{name: "paris_large_transaction", description: "Bellatrix payload with a transaction of 4 KiB of calldata, spanning many chunks of its byte list",
+			fork: "Paris", txs: []types.TxData{large}},
+		{name: "paris_max_extra_data", description: "Bellatrix payload with 32 bytes of extra data, the maximum",
+			fork: "Paris", extra: bytes.Repeat([]byte{0xee}, ssz.MaxExtraDataBytes)},
+		{name: "paris_max_base_fee", description: "Bellatrix payload with the maximum uint256 base fee, encoded little endian",
+			fork: "Paris", baseFee: maxBaseFee},
+		{name: "shanghai_no_withdrawals", description: "Capella payload with an empty withdrawals list",
+			fork: "Shanghai", withdrawals: []*types.Withdrawal{}},
+		{name: "shanghai_withdrawal", description: "Capella payload with a transaction and a withdrawal",
+			fork: "Shanghai", txs: []types.TxData{dynamicFee}, withdrawals: withdrawals(1)},
+		{name: "shanghai_max_withdrawals", description: "Capella payload with 16 withdrawals, the maximum",
+			fork: "Shanghai", withdrawals: withdrawals(ssz.MaxWithdrawalsPerPayload)},
+		{name: "cancun_blob_transaction", description: "Deneb payload with a blob transaction of two blobs",
+			fork: "Cancun", txs: []types.TxData{dynamicFee, blob}, withdrawals: withdrawals(2)},
+		{name: "cancun_extreme_values", description: "Deneb payload with the maximum number, gas, timestamp, blob gas and base fee",
+			fork: "Cancun", withdrawals: []*types.Withdrawal{{Index: math.MaxUint64, Validator: math.MaxUint64, Address: recipient, Amount: math.MaxUint64}},
+			baseFee: maxBaseFee, extreme: true},
+		{name: "prague_no_requests", description: "Electra payload without execution requests, which leave the payload unchanged",
+			fork: "Prague", txs: []types.TxData{setCode}, withdrawals: []*types.Withdrawal{}, requests: [][]byte{}},
+		{name: "prague_requests", description: "Electra payload of a block with a deposit and a withdrawal request, committed to by its requests hash only",
+			fork: "Prague", txs: []types.TxData{blob, setCode}, withdrawals: withdrawals(1), requests: [][]byte{deposit, withdrawal}},
+	}
+}
+
+// blobHash returns a versioned hash, the KZG version byte 0x01 followed by
+// filler.
+func blobHash(i byte) common.Hash {
+	hash := common.Hash{0x01}
+	for j := 1; j < common.HashLength; j++ {
+		hash[j] = i
+	}
+	return hash
+}
+
+func authorization(address common.Address, nonce uint64) types.SetCodeAuthorization {
+	auth, err := types.SignSetCode(senderKey, types.SetCodeAuthorization{ChainID: *uint256.MustFromBig(chainID), Address: address, Nonce: nonce})
+	if err != nil {
+		panic(err)
+	}
+	return auth
+}
+
+// withdrawals returns n withdrawals of distinct indexes, validators,
+// addresses and amounts.
+func withdrawals(n int) []*types.Withdrawal {
+	ws := make([]*types.Withdrawal, n)
+	for i := range ws {
+		ws[i] = &types.Withdrawal{
+			Index:     uint64(1000 + i),
+			Validator: uint64(2000 + i),
+			Address:   common.BigToAddress(big.NewInt(int64(0x3000 + i))),
+			Amount:    uint64(32000000000 + i),
+		}
+	}
+	return ws
+}
+
+// block builds the block of the case. The roots of the header are arbitrary,
+// the block is never executed.
+func (c *payloadCase) block() (*types.Block, error) {
+	header := &types.Header{
+		ParentHash:  common.Hash{0x01},
+		Coinbase:    common.Address{0x02},
+		Root:        common.Hash{0x03},
+		ReceiptHash: common.Hash{0x04},
+		Bloom:       types.BytesToBloom(bytes.Repeat([]byte{0x05}, types.BloomByteLength)),
+		Difficulty:  new(big.Int),
+		Number:      big.NewInt(6),
+		GasLimit:    30000000,
+		GasUsed:     21000,
+		Time:        8,
+		Extra:       c.extra,
+		MixDigest:   common.Hash{0x09},
+		BaseFee:     big.NewInt(params.InitialBaseFee),
+	}
+	if c.baseFee != nil {
+		header.BaseFee = c.baseFee
+	}
+	if c.fork == "Cancun" || c.fork == "Prague" {
+		blobGasUsed, excessBlobGas := uint64(0), uint64(10*params.BlobTxBlobGasPerBlob)
+		beaconRoot := common.Hash{0x0b}
+		header.BlobGasUsed, header.ExcessBlobGas, header.ParentBeaconRoot = &blobGasUsed, &excessBlobGas, &beaconRoot
+	}
+	if c.extreme {
+		blobGasUsed, excessBlobGas := uint64(math.MaxUint64), uint64(math.MaxUint64)
+		header.Number = new(big.Int).SetUint64(math.MaxUint64)
+		header.GasLimit, header.GasUsed, header.Time = math.MaxUint64, math.MaxUint64, math.MaxUint64
+		header.BlobGasUsed, header.ExcessBlobGas = &blobGasUsed, &excessBlobGas
+	}
+	if c.requests != nil {
+		requestsHash := types.CalcRequestsHash(c.requests)
+		header.RequestsHash = &requestsHash
+	}
+	body := &types.Body{Withdrawals: c.withdrawals}
+	for i, data := range c.txs {
+		tx, err := types.SignNewTx(senderKey, signer, data)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		body.Transactions = append(body.Transactions, tx)
+		if !c.extreme && header.BlobGasUsed != nil {
+			*header.BlobGasUsed += tx.BlobGas()
+		}
+	}
+	if (c.fork == "Paris") != (c.withdrawals == nil) {
+		return nil, fmt.Errorf("withdrawals do not match the %s fork", c.fork)
+	}
+	return types.NewBlock(header, body, nil, trie.NewStackTrie(nil)), nil
+}
+
+// withdrawalsVector is the EIP-6465 withdrawals root of a withdrawals list
+// along with its trie root, the withdrawals root of the block header.
+type withdrawalsVector struct {
+	Name        string              `json:"name"`
+	Withdrawals []*types.Withdrawal `json:"withdrawals"`
+	SSZRoot     common.Hash         `json:"sszRoot"`
+	TrieRoot    common.Hash         `json:"trieRoot"`
+}
+
+// vectorFile is a vector file with the info of the tool filling it.
+type vectorFile struct {
+	Info    map[string]string `json:"_info"`
+	Vectors interface{}       `json:"vectors"`
+}
+
+// writeVectors builds the handcrafted blocks, checks the round trip of their
+// payloads and writes the vectors.
+func writeVectors(output string) error {
+	var payloads []*payloadVector
+	for _, c := range payloadCases() {
+		block, err := c.block()
+		if err != nil {
+			return fmt.Errorf("case %s: %v", c.name, err)
+		}
+		v, err := blockVector(block, c.requests)
+		if err != nil {
+			return fmt.Errorf("case %s: %v", c.name, err)
+		}
+		v.Name, v.Description = c.name, c.description
+		payloads = append(payloads, v)
+	}
+	var lists []withdrawalsVector
+	for _, n := range []int{0, 1, 2, 5, ssz.MaxWithdrawalsPerPayload} {
+		ws := withdrawals(n)
+		lists = append(lists, withdrawalsVector{
+			Name:        fmt.Sprintf("withdrawals_%d", n),
+			Withdrawals: ws,
+			SSZRoot:     ssz.WithdrawalsRoot(ws),
+			TrieRoot:    types.DeriveSha(types.Withdrawals(ws), trie.NewStackTrie(nil)),
+		})
+	}
+	transactions, receipts, err := containerVectors()
+	if err != nil {
+		return err
+	}
+	files := map[string]interface{}{
+		"payloads.json":     payloads,
+		"withdrawals.json":  lists,
+		"transactions.json": transactions,
+		"receipts.json":     receipts,
+	}
+	if err := write(output, files, "handcrafted"); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d payload, %d withdrawals, %d transaction and %d receipt vectors to %s\n", len(payloads), len(lists), len(transactions), len(receipts), output)
+	return nil
+}
+
+// writeFixtureVectors writes the vectors of the valid blocks of blockchain
+// test fixtures. The requests of Prague blocks are those of their execution,
+// the fixtures are converted as engine fixtures to obtain them.
+func writeFixtureVectors(file, test, output string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var fixtures map[string]*blocktest.Fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	names := make([]string, 0, len(fixtures))
+	for name := range fixtures {
+		if test == "" || name == test {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%s: no test %q", file, test)
+	}
+	sort.Strings(names)
+	var payloads []*payloadVector
+	for _, name := range names {
+		ef, err := fixtures[name].Engine()
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		for i, p := range ef.Payloads {
+			if p.ExpectException != "" {
+				continue
+			}
+			var requests [][]byte
+			if p.Version >= 4 {
+				requests = make([][]byte, len(p.ExecutionRequests))
+				for j, r := range p.ExecutionRequests {
+					requests[j] = r
+				}
+			}
+			v, err := newVector(p.ExecutionPayload, p.BeaconRoot, requests, true)
+			if err != nil {
+				return fmt.Errorf("%s: block %d: %v", name, i, err)
+			}
+			v.Name = fmt.Sprintf("%s_block_%d", name, i)
+			payloads = append(payloads, v)
+		}
+	}
+	if err := write(output, map[string]interface{}{"fixtures.json": payloads}, filepath.Base(file)); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d payload vectors of %d tests to %s\n", len(payloads), len(names), output)
+	return nil
+}
+
+func write(output string, files map[string]interface{}, source string) error {
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return err
+	}
+	for name, vectors := range files {
+		data, err := json.MarshalIndent(vectorFile{Info: info(name, source), Vectors: vectors}, "", "    ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(output, name), append(data, '\n'), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func info(file, source string) map[string]string {
+	descriptions := map[string]string{
+		"payloads.json":     "SSZ encodings and hash tree roots of the ExecutionPayload of handcrafted blocks",
+		"withdrawals.json":  "hash tree roots of withdrawal lists, the withdrawals root of EIP-6465",
+		"fixtures.json":     "SSZ encodings and hash tree roots of the ExecutionPayload of the valid blocks of blockchain tests",
+		"transactions.json": "RLP and EIP-6404 SSZ encodings and hash tree roots of handcrafted transactions",
+		"receipts.json":     "RLP and EIP-6466 SSZ encodings and hash tree roots of handcrafted receipts",
+	}
+	return statetest.Info("ssz-vectors", source, descriptions[file])
+}
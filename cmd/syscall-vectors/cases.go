@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Constants of the system contracts.
+const (
+	historyBufferLength = 8191 // EIP-4788 and EIP-2935 ring buffer length
+
+	maxWithdrawalRequests    = 16 // EIP-7002 requests dequeued per block
+	maxConsolidationRequests = 2  // EIP-7251 requests dequeued per block
+
+	// The queue contracts keep the excess number of requests in slot 0.
+	excessSlot = 0
+)
+
+var (
+	beaconRoot  = common.HexToHash("0x4788000000000000000000000000000000000000000000000000000000000001")
+	beaconRoot2 = common.HexToHash("0x4788000000000000000000000000000000000000000000000000000000000002")
+
+	// revertCode reverts without output, invalidCode halts exceptionally.
+	revertCode  = []byte{byte(vm.PUSH0), byte(vm.PUSH0), byte(vm.REVERT)}
+	invalidCode = []byte{byte(vm.INVALID)}
+)
+
+// testCase is a chain exercising the system calls, built on top of a genesis
+// holding the system contracts of the fork.
+type testCase struct {
+	name        string
+	description string
+	fork        string // first fork the case applies to
+
+	// alloc modifies the genesis allocation, e.g. removing a system contract.
+	alloc func(alloc types.GenesisAlloc)
+
+	// blocks returns the blocks of the chain. Their transactions are sent by
+	// the sender, starting at nonce 0.
+	blocks func() ([]*blocktest.Block, error)
+
+	// invalid, if set, is the exception of an empty block on top of the
+	// genesis, which must be rejected because a checked system call fails.
+	invalid string
+
+	// check verifies the fixture of the chain.
+	check func(f *blocktest.Fixture) error
+}
+
+// withoutCode returns an alloc modification removing the code of a system
+// contract, or replacing it if code is given.
+func withoutCode(addr common.Address, code []byte) func(types.GenesisAlloc) {
+	return func(alloc types.GenesisAlloc) {
+		if code == nil {
+			delete(alloc, addr)
+			return
+		}
+		account := alloc[addr]
+		account.Code = code
+		alloc[addr] = account
+	}
+}
+
+// emptyBlocks returns n blocks without transactions.
+func emptyBlocks(n int) func() ([]*blocktest.Block, error) {
+	return func() ([]*blocktest.Block, error) {
+		blocks := make([]*blocktest.Block, n)
+		for i := range blocks {
+			blocks[i] = new(blocktest.Block)
+		}
+		return blocks, nil
+	}
+}
+
+// beaconBlock returns a block with the given timestamp and parent beacon root.
+func beaconBlock(timestamp uint64, root common.Hash) *blocktest.Block {
+	t := math.HexOrDecimal64(timestamp)
+	return &blocktest.Block{Header: blocktest.HeaderOverrides{Timestamp: &t, ParentBeaconBlockRoot: &root}}
+}
+
+// validatorPubkey returns a distinct 48 byte validator public key.
+func validatorPubkey(i int) []byte {
+	pubkey := make([]byte, 48)
+	pubkey[0] = 0xb0
+	binary.BigEndian.PutUint32(pubkey[44:], uint32(i))
+	return pubkey
+}
+
+// withdrawal is an EIP-7002 withdrawal request of the sender.
+type withdrawal struct {
+	pubkey []byte
+	amount uint64
+}
+
+// input returns the calldata adding the request to the queue.
+func (w withdrawal) input() []byte {
+	return binary.BigEndian.AppendUint64(bytes.Clone(w.pubkey), w.amount)
+}
+
+// data returns the request data dequeued by the system call. Unlike in the
+// calldata, the amount is little endian.
+func (w withdrawal) data() []byte {
+	return append(append(sender.Bytes(), w.pubkey...), binary.LittleEndian.AppendUint64(nil, w.amount)...)
+}
+
+// consolidation is an EIP-7251 consolidation request of the sender.
+type consolidation struct {
+	source, target []byte
+}
+
+// input returns the calldata adding the request to the queue.
+func (c consolidation) input() []byte {
+	return append(bytes.Clone(c.source), c.target...)
+}
+
+// data returns the request data dequeued by the system call.
+func (c consolidation) data() []byte {
+	return append(append(sender.Bytes(), c.source...), c.target...)
+}
+
+// requests returns the EIP-7685 requests list of a block dequeuing the given
+// withdrawal and consolidation requests.
+func requests(withdrawals []withdrawal, consolidations []consolidation) [][]byte {
+	list := [][]byte{}
+	if len(withdrawals) > 0 {
+		item := []byte{0x01}
+		for _, w := range withdrawals {
+			item = append(item, w.data()...)
+		}
+		list = append(list, item)
+	}
+	if len(consolidations) > 0 {
+		item := []byte{0x02}
+		for _, c := range consolidations {
+			item = append(item, c.data()...)
+		}
+		list = append(list, item)
+	}
+	return list
+}
+
+// requestBlocks returns blocks making the given calls, split at the given
+// boundaries: [n] puts the first n calls into the first block and the
+// remaining ones into a second.
+func requestBlocks(calls []call, boundaries ...int) func() ([]*blocktest.Block, error) {
+	return func() ([]*blocktest.Block, error) {
+		boundaries := append(append([]int{0}, boundaries...), len(calls))
+		var blocks []*blocktest.Block
+		for i := 1; i < len(boundaries); i++ {
+			block := new(blocktest.Block)
+			for nonce := boundaries[i-1]; nonce < boundaries[i]; nonce++ {
+				tx, err := calls[nonce].transaction(uint64(nonce))
+				if err != nil {
+					return nil, err
+				}
+				enc, err := tx.MarshalBinary()
+				if err != nil {
+					return nil, err
+				}
+				block.Transactions = append(block.Transactions, enc)
+			}
+			blocks = append(blocks, block)
+		}
+		return blocks, nil
+	}
+}
+
+// call is a transaction of the sender to a queue contract.
+type call struct {
+	to    common.Address
+	input []byte
+	fee   int64
+}
+
+// withdrawalCalls returns the calls adding the withdrawal requests, each
+// paying fee.
+func withdrawalCalls(withdrawals []withdrawal, fee int64) []call {
+	calls := make([]call, len(withdrawals))
+	for i, w := range withdrawals {
+		calls[i] = call{params.WithdrawalQueueAddress, w.input(), fee}
+	}
+	return calls
+}
+
+// consolidationCalls returns the calls adding the consolidation requests,
+// each paying fee.
+func consolidationCalls(consolidations []consolidation, fee int64) []call {
+	calls := make([]call, len(consolidations))
+	for i, c := range consolidations {
+		calls[i] = call{params.ConsolidationQueueAddress, c.input(), fee}
+	}
+	return calls
+}
+
+// checkRequests returns a check of the requests hash of every block.
+func checkRequests(blocks ...[][]byte) blocktest.Check {
+	return func(f *blocktest.Fixture) error {
+		if len(f.Blocks) != len(blocks) {
+			return fmt.Errorf("%d blocks, expected %d", len(f.Blocks), len(blocks))
+		}
+		for i, list := range blocks {
+			want := types.CalcRequestsHash(list)
+			if have := f.Blocks[i].BlockHeader.RequestsHash; have == nil || *have != want {
+				return fmt.Errorf("block %d: requests hash %v, expected %s for the requests %x", i+1, have, want.Hex(), list)
+			}
+		}
+		return nil
+	}
+}
+
+// checkHistory returns a check that the history contract holds the hash of
+// every block but the head.
+func checkHistory(f *blocktest.Fixture) error {
+	want := map[uint64]common.Hash{0: f.Genesis.Hash}
+	for i, block := range f.Blocks[:len(f.Blocks)-1] {
+		want[uint64(i+1)%historyBufferLength] = block.BlockHeader.Hash
+	}
+	return blocktest.CheckStorage(params.HistoryStorageAddress, want)(f)
+}
+
+// cases returns the handcrafted test cases.
+func cases() []testCase {
+	var (
+		oneWithdrawal  = []withdrawal{{validatorPubkey(0), 1000000000}}
+		manyWithdrawal = make([]withdrawal, maxWithdrawalRequests+1)
+		oneConsol      = []consolidation{{validatorPubkey(0), validatorPubkey(1)}}
+		manyConsol     = make([]consolidation, maxConsolidationRequests+1)
+	)
+	for i := range manyWithdrawal {
+		manyWithdrawal[i] = withdrawal{validatorPubkey(i), uint64(i)}
+	}
+	for i := range manyConsol {
+		manyConsol[i] = consolidation{validatorPubkey(2 * i), validatorPubkey(2*i + 1)}
+	}
+	return []testCase{
+		{
+			name:        "beacon_root",
+			description: "the parent beacon block root is stored under the timestamp of the block",
+			fork:        "Cancun",
+			blocks: func() ([]*blocktest.Block, error) {
+				return []*blocktest.Block{beaconBlock(100, beaconRoot)}, nil
+			},
+			check: blocktest.CheckStorage(params.BeaconRootsAddress, map[uint64]common.Hash{
+				100:                       common.BigToHash(big.NewInt(100)),
+				100 + historyBufferLength: beaconRoot,
+			}),
+		},
+		{
+			name:        "beacon_root_ring_buffer",
+			description: "a timestamp one buffer length later overwrites the entries of the earlier one",
+			fork:        "Cancun",
+			blocks: func() ([]*blocktest.Block, error) {
+				return []*blocktest.Block{beaconBlock(100, beaconRoot), beaconBlock(100+historyBufferLength, beaconRoot2)}, nil
+			},
+			check: blocktest.CheckStorage(params.BeaconRootsAddress, map[uint64]common.Hash{
+				100:                       common.BigToHash(big.NewInt(100 + historyBufferLength)),
+				100 + historyBufferLength: beaconRoot2,
+			}),
+		},
+		{
+			name:        "beacon_root_missing_code",
+			description: "the beacon root system call to an account without code does nothing",
+			fork:        "Cancun",
+			alloc:       withoutCode(params.BeaconRootsAddress, nil),
+			blocks: func() ([]*blocktest.Block, error) {
+				return []*blocktest.Block{beaconBlock(100, beaconRoot)}, nil
+			},
+			check: blocktest.CheckAbsent(params.BeaconRootsAddress),
+		},
+		{
+			name:        "beacon_root_call_failed",
+			description: "a failing beacon root system call is ignored",
+			fork:        "Cancun",
+			alloc:       withoutCode(params.BeaconRootsAddress, revertCode),
+			blocks: func() ([]*blocktest.Block, error) {
+				return []*blocktest.Block{beaconBlock(100, beaconRoot)}, nil
+			},
+			check: blocktest.CheckStorage(params.BeaconRootsAddress, nil),
+		},
+		{
+			name:        "history_storage",
+			description: "every block stores the hash of its parent under the number of the parent",
+			fork:        "Prague",
+			blocks:      emptyBlocks(3),
+			check:       checkHistory,
+		},
+		{
+			name:        "history_storage_missing_code",
+			description: "the history system call to an account without code does nothing",
+			fork:        "Prague",
+			alloc:       withoutCode(params.HistoryStorageAddress, nil),
+			blocks:      emptyBlocks(2),
+			check:       blocktest.CheckAbsent(params.HistoryStorageAddress),
+		},
+		{
+			name:        "withdrawal_request",
+			description: "a withdrawal request is dequeued at the end of the block adding it",
+			fork:        "Prague",
+			blocks:      requestBlocks(withdrawalCalls(oneWithdrawal, 1)),
+			check:       checkRequests(requests(oneWithdrawal, nil)),
+		},
+		{
+			name:        "withdrawal_request_fee_too_low",
+			description: "a withdrawal request paying less than the fee is rejected by the contract",
+			fork:        "Prague",
+			blocks:      requestBlocks(withdrawalCalls(oneWithdrawal, 0)),
+			check:       checkRequests(requests(nil, nil)),
+		},
+		{
+			name:        "withdrawal_requests_dequeue_limit",
+			description: "at most 16 withdrawal requests are dequeued per block, the excess grows by the requests above the target of 2",
+			fork:        "Prague",
+			blocks:      requestBlocks(withdrawalCalls(manyWithdrawal, 1), len(manyWithdrawal)),
+			check: blocktest.All(
+				checkRequests(requests(manyWithdrawal[:maxWithdrawalRequests], nil), requests(manyWithdrawal[maxWithdrawalRequests:], nil)),
+				blocktest.CheckSlot(params.WithdrawalQueueAddress, excessSlot, common.BigToHash(big.NewInt(int64(len(manyWithdrawal)-2-2)))),
+			),
+		},
+		{
+			name:        "consolidation_request",
+			description: "a consolidation request is dequeued at the end of the block adding it",
+			fork:        "Prague",
+			blocks:      requestBlocks(consolidationCalls(oneConsol, 1)),
+			check:       checkRequests(requests(nil, oneConsol)),
+		},
+		{
+			name:        "consolidation_requests_dequeue_limit",
+			description: "at most 2 consolidation requests are dequeued per block",
+			fork:        "Prague",
+			blocks:      requestBlocks(consolidationCalls(manyConsol, 1), len(manyConsol)),
+			check:       checkRequests(requests(nil, manyConsol[:maxConsolidationRequests]), requests(nil, manyConsol[maxConsolidationRequests:])),
+		},
+		{
+			name:        "withdrawal_and_consolidation_requests",
+			description: "the requests list holds the withdrawal requests before the consolidation requests",
+			fork:        "Prague",
+			blocks:      requestBlocks(append(consolidationCalls(oneConsol, 1), withdrawalCalls(oneWithdrawal, 1)...)),
+			check:       checkRequests(requests(oneWithdrawal, oneConsol)),
+		},
+		{
+			name:        "withdrawal_queue_missing_code",
+			description: "a block is invalid if the withdrawal request contract has no code",
+			fork:        "Prague",
+			alloc:       withoutCode(params.WithdrawalQueueAddress, nil),
+			invalid:     "BlockException.SYSTEM_CONTRACT_EMPTY",
+		},
+		{
+			name:        "consolidation_queue_missing_code",
+			description: "a block is invalid if the consolidation request contract has no code",
+			fork:        "Prague",
+			alloc:       withoutCode(params.ConsolidationQueueAddress, nil),
+			invalid:     "BlockException.SYSTEM_CONTRACT_EMPTY",
+		},
+		{
+			name:        "withdrawal_queue_call_failed",
+			description: "a block is invalid if the withdrawal request system call reverts",
+			fork:        "Prague",
+			alloc:       withoutCode(params.WithdrawalQueueAddress, revertCode),
+			invalid:     "BlockException.SYSTEM_CONTRACT_CALL_FAILED",
+		},
+		{
+			name:        "consolidation_queue_call_failed",
+			description: "a block is invalid if the consolidation request system call halts exceptionally",
+			fork:        "Prague",
+			alloc:       withoutCode(params.ConsolidationQueueAddress, invalidCode),
+			invalid:     "BlockException.SYSTEM_CONTRACT_CALL_FAILED",
+		},
+	}
+}
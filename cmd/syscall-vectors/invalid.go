@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Exceptions of blocks whose checked system call fails.
+const (
+	exceptionEmpty      = "BlockException.SYSTEM_CONTRACT_EMPTY"
+	exceptionCallFailed = "BlockException.SYSTEM_CONTRACT_CALL_FAILED"
+)
+
+// checkedCalls are the system calls which invalidate the block if the contract
+// has no code or the call fails, in the order they are made.
+var checkedCalls = []struct {
+	addr    common.Address
+	process func(*[][]byte, *vm.EVM) error
+}{
+	{params.WithdrawalQueueAddress, core.ProcessWithdrawalQueue},
+	{params.ConsolidationQueueAddress, core.ProcessConsolidationQueue},
+}
+
+// appendInvalid appends an empty block on top of the genesis to the fixture,
+// which is invalid with the given exception, and imports it into go-ethereum.
+func appendInvalid(f *blocktest.Fixture, genesis *core.Genesis, exception string) error {
+	config := core.DefaultConfig()
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), genesis, beacon.New(ethash.NewFaker()), config)
+	if err != nil {
+		return err
+	}
+	defer chain.Stop()
+	if hash := chain.Genesis().Hash(); hash != f.Genesis.Hash {
+		return fmt.Errorf("genesis %x, fixture genesis %x", hash, f.Genesis.Hash)
+	}
+	block, failed, err := assembleInvalid(chain)
+	if err != nil {
+		return err
+	}
+	if failed != exception {
+		return fmt.Errorf("block fails with %s, expected %s", failed, exception)
+	}
+	enc, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return err
+	}
+	f.Blocks = append(f.Blocks, &blocktest.FixtureBlock{RLP: enc, ExpectException: exception})
+
+	_, err = chain.InsertChain(types.Blocks{block})
+	switch {
+	case exception == exceptionEmpty && err != nil:
+		// go-ethereum skips the calls into empty contracts, anything else
+		// rejecting the block is a fault of the block.
+		return fmt.Errorf("block rejected for another reason: %v", err)
+	case exception == exceptionCallFailed && err == nil:
+		return errors.New("block accepted")
+	case exception == exceptionCallFailed && !strings.Contains(err.Error(), "system call failed"):
+		return fmt.Errorf("block rejected for another reason: %v", err)
+	}
+	return nil
+}
+
+// assembleInvalid builds an empty block on top of the genesis of the chain,
+// making the system calls the way a valid block would: the header commits to
+// the state and requests of the calls, with a failing call contributing
+// nothing. It returns the block together with the exception of the first
+// failing checked call.
+func assembleInvalid(chain *core.BlockChain) (*types.Block, string, error) {
+	var (
+		parent = chain.Genesis().Header()
+		config = chain.Config()
+		header = &types.Header{
+			ParentHash:       parent.Hash(),
+			UncleHash:        types.EmptyUncleHash,
+			Coinbase:         parent.Coinbase,
+			Number:           new(big.Int).Add(parent.Number, common.Big1),
+			GasLimit:         core.CalcGasLimit(parent.GasLimit, parent.GasLimit),
+			Time:             parent.Time + 10,
+			Difficulty:       new(big.Int),
+			BaseFee:          eip1559.CalcBaseFee(config, parent),
+			WithdrawalsHash:  &types.EmptyWithdrawalsHash,
+			BlobGasUsed:      new(uint64),
+			ParentBeaconRoot: new(common.Hash),
+		}
+	)
+	excess := eip4844.CalcExcessBlobGas(config, parent, header.Time)
+	header.ExcessBlobGas = &excess
+
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return nil, "", err
+	}
+	evm := vm.NewEVM(core.NewEVMBlockContext(header, chain, nil), statedb, config, vm.Config{})
+	core.ProcessBeaconBlockRoot(*header.ParentBeaconRoot, evm)
+	core.ProcessParentBlockHash(header.ParentHash, evm)
+
+	var (
+		requests = [][]byte{}
+		failed   string
+	)
+	for _, call := range checkedCalls {
+		empty := statedb.GetCodeSize(call.addr) == 0
+		err := call.process(&requests, evm)
+		switch {
+		case failed != "":
+		case empty:
+			failed = exceptionEmpty
+		case err != nil:
+			failed = exceptionCallFailed
+		}
+	}
+	if failed == "" {
+		return nil, "", errors.New("no checked system call fails")
+	}
+	header.Root = statedb.IntermediateRoot(true)
+	requestsHash := types.CalcRequestsHash(requests)
+	header.RequestsHash = &requestsHash
+	return types.NewBlock(header, &types.Body{Withdrawals: []*types.Withdrawal{}}, nil, trie.NewStackTrie(nil)), failed, nil
+}
@@ -0,0 +1,158 @@
+// syscall-vectors generates vectors of the system calls surrounding block
+// execution in the format of the blockchain_tests fixtures of the execution
+// spec tests, using go-ethereum to build and import the chains.
+//
+// Usage:
+//
+//	go run ./cmd/syscall-vectors [--forks Cancun,Prague] [--output syscall_vectors.json]
+//
+// The vectors cover the EIP-4788 beacon root update, including its ring
+// buffer, the EIP-2935 history write and the EIP-7002 and EIP-7251 request
+// dequeuing, including the per block limits and the ordering of the requests
+// list. The EIP-4788 and EIP-2935 calls are unchecked: a missing or failing
+// contract leaves the block valid. The request calls are checked: a block is
+// invalid if the contract has no code or the call fails, which the vectors
+// cover with an otherwise valid empty block, its header committing to the
+// remaining system calls.
+//
+// The expected post-states and requests hashes are declared with each vector
+// and checked before anything is written. The chains are imported into
+// go-ethereum, which must reject blocks whose request call fails. It does not
+// implement the missing code check and must accept these blocks instead,
+// confirming that they violate nothing else.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// The parameters of the genesis and the transactions.
+const (
+	gasLimit = 30000000
+	baseFee  = 7
+	feeCap   = 10
+	tipCap   = 1
+	txGas    = 500000
+)
+
+var (
+	chainID = big.NewInt(1)
+
+	senderKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	sender       = crypto.PubkeyToAddress(senderKey.PublicKey)
+)
+
+func main() {
+	var (
+		forkList = flag.String("forks", "Cancun,Prague", "comma separated forks the vectors are built for")
+		output   = flag.String("output", "syscall_vectors.json", "file the fixtures are written to")
+	)
+	flag.Parse()
+	cancun, _ := forks.Index("Cancun")
+	forkNames := strings.Split(*forkList, ",")
+	for _, fork := range forkNames {
+		if _, ok := tests.Forks[fork]; !ok {
+			fatalf("unknown fork %q", fork)
+		}
+		if index, err := forks.Index(fork); err != nil || index < cancun {
+			fatalf("fork %s predates the system calls", fork)
+		}
+	}
+	fixtures := make(map[string]*blocktest.Fixture)
+	for _, c := range cases() {
+		first, _ := forks.Index(c.fork)
+		for _, fork := range forkNames {
+			if index, _ := forks.Index(fork); index < first {
+				continue
+			}
+			f, err := fill(&c, fork)
+			if err != nil {
+				fatalf("case %s: %s: %v", c.name, fork, err)
+			}
+			fixtures["syscall_vectors/"+c.name+"/"+fork] = f
+		}
+	}
+	data, err := json.MarshalIndent(fixtures, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d fixtures to %s\n", len(fixtures), *output)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// fill builds the fixture of a test case on a fork and verifies it.
+func fill(c *testCase, fork string) (*blocktest.Fixture, error) {
+	config := tests.Forks[fork]
+	genesis := &core.Genesis{
+		Config:     config,
+		GasLimit:   gasLimit,
+		BaseFee:    big.NewInt(baseFee),
+		Difficulty: new(big.Int),
+		Alloc:      gen.SystemContractAlloc(config),
+	}
+	genesis.Alloc[sender] = types.Account{Balance: big.NewInt(params.Ether)}
+	if c.alloc != nil {
+		c.alloc(genesis.Alloc)
+	}
+	var (
+		blocks []*blocktest.Block
+		err    error
+	)
+	if c.blocks != nil {
+		if blocks, err = c.blocks(); err != nil {
+			return nil, err
+		}
+	}
+	f, err := blocktest.Build(genesis, fork, blocks)
+	if err != nil {
+		return nil, err
+	}
+	if c.invalid != "" {
+		if err := appendInvalid(f, genesis, c.invalid); err != nil {
+			return nil, err
+		}
+	}
+	if c.check != nil {
+		if err := c.check(f); err != nil {
+			return nil, err
+		}
+	}
+	f.Info = statetest.Info("syscall-vectors", "handcrafted", c.description)
+	return f, nil
+}
+
+// transaction returns the signed transaction making the call.
+func (c call) transaction(nonce uint64) (*types.Transaction, error) {
+	return types.SignNewTx(senderKey, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(tipCap),
+		GasFeeCap: big.NewInt(feeCap),
+		Gas:       txGas,
+		To:        &c.to,
+		Value:     big.NewInt(c.fee),
+		Data:      c.input,
+	})
+}
@@ -0,0 +1,298 @@
+// t8n-diff runs the same state transition through the EELS t8n tool and the
+// geth evm t8n tool and reports where their outputs diverge.
+//
+// Usage:
+//
+//	go run ./cmd/t8n-diff --input.alloc alloc.json --input.env env.json \
+//	    --input.txs txs.json --state.fork London
+//
+// Both tools are invoked with their outputs on stdout. The receipts are
+// compared first in transaction order, followed by the rejected transactions,
+// the post-state accounts in address order and finally the remaining result
+// fields (roots, logs hash and bloom, gas used, base fee, difficulty, ...).
+// Receipt fields only emitted by one of the tools are ignored. Numbers are
+// compared by value, so differently padded hex encodings are equal.
+//
+// The first divergence is printed and the tool exits with a nonzero code. With
+// --all every divergence is listed instead.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// t8nOutput is the combined stdout output of a t8n run.
+type t8nOutput struct {
+	Alloc  map[common.Address]t8nAccount `json:"alloc"`
+	Result map[string]json.RawMessage    `json:"result"`
+}
+
+// t8nAccount is a post-state account. The tools omit zero fields, so all of
+// them are optional.
+type t8nAccount struct {
+	Balance *math.HexOrDecimal256 `json:"balance"`
+	Nonce   math.HexOrDecimal64   `json:"nonce"`
+	Code    hexutil.Bytes         `json:"code"`
+	Storage map[string]string     `json:"storage"`
+}
+
+// divergence is a single difference between the two outputs. Missing values
+// are reported as "none".
+type divergence struct {
+	Path string
+	EELS string
+	Geth string
+}
+
+func main() {
+	var (
+		alloc   = flag.String("input.alloc", "alloc.json", "pre-state allocation")
+		env     = flag.String("input.env", "env.json", "block environment")
+		txs     = flag.String("input.txs", "txs.json", "transactions, as JSON or RLP")
+		fork    = flag.String("state.fork", "Frontier", "fork rules to apply")
+		chainID = flag.Int64("state.chainid", 1, "chain id")
+		reward  = flag.Int64("state.reward", 0, "block reward, -1 to disable")
+		eels    = flag.String("eels", "ethereum-spec-evm", "command running the EELS evm tool")
+		geth    = flag.String("geth", "evm", "command running the geth evm tool")
+		all     = flag.Bool("all", false, "report every divergence instead of the first one")
+	)
+	flag.Parse()
+
+	args := []string{
+		"t8n",
+		"--input.alloc", *alloc,
+		"--input.env", *env,
+		"--input.txs", *txs,
+		"--output.alloc", "stdout",
+		"--output.result", "stdout",
+		"--state.fork", *fork,
+		"--state.chainid", strconv.FormatInt(*chainID, 10),
+		"--state.reward", strconv.FormatInt(*reward, 10),
+	}
+	eelsOut, err := runT8n(*eels, args)
+	if err != nil {
+		fatalf("EELS t8n failed: %v", err)
+	}
+	gethOut, err := runT8n(*geth, args)
+	if err != nil {
+		fatalf("geth t8n failed: %v", err)
+	}
+	divergences := compareOutputs(eelsOut, gethOut)
+	if len(divergences) == 0 {
+		fmt.Println("No divergence")
+		return
+	}
+	if !*all {
+		divergences = divergences[:1]
+	}
+	for _, d := range divergences {
+		fmt.Printf("%s\n    eels: %s\n    geth: %s\n", d.Path, d.EELS, d.Geth)
+	}
+	os.Exit(1)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// runT8n executes a t8n tool and decodes its output. The command may contain
+// arguments of its own, e.g. "python -m ethereum_spec_tools.evm_tools".
+func runT8n(command string, args []string) (*t8nOutput, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(fields[0], append(fields[1:], args...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%v\n%s", err, stderr.Bytes())
+	}
+	out := new(t8nOutput)
+	if err := json.Unmarshal(stdout.Bytes(), out); err != nil {
+		return nil, fmt.Errorf("invalid output: %v", err)
+	}
+	return out, nil
+}
+
+// compareOutputs lists the divergences between the two outputs in reporting
+// order.
+func compareOutputs(eels, geth *t8nOutput) []divergence {
+	var divergences []divergence
+	diff := func(path string, eelsValue, gethValue string) {
+		if eelsValue != gethValue {
+			divergences = append(divergences, divergence{Path: path, EELS: eelsValue, Geth: gethValue})
+		}
+	}
+	// Receipts and rejected transactions, in transaction order.
+	eelsReceipts := decodeList(eels.Result["receipts"])
+	gethReceipts := decodeList(geth.Result["receipts"])
+	diff("receipts", strconv.Itoa(len(eelsReceipts)), strconv.Itoa(len(gethReceipts)))
+	for i := 0; i < len(eelsReceipts) && i < len(gethReceipts); i++ {
+		for _, key := range sortedKeys(eelsReceipts[i]) {
+			if gethValue, ok := gethReceipts[i][key]; ok {
+				diff(fmt.Sprintf("receipts[%d].%s", i, key), normalize(eelsReceipts[i][key]), normalize(gethValue))
+			}
+		}
+	}
+	diff("rejected", normalizeRejected(eels.Result["rejected"]), normalizeRejected(geth.Result["rejected"]))
+
+	// Post-state accounts, in address order.
+	addrs := make(map[common.Address]struct{})
+	for addr := range eels.Alloc {
+		addrs[addr] = struct{}{}
+	}
+	for addr := range geth.Alloc {
+		addrs[addr] = struct{}{}
+	}
+	sorted := make([]common.Address, 0, len(addrs))
+	for addr := range addrs {
+		sorted = append(sorted, addr)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+	for _, addr := range sorted {
+		eelsAccount, eelsOk := eels.Alloc[addr]
+		gethAccount, gethOk := geth.Alloc[addr]
+		path := "alloc." + addr.Hex()
+		if !eelsOk || !gethOk {
+			diff(path, present(eelsOk), present(gethOk))
+			continue
+		}
+		diff(path+".balance", balance(eelsAccount), balance(gethAccount))
+		diff(path+".nonce", strconv.FormatUint(uint64(eelsAccount.Nonce), 10), strconv.FormatUint(uint64(gethAccount.Nonce), 10))
+		diff(path+".code", hexutil.Encode(eelsAccount.Code), hexutil.Encode(gethAccount.Code))
+		eelsStorage, gethStorage := storage(eelsAccount), storage(gethAccount)
+		for _, slot := range sortedSlots(eelsStorage, gethStorage) {
+			diff(fmt.Sprintf("%s.storage[%s]", path, slot.Hex()), slotValue(eelsStorage, slot), slotValue(gethStorage, slot))
+		}
+	}
+
+	// Remaining result fields, skipping the ones only one tool reports.
+	for _, key := range sortedKeys(eels.Result) {
+		if key == "receipts" || key == "rejected" {
+			continue
+		}
+		if gethValue, ok := geth.Result[key]; ok {
+			diff("result."+key, normalize(eels.Result[key]), normalize(gethValue))
+		}
+	}
+	return divergences
+}
+
+// decodeList decodes a JSON list of objects, treating null as empty.
+func decodeList(raw json.RawMessage) []map[string]json.RawMessage {
+	var list []map[string]json.RawMessage
+	json.Unmarshal(raw, &list)
+	return list
+}
+
+// normalize renders a JSON value for comparison. Hex strings of up to 32
+// bytes are treated as numbers, which makes leading zeros irrelevant.
+func normalize(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+			return "none"
+		}
+		return string(raw)
+	}
+	s = strings.ToLower(s)
+	if strings.HasPrefix(s, "0x") && len(s) > 2 && len(s) <= 2+2*common.HashLength {
+		if n, ok := new(big.Int).SetString(s[2:], 16); ok {
+			return "0x" + n.Text(16)
+		}
+	}
+	return s
+}
+
+// normalizeRejected renders the rejected transaction indices. The error
+// messages are client specific and therefore not compared.
+func normalizeRejected(raw json.RawMessage) string {
+	var rejected []struct {
+		Index int `json:"index"`
+	}
+	json.Unmarshal(raw, &rejected)
+	indices := make([]string, len(rejected))
+	for i, r := range rejected {
+		indices[i] = strconv.Itoa(r.Index)
+	}
+	return "[" + strings.Join(indices, ",") + "]"
+}
+
+func present(ok bool) string {
+	if ok {
+		return "present"
+	}
+	return "none"
+}
+
+func balance(account t8nAccount) string {
+	if account.Balance == nil {
+		return "0"
+	}
+	return (*big.Int)(account.Balance).String()
+}
+
+// storage decodes the storage of an account, dropping zero slots.
+func storage(account t8nAccount) map[common.Hash]common.Hash {
+	slots := make(map[common.Hash]common.Hash, len(account.Storage))
+	for key, value := range account.Storage {
+		k, ok1 := math.ParseBig256(key)
+		v, ok2 := math.ParseBig256(value)
+		if ok1 && ok2 && v.Sign() != 0 {
+			slots[common.BigToHash(k)] = common.BigToHash(v)
+		}
+	}
+	return slots
+}
+
+func slotValue(storage map[common.Hash]common.Hash, slot common.Hash) string {
+	value, ok := storage[slot]
+	if !ok {
+		return "none"
+	}
+	return value.Hex()
+}
+
+func sortedSlots(a, b map[common.Hash]common.Hash) []common.Hash {
+	slots := make(map[common.Hash]struct{})
+	for slot := range a {
+		slots[slot] = struct{}{}
+	}
+	for slot := range b {
+		slots[slot] = struct{}{}
+	}
+	sorted := make([]common.Hash, 0, len(slots))
+	for slot := range slots {
+		sorted = append(sorted, slot)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+	return sorted
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+// trace-diff compares two EIP-3155 traces, typically produced by different
+// clients for the same transaction or state test.
+//
+// Usage:
+//
+//	go run ./cmd/trace-diff [--context 10] [--nomemory] [--noreturndata] a.jsonl b.jsonl
+//
+// The traces are normalized before comparing them, so differences in number
+// encoding do not matter. Fields reported by only one of the clients (stack,
+// memory, return data) are ignored; --nomemory, --noreturndata, --nostack and
+// --norefund exclude them from the comparison altogether, and
+// --collapserefunds ignores whether the refund of SSTORE and SELFDESTRUCT is
+// included in their steps. Lines which are not JSON objects, such as client
+// log output, are skipped.
+//
+// If the steps match, the transaction summaries and reported state roots are
+// compared. The tool exits with a nonzero code if the traces diverge.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/trace"
+)
+
+func main() {
+	var (
+		context = flag.Int("context", 10, "number of steps shown before the divergence")
+		opts    trace.Options
+	)
+	flag.BoolVar(&opts.NoMemory, "nomemory", false, "ignore memory")
+	flag.BoolVar(&opts.NoReturnData, "noreturndata", false, "ignore return data")
+	flag.BoolVar(&opts.NoStack, "nostack", false, "ignore the stack")
+	flag.BoolVar(&opts.NoRefund, "norefund", false, "ignore the refund counter")
+	flag.BoolVar(&opts.CollapseRefunds, "collapserefunds", false, "compare the refund counter of SSTORE and SELFDESTRUCT as before them")
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fatalf("expected two trace files")
+	}
+	a, err := loadTrace(flag.Arg(0), opts)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	b, err := loadTrace(flag.Arg(1), opts)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if d := trace.Diff(a.Steps, b.Steps); d != nil {
+		fmt.Printf("left:  %s\nright: %s\n\n", flag.Arg(0), flag.Arg(1))
+		trace.PrintDiff(os.Stdout, a.Steps, b.Steps, d, *context)
+		os.Exit(1)
+	}
+	for i := 0; i < len(a.Summaries) && i < len(b.Summaries); i++ {
+		if a.Summaries[i] != b.Summaries[i] {
+			fmt.Printf("traces match, transaction %d summaries differ:\n    %+v\n    %+v\n", i, a.Summaries[i], b.Summaries[i])
+			os.Exit(1)
+		}
+	}
+	for i := 0; i < len(a.StateRoots) && i < len(b.StateRoots); i++ {
+		if a.StateRoots[i] != b.StateRoots[i] {
+			fmt.Printf("traces match, state root %d differs:\n    %s\n    %s\n", i, a.StateRoots[i], b.StateRoots[i])
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("traces match (%d steps)\n", len(a.Steps))
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// loadTrace reads and normalizes a trace file.
+func loadTrace(path string, opts trace.Options) (*trace.Trace, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t, err := trace.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	t.Normalize(opts)
+	return t, nil
+}
@@ -0,0 +1,121 @@
+// trace-normalize rewrites a client's EIP-3155 trace in a canonical form, so
+// that the traces of different clients are directly comparable, e.g. with
+// diff or by hash, and cheaper to store.
+//
+// Usage:
+//
+//	go run ./cmd/trace-normalize [--nomemory] [--noreturndata] [--collapserefunds] [--sample 100] [--output out.jsonl] trace.jsonl
+//
+// The trace is read from the given file, or from stdin, and streamed to
+// --output, or to stdout. Every step is written with the same encoding:
+// numbers as in EIP-3155, gas values, stack items and byte strings as
+// minimal or lowercase hex. --nomemory, --noreturndata, --nostack and
+// --norefund strip those fields; --collapserefunds reports the refund counter
+// of SSTORE and SELFDESTRUCT steps as it was before them, which some clients
+// do and others do not. Transaction summaries and state root reports are
+// kept, lines which are not part of the trace, such as client log output,
+// are dropped.
+//
+// With --sample N only every N-th step is written, counting from the first
+// one. The normalization is applied to every step before sampling, so the
+// sampled traces of two clients still line up.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/trace"
+)
+
+func main() {
+	var (
+		output = flag.String("output", "", "file the normalized trace is written to (default stdout)")
+		sample = flag.Int("sample", 1, "write only every N-th step")
+		opts   trace.Options
+	)
+	flag.BoolVar(&opts.NoMemory, "nomemory", false, "strip memory")
+	flag.BoolVar(&opts.NoReturnData, "noreturndata", false, "strip return data")
+	flag.BoolVar(&opts.NoStack, "nostack", false, "strip the stack")
+	flag.BoolVar(&opts.NoRefund, "norefund", false, "strip the refund counter")
+	flag.BoolVar(&opts.CollapseRefunds, "collapserefunds", false, "report the refund counter of SSTORE and SELFDESTRUCT as before them")
+	flag.Parse()
+	if *sample < 1 {
+		fatalf("invalid sample interval %d", *sample)
+	}
+
+	var in io.Reader = os.Stdin
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fatalf("%v", err)
+		}
+		defer f.Close()
+		in = f
+	default:
+		fatalf("expected at most one trace file")
+	}
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	steps, written, err := normalize(in, w, opts, *sample)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Fprintf(os.Stderr, "%d steps read, %d written\n", steps, written)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// normalize streams the trace from r to w, normalizing every step and
+// keeping every sample-th one. It returns the number of steps read and
+// written.
+func normalize(r io.Reader, w io.Writer, opts trace.Options, sample int) (steps, written int, err error) {
+	var (
+		scanner    = trace.NewScanner(r)
+		normalizer = trace.NewNormalizer(opts)
+		enc        = json.NewEncoder(w)
+	)
+	for scanner.Scan() {
+		if step := scanner.Step(); step != nil {
+			normalizer.Normalize(step)
+			if steps%sample == 0 {
+				if err := enc.Encode(step); err != nil {
+					return steps, written, err
+				}
+				written++
+			}
+			steps++
+		}
+		if summary := scanner.Summary(); summary != nil {
+			if err := enc.Encode(summary); err != nil {
+				return steps, written, err
+			}
+		}
+		if root := scanner.StateRoot(); root != "" {
+			if err := enc.Encode(map[string]string{"stateRoot": root}); err != nil {
+				return steps, written, err
+			}
+		}
+	}
+	return steps, written, scanner.Err()
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// testReport is the triaged outcome of a test file, as written by fuzz-run.
+type testReport struct {
+	File      string          `json:"file"`
+	Pass      map[string]bool `json:"pass"`
+	Signature string          `json:"signature,omitempty"`
+	Detail    string          `json:"detail,omitempty"`
+}
+
+// triageReport is the part of a fuzz-run report the results are read from.
+type triageReport struct {
+	Runners []string     `json:"runners"`
+	Results []testReport `json:"results"`
+}
+
+// signatureGroup is the failing tests sharing a divergence signature.
+type signatureGroup struct {
+	signature string
+	tests     []*testReport // in file order
+}
+
+// database is the results of the reports of one or several fuzz-run runs,
+// such as the shards of a corpus, with the root cause tags of the failures.
+type database struct {
+	runners    []string
+	tests      int
+	signatures []*signatureGroup // by decreasing number of tests
+	tags       *tags
+}
+
+// loadDatabase merges the reports, which must have been run with the same
+// runners and on distinct test files.
+func loadDatabase(paths []string, t *tags) (*database, error) {
+	var (
+		db     = &database{tags: t}
+		seen   = make(map[string]bool)
+		groups = make(map[string]*signatureGroup)
+	)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var report triageReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if db.runners == nil {
+			db.runners = report.Runners
+		} else if !slices.Equal(db.runners, report.Runners) {
+			return nil, fmt.Errorf("%s: runners %s, %s has %s", path, strings.Join(report.Runners, ","), paths[0], strings.Join(db.runners, ","))
+		}
+		for i := range report.Results {
+			result := &report.Results[i]
+			if seen[result.File] {
+				return nil, fmt.Errorf("%s: test %s is in several reports", path, result.File)
+			}
+			seen[result.File] = true
+			db.tests++
+			if result.Signature == "" {
+				continue
+			}
+			group := groups[result.Signature]
+			if group == nil {
+				group = &signatureGroup{signature: result.Signature}
+				groups[result.Signature] = group
+				db.signatures = append(db.signatures, group)
+			}
+			group.tests = append(group.tests, result)
+		}
+	}
+	for _, group := range db.signatures {
+		sort.Slice(group.tests, func(i, j int) bool { return group.tests[i].File < group.tests[j].File })
+	}
+	sort.SliceStable(db.signatures, func(i, j int) bool {
+		a, b := db.signatures[i], db.signatures[j]
+		if len(a.tests) != len(b.tests) {
+			return len(a.tests) > len(b.tests)
+		}
+		return a.signature < b.signature
+	})
+	return db, nil
+}
+
+// failed returns the number of failing tests.
+func (db *database) failed() int {
+	var n int
+	for _, group := range db.signatures {
+		n += len(group.tests)
+	}
+	return n
+}
+
+// tags are the root causes assigned to signatures and to test files, kept in
+// a JSON file. The tag of a test takes precedence over that of its signature,
+// for the signatures shared by failures of different causes.
+type tags struct {
+	path       string
+	Signatures map[string]string `json:"signatures"`
+	Tests      map[string]string `json:"tests"`
+}
+
+// loadTags reads the tags file, which is created on the first change if it
+// does not exist.
+func loadTags(path string) (*tags, error) {
+	t := &tags{path: path, Signatures: make(map[string]string), Tests: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if t.Signatures == nil {
+		t.Signatures = make(map[string]string)
+	}
+	if t.Tests == nil {
+		t.Tests = make(map[string]string)
+	}
+	return t, nil
+}
+
+// of returns the root cause of a failing test.
+func (t *tags) of(test *testReport) string {
+	if tag := t.Tests[test.File]; tag != "" {
+		return tag
+	}
+	return t.Signatures[test.Signature]
+}
+
+// set assigns a tag, removing it if empty, and saves the tags.
+func (t *tags) set(m map[string]string, key, tag string) error {
+	if tag = strings.TrimSpace(tag); tag == "" {
+		delete(m, key)
+	} else {
+		m[key] = tag
+	}
+	data, err := json.MarshalIndent(t, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/evmfuzz"
+	"github.com/ethereum/execution-specs/pkg/shrink"
+	"github.com/ethereum/execution-specs/pkg/trace"
+)
+
+// traceDiff is the execution of the first post state of a test file on which
+// the backends diverge.
+type traceDiff struct {
+	test     string // name of the test in the file
+	position int    // of the post state in those of its fork
+	finding  *evmfuzz.Finding
+	results  []*evmfuzz.Result // nil for backends which failed
+	errors   []string          // of the backends which failed
+
+	c *shrink.Case
+}
+
+// runDiff selects the first diverging post state of the test file and
+// executes it on the backends, keeping their traces.
+func runDiff(path string, backends []evmfuzz.Backend) (*traceDiff, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	s := &shrink.Shrinker{Backends: backends}
+	name, pos, finding, err := s.Select(file, "", "")
+	if err != nil {
+		return nil, err
+	}
+	test, err := s.Current.Encode()
+	if err != nil {
+		return nil, err
+	}
+	d := &traceDiff{test: name, position: pos, finding: finding, results: make([]*evmfuzz.Result, len(backends)), errors: make([]string, len(backends)), c: s.Current}
+	for i, b := range backends {
+		result, err := b.Run(test, s.Current.Fork())
+		if err != nil {
+			d.errors[i] = err.Error()
+		}
+		d.results[i] = result
+	}
+	return d, nil
+}
+
+// fork returns the fork of the diverging post state.
+func (d *traceDiff) fork() string { return d.c.Fork() }
+
+// diverging returns the index of the first backend diverging from the first
+// one, or 1 if none does any more.
+func (d *traceDiff) diverging() int {
+	for i := 1; i < len(d.results); i++ {
+		if d.results[0] == nil || d.results[i] == nil || evmfuzz.CompareResults(d.results[0], d.results[i]) != nil {
+			return i
+		}
+	}
+	return 1
+}
+
+// diffRow is a step of the traces of two backends side by side.
+type diffRow struct {
+	a, b  *trace.Step // nil past the end of a trace
+	field string      // first differing field, "" if the steps are equal
+}
+
+// rows aligns the traces of the first and the other backend by step.
+func (d *traceDiff) rows(other int) []diffRow {
+	var a, b []trace.Step
+	if r := d.results[0]; r != nil {
+		a = r.Steps
+	}
+	if r := d.results[other]; r != nil {
+		b = r.Steps
+	}
+	rows := make([]diffRow, max(len(a), len(b)))
+	for i := range rows {
+		row := &rows[i]
+		if i < len(a) {
+			row.a = &a[i]
+		}
+		if i < len(b) {
+			row.b = &b[i]
+		}
+		if row.a == nil || row.b == nil {
+			row.field = "length"
+		} else {
+			row.field = trace.StepDifference(row.a, row.b)
+		}
+	}
+	return rows
+}
+
+// exportPath returns the file the reproducer of a test file is exported to.
+func exportPath(dir, file string) string {
+	return filepath.Join(dir, strings.TrimSuffix(filepath.Base(file), ".json")+"-shrunk.json")
+}
+
+// export shrinks the diverging post state of a diff as by shrink, preserving
+// the signature of its divergence, and writes the reproducer to the path. The
+// root cause of the test is recorded in the info of the reproducer.
+func export(d *traceDiff, backends []evmfuzz.Backend, cause, path string, progress func(s *shrink.Shrinker)) error {
+	if d.finding == nil {
+		return errors.New("the backends do not diverge")
+	}
+	s := &shrink.Shrinker{
+		Backends:  backends,
+		Signature: d.finding.Signature,
+		Current:   d.c,
+	}
+	s.Log = func(step string, c *shrink.Case) { progress(s) }
+	s.Shrink()
+	result, err := s.Reproducer()
+	if err != nil {
+		return err
+	}
+	if cause != "" {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(result, &fields); err != nil {
+			return err
+		}
+		info := make(map[string]interface{})
+		if raw, ok := fields["_info"]; ok {
+			json.Unmarshal(raw, &info)
+		}
+		info["rootCause"] = cause
+		fields["_info"], _ = json.Marshal(info)
+		if result, err = json.Marshal(fields); err != nil {
+			return err
+		}
+	}
+	out, err := json.MarshalIndent(map[string]json.RawMessage{d.test: result}, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(out, '\n'), 0644)
+}
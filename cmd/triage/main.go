@@ -0,0 +1,154 @@
+// triage is a terminal UI over the results of differential runs, the triage
+// reports of fuzz-run, for browsing the divergent tests, stepping through the
+// aligned traces of the backends, tagging root causes and exporting
+// minimized reproducers.
+//
+// Usage:
+//
+//	go run ./cmd/triage [--backend go --backend eels] [--tags triage-tags.json] [--export reproducers] triage.json...
+//
+// The reports of several runs on distinct test files, such as the shards of
+// a corpus, are merged. The first screen lists the signatures of the
+// failures by decreasing number of failing tests. A signature opens the list
+// of its tests, with the verdicts of the runners, and a test the diff of its
+// traces: the first post state of the test file on which the backends
+// diverge is selected as by shrink and executed on every backend, and the
+// trace of the first backend is shown side by side with that of the first
+// diverging one, step by step. The differing steps are marked and the fields
+// of the selected steps are compared below them.
+//
+// Backends are given as for shrink: go for go-ethereum in process, NAME for
+// the statetest command of a known client (eels, geth, besu) or
+// NAME=COMMAND. By default the runners of the reports are the backends,
+// which then have to be known clients. The test files are resolved against
+// --root, the directory fuzz-run was run in.
+//
+// The keys are shown at the bottom of every screen: the arrow and page keys
+// or j and k move, enter opens, escape goes back, / filters the lists by
+// substring, t tags the selected signature or test with its root cause, d
+// jumps to the divergence of the traces, n and N to the next and previous
+// differing step and b compares the first backend with the next one. The
+// tags are saved to --tags on every change; the tag of a test takes
+// precedence over that of its signature.
+//
+// x exports the reproducer of the selected test: its diverging post state is
+// shrunk as by shrink, preserving the signature of the divergence, and
+// written to --export named after the test file, with its root cause in the
+// info. Only one test is run or shrunk at a time, in the background.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/evmfuzz"
+	"github.com/ethereum/execution-specs/pkg/flags"
+)
+
+func main() {
+	var (
+		backendDefs flags.Strings
+		tagsPath    = flag.String("tags", "triage-tags.json", "file the root cause tags are kept in")
+		exportDir   = flag.String("export", "reproducers", "directory the reproducers are exported to")
+		root        = flag.String("root", "", "directory the test files of the reports are relative to (default the working directory)")
+		timeout     = flag.Duration("timeout", time.Minute, "timeout of a single backend invocation")
+	)
+	flag.Var(&backendDefs, "backend", "backend as go, NAME or NAME=COMMAND (repeatable, default the runners of the reports)")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fatalf("no triage reports given")
+	}
+	t, err := loadTags(*tagsPath)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	db, err := loadDatabase(flag.Args(), t)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if len(backendDefs) == 0 {
+		backendDefs = db.runners
+	}
+	if len(backendDefs) < 2 {
+		fatalf("at least two backends are needed")
+	}
+	var backends []evmfuzz.Backend
+	for _, def := range backendDefs {
+		b, err := evmfuzz.ParseBackend(def, *timeout)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		backends = append(backends, b)
+	}
+	if err := run(newApp(db, backends, *root, *exportDir)); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// run draws the UI on the terminal until it is quit, handling the keys typed
+// and the events of the jobs.
+func run(a *app) error {
+	fd := int(os.Stdin.Fd())
+	if _, _, err := terminalSize(fd); err != nil {
+		return fmt.Errorf("triage needs a terminal: %v", err)
+	}
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	out := bufio.NewWriter(os.Stdout)
+	out.WriteString("\x1b[?1049h")
+	defer func() {
+		out.WriteString("\x1b[?25h\x1b[?1049l")
+		out.Flush()
+	}()
+
+	keys := make(chan []string)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- parseKeys(buf[:n])
+		}
+	}()
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+
+	for {
+		if width, height, err := terminalSize(fd); err == nil {
+			a.width, a.height = width, height
+		}
+		a.draw(out)
+		out.Flush()
+		select {
+		case typed, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, key := range typed {
+				if !a.handle(key) {
+					return nil
+				}
+			}
+		case event := <-a.events:
+			event()
+		case <-resize:
+			out.WriteString("\x1b[2J")
+		}
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoTerminal = errors.New("the terminal UI is not supported on this platform")
+
+func makeRaw(fd int) (func(), error) { return nil, errNoTerminal }
+
+func terminalSize(fd int) (int, int, error) { return 0, 0, errNoTerminal }
+
+func notifyResize(c chan<- os.Signal) {}
@@ -0,0 +1,724 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ethereum/execution-specs/pkg/evmfuzz"
+	"github.com/ethereum/execution-specs/pkg/shrink"
+	"github.com/ethereum/execution-specs/pkg/trace"
+)
+
+// The screens of the UI, each opened from the previous one.
+const (
+	screenSignatures = iota
+	screenTests
+	screenDiff
+)
+
+// The ANSI escape sequences the UI is drawn with.
+const (
+	styleReset   = "\x1b[0m"
+	styleReverse = "\x1b[7m"
+	styleBold    = "\x1b[1m"
+	styleRed     = "\x1b[31m"
+	styleYellow  = "\x1b[33m"
+	styleDim     = "\x1b[2m"
+)
+
+// stepFields are the fields of the steps shown side by side below a diff.
+var stepFields = []string{"depth", "pc", "op", "gas", "gasCost", "refund", "error", "stack", "returnData", "memory"}
+
+// cursor is the selected row of a list and the first row shown.
+type cursor struct {
+	row, offset int
+}
+
+// move moves the cursor by delta rows within a list of n rows.
+func (c *cursor) move(delta, n int) {
+	c.row = max(min(c.row+delta, n-1), 0)
+}
+
+// scroll returns the first row to show of a list with the given height for
+// the cursor to be visible.
+func (c *cursor) scroll(height int) int {
+	if c.row < c.offset {
+		c.offset = c.row
+	}
+	if c.row >= c.offset+height {
+		c.offset = c.row - height + 1
+	}
+	return c.offset
+}
+
+// prompt is a line of input being edited in the footer.
+type prompt struct {
+	label string
+	text  string
+	done  func(text string)
+}
+
+// app is the state of the UI. It is only accessed by the event loop; the
+// jobs running backends post their results as events.
+type app struct {
+	db        *database
+	backends  []evmfuzz.Backend
+	root      string // directory the test files are relative to
+	exportDir string
+
+	width, height int
+	screen        int
+	signatures    cursor
+	tests         cursor
+	steps         cursor
+	sigFilter     string
+	testFilter    string
+
+	group *signatureGroup // of the tests screen
+	test  *testReport     // of the diff screen
+	other int             // backend whose trace is compared with the first one
+	rows  []diffRow
+
+	diffs    map[string]*traceDiff
+	failures map[string]string // errors of the diffs which could not be run
+
+	prompt *prompt
+	status string
+	busy   string // description of the running job
+	events chan func()
+}
+
+func newApp(db *database, backends []evmfuzz.Backend, root, exportDir string) *app {
+	return &app{
+		db:        db,
+		backends:  backends,
+		root:      root,
+		exportDir: exportDir,
+		diffs:     make(map[string]*traceDiff),
+		failures:  make(map[string]string),
+		events:    make(chan func(), 16),
+	}
+}
+
+// path resolves the file of a test.
+func (a *app) path(test *testReport) string {
+	if a.root == "" || filepath.IsAbs(test.File) {
+		return test.File
+	}
+	return filepath.Join(a.root, test.File)
+}
+
+// start runs a job outside of the event loop. The function returned by the
+// job is called by the event loop once it is done. Only one job runs at a
+// time, as the backends are not run in parallel.
+func (a *app) start(description string, job func() func()) {
+	if a.busy != "" {
+		a.status = "busy " + a.busy
+		return
+	}
+	a.busy, a.status = description, ""
+	go func() {
+		done := job()
+		a.events <- func() {
+			a.busy = ""
+			done()
+		}
+	}()
+}
+
+// visibleSignatures returns the signatures matching the filter.
+func (a *app) visibleSignatures() []*signatureGroup {
+	var groups []*signatureGroup
+	for _, group := range a.db.signatures {
+		if matches(a.sigFilter, group.signature, a.db.tags.Signatures[group.signature]) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// visibleTests returns the tests of the open signature matching the filter.
+func (a *app) visibleTests() []*testReport {
+	var tests []*testReport
+	for _, test := range a.group.tests {
+		if matches(a.testFilter, test.File, test.Detail, a.db.tags.of(test)) {
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
+
+// matches reports whether any of the values contains the filter.
+func matches(filter string, values ...string) bool {
+	return filter == "" || slices.ContainsFunc(values, func(v string) bool {
+		return strings.Contains(strings.ToLower(v), strings.ToLower(filter))
+	})
+}
+
+// handle processes a key and reports whether the UI should keep running.
+func (a *app) handle(key string) bool {
+	if key == "ctrl-c" {
+		return false
+	}
+	if a.prompt != nil {
+		a.edit(key)
+		return true
+	}
+	a.status = ""
+	switch a.screen {
+	case screenSignatures:
+		return a.handleSignatures(key)
+	case screenTests:
+		a.handleTests(key)
+	case screenDiff:
+		a.handleDiff(key)
+	}
+	return true
+}
+
+// edit applies a key to the prompt.
+func (a *app) edit(key string) {
+	p := a.prompt
+	switch key {
+	case "enter":
+		a.prompt = nil
+		p.done(p.text)
+	case "esc":
+		a.prompt = nil
+	case "backspace":
+		if _, size := utf8.DecodeLastRuneInString(p.text); size > 0 {
+			p.text = p.text[:len(p.text)-size]
+		}
+	case "ctrl-u":
+		p.text = ""
+	default:
+		if utf8.RuneCountInString(key) == 1 {
+			p.text += key
+		}
+	}
+}
+
+// navigate moves the cursor of a list of n rows, of which page are shown, by
+// the movement keys, and reports whether the key was one of them.
+func navigate(c *cursor, key string, n, page int) bool {
+	switch key {
+	case "up", "k":
+		c.move(-1, n)
+	case "down", "j":
+		c.move(1, n)
+	case "pgup":
+		c.move(-page, n)
+	case "pgdown", " ":
+		c.move(page, n)
+	case "home", "g":
+		c.move(-n, n)
+	case "end", "G":
+		c.move(n, n)
+	default:
+		return false
+	}
+	return true
+}
+
+func (a *app) handleSignatures(key string) bool {
+	groups := a.visibleSignatures()
+	if navigate(&a.signatures, key, len(groups), a.listHeight()) {
+		return true
+	}
+	var selected *signatureGroup
+	if a.signatures.row < len(groups) {
+		selected = groups[a.signatures.row]
+	}
+	switch key {
+	case "q":
+		return false
+	case "esc":
+		a.sigFilter = ""
+	case "/":
+		a.prompt = &prompt{label: "filter signatures: ", text: a.sigFilter, done: func(text string) {
+			a.sigFilter, a.signatures = text, cursor{}
+		}}
+	case "enter", "right", "l":
+		if selected != nil {
+			a.group, a.tests, a.testFilter = selected, cursor{}, ""
+			a.screen = screenTests
+		}
+	case "t":
+		if selected != nil {
+			a.tag(a.db.tags.Signatures, selected.signature, "tag signature: ")
+		}
+	}
+	return true
+}
+
+func (a *app) handleTests(key string) {
+	tests := a.visibleTests()
+	if navigate(&a.tests, key, len(tests), a.listHeight()) {
+		return
+	}
+	var selected *testReport
+	if a.tests.row < len(tests) {
+		selected = tests[a.tests.row]
+	}
+	switch key {
+	case "q", "esc", "left", "h":
+		if key == "esc" && a.testFilter != "" {
+			a.testFilter = ""
+			return
+		}
+		a.screen = screenSignatures
+	case "/":
+		a.prompt = &prompt{label: "filter tests: ", text: a.testFilter, done: func(text string) {
+			a.testFilter, a.tests = text, cursor{}
+		}}
+	case "enter", "right", "l":
+		if selected != nil {
+			a.openDiff(selected)
+		}
+	case "t":
+		if selected != nil {
+			a.tag(a.db.tags.Tests, selected.File, "tag test: ")
+		}
+	case "x":
+		if selected != nil {
+			a.export(selected)
+		}
+	}
+}
+
+func (a *app) handleDiff(key string) {
+	if navigate(&a.steps, key, len(a.rows), a.diffHeight()) {
+		return
+	}
+	switch key {
+	case "q", "esc", "left", "h":
+		a.screen = screenTests
+	case "d":
+		if i := slices.IndexFunc(a.rows, func(r diffRow) bool { return r.field != "" }); i >= 0 {
+			a.steps.row = i
+		} else {
+			a.status = "the traces are equal"
+		}
+	case "n", "N":
+		step := 1
+		if key == "N" {
+			step = -1
+		}
+		for i := a.steps.row + step; i >= 0 && i < len(a.rows); i += step {
+			if a.rows[i].field != "" {
+				a.steps.row = i
+				return
+			}
+		}
+		a.status = "no further difference"
+	case "b":
+		if d := a.diffs[a.test.File]; d != nil && len(a.backends) > 2 {
+			a.other = a.other%(len(a.backends)-1) + 1
+			a.rows = d.rows(a.other)
+		}
+	case "t":
+		a.tag(a.db.tags.Tests, a.test.File, "tag test: ")
+	case "x":
+		a.export(a.test)
+	}
+}
+
+// tag prompts for the tag of a signature or a test.
+func (a *app) tag(m map[string]string, key, label string) {
+	a.prompt = &prompt{label: label, text: m[key], done: func(text string) {
+		if err := a.db.tags.set(m, key, text); err != nil {
+			a.status = "saving tags: " + err.Error()
+		}
+	}}
+}
+
+// openDiff shows the traces of a test, running the backends on it unless
+// they already were.
+func (a *app) openDiff(test *testReport) {
+	a.screen, a.test, a.steps, a.rows = screenDiff, test, cursor{}, nil
+	if d := a.diffs[test.File]; d != nil {
+		a.showDiff(d)
+		return
+	}
+	if a.failures[test.File] != "" {
+		return
+	}
+	path := a.path(test)
+	a.start("running the backends on "+test.File, func() func() {
+		d, err := runDiff(path, a.backends)
+		return func() {
+			if err != nil {
+				a.failures[test.File] = err.Error()
+				return
+			}
+			a.diffs[test.File] = d
+			if a.screen == screenDiff && a.test == test {
+				a.showDiff(d)
+			}
+		}
+	})
+}
+
+// showDiff shows the traces of a diff with the cursor on their divergence.
+func (a *app) showDiff(d *traceDiff) {
+	a.other = d.diverging()
+	a.rows = d.rows(a.other)
+	if i := slices.IndexFunc(a.rows, func(r diffRow) bool { return r.field != "" }); i >= 0 {
+		a.steps.row = i
+	}
+}
+
+// export shrinks the diverging post state of a test into a reproducer,
+// running the backends on it first unless they already were.
+func (a *app) export(test *testReport) {
+	var (
+		d     = a.diffs[test.File]
+		path  = a.path(test)
+		out   = exportPath(a.exportDir, test.File)
+		cause = a.db.tags.of(test)
+	)
+	a.start("shrinking "+test.File, func() func() {
+		if d == nil {
+			var err error
+			if d, err = runDiff(path, a.backends); err != nil {
+				return func() { a.status = "export failed: " + err.Error() }
+			}
+		}
+		err := export(d, a.backends, cause, out, func(s *shrink.Shrinker) {
+			progress := fmt.Sprintf("shrinking %s: %d reductions kept of %d tried, %s", test.File, s.Kept, s.Runs, s.Current.Size())
+			a.events <- func() { a.busy = progress }
+		})
+		return func() {
+			if a.diffs[test.File] == nil {
+				a.diffs[test.File] = d
+			}
+			if err != nil {
+				a.status = "export failed: " + err.Error()
+				return
+			}
+			a.status = "reproducer of " + test.File + " written to " + out
+		}
+	})
+}
+
+// listHeight is the number of rows of the lists.
+func (a *app) listHeight() int {
+	// The title, a header, the detail of the selection, the status and
+	// the footer.
+	return max(a.height-5, 1)
+}
+
+// diffHeight is the number of steps shown in the diff, and paneHeight that
+// of the fields of the selected steps below it, left out on small screens.
+func (a *app) diffHeight() int {
+	return max(a.height-7-a.paneHeight(), 1)
+}
+
+func (a *app) paneHeight() int {
+	if a.height < 30 {
+		return 0
+	}
+	return len(stepFields) + 1
+}
+
+// draw renders the screen.
+func (a *app) draw(w io.Writer) {
+	lines := make([]string, 0, a.height)
+	title := fmt.Sprintf(" triage  %d tests, %d failed, %d signatures  runners %s  backends %s",
+		a.db.tests, a.db.failed(), len(a.db.signatures), strings.Join(a.db.runners, ","), backendNames(a.backends))
+	lines = append(lines, styleReverse+fit(title, a.width)+styleReset)
+	switch a.screen {
+	case screenSignatures:
+		lines = append(lines, a.drawSignatures()...)
+	case screenTests:
+		lines = append(lines, a.drawTests()...)
+	case screenDiff:
+		lines = append(lines, a.drawDiff()...)
+	}
+	for len(lines) < a.height-2 {
+		lines = append(lines, "")
+	}
+	lines = lines[:max(a.height-2, 0)]
+	switch {
+	case a.busy != "":
+		lines = append(lines, styleYellow+fit(a.busy+" ...", a.width)+styleReset)
+	default:
+		lines = append(lines, fit(a.status, a.width))
+	}
+	var footer string
+	switch {
+	case a.prompt != nil:
+		footer = a.prompt.label + a.prompt.text
+	case a.screen == screenSignatures:
+		footer = "enter tests  t tag  / filter  q quit"
+	case a.screen == screenTests:
+		footer = "enter traces  t tag  x export  / filter  esc back"
+	default:
+		footer = "up/down step  d divergence  n/N next/previous difference  b backend  t tag  x export  esc back"
+	}
+	lines = append(lines, styleDim+fit(footer, a.width)+styleReset)
+
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[?25l")
+	for i, line := range lines {
+		fmt.Fprintf(&buf, "\x1b[%d;1H%s\x1b[K", i+1, line)
+	}
+	if a.prompt != nil {
+		fmt.Fprintf(&buf, "\x1b[%d;%dH\x1b[?25h", len(lines), min(utf8.RuneCountInString(a.prompt.label+a.prompt.text)+1, a.width))
+	}
+	w.Write(buf.Bytes())
+}
+
+func (a *app) drawSignatures() []string {
+	groups := a.visibleSignatures()
+	header := fmt.Sprintf("%7s  %s", "tests", "signature")
+	if a.sigFilter != "" {
+		header += fmt.Sprintf("  (filter %q: %d of %d)", a.sigFilter, len(groups), len(a.db.signatures))
+	}
+	lines := []string{styleBold + fit(header, a.width) + styleReset}
+	height := a.listHeight()
+	offset := a.signatures.scroll(height)
+	for i := offset; i < len(groups) && i < offset+height; i++ {
+		line := fmt.Sprintf("%7d  %s", len(groups[i].tests), groups[i].signature)
+		if tag := a.db.tags.Signatures[groups[i].signature]; tag != "" {
+			line += "  [" + tag + "]"
+		}
+		lines = append(lines, a.row(line, i == a.signatures.row, false))
+	}
+	for len(lines) < height+1 {
+		lines = append(lines, "")
+	}
+	switch {
+	case len(a.db.signatures) == 0:
+		lines = append(lines, "no failures")
+	case a.signatures.row < len(groups):
+		lines = append(lines, fit("detail of "+groups[a.signatures.row].tests[0].File+": "+groups[a.signatures.row].tests[0].Detail, a.width))
+	}
+	return lines
+}
+
+func (a *app) drawTests() []string {
+	tests := a.visibleTests()
+	header := a.group.signature
+	if tag := a.db.tags.Signatures[a.group.signature]; tag != "" {
+		header += "  [" + tag + "]"
+	}
+	if a.testFilter != "" {
+		header += fmt.Sprintf("  (filter %q: %d of %d)", a.testFilter, len(tests), len(a.group.tests))
+	}
+	lines := []string{styleBold + fit(header, a.width) + styleReset}
+	height := a.listHeight()
+	offset := a.tests.scroll(height)
+	for i := offset; i < len(tests) && i < offset+height; i++ {
+		test := tests[i]
+		var passes []string
+		for _, runner := range a.db.runners {
+			verdict := "FAIL"
+			if test.Pass[runner] {
+				verdict = "ok"
+			}
+			passes = append(passes, runner+" "+verdict)
+		}
+		line := fmt.Sprintf("%s  %s", test.File, strings.Join(passes, " "))
+		if tag := a.db.tags.Tests[test.File]; tag != "" {
+			line += "  [" + tag + "]"
+		}
+		if a.diffs[test.File] != nil {
+			line += "  (traced)"
+		}
+		lines = append(lines, a.row(line, i == a.tests.row, false))
+	}
+	for len(lines) < height+1 {
+		lines = append(lines, "")
+	}
+	if a.tests.row < len(tests) {
+		lines = append(lines, fit(tests[a.tests.row].Detail, a.width))
+	}
+	return lines
+}
+
+func (a *app) drawDiff() []string {
+	lines := []string{styleBold + fit(a.test.File+"  "+a.test.Signature, a.width) + styleReset}
+	d := a.diffs[a.test.File]
+	if d == nil {
+		if err := a.failures[a.test.File]; err != "" {
+			lines = append(lines, fit("cannot trace the test: "+err, a.width))
+		}
+		return lines
+	}
+	lines = append(lines, fit(fmt.Sprintf("%s post state %d of %s: %s", d.test, d.position, d.fork(), d.finding.Detail), a.width))
+	var failed []string
+	for i, err := range d.errors {
+		if err != "" {
+			failed = append(failed, a.backends[i].Name()+" failed: "+err)
+		}
+	}
+	lines = append(lines, fit(strings.Join(failed, "; "), a.width))
+
+	names := [2]string{a.backends[0].Name(), a.backends[a.other].Name()}
+	column := max((a.width-13)/2, 1)
+	header := fmt.Sprintf("  %7s  %s | %s", "step", fit(summarize(names[0], d.results[0]), column), summarize(names[1], d.results[a.other]))
+	lines = append(lines, styleBold+fit(header, a.width)+styleReset)
+
+	height := a.diffHeight()
+	offset := a.steps.scroll(height)
+	for i := offset; i < len(a.rows) && i < offset+height; i++ {
+		row := a.rows[i]
+		marker := " "
+		if row.field != "" {
+			marker = "*"
+		}
+		line := fmt.Sprintf("%s %7d  %s | %s", marker, i, fit(stepLine(row.a), column), stepLine(row.b))
+		lines = append(lines, a.row(line, i == a.steps.row, row.field != ""))
+	}
+	if a.paneHeight() == 0 || a.steps.row >= len(a.rows) {
+		return lines
+	}
+	for len(lines) < height+4 {
+		lines = append(lines, "")
+	}
+	row := a.rows[a.steps.row]
+	summary := fmt.Sprintf("step %d: equal", a.steps.row)
+	if row.field != "" {
+		summary = fmt.Sprintf("step %d: %s differs", a.steps.row, row.field)
+	}
+	lines = append(lines, styleBold+fit(summary, a.width)+styleReset)
+	for _, field := range stepFields {
+		va, vb := stepField(row.a, field), stepField(row.b, field)
+		line := fmt.Sprintf("  %-10s %s | %s", field, fit(va, column-2), vb)
+		if va != vb {
+			line = styleRed + fit(line, a.width) + styleReset
+		} else {
+			line = fit(line, a.width)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// row renders a row of a list, highlighted if selected or differing.
+func (a *app) row(line string, selected, differs bool) string {
+	line = fit(line, a.width)
+	switch {
+	case selected:
+		return styleReverse + line + styleReset
+	case differs:
+		return styleRed + line + styleReset
+	}
+	return line
+}
+
+// summarize describes the result of a backend by its number of steps and
+// its state root.
+func summarize(name string, r *evmfuzz.Result) string {
+	if r == nil {
+		return name + " failed"
+	}
+	return fmt.Sprintf("%s, %d steps, state root %s", name, len(r.Steps), r.StateRoot.TerminalString())
+}
+
+func stepLine(s *trace.Step) string {
+	if s == nil {
+		return "(end of trace)"
+	}
+	return s.String()
+}
+
+// stepField renders a field of a step, the stack from its top.
+func stepField(s *trace.Step, field string) string {
+	switch {
+	case s == nil:
+		return "-"
+	case field == "stack":
+		if s.Stack == nil {
+			return "-"
+		}
+		top := slices.Clone(s.Stack)
+		slices.Reverse(top)
+		return "top " + strings.Join(top, " ")
+	}
+	return s.Field(field)
+}
+
+func backendNames(backends []evmfuzz.Backend) string {
+	names := make([]string, len(backends))
+	for i, b := range backends {
+		names[i] = b.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// fit truncates or pads a line to the width.
+func fit(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	runes := []rune(s)
+	if width <= 1 {
+		return string(runes[:max(width, 0)])
+	}
+	return string(runes[:width-1]) + "~"
+}
+
+// The escape sequences of the keys the UI handles.
+var escapeKeys = []struct {
+	sequence string
+	key      string
+}{
+	{"\x1b[A", "up"}, {"\x1bOA", "up"},
+	{"\x1b[B", "down"}, {"\x1bOB", "down"},
+	{"\x1b[C", "right"}, {"\x1bOC", "right"},
+	{"\x1b[D", "left"}, {"\x1bOD", "left"},
+	{"\x1b[5~", "pgup"}, {"\x1b[6~", "pgdown"},
+	{"\x1b[H", "home"}, {"\x1bOH", "home"}, {"\x1b[1~", "home"}, {"\x1b[7~", "home"},
+	{"\x1b[F", "end"}, {"\x1bOF", "end"}, {"\x1b[4~", "end"}, {"\x1b[8~", "end"},
+}
+
+// parseKeys splits the input read from the terminal into keys: the names of
+// the special keys, or the characters typed.
+func parseKeys(input []byte) []string {
+	var keys []string
+	for len(input) > 0 {
+		switch c := input[0]; {
+		case c == 0x1b:
+			key, n := "esc", 1
+			for _, e := range escapeKeys {
+				if bytes.HasPrefix(input, []byte(e.sequence)) {
+					key, n = e.key, len(e.sequence)
+					break
+				}
+			}
+			if key == "esc" && len(input) > 2 && (input[1] == '[' || input[1] == 'O') {
+				// An unknown sequence, skipped up to its final byte.
+				key, n = "", 2
+				for n < len(input) && (input[n] < 0x40 || input[n] > 0x7e) {
+					n++
+				}
+				n = min(n+1, len(input))
+			}
+			if key != "" {
+				keys = append(keys, key)
+			}
+			input = input[n:]
+		case c == '\r' || c == '\n':
+			keys, input = append(keys, "enter"), input[1:]
+		case c == 0x7f || c == 0x08:
+			keys, input = append(keys, "backspace"), input[1:]
+		case c == 0x03:
+			keys, input = append(keys, "ctrl-c"), input[1:]
+		case c == 0x15:
+			keys, input = append(keys, "ctrl-u"), input[1:]
+		case c < 0x20:
+			input = input[1:]
+		default:
+			r, n := utf8.DecodeRune(input)
+			keys, input = append(keys, string(r)), input[n:]
+		}
+	}
+	return keys
+}
@@ -0,0 +1,170 @@
+// txbuild constructs and signs transactions from declarative definitions,
+// writing their binary encoding and fixture representation.
+//
+// Usage:
+//
+//	go run ./cmd/txbuild [--rlp] [--access-list genesis.json] [--output txs.json] definitions.json
+//
+// The definitions file holds a transaction definition or a JSON list of them,
+// see pkg/txbuilder for the fields:
+//
+//	[{
+//	    "chainId": "0x1",
+//	    "nonce": "0x0",
+//	    "maxPriorityFeePerGas": "0x1",
+//	    "maxFeePerGas": "0x3b9aca00",
+//	    "gasLimit": "0x5208",
+//	    "to": "0x000000000000000000000000000000000000c0de",
+//	    "secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"
+//	}]
+//
+// The output is the list of built transactions with their hash, binary
+// encodings and fixture representation. With --rlp only the list of the
+// canonical encodings is written, as read by the blocktest command and the
+// minimize subcommand of the genesis tool.
+//
+// With --access-list the transactions are executed one after the other on
+// the state of the genesis, in the block following it. Typed transactions
+// defined without an access list get the one covering the addresses and
+// storage slots they touch, and the output records the gas they use with and
+// without it.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/txbuilder"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func main() {
+	var (
+		rlpOnly     = flag.Bool("rlp", false, "only write the list of canonical transaction encodings")
+		genesisFile = flag.String("access-list", "", "genesis file to execute the transactions on, generating their access lists")
+		output      = flag.String("output", "", "output file (default: stdout)")
+	)
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fatalf("usage: txbuild [flags] definitions.json")
+	}
+	defs, err := readDefinitions(flag.Arg(0))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	var executor *txbuilder.Executor
+	if *genesisFile != "" {
+		genesis := new(core.Genesis)
+		if err := readJSON(*genesisFile, genesis); err != nil {
+			fatalf("%v", err)
+		}
+		if executor, err = txbuilder.NewExecutor(genesis); err != nil {
+			fatalf("%v", err)
+		}
+		defer executor.Close()
+	}
+	var (
+		results = make([]*result, len(defs))
+		rlps    = make([]hexutil.Bytes, len(defs))
+	)
+	for i, def := range defs {
+		if results[i], err = build(def, executor); err != nil {
+			fatalf("transaction %d: %v", i, err)
+		}
+		rlps[i] = results[i].RLP
+		if gas := results[i].AccessListGas; gas != nil {
+			fmt.Fprintf(os.Stderr, "Transaction %d: access list of %d addresses and %d storage keys, gas used %d without and %d with it (%+d)\n",
+				i, len(*def.AccessList), def.AccessList.StorageKeys(), gas.Without, gas.With, -gas.Saved())
+		}
+	}
+	var out interface{} = results
+	if *rlpOnly {
+		out = rlps
+	}
+	data, err := json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// result is a built transaction and, if its access list was generated, the
+// gas used with and without it.
+type result struct {
+	*txbuilder.Result
+	AccessListGas *txbuilder.AccessListGas `json:"accessListGas,omitempty"`
+}
+
+// build builds the defined transaction. Given an executor, the access list of
+// typed transactions defined without one is generated first, and the
+// transaction is applied to the state of the executor.
+func build(def *txbuilder.Definition, executor *txbuilder.Executor) (*result, error) {
+	r := new(result)
+	if executor != nil && def.AccessList == nil && def.TxType() != types.LegacyTxType {
+		list, gas, err := executor.AccessList(def)
+		if err != nil {
+			return nil, err
+		}
+		def.AccessList, r.AccessListGas = &list, gas
+	}
+	tx, err := txbuilder.Build(def)
+	if err != nil {
+		return nil, err
+	}
+	if executor != nil {
+		if _, err := executor.Apply(tx); err != nil {
+			return nil, err
+		}
+	}
+	if r.Result, err = txbuilder.Encode(tx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// readDefinitions reads a single transaction definition or a list of them.
+func readDefinitions(path string) ([]*txbuilder.Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var defs []*txbuilder.Definition
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		defs = make([]*txbuilder.Definition, 1)
+		err = json.Unmarshal(data, &defs[0])
+	} else {
+		err = json.Unmarshal(data, &defs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return defs, nil
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/txbuilder"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// The genesis funds the accounts of the keys with 1 ether each.
+	senderKey    = common.HexToHash("0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	otherKey     = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000007702")
+	authorityKey = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000a01")
+
+	recipient = common.HexToAddress("0x000000000000000000000000000000000000dead")
+	delegate  = common.HexToAddress("0x0000000000000000000000000000000000007701")
+	delegate2 = common.HexToAddress("0x0000000000000000000000000000000000007702")
+)
+
+// address returns the address of a secret key.
+func address(key common.Hash) common.Address {
+	k, err := crypto.ToECDSA(key[:])
+	if err != nil {
+		panic(err)
+	}
+	return crypto.PubkeyToAddress(k.PublicKey)
+}
+
+// testCase is a sequence of transactions submitted to an empty pool.
+type testCase struct {
+	name        string
+	description string
+	fork        string // first fork the case applies to, all if empty
+
+	// alloc holds further accounts of the genesis, replacing those of the
+	// keys.
+	alloc types.GenesisAlloc
+
+	// excessBlobGas is the excess blob gas of the genesis from Cancun, which
+	// sets the blob base fee.
+	excessBlobGas uint64
+
+	txs []poolTx
+}
+
+// poolTx is a transaction of a test case and the expected answer of the pool
+// on each fork. Blob transactions get cell proofs from Osaka.
+type poolTx struct {
+	tx     *txbuilder.Definition
+	expect func(params.Rules) expectation
+}
+
+// expectation is the expected answer of the pool to a transaction: the status
+// of an accepted transaction once all are submitted, or the error rejecting
+// it.
+type expectation struct {
+	status string
+	err    error
+}
+
+// check compares the answer of the pool with the expectation.
+func (e expectation) check(have *outcome) error {
+	switch {
+	case e.err != nil && have.Accepted:
+		return fmt.Errorf("accepted (%s), expected %s", have.Status, reason(e.err))
+	case e.err != nil && have.Reason != reason(e.err):
+		return fmt.Errorf("rejected with %q, expected %s", have.Error, reason(e.err))
+	case e.err == nil && !have.Accepted:
+		return fmt.Errorf("rejected with %q, expected %s", have.Error, e.status)
+	case e.err == nil && have.Status != e.status:
+		return fmt.Errorf("%s, expected %s", have.Status, e.status)
+	}
+	return nil
+}
+
+// always returns the expectation of every fork.
+func always(e expectation) func(params.Rules) expectation {
+	return func(params.Rules) expectation { return e }
+}
+
+var (
+	pending = always(expectation{status: statusPending})
+	queued  = always(expectation{status: statusQueued})
+	dropped = always(expectation{status: statusDropped})
+)
+
+// rejected returns the expectation of a rejection with err on every fork.
+func rejected(err error) func(params.Rules) expectation {
+	return always(expectation{err: err})
+}
+
+// since returns the expectation from the fork for which active holds, before
+// otherwise.
+func since(active func(params.Rules) bool, from, before func(params.Rules) expectation) func(params.Rules) expectation {
+	return func(rules params.Rules) expectation {
+		if active(rules) {
+			return from(rules)
+		}
+		return before(rules)
+	}
+}
+
+func isShanghai(rules params.Rules) bool { return rules.IsShanghai }
+func isCancun(rules params.Rules) bool   { return rules.IsCancun }
+func isPrague(rules params.Rules) bool   { return rules.IsPrague }
+func isOsaka(rules params.Rules) bool    { return rules.IsOsaka }
+
+func big256(v int64) *math.HexOrDecimal256 {
+	return (*math.HexOrDecimal256)(big.NewInt(v))
+}
+
+// legacy returns a legacy transfer of 1 wei to the recipient.
+func legacy(key common.Hash, nonce uint64, gasPrice int64) *txbuilder.Definition {
+	return &txbuilder.Definition{
+		Nonce:     math.HexOrDecimal64(nonce),
+		GasPrice:  big256(gasPrice),
+		GasLimit:  math.HexOrDecimal64(params.TxGas),
+		To:        &recipient,
+		Value:     big256(1),
+		SecretKey: key,
+	}
+}
+
+// dynamic returns a dynamic fee transfer of 1 wei to the recipient.
+func dynamic(key common.Hash, nonce uint64, tipCap, feeCap int64) *txbuilder.Definition {
+	return &txbuilder.Definition{
+		Nonce:                math.HexOrDecimal64(nonce),
+		MaxPriorityFeePerGas: big256(tipCap),
+		MaxFeePerGas:         big256(feeCap),
+		GasLimit:             math.HexOrDecimal64(params.TxGas),
+		To:                   &recipient,
+		Value:                big256(1),
+		SecretKey:            key,
+	}
+}
+
+// withGas sets the gas limit of a transaction definition.
+func withGas(d *txbuilder.Definition, gas uint64) *txbuilder.Definition {
+	d.GasLimit = math.HexOrDecimal64(gas)
+	return d
+}
+
+// create returns a dynamic fee contract creation with zero initcode of the
+// given size.
+func create(key common.Hash, size int) *txbuilder.Definition {
+	d := dynamic(key, 0, 1, 10)
+	d.To, d.Value, d.Data = nil, nil, make(hexutil.Bytes, size)
+	d.GasLimit = 1000000
+	return d
+}
+
+// blob returns a blob transaction with a single zero blob.
+func blob(key common.Hash, nonce uint64, tipCap, feeCap, blobFeeCap int64) *txbuilder.Definition {
+	d := dynamic(key, nonce, tipCap, feeCap)
+	d.MaxFeePerBlobGas = big256(blobFeeCap)
+	d.Blobs = []hexutil.Bytes{{}}
+	return d
+}
+
+// setCode returns a set code transaction calling the recipient with the
+// authorizations.
+func setCode(key common.Hash, nonce uint64, auths ...*txbuilder.Authorization) *txbuilder.Definition {
+	d := dynamic(key, nonce, 1, 10)
+	d.GasLimit = 100000
+	d.AuthorizationList = append([]*txbuilder.Authorization{}, auths...)
+	return d
+}
+
+// authorization returns an authorization of the authority to delegate to the
+// target.
+func authorization(target common.Address, nonce uint64) *txbuilder.Authorization {
+	return &txbuilder.Authorization{Address: target, Nonce: math.HexOrDecimal64(nonce), SecretKey: authorityKey}
+}
+
+// cases returns the handcrafted test cases.
+func cases() []testCase {
+	var (
+		authority = address(authorityKey)
+		delegated = types.GenesisAlloc{authority: {
+			Balance: big.NewInt(params.Ether),
+			Code:    types.AddressToDelegation(delegate),
+		}}
+		value2Ether = dynamic(senderKey, 0, 1, 10)
+	)
+	value2Ether.Value = big256(2 * params.Ether)
+
+	return []testCase{
+		// Rules of all transactions.
+		{
+			name:        "legacy_transfer",
+			description: "a legacy transfer",
+			txs:         []poolTx{{legacy(senderKey, 0, 10), pending}},
+		},
+		{
+			name:        "dynamic_fee_transfer",
+			description: "a dynamic fee transfer",
+			txs:         []poolTx{{dynamic(senderKey, 0, 1, 10), pending}},
+		},
+		{
+			name:        "fee_cap_below_base_fee",
+			description: "a fee cap one below the base fee, which the pool holds until the base fee drops",
+			txs:         []poolTx{{dynamic(senderKey, 0, 1, baseFee-1), pending}},
+		},
+		{
+			name:        "tip_below_minimum",
+			description: "a tip of zero, below the minimum tip of 1 wei",
+			txs:         []poolTx{{dynamic(senderKey, 0, 0, 10), rejected(txpool.ErrTxGasPriceTooLow)}},
+		},
+		{
+			name:        "gas_price_below_minimum",
+			description: "a legacy gas price of zero, below the minimum tip of 1 wei",
+			txs:         []poolTx{{legacy(senderKey, 0, 0), rejected(txpool.ErrTxGasPriceTooLow)}},
+		},
+		{
+			name:        "tip_above_fee_cap",
+			description: "a tip above the fee cap",
+			txs:         []poolTx{{dynamic(senderKey, 0, 11, 10), rejected(core.ErrTipAboveFeeCap)}},
+		},
+		{
+			name:        "nonce_gap",
+			description: "a nonce one above the account nonce, queued until the gap is filled",
+			txs:         []poolTx{{dynamic(senderKey, 1, 1, 10), queued}},
+		},
+		{
+			name:        "nonce_gap_filled",
+			description: "a gapped transaction promoted by the transaction filling the gap",
+			txs: []poolTx{
+				{dynamic(senderKey, 1, 1, 10), pending},
+				{dynamic(senderKey, 0, 1, 10), pending},
+			},
+		},
+		{
+			name:        "nonce_too_low",
+			description: "a nonce below the account nonce",
+			alloc:       types.GenesisAlloc{address(senderKey): {Balance: big.NewInt(params.Ether), Nonce: 1}},
+			txs:         []poolTx{{dynamic(senderKey, 0, 1, 10), rejected(core.ErrNonceTooLow)}},
+		},
+		{
+			name:        "insufficient_funds",
+			description: "a value above the balance of the sender",
+			txs:         []poolTx{{value2Ether, rejected(core.ErrInsufficientFunds)}},
+		},
+		{
+			name:        "intrinsic_gas_too_low",
+			description: "a gas limit one below the intrinsic gas",
+			txs:         []poolTx{{withGas(dynamic(senderKey, 0, 1, 10), params.TxGas-1), rejected(core.ErrIntrinsicGas)}},
+		},
+		{
+			name:        "gas_above_block_limit",
+			description: "a gas limit one above the block gas limit, above the EIP-7825 cap from Osaka",
+			txs: []poolTx{{
+				withGas(dynamic(senderKey, 0, 1, 10), gasLimit+1),
+				since(isOsaka, rejected(core.ErrGasLimitTooHigh), rejected(txpool.ErrGasLimit)),
+			}},
+		},
+		{
+			name:        "gas_above_transaction_cap",
+			description: "a gas limit one above the EIP-7825 transaction gas limit cap of Osaka",
+			txs: []poolTx{{
+				withGas(dynamic(senderKey, 0, 1, 10), params.MaxTxGas+1),
+				since(isOsaka, rejected(core.ErrGasLimitTooHigh), pending),
+			}},
+		},
+		{
+			name:        "replacement",
+			description: "a replacement bumping the fee cap and tip by 10%",
+			txs: []poolTx{
+				{dynamic(senderKey, 0, 10, 100), dropped},
+				{dynamic(senderKey, 0, 11, 110), pending},
+			},
+		},
+		{
+			name:        "replacement_underpriced",
+			description: "a replacement bumping the fee cap by 10% but not the tip",
+			txs: []poolTx{
+				{dynamic(senderKey, 0, 10, 100), pending},
+				{dynamic(senderKey, 0, 10, 110), rejected(txpool.ErrReplaceUnderpriced)},
+			},
+		},
+		{
+			name:        "initcode_max_size",
+			description: "a contract creation with initcode of the maximum size of 49152 bytes",
+			txs:         []poolTx{{create(senderKey, params.MaxInitCodeSize), pending}},
+		},
+		{
+			name:        "initcode_too_large",
+			description: "a contract creation with initcode one byte above the maximum size, allowed before Shanghai",
+			txs: []poolTx{{
+				create(senderKey, params.MaxInitCodeSize+1),
+				since(isShanghai, rejected(core.ErrMaxInitCodeSizeExceeded), pending),
+			}},
+		},
+
+		// Blob transactions.
+		{
+			name:        "blob_transaction",
+			description: "a blob transaction with one blob, rejected before Cancun",
+			txs: []poolTx{{
+				blob(senderKey, 0, 1, 10, 1),
+				since(isCancun, pending, rejected(core.ErrTxTypeNotSupported)),
+			}},
+		},
+		{
+			name:        "blob_fee_cap_zero",
+			description: "a blob fee cap of zero, below the minimum blob base fee of 1 wei",
+			fork:        "Cancun",
+			txs:         []poolTx{{blob(senderKey, 0, 1, 10, 0), rejected(txpool.ErrTxGasPriceTooLow)}},
+		},
+		{
+			name:          "blob_fee_cap_below_blob_base_fee",
+			description:   "a blob fee cap of 1 wei, below the blob base fee, which the pool holds until the blob base fee drops",
+			fork:          "Cancun",
+			excessBlobGas: 10000000,
+			txs:           []poolTx{{blob(senderKey, 0, 1, 10, 1), pending}},
+		},
+		{
+			name:        "blob_fee_cap_below_base_fee",
+			description: "a blob transaction with a fee cap one below the base fee",
+			fork:        "Cancun",
+			txs:         []poolTx{{blob(senderKey, 0, 1, baseFee-1, 1), pending}},
+		},
+		{
+			name:        "blob_nonce_gap",
+			description: "a blob transaction with a nonce gap, which the blob pool does not queue",
+			fork:        "Cancun",
+			txs:         []poolTx{{blob(senderKey, 1, 1, 10, 1), rejected(core.ErrNonceTooHigh)}},
+		},
+		{
+			name:        "blob_sender_reserved",
+			description: "a blob transaction of a sender with a pooled transaction of another type, at the account nonce as the blob pool rejects gaps first",
+			fork:        "Cancun",
+			txs: []poolTx{
+				{dynamic(senderKey, 0, 1, 10), pending},
+				{blob(senderKey, 0, 1, 10, 1), rejected(txpool.ErrAlreadyReserved)},
+			},
+		},
+		{
+			name:        "legacy_sender_reserved",
+			description: "a dynamic fee transaction of a sender with a pooled blob transaction",
+			fork:        "Cancun",
+			txs: []poolTx{
+				{blob(senderKey, 0, 1, 10, 1), pending},
+				{dynamic(senderKey, 1, 1, 10), rejected(txpool.ErrAlreadyReserved)},
+			},
+		},
+		{
+			name:        "blob_replacement",
+			description: "a blob transaction replacement doubling the fee cap, tip and blob fee cap",
+			fork:        "Cancun",
+			txs: []poolTx{
+				{blob(senderKey, 0, 10, 100, 10), dropped},
+				{blob(senderKey, 0, 20, 200, 20), pending},
+			},
+		},
+		{
+			name:        "blob_replacement_underpriced",
+			description: "a blob transaction replacement doubling the fee cap and tip but raising the blob fee cap by 50%",
+			fork:        "Cancun",
+			txs: []poolTx{
+				{blob(senderKey, 0, 10, 100, 10), pending},
+				{blob(senderKey, 0, 20, 200, 15), rejected(txpool.ErrReplaceUnderpriced)},
+			},
+		},
+
+		// Set code transactions and delegated accounts.
+		{
+			name:        "setcode_transaction",
+			description: "a set code transaction with one authorization, rejected before Prague",
+			txs: []poolTx{{
+				setCode(senderKey, 0, authorization(delegate, 0)),
+				since(isPrague, pending, rejected(core.ErrTxTypeNotSupported)),
+			}},
+		},
+		{
+			name:        "setcode_empty_authorization_list",
+			description: "a set code transaction without authorizations",
+			fork:        "Prague",
+			txs:         []poolTx{{setCode(senderKey, 0), rejected(errEmptyAuthorizations)}},
+		},
+		{
+			name:        "setcode_conflicting_delegations",
+			description: "two set code transactions of different senders delegating the same authority with the same nonce to different targets, of which only one can be included",
+			fork:        "Prague",
+			txs: []poolTx{
+				{setCode(senderKey, 0, authorization(delegate, 0)), pending},
+				{setCode(otherKey, 0, authorization(delegate2, 0)), pending},
+			},
+		},
+		{
+			name:        "setcode_authority_with_pooled_tx",
+			description: "a set code transaction whose authority has one pooled transaction",
+			fork:        "Prague",
+			txs: []poolTx{
+				{dynamic(authorityKey, 0, 1, 10), pending},
+				{setCode(senderKey, 0, authorization(delegate, 1)), pending},
+			},
+		},
+		{
+			name:        "setcode_authority_with_pooled_txs",
+			description: "a set code transaction whose authority has two pooled transactions",
+			fork:        "Prague",
+			txs: []poolTx{
+				{dynamic(authorityKey, 0, 1, 10), pending},
+				{dynamic(authorityKey, 1, 1, 10), pending},
+				{setCode(senderKey, 0, authorization(delegate, 2)), rejected(legacypool.ErrAuthorityReserved)},
+			},
+		},
+		{
+			name:        "setcode_authority_reserved_by_blob_pool",
+			description: "a set code transaction whose authority has a pooled blob transaction",
+			fork:        "Prague",
+			txs: []poolTx{
+				{blob(authorityKey, 0, 1, 10, 1), pending},
+				{setCode(senderKey, 0, authorization(delegate, 1)), rejected(legacypool.ErrAuthorityReserved)},
+			},
+		},
+		{
+			name:        "pending_delegation_inflight_limit",
+			description: "transactions of an authority delegated by a pooled set code transaction, limited to one in flight",
+			fork:        "Prague",
+			txs: []poolTx{
+				{setCode(senderKey, 0, authorization(delegate, 0)), pending},
+				{dynamic(authorityKey, 0, 1, 10), pending},
+				{dynamic(authorityKey, 1, 1, 10), rejected(txpool.ErrInflightTxLimitReached)},
+			},
+		},
+		{
+			name:        "delegated_sender_inflight_limit",
+			description: "transactions of a delegated account, limited to one in flight",
+			fork:        "Prague",
+			alloc:       delegated,
+			txs: []poolTx{
+				{dynamic(authorityKey, 0, 1, 10), pending},
+				{dynamic(authorityKey, 1, 1, 10), rejected(txpool.ErrInflightTxLimitReached)},
+			},
+		},
+		{
+			name:        "delegated_sender_replacement",
+			description: "a replacement of the in-flight transaction of a delegated account",
+			fork:        "Prague",
+			alloc:       delegated,
+			txs: []poolTx{
+				{dynamic(authorityKey, 0, 10, 100), dropped},
+				{dynamic(authorityKey, 0, 11, 110), pending},
+			},
+		},
+		{
+			name:        "delegated_sender_nonce_gap",
+			description: "a gapped transaction of a delegated account",
+			fork:        "Prague",
+			alloc:       delegated,
+			txs:         []poolTx{{dynamic(authorityKey, 1, 1, 10), rejected(legacypool.ErrOutOfOrderTxFromDelegated)}},
+		},
+		{
+			name:        "delegated_sender_blob_inflight_limit",
+			description: "blob transactions of a delegated account, limited to one in flight",
+			fork:        "Prague",
+			alloc:       delegated,
+			txs: []poolTx{
+				{blob(authorityKey, 0, 1, 10, 1), pending},
+				{blob(authorityKey, 1, 1, 10, 1), rejected(txpool.ErrInflightTxLimitReached)},
+			},
+		},
+	}
+}
@@ -0,0 +1,292 @@
+// txpool-vectors generates transaction pool acceptance vectors, using the
+// transaction pools of go-ethereum as the reference implementation.
+//
+// Usage:
+//
+//	go run ./cmd/txpool-vectors [--forks London,Shanghai,Cancun,Prague,Osaka] [--output txpool_vectors.json]
+//
+// Every vector is a genesis and a sequence of signed transactions in their
+// network encoding, blob transactions with their sidecars, submitted one by
+// one to an empty pool whose head is the genesis block. Each transaction is
+// annotated with the expected answer of the pool: accepted, with the status
+// it has once every transaction of the vector is submitted (pending,
+// queued, or dropped if a later transaction replaced it), or rejected, with
+// the rule rejecting it and go-ethereum's error.
+//
+// The vectors cover the validation rules shared by all pools, such as the
+// enabled transaction types, the EIP-3860 initcode size limit and the
+// EIP-7825 gas limit cap, and the pool policies where clients most often
+// disagree: fee caps below the base fee, minimum tips and blob fee caps, nonce
+// gaps, which blob pools do not queue, replacement price bumps, senders
+// reserved by the pool of the other transaction type, and the EIP-7702 limits
+// on delegated accounts, authorities with pooled transactions and conflicting
+// delegations.
+//
+// The rules are named by the TransactionException of the execution spec tests
+// where the rule is a validity rule, and as TxPool.NAME where it is a pool
+// policy. The expectations are declared for each fork with the cases and
+// checked against go-ethereum before anything is written.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/execution-specs/pkg/txbuilder"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
+	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// The parameters of the genesis.
+const (
+	gasLimit = 30000000
+	baseFee  = 7
+)
+
+// vector is a transaction pool acceptance vector.
+type vector struct {
+	Info         map[string]string `json:"_info"`
+	Network      string            `json:"network"`
+	Genesis      *core.Genesis     `json:"genesis"`
+	Transactions []*submission     `json:"transactions"`
+}
+
+// submission is a transaction of a vector with the expected answer of the
+// pool.
+type submission struct {
+	RLP      hexutil.Bytes  `json:"rlp"`
+	Hash     common.Hash    `json:"hash"`
+	Sender   common.Address `json:"sender"`
+	Expected *outcome       `json:"expected"`
+}
+
+// outcome is the answer of the pool to a transaction.
+type outcome struct {
+	Accepted bool   `json:"accepted"`
+	Status   string `json:"status,omitempty"` // pending, queued or dropped
+	Reason   string `json:"reason,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func main() {
+	var (
+		forkList = flag.String("forks", "London,Shanghai,Cancun,Prague,Osaka", "comma separated forks the vectors are generated for")
+		output   = flag.String("output", "txpool_vectors.json", "file the vectors are written to")
+	)
+	flag.Parse()
+	london, _ := forks.Index("London")
+	forkNames := strings.Split(*forkList, ",")
+	for _, fork := range forkNames {
+		if _, ok := tests.Forks[fork]; !ok {
+			fatalf("unknown fork %q", fork)
+		}
+		if index, err := forks.Index(fork); err != nil || index < london {
+			fatalf("fork %s predates EIP-1559", fork)
+		}
+	}
+	vectors := make(map[string]*vector)
+	for _, c := range cases() {
+		first := london
+		if c.fork != "" {
+			first, _ = forks.Index(c.fork)
+		}
+		for _, fork := range forkNames {
+			if index, _ := forks.Index(fork); index < first {
+				continue
+			}
+			v, err := fill(&c, fork)
+			if err != nil {
+				fatalf("case %s: %s: %v", c.name, fork, err)
+			}
+			vectors["txpool_vectors/"+c.name+"/"+fork] = v
+		}
+	}
+	data, err := json.MarshalIndent(vectors, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d vectors to %s\n", len(vectors), *output)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// fill submits the transactions of a test case to a go-ethereum pool on a
+// fork and checks its answers against the expectations of the case.
+func fill(c *testCase, fork string) (*vector, error) {
+	config := tests.Forks[fork]
+	genesis := &core.Genesis{
+		Config:     config,
+		GasLimit:   gasLimit,
+		BaseFee:    big.NewInt(baseFee),
+		Difficulty: new(big.Int),
+		Alloc:      make(types.GenesisAlloc),
+	}
+	if config.TerminalTotalDifficulty == nil {
+		genesis.Difficulty = big.NewInt(0x20000)
+	}
+	if config.CancunTime != nil {
+		genesis.ExcessBlobGas = &c.excessBlobGas
+		genesis.BlobGasUsed = new(uint64)
+	}
+	for _, key := range []common.Hash{senderKey, otherKey, authorityKey} {
+		genesis.Alloc[address(key)] = types.Account{Balance: big.NewInt(params.Ether)}
+	}
+	for addr, account := range c.alloc {
+		genesis.Alloc[addr] = account
+	}
+	rules := config.Rules(new(big.Int), config.TerminalTotalDifficulty != nil, 0)
+
+	var (
+		txs  = make([]*types.Transaction, len(c.txs))
+		want = make([]expectation, len(c.txs))
+	)
+	for i, s := range c.txs {
+		def := *s.tx
+		def.CellProofs = def.Blobs != nil && rules.IsOsaka
+		tx, err := txbuilder.Build(&def)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		txs[i], want[i] = tx, s.expect(rules)
+	}
+	have, err := submit(genesis, txs)
+	if err != nil {
+		return nil, err
+	}
+	v := &vector{
+		Info:    statetest.Info("txpool-vectors", "handcrafted", c.description),
+		Network: fork,
+		Genesis: genesis,
+	}
+	for i, tx := range txs {
+		if err := want[i].check(have[i]); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		enc, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		sender, _ := types.Sender(types.LatestSigner(config), tx)
+		v.Transactions = append(v.Transactions, &submission{RLP: enc, Hash: tx.Hash(), Sender: sender, Expected: have[i]})
+	}
+	return v, nil
+}
+
+// submit adds the transactions one by one to a new go-ethereum pool on top of
+// the genesis, configured as a node by default, and returns its answers.
+func submit(genesis *core.Genesis, txs []*types.Transaction) ([]*outcome, error) {
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), genesis, beacon.New(ethash.NewFaker()), core.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	defer chain.Stop()
+
+	legacyConfig := legacypool.DefaultConfig
+	legacyConfig.Journal = ""
+	legacyPool := legacypool.New(legacyConfig, chain)
+	blobConfig := blobpool.DefaultConfig
+	blobConfig.Datadir = ""
+	blobPool := blobpool.New(blobConfig, chain, legacyPool.HasPendingAuth)
+	pool, err := txpool.New(legacyConfig.PriceLimit, chain, []txpool.SubPool{legacyPool, blobPool})
+	if err != nil {
+		return nil, err
+	}
+	defer pool.Close()
+
+	outcomes := make([]*outcome, len(txs))
+	for i, tx := range txs {
+		if err := pool.Add([]*types.Transaction{tx}, true)[0]; err != nil {
+			outcomes[i] = &outcome{Reason: reason(err), Error: err.Error()}
+			continue
+		}
+		outcomes[i] = &outcome{Accepted: true}
+	}
+	if err := pool.Sync(); err != nil {
+		return nil, err
+	}
+	for i, tx := range txs {
+		if !outcomes[i].Accepted {
+			continue
+		}
+		switch pool.Status(tx.Hash()) {
+		case txpool.TxStatusPending:
+			outcomes[i].Status = statusPending
+		case txpool.TxStatusQueued:
+			outcomes[i].Status = statusQueued
+		default:
+			outcomes[i].Status = statusDropped
+		}
+	}
+	return outcomes, nil
+}
+
+// The statuses of accepted transactions.
+const (
+	statusPending = "pending"
+	statusQueued  = "queued"
+	statusDropped = "dropped"
+)
+
+// errEmptyAuthorizations is the unexported error go-ethereum's pools reject
+// set code transactions without authorizations with.
+var errEmptyAuthorizations = errors.New("set code tx must have at least one authorization tuple")
+
+// reasons names the errors of go-ethereum's pools. Validity rules carry the
+// name of the TransactionException of the execution spec tests, pool
+// policies their own.
+var reasons = []struct {
+	err  error
+	name string
+}{
+	{core.ErrTxTypeNotSupported, "TransactionException.TYPE_NOT_SUPPORTED"},
+	{core.ErrMaxInitCodeSizeExceeded, "TransactionException.INITCODE_SIZE_EXCEEDED"},
+	{core.ErrGasLimitTooHigh, "TransactionException.GAS_LIMIT_EXCEEDS_MAXIMUM"},
+	{txpool.ErrGasLimit, "TransactionException.GAS_ALLOWANCE_EXCEEDED"},
+	{core.ErrTipAboveFeeCap, "TransactionException.PRIORITY_GREATER_THAN_MAX_FEE_PER_GAS"},
+	{core.ErrIntrinsicGas, "TransactionException.INTRINSIC_GAS_TOO_LOW"},
+	{core.ErrFloorDataGas, "TransactionException.INTRINSIC_GAS_BELOW_FLOOR_GAS_COST"},
+	{core.ErrNonceTooLow, "TransactionException.NONCE_MISMATCH_TOO_LOW"},
+	{core.ErrNonceTooHigh, "TransactionException.NONCE_MISMATCH_TOO_HIGH"},
+	{core.ErrInsufficientFunds, "TransactionException.INSUFFICIENT_ACCOUNT_FUNDS"},
+	{errEmptyAuthorizations, "TransactionException.TYPE_4_EMPTY_AUTHORIZATION_LIST"},
+	{txpool.ErrTxGasPriceTooLow, "TxPool.UNDERPRICED"},
+	{txpool.ErrReplaceUnderpriced, "TxPool.REPLACEMENT_UNDERPRICED"},
+	{txpool.ErrAlreadyReserved, "TxPool.SENDER_RESERVED"},
+	{txpool.ErrInflightTxLimitReached, "TxPool.DELEGATED_INFLIGHT_LIMIT"},
+	{legacypool.ErrOutOfOrderTxFromDelegated, "TxPool.DELEGATED_NONCE_GAP"},
+	{legacypool.ErrAuthorityReserved, "TxPool.AUTHORITY_RESERVED"},
+}
+
+// reason returns the name of the rule a go-ethereum error stands for, or the
+// error itself if it is not one of the named ones.
+func reason(err error) string {
+	for _, r := range reasons {
+		if errors.Is(err, r.err) || err.Error() == r.err.Error() {
+			return r.name
+		}
+	}
+	return err.Error()
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+)
+
+// The error classes of the vectors.
+const (
+	classTypeNotSupported    = "TransactionException.TYPE_NOT_SUPPORTED"
+	classTruncated           = "RLP.TRUNCATED"
+	classTrailingBytes       = "RLP.TRAILING_BYTES"
+	classExpectedList        = "RLP.EXPECTED_LIST"
+	classExpectedString      = "RLP.EXPECTED_STRING"
+	classTooFewElements      = "RLP.TOO_FEW_ELEMENTS"
+	classTooManyElements     = "RLP.TOO_MANY_ELEMENTS"
+	classNonCanonicalInteger = "RLP.NON_CANONICAL_INTEGER"
+	classNonCanonicalSize    = "RLP.NON_CANONICAL_SIZE"
+	classFieldTooLarge       = "RLP.FIELD_TOO_LARGE"
+	classFieldTooShort       = "RLP.FIELD_TOO_SHORT"
+)
+
+// The indexes of the fields of the transactions mutated by the cases.
+const (
+	legacyNonce    = 0
+	legacyGasPrice = 1
+	legacyGas      = 2
+	legacyTo       = 3
+	legacyData     = 5
+	legacyV        = 6
+
+	accessListList = 7
+
+	dynamicChainID = 0
+	dynamicTo      = 5
+
+	blobChainID = 0
+	blobTo      = 5
+	blobValue   = 6
+	blobHashes  = 10
+
+	setCodeTo       = 5
+	setCodeAuthList = 9
+	setCodeR        = 11
+
+	authNonce   = 2
+	authYParity = 3
+)
+
+var (
+	// The key signing the transactions and the authorization.
+	senderKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+
+	recipient  = common.HexToAddress("0x000000000000000000000000000000000000dead")
+	delegate   = common.HexToAddress("0x0000000000000000000000000000000000007702")
+	storageKey = common.HexToHash("0x0000000000000000000000000000000000000000000000000000000000000001")
+	blobHash   = common.HexToHash("0x0100000000000000000000000000000000000000000000000000000000000001")
+)
+
+// testCase is a malformed transaction encoding and the class of error it is
+// rejected with.
+type testCase struct {
+	name        string
+	description string
+	class       string
+	txBytes     []byte
+}
+
+// encoding is a transaction encoding split into its type byte, none for a
+// legacy transaction, and the elements of its RLP list.
+type encoding struct {
+	prefix []byte
+	fields []rlp.RawValue
+}
+
+// split splits the encoding of a transaction.
+func split(tx *types.Transaction) (encoding, error) {
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		return encoding{}, err
+	}
+	var e encoding
+	if tx.Type() != types.LegacyTxType {
+		e.prefix, enc = enc[:1], enc[1:]
+	}
+	if err := rlp.DecodeBytes(enc, &e.fields); err != nil {
+		return encoding{}, err
+	}
+	return e, nil
+}
+
+// bytes joins the encoding back together.
+func (e encoding) bytes() []byte {
+	return append(common.CopyBytes(e.prefix), list(e.fields...)...)
+}
+
+// with returns the encoding with the field at index i replaced.
+func (e encoding) with(i int, value rlp.RawValue) encoding {
+	fields := append([]rlp.RawValue{}, e.fields...)
+	fields[i] = value
+	return encoding{e.prefix, fields}
+}
+
+// without returns the encoding without its last field.
+func (e encoding) without() encoding {
+	return encoding{e.prefix, e.fields[:len(e.fields)-1]}
+}
+
+// plus returns the encoding with a field appended.
+func (e encoding) plus(value rlp.RawValue) encoding {
+	return encoding{e.prefix, append(append([]rlp.RawValue{}, e.fields...), value)}
+}
+
+// raw returns a hex encoded RLP item as it is.
+func raw(s string) rlp.RawValue {
+	return hexutil.MustDecode(s)
+}
+
+// str returns the RLP string of the bytes.
+func str(b []byte) rlp.RawValue {
+	enc, _ := rlp.EncodeToBytes(b)
+	return enc
+}
+
+// list returns the RLP list of the items.
+func list(items ...rlp.RawValue) rlp.RawValue {
+	enc, _ := rlp.EncodeToBytes(items)
+	return enc
+}
+
+// elements returns the elements of an RLP list.
+func elements(l rlp.RawValue) ([]rlp.RawValue, error) {
+	var items []rlp.RawValue
+	err := rlp.DecodeBytes(l, &items)
+	return items, err
+}
+
+// longList returns the RLP list of the content with the given size in its
+// long size prefix, which must take one byte.
+func longList(content []byte, size int) ([]byte, error) {
+	if size < 56 || size > 0xff {
+		return nil, fmt.Errorf("list size %d does not take a long one byte prefix", size)
+	}
+	return append([]byte{0xf8, byte(size)}, content...), nil
+}
+
+// bases returns the valid encodings the cases mutate: a legacy, an access
+// list, a dynamic fee, a blob and a set code transaction, and the
+// authorization of the last.
+func bases() (legacy, accessList, dynamic, blob, setCode encoding, auth []rlp.RawValue, err error) {
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	authorization, err := types.SignSetCode(senderKey, types.SetCodeAuthorization{ChainID: *uint256.NewInt(1), Address: delegate})
+	if err != nil {
+		return
+	}
+	var encodings []encoding
+	for _, data := range []types.TxData{
+		&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(10), Gas: 21000, To: &recipient, Value: big.NewInt(1)},
+		&types.AccessListTx{
+			ChainID: big.NewInt(1), Nonce: 1, GasPrice: big.NewInt(10), Gas: 30000, To: &recipient, Value: big.NewInt(1),
+			AccessList: types.AccessList{{Address: recipient, StorageKeys: []common.Hash{storageKey}}},
+		},
+		&types.DynamicFeeTx{ChainID: big.NewInt(1), Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10), Gas: 21000, To: &recipient, Value: big.NewInt(1)},
+		&types.BlobTx{
+			ChainID: uint256.NewInt(1), Nonce: 1, GasTipCap: uint256.NewInt(1), GasFeeCap: uint256.NewInt(10), Gas: 21000, To: recipient, Value: uint256.NewInt(1),
+			BlobFeeCap: uint256.NewInt(1), BlobHashes: []common.Hash{blobHash},
+		},
+		&types.SetCodeTx{
+			ChainID: uint256.NewInt(1), Nonce: 1, GasTipCap: uint256.NewInt(1), GasFeeCap: uint256.NewInt(10), Gas: 50000, To: recipient, Value: uint256.NewInt(1),
+			AuthList: []types.SetCodeAuthorization{authorization},
+		},
+	} {
+		tx, err := types.SignNewTx(senderKey, signer, data)
+		if err != nil {
+			return legacy, accessList, dynamic, blob, setCode, nil, err
+		}
+		e, err := split(tx)
+		if err != nil {
+			return legacy, accessList, dynamic, blob, setCode, nil, err
+		}
+		// The mutations must start from encodings which decode.
+		if err := new(types.Transaction).UnmarshalBinary(e.bytes()); err != nil {
+			return legacy, accessList, dynamic, blob, setCode, nil, fmt.Errorf("base transaction of type %d: %v", tx.Type(), err)
+		}
+		encodings = append(encodings, e)
+	}
+	legacy, accessList, dynamic, blob, setCode = encodings[0], encodings[1], encodings[2], encodings[3], encodings[4]
+	auths, err := elements(setCode.fields[setCodeAuthList])
+	if err != nil {
+		return
+	}
+	auth, err = elements(auths[0])
+	return
+}
+
+func cases() ([]testCase, error) {
+	legacy, accessList, dynamic, blob, setCode, auth, err := bases()
+	if err != nil {
+		return nil, err
+	}
+	legacyBytes, dynamicBytes := legacy.bytes(), dynamic.bytes()
+	_, content, _, err := rlp.Split(legacyBytes)
+	if err != nil {
+		return nil, err
+	}
+	shortList, err := longList(content, len(content)-1)
+	if err != nil {
+		return nil, err
+	}
+	withAuth := func(i int, value rlp.RawValue) rlp.RawValue {
+		fields := append([]rlp.RawValue{}, auth...)
+		fields[i] = value
+		return list(list(fields...))
+	}
+	return []testCase{
+		// Truncated input.
+		{"empty", "No input at all.", classTruncated, []byte{}},
+		{"type_byte_only", "The type byte of a dynamic fee transaction without its payload.", classTruncated, []byte{types.DynamicFeeTxType}},
+		{"legacy_truncated", "A legacy transaction without its last byte.", classTruncated, legacyBytes[:len(legacyBytes)-1]},
+		{"typed_truncated", "A dynamic fee transaction without its last byte.", classTruncated, dynamicBytes[:len(dynamicBytes)-1]},
+		{"list_size_too_small", "A legacy transaction whose list size is one byte short of its content, which the signature s extends beyond.", classTruncated, shortList},
+
+		// Type bytes.
+		{"type_0x05", "The payload of a dynamic fee transaction behind the undefined type byte 0x05.", classTypeNotSupported, append([]byte{0x05}, dynamicBytes[1:]...)},
+		{"type_0x7f", "The payload of a dynamic fee transaction behind 0x7f, the highest type byte of EIP-2718.", classTypeNotSupported, append([]byte{0x7f}, dynamicBytes[1:]...)},
+		{"type_0x00", "A legacy transaction behind the type byte 0x00, which is not the envelope of legacy transactions.", classTypeNotSupported, append([]byte{0x00}, legacyBytes...)},
+		{"typed_in_rlp_string", "A dynamic fee transaction wrapped into an RLP string, as in the transactions list of a block, which starts like a legacy transaction.", classExpectedList, str(dynamicBytes)},
+
+		// Trailing bytes.
+		{"legacy_trailing_byte", "A legacy transaction followed by a zero byte.", classTrailingBytes, append(common.CopyBytes(legacyBytes), 0x00)},
+		{"legacy_trailing_list", "A legacy transaction followed by an empty list.", classTrailingBytes, append(common.CopyBytes(legacyBytes), 0xc0)},
+		{"typed_trailing_byte", "A dynamic fee transaction followed by a zero byte.", classTrailingBytes, append(common.CopyBytes(dynamicBytes), 0x00)},
+		{"blob_trailing_byte", "A blob transaction followed by a zero byte.", classTrailingBytes, append(blob.bytes(), 0x00)},
+
+		// Numbers of elements.
+		{"legacy_too_few_fields", "A legacy transaction without the signature s.", classTooFewElements, legacy.without().bytes()},
+		{"legacy_too_many_fields", "A legacy transaction with an empty string after the signature.", classTooManyElements, legacy.plus(raw("0x80")).bytes()},
+		{"dynamic_too_few_fields", "A dynamic fee transaction without the signature s.", classTooFewElements, dynamic.without().bytes()},
+		{"dynamic_too_many_fields", "A dynamic fee transaction with an empty string after the signature.", classTooManyElements, dynamic.plus(raw("0x80")).bytes()},
+		{"set_code_too_few_fields", "A set code transaction without the signature s.", classTooFewElements, setCode.without().bytes()},
+		{"access_tuple_too_few_elements", "An access list entry without its storage keys.", classTooFewElements, accessList.with(accessListList, list(list(str(recipient[:])))).bytes()},
+		{"access_tuple_too_many_elements", "An access list entry with an empty string after its storage keys.", classTooManyElements, accessList.with(accessListList, list(list(str(recipient[:]), list(str(storageKey[:])), raw("0x80")))).bytes()},
+		{"authorization_too_few_elements", "An authorization without its signature s.", classTooFewElements, setCode.with(setCodeAuthList, list(list(auth[:len(auth)-1]...))).bytes()},
+		{"authorization_too_many_elements", "An authorization with an empty string after its signature.", classTooManyElements, setCode.with(setCodeAuthList, list(list(append(append([]rlp.RawValue{}, auth...), raw("0x80"))...))).bytes()},
+
+		// Kinds of fields.
+		{"nonce_as_list", "The nonce of a legacy transaction as an empty list.", classExpectedString, legacy.with(legacyNonce, raw("0xc0")).bytes()},
+		{"data_as_list", "The data of a legacy transaction as an empty list.", classExpectedString, legacy.with(legacyData, raw("0xc0")).bytes()},
+		{"to_as_list", "The recipient of a dynamic fee transaction as an empty list.", classExpectedString, dynamic.with(dynamicTo, raw("0xc0")).bytes()},
+		{"access_list_as_string", "The access list of an access list transaction as an empty string.", classExpectedList, accessList.with(accessListList, raw("0x80")).bytes()},
+		{"storage_keys_as_string", "The storage keys of an access list entry as an empty string.", classExpectedList, accessList.with(accessListList, list(list(str(recipient[:]), raw("0x80")))).bytes()},
+		{"blob_hashes_as_string", "The blob versioned hashes of a blob transaction as an empty string.", classExpectedList, blob.with(blobHashes, raw("0x80")).bytes()},
+		{"authorization_as_string", "An authorization of a set code transaction as an empty string.", classExpectedList, setCode.with(setCodeAuthList, list(raw("0x80"))).bytes()},
+
+		// Non-canonical integers.
+		{"nonce_leading_zero", "The nonce 1 of a legacy transaction as 0x0001.", classNonCanonicalInteger, legacy.with(legacyNonce, raw("0x820001")).bytes()},
+		{"nonce_zero_byte", "The nonce 0 of a legacy transaction as the byte 0x00 instead of the empty string.", classNonCanonicalInteger, legacy.with(legacyNonce, raw("0x00")).bytes()},
+		{"gas_price_leading_zero", "The gas price 10 of a legacy transaction as 0x000a.", classNonCanonicalInteger, legacy.with(legacyGasPrice, raw("0x82000a")).bytes()},
+		{"v_leading_zero", "The EIP-155 v 37 of a legacy transaction as 0x0025.", classNonCanonicalInteger, legacy.with(legacyV, raw("0x820025")).bytes()},
+		{"chain_id_leading_zero", "The chain id 1 of a dynamic fee transaction as 0x0001.", classNonCanonicalInteger, dynamic.with(dynamicChainID, raw("0x820001")).bytes()},
+		{"blob_value_leading_zero", "The value 1 of a blob transaction as 0x0001.", classNonCanonicalInteger, blob.with(blobValue, raw("0x820001")).bytes()},
+		{"set_code_r_leading_zero", "The signature r of a set code transaction as 0x0001.", classNonCanonicalInteger, setCode.with(setCodeR, raw("0x820001")).bytes()},
+
+		// Non-canonical sizes.
+		{"single_byte_as_string", "The nonce 5 of a legacy transaction as the string 0x8105 instead of the byte 0x05.", classNonCanonicalSize, legacy.with(legacyNonce, raw("0x8105")).bytes()},
+		{"long_size_short_string", "Two bytes of data of a legacy transaction with a long size prefix.", classNonCanonicalSize, legacy.with(legacyData, raw("0xb802abcd")).bytes()},
+		{"size_leading_zero", "56 bytes of data of a legacy transaction whose long size has a leading zero byte.", classNonCanonicalSize, legacy.with(legacyData, append(raw("0xb90038"), make([]byte, 56)...)).bytes()},
+		{"long_size_short_list", "The empty access list of an access list transaction with a long size prefix.", classNonCanonicalSize, accessList.with(accessListList, raw("0xf800")).bytes()},
+
+		// Sizes of fields.
+		{"nonce_over_64_bits", "A nonce of 2^64, beyond the uint64 of EIP-2681.", classFieldTooLarge, legacy.with(legacyNonce, raw("0x89010000000000000000")).bytes()},
+		{"gas_over_64_bits", "A gas limit of 2^64.", classFieldTooLarge, legacy.with(legacyGas, raw("0x89010000000000000000")).bytes()},
+		{"to_21_bytes", "A recipient of 21 bytes.", classFieldTooLarge, legacy.with(legacyTo, str(append(recipient.Bytes(), 0x00))).bytes()},
+		{"to_19_bytes", "A recipient of 19 bytes.", classFieldTooShort, legacy.with(legacyTo, str(recipient[1:])).bytes()},
+		{"storage_key_33_bytes", "An access list storage key of 33 bytes.", classFieldTooLarge, accessList.with(accessListList, list(list(str(recipient[:]), list(str(append(storageKey.Bytes(), 0x00)))))).bytes()},
+		{"storage_key_31_bytes", "An access list storage key of 31 bytes.", classFieldTooShort, accessList.with(accessListList, list(list(str(recipient[:]), list(str(storageKey[1:]))))).bytes()},
+		{"blob_hash_33_bytes", "A blob versioned hash of 33 bytes.", classFieldTooLarge, blob.with(blobHashes, list(str(append(blobHash.Bytes(), 0x00)))).bytes()},
+		{"blob_chain_id_over_256_bits", "A chain id of 2^256 in a blob transaction.", classFieldTooLarge, blob.with(blobChainID, str(append([]byte{1}, make([]byte, 32)...))).bytes()},
+		{"blob_value_over_256_bits", "A value of 2^256 in a blob transaction.", classFieldTooLarge, blob.with(blobValue, str(append([]byte{1}, make([]byte, 32)...))).bytes()},
+		{"set_code_r_over_256_bits", "A signature r of 2^256 in a set code transaction.", classFieldTooLarge, setCode.with(setCodeR, str(append([]byte{1}, make([]byte, 32)...))).bytes()},
+		{"authorization_nonce_over_64_bits", "An authorization nonce of 2^64.", classFieldTooLarge, setCode.with(setCodeAuthList, withAuth(authNonce, raw("0x89010000000000000000"))).bytes()},
+		{"authorization_y_parity_over_8_bits", "An authorization y parity of 256, beyond its byte.", classFieldTooLarge, setCode.with(setCodeAuthList, withAuth(authYParity, raw("0x820100"))).bytes()},
+		{"blob_create", "A blob transaction with the empty recipient of a contract creation, which blob transactions cannot be.", classFieldTooShort, blob.with(blobTo, raw("0x80")).bytes()},
+		{"set_code_create", "A set code transaction with the empty recipient of a contract creation, which set code transactions cannot be.", classFieldTooShort, setCode.with(setCodeTo, raw("0x80")).bytes()},
+	}, nil
+}
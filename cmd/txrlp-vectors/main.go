@@ -0,0 +1,189 @@
+// txrlp-vectors generates negative vectors of the decoding of transactions:
+// malformed encodings, each annotated with the class of error a decoder must
+// reject it with, for conformance tests of the wire-level transaction
+// decoders of clients.
+//
+// Usage:
+//
+//	go run ./cmd/txrlp-vectors [--forks Frontier,...,Osaka] [--output txrlp_vectors.json]
+//
+// The vectors are mutations of valid, signed transactions of every type, and
+// cover empty and truncated input, type bytes no fork defines, typed
+// transactions wrapped into an RLP string, trailing bytes, lists with too few
+// or too many elements, fields of the wrong kind, non-canonical integers and
+// size prefixes, and fields too long or too short for their type: integers
+// beyond 64 or 256 bits, addresses and hashes of 19, 21, 31 or 33 bytes and
+// contract creations of the transaction types which cannot create.
+//
+// The error classes are
+//
+//   - TransactionException.TYPE_NOT_SUPPORTED, a type byte no fork defines.
+//   - RLP.TRUNCATED, input ending before the transaction, one of its fields
+//     or an element of one of its lists does by its size prefix.
+//   - RLP.TRAILING_BYTES, input continuing after the transaction.
+//   - RLP.EXPECTED_LIST and RLP.EXPECTED_STRING, a string where a list must
+//     be and conversely.
+//   - RLP.TOO_FEW_ELEMENTS and RLP.TOO_MANY_ELEMENTS, a list of the wrong
+//     number of elements.
+//   - RLP.NON_CANONICAL_INTEGER, an integer with leading zero bytes.
+//   - RLP.NON_CANONICAL_SIZE, a size prefix longer than needed, a single byte
+//     below 0x80 encoded as a string or a long size with leading zero bytes.
+//   - RLP.FIELD_TOO_LARGE and RLP.FIELD_TOO_SHORT, a field too long or too
+//     short for its type.
+//
+// The vectors are written in the format of the transaction_tests fixtures,
+// with the error class as the exception of every fork and as errorClass. A
+// malformed encoding is rejected whatever the fork, before the rules of the
+// fork apply. Every vector is decoded with go-ethereum, whose error must be of
+// the declared class, and run through go-ethereum's transaction test runner
+// before anything is written.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// txFixture is a transaction_tests fixture of a malformed encoding, with the
+// class of the decoding error.
+type txFixture struct {
+	Info       map[string]string    `json:"_info"`
+	TxBytes    hexutil.Bytes        `json:"txbytes"`
+	ErrorClass string               `json:"errorClass"`
+	Result     map[string]*txResult `json:"result"`
+}
+
+// txResult is the outcome of a transaction vector on one fork.
+type txResult struct {
+	IntrinsicGas hexutil.Uint64 `json:"intrinsicGas"`
+	Exception    string         `json:"exception"`
+}
+
+func main() {
+	var (
+		forkList = flag.String("forks", "Frontier,Homestead,EIP150,EIP158,Byzantium,ConstantinopleFix,Istanbul,Berlin,London,Paris,Shanghai,Cancun,Prague,Osaka", "comma separated forks the vectors are filled for")
+		output   = flag.String("output", "txrlp_vectors.json", "file the vectors are written to")
+	)
+	flag.Parse()
+	forkNames := strings.Split(*forkList, ",")
+	for _, fork := range forkNames {
+		if _, ok := tests.Forks[fork]; !ok {
+			fatalf("unknown fork %q", fork)
+		}
+		if _, err := forks.Index(fork); err != nil {
+			fatalf("%v", err)
+		}
+	}
+	cs, err := cases()
+	if err != nil {
+		fatalf("%v", err)
+	}
+	fixtures := make(map[string]*txFixture, len(cs))
+	for _, c := range cs {
+		if _, ok := fixtures["txrlp_vectors/"+c.name]; ok {
+			fatalf("duplicate case %s", c.name)
+		}
+		f, err := fill(&c, forkNames)
+		if err != nil {
+			fatalf("case %s: %v", c.name, err)
+		}
+		fixtures["txrlp_vectors/"+c.name] = f
+	}
+	data, err := json.MarshalIndent(fixtures, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d vectors to %s on %d forks\n", len(fixtures), *output, len(forkNames))
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// classes names the decoding errors of go-ethereum by the messages of its
+// transaction and RLP decoders, which wrap most errors without keeping them.
+var classes = []struct {
+	message string
+	class   string
+}{
+	{types.ErrTxTypeNotSupported.Error(), classTypeNotSupported},
+	{"typed transaction too short", classTruncated},
+	{"value size exceeds available input length", classTruncated},
+	{"element is larger than containing list", classTruncated},
+	{"unexpected EOF", classTruncated},
+	{"input contains more than one value", classTrailingBytes},
+	{"expected input list", classExpectedList},
+	{"expected List", classExpectedList},
+	{"expected input string or byte", classExpectedString},
+	{"expected String or Byte", classExpectedString},
+	{"got List, want String", classExpectedString},
+	{"got String, want List", classExpectedList},
+	{"too few elements", classTooFewElements},
+	{"too many elements", classTooManyElements},
+	{"non-canonical integer", classNonCanonicalInteger},
+	{"non-canonical size information", classNonCanonicalSize},
+	{"input string too long", classFieldTooLarge},
+	{"value too large for uint256", classFieldTooLarge},
+	{"input string too short", classFieldTooShort},
+}
+
+// class returns the class of a decoding error of go-ethereum, or the error
+// itself if it is of none.
+func class(err error) string {
+	for _, c := range classes {
+		if strings.Contains(err.Error(), c.message) {
+			return c.class
+		}
+	}
+	return err.Error()
+}
+
+// fill builds the fixture of a case, checks that go-ethereum rejects the
+// encoding with an error of the class of the case and runs the fixture
+// through go-ethereum's transaction tests.
+func fill(c *testCase, forkNames []string) (*txFixture, error) {
+	err := new(types.Transaction).UnmarshalBinary(c.txBytes)
+	if err == nil {
+		return nil, errors.New("go-ethereum decodes the transaction")
+	}
+	if got := class(err); got != c.class {
+		return nil, fmt.Errorf("go-ethereum error %q is of class %s, want %s", err, got, c.class)
+	}
+	f := &txFixture{
+		Info:       statetest.Info("txrlp-vectors", "handcrafted", c.description),
+		TxBytes:    c.txBytes,
+		ErrorClass: c.class,
+		Result:     make(map[string]*txResult, len(forkNames)),
+	}
+	f.Info["go-ethereum-error"] = err.Error()
+	for _, fork := range forkNames {
+		f.Result[fork] = &txResult{Exception: c.class}
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	var test tests.TransactionTest
+	if err := json.Unmarshal(data, &test); err != nil {
+		return nil, err
+	}
+	if err := test.Run(); err != nil {
+		return nil, fmt.Errorf("transaction test: %v", err)
+	}
+	return f, nil
+}
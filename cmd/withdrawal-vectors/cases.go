@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Exceptions of the invalid blocks.
+const (
+	exceptionRoot   = "BlockException.INVALID_WITHDRAWALS_ROOT"
+	exceptionState  = "BlockException.INVALID_STATE_ROOT"
+	exceptionFormat = "BlockException.INCORRECT_BLOCK_FORMAT"
+)
+
+var (
+	// recipientKey controls an account first funded by a withdrawal.
+	recipientKey, _ = crypto.HexToECDSA("4895000000000000000000000000000000000000000000000000000000000001")
+	recipient       = crypto.PubkeyToAddress(recipientKey.PublicKey)
+
+	// storeCode stores 1 in slot 0 if the contract is ever called.
+	storeCode = []byte{byte(vm.PUSH1), 1, byte(vm.PUSH0), byte(vm.SSTORE), byte(vm.STOP)}
+)
+
+// testCase is a chain of blocks with withdrawals, built on top of a genesis
+// funding the sender and holding the system contracts of the fork.
+type testCase struct {
+	name        string
+	description string
+	preShanghai bool // built for the forks before Shanghai instead of those from it
+
+	// alloc holds further accounts of the genesis.
+	alloc types.GenesisAlloc
+
+	// blocks returns the blocks of the chain.
+	blocks func() ([]*blocktest.Block, error)
+
+	// check verifies the fixture of the chain.
+	check func(f *blocktest.Fixture) error
+}
+
+// account returns the n-th withdrawal recipient, an address without code or
+// balance in the genesis.
+func account(n int) common.Address {
+	return common.BigToAddress(big.NewInt(int64(0x48950000 + n)))
+}
+
+// withdrawal returns a withdrawal of amount Gwei to addr. The index only
+// matters to blocks keeping their withdrawal indices, the others are numbered
+// along the chain.
+func withdrawal(index, validator uint64, addr common.Address, amount uint64) *types.Withdrawal {
+	return &types.Withdrawal{Index: index, Validator: validator, Address: addr, Amount: amount}
+}
+
+// gwei returns the balance in wei credited by withdrawals of the amounts.
+func gwei(amounts ...uint64) *big.Int {
+	sum := new(big.Int)
+	for _, amount := range amounts {
+		sum.Add(sum, new(big.Int).SetUint64(amount))
+	}
+	return sum.Mul(sum, big.NewInt(params.GWei))
+}
+
+// withdrawalsRoot computes the withdrawals root of a list of withdrawals.
+func withdrawalsRoot(withdrawals []*types.Withdrawal) common.Hash {
+	return types.DeriveSha(types.Withdrawals(withdrawals), trie.NewStackTrie(nil))
+}
+
+// withWithdrawals returns the blocks holding the given withdrawals, one block
+// per list. The blocks keep the indices of the withdrawals if keep is set.
+func withWithdrawals(keep bool, lists ...[]*types.Withdrawal) func() ([]*blocktest.Block, error) {
+	return func() ([]*blocktest.Block, error) {
+		blocks := make([]*blocktest.Block, len(lists))
+		for i, list := range lists {
+			blocks[i] = &blocktest.Block{Withdrawals: list, WithdrawalIndices: keep}
+		}
+		return blocks, nil
+	}
+}
+
+// invalidBlock returns a single block with the withdrawals, invalid by the
+// corruption with the exception and a part of go-ethereum's error.
+func invalidBlock(withdrawals []*types.Withdrawal, corruption blocktest.Corruption, exception, reason string) func() ([]*blocktest.Block, error) {
+	return func() ([]*blocktest.Block, error) {
+		return []*blocktest.Block{{
+			Withdrawals:     withdrawals,
+			Corrupt:         &corruption,
+			ExpectException: exception,
+			Reasons:         []string{reason},
+		}}, nil
+	}
+}
+
+// checkWithdrawals returns a check of the withdrawals in the body of every
+// block, nil for a body without a withdrawals list, and of the withdrawals
+// root of every valid block.
+func checkWithdrawals(blocks ...[]*types.Withdrawal) blocktest.Check {
+	return func(f *blocktest.Fixture) error {
+		if len(f.Blocks) != len(blocks) {
+			return fmt.Errorf("%d blocks, expected %d", len(f.Blocks), len(blocks))
+		}
+		for i, want := range blocks {
+			block := new(types.Block)
+			if err := rlp.DecodeBytes(f.Blocks[i].RLP, block); err != nil {
+				return fmt.Errorf("block %d: %v", i+1, err)
+			}
+			have := block.Withdrawals()
+			if (have == nil) != (want == nil) || len(have) != len(want) {
+				return fmt.Errorf("block %d: %d withdrawals, expected %d", i+1, len(have), len(want))
+			}
+			for j := range want {
+				if *have[j] != *want[j] {
+					return fmt.Errorf("block %d: withdrawal %d is %+v, expected %+v", i+1, j, *have[j], *want[j])
+				}
+			}
+			if header := f.Blocks[i].BlockHeader; header != nil && want != nil {
+				root := withdrawalsRoot(want)
+				if header.WithdrawalsRoot == nil || *header.WithdrawalsRoot != root {
+					return fmt.Errorf("block %d: withdrawals root %v, expected %s", i+1, header.WithdrawalsRoot, root.Hex())
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// cases returns the handcrafted test cases.
+func cases() []testCase {
+	var (
+		one   = []*types.Withdrawal{withdrawal(0, 0, account(1), 1)}
+		many  = make([]*types.Withdrawal, 16)
+		pair  = []*types.Withdrawal{withdrawal(0, 0, account(1), 1), withdrawal(1, 1, account(2), 2)}
+		paid  = []*types.Withdrawal{withdrawal(0, 0, recipient, params.Ether/params.GWei)}
+		value = big.NewInt(params.Ether / 2)
+	)
+	for i := range many {
+		// Two recipients, alternating, each paid by several validators.
+		many[i] = withdrawal(uint64(i), uint64(i%5), account(1+i%2), uint64(i+1))
+	}
+	return []testCase{
+		{
+			name:        "single",
+			description: "one withdrawal of 1 Gwei to a new account",
+			blocks:      withWithdrawals(false, one),
+			check:       blocktest.All(blocktest.CheckBalance(account(1), gwei(1)), checkWithdrawals(one)),
+		},
+		{
+			name:        "many_same_block",
+			description: "16 withdrawals in a block, alternating between two recipients",
+			blocks:      withWithdrawals(false, many),
+			check: blocktest.All(
+				blocktest.CheckBalance(account(1), gwei(1, 3, 5, 7, 9, 11, 13, 15)),
+				blocktest.CheckBalance(account(2), gwei(2, 4, 6, 8, 10, 12, 14, 16)),
+				checkWithdrawals(many),
+			),
+		},
+		{
+			name:        "consecutive_blocks",
+			description: "withdrawals numbered along the chain across a block without withdrawals",
+			blocks: withWithdrawals(false,
+				[]*types.Withdrawal{withdrawal(0, 0, account(1), 1), withdrawal(0, 1, account(1), 2)},
+				nil,
+				[]*types.Withdrawal{withdrawal(0, 0, account(1), 4)},
+			),
+			check: blocktest.All(
+				blocktest.CheckBalance(account(1), gwei(1, 2, 4)),
+				checkWithdrawals(
+					[]*types.Withdrawal{withdrawal(0, 0, account(1), 1), withdrawal(1, 1, account(1), 2)},
+					[]*types.Withdrawal{},
+					[]*types.Withdrawal{withdrawal(2, 0, account(1), 4)},
+				),
+			),
+		},
+		{
+			name:        "empty_list",
+			description: "a block without withdrawals, committing to the empty withdrawals list",
+			blocks:      withWithdrawals(false, nil),
+			check: blocktest.All(
+				checkWithdrawals([]*types.Withdrawal{}),
+				func(f *blocktest.Fixture) error {
+					if root := f.Blocks[0].BlockHeader.WithdrawalsRoot; *root != types.EmptyWithdrawalsHash {
+						return fmt.Errorf("withdrawals root %s, expected the empty root", root.Hex())
+					}
+					return nil
+				},
+			),
+		},
+		{
+			name:        "zero_amount_new_account",
+			description: "a zero withdrawal to a new account, which stays empty and is not created",
+			blocks:      withWithdrawals(false, []*types.Withdrawal{withdrawal(0, 0, account(1), 0)}),
+			check:       blocktest.All(blocktest.CheckAbsent(account(1)), checkWithdrawals([]*types.Withdrawal{withdrawal(0, 0, account(1), 0)})),
+		},
+		{
+			name:        "zero_amount_existing_account",
+			description: "a zero withdrawal to an account with a balance of 1 wei",
+			alloc:       types.GenesisAlloc{account(1): {Balance: big.NewInt(1)}},
+			blocks:      withWithdrawals(false, []*types.Withdrawal{withdrawal(0, 0, account(1), 0)}),
+			check:       blocktest.CheckBalance(account(1), big.NewInt(1)),
+		},
+		{
+			name:        "max_amount",
+			description: "a withdrawal of 2^64-1 Gwei",
+			blocks:      withWithdrawals(false, []*types.Withdrawal{withdrawal(0, 0, account(1), math.MaxUint64)}),
+			check:       blocktest.CheckBalance(account(1), gwei(math.MaxUint64)),
+		},
+		{
+			name:        "max_index",
+			description: "a withdrawal with index and validator index 2^64-1",
+			blocks:      withWithdrawals(true, []*types.Withdrawal{withdrawal(math.MaxUint64, math.MaxUint64, account(1), 1)}),
+			check: blocktest.All(
+				blocktest.CheckBalance(account(1), gwei(1)),
+				checkWithdrawals([]*types.Withdrawal{withdrawal(math.MaxUint64, math.MaxUint64, account(1), 1)}),
+			),
+		},
+		{
+			name:        "arbitrary_indices",
+			description: "withdrawal indices decreasing from one block to the next, which the execution layer does not validate",
+			blocks: withWithdrawals(true,
+				[]*types.Withdrawal{withdrawal(1000, 7, account(1), 1), withdrawal(1000, 7, account(1), 2)},
+				[]*types.Withdrawal{withdrawal(7, 1000, account(1), 4)},
+			),
+			check: blocktest.All(
+				blocktest.CheckBalance(account(1), gwei(1, 2, 4)),
+				checkWithdrawals(
+					[]*types.Withdrawal{withdrawal(1000, 7, account(1), 1), withdrawal(1000, 7, account(1), 2)},
+					[]*types.Withdrawal{withdrawal(7, 1000, account(1), 4)},
+				),
+			),
+		},
+		{
+			name:        "to_contract",
+			description: "a withdrawal to a contract storing to slot 0 when called, whose code does not run",
+			alloc:       types.GenesisAlloc{account(1): {Balance: new(big.Int), Code: storeCode}},
+			blocks:      withWithdrawals(false, one),
+			check:       blocktest.All(blocktest.CheckBalance(account(1), gwei(1)), blocktest.CheckNoStorage(account(1))),
+		},
+		{
+			name:        "to_precompile",
+			description: "a withdrawal to the ecrecover precompile",
+			blocks:      withWithdrawals(false, []*types.Withdrawal{withdrawal(0, 0, common.BytesToAddress([]byte{1}), 1)}),
+			check:       blocktest.CheckBalance(common.BytesToAddress([]byte{1}), gwei(1)),
+		},
+		{
+			name:        "funds_transaction",
+			description: "a withdrawal of 1 ether funding a transfer of its recipient in the next block",
+			blocks: func() ([]*blocktest.Block, error) {
+				tx, err := types.SignNewTx(recipientKey, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+					ChainID:   chainID,
+					GasTipCap: big.NewInt(tipCap),
+					GasFeeCap: big.NewInt(feeCap),
+					Gas:       params.TxGas,
+					To:        &sender,
+					Value:     value,
+				})
+				if err != nil {
+					return nil, err
+				}
+				enc, err := tx.MarshalBinary()
+				if err != nil {
+					return nil, err
+				}
+				return []*blocktest.Block{{Withdrawals: paid}, {Transactions: []hexutil.Bytes{enc}}}, nil
+			},
+			check: blocktest.All(
+				blocktest.CheckBalance(sender, new(big.Int).Add(big.NewInt(params.Ether), value)),
+				checkWithdrawals(paid, []*types.Withdrawal{}),
+			),
+		},
+		{
+			name:        "invalid_root",
+			description: "a withdrawals root not matching the withdrawals",
+			blocks:      invalidBlock(one, blocktest.Corruption{Field: "withdrawalsRoot"}, exceptionRoot, "withdrawals root hash mismatch"),
+			check:       blocktest.All(blocktest.CheckAbsent(account(1)), checkWithdrawals(one)),
+		},
+		{
+			name:        "invalid_root_empty_list",
+			description: "a withdrawals root not matching the empty withdrawals list",
+			blocks:      invalidBlock(nil, blocktest.Corruption{Field: "withdrawalsRoot"}, exceptionRoot, "withdrawals root hash mismatch"),
+			check:       checkWithdrawals([]*types.Withdrawal{}),
+		},
+		{
+			name:        "invalid_root_order",
+			description: "a withdrawals root committing to the withdrawals in reverse order",
+			blocks: invalidBlock(pair, blocktest.Corruption{
+				Field: "withdrawalsRoot",
+				Value: withdrawalsRoot([]*types.Withdrawal{pair[1], pair[0]}).Hex(),
+			}, exceptionRoot, "withdrawals root hash mismatch"),
+			check: blocktest.All(blocktest.CheckAbsent(account(1)), checkWithdrawals(pair)),
+		},
+		{
+			name:        "invalid_not_applied",
+			description: "withdrawals in the body and the withdrawals root, but not credited in the state root",
+			blocks:      invalidBlock(one, blocktest.Corruption{Field: "withdrawals"}, exceptionState, "invalid merkle root"),
+			check:       blocktest.All(blocktest.CheckAbsent(account(1)), checkWithdrawals(one)),
+		},
+		{
+			name:        "pre_shanghai_withdrawals",
+			description: "a block before Shanghai carrying a withdrawal and its withdrawals root",
+			preShanghai: true,
+			blocks:      invalidBlock(one, blocktest.Corruption{Field: "withdrawals"}, exceptionFormat, "invalid withdrawalsHash"),
+			check:       blocktest.All(blocktest.CheckAbsent(account(1)), checkWithdrawals(one)),
+		},
+		{
+			name:        "pre_shanghai_empty_withdrawals",
+			description: "a block before Shanghai carrying an empty withdrawals list and its root",
+			preShanghai: true,
+			blocks:      invalidBlock(nil, blocktest.Corruption{Field: "withdrawals"}, exceptionFormat, "invalid withdrawalsHash"),
+			check:       checkWithdrawals([]*types.Withdrawal{}),
+		},
+		{
+			name:        "pre_shanghai_withdrawals_root",
+			description: "a block before Shanghai with the empty withdrawals root in its header but no withdrawals list",
+			preShanghai: true,
+			blocks: invalidBlock(nil, blocktest.Corruption{
+				Field: "withdrawalsRoot",
+				Value: types.EmptyWithdrawalsHash.Hex(),
+			}, exceptionFormat, "invalid withdrawalsHash"),
+			check: checkWithdrawals(nil),
+		},
+	}
+}
@@ -0,0 +1,134 @@
+// withdrawal-vectors generates EIP-4895 withdrawal vectors in the format of
+// the blockchain_tests fixtures of the execution spec tests, using go-ethereum
+// to build and import the chains.
+//
+// Usage:
+//
+//	go run ./cmd/withdrawal-vectors [--forks Paris,Shanghai,Cancun,Prague] [--output withdrawal_vectors.json]
+//
+// The valid vectors cover single and many withdrawals per block, withdrawals
+// to the same address, to contracts, whose code does not run, and to
+// precompiles, zero amounts, which leave a new recipient nonexistent, the
+// largest amount and index, indices not following the earlier blocks, which
+// only the consensus layer checks, and withdrawals funding a later
+// transaction. The invalid vectors cover a withdrawals root not matching the
+// withdrawals, withdrawals left out of the state root and, on the forks
+// before Shanghai, blocks carrying withdrawals or a withdrawals root.
+//
+// The expected balances and the withdrawals of every block are declared with
+// each vector, the withdrawals root of every valid block is computed from
+// them, and all of it is checked before anything is written. The chains are
+// imported into go-ethereum, which must reject the invalid blocks for the
+// intended reason.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/blocktest"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// The parameters of the genesis and the transactions.
+const (
+	gasLimit = 30000000
+	baseFee  = 7
+	feeCap   = 10
+	tipCap   = 1
+)
+
+var (
+	chainID = big.NewInt(1)
+
+	senderKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	sender       = crypto.PubkeyToAddress(senderKey.PublicKey)
+)
+
+func main() {
+	var (
+		forkList = flag.String("forks", "Paris,Shanghai,Cancun,Prague", "comma separated forks the vectors are built for")
+		output   = flag.String("output", "withdrawal_vectors.json", "file the fixtures are written to")
+	)
+	flag.Parse()
+	london, _ := forks.Index("London")
+	shanghai, _ := forks.Index("Shanghai")
+	forkNames := strings.Split(*forkList, ",")
+	for _, fork := range forkNames {
+		if _, ok := tests.Forks[fork]; !ok {
+			fatalf("unknown fork %q", fork)
+		}
+		if index, err := forks.Index(fork); err != nil || index < london {
+			fatalf("fork %s predates EIP-1559", fork)
+		}
+	}
+	fixtures := make(map[string]*blocktest.Fixture)
+	for _, c := range cases() {
+		for _, fork := range forkNames {
+			if index, _ := forks.Index(fork); (index < shanghai) != c.preShanghai {
+				continue
+			}
+			f, err := fill(&c, fork)
+			if err != nil {
+				fatalf("case %s: %s: %v", c.name, fork, err)
+			}
+			fixtures["withdrawal_vectors/"+c.name+"/"+fork] = f
+		}
+	}
+	if len(fixtures) == 0 {
+		fatalf("no vectors for the forks %s", *forkList)
+	}
+	data, err := json.MarshalIndent(fixtures, "", "    ")
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0644); err != nil {
+		fatalf("%v", err)
+	}
+	fmt.Printf("Wrote %d fixtures to %s\n", len(fixtures), *output)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// fill builds the fixture of a test case on a fork and verifies it.
+func fill(c *testCase, fork string) (*blocktest.Fixture, error) {
+	config := tests.Forks[fork]
+	genesis := &core.Genesis{
+		Config:     config,
+		GasLimit:   gasLimit,
+		BaseFee:    big.NewInt(baseFee),
+		Difficulty: new(big.Int),
+		Alloc:      gen.SystemContractAlloc(config),
+	}
+	genesis.Alloc[sender] = types.Account{Balance: big.NewInt(params.Ether)}
+	for addr, account := range c.alloc {
+		genesis.Alloc[addr] = account
+	}
+	blocks, err := c.blocks()
+	if err != nil {
+		return nil, err
+	}
+	f, err := blocktest.Build(genesis, fork, blocks)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.check(f); err != nil {
+		return nil, err
+	}
+	f.Info = statetest.Info("withdrawal-vectors", "handcrafted", c.description)
+	return f, nil
+}
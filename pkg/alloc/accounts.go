@@ -0,0 +1,129 @@
+package alloc
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DerivationPath is the BIP-44 path of the Ethereum accounts derived from a
+// seed, the index of the account is appended as the last, non-hardened
+// component.
+const DerivationPath = "m/44'/60'/0'/0"
+
+// hardened is the offset of the hardened BIP-32 child indexes.
+const hardened = 1 << 31
+
+// DerivedAccount is an account derived from a seed, together with its key.
+type DerivedAccount struct {
+	Path       string         `json:"path"`
+	Address    common.Address `json:"address"`
+	PrivateKey hexutil.Bytes  `json:"privateKey"`
+}
+
+// MnemonicSeed returns the BIP-39 seed of a mnemonic sentence and passphrase.
+// The words are not checked against the BIP-39 word list, any sentence is
+// accepted, but only lists of English words yield the seed other wallets
+// derive from the same sentence.
+func MnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty mnemonic")
+	}
+	return pbkdf2.Key(sha512.New, strings.Join(words, " "), []byte("mnemonic"+passphrase), 2048, 64)
+}
+
+// DeriveAccounts derives n accounts from a BIP-32 seed along the BIP-44
+// DerivationPath, the same accounts wallets and development nodes derive from
+// the seed of a mnemonic.
+func DeriveAccounts(seed []byte, n int) ([]DerivedAccount, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("seed must be between 16 and 64 bytes, have %d", len(seed))
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key, chain := new(big.Int).SetBytes(sum[:32]), sum[32:]
+	if key.Sign() == 0 || key.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, fmt.Errorf("seed yields an invalid master key")
+	}
+	var err error
+	for _, index := range []uint32{44 + hardened, 60 + hardened, hardened, 0} {
+		if key, chain, err = deriveChild(key, chain, index); err != nil {
+			return nil, err
+		}
+	}
+	accounts := make([]DerivedAccount, n)
+	for i := range accounts {
+		child, _, err := deriveChild(key, chain, uint32(i))
+		if err != nil {
+			return nil, fmt.Errorf("account %d: %v", i, err)
+		}
+		priv, err := crypto.ToECDSA(keyBytes(child))
+		if err != nil {
+			return nil, fmt.Errorf("account %d: %v", i, err)
+		}
+		accounts[i] = DerivedAccount{
+			Path:       fmt.Sprintf("%s/%d", DerivationPath, i),
+			Address:    crypto.PubkeyToAddress(priv.PublicKey),
+			PrivateKey: crypto.FromECDSA(priv),
+		}
+	}
+	return accounts, nil
+}
+
+// deriveChild derives the BIP-32 child private key and chain code of the given
+// index from a parent private key.
+func deriveChild(key *big.Int, chain []byte, index uint32) (*big.Int, []byte, error) {
+	var data []byte
+	if index >= hardened {
+		data = append([]byte{0}, keyBytes(key)...)
+	} else {
+		priv, err := crypto.ToECDSA(keyBytes(key))
+		if err != nil {
+			return nil, nil, err
+		}
+		data = crypto.CompressPubkey(&priv.PublicKey)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, chain)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := crypto.S256().Params().N
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(n) >= 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+	child := tweak.Add(tweak, key)
+	child.Mod(child, n)
+	if child.Sign() == 0 {
+		return nil, nil, fmt.Errorf("invalid child key at index %d", index)
+	}
+	return child, sum[32:], nil
+}
+
+// keyBytes returns the 32 byte big endian encoding of a private key.
+func keyBytes(key *big.Int) []byte {
+	return key.FillBytes(make([]byte, 32))
+}
+
+// Fund returns an allocation crediting the given balance to every account.
+func Fund(accounts []DerivedAccount, balance *big.Int) types.GenesisAlloc {
+	alloc := make(types.GenesisAlloc, len(accounts))
+	for _, account := range accounts {
+		alloc[account.Address] = types.Account{Balance: new(big.Int).Set(balance)}
+	}
+	return alloc
+}
@@ -0,0 +1,115 @@
+package alloc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AddressWarning reports a suspicious address of an allocation input: one
+// that is well formed but fails its EIP-55 checksum, or that is given more
+// than once with different spellings.
+type AddressWarning struct {
+	Address common.Address
+	Keys    []string // spellings of the address in the input
+	Message string
+}
+
+func (w AddressWarning) String() string {
+	return w.Message
+}
+
+// ParseAddress parses an address of exactly 20 hex encoded bytes, with or
+// without 0x prefix. Unlike common.HexToAddress, shorter, longer and non-hex
+// input is rejected rather than truncated or padded.
+func ParseAddress(s string) (common.Address, error) {
+	hex := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(hex) != 2*common.AddressLength || !common.IsHexAddress(hex) {
+		return common.Address{}, fmt.Errorf("malformed address %q", s)
+	}
+	return common.HexToAddress(hex), nil
+}
+
+// ValidChecksum reports whether a well formed address passes its EIP-55
+// checksum. Addresses in a single case carry no checksum and always pass.
+func ValidChecksum(s string) bool {
+	hex := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if hex == strings.ToLower(hex) || hex == strings.ToUpper(hex) {
+		return true
+	}
+	return common.HexToAddress(hex).Hex()[2:] == hex
+}
+
+// CheckKeys validates the address keys of an allocation in input order. A
+// malformed key is an error. Keys failing their checksum and addresses given
+// by several keys, which decode into the last of them, are returned as
+// warnings in input order.
+func CheckKeys(keys []string) ([]AddressWarning, error) {
+	var (
+		warnings []AddressWarning
+		seen     = make(map[common.Address][]string)
+		order    []common.Address
+	)
+	for _, key := range keys {
+		addr, err := ParseAddress(key)
+		if err != nil {
+			return nil, err
+		}
+		if !ValidChecksum(key) {
+			warnings = append(warnings, AddressWarning{
+				Address: addr,
+				Keys:    []string{key},
+				Message: fmt.Sprintf("address %q fails the EIP-55 checksum, expected %s", key, addr.Hex()),
+			})
+		}
+		if _, ok := seen[addr]; !ok {
+			order = append(order, addr)
+		}
+		seen[addr] = append(seen[addr], key)
+	}
+	for _, addr := range order {
+		if spellings := seen[addr]; len(spellings) > 1 {
+			warnings = append(warnings, AddressWarning{
+				Address: addr,
+				Keys:    spellings,
+				Message: fmt.Sprintf("address %s is given %d times as %s, the last account is used", addr.Hex(), len(spellings), quoteAll(spellings)),
+			})
+		}
+	}
+	return warnings, nil
+}
+
+// JSONKeys returns the keys of a JSON object in input order, including
+// repeated ones, which decoding into a map silently drops.
+func JSONKeys(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, fmt.Errorf("allocation is not a JSON object")
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, tok.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+func quoteAll(s []string) string {
+	quoted := make([]string, len(s))
+	for i, v := range s {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
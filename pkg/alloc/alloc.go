@@ -0,0 +1,240 @@
+// Package alloc loads, merges and commits genesis allocations. Allocations are
+// read from geth JSON or CSV files and merged with the origin of every account
+// tracked, and their state root is computed incrementally with stack tries
+// instead of a state database. Funded test accounts are derived from a seed
+// with BIP-32 along the BIP-44 Ethereum path, and synthetic allocations of a
+// given shape from a pseudorandom seed, in memory or, when too large for it,
+// in an on-disk Store. The balances of an ERC-20 token holder snapshot are
+// laid out into the storage of the token contract.
+package alloc
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/compress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Load reads an allocation file, selecting the decoder based on the file
+// extension. Files ending in .csv are parsed as CSV, everything else as JSON,
+// either of them optionally gzip or zstd compressed. Malformed addresses are
+// rejected, suspicious ones returned as warnings.
+func Load(path string) (types.GenesisAlloc, []AddressWarning, error) {
+	f, err := compress.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	var (
+		alloc    types.GenesisAlloc
+		warnings []AddressWarning
+	)
+	if strings.EqualFold(filepath.Ext(compress.TrimExt(path)), ".csv") {
+		alloc, warnings, err = DecodeCSV(f)
+	} else {
+		alloc, warnings, err = DecodeJSON(f)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return alloc, warnings, nil
+}
+
+// DecodeJSON parses a JSON allocation. Both a bare alloc object and a full
+// genesis file (whose "alloc" field is used) are accepted. The address keys
+// are checked as by CheckKeys before decoding.
+func DecodeJSON(r io.Reader) (types.GenesisAlloc, []AddressWarning, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	var wrapper struct {
+		Alloc json.RawMessage `json:"alloc"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err == nil && len(wrapper.Alloc) > 0 && string(wrapper.Alloc) != "null" {
+		data = wrapper.Alloc
+	}
+	keys, err := JSONKeys(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings, err := CheckKeys(keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	var alloc types.GenesisAlloc
+	if err := json.Unmarshal(data, &alloc); err != nil {
+		return nil, nil, err
+	}
+	return alloc, warnings, nil
+}
+
+// DecodeCSV parses a CSV allocation. The first row is a header naming the
+// columns; "address" is mandatory, "balance", "nonce", "code" and "storage"
+// are optional. Storage is a list of slot=value pairs separated by ';'.
+// Malformed and repeated addresses are rejected, addresses failing their
+// EIP-55 checksum returned as warnings.
+func DecodeCSV(r io.Reader) (types.GenesisAlloc, []AddressWarning, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("missing header: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "address", "balance", "nonce", "code", "storage":
+		default:
+			return nil, nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["address"]; !ok {
+		return nil, nil, fmt.Errorf("missing address column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	var (
+		alloc    = make(types.GenesisAlloc)
+		warnings []AddressWarning
+	)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		addr := field(record, "address")
+		address, err := ParseAddress(addr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if !ValidChecksum(addr) {
+			warnings = append(warnings, AddressWarning{
+				Address: address,
+				Keys:    []string{addr},
+				Message: fmt.Sprintf("line %d: address %q fails the EIP-55 checksum, expected %s", line, addr, address.Hex()),
+			})
+		}
+		if _, ok := alloc[address]; ok {
+			return nil, nil, fmt.Errorf("line %d: duplicate address %s", line, address.Hex())
+		}
+		account, err := parseCSVAccount(field(record, "balance"), field(record, "nonce"), field(record, "code"), field(record, "storage"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		alloc[address] = account
+	}
+	return alloc, warnings, nil
+}
+
+// parseCSVAccount assembles an account from the textual CSV columns.
+func parseCSVAccount(balance, nonce, code, storage string) (types.Account, error) {
+	slots := make(map[string]string)
+	for _, entry := range strings.Split(storage, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		slot, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return types.Account{}, fmt.Errorf("invalid storage entry %q, want slot=value", entry)
+		}
+		slots[slot] = value
+	}
+	return ParseAccount(balance, nonce, code, slots)
+}
+
+// ParseAccount assembles an account from its textual fields. Numbers may be
+// given in decimal or hex, empty fields are left at their zero value.
+func ParseAccount(balance, nonce, code string, storage map[string]string) (types.Account, error) {
+	account := types.Account{Balance: new(big.Int)}
+	if balance != "" {
+		b, ok := math.ParseBig256(balance)
+		if !ok {
+			return account, fmt.Errorf("invalid balance %q", balance)
+		}
+		account.Balance = b
+	}
+	if nonce != "" {
+		n, ok := math.ParseUint64(nonce)
+		if !ok {
+			return account, fmt.Errorf("invalid nonce %q", nonce)
+		}
+		account.Nonce = n
+	}
+	if code != "" {
+		c, err := hexutil.Decode(code)
+		if err != nil {
+			return account, fmt.Errorf("invalid code: %v", err)
+		}
+		account.Code = c
+	}
+	if len(storage) > 0 {
+		account.Storage = make(map[common.Hash]common.Hash, len(storage))
+		for slot, value := range storage {
+			key, err := ParseHash(slot)
+			if err != nil {
+				return account, fmt.Errorf("invalid storage slot %q: %v", slot, err)
+			}
+			val, err := ParseHash(value)
+			if err != nil {
+				return account, fmt.Errorf("invalid storage value %q: %v", value, err)
+			}
+			account.Storage[key] = val
+		}
+	}
+	return account, nil
+}
+
+// ParseHash parses a hex or decimal 256 bit number into a hash.
+func ParseHash(s string) (common.Hash, error) {
+	n, ok := math.ParseBig256(strings.TrimSpace(s))
+	if !ok {
+		return common.Hash{}, fmt.Errorf("not a 256 bit number")
+	}
+	return common.BigToHash(n), nil
+}
+
+// Balance returns the balance of an account, treating nil as zero.
+func Balance(account types.Account) *big.Int {
+	if account.Balance == nil {
+		return new(big.Int)
+	}
+	return account.Balance
+}
+
+// SortedAddresses returns the addresses of an allocation in ascending order.
+func SortedAddresses(alloc types.GenesisAlloc) []common.Address {
+	addrs := make([]common.Address, 0, len(alloc))
+	for addr := range alloc {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
@@ -0,0 +1,93 @@
+package alloc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Override records an account that was already present in the allocation and
+// got replaced by an account of another source.
+type Override struct {
+	Address  common.Address
+	Previous string // origin of the replaced account
+	Source   string // origin of the account that replaced it
+}
+
+// Merge copies every account of src into dst, replacing existing ones. The
+// origins map tracks where each account of dst came from and is updated in
+// place; the replaced accounts are returned in address order.
+func Merge(dst, src types.GenesisAlloc, source string, origins map[common.Address]string) []Override {
+	var overrides []Override
+	for _, addr := range SortedAddresses(src) {
+		if _, ok := dst[addr]; ok {
+			previous, ok := origins[addr]
+			if !ok {
+				previous = "genesis"
+			}
+			overrides = append(overrides, Override{Address: addr, Previous: previous, Source: source})
+		}
+		dst[addr] = src[addr]
+		origins[addr] = source
+	}
+	return overrides
+}
+
+// Overlap is an address defined by more than one allocation file.
+type Overlap struct {
+	Address  common.Address `json:"address"`
+	Sources  []string       `json:"sources"`  // files defining the address, in merge order
+	Winner   string         `json:"winner"`   // file whose account ended up in the allocation
+	Conflict bool           `json:"conflict"` // whether the accounts differ
+}
+
+// Provenance records for every address of a merged allocation the file its
+// account was taken from, along with the overlaps between the files.
+type Provenance struct {
+	Sources  map[common.Address]string `json:"sources"`
+	Overlaps []Overlap                 `json:"overlaps"`
+}
+
+// MergeAll merges the allocations in order, the account of the last file
+// defining an address wins. Overlaps are reported in address order, they are
+// conflicts if the accounts are not identical.
+func MergeAll(sources []string, allocs []types.GenesisAlloc) (types.GenesisAlloc, *Provenance, error) {
+	var (
+		merged  = make(types.GenesisAlloc)
+		origins = make(map[common.Address]string)
+		defined = make(map[common.Address][]string)
+		encoded = make(map[common.Address][]byte) // encoding of the first account
+		differ  = make(map[common.Address]bool)
+	)
+	for i, alloc := range allocs {
+		for _, addr := range SortedAddresses(alloc) {
+			enc, err := json.Marshal(alloc[addr])
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %s: %v", sources[i], addr.Hex(), err)
+			}
+			if first, ok := encoded[addr]; !ok {
+				encoded[addr] = enc
+			} else if !bytes.Equal(first, enc) {
+				differ[addr] = true
+			}
+			defined[addr] = append(defined[addr], sources[i])
+		}
+		Merge(merged, alloc, sources[i], origins)
+	}
+	prov := &Provenance{Sources: origins, Overlaps: []Overlap{}}
+	for _, addr := range SortedAddresses(merged) {
+		if len(defined[addr]) < 2 {
+			continue
+		}
+		prov.Overlaps = append(prov.Overlaps, Overlap{
+			Address:  addr,
+			Sources:  defined[addr],
+			Winner:   origins[addr],
+			Conflict: differ[addr],
+		})
+	}
+	return merged, prov, nil
+}
@@ -0,0 +1,300 @@
+package alloc
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Overlay is a layer of a composed state: patches of accounts applied on top
+// of a base allocation, such as that of a devnet, to set up a scenario.
+// Overlay files are YAML (and therefore also JSON) documents of the form
+//
+//	name: scenario
+//	accounts:
+//	  "0x...":
+//	    balance: "1000000000000000000"
+//	    nonce: "1"
+//	    code: "0x..."
+//	    clearStorage: true
+//	    storage:
+//	      "0x00": "0x01"
+//	      "0x01": "0x00"
+//	  "0x...":
+//	    delete: true
+//
+// The fields given replace those of the account, which is created if it does
+// not exist yet. The slots given are set, those set to zero removed, the
+// others kept unless clearStorage drops the storage first. A deleted account
+// takes no other fields. The name defaults to the file name.
+type Overlay struct {
+	Name    string
+	Patches []Patch // in address order
+}
+
+// Patch is the change of an account by an overlay. Nil fields are left alone.
+type Patch struct {
+	Address      common.Address
+	Delete       bool
+	Balance      *big.Int
+	Nonce        *uint64
+	Code         *hexutil.Bytes
+	ClearStorage bool
+	Storage      map[common.Hash]common.Hash
+}
+
+// overlayFile is the encoding of an overlay file.
+type overlayFile struct {
+	Name     string                         `yaml:"name"`
+	Accounts map[string]*overlayFileAccount `yaml:"accounts"`
+}
+
+type overlayFileAccount struct {
+	Delete       bool              `yaml:"delete"`
+	Balance      *string           `yaml:"balance"`
+	Nonce        *string           `yaml:"nonce"`
+	Code         *string           `yaml:"code"`
+	ClearStorage bool              `yaml:"clearStorage"`
+	Storage      map[string]string `yaml:"storage"`
+}
+
+// LoadOverlay reads an overlay file.
+func LoadOverlay(path string) (*Overlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	o, err := ParseOverlay(data, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return o, nil
+}
+
+// ParseOverlay decodes an overlay file, named name unless it names itself.
+func ParseOverlay(data []byte, name string) (*Overlay, error) {
+	var file overlayFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	o := &Overlay{Name: file.Name}
+	if o.Name == "" {
+		o.Name = name
+	}
+	for key, account := range file.Accounts {
+		if !common.IsHexAddress(key) {
+			return nil, fmt.Errorf("invalid address %q", key)
+		}
+		if account == nil {
+			return nil, fmt.Errorf("%s: empty patch", key)
+		}
+		p, err := account.patch(common.HexToAddress(key))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		o.Patches = append(o.Patches, p)
+	}
+	sort.Slice(o.Patches, func(i, j int) bool {
+		return o.Patches[i].Address.Cmp(o.Patches[j].Address) < 0
+	})
+	for i := 1; i < len(o.Patches); i++ {
+		if o.Patches[i].Address == o.Patches[i-1].Address {
+			return nil, fmt.Errorf("address %s patched twice", o.Patches[i].Address.Hex())
+		}
+	}
+	return o, nil
+}
+
+func (a *overlayFileAccount) patch(addr common.Address) (Patch, error) {
+	p := Patch{Address: addr, Delete: a.Delete, ClearStorage: a.ClearStorage}
+	if a.Delete {
+		if a.Balance != nil || a.Nonce != nil || a.Code != nil || a.ClearStorage || len(a.Storage) > 0 {
+			return p, errors.New("a deleted account takes no other fields")
+		}
+		return p, nil
+	}
+	if a.Balance != nil {
+		b, ok := math.ParseBig256(*a.Balance)
+		if !ok {
+			return p, fmt.Errorf("invalid balance %q", *a.Balance)
+		}
+		p.Balance = b
+	}
+	if a.Nonce != nil {
+		n, ok := math.ParseUint64(*a.Nonce)
+		if !ok {
+			return p, fmt.Errorf("invalid nonce %q", *a.Nonce)
+		}
+		p.Nonce = &n
+	}
+	if a.Code != nil {
+		code, err := hexutil.Decode(*a.Code)
+		if err != nil {
+			return p, fmt.Errorf("invalid code: %v", err)
+		}
+		p.Code = (*hexutil.Bytes)(&code)
+	}
+	if len(a.Storage) > 0 {
+		p.Storage = make(map[common.Hash]common.Hash, len(a.Storage))
+		for slot, value := range a.Storage {
+			key, err := ParseHash(slot)
+			if err != nil {
+				return p, fmt.Errorf("invalid storage slot %q: %v", slot, err)
+			}
+			val, err := ParseHash(value)
+			if err != nil {
+				return p, fmt.Errorf("invalid storage value %q: %v", value, err)
+			}
+			if _, ok := p.Storage[key]; ok {
+				return p, fmt.Errorf("storage slot %s given twice", key.Hex())
+			}
+			p.Storage[key] = val
+		}
+	}
+	return p, nil
+}
+
+// Composition is an allocation composed of a base and overlays, applied in
+// order, which records the layer every field of the accounts comes from.
+type Composition struct {
+	Alloc types.GenesisAlloc
+
+	prov *CompositionProvenance
+}
+
+// CompositionProvenance is the provenance report of a composition: the layers
+// in order with what each of them changed, and for every account the layer
+// each of its fields was last set by.
+type CompositionProvenance struct {
+	Layers   []LayerSummary                        `json:"layers"`
+	Accounts map[common.Address]*AccountProvenance `json:"accounts"`
+	Deleted  map[common.Address]string             `json:"deleted"` // deleted accounts, by the layer deleting them
+}
+
+// LayerSummary counts the accounts a layer of a composition created, patched
+// and deleted. The base creates all of its accounts.
+type LayerSummary struct {
+	Name    string `json:"name"`
+	Created int    `json:"created"`
+	Patched int    `json:"patched"`
+	Deleted int    `json:"deleted"`
+}
+
+// AccountProvenance names the layers which last set the fields of an
+// account. Storage names the layer of every slot; ClearedStorage the layer
+// which last dropped the storage, if any.
+type AccountProvenance struct {
+	Created        string                 `json:"created"`
+	Balance        string                 `json:"balance"`
+	Nonce          string                 `json:"nonce"`
+	Code           string                 `json:"code"`
+	ClearedStorage string                 `json:"clearedStorage,omitempty"`
+	Storage        map[common.Hash]string `json:"storage,omitempty"`
+}
+
+// NewComposition starts a composition from the base allocation, which is
+// modified in place by the overlays. The accounts of the base are attributed
+// to their origins, as tracked by Merge, or to the source.
+func NewComposition(base types.GenesisAlloc, source string, origins map[common.Address]string) *Composition {
+	c := &Composition{
+		Alloc: base,
+		prov: &CompositionProvenance{
+			Layers:   []LayerSummary{{Name: source, Created: len(base)}},
+			Accounts: make(map[common.Address]*AccountProvenance, len(base)),
+			Deleted:  make(map[common.Address]string),
+		},
+	}
+	for addr, account := range base {
+		origin, ok := origins[addr]
+		if !ok {
+			origin = source
+		}
+		p := &AccountProvenance{Created: origin, Balance: origin, Nonce: origin, Code: origin}
+		if len(account.Storage) > 0 {
+			p.Storage = make(map[common.Hash]string, len(account.Storage))
+			for slot := range account.Storage {
+				p.Storage[slot] = origin
+			}
+		}
+		c.prov.Accounts[addr] = p
+	}
+	return c
+}
+
+// Apply applies an overlay on top of the layers so far.
+func (c *Composition) Apply(o *Overlay) {
+	summary := LayerSummary{Name: o.Name}
+	for _, patch := range o.Patches {
+		addr := patch.Address
+		account, exists := c.Alloc[addr]
+		if patch.Delete {
+			if exists {
+				delete(c.Alloc, addr)
+				delete(c.prov.Accounts, addr)
+				c.prov.Deleted[addr] = o.Name
+				summary.Deleted++
+			}
+			continue
+		}
+		p := c.prov.Accounts[addr]
+		if exists {
+			summary.Patched++
+		} else {
+			account = types.Account{Balance: new(big.Int)}
+			p = &AccountProvenance{Created: o.Name, Balance: o.Name, Nonce: o.Name, Code: o.Name}
+			c.prov.Accounts[addr] = p
+			delete(c.prov.Deleted, addr)
+			summary.Created++
+		}
+		if patch.Balance != nil {
+			account.Balance, p.Balance = new(big.Int).Set(patch.Balance), o.Name
+		}
+		if patch.Nonce != nil {
+			account.Nonce, p.Nonce = *patch.Nonce, o.Name
+		}
+		if patch.Code != nil {
+			account.Code, p.Code = common.CopyBytes(*patch.Code), o.Name
+		}
+		if patch.ClearStorage {
+			account.Storage, p.Storage, p.ClearedStorage = nil, nil, o.Name
+		}
+		if len(patch.Storage) > 0 {
+			storage := make(map[common.Hash]common.Hash, len(account.Storage)+len(patch.Storage))
+			for slot, value := range account.Storage {
+				storage[slot] = value
+			}
+			if p.Storage == nil {
+				p.Storage = make(map[common.Hash]string, len(patch.Storage))
+			}
+			for slot, value := range patch.Storage {
+				if value == (common.Hash{}) {
+					delete(storage, slot)
+					delete(p.Storage, slot)
+				} else {
+					storage[slot], p.Storage[slot] = value, o.Name
+				}
+			}
+			account.Storage = storage
+		}
+		if len(account.Storage) == 0 {
+			account.Storage, p.Storage = nil, nil
+		}
+		c.Alloc[addr] = account
+	}
+	c.prov.Layers = append(c.prov.Layers, summary)
+}
+
+// Provenance returns the provenance report of the composition.
+func (c *Composition) Provenance() *CompositionProvenance {
+	return c.prov
+}
@@ -0,0 +1,282 @@
+package alloc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+)
+
+// hashedAccount is an account of the allocation with the hash of its address,
+// its path in the account trie.
+type hashedAccount struct {
+	hash common.Hash
+	addr common.Address
+}
+
+// Root computes the state root of the allocation incrementally, inserting the
+// accounts into stack tries in the order of their hashed addresses. Unlike a
+// state database, only the paths of the tries being built are kept in memory.
+// With more than one job the storage tries are built concurrently and the
+// account trie is split into the 16 subtries below its root branch, which are
+// built concurrently as well.
+func Root(alloc types.GenesisAlloc, jobs int) (common.Hash, error) {
+	accounts := make([]hashedAccount, 0, len(alloc))
+	for addr := range alloc {
+		accounts = append(accounts, hashedAccount{crypto.Keccak256Hash(addr[:]), addr})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i].hash[:], accounts[j].hash[:]) < 0
+	})
+	// Split the accounts by the first nibble of their path. The root of the
+	// account trie is a branch only if there are two accounts which differ in
+	// it, otherwise the trie is built as a whole.
+	var shards [16][]hashedAccount
+	for start := 0; start < len(accounts); {
+		nibble := accounts[start].hash[0] >> 4
+		end := start
+		for end < len(accounts) && accounts[end].hash[0]>>4 == nibble {
+			end++
+		}
+		shards[nibble], start = accounts[start:end], end
+	}
+	if jobs <= 1 || len(accounts) == 0 || len(shards[accounts[0].hash[0]>>4]) == len(accounts) {
+		return accountTrie(alloc, accounts, nil, nil)
+	}
+	roots, err := storageRoots(alloc, accounts, jobs)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var (
+		children [17]rlp.RawValue
+		errs     [16]error
+		tasks    = make(chan int, 16)
+		wg       sync.WaitGroup
+	)
+	for nibble := range shards {
+		tasks <- nibble
+	}
+	close(tasks)
+	for i := 0; i < min(jobs, 16); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for nibble := range tasks {
+				children[nibble], errs[nibble] = shardChild(alloc, shards[nibble], roots)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs[:]...); err != nil {
+		return common.Hash{}, err
+	}
+	children[16] = rlp.EmptyString
+	branch, err := rlp.EncodeToBytes(children[:])
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(branch), nil
+}
+
+// storageRoots computes the storage roots of the accounts with storage using
+// the given number of workers.
+func storageRoots(alloc types.GenesisAlloc, accounts []hashedAccount, jobs int) (map[common.Address]common.Hash, error) {
+	var (
+		roots = make(map[common.Address]common.Hash)
+		tasks = make(chan common.Address)
+		lock  sync.Mutex
+		errs  []error
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for addr := range tasks {
+				root, err := StorageRoot(alloc[addr].Storage)
+				lock.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("account %s: %v", addr.Hex(), err))
+				}
+				roots[addr] = root
+				lock.Unlock()
+			}
+		}()
+	}
+	for _, a := range accounts {
+		if len(alloc[a.addr].Storage) > 0 {
+			tasks <- a.addr
+		}
+	}
+	close(tasks)
+	wg.Wait()
+	return roots, errors.Join(errs...)
+}
+
+// accountTrie inserts the accounts into a stack trie, calling the callback
+// with its nodes, and returns its root. The storage roots are looked up in
+// roots, or computed if roots is nil.
+func accountTrie(alloc types.GenesisAlloc, accounts []hashedAccount, roots map[common.Address]common.Hash, onTrieNode trie.OnTrieNode) (common.Hash, error) {
+	tr := trie.NewStackTrie(onTrieNode)
+	for _, a := range accounts {
+		account := alloc[a.addr]
+		root := types.EmptyRootHash
+		if roots != nil {
+			if r, ok := roots[a.addr]; ok {
+				root = r
+			}
+		} else {
+			var err error
+			if root, err = StorageRoot(account.Storage); err != nil {
+				return common.Hash{}, fmt.Errorf("account %s: %v", a.addr.Hex(), err)
+			}
+		}
+		value, err := encodeAccount(account, root)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("account %s: %v", a.addr.Hex(), err)
+		}
+		if err := tr.Update(a.hash[:], value); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	return tr.Hash(), nil
+}
+
+// encodeAccount returns the value of an account with the storage root in the
+// account trie.
+func encodeAccount(account types.Account, root common.Hash) ([]byte, error) {
+	balance, overflow := uint256.FromBig(Balance(account))
+	if overflow {
+		return nil, errors.New("balance overflows 256 bits")
+	}
+	state := types.StateAccount{
+		Nonce:    account.Nonce,
+		Balance:  balance,
+		Root:     root,
+		CodeHash: types.EmptyCodeHash.Bytes(),
+	}
+	if len(account.Code) > 0 {
+		state.CodeHash = crypto.Keccak256(account.Code)
+	}
+	return rlp.EncodeToBytes(&state)
+}
+
+// shardChild builds the subtrie of the accounts sharing the first nibble of
+// their path and returns its reference in the root branch of the account
+// trie. The stack trie of the shard has a root covering the shared nibble, an
+// extension or a leaf, which is shortened by it to become the child.
+func shardChild(alloc types.GenesisAlloc, accounts []hashedAccount, roots map[common.Address]common.Hash) (rlp.RawValue, error) {
+	if len(accounts) == 0 {
+		return rlp.EmptyString, nil
+	}
+	var root []byte
+	_, err := accountTrie(alloc, accounts, roots, func(path []byte, hash common.Hash, blob []byte) {
+		if len(path) == 0 {
+			root = common.CopyBytes(blob)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shardReference(root)
+}
+
+// shardReference returns the reference in the root branch of the account
+// trie of the root node of a shard.
+func shardReference(root []byte) (rlp.RawValue, error) {
+	var node []rlp.RawValue
+	if err := rlp.DecodeBytes(root, &node); err != nil {
+		return nil, err
+	}
+	if len(node) != 2 {
+		return nil, errors.New("account shard root is not a short node")
+	}
+	var key []byte
+	if err := rlp.DecodeBytes(node[0], &key); err != nil {
+		return nil, err
+	}
+	nibbles, leaf := compactToNibbles(key)
+	if !leaf && len(nibbles) == 1 {
+		return node[1], nil // the extension only covers the shared nibble
+	}
+	short, err := rlp.EncodeToBytes([]interface{}{nibblesToCompact(nibbles[1:], leaf), node[1]})
+	if err != nil {
+		return nil, err
+	}
+	if len(short) < 32 {
+		return short, nil
+	}
+	return rlp.EncodeToBytes(crypto.Keccak256(short))
+}
+
+// compactToNibbles decodes the hex prefix encoding of a short node key.
+func compactToNibbles(compact []byte) ([]byte, bool) {
+	if len(compact) == 0 {
+		return nil, false
+	}
+	var (
+		flag    = compact[0] >> 4
+		nibbles []byte
+	)
+	if flag&1 == 1 {
+		nibbles = append(nibbles, compact[0]&0x0f)
+	}
+	for _, b := range compact[1:] {
+		nibbles = append(nibbles, b>>4, b&0x0f)
+	}
+	return nibbles, flag&2 == 2
+}
+
+// nibblesToCompact encodes a short node key with the hex prefix encoding.
+func nibblesToCompact(nibbles []byte, leaf bool) []byte {
+	var flag byte
+	if leaf {
+		flag = 2
+	}
+	compact := []byte{flag << 4}
+	if len(nibbles)%2 == 1 {
+		compact[0] |= 0x10 | nibbles[0]
+		nibbles = nibbles[1:]
+	}
+	for i := 0; i < len(nibbles); i += 2 {
+		compact = append(compact, nibbles[i]<<4|nibbles[i+1])
+	}
+	return compact
+}
+
+// StorageRoot computes the root of a storage trie, leaving out zero slots.
+func StorageRoot(storage map[common.Hash]common.Hash) (common.Hash, error) {
+	type slot struct {
+		hash  common.Hash
+		value []byte
+	}
+	slots := make([]slot, 0, len(storage))
+	for key, value := range storage {
+		if value == (common.Hash{}) {
+			continue
+		}
+		enc, err := rlp.EncodeToBytes(common.TrimLeftZeroes(value[:]))
+		if err != nil {
+			return common.Hash{}, err
+		}
+		slots = append(slots, slot{crypto.Keccak256Hash(key[:]), enc})
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return bytes.Compare(slots[i].hash[:], slots[j].hash[:]) < 0
+	})
+	tr := trie.NewStackTrie(nil)
+	for _, s := range slots {
+		if err := tr.Update(s.hash[:], s.value); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	return tr.Hash(), nil
+}
@@ -0,0 +1,302 @@
+package alloc
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// The key layout of a store. Accounts are kept by address, the order of the
+// genesis encoding, and indexed by the hash of their address, the order of
+// the account trie. Slots are kept by the hash of their key below their
+// account, the order of the storage trie, with the key and the value.
+var (
+	storeAccountPrefix = []byte("a") // + address -> RLP of storedAccount
+	storeHashPrefix    = []byte("h") // + keccak256(address) -> address
+	storeSlotPrefix    = []byte("s") // + address + keccak256(slot) -> slot + value
+)
+
+// Store is an allocation held in a pebble database on disk instead of in a
+// GenesisAlloc, for synthetic states of hundreds of millions of accounts. Only
+// the account being written or read is held in memory, and the state root is
+// computed by iterating the accounts in the order of their hashed addresses.
+// A store is not safe for concurrent writes.
+type Store struct {
+	db       ethdb.KeyValueStore
+	batch    ethdb.Batch
+	accounts int
+}
+
+// storedAccount is the encoding of an account in a store, without its storage.
+type storedAccount struct {
+	Nonce   uint64
+	Balance *big.Int
+	Code    []byte
+}
+
+// OpenStore opens the store in the directory, creating it if needed, with a
+// cache of the given number of megabytes. The accounts of an existing store
+// are not counted, so new stores should be opened in empty directories.
+func OpenStore(dir string, cache int) (*Store, error) {
+	db, err := pebble.New(dir, cache, 0, "", false)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db, batch: db.NewBatch()}, nil
+}
+
+// Close flushes the pending writes and closes the store.
+func (s *Store) Close() error {
+	return errors.Join(s.Flush(), s.db.Close())
+}
+
+// Len returns the number of accounts put into the store.
+func (s *Store) Len() int {
+	return s.accounts
+}
+
+// Put adds an account, which must not be in the store yet. Writes are
+// batched, and flushed once a batch is full or by Flush.
+func (s *Store) Put(addr common.Address, account types.Account) error {
+	record, err := rlp.EncodeToBytes(&storedAccount{Nonce: account.Nonce, Balance: Balance(account), Code: account.Code})
+	if err != nil {
+		return err
+	}
+	s.batch.Put(storeKey(storeAccountPrefix, addr[:]), record)
+	s.batch.Put(storeKey(storeHashPrefix, crypto.Keccak256(addr[:])), addr[:])
+	for slot, value := range account.Storage {
+		s.batch.Put(storeKey(storeSlotPrefix, addr[:], crypto.Keccak256(slot[:])), append(slot.Bytes(), value[:]...))
+		if s.batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := s.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	s.accounts++
+	if s.batch.ValueSize() >= ethdb.IdealBatchSize {
+		return s.Flush()
+	}
+	return nil
+}
+
+// Flush writes the pending writes to the database.
+func (s *Store) Flush() error {
+	if s.batch.ValueSize() == 0 {
+		return nil
+	}
+	if err := s.batch.Write(); err != nil {
+		return err
+	}
+	s.batch.Reset()
+	return nil
+}
+
+// Range calls fn with every account of the store, in address order, until it
+// returns an error.
+func (s *Store) Range(fn func(addr common.Address, account types.Account) error) error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+	it := s.db.NewIterator(storeKey(storeAccountPrefix), nil)
+	defer it.Release()
+	for it.Next() {
+		addr := common.BytesToAddress(it.Key()[len(storeAccountPrefix):])
+		account, err := s.account(addr, it.Value())
+		if err != nil {
+			return fmt.Errorf("account %s: %v", addr.Hex(), err)
+		}
+		if err := fn(addr, account); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// account decodes the record of an account and reads its storage.
+func (s *Store) account(addr common.Address, record []byte) (types.Account, error) {
+	var stored storedAccount
+	if err := rlp.DecodeBytes(record, &stored); err != nil {
+		return types.Account{}, err
+	}
+	account := types.Account{Nonce: stored.Nonce, Balance: stored.Balance, Code: stored.Code}
+	err := s.rangeSlots(addr, func(hash, slot, value []byte) {
+		if account.Storage == nil {
+			account.Storage = make(map[common.Hash]common.Hash)
+		}
+		account.Storage[common.BytesToHash(slot)] = common.BytesToHash(value)
+	})
+	return account, err
+}
+
+// rangeSlots calls fn with the hashed key, the key and the value of every
+// slot of the account, in the order of their hashed keys.
+func (s *Store) rangeSlots(addr common.Address, fn func(hash, slot, value []byte)) error {
+	prefix := storeKey(storeSlotPrefix, addr[:])
+	it := s.db.NewIterator(prefix, nil)
+	defer it.Release()
+	for it.Next() {
+		if len(it.Value()) != 2*common.HashLength {
+			return fmt.Errorf("invalid slot record of length %d", len(it.Value()))
+		}
+		fn(it.Key()[len(prefix):], it.Value()[:common.HashLength], it.Value()[common.HashLength:])
+	}
+	return it.Error()
+}
+
+// Root computes the state root of the store as Root does, building the 16
+// subtries below the root branch of the account trie with up to jobs
+// workers. The number of accounts of the trie is checked against the number
+// put, which differ if an address was put twice.
+func (s *Store) Root(jobs int) (common.Hash, error) {
+	if err := s.Flush(); err != nil {
+		return common.Hash{}, err
+	}
+	var (
+		shards [16]storeShard
+		tasks  = make(chan int, 16)
+		wg     sync.WaitGroup
+	)
+	for nibble := range shards {
+		tasks <- nibble
+	}
+	close(tasks)
+	for i := 0; i < min(max(jobs, 1), 16); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for nibble := range tasks {
+				shards[nibble] = s.shard(byte(nibble))
+			}
+		}()
+	}
+	wg.Wait()
+
+	var (
+		children [17]rlp.RawValue
+		errs     []error
+		accounts int
+		filled   []int
+	)
+	for nibble, shard := range shards {
+		errs = append(errs, shard.err)
+		accounts += shard.accounts
+		children[nibble] = shard.child
+		if shard.accounts > 0 {
+			filled = append(filled, nibble)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return common.Hash{}, err
+	}
+	if accounts != s.accounts {
+		return common.Hash{}, fmt.Errorf("%d accounts put into the store, %d in its trie: an address was put twice", s.accounts, accounts)
+	}
+	switch len(filled) {
+	case 0:
+		return types.EmptyRootHash, nil
+	case 1:
+		// The accounts share the first nibble, the root of their shard is
+		// that of the account trie.
+		return shards[filled[0]].root, nil
+	}
+	children[16] = rlp.EmptyString
+	branch, err := rlp.EncodeToBytes(children[:])
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(branch), nil
+}
+
+// storeShard is the subtrie of the accounts of a store whose hashed address
+// starts with a nibble: the number of its accounts, the root of its stack
+// trie and its reference in the root branch of the account trie.
+type storeShard struct {
+	accounts int
+	root     common.Hash
+	child    rlp.RawValue
+	err      error
+}
+
+// shard builds the subtrie of the accounts whose hashed address starts with
+// the nibble.
+func (s *Store) shard(nibble byte) storeShard {
+	var (
+		shard = storeShard{child: rlp.EmptyString}
+		node  []byte
+		tr    = trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+			if len(path) == 0 {
+				node = common.CopyBytes(blob)
+			}
+		})
+	)
+	it := s.db.NewIterator(storeKey(storeHashPrefix), []byte{nibble << 4})
+	defer it.Release()
+	for it.Next() {
+		hash := it.Key()[len(storeHashPrefix):]
+		if hash[0]>>4 != nibble {
+			break
+		}
+		addr := common.BytesToAddress(it.Value())
+		value, err := s.trieAccount(addr)
+		if err != nil {
+			shard.err = fmt.Errorf("account %s: %v", addr.Hex(), err)
+			return shard
+		}
+		if err := tr.Update(hash, value); err != nil {
+			shard.err = err
+			return shard
+		}
+		shard.accounts++
+	}
+	if shard.err = it.Error(); shard.err != nil || shard.accounts == 0 {
+		return shard
+	}
+	shard.root = tr.Hash()
+	shard.child, shard.err = shardReference(node)
+	return shard
+}
+
+// trieAccount returns the value of an account of the store in the account
+// trie, computing its storage root.
+func (s *Store) trieAccount(addr common.Address) ([]byte, error) {
+	record, err := s.db.Get(storeKey(storeAccountPrefix, addr[:]))
+	if err != nil {
+		return nil, err
+	}
+	var stored storedAccount
+	if err := rlp.DecodeBytes(record, &stored); err != nil {
+		return nil, err
+	}
+	var (
+		tr        = trie.NewStackTrie(nil)
+		updateErr error
+	)
+	err = s.rangeSlots(addr, func(hash, slot, value []byte) {
+		if value = common.TrimLeftZeroes(value); len(value) > 0 && updateErr == nil {
+			enc, _ := rlp.EncodeToBytes(value)
+			updateErr = tr.Update(hash, enc)
+		}
+	})
+	if err := errors.Join(err, updateErr); err != nil {
+		return nil, err
+	}
+	return encodeAccount(types.Account{Nonce: stored.Nonce, Balance: stored.Balance, Code: stored.Code}, tr.Hash())
+}
+
+// storeKey concatenates the parts of a key into a new slice.
+func storeKey(parts ...[]byte) []byte {
+	var key []byte
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return key
+}
@@ -0,0 +1,189 @@
+package alloc
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Distribution draws non-negative sizes, such as the number of storage slots
+// or the code size of an account. It is given as a constant N,
+// uniform:MIN-MAX, exp:MEAN with an optional ,MAX cap, or zipf:S,MAX for the
+// heavy tailed distribution of real states, where few accounts hold most of
+// the storage, with exponent S > 1. Sizes are at most maxSize.
+type Distribution struct {
+	Kind     string // "constant", "uniform", "exp" or "zipf"
+	Min, Max uint64
+	Mean     float64 // of exp
+	Exponent float64 // of zipf
+}
+
+// maxSize bounds the sizes drawn from a distribution.
+const maxSize = 1 << 32
+
+// ParseDistribution parses a distribution in the syntax of Distribution.
+func ParseDistribution(s string) (Distribution, error) {
+	kind, args, ok := strings.Cut(s, ":")
+	if !ok {
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || n > maxSize {
+			return Distribution{}, fmt.Errorf("invalid distribution %q", s)
+		}
+		return Distribution{Kind: "constant", Min: n, Max: n}, nil
+	}
+	switch kind {
+	case "uniform":
+		lo, hi, ok := strings.Cut(args, "-")
+		min, err1 := strconv.ParseUint(lo, 10, 64)
+		max, err2 := strconv.ParseUint(hi, 10, 64)
+		if !ok || err1 != nil || err2 != nil || min > max || max > maxSize {
+			return Distribution{}, fmt.Errorf("invalid uniform distribution %q, want uniform:MIN-MAX", s)
+		}
+		return Distribution{Kind: kind, Min: min, Max: max}, nil
+	case "exp":
+		mean, limit, capped := strings.Cut(args, ",")
+		d := Distribution{Kind: kind, Max: maxSize}
+		var err error
+		if d.Mean, err = strconv.ParseFloat(mean, 64); err != nil || d.Mean < 0 {
+			return Distribution{}, fmt.Errorf("invalid exponential distribution %q, want exp:MEAN[,MAX]", s)
+		}
+		if capped {
+			if d.Max, err = strconv.ParseUint(limit, 10, 64); err != nil || d.Max > maxSize {
+				return Distribution{}, fmt.Errorf("invalid exponential distribution %q, want exp:MEAN[,MAX]", s)
+			}
+		}
+		return d, nil
+	case "zipf":
+		arg, limit, ok := strings.Cut(args, ",")
+		exponent, err1 := strconv.ParseFloat(arg, 64)
+		max, err2 := strconv.ParseUint(limit, 10, 64)
+		if !ok || err1 != nil || err2 != nil || exponent <= 1 || max > maxSize {
+			return Distribution{}, fmt.Errorf("invalid zipf distribution %q, want zipf:S,MAX with S > 1", s)
+		}
+		return Distribution{Kind: kind, Max: max, Exponent: exponent}, nil
+	}
+	return Distribution{}, fmt.Errorf("unknown distribution %q", kind)
+}
+
+// String returns the distribution in the syntax of ParseDistribution.
+func (d Distribution) String() string {
+	switch d.Kind {
+	case "uniform":
+		return fmt.Sprintf("uniform:%d-%d", d.Min, d.Max)
+	case "exp":
+		if d.Max == maxSize {
+			return fmt.Sprintf("exp:%g", d.Mean)
+		}
+		return fmt.Sprintf("exp:%g,%d", d.Mean, d.Max)
+	case "zipf":
+		return fmt.Sprintf("zipf:%g,%d", d.Exponent, d.Max)
+	}
+	return strconv.FormatUint(d.Min, 10)
+}
+
+// sampler returns a function drawing from the distribution.
+func (d Distribution) sampler(rng *rand.Rand) func() uint64 {
+	switch d.Kind {
+	case "uniform":
+		return func() uint64 {
+			return d.Min + uint64(rng.Int63n(int64(d.Max-d.Min)+1))
+		}
+	case "exp":
+		return func() uint64 {
+			return uint64(min(rng.ExpFloat64()*d.Mean, float64(d.Max)))
+		}
+	case "zipf":
+		zipf := rand.NewZipf(rng, d.Exponent, 1, d.Max)
+		return zipf.Uint64
+	}
+	return func() uint64 { return d.Min }
+}
+
+// Shape describes a synthetic allocation: the number of accounts, the share
+// of them that are contracts and the distributions of the code size and the
+// number of storage slots of the contracts. Externally owned accounts have
+// neither code nor storage.
+type Shape struct {
+	Accounts  int
+	Contracts float64 // share of the accounts with code and storage, 0 to 1
+	CodeSize  Distribution
+	Slots     Distribution
+}
+
+// Generate deterministically generates an allocation of the given shape from
+// the seed: the same seed and shape always yield the same accounts. Addresses,
+// balances, nonces, code, slot keys and values are random. Code sizes are
+// capped at the EIP-170 limit.
+func Generate(shape Shape, seed int64) (types.GenesisAlloc, error) {
+	result := make(types.GenesisAlloc, max(shape.Accounts, 0))
+	exists := func(addr common.Address) bool {
+		_, ok := result[addr]
+		return ok
+	}
+	err := GenerateFunc(shape, seed, exists, func(addr common.Address, account types.Account) error {
+		result[addr] = account
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GenerateFunc generates the accounts of Generate one at a time, calling put
+// with each of them, for allocations held elsewhere than in memory, such as
+// in a Store. Addresses for which exists reports true are drawn again; with a
+// nil exists they are not checked, which only makes a difference for an
+// address drawn twice, with a chance of about n^2/2^161 for n accounts.
+func GenerateFunc(shape Shape, seed int64, exists func(common.Address) bool, put func(common.Address, types.Account) error) error {
+	if shape.Accounts < 0 {
+		return fmt.Errorf("invalid number of accounts %d", shape.Accounts)
+	}
+	if shape.Contracts < 0 || shape.Contracts > 1 {
+		return fmt.Errorf("invalid share of contracts %g", shape.Contracts)
+	}
+	var (
+		rng      = rand.New(rand.NewSource(seed))
+		codeSize = shape.CodeSize.sampler(rng)
+		slots    = shape.Slots.sampler(rng)
+	)
+	for added := 0; added < shape.Accounts; {
+		var addr common.Address
+		rng.Read(addr[:])
+		if exists != nil && exists(addr) {
+			continue
+		}
+		account := types.Account{
+			Balance: new(big.Int).Rand(rng, new(big.Int).Lsh(big.NewInt(1), 96)),
+			Nonce:   uint64(rng.Intn(1 << 16)),
+		}
+		if rng.Float64() < shape.Contracts {
+			account.Nonce = 1
+			account.Code = make([]byte, min(codeSize(), params.MaxCodeSize))
+			rng.Read(account.Code)
+			if n := slots(); n > 0 {
+				account.Storage = make(map[common.Hash]common.Hash, n)
+				for uint64(len(account.Storage)) < n {
+					var key, value common.Hash
+					rng.Read(key[:])
+					rng.Read(value[32-1-rng.Intn(32):])
+					if value == (common.Hash{}) {
+						value[31] = 1
+					}
+					account.Storage[key] = value
+				}
+			}
+		}
+		if err := put(addr, account); err != nil {
+			return err
+		}
+		added++
+	}
+	return nil
+}
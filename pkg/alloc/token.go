@@ -0,0 +1,106 @@
+package alloc
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TokenBalance is the balance of a holder in a token snapshot, in the
+// smallest unit of the token.
+type TokenBalance struct {
+	Holder  common.Address
+	Balance *big.Int
+}
+
+// DecodeTokenBalances parses a token holder snapshot, a CSV file of address
+// and balance rows. A first row whose address column is not an address is
+// taken as the header and skipped, further columns are ignored. Balances are
+// decimal or hex integers in the smallest unit of the token. Malformed and
+// repeated holders are rejected, holders failing their EIP-55 checksum
+// returned as warnings.
+func DecodeTokenBalances(r io.Reader) ([]TokenBalance, []AddressWarning, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+
+	var (
+		balances []TokenBalance
+		warnings []AddressWarning
+		seen     = make(map[common.Address]int)
+	)
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 {
+			return nil, nil, fmt.Errorf("line %d: want address and balance columns", line)
+		}
+		addr, value := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		holder, err := ParseAddress(addr)
+		if err != nil {
+			if first {
+				continue
+			}
+			return nil, nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if !ValidChecksum(addr) {
+			warnings = append(warnings, AddressWarning{
+				Address: holder,
+				Keys:    []string{addr},
+				Message: fmt.Sprintf("line %d: address %q fails the EIP-55 checksum, expected %s", line, addr, holder.Hex()),
+			})
+		}
+		if prev, ok := seen[holder]; ok {
+			return nil, nil, fmt.Errorf("line %d: holder %s already listed on line %d", line, holder.Hex(), prev)
+		}
+		seen[holder] = line
+		balance, ok := math.ParseBig256(value)
+		if !ok {
+			return nil, nil, fmt.Errorf("line %d: invalid balance %q", line, value)
+		}
+		balances = append(balances, TokenBalance{Holder: holder, Balance: balance})
+	}
+	return balances, warnings, nil
+}
+
+// MappingSlot returns the storage slot of the entry of an address in a
+// Solidity mapping at the given slot, keccak256(key . slot) with both padded
+// to 32 bytes.
+func MappingSlot(key common.Address, slot common.Hash) common.Hash {
+	return crypto.Keccak256Hash(common.LeftPadBytes(key[:], common.HashLength), slot[:])
+}
+
+// TokenStorage returns the storage slots holding the balances in the mapping
+// at the given slot, the layout of a Solidity ERC-20 such as
+// mapping(address => uint256) balances, and the total supply of the
+// balances. Zero balances occupy no slot.
+func TokenStorage(balances []TokenBalance, slot common.Hash) (map[common.Hash]common.Hash, *big.Int, error) {
+	var (
+		storage = make(map[common.Hash]common.Hash, len(balances))
+		supply  = new(big.Int)
+	)
+	for _, b := range balances {
+		supply.Add(supply, b.Balance)
+		if b.Balance.Sign() == 0 {
+			continue
+		}
+		storage[MappingSlot(b.Holder, slot)] = common.BigToHash(b.Balance)
+	}
+	if supply.BitLen() > 256 {
+		return nil, nil, fmt.Errorf("total supply exceeds 256 bits")
+	}
+	return storage, supply, nil
+}
@@ -0,0 +1,76 @@
+package alloc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/utils"
+	"github.com/ethereum/go-verkle"
+	"github.com/holiman/uint256"
+)
+
+// VerkleLeaves maps the allocation onto the leaves of an EIP-6800 verkle tree,
+// keyed by their tree key. Every account has a basic data leaf, holding the
+// version, code size, nonce and balance, and a code hash leaf. The code is
+// split into 31 byte chunks, each prefixed with the number of leading bytes
+// which are push data, and the storage slots are placed in the account header
+// or the main storage. Zero storage values are omitted, as they are from the
+// Merkle Patricia state.
+func VerkleLeaves(alloc types.GenesisAlloc) (map[common.Hash]common.Hash, error) {
+	var (
+		leaves = make(map[common.Hash]common.Hash)
+		points = utils.NewPointCache(len(alloc))
+	)
+	for addr, account := range alloc {
+		balance, overflow := uint256.FromBig(Balance(account))
+		if overflow || balance.ByteLen() > 16 {
+			return nil, fmt.Errorf("account %s: balance does not fit into 16 bytes", addr.Hex())
+		}
+		point := points.Get(addr[:])
+
+		var basic common.Hash
+		binary.BigEndian.PutUint32(basic[utils.BasicDataCodeSizeOffset-1:], uint32(len(account.Code)))
+		binary.BigEndian.PutUint64(basic[utils.BasicDataNonceOffset:], account.Nonce)
+		balance.WriteToSlice(basic[utils.BasicDataBalanceOffset:])
+		leaves[common.BytesToHash(utils.BasicDataKeyWithEvaluatedAddress(point))] = basic
+		leaves[common.BytesToHash(utils.CodeHashKeyWithEvaluatedAddress(point))] = crypto.Keccak256Hash(account.Code)
+
+		chunks := trie.ChunkifyCode(account.Code)
+		for i := 0; i < len(chunks); i += 32 {
+			key := utils.CodeChunkKeyWithEvaluatedAddress(point, uint256.NewInt(uint64(i/32)))
+			leaves[common.BytesToHash(key)] = common.BytesToHash(chunks[i : i+32])
+		}
+		for slot, value := range account.Storage {
+			if value == (common.Hash{}) {
+				continue
+			}
+			leaves[common.BytesToHash(utils.StorageSlotKeyWithEvaluatedAddress(point, slot[:]))] = value
+		}
+	}
+	return leaves, nil
+}
+
+// VerkleRoot returns the root commitment of the verkle tree holding the given
+// leaves.
+func VerkleRoot(leaves map[common.Hash]common.Hash) (common.Hash, error) {
+	keys := make([]common.Hash, 0, len(leaves))
+	for key := range leaves {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i][:], keys[j][:]) < 0 })
+
+	root := verkle.New()
+	for _, key := range keys {
+		value := leaves[key]
+		if err := root.Insert(key[:], value[:], nil); err != nil {
+			return common.Hash{}, fmt.Errorf("leaf %x: %v", key, err)
+		}
+	}
+	return root.Commit().Bytes(), nil
+}
@@ -0,0 +1,145 @@
+// Package backend abstracts the operations the tooling takes from an Ethereum
+// implementation: computing the state root of an allocation, around which a
+// genesis block is assembled, and executing state tests. A backend is chosen
+// at runtime, so that the results of several go-ethereum versions and the
+// execution specs can be compared with each other for regression hunting.
+//
+// The backends are
+//
+//   - go: the go-ethereum version of go.mod, in process,
+//   - ref: the reference implementations of this repository, alloc.Root for
+//     the state root; it executes no state tests,
+//   - a client evm tool run as a subprocess, such as the evm tool of another
+//     go-ethereum version or ethereum-spec-evm of the execution specs. State
+//     roots are computed by a t8n transition without transactions, state
+//     tests by its statetest command.
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/evmfuzz"
+	"github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ErrUnsupported is returned for operations a backend does not implement.
+var ErrUnsupported = errors.New("not supported by the backend")
+
+// Backend is an implementation of the state trie and the EVM. As an
+// evmfuzz.Backend it executes state tests, so backends can be compared with
+// evmfuzz.Compare.
+type Backend interface {
+	evmfuzz.Backend
+	// StateRoot computes the state root of the allocation.
+	StateRoot(alloc types.GenesisAlloc) (common.Hash, error)
+}
+
+// Tool is the command line of a client evm tool: the command and the
+// subcommands of its state transition and state test runner.
+type Tool struct {
+	Command   string
+	T8n       string
+	StateTest string
+}
+
+// Tools are the evm tools of the known clients. A backend given by a command
+// only uses the conventions of its client if it has the name of the client,
+// those of geth otherwise, which the execution specs share.
+var Tools = map[string]Tool{
+	"geth": {Command: "evm", T8n: "t8n", StateTest: "statetest --json"},
+	"eels": {Command: "ethereum-spec-evm", T8n: "t8n", StateTest: "statetest --json"},
+	"besu": {Command: "evmtool", T8n: "t8n", StateTest: "state-test --json"},
+}
+
+// Parse parses a backend definition: "go", "ref", the name of a known client
+// for its evm tool on the PATH, or NAME=COMMAND for the evm tool at COMMAND.
+// The jobs are those of the reference state root computation, the timeout
+// bounds every subprocess invocation.
+func Parse(def string, jobs int, timeout time.Duration) (Backend, error) {
+	name, command, explicit := strings.Cut(def, "=")
+	if !explicit {
+		switch name {
+		case "go":
+			return goBackend{}, nil
+		case "ref":
+			return refBackend{jobs: jobs}, nil
+		}
+	}
+	tool, ok := Tools[name]
+	switch {
+	case !ok && !explicit:
+		return nil, fmt.Errorf("unknown backend %q, give its evm tool as %s=COMMAND", name, name)
+	case !ok:
+		tool = Tools["geth"]
+	}
+	if explicit {
+		tool.Command = command
+	}
+	if name == "" || strings.TrimSpace(tool.Command) == "" {
+		return nil, fmt.Errorf("invalid backend %q", def)
+	}
+	return newToolBackend(name, tool, timeout)
+}
+
+// GenesisBlock assembles the genesis block like core.Genesis.ToBlock, with the
+// state root computed by the backend. Verkle genesis states are not supported.
+func GenesisBlock(b Backend, g *core.Genesis) (*types.Block, error) {
+	if g.IsVerkle() {
+		return nil, fmt.Errorf("verkle genesis: %w", ErrUnsupported)
+	}
+	root, err := b.StateRoot(g.Alloc)
+	if err != nil {
+		return nil, err
+	}
+	block := genesis.WithoutAlloc(g).ToBlock()
+	header := block.Header()
+	header.Root = root
+	return block.WithSeal(header), nil
+}
+
+// RunsStateTests reports whether the backend executes state tests.
+func RunsStateTests(b Backend) bool {
+	_, ref := b.(refBackend)
+	return !ref
+}
+
+// goBackend is the go-ethereum version of go.mod, in process.
+type goBackend struct {
+	evmfuzz.GoBackend
+}
+
+// StateRoot computes the state root with a go-ethereum state database, the
+// way it commits a genesis.
+func (goBackend) StateRoot(a types.GenesisAlloc) (root common.Hash, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	g := &core.Genesis{Config: params.AllEthashProtocolChanges, Alloc: a}
+	return g.ToBlock().Root(), nil
+}
+
+// refBackend is the reference implementation of this repository.
+type refBackend struct {
+	jobs int
+}
+
+func (refBackend) Name() string { return "ref" }
+
+func (b refBackend) StateRoot(a types.GenesisAlloc) (common.Hash, error) {
+	return alloc.Root(a, b.jobs)
+}
+
+func (refBackend) Run(test json.RawMessage, fork string) (*evmfuzz.Result, error) {
+	return nil, fmt.Errorf("state tests: %w", ErrUnsupported)
+}
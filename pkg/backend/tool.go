@@ -0,0 +1,108 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/evmfuzz"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// rootFork is the fork of the state transitions computing state roots: the
+// first one without block rewards which introduces no system calls, so an
+// empty block leaves the allocation unchanged.
+const rootFork = "Shanghai"
+
+// rootEnv is the environment of the empty block. The base fee and random
+// value are given so that no parent block is needed.
+var rootEnv = map[string]interface{}{
+	"currentCoinbase":  common.Address{},
+	"currentGasLimit":  "0x1c9c380",
+	"currentNumber":    "0x1",
+	"currentTimestamp": "0x1",
+	"currentRandom":    common.Hash{},
+	"currentBaseFee":   "0x7",
+	"withdrawals":      []interface{}{},
+	"blockHashes":      map[string]interface{}{},
+}
+
+// toolBackend is a client evm tool run as a subprocess.
+type toolBackend struct {
+	*evmfuzz.CommandBackend
+	t8n     []string
+	timeout time.Duration
+}
+
+func newToolBackend(name string, tool Tool, timeout time.Duration) (Backend, error) {
+	command := strings.Fields(tool.Command)
+	stateTest, err := evmfuzz.ParseBackend(name+"="+strings.Join(append(command, tool.StateTest), " "), timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &toolBackend{
+		CommandBackend: stateTest.(*evmfuzz.CommandBackend),
+		t8n:            append(command, strings.Fields(tool.T8n)...),
+		timeout:        timeout,
+	}, nil
+}
+
+// StateRoot runs a state transition of an empty block on the allocation and
+// returns the state root of its result.
+func (b *toolBackend) StateRoot(alloc types.GenesisAlloc) (common.Hash, error) {
+	input, err := json.Marshal(map[string]interface{}{
+		"alloc": alloc,
+		"env":   rootEnv,
+		"txs":   []interface{}{},
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	args := []string{
+		"--input.alloc", "stdin",
+		"--input.env", "stdin",
+		"--input.txs", "stdin",
+		"--output.result", "stdout",
+		"--output.alloc", "stdout",
+		"--state.fork", rootFork,
+		"--state.reward", "-1",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, b.t8n[0], append(b.t8n[1:], args...)...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err = cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		return common.Hash{}, fmt.Errorf("%s timed out after %v", b.Name(), b.timeout)
+	case err != nil && stderr.Len() == 0:
+		return common.Hash{}, fmt.Errorf("%s: %v", b.Name(), err)
+	case err != nil:
+		return common.Hash{}, fmt.Errorf("%s: %v: %s", b.Name(), err, lastLine(stderr.Bytes()))
+	}
+	var out struct {
+		Result struct {
+			StateRoot *common.Hash `json:"stateRoot"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return common.Hash{}, fmt.Errorf("%s: invalid t8n output: %v", b.Name(), err)
+	}
+	if out.Result.StateRoot == nil {
+		return common.Hash{}, errors.New(b.Name() + ": no state root reported")
+	}
+	return *out.Result.StateRoot, nil
+}
+
+func lastLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return lines[len(lines)-1]
+}
@@ -0,0 +1,196 @@
+// Package basefee implements the EIP-1559 base fee adjustment as the
+// execution specs define it, with configurable elasticity multiplier and base
+// fee change denominator, and simulates the base fee over a sequence of
+// per-block gas used.
+//
+// The base fee moves towards the gas target, the gas limit divided by the
+// elasticity multiplier, by at most one denominator-th of itself per block.
+// An increase is at least one wei, so even a zero base fee rises above a
+// block over the target, while a decrease may round down to nothing.
+package basefee
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// The EIP-1559 constants of the specs.
+const (
+	DefaultElasticityMultiplier     = 2          // ratio of the gas limit to the gas target
+	DefaultBaseFeeChangeDenominator = 8          // bound of the base fee change per block
+	InitialBaseFee                  = 1000000000 // base fee of the London fork block
+)
+
+// Params are the parameters of the fee market.
+type Params struct {
+	ElasticityMultiplier     uint64 `json:"elasticityMultiplier"`
+	BaseFeeChangeDenominator uint64 `json:"baseFeeChangeDenominator"`
+}
+
+// Mainnet are the parameters of the mainnet fee market.
+var Mainnet = Params{
+	ElasticityMultiplier:     DefaultElasticityMultiplier,
+	BaseFeeChangeDenominator: DefaultBaseFeeChangeDenominator,
+}
+
+// Validate checks that the parameters are usable.
+func (p Params) Validate() error {
+	switch {
+	case p.ElasticityMultiplier == 0:
+		return errors.New("zero elasticity multiplier")
+	case p.BaseFeeChangeDenominator == 0:
+		return errors.New("zero base fee change denominator")
+	}
+	return nil
+}
+
+// Target returns the gas target of a block with the gas limit.
+func (p Params) Target(gasLimit uint64) uint64 {
+	return gasLimit / p.ElasticityMultiplier
+}
+
+// Next returns the base fee of a block from the gas limit, gas used and base
+// fee of its parent. The gas target of the parent must be positive.
+func (p Params) Next(parentGasLimit, parentGasUsed uint64, parentBaseFee *big.Int) *big.Int {
+	target := p.Target(parentGasLimit)
+	switch {
+	case parentGasUsed == target:
+		return new(big.Int).Set(parentBaseFee)
+	case parentGasUsed > target:
+		delta := p.delta(parentBaseFee, parentGasUsed-target, target)
+		if delta.Sign() == 0 {
+			delta.SetUint64(1)
+		}
+		return delta.Add(parentBaseFee, delta)
+	default:
+		delta := p.delta(parentBaseFee, target-parentGasUsed, target)
+		return delta.Sub(parentBaseFee, delta)
+	}
+}
+
+// delta returns baseFee * gasDelta / target / denominator.
+func (p Params) delta(baseFee *big.Int, gasDelta, target uint64) *big.Int {
+	delta := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(gasDelta))
+	delta.Div(delta, new(big.Int).SetUint64(target))
+	return delta.Div(delta, new(big.Int).SetUint64(p.BaseFeeChangeDenominator))
+}
+
+// Usage is the gas used by a block: an amount of gas, or the target or the
+// gas limit of the block offset by an amount of gas.
+type Usage struct {
+	Gas      uint64
+	Relative string // "target" or "max", for a usage following the gas limit
+	Offset   int64  // added to the relative usage
+}
+
+// Of returns the gas used of the usage in a block with the gas limit.
+func (u Usage) Of(p Params, gasLimit uint64) (uint64, error) {
+	var gas uint64
+	switch u.Relative {
+	case "target":
+		gas = p.Target(gasLimit)
+	case "max":
+		gas = gasLimit
+	default:
+		return u.Gas, nil
+	}
+	if u.Offset < 0 && uint64(-u.Offset) > gas {
+		return 0, fmt.Errorf("%s%d is below zero", u.Relative, u.Offset)
+	}
+	return gas + uint64(u.Offset), nil
+}
+
+// ParseUsage parses a comma separated gas used sequence. Each entry is an
+// amount of gas, or "target" or "max" with an optional "+gas" or "-gas"
+// offset, optionally repeated by a "*count" suffix: "max*10,target+1*5,0" is
+// ten full blocks, five blocks one gas above the target and an empty block.
+func ParseUsage(s string) ([]Usage, error) {
+	var usage []Usage
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		value, repeat := entry, 1
+		if v, count, ok := strings.Cut(entry, "*"); ok {
+			n, err := strconv.Atoi(count)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid repetition %q in gas usage %q", count, entry)
+			}
+			value, repeat = v, n
+		}
+		var u Usage
+		switch {
+		case strings.HasPrefix(value, "target"), strings.HasPrefix(value, "max"):
+			u.Relative = strings.TrimRight(value, "+-0123456789")
+			offset := strings.TrimPrefix(value, u.Relative)
+			if u.Relative != "target" && u.Relative != "max" {
+				return nil, fmt.Errorf("invalid gas usage %q, want an amount of gas, target or max with an optional offset", entry)
+			}
+			if offset != "" {
+				n, err := strconv.ParseInt(offset, 10, 64)
+				if err != nil || (offset[0] != '+' && offset[0] != '-') {
+					return nil, fmt.Errorf("invalid gas usage %q, want an amount of gas, target or max with an optional offset", entry)
+				}
+				u.Offset = n
+			}
+		default:
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid gas usage %q, want an amount of gas, target or max with an optional offset", entry)
+			}
+			u.Gas = n
+		}
+		for i := 0; i < repeat; i++ {
+			usage = append(usage, u)
+		}
+	}
+	return usage, nil
+}
+
+// Start is the first block of a simulation. The gas limit stays constant.
+type Start struct {
+	Number   uint64
+	GasLimit uint64
+	BaseFee  *big.Int
+}
+
+// Block is a simulated block.
+type Block struct {
+	Number   uint64   `json:"number"`
+	GasLimit uint64   `json:"gasLimit"`
+	GasUsed  uint64   `json:"gasUsed"`
+	BaseFee  *big.Int `json:"baseFeePerGas"`
+}
+
+// Simulate runs the base fee adjustment over a block per usage entry,
+// starting with the given block.
+func Simulate(p Params, start *Start, usage []Usage) ([]*Block, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if p.Target(start.GasLimit) == 0 {
+		return nil, fmt.Errorf("gas limit %d has no gas target with an elasticity multiplier of %d", start.GasLimit, p.ElasticityMultiplier)
+	}
+	if start.BaseFee == nil || start.BaseFee.Sign() < 0 {
+		return nil, errors.New("missing or negative initial base fee")
+	}
+	blocks := make([]*Block, len(usage))
+	for i, u := range usage {
+		b := &Block{Number: start.Number + uint64(i), GasLimit: start.GasLimit, BaseFee: start.BaseFee}
+		if i > 0 {
+			parent := blocks[i-1]
+			b.BaseFee = p.Next(parent.GasLimit, parent.GasUsed, parent.BaseFee)
+		}
+		gas, err := u.Of(p, b.GasLimit)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", b.Number, err)
+		}
+		if gas > b.GasLimit {
+			return nil, fmt.Errorf("block %d: gas used %d exceeds the gas limit %d", b.Number, gas, b.GasLimit)
+		}
+		b.GasUsed = gas
+		blocks[i] = b
+	}
+	return blocks, nil
+}
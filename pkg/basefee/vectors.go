@@ -0,0 +1,107 @@
+package basefee
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Vector is a base fee computation: the parent values and the base fee of the
+// child under the parameters.
+type Vector struct {
+	Name           string         `json:"name"`
+	Params         Params         `json:"params"`
+	ParentGasLimit hexutil.Uint64 `json:"parentGasLimit"`
+	ParentGasUsed  hexutil.Uint64 `json:"parentGasUsed"`
+	ParentBaseFee  *hexutil.Big   `json:"parentBaseFeePerGas"`
+	BaseFee        *hexutil.Big   `json:"baseFeePerGas"`
+}
+
+// Trajectory is a simulation over a gas used sequence.
+type Trajectory struct {
+	Name   string   `json:"name"`
+	Params Params   `json:"params"`
+	Usage  string   `json:"usage"`
+	Blocks []*Block `json:"blocks"`
+}
+
+// VectorsGasLimit is the gas limit of the vectors.
+const VectorsGasLimit = 30000000
+
+// Vectors generates the base fee vectors of the parameters: gas used at,
+// below and above the target and at the limits, the base fees at which an
+// increase rounds to zero and is clamped to one wei, an odd gas limit whose
+// target is rounded down and base fees beyond 64 bits.
+func Vectors(p Params) []*Vector {
+	var (
+		limit  = uint64(VectorsGasLimit)
+		target = p.Target(limit)
+		gwei   = big.NewInt(InitialBaseFee)
+		// The largest base fee whose increase by a one gas excess rounds to
+		// zero before the one wei minimum.
+		unitFee = new(big.Int).SetUint64(target*p.BaseFeeChangeDenominator - 1)
+		large   = new(big.Int).Lsh(big.NewInt(1), 64)
+	)
+	cases := []struct {
+		name          string
+		limit, used   uint64
+		parentBaseFee *big.Int
+	}{
+		{"at_target", limit, target, gwei},
+		{"empty", limit, 0, gwei},
+		{"full", limit, limit, gwei},
+		{"below_target", limit, target - 1, gwei},
+		{"above_target", limit, target + 1, gwei},
+		{"minimum_increase", limit, target + 1, unitFee},
+		{"minimum_increase_full", limit, limit, unitFee},
+		{"small_base_fee_full", limit, limit, big.NewInt(7)},
+		{"small_base_fee_empty", limit, 0, big.NewInt(7)},
+		{"one_wei_empty", limit, 0, big.NewInt(1)},
+		{"zero_base_fee_above_target", limit, target + 1, new(big.Int)},
+		{"zero_base_fee_empty", limit, 0, new(big.Int)},
+		{"odd_gas_limit_at_target", limit + 1, p.Target(limit + 1), gwei},
+		{"odd_gas_limit_full", limit + 1, limit + 1, gwei},
+		{"2_pow_64_full", limit, limit, large},
+		{"2_pow_64_empty", limit, 0, large},
+		{"2_pow_256_full", limit, limit, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))},
+	}
+	var vectors []*Vector
+	for _, c := range cases {
+		vectors = append(vectors, &Vector{
+			Name:           c.name,
+			Params:         p,
+			ParentGasLimit: hexutil.Uint64(c.limit),
+			ParentGasUsed:  hexutil.Uint64(c.used),
+			ParentBaseFee:  (*hexutil.Big)(c.parentBaseFee),
+			BaseFee:        (*hexutil.Big)(p.Next(c.limit, c.used, c.parentBaseFee)),
+		})
+	}
+	return vectors
+}
+
+// Trajectories generates simulations of the parameters from the London
+// initial base fee: sustained full and empty blocks, a base fee pumped up and
+// drained back, alternating full and empty blocks, which drift downwards, and
+// blocks just off the target.
+func Trajectories(p Params) ([]*Trajectory, error) {
+	cases := []struct{ name, usage string }{
+		{"full_blocks", "max*32"},
+		{"empty_blocks", "0*256"},
+		{"pump_and_drain", "max*20,target*5,0*40"},
+		{"alternating", "max,0,max,0,max,0,max,0,max,0,max,0,max,0,max,0"},
+		{"off_target", "target+1*8,target-1*8,target*4"},
+	}
+	var out []*Trajectory
+	for _, c := range cases {
+		usage, err := ParseUsage(c.usage)
+		if err != nil {
+			return nil, err
+		}
+		blocks, err := Simulate(p, &Start{Number: 1, GasLimit: VectorsGasLimit, BaseFee: big.NewInt(InitialBaseFee)}, usage)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &Trajectory{Name: c.name, Params: p, Usage: c.usage, Blocks: blocks})
+	}
+	return out, nil
+}
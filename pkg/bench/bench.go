@@ -0,0 +1,136 @@
+// Package bench benchmarks the gas and execution time of single opcodes.
+// Workloads are generated from a standardized parameter schema, executed by a
+// t8n runner, either in process or through any client implementing the t8n
+// command line interface, and reported per opcode in a uniform CSV or JSON
+// layout, so that measurements of different clients and repricing proposals
+// can be compared row by row.
+package bench
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"gopkg.in/yaml.v3"
+)
+
+// Params is the standardized parameter schema of a workload. The same names
+// are used in matrix files and as the columns of the results.
+type Params struct {
+	// CalldataLength is the length of the transaction calldata, which is
+	// also the size operand of CALLDATACOPY.
+	CalldataLength int `json:"calldata_length" yaml:"calldata_length"`
+	// CalldataNonzero is the number of nonzero bytes at the start of the
+	// calldata, the remaining bytes are zero.
+	CalldataNonzero int `json:"calldata_nonzero" yaml:"calldata_nonzero"`
+	// MemorySize is the memory expanded before the measured operations and
+	// the size operand of the operations reading or writing memory ranges.
+	MemorySize int `json:"memory_size" yaml:"memory_size"`
+	// WarmAccess selects whether the accounts and storage slots accessed by
+	// the operations are warmed beforehand. Otherwise every operation
+	// accesses a different cold one.
+	WarmAccess bool `json:"warm_access" yaml:"warm_access"`
+	// Iterations is the number of measured operations of the workload.
+	Iterations int `json:"iterations" yaml:"iterations"`
+}
+
+// Validate checks that the parameters describe a workload.
+func (p Params) Validate() error {
+	switch {
+	case p.CalldataLength < 0 || p.MemorySize < 0:
+		return fmt.Errorf("calldata_length and memory_size must not be negative")
+	case p.CalldataNonzero < 0 || p.CalldataNonzero > p.CalldataLength:
+		return fmt.Errorf("calldata_nonzero must be between 0 and calldata_length (%d)", p.CalldataLength)
+	case p.Iterations <= 0:
+		return fmt.Errorf("iterations must be positive")
+	}
+	return nil
+}
+
+// Matrix lists the values of every parameter to benchmark. The workloads are
+// the cross product of all lists for every opcode.
+type Matrix struct {
+	Opcodes         []string `json:"opcodes" yaml:"opcodes"`
+	CalldataLength  []int    `json:"calldata_length" yaml:"calldata_length"`
+	CalldataNonzero []int    `json:"calldata_nonzero" yaml:"calldata_nonzero"`
+	MemorySize      []int    `json:"memory_size" yaml:"memory_size"`
+	WarmAccess      []bool   `json:"warm_access" yaml:"warm_access"`
+	Iterations      []int    `json:"iterations" yaml:"iterations"`
+}
+
+// DefaultMatrix benchmarks every supported opcode with empty calldata and
+// memory, cold and warm.
+func DefaultMatrix() *Matrix {
+	return &Matrix{
+		Opcodes:         Opcodes(),
+		CalldataLength:  []int{0},
+		CalldataNonzero: []int{0},
+		MemorySize:      []int{0},
+		WarmAccess:      []bool{false, true},
+		Iterations:      []int{256},
+	}
+}
+
+// LoadMatrix reads a YAML or JSON matrix file. Parameters missing from the
+// file take the values of the DefaultMatrix.
+func LoadMatrix(path string) (*Matrix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	matrix := DefaultMatrix()
+	if err := yaml.Unmarshal(data, matrix); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return matrix, nil
+}
+
+// Expand returns the parameters of every workload of the matrix. Combinations
+// with more nonzero calldata bytes than calldata are skipped.
+func (m *Matrix) Expand() ([]Params, error) {
+	var all []Params
+	for _, length := range m.CalldataLength {
+		for _, nonzero := range m.CalldataNonzero {
+			if nonzero > length {
+				continue
+			}
+			for _, memory := range m.MemorySize {
+				for _, warm := range m.WarmAccess {
+					for _, iterations := range m.Iterations {
+						p := Params{
+							CalldataLength:  length,
+							CalldataNonzero: nonzero,
+							MemorySize:      memory,
+							WarmAccess:      warm,
+							Iterations:      iterations,
+						}
+						if err := p.Validate(); err != nil {
+							return nil, err
+						}
+						all = append(all, p)
+					}
+				}
+			}
+		}
+	}
+	if len(all) == 0 {
+		return nil, fmt.Errorf("the matrix has no workloads")
+	}
+	return all, nil
+}
+
+// Opcodes returns the names of the supported opcodes in opcode order.
+func Opcodes() []string {
+	ops := make([]vm.OpCode, 0, len(operations))
+	for op := range operations {
+		ops = append(ops, op)
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i] < ops[j] })
+
+	names := make([]string, len(ops))
+	for i, op := range ops {
+		names[i] = op.String()
+	}
+	return names
+}
@@ -0,0 +1,114 @@
+package bench
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Result is the measurement of one workload. The same fields, in the same
+// order, are the columns of the CSV output.
+type Result struct {
+	Runner string `json:"runner"`
+	Fork   string `json:"fork"`
+	Opcode string `json:"opcode"`
+	Params
+	GasUsed         uint64  `json:"gas_used"`
+	BaselineGasUsed uint64  `json:"baseline_gas_used"`
+	GasPerOp        float64 `json:"gas_per_op"`
+	TimeNs          int64   `json:"time_ns"`
+	BaselineTimeNs  int64   `json:"baseline_time_ns"`
+	NsPerOp         float64 `json:"ns_per_op"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// resultColumns are the CSV column names of a Result.
+var resultColumns = []string{
+	"runner", "fork", "opcode",
+	"calldata_length", "calldata_nonzero", "memory_size", "warm_access", "iterations",
+	"gas_used", "baseline_gas_used", "gas_per_op",
+	"time_ns", "baseline_time_ns", "ns_per_op", "error",
+}
+
+// Measure runs a workload and its baseline with the runner. The execution
+// time is the fastest of the given number of runs, the per operation values
+// are the differences to the baseline divided by the iterations.
+func Measure(runner Runner, env *Env, w *Workload, runs int) (*Result, error) {
+	result := &Result{Runner: runner.Name(), Fork: env.Fork, Opcode: w.Opcode, Params: w.Params}
+
+	code, err := measure(runner, env, w.Code, w.Calldata, runs)
+	if err != nil {
+		return nil, err
+	}
+	baseline, err := measure(runner, env, w.Baseline, w.Calldata, runs)
+	if err != nil {
+		return nil, err
+	}
+	result.GasUsed, result.BaselineGasUsed = code.GasUsed, baseline.GasUsed
+	result.TimeNs, result.BaselineTimeNs = code.Elapsed.Nanoseconds(), baseline.Elapsed.Nanoseconds()
+
+	// The per operation values of failed workloads are meaningless.
+	switch {
+	case code.Err != "":
+		result.Error = code.Err
+		return result, nil
+	case baseline.Err != "":
+		result.Error = "baseline: " + baseline.Err
+		return result, nil
+	}
+	iterations := float64(w.Params.Iterations)
+	result.GasPerOp = (float64(code.GasUsed)-float64(baseline.GasUsed))/iterations + float64(w.replacementGas())
+	result.NsPerOp = float64(code.Elapsed-baseline.Elapsed) / iterations
+	return result, nil
+}
+
+// measure runs the code the given number of times and returns the execution
+// with the shortest time.
+func measure(runner Runner, env *Env, code, calldata []byte, runs int) (*Execution, error) {
+	if runs <= 0 {
+		return nil, fmt.Errorf("the number of runs must be positive")
+	}
+	tx, err := env.transaction(calldata)
+	if err != nil {
+		return nil, err
+	}
+	pre := env.preState(code)
+
+	var fastest *Execution
+	for i := 0; i < runs; i++ {
+		execution, err := runner.Run(env, pre, tx)
+		if err != nil {
+			return nil, err
+		}
+		if fastest == nil || execution.Elapsed < fastest.Elapsed {
+			fastest = execution
+		}
+	}
+	return fastest, nil
+}
+
+// WriteCSV writes the results as CSV with a header row.
+func WriteCSV(out io.Writer, results []*Result) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(resultColumns); err != nil {
+		return err
+	}
+	for _, r := range results {
+		record := []string{
+			r.Runner, r.Fork, r.Opcode,
+			strconv.Itoa(r.CalldataLength), strconv.Itoa(r.CalldataNonzero), strconv.Itoa(r.MemorySize),
+			strconv.FormatBool(r.WarmAccess), strconv.Itoa(r.Iterations),
+			strconv.FormatUint(r.GasUsed, 10), strconv.FormatUint(r.BaselineGasUsed, 10),
+			strconv.FormatFloat(r.GasPerOp, 'f', -1, 64),
+			strconv.FormatInt(r.TimeNs, 10), strconv.FormatInt(r.BaselineTimeNs, 10),
+			strconv.FormatFloat(r.NsPerOp, 'f', 2, 64),
+			r.Error,
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
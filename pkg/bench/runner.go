@@ -0,0 +1,313 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+var (
+	// benchKey signs the workload transactions.
+	benchKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+
+	// benchContract is the address the workload code is deployed to.
+	benchContract = common.HexToAddress("0x00000000000000000000000000000000000be7c4")
+)
+
+// t8nForks maps the execution specs fork names onto the names of the t8n
+// command line interface where they differ.
+var t8nForks = map[string]string{
+	"TangerineWhistle": "EIP150",
+	"SpuriousDragon":   "EIP158",
+	"Constantinople":   "ConstantinopleFix",
+}
+
+// Env is the block environment the workloads are executed in.
+type Env struct {
+	Fork   string
+	Config *params.ChainConfig
+	Header *types.Header
+}
+
+// NewEnv returns the environment of the first block of a chain with the given
+// fork active from genesis.
+func NewEnv(fork string) (*Env, error) {
+	index, err := forks.Index(fork)
+	if err != nil {
+		return nil, err
+	}
+	config := &params.ChainConfig{ChainID: big.NewInt(1)}
+	if err := forks.Activate(config, fork); err != nil {
+		return nil, err
+	}
+	header := &types.Header{
+		Coinbase:   common.HexToAddress("0x00000000000000000000000000000000c0ffee00"),
+		Number:     big.NewInt(1),
+		GasLimit:   2 * params.MaxTxGas,
+		Time:       1000,
+		Difficulty: big.NewInt(0x20000),
+	}
+	if config.IsLondon(header.Number) {
+		header.BaseFee = big.NewInt(7)
+	}
+	if config.TerminalTotalDifficulty != nil {
+		header.Difficulty = new(big.Int)
+		header.MixDigest = common.HexToHash("0x01")
+	}
+	if config.IsCancun(header.Number, header.Time) {
+		excess := uint64(0)
+		header.ExcessBlobGas = &excess
+		header.ParentBeaconRoot = new(common.Hash)
+	}
+	return &Env{Fork: forks.Names()[index], Config: config, Header: header}, nil
+}
+
+// transaction returns the signed transaction calling the workload contract
+// with the given calldata.
+func (env *Env) transaction(calldata []byte) (*types.Transaction, error) {
+	tx := types.NewTx(&types.LegacyTx{
+		GasPrice: big.NewInt(7),
+		Gas:      params.MaxTxGas,
+		To:       &benchContract,
+		Data:     calldata,
+	})
+	return types.SignTx(tx, types.MakeSigner(env.Config, env.Header.Number, env.Header.Time), benchKey)
+}
+
+// preState returns the state the workload code is executed on: the contract,
+// the funded sender and the system contracts of the fork.
+func (env *Env) preState(code []byte) types.GenesisAlloc {
+	pre := gen.SystemContractAlloc(env.Config)
+	pre[benchContract] = types.Account{Code: code, Nonce: 1, Balance: new(big.Int)}
+	pre[crypto.PubkeyToAddress(benchKey.PublicKey)] = types.Account{Balance: big.NewInt(params.Ether)}
+	return pre
+}
+
+// Execution is the outcome of running a workload transaction.
+type Execution struct {
+	GasUsed uint64
+	Elapsed time.Duration
+	Err     string // failure of the transaction, empty if it succeeded
+}
+
+// Runner executes a transaction on a pre-state, the way a t8n tool does.
+type Runner interface {
+	Name() string
+	Run(env *Env, pre types.GenesisAlloc, tx *types.Transaction) (*Execution, error)
+}
+
+// InProcess runs the workloads in the go-ethereum EVM linked into the binary.
+// Only the execution of the transaction is timed.
+type InProcess struct{}
+
+// Name implements Runner.
+func (InProcess) Name() string { return "geth" }
+
+// Run implements Runner.
+func (InProcess) Run(env *Env, pre types.GenesisAlloc, tx *types.Transaction) (*Execution, error) {
+	db := state.NewDatabaseForTesting()
+	statedb, err := state.New(types.EmptyRootHash, db)
+	if err != nil {
+		return nil, err
+	}
+	for addr, account := range pre {
+		statedb.SetBalance(addr, uint256.MustFromBig(account.Balance), tracing.BalanceChangeUnspecified)
+		statedb.SetNonce(addr, account.Nonce, tracing.NonceChangeUnspecified)
+		statedb.SetCode(addr, account.Code, tracing.CodeChangeUnspecified)
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	// Commit the pre-state so that it is the original state of the slots.
+	root, err := statedb.Commit(0, true, false)
+	if err != nil {
+		return nil, err
+	}
+	if statedb, err = state.New(root, db); err != nil {
+		return nil, err
+	}
+	header := env.Header
+	msg, err := core.TransactionToMessage(tx, types.MakeSigner(env.Config, header.Number, header.Time), header.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+	blockContext := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		Coinbase:    header.Coinbase,
+		BlockNumber: header.Number,
+		Time:        header.Time,
+		Difficulty:  header.Difficulty,
+		BaseFee:     header.BaseFee,
+		GasLimit:    header.GasLimit,
+	}
+	if header.Difficulty.Sign() == 0 {
+		blockContext.Random = &header.MixDigest
+	}
+	if header.ExcessBlobGas != nil {
+		blockContext.BlobBaseFee = eip4844.CalcBlobFee(env.Config, header)
+	}
+	evm := vm.NewEVM(blockContext, statedb, env.Config, vm.Config{})
+	evm.SetTxContext(core.NewEVMTxContext(msg))
+	statedb.SetTxContext(tx.Hash(), 0)
+
+	start := time.Now()
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(header.GasLimit))
+	elapsed := time.Since(start)
+	if err != nil {
+		return &Execution{Elapsed: elapsed, Err: err.Error()}, nil
+	}
+	execution := &Execution{GasUsed: result.UsedGas, Elapsed: elapsed}
+	if result.Err != nil {
+		execution.Err = result.Err.Error()
+	}
+	return execution, nil
+}
+
+// T8n runs the workloads with an external state transition tool implementing
+// the t8n command line interface, e.g. "evm t8n" of go-ethereum or
+// "ethereum-spec-evm t8n" of the execution specs. The whole invocation is
+// timed, the process startup cancels out against the baseline.
+type T8n struct {
+	Command []string
+}
+
+// Name implements Runner.
+func (t *T8n) Name() string { return strings.Join(t.Command, " ") }
+
+// t8nEnv is the env.json input of a t8n invocation.
+type t8nEnv struct {
+	Coinbase         common.Address    `json:"currentCoinbase"`
+	GasLimit         hexutil.Uint64    `json:"currentGasLimit"`
+	Number           hexutil.Uint64    `json:"currentNumber"`
+	Timestamp        hexutil.Uint64    `json:"currentTimestamp"`
+	Difficulty       *hexutil.Big      `json:"currentDifficulty,omitempty"`
+	Random           *common.Hash      `json:"currentRandom,omitempty"`
+	BaseFee          *hexutil.Big      `json:"currentBaseFee,omitempty"`
+	ExcessBlobGas    *hexutil.Uint64   `json:"currentExcessBlobGas,omitempty"`
+	ParentBeaconRoot *common.Hash      `json:"parentBeaconBlockRoot,omitempty"`
+	Withdrawals      json.RawMessage   `json:"withdrawals,omitempty"`
+	BlockHashes      map[string]string `json:"blockHashes"`
+}
+
+// t8nResult is the part of the result.json output of a t8n invocation
+// describing the transaction outcome.
+type t8nResult struct {
+	Receipts []struct {
+		Status  hexutil.Uint64 `json:"status"`
+		GasUsed hexutil.Uint64 `json:"gasUsed"`
+	} `json:"receipts"`
+	Rejected []struct {
+		Error string `json:"error"`
+	} `json:"rejected"`
+}
+
+// Run implements Runner.
+func (t *T8n) Run(env *Env, pre types.GenesisAlloc, tx *types.Transaction) (*Execution, error) {
+	if len(t.Command) == 0 {
+		return nil, fmt.Errorf("empty t8n command")
+	}
+	dir, err := os.MkdirTemp("", "bench-t8n")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	header := env.Header
+	input := t8nEnv{
+		Coinbase:         header.Coinbase,
+		GasLimit:         hexutil.Uint64(header.GasLimit),
+		Number:           hexutil.Uint64(header.Number.Uint64()),
+		Timestamp:        hexutil.Uint64(header.Time),
+		BaseFee:          (*hexutil.Big)(header.BaseFee),
+		ExcessBlobGas:    (*hexutil.Uint64)(header.ExcessBlobGas),
+		ParentBeaconRoot: header.ParentBeaconRoot,
+		BlockHashes:      map[string]string{"0": common.Hash{}.Hex()},
+	}
+	if header.Difficulty.Sign() == 0 {
+		input.Random = &header.MixDigest
+	} else {
+		input.Difficulty = (*hexutil.Big)(header.Difficulty)
+	}
+	if env.Config.IsShanghai(header.Number, header.Time) {
+		input.Withdrawals = json.RawMessage("[]")
+	}
+	files := map[string]interface{}{
+		"alloc.json": pre,
+		"env.json":   input,
+		"txs.json":   []*types.Transaction{tx},
+	}
+	for name, v := range files {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return nil, err
+		}
+	}
+	fork := env.Fork
+	if name, ok := t8nForks[fork]; ok {
+		fork = name
+	}
+	args := append(append([]string{}, t.Command[1:]...),
+		"--input.alloc", filepath.Join(dir, "alloc.json"),
+		"--input.env", filepath.Join(dir, "env.json"),
+		"--input.txs", filepath.Join(dir, "txs.json"),
+		"--state.fork", fork,
+		"--state.chainid", env.Config.ChainID.String(),
+		"--output.basedir", dir,
+		"--output.result", "result.json",
+		"--output.alloc", "post.json",
+	)
+	var stderr bytes.Buffer
+	cmd := exec.Command(t.Command[0], args...)
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	elapsed := time.Since(start)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v: %s", t.Name(), err, strings.TrimSpace(stderr.String()))
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "result.json"))
+	if err != nil {
+		return nil, err
+	}
+	var result t8nResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("%s: invalid result: %v", t.Name(), err)
+	}
+	switch {
+	case len(result.Rejected) > 0:
+		return &Execution{Elapsed: elapsed, Err: result.Rejected[0].Error}, nil
+	case len(result.Receipts) != 1:
+		return nil, fmt.Errorf("%s: %d receipts for one transaction", t.Name(), len(result.Receipts))
+	}
+	execution := &Execution{GasUsed: uint64(result.Receipts[0].GasUsed), Elapsed: elapsed}
+	if result.Receipts[0].Status != hexutil.Uint64(types.ReceiptStatusSuccessful) {
+		execution.Err = "execution failed"
+	}
+	return execution, nil
+}
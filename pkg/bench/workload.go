@@ -0,0 +1,251 @@
+package bench
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// operand is the kind of a stack input of a benchmarked operation.
+type operand int
+
+const (
+	operandValue    operand = iota // a full 256 bit value
+	operandSmall                   // a small index, shift or size
+	operandZero                    // a zero offset or block number
+	operandSize                    // the memory_size parameter
+	operandCalldata                // the calldata_length parameter
+	operandAddress                 // an account, cold or warm
+	operandSlot                    // a storage slot, cold or warm
+)
+
+// operation describes how an opcode is driven: its stack inputs, the first
+// one being the top of the stack, and the number of its stack outputs.
+type operation struct {
+	inputs  []operand
+	outputs int
+}
+
+var (
+	nullary = operation{outputs: 1}
+	unary   = operation{inputs: []operand{operandValue}, outputs: 1}
+	binary  = operation{inputs: []operand{operandValue, operandValue}, outputs: 1}
+	ternary = operation{inputs: []operand{operandValue, operandValue, operandValue}, outputs: 1}
+	shift   = operation{inputs: []operand{operandSmall, operandValue}, outputs: 1}
+	ranged  = operation{inputs: []operand{operandZero, operandZero, operandSize}}
+)
+
+// operations lists the supported opcodes.
+var operations = map[vm.OpCode]operation{
+	vm.ADD: binary, vm.MUL: binary, vm.SUB: binary, vm.DIV: binary,
+	vm.SDIV: binary, vm.MOD: binary, vm.SMOD: binary, vm.ADDMOD: ternary,
+	vm.MULMOD: ternary, vm.EXP: binary, vm.SIGNEXTEND: shift,
+	vm.LT: binary, vm.GT: binary, vm.SLT: binary, vm.SGT: binary, vm.EQ: binary,
+	vm.ISZERO: unary, vm.AND: binary, vm.OR: binary, vm.XOR: binary,
+	vm.NOT: unary, vm.BYTE: shift, vm.SHL: shift, vm.SHR: shift, vm.SAR: shift,
+
+	vm.KECCAK256: {inputs: []operand{operandZero, operandSize}, outputs: 1},
+
+	vm.ADDRESS: nullary, vm.BALANCE: {inputs: []operand{operandAddress}, outputs: 1},
+	vm.ORIGIN: nullary, vm.CALLER: nullary, vm.CALLVALUE: nullary,
+	vm.CALLDATALOAD: {inputs: []operand{operandZero}, outputs: 1},
+	vm.CALLDATASIZE: nullary, vm.CALLDATACOPY: {inputs: []operand{operandZero, operandZero, operandCalldata}},
+	vm.CODESIZE: nullary, vm.CODECOPY: ranged, vm.GASPRICE: nullary,
+	vm.EXTCODESIZE:    {inputs: []operand{operandAddress}, outputs: 1},
+	vm.EXTCODECOPY:    {inputs: []operand{operandAddress, operandZero, operandZero, operandSize}},
+	vm.RETURNDATASIZE: nullary,
+	vm.EXTCODEHASH:    {inputs: []operand{operandAddress}, outputs: 1},
+
+	vm.BLOCKHASH: {inputs: []operand{operandZero}, outputs: 1},
+	vm.COINBASE:  nullary, vm.TIMESTAMP: nullary, vm.NUMBER: nullary,
+	vm.DIFFICULTY: nullary, vm.GASLIMIT: nullary, vm.CHAINID: nullary,
+	vm.SELFBALANCE: nullary, vm.BASEFEE: nullary,
+	vm.BLOBHASH: {inputs: []operand{operandZero}, outputs: 1}, vm.BLOBBASEFEE: nullary,
+
+	vm.MLOAD:   {inputs: []operand{operandZero}, outputs: 1},
+	vm.MSTORE:  {inputs: []operand{operandZero, operandValue}},
+	vm.MSTORE8: {inputs: []operand{operandZero, operandValue}},
+	vm.SLOAD:   {inputs: []operand{operandSlot}, outputs: 1},
+	vm.SSTORE:  {inputs: []operand{operandSlot, operandValue}},
+	vm.PC:      nullary, vm.MSIZE: nullary, vm.GAS: nullary,
+	vm.TLOAD:  {inputs: []operand{operandSlot}, outputs: 1},
+	vm.TSTORE: {inputs: []operand{operandSlot, operandValue}},
+	vm.MCOPY:  ranged,
+	vm.PUSH0:  nullary,
+	vm.DUP1:   {inputs: []operand{operandValue}, outputs: 2},
+	vm.SWAP1:  {inputs: []operand{operandValue, operandValue}, outputs: 2},
+
+	vm.LOG0: {inputs: []operand{operandZero, operandSize}},
+	vm.LOG1: {inputs: []operand{operandZero, operandSize, operandValue}},
+	vm.LOG2: {inputs: []operand{operandZero, operandSize, operandValue, operandValue}},
+	vm.LOG3: {inputs: []operand{operandZero, operandSize, operandValue, operandValue, operandValue}},
+	vm.LOG4: {inputs: []operand{operandZero, operandSize, operandValue, operandValue, operandValue, operandValue}},
+}
+
+// coldAccounts is the first of the accounts accessed by cold workloads, one
+// per iteration. None of them exists.
+var coldAccounts = common.HexToAddress("0x00000000000000000000000000000000000c0000")
+
+// Workload is the generated code benchmarking an opcode. Code executes the
+// opcode Iterations times, Baseline is the same code with every execution of
+// the opcode replaced by popping its inputs and pushing as many outputs with
+// PC, so that the cost of the opcode is the difference of the two less the
+// known cost of the replacement.
+type Workload struct {
+	Opcode   string
+	Params   Params
+	Code     []byte
+	Baseline []byte
+	Calldata []byte
+
+	inputs, outputs int
+}
+
+// Generate builds the workload of an opcode for the given parameters.
+func Generate(name string, p Params) (*Workload, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	op := vm.StringToOp(strings.ToUpper(name))
+	operation, ok := operations[op]
+	if !ok {
+		return nil, fmt.Errorf("unsupported opcode %q, supported opcodes: %s", name, strings.Join(Opcodes(), ", "))
+	}
+	prologue := burnCalldataFloor(p)
+	if p.MemorySize > 0 {
+		prologue = append(prologue, push(big.NewInt(0))...)
+		prologue = append(prologue, push(big.NewInt(int64(p.MemorySize-1)))...)
+		prologue = append(prologue, byte(vm.MSTORE8))
+	}
+	// Warm workloads execute the opcode once up front, warming the accessed
+	// account or slot and leaving the stored values in place, so that every
+	// measured execution hits the steady state.
+	if p.WarmAccess && operation.accesses() {
+		prologue = append(prologue, operation.execute(op, p, 0)...)
+	}
+	w := &Workload{
+		Opcode:   op.String(),
+		Params:   p,
+		Code:     append([]byte{}, prologue...),
+		Baseline: append([]byte{}, prologue...),
+		Calldata: make([]byte, p.CalldataLength),
+		inputs:   len(operation.inputs),
+		outputs:  operation.outputs,
+	}
+	for i := range w.Calldata[:p.CalldataNonzero] {
+		w.Calldata[i] = 0xff
+	}
+	for i := 0; i < p.Iterations; i++ {
+		w.Code = append(w.Code, operation.execute(op, p, i)...)
+
+		w.Baseline = append(w.Baseline, operation.pushes(p, i)...)
+		w.Baseline = append(w.Baseline, repeat(vm.POP, len(operation.inputs))...)
+		w.Baseline = append(w.Baseline, repeat(vm.PC, operation.outputs)...)
+		w.Baseline = append(w.Baseline, repeat(vm.POP, operation.outputs)...)
+	}
+	w.Code = append(w.Code, byte(vm.STOP))
+	w.Baseline = append(w.Baseline, byte(vm.STOP))
+	return w, nil
+}
+
+// burnCalldataFloor returns a loop spending at least the EIP-7623 calldata
+// floor of the parameters in excess of the standard calldata cost. Without
+// it the gas used of both the workload and the baseline could be the floor,
+// hiding the cost of the opcode.
+func burnCalldataFloor(p Params) []byte {
+	zero := p.CalldataLength - p.CalldataNonzero
+	excess := 6*zero + 24*p.CalldataNonzero
+	if excess == 0 {
+		return nil
+	}
+	// Every round of the loop costs at least 26 gas. The loop is placed at
+	// the start of the code, so the offset of its JUMPDEST fits into a PUSH1.
+	code := push(big.NewInt(int64(excess/26 + 1)))
+	dest := len(code)
+	code = append(code, byte(vm.JUMPDEST), byte(vm.PUSH1), 1, byte(vm.SWAP1), byte(vm.SUB), byte(vm.DUP1))
+	code = append(code, byte(vm.PUSH1), byte(dest), byte(vm.JUMPI), byte(vm.POP))
+	return code
+}
+
+// accesses reports whether the operation accesses accounts or storage slots.
+func (o operation) accesses() bool {
+	for _, in := range o.inputs {
+		if in == operandAddress || in == operandSlot {
+			return true
+		}
+	}
+	return false
+}
+
+// pushes returns the code pushing the inputs of the operation in the given
+// iteration.
+func (o operation) pushes(p Params, iteration int) []byte {
+	var code []byte
+	for i := len(o.inputs) - 1; i >= 0; i-- {
+		code = append(code, push(operandValueOf(o.inputs[i], p, iteration))...)
+	}
+	return code
+}
+
+// execute returns the code executing the operation once in the given
+// iteration, popping its outputs.
+func (o operation) execute(op vm.OpCode, p Params, iteration int) []byte {
+	code := append(o.pushes(p, iteration), byte(op))
+	return append(code, repeat(vm.POP, o.outputs)...)
+}
+
+// replacementGas returns the gas the baseline spends per iteration in place
+// of the opcode: a POP per input and a PC per output, 2 gas each.
+func (w *Workload) replacementGas() uint64 {
+	return 2 * uint64(w.inputs+w.outputs)
+}
+
+// operandValueOf returns the value of an operand in the given iteration.
+// Accounts and slots differ per iteration unless they are warm.
+func operandValueOf(in operand, p Params, iteration int) *big.Int {
+	switch in {
+	case operandValue:
+		return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	case operandSmall:
+		return big.NewInt(3)
+	case operandSize:
+		return big.NewInt(int64(p.MemorySize))
+	case operandCalldata:
+		return big.NewInt(int64(p.CalldataLength))
+	case operandAddress:
+		addr := new(big.Int).SetBytes(coldAccounts[:])
+		if !p.WarmAccess {
+			addr.Add(addr, big.NewInt(int64(iteration)))
+		}
+		return addr
+	case operandSlot:
+		if p.WarmAccess {
+			return big.NewInt(1)
+		}
+		return big.NewInt(int64(iteration) + 1)
+	default:
+		return new(big.Int)
+	}
+}
+
+// push returns the shortest PUSH instruction of a value. Zero is pushed with
+// PUSH1 rather than PUSH0 so that the workloads run on every fork.
+func push(v *big.Int) []byte {
+	data := v.Bytes()
+	if len(data) == 0 {
+		data = []byte{0}
+	}
+	return append([]byte{byte(vm.PUSH1) + byte(len(data)-1)}, data...)
+}
+
+// repeat returns n copies of an opcode.
+func repeat(op vm.OpCode, n int) []byte {
+	code := make([]byte, n)
+	for i := range code {
+		code[i] = byte(op)
+	}
+	return code
+}
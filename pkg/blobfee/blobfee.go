@@ -0,0 +1,217 @@
+// Package blobfee implements the blob fee market of EIP-4844 as the execution
+// specs define it: the excess blob gas carried from block to block, the blob
+// base fee derived from it by the fake exponential, the blob parameters of
+// each fork from the EIP-7892 blob schedule and, since Osaka, the reserve
+// price of EIP-7918. Simulate runs the market over a sequence of blocks with
+// a given blob usage.
+package blobfee
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// The blob fee constants of the specs.
+const (
+	GasPerBlob     = 1 << 17 // blob gas used by a blob (EIP-4844)
+	MinBlobBaseFee = 1       // minimum blob base fee (EIP-4844)
+	BlobBaseCost   = 1 << 13 // execution gas a blob is priced at least, the reserve price (EIP-7918)
+)
+
+// Params are the blob parameters in effect at a block.
+type Params struct {
+	Fork           string `json:"fork"`
+	Target         int    `json:"target"`
+	Max            int    `json:"max"`
+	UpdateFraction uint64 `json:"baseFeeUpdateFraction"`
+	ReservePrice   bool   `json:"reservePrice"` // EIP-7918 is active
+}
+
+// ParamsAt returns the blob parameters of the chain config at a timestamp:
+// those of the latest fork with a blob schedule entry. Fork is the latest
+// fork active at the timestamp, which need not change the blob parameters.
+func ParamsAt(config *params.ChainConfig, time uint64) (*Params, error) {
+	var p *Params
+	fork := ""
+	for _, a := range forks.Timeline(config) {
+		if a.Time == nil || *a.Time > time {
+			continue
+		}
+		fork = a.Fork
+		if a.Blob != nil {
+			reserve := p != nil && p.ReservePrice
+			p = &Params{Target: a.Blob.Target, Max: a.Blob.Max, UpdateFraction: a.Blob.UpdateFraction, ReservePrice: reserve}
+		}
+		if p != nil && a.Fork == "Osaka" {
+			p.ReservePrice = true
+		}
+	}
+	if p == nil {
+		return nil, fmt.Errorf("no blob schedule entry is active at time %d", time)
+	}
+	if p.UpdateFraction == 0 {
+		return nil, fmt.Errorf("%s has no blob base fee update fraction", fork)
+	}
+	p.Fork = fork
+	return p, nil
+}
+
+// TargetBlobGas returns the blob gas target for a block.
+func (p *Params) TargetBlobGas() uint64 {
+	return uint64(p.Target) * GasPerBlob
+}
+
+// MaxBlobGas returns the blob gas limit for a block.
+func (p *Params) MaxBlobGas() uint64 {
+	return uint64(p.Max) * GasPerBlob
+}
+
+// BaseFee returns the blob base fee at the excess blob gas.
+func (p *Params) BaseFee(excessBlobGas uint64) *big.Int {
+	return FakeExponential(big.NewInt(MinBlobBaseFee), new(big.Int).SetUint64(excessBlobGas), new(big.Int).SetUint64(p.UpdateFraction))
+}
+
+// ExcessBlobGas returns the excess blob gas of a block with the parameters
+// of the block, from the excess blob gas, blob gas used and base fee of its
+// parent. Reserve reports whether the EIP-7918 reserve price took effect,
+// charging only the share above the target of the blob gas used.
+func (p *Params) ExcessBlobGas(parentExcess, parentBlobGasUsed uint64, parentBaseFee *big.Int) (excess uint64, reserve bool) {
+	if parentExcess+parentBlobGasUsed < p.TargetBlobGas() {
+		return 0, false
+	}
+	if p.ReservePrice && parentBaseFee != nil {
+		reservePrice := new(big.Int).Mul(big.NewInt(BlobBaseCost), parentBaseFee)
+		blobPrice := new(big.Int).Mul(big.NewInt(GasPerBlob), p.BaseFee(parentExcess))
+		if reservePrice.Cmp(blobPrice) > 0 {
+			return parentExcess + parentBlobGasUsed*uint64(p.Max-p.Target)/uint64(p.Max), true
+		}
+	}
+	return parentExcess + parentBlobGasUsed - p.TargetBlobGas(), false
+}
+
+// FakeExponential approximates factor * e ** (numerator / denominator) with
+// the Taylor expansion of the specs.
+func FakeExponential(factor, numerator, denominator *big.Int) *big.Int {
+	var (
+		i           = int64(1)
+		output      = new(big.Int)
+		accumulator = new(big.Int).Mul(factor, denominator)
+	)
+	for accumulator.Sign() > 0 {
+		output.Add(output, accumulator)
+		accumulator.Mul(accumulator, numerator)
+		accumulator.Div(accumulator, new(big.Int).Mul(denominator, big.NewInt(i)))
+		i++
+	}
+	return output.Div(output, denominator)
+}
+
+// Usage is the blob usage of a block: a number of blobs, or the target or
+// the maximum of the block's fork.
+type Usage struct {
+	Blobs    int
+	Relative string // "target" or "max", for a usage following the fork
+}
+
+// Of returns the number of blobs of the usage under the parameters.
+func (u Usage) Of(p *Params) int {
+	switch u.Relative {
+	case "target":
+		return p.Target
+	case "max":
+		return p.Max
+	}
+	return u.Blobs
+}
+
+// ParseUsage parses a comma separated blob usage sequence. Each entry is a
+// number of blobs, "target" or "max", optionally repeated by a "*count"
+// suffix: "max*100,0*50,target" is 100 full blocks, 50 empty ones and a
+// block at the target.
+func ParseUsage(s string) ([]Usage, error) {
+	var usage []Usage
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		value, repeat := entry, 1
+		if v, count, ok := strings.Cut(entry, "*"); ok {
+			n, err := strconv.Atoi(count)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid repetition %q in blob usage %q", count, entry)
+			}
+			value, repeat = v, n
+		}
+		var u Usage
+		switch value {
+		case "target", "max":
+			u.Relative = value
+		default:
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid blob usage %q, want a number of blobs, target or max", entry)
+			}
+			u.Blobs = n
+		}
+		for i := 0; i < repeat; i++ {
+			usage = append(usage, u)
+		}
+	}
+	return usage, nil
+}
+
+// Start is the first block of a simulation. The execution base fee stays
+// constant, it only matters for the EIP-7918 reserve price.
+type Start struct {
+	Number        uint64
+	Time          uint64
+	BlockTime     uint64
+	ExcessBlobGas uint64
+	BaseFee       *big.Int
+}
+
+// Block is a simulated block.
+type Block struct {
+	Number        uint64   `json:"number"`
+	Time          uint64   `json:"timestamp"`
+	Params        *Params  `json:"params"`
+	Blobs         int      `json:"blobs"`
+	BlobGasUsed   uint64   `json:"blobGasUsed"`
+	ExcessBlobGas uint64   `json:"excessBlobGas"`
+	BlobBaseFee   *big.Int `json:"blobBaseFee"`
+	ReservePrice  bool     `json:"reservePrice,omitempty"` // the excess blob gas was reduced by EIP-7918
+}
+
+// Simulate runs the blob fee market over a block per usage entry, starting
+// with the given block.
+func Simulate(config *params.ChainConfig, start *Start, usage []Usage) ([]*Block, error) {
+	if start.BlockTime == 0 && len(usage) > 1 {
+		return nil, errors.New("zero block time")
+	}
+	blocks := make([]*Block, len(usage))
+	for i, u := range usage {
+		b := &Block{Number: start.Number + uint64(i), Time: start.Time + uint64(i)*start.BlockTime}
+		p, err := ParamsAt(config, b.Time)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", b.Number, err)
+		}
+		b.Params, b.Blobs = p, u.Of(p)
+		if b.Blobs > p.Max {
+			return nil, fmt.Errorf("block %d: %d blobs exceed the maximum %d of %s", b.Number, b.Blobs, p.Max, p.Fork)
+		}
+		b.BlobGasUsed = uint64(b.Blobs) * GasPerBlob
+		if i == 0 {
+			b.ExcessBlobGas = start.ExcessBlobGas
+		} else {
+			parent := blocks[i-1]
+			b.ExcessBlobGas, b.ReservePrice = p.ExcessBlobGas(parent.ExcessBlobGas, parent.BlobGasUsed, start.BaseFee)
+		}
+		b.BlobBaseFee = p.BaseFee(b.ExcessBlobGas)
+		blocks[i] = b
+	}
+	return blocks, nil
+}
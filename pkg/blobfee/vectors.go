@@ -0,0 +1,207 @@
+package blobfee
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// VectorForks are the forks the vectors are generated for, with the mainnet
+// blob parameters.
+var VectorForks = []string{"Cancun", "Prague", "Osaka", "BPO1", "BPO2"}
+
+// ExcessVector is an excess blob gas computation: the parent values and the
+// excess blob gas of the child under the parameters of the child's fork.
+type ExcessVector struct {
+	Name                string         `json:"name"`
+	Params              *Params        `json:"params"`
+	ParentExcessBlobGas hexutil.Uint64 `json:"parentExcessBlobGas"`
+	ParentBlobGasUsed   hexutil.Uint64 `json:"parentBlobGasUsed"`
+	ParentBaseFee       *hexutil.Big   `json:"parentBaseFeePerGas"`
+	ExcessBlobGas       hexutil.Uint64 `json:"excessBlobGas"`
+	ReservePrice        bool           `json:"reservePrice"`
+}
+
+// FeeVector is a blob base fee computation from the excess blob gas.
+type FeeVector struct {
+	Name          string         `json:"name"`
+	Params        *Params        `json:"params"`
+	ExcessBlobGas hexutil.Uint64 `json:"excessBlobGas"`
+	BlobBaseFee   *hexutil.Big   `json:"blobBaseFee"`
+}
+
+// ScheduleVector is a simulation across the blob forks of a chain config.
+type ScheduleVector struct {
+	Config  *params.ChainConfig `json:"config"`
+	BaseFee *hexutil.Big        `json:"baseFeePerGas"`
+	Blocks  []*Block            `json:"blocks"`
+}
+
+// ForkConfig returns a chain config with the fork and its predecessors active
+// from genesis and the mainnet blob parameters.
+func ForkConfig(fork string) (*params.ChainConfig, error) {
+	config := *params.MainnetChainConfig
+	config.BlobScheduleConfig = nil
+	if err := forks.Activate(&config, fork); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Vectors generates the excess blob gas and blob base fee vectors of a fork:
+// blob gas used below, at and above the target and at the maximum, excess
+// blob gas draining to zero, the execution base fees at which the EIP-7918
+// reserve price starts to apply, which are plain EIP-4844 updates before
+// Osaka, and the smallest excess blob gas reaching blob base fees from 2 wei
+// up to beyond 64 bits.
+func Vectors(fork string) ([]*ExcessVector, []*FeeVector, error) {
+	config, err := ForkConfig(fork)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := ParamsAt(config, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		target = p.TargetBlobGas()
+		max    = p.MaxBlobGas()
+		gwei   = big.NewInt(params.GWei)
+		// The excess blob gas at which the blob base fee reaches 100 wei, and
+		// the execution base fee at which the reserve price equals the blob
+		// price there.
+		priced   = minExcess(p, big.NewInt(100))
+		boundary = new(big.Int).Div(new(big.Int).Mul(big.NewInt(GasPerBlob), p.BaseFee(priced)), big.NewInt(BlobBaseCost))
+	)
+	excessCases := []struct {
+		name               string
+		parentExcess, used uint64
+		parentBaseFee      *big.Int
+	}{
+		{"empty_parent", 0, 0, gwei},
+		{"below_target", 0, target - GasPerBlob, gwei},
+		{"at_target", 0, target, gwei},
+		{"above_target", 0, target + GasPerBlob, gwei},
+		{"at_max", 0, max, gwei},
+		{"sum_below_target", target - GasPerBlob - 1, GasPerBlob, gwei},
+		{"sum_at_target", target - GasPerBlob, GasPerBlob, gwei},
+		{"draining", 3 * target, 0, big.NewInt(1)},
+		{"drained", target - 1, 0, big.NewInt(1)},
+		{"reserve_price_equal", priced, target, boundary},
+		{"reserve_price_above", priced, target, new(big.Int).Add(boundary, big.NewInt(1))},
+		{"reserve_price_max", priced, max, new(big.Int).Add(boundary, big.NewInt(1))},
+		{"reserve_price_rounding", target, GasPerBlob, gwei},
+	}
+	var excess []*ExcessVector
+	for _, c := range excessCases {
+		value, reserve := p.ExcessBlobGas(c.parentExcess, c.used, c.parentBaseFee)
+		excess = append(excess, &ExcessVector{
+			Name:                c.name,
+			Params:              p,
+			ParentExcessBlobGas: hexutil.Uint64(c.parentExcess),
+			ParentBlobGasUsed:   hexutil.Uint64(c.used),
+			ParentBaseFee:       (*hexutil.Big)(c.parentBaseFee),
+			ExcessBlobGas:       hexutil.Uint64(value),
+			ReservePrice:        reserve,
+		})
+	}
+
+	feeCases := map[string]uint64{
+		"zero_excess":     0,
+		"update_fraction": p.UpdateFraction,
+	}
+	for name, fee := range map[string]*big.Int{
+		"2_wei":    big.NewInt(2),
+		"10_wei":   big.NewInt(10),
+		"1_gwei":   gwei,
+		"2_pow_64": new(big.Int).Lsh(big.NewInt(1), 64),
+	} {
+		e := minExcess(p, fee)
+		feeCases["below_"+name] = e - 1
+		feeCases["at_"+name] = e
+	}
+	var fees []*FeeVector
+	for name, e := range feeCases {
+		fees = append(fees, &FeeVector{Name: name, Params: p, ExcessBlobGas: hexutil.Uint64(e), BlobBaseFee: (*hexutil.Big)(p.BaseFee(e))})
+	}
+	sort.Slice(fees, func(i, j int) bool {
+		if fees[i].ExcessBlobGas != fees[j].ExcessBlobGas {
+			return fees[i].ExcessBlobGas < fees[j].ExcessBlobGas
+		}
+		return fees[i].Name < fees[j].Name
+	})
+	return excess, fees, nil
+}
+
+// minExcess returns the smallest excess blob gas whose blob base fee is at
+// least the given fee.
+func minExcess(p *Params, fee *big.Int) uint64 {
+	lo, hi := uint64(0), p.UpdateFraction
+	for p.BaseFee(hi).Cmp(fee) < 0 {
+		lo, hi = hi, 2*hi
+	}
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if p.BaseFee(mid).Cmp(fee) < 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// Schedule generates a simulation across the mainnet blob forks, activated
+// ten blocks apart, filling the first half of the blocks of each fork to
+// the maximum and leaving the rest empty at an execution base fee of 1 gwei.
+func Schedule() (*ScheduleVector, error) {
+	const (
+		blockTime     = 12
+		blocksPerFork = 10
+	)
+	config, err := ForkConfig("Shanghai")
+	if err != nil {
+		return nil, err
+	}
+	var usage []Usage
+	for i, fork := range VectorForks {
+		time := uint64(i * blocksPerFork * blockTime)
+		if err := setForkTime(config, fork, time); err != nil {
+			return nil, err
+		}
+		for j := 0; j < blocksPerFork; j++ {
+			if j < blocksPerFork/2 {
+				usage = append(usage, Usage{Relative: "max"})
+			} else {
+				usage = append(usage, Usage{})
+			}
+		}
+	}
+	forks.FillBlobSchedule(config, true)
+	baseFee := big.NewInt(params.GWei)
+	blocks, err := Simulate(config, &Start{Number: 1, Time: 0, BlockTime: blockTime, BaseFee: baseFee}, usage)
+	if err != nil {
+		return nil, err
+	}
+	return &ScheduleVector{Config: config, BaseFee: (*hexutil.Big)(baseFee), Blocks: blocks}, nil
+}
+
+// setForkTime schedules a timestamp fork.
+func setForkTime(config *params.ChainConfig, fork string, time uint64) error {
+	index, err := forks.Index(fork)
+	if err != nil {
+		return err
+	}
+	name := forks.Names()[index]
+	field := forks.FindField(strings.ToLower(name) + "-time")
+	if field == nil || field.Time == nil {
+		return fmt.Errorf("%s is not a timestamp fork", name)
+	}
+	*field.Time(config) = &time
+	return nil
+}
@@ -0,0 +1,354 @@
+// Package blocktest builds blockchain test fixtures from a genesis and a list
+// of block definitions.
+//
+// Each block is assembled by go-ethereum's chain generator from its signed
+// transactions and withdrawals, so the transactions, receipts and withdrawals
+// roots, the logs bloom and the blob gas fields are all derived correctly. A
+// block may name a header field to corrupt afterwards, or have its withdrawals
+// attached without applying them, turning it into an invalid block the client
+// under test must reject. Invalid blocks are siblings of the next valid block:
+// the chain continues on top of the last valid one.
+package blocktest
+
+import (
+	"fmt"
+	stdmath "math"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+)
+
+// Block defines a block of the test chain.
+type Block struct {
+	// Transactions are the signed transactions of the block in their binary
+	// encoding. Transactions which cannot be executed fail the build.
+	Transactions []hexutil.Bytes `json:"transactions"`
+
+	// Withdrawals are numbered consecutively along the chain, their index
+	// fields are ignored unless WithdrawalIndices is set. Execution clients
+	// do not validate the indices, they only commit to them through the
+	// withdrawals root, so kept indices need not follow the earlier blocks.
+	Withdrawals       []*types.Withdrawal `json:"withdrawals"`
+	WithdrawalIndices bool                `json:"withdrawalIndices"`
+	Header            HeaderOverrides     `json:"header"`
+
+	// Corrupt, if set, makes the block invalid by replacing a field of its
+	// header after it has been assembled.
+	Corrupt *Corruption `json:"corrupt"`
+
+	// ExpectException is the exception recorded for an invalid block. It
+	// defaults to "invalid <field>" for corrupted blocks.
+	ExpectException string `json:"expectException"`
+
+	// Reasons, if set, are parts of go-ethereum's errors rejecting the
+	// corrupted block, one of which must match when the fixture is verified.
+	Reasons []string `json:"reasons"`
+}
+
+// HeaderOverrides are the header fields which may be chosen for a block. The
+// remaining fields follow from the parent and the block contents.
+type HeaderOverrides struct {
+	Coinbase              *common.Address      `json:"coinbase"`
+	Timestamp             *math.HexOrDecimal64 `json:"timestamp"` // defaults to the parent's plus 10
+	ExtraData             *hexutil.Bytes       `json:"extraData"`
+	Nonce                 *types.BlockNonce    `json:"nonce"`
+	ParentBeaconBlockRoot *common.Hash         `json:"parentBeaconBlockRoot"`
+}
+
+// Corruption replaces a header field, identified by its fixture name such as
+// "stateRoot" or "gasUsed". Without a value, the field is perturbed: numbers
+// are incremented and the last byte of hashes and byte fields is flipped.
+//
+// The field "withdrawals" takes no value: the block is assembled without its
+// withdrawals, which are then added to its body and their root to its header.
+// Before Shanghai that makes a block carrying withdrawals at all, from
+// Shanghai one whose withdrawals are missing from its state root.
+type Corruption struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// Build generates the chain defined by blocks on top of the genesis and returns
+// it as a fixture for the given fork. Only the genesis fields used by fixtures
+// (alloc and the header fields of the genesis block) are taken over; the chain
+// configuration is that of the fork in the reference tests.
+//
+// The generated chain is imported into a fresh go-ethereum chain before the
+// fixture is returned, checking that valid blocks are accepted and corrupted
+// ones rejected, and the post-state is taken from the imported head.
+func Build(genesis *core.Genesis, fork string, blocks []*Block) (*Fixture, error) {
+	config, err := forkChainConfig(fork)
+	if err != nil {
+		return nil, err
+	}
+	gspec := &core.Genesis{
+		Config:        config,
+		Nonce:         genesis.Nonce,
+		Timestamp:     genesis.Timestamp,
+		ParentHash:    genesis.ParentHash,
+		ExtraData:     genesis.ExtraData,
+		GasLimit:      genesis.GasLimit,
+		GasUsed:       genesis.GasUsed,
+		Difficulty:    genesis.Difficulty,
+		Mixhash:       genesis.Mixhash,
+		Coinbase:      genesis.Coinbase,
+		Alloc:         genesis.Alloc,
+		BaseFee:       genesis.BaseFee,
+		BlobGasUsed:   genesis.BlobGasUsed,
+		ExcessBlobGas: genesis.ExcessBlobGas,
+	}
+	engine := beacon.New(ethash.NewFaker())
+	db := rawdb.NewMemoryDatabase()
+	tdb := triedb.NewDatabase(db, triedb.HashDefaults)
+	gblock, err := gspec.Commit(db, tdb)
+	tdb.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate the valid blocks as one chain, imported as it grows so that
+	// earlier block hashes are available to them, then each invalid block on
+	// top of its valid parent.
+	bc, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), gspec, engine, core.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	defer bc.Stop()
+	var valid []int
+	for i, block := range blocks {
+		if block.Corrupt == nil {
+			valid = append(valid, i)
+		}
+	}
+	chain, err := generate(config, gblock, engine, db, bc, blocks, valid, true)
+	if err != nil {
+		return nil, err
+	}
+	fixture := &Fixture{
+		Genesis:    newFixtureHeader(gblock.Header()),
+		Pre:        gspec.Alloc,
+		Network:    fork,
+		SealEngine: "NoProof",
+	}
+	if fixture.GenesisRLP, err = encodeBlock(gblock); err != nil {
+		return nil, err
+	}
+	var (
+		parent = gblock
+		next   = 0
+	)
+	for i, block := range blocks {
+		if block.Corrupt == nil {
+			parent = chain[next]
+			next++
+			fb := &FixtureBlock{BlockHeader: newFixtureHeader(parent.Header())}
+			if fb.RLP, err = encodeBlock(parent); err != nil {
+				return nil, err
+			}
+			fixture.Blocks = append(fixture.Blocks, fb)
+			continue
+		}
+		invalid, err := buildInvalid(config, parent, engine, db, bc, blocks, i)
+		if err != nil {
+			return nil, err
+		}
+		fb := &FixtureBlock{ExpectException: block.ExpectException, reasons: block.Reasons}
+		if fb.ExpectException == "" {
+			fb.ExpectException = "invalid " + block.Corrupt.Field
+		}
+		if fb.RLP, err = encodeBlock(invalid); err != nil {
+			return nil, err
+		}
+		fixture.Blocks = append(fixture.Blocks, fb)
+	}
+	fixture.LastBlockHash = parent.Hash()
+
+	if err := fixture.verify(gspec, engine); err != nil {
+		return nil, err
+	}
+	return fixture, nil
+}
+
+// buildInvalid generates the corrupted block at the given index on top of
+// parent and applies its corruption.
+func buildInvalid(config *params.ChainConfig, parent *types.Block, engine consensus.Engine, db ethdb.Database, bc *core.BlockChain, blocks []*Block, index int) (*types.Block, error) {
+	block := blocks[index]
+	if block.Corrupt.Field != "withdrawals" {
+		generated, err := generate(config, parent, engine, db, bc, blocks, []int{index}, false)
+		if err != nil {
+			return nil, err
+		}
+		header := generated[0].Header()
+		if err := corrupt(header, block.Corrupt); err != nil {
+			return nil, fmt.Errorf("block %d: %v", index, err)
+		}
+		return generated[0].WithSeal(header), nil
+	}
+	if block.Corrupt.Value != "" {
+		return nil, fmt.Errorf("block %d: corrupt withdrawals: unexpected value %q", index, block.Corrupt.Value)
+	}
+	withheld := *block
+	withheld.Withdrawals = nil
+	selected := slices.Clone(blocks)
+	selected[index] = &withheld
+	generated, err := generate(config, parent, engine, db, bc, selected, []int{index}, false)
+	if err != nil {
+		return nil, err
+	}
+	// Number the withdrawals the way the chain generator would have.
+	withdrawals := make([]*types.Withdrawal, len(block.Withdrawals))
+	next := uint64(0)
+	if prev := parent.Withdrawals(); len(prev) > 0 {
+		next = prev[len(prev)-1].Index + 1
+	}
+	for i, w := range block.Withdrawals {
+		cpy := *w
+		if !block.WithdrawalIndices {
+			cpy.Index = next + uint64(i)
+		}
+		withdrawals[i] = &cpy
+	}
+	header := generated[0].Header()
+	root := types.DeriveSha(types.Withdrawals(withdrawals), trie.NewStackTrie(nil))
+	header.WithdrawalsHash = &root
+	body := generated[0].Body()
+	body.Withdrawals = withdrawals
+	return generated[0].WithSeal(header).WithBody(*body), nil
+}
+
+// forkChainConfig returns the chain config of the fork in the reference
+// tests.
+func forkChainConfig(fork string) (*params.ChainConfig, error) {
+	forkConfig, ok := tests.Forks[fork]
+	if !ok {
+		return nil, fmt.Errorf("unknown fork %q", fork)
+	}
+	config := *forkConfig
+	if config.TerminalTotalDifficulty == nil {
+		// Block tests of pre-merge forks never reach the merge.
+		config.TerminalTotalDifficulty = big.NewInt(stdmath.MaxInt64)
+	}
+	return &config, nil
+}
+
+// generate creates a chain of the selected blocks on top of parent, the state
+// of which must be available in db. The transactions are executed against bc,
+// which must hold parent and its ancestors so that BLOCKHASH reaches beyond
+// the parent. With insert set, the blocks are generated one at a time and
+// each one is inserted into bc for the next ones.
+func generate(config *params.ChainConfig, parent *types.Block, engine consensus.Engine, db ethdb.Database, bc *core.BlockChain, blocks []*Block, selected []int, insert bool) ([]*types.Block, error) {
+	var (
+		chain = make([]*types.Block, 0, len(selected))
+		next  uint64 // index of the next withdrawal
+	)
+	for _, index := range selected {
+		block, err := generateBlock(config, parent, engine, db, bc, blocks[index], next)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %v", index, err)
+		}
+		if ws := block.Withdrawals(); len(ws) > 0 {
+			next = ws[len(ws)-1].Index + 1
+		}
+		if insert {
+			if _, err := bc.InsertChain(types.Blocks{block}); err != nil {
+				return nil, fmt.Errorf("block %d rejected: %v", index, err)
+			}
+		}
+		chain = append(chain, block)
+		parent = block
+	}
+	return chain, nil
+}
+
+// generateBlock creates the block on top of parent, numbering its withdrawals
+// from next unless it keeps their indices.
+func generateBlock(config *params.ChainConfig, parent *types.Block, engine consensus.Engine, db ethdb.Database, bc *core.BlockChain, block *Block, next uint64) (generated *types.Block, err error) {
+	var txs []*types.Transaction
+	for j, enc := range block.Transactions {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(enc); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", j, err)
+		}
+		txs = append(txs, tx)
+	}
+	// The chain generator numbers the withdrawals from those of the blocks of
+	// a single run, so the numbered ones are swapped in when the block is
+	// assembled.
+	if len(block.Withdrawals) > 0 {
+		withdrawals := block.Withdrawals
+		if !block.WithdrawalIndices {
+			withdrawals = make([]*types.Withdrawal, len(block.Withdrawals))
+			for i, w := range block.Withdrawals {
+				cpy := *w
+				cpy.Index = next + uint64(i)
+				withdrawals[i] = &cpy
+			}
+		}
+		engine = &withdrawalsEngine{Engine: engine, withdrawals: map[uint64][]*types.Withdrawal{parent.NumberU64() + 1: withdrawals}}
+	}
+	// The chain generator panics on transactions it cannot execute.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	chain, _ := core.GenerateChain(config, parent, engine, db, 1, func(i int, gen *core.BlockGen) {
+		header := block.Header
+		if header.Coinbase != nil {
+			gen.SetCoinbase(*header.Coinbase)
+		}
+		if header.Timestamp != nil {
+			gen.OffsetTime(int64(uint64(*header.Timestamp)) - int64(gen.Timestamp()))
+		}
+		if header.ExtraData != nil {
+			gen.SetExtra(*header.ExtraData)
+		}
+		if header.Nonce != nil {
+			gen.SetNonce(*header.Nonce)
+		}
+		// The generator leaves the beacon root system call to the caller.
+		if config.IsCancun(gen.Number(), gen.Timestamp()) {
+			var root common.Hash
+			if header.ParentBeaconBlockRoot != nil {
+				root = *header.ParentBeaconBlockRoot
+			}
+			gen.SetParentBeaconRoot(root)
+		}
+		for _, tx := range txs {
+			gen.AddTxWithChain(bc, tx)
+		}
+		for _, w := range block.Withdrawals {
+			gen.AddWithdrawal(w)
+		}
+	})
+	return chain[0], nil
+}
+
+// withdrawalsEngine assembles blocks with the given withdrawals, by block
+// number, instead of those collected by the chain generator.
+type withdrawalsEngine struct {
+	consensus.Engine
+	withdrawals map[uint64][]*types.Withdrawal
+}
+
+func (e *withdrawalsEngine) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, statedb *state.StateDB, body *types.Body, receipts []*types.Receipt) (*types.Block, error) {
+	if withdrawals, ok := e.withdrawals[header.Number.Uint64()]; ok {
+		body.Withdrawals = withdrawals
+	}
+	return e.Engine.FinalizeAndAssemble(chain, header, statedb, body, receipts)
+}
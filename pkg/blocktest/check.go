@@ -0,0 +1,96 @@
+package blocktest
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Check verifies a built fixture against the expectations of a vector.
+type Check func(*Fixture) error
+
+// All combines checks, failing with the first failed one.
+func All(checks ...Check) Check {
+	return func(f *Fixture) error {
+		for _, check := range checks {
+			if err := check(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// CheckBalance returns a check of the balance of an account in the
+// post-state.
+func CheckBalance(addr common.Address, want *big.Int) Check {
+	return func(f *Fixture) error {
+		account, ok := f.Post[addr]
+		if !ok {
+			return fmt.Errorf("account %s missing from the post-state", addr.Hex())
+		}
+		if account.Balance.Cmp(want) != 0 {
+			return fmt.Errorf("account %s: balance %v, expected %v", addr.Hex(), account.Balance, want)
+		}
+		return nil
+	}
+}
+
+// CheckAbsent returns a check that an account is missing from the post-state.
+func CheckAbsent(addr common.Address) Check {
+	return func(f *Fixture) error {
+		if _, ok := f.Post[addr]; ok {
+			return fmt.Errorf("account %s should not exist", addr.Hex())
+		}
+		return nil
+	}
+}
+
+// CheckNoStorage returns a check that an account has no storage in the
+// post-state.
+func CheckNoStorage(addr common.Address) Check {
+	return func(f *Fixture) error {
+		if storage := f.Post[addr].Storage; len(storage) > 0 {
+			return fmt.Errorf("account %s: unexpected storage %v", addr.Hex(), storage)
+		}
+		return nil
+	}
+}
+
+// CheckStorage returns a check of the storage slots of an account in the
+// post-state. Slots missing from want must be empty.
+func CheckStorage(addr common.Address, want map[uint64]common.Hash) Check {
+	return func(f *Fixture) error {
+		account, ok := f.Post[addr]
+		if !ok {
+			return fmt.Errorf("account %s missing from the post-state", addr.Hex())
+		}
+		slots := make(map[common.Hash]common.Hash, len(want))
+		for slot, value := range want {
+			key := common.BigToHash(new(big.Int).SetUint64(slot))
+			if have := account.Storage[key]; have != value {
+				return fmt.Errorf("account %s: storage slot %d is %s, expected %s", addr.Hex(), slot, have.Hex(), value.Hex())
+			}
+			slots[key] = value
+		}
+		for slot, value := range account.Storage {
+			if _, ok := slots[slot]; !ok {
+				return fmt.Errorf("account %s: unexpected storage slot %s: %s", addr.Hex(), slot.Hex(), value.Hex())
+			}
+		}
+		return nil
+	}
+}
+
+// CheckSlot returns a check of a single storage slot of an account in the
+// post-state, ignoring the others.
+func CheckSlot(addr common.Address, slot uint64, want common.Hash) Check {
+	return func(f *Fixture) error {
+		key := common.BigToHash(new(big.Int).SetUint64(slot))
+		if have := f.Post[addr].Storage[key]; have != want {
+			return fmt.Errorf("account %s: storage slot %d is %s, expected %s", addr.Hex(), slot, have.Hex(), want.Hex())
+		}
+		return nil
+	}
+}
@@ -0,0 +1,149 @@
+package blocktest
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// corrupt replaces the header field named by c. Optional fields absent from the
+// header are added.
+func corrupt(h *types.Header, c *Corruption) error {
+	var err error
+	switch c.Field {
+	case "parentHash":
+		h.ParentHash, err = corruptHash(h.ParentHash, c.Value)
+	case "uncleHash":
+		h.UncleHash, err = corruptHash(h.UncleHash, c.Value)
+	case "coinbase":
+		h.Coinbase, err = corruptAddress(h.Coinbase, c.Value)
+	case "stateRoot":
+		h.Root, err = corruptHash(h.Root, c.Value)
+	case "transactionsTrie":
+		h.TxHash, err = corruptHash(h.TxHash, c.Value)
+	case "receiptTrie":
+		h.ReceiptHash, err = corruptHash(h.ReceiptHash, c.Value)
+	case "bloom":
+		var bloom []byte
+		if bloom, err = corruptBytes(h.Bloom[:], c.Value); err == nil {
+			h.Bloom = types.BytesToBloom(bloom)
+		}
+	case "difficulty":
+		h.Difficulty, err = corruptBig(h.Difficulty, c.Value)
+	case "number":
+		h.Number, err = corruptBig(h.Number, c.Value)
+	case "gasLimit":
+		h.GasLimit, err = corruptUint64(h.GasLimit, c.Value)
+	case "gasUsed":
+		h.GasUsed, err = corruptUint64(h.GasUsed, c.Value)
+	case "timestamp":
+		h.Time, err = corruptUint64(h.Time, c.Value)
+	case "extraData":
+		h.Extra, err = corruptBytes(h.Extra, c.Value)
+	case "mixHash":
+		h.MixDigest, err = corruptHash(h.MixDigest, c.Value)
+	case "nonce":
+		var nonce uint64
+		if nonce, err = corruptUint64(h.Nonce.Uint64(), c.Value); err == nil {
+			h.Nonce = types.EncodeNonce(nonce)
+		}
+	case "baseFeePerGas":
+		h.BaseFee, err = corruptBig(h.BaseFee, c.Value)
+	case "withdrawalsRoot":
+		h.WithdrawalsHash, err = corruptOptionalHash(h.WithdrawalsHash, c.Value)
+	case "blobGasUsed":
+		h.BlobGasUsed, err = corruptOptionalUint64(h.BlobGasUsed, c.Value)
+	case "excessBlobGas":
+		h.ExcessBlobGas, err = corruptOptionalUint64(h.ExcessBlobGas, c.Value)
+	case "parentBeaconBlockRoot":
+		h.ParentBeaconRoot, err = corruptOptionalHash(h.ParentBeaconRoot, c.Value)
+	case "requestsHash":
+		h.RequestsHash, err = corruptOptionalHash(h.RequestsHash, c.Value)
+	default:
+		return fmt.Errorf("unknown header field %q", c.Field)
+	}
+	if err != nil {
+		return fmt.Errorf("corrupt %s: %v", c.Field, err)
+	}
+	return nil
+}
+
+func corruptBytes(b []byte, value string) ([]byte, error) {
+	if value != "" {
+		return hexutil.Decode(value)
+	}
+	b = common.CopyBytes(b)
+	if len(b) == 0 {
+		return []byte{1}, nil
+	}
+	b[len(b)-1] ^= 1
+	return b, nil
+}
+
+func corruptHash(h common.Hash, value string) (common.Hash, error) {
+	b, err := corruptBytes(h[:], value)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid hash length %d", len(b))
+	}
+	return common.BytesToHash(b), nil
+}
+
+func corruptOptionalHash(h *common.Hash, value string) (*common.Hash, error) {
+	if h == nil {
+		h = new(common.Hash)
+	}
+	corrupted, err := corruptHash(*h, value)
+	return &corrupted, err
+}
+
+func corruptAddress(a common.Address, value string) (common.Address, error) {
+	b, err := corruptBytes(a[:], value)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(b) != common.AddressLength {
+		return common.Address{}, fmt.Errorf("invalid address length %d", len(b))
+	}
+	return common.BytesToAddress(b), nil
+}
+
+func corruptBig(n *big.Int, value string) (*big.Int, error) {
+	if value != "" {
+		v, ok := math.ParseBig256(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid number %q", value)
+		}
+		return v, nil
+	}
+	if n == nil {
+		return big.NewInt(1), nil
+	}
+	return new(big.Int).Add(n, common.Big1), nil
+}
+
+func corruptUint64(n uint64, value string) (uint64, error) {
+	if value != "" {
+		v, ok := math.ParseUint64(value)
+		if !ok {
+			return 0, fmt.Errorf("invalid number %q", value)
+		}
+		return v, nil
+	}
+	return n + 1, nil
+}
+
+func corruptOptionalUint64(n *uint64, value string) (*uint64, error) {
+	var v uint64
+	if n != nil {
+		v = *n
+	}
+	corrupted, err := corruptUint64(v, value)
+	return &corrupted, err
+}
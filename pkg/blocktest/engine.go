@@ -0,0 +1,315 @@
+package blocktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/catalyst"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// EngineFixture is a blockchain test expressed as the Engine API calls a
+// consensus client makes to import its blocks: an engine_newPayload call per
+// block, each valid one followed by an engine_forkchoiceUpdated call making it
+// the head.
+type EngineFixture struct {
+	Info          map[string]string  `json:"_info,omitempty"`
+	Network       string             `json:"network"`
+	Genesis       *FixtureHeader     `json:"genesisBlockHeader"`
+	Pre           types.GenesisAlloc `json:"pre"`
+	Payloads      []*EnginePayload   `json:"engineNewPayloads"`
+	LastBlockHash common.Hash        `json:"lastblockhash"`
+	Post          types.GenesisAlloc `json:"postState"`
+}
+
+// EnginePayload is an engine_newPayload call and the status the client must
+// reply with. It is encoded with the method name and its positional params.
+type EnginePayload struct {
+	Version           int                    `json:"-"` // 1 to 4, the version of engine_newPayload
+	ExecutionPayload  *engine.ExecutableData `json:"-"`
+	VersionedHashes   []common.Hash          `json:"-"` // from V3
+	BeaconRoot        *common.Hash           `json:"-"` // from V3
+	ExecutionRequests []hexutil.Bytes        `json:"-"` // from V4
+
+	ExpectedStatus  engine.PayloadStatusV1 `json:"expectedStatus"`
+	ExpectException string                 `json:"expectException,omitempty"`
+	Forkchoice      *EngineForkchoice      `json:"forkchoiceUpdated,omitempty"`
+
+	// missingRequestContract marks an invalid payload whose request system
+	// call targets an account without code. go-ethereum skips such calls
+	// and accepts the payload.
+	missingRequestContract bool
+}
+
+// EngineForkchoice is an engine_forkchoiceUpdated call without payload
+// attributes and the response the client must reply with.
+type EngineForkchoice struct {
+	Version          int                       `json:"-"` // 1 to 3, the version of engine_forkchoiceUpdated
+	State            engine.ForkchoiceStateV1  `json:"-"`
+	ExpectedResponse engine.ForkChoiceResponse `json:"expectedStatus"`
+}
+
+// Method returns the name of the Engine API method.
+func (p *EnginePayload) Method() string {
+	return fmt.Sprintf("engine_newPayloadV%d", p.Version)
+}
+
+// Params returns the positional parameters of the call.
+func (p *EnginePayload) Params() []interface{} {
+	params := []interface{}{p.ExecutionPayload}
+	if p.Version >= 3 {
+		params = append(params, p.VersionedHashes, p.BeaconRoot)
+	}
+	if p.Version >= 4 {
+		params = append(params, p.ExecutionRequests)
+	}
+	return params
+}
+
+// MarshalJSON encodes the call with its method and params.
+func (p *EnginePayload) MarshalJSON() ([]byte, error) {
+	type payload EnginePayload
+	return json.Marshal(struct {
+		Method string        `json:"method"`
+		Params []interface{} `json:"params"`
+		*payload
+	}{p.Method(), p.Params(), (*payload)(p)})
+}
+
+// Method returns the name of the Engine API method.
+func (fc *EngineForkchoice) Method() string {
+	return fmt.Sprintf("engine_forkchoiceUpdatedV%d", fc.Version)
+}
+
+// MarshalJSON encodes the call with its method and params, the forkchoice
+// state and null payload attributes.
+func (fc *EngineForkchoice) MarshalJSON() ([]byte, error) {
+	type forkchoice EngineForkchoice
+	return json.Marshal(struct {
+		Method string        `json:"method"`
+		Params []interface{} `json:"params"`
+		*forkchoice
+	}{fc.Method(), []interface{}{fc.State, nil}, (*forkchoice)(fc)})
+}
+
+// Engine converts a fixture of a post-merge chain into the Engine API calls
+// importing it. The payload version follows from the header fields of the
+// block: V4 with a requests hash, V3 with a parent beacon root, V2 with a
+// withdrawals root and V1 otherwise, and the forkchoice version likewise.
+//
+// Valid blocks are expected VALID with themselves as the latest valid hash.
+// Invalid blocks are expected INVALID with their parent as the latest valid
+// hash; the all-zero hash if the parent is a proof-of-work block. Blocks which
+// cannot be expressed as a payload, because the fields it leaves out such as
+// the uncles, the nonce or the requests hash do not match the block hash, fail
+// the block hash check and are expected INVALID without a latest valid hash.
+// The execution requests of a payload are those produced by executing the
+// block on top of its parent.
+//
+// The calls are replayed against the Engine API of a go-ethereum node, which
+// must reply with the expected statuses, before the fixture is returned.
+func (f *Fixture) Engine() (*EngineFixture, error) {
+	gspec, err := f.genesis()
+	if err != nil {
+		return nil, err
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), gspec, beacon.New(ethash.NewFaker()), core.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	defer chain.Stop()
+
+	ef := &EngineFixture{
+		Network:       f.Network,
+		Genesis:       f.Genesis,
+		Pre:           f.Pre,
+		LastBlockHash: f.LastBlockHash,
+		Post:          f.Post,
+	}
+	processor := core.NewStateProcessor(chain)
+	for i, fb := range f.Blocks {
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(fb.RLP, block); err != nil {
+			return nil, fmt.Errorf("block %d: %v", i, err)
+		}
+		if block.Difficulty().Sign() != 0 {
+			return nil, fmt.Errorf("block %d: proof-of-work block, the Engine API starts at the merge", i)
+		}
+		parent := chain.GetHeaderByHash(block.ParentHash())
+		if parent == nil {
+			return nil, fmt.Errorf("block %d: unknown parent %x", i, block.ParentHash())
+		}
+		p := &EnginePayload{Version: 1, ExpectException: fb.ExpectException}
+		switch header := block.Header(); {
+		case header.RequestsHash != nil:
+			p.Version = 4
+		case header.ParentBeaconRoot != nil:
+			p.Version = 3
+		case header.WithdrawalsHash != nil:
+			p.Version = 2
+		}
+		var requests [][]byte
+		if p.Version >= 4 {
+			requests = [][]byte{}
+			statedb, err := chain.StateAt(parent.Root)
+			if err != nil {
+				return nil, err
+			}
+			p.missingRequestContract = statedb.GetCodeSize(params.WithdrawalQueueAddress) == 0 || statedb.GetCodeSize(params.ConsolidationQueueAddress) == 0
+			if res, err := processor.Process(block, statedb, vm.Config{}); err == nil {
+				requests = res.Requests
+			} else if fb.BlockHeader != nil {
+				return nil, fmt.Errorf("block %d: %v", i, err)
+			}
+			// The requests of an invalid block whose execution fails are
+			// unknown, such a payload is sent without any.
+			p.ExecutionRequests = make([]hexutil.Bytes, len(requests))
+			for j, r := range requests {
+				p.ExecutionRequests[j] = r
+			}
+		}
+		p.ExecutionPayload = engine.BlockToExecutableData(block, nil, nil, nil).ExecutionPayload
+		if p.Version >= 3 {
+			p.VersionedHashes = []common.Hash{}
+			for _, tx := range block.Transactions() {
+				p.VersionedHashes = append(p.VersionedHashes, tx.BlobHashes()...)
+			}
+			p.BeaconRoot = block.BeaconRoot()
+		}
+
+		if fb.BlockHeader != nil {
+			hash := block.Hash()
+			if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+				return nil, fmt.Errorf("block %d rejected: %v", i, err)
+			}
+			p.ExpectedStatus = engine.PayloadStatusV1{Status: engine.VALID, LatestValidHash: &hash}
+			p.Forkchoice = &EngineForkchoice{
+				Version: min(p.Version, 3),
+				State:   engine.ForkchoiceStateV1{HeadBlockHash: hash, SafeBlockHash: hash, FinalizedBlockHash: hash},
+				ExpectedResponse: engine.ForkChoiceResponse{
+					PayloadStatus: engine.PayloadStatusV1{Status: engine.VALID, LatestValidHash: &hash},
+				},
+			}
+		} else if rebuilt, err := engine.ExecutableDataToBlock(*p.ExecutionPayload, p.VersionedHashes, p.BeaconRoot, requests); err != nil || rebuilt.Hash() != block.Hash() {
+			p.ExpectedStatus = engine.PayloadStatusV1{Status: engine.INVALID}
+		} else {
+			var latestValid common.Hash
+			if parent.Difficulty.Sign() == 0 {
+				latestValid = parent.Hash()
+			}
+			p.ExpectedStatus = engine.PayloadStatusV1{Status: engine.INVALID, LatestValidHash: &latestValid}
+		}
+		ef.Payloads = append(ef.Payloads, p)
+	}
+	if head := chain.CurrentBlock().Hash(); head != f.LastBlockHash {
+		return nil, fmt.Errorf("chain head %x, expected %x", head, f.LastBlockHash)
+	}
+	if err := ef.verify(gspec); err != nil {
+		return nil, err
+	}
+	return ef, nil
+}
+
+// verify replays the calls of the fixture over JSON-RPC against the Engine
+// API of an in-process go-ethereum node started from the genesis, checking
+// its replies and the resulting head. Payloads with a missing request
+// contract must be accepted, go-ethereum does not check for its code.
+func (ef *EngineFixture) verify(gspec *core.Genesis) error {
+	stack, err := node.New(&node.Config{P2P: p2p.Config{NoDiscovery: true, NoDial: true}})
+	if err != nil {
+		return err
+	}
+	defer stack.Close()
+	backend, err := eth.New(stack, &ethconfig.Config{
+		Genesis:        gspec,
+		SyncMode:       ethconfig.FullSync,
+		TrieTimeout:    time.Minute,
+		TrieDirtyCache: 256,
+		TrieCleanCache: 256,
+		Miner:          miner.DefaultConfig,
+	})
+	if err != nil {
+		return err
+	}
+	if err := catalyst.Register(stack, backend); err != nil {
+		return err
+	}
+	if err := stack.Start(); err != nil {
+		return err
+	}
+	backend.SetSynced()
+	client := stack.Attach()
+	defer client.Close()
+
+	for i, p := range ef.Payloads {
+		var status engine.PayloadStatusV1
+		if err := client.Call(&status, p.Method(), p.Params()...); err != nil {
+			return fmt.Errorf("payload %d: %s: %v", i, p.Method(), err)
+		}
+		want := p.ExpectedStatus
+		if p.missingRequestContract && want.Status == engine.INVALID && want.LatestValidHash != nil {
+			hash := p.ExecutionPayload.BlockHash
+			want = engine.PayloadStatusV1{Status: engine.VALID, LatestValidHash: &hash}
+		}
+		if err := checkStatus(status, want); err != nil {
+			return fmt.Errorf("payload %d: %s: %v", i, p.Method(), err)
+		}
+		if p.Forkchoice == nil {
+			continue
+		}
+		var response engine.ForkChoiceResponse
+		if err := client.Call(&response, p.Forkchoice.Method(), p.Forkchoice.State, nil); err != nil {
+			return fmt.Errorf("payload %d: %s: %v", i, p.Forkchoice.Method(), err)
+		}
+		if err := checkStatus(response.PayloadStatus, p.Forkchoice.ExpectedResponse.PayloadStatus); err != nil {
+			return fmt.Errorf("payload %d: %s: %v", i, p.Forkchoice.Method(), err)
+		}
+	}
+	if head := backend.BlockChain().CurrentBlock().Hash(); head != ef.LastBlockHash {
+		return fmt.Errorf("chain head %x, expected %x", head, ef.LastBlockHash)
+	}
+	return nil
+}
+
+// checkStatus compares a payload status with the expected one, ignoring the
+// validation error, the text of which is up to the client.
+func checkStatus(have, want engine.PayloadStatusV1) error {
+	if have.Status != want.Status {
+		return fmt.Errorf("status %s, expected %s (%s)", have.Status, want.Status, validationError(have))
+	}
+	if (have.LatestValidHash == nil) != (want.LatestValidHash == nil) ||
+		have.LatestValidHash != nil && *have.LatestValidHash != *want.LatestValidHash {
+		return fmt.Errorf("latest valid hash %s, expected %s", hashString(have.LatestValidHash), hashString(want.LatestValidHash))
+	}
+	return nil
+}
+
+func validationError(status engine.PayloadStatusV1) string {
+	if status.ValidationError == nil {
+		return "no validation error"
+	}
+	return *status.ValidationError
+}
+
+func hashString(hash *common.Hash) string {
+	if hash == nil {
+		return "null"
+	}
+	return hash.Hex()
+}
@@ -0,0 +1,162 @@
+package blocktest
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Fixture is a blockchain test in the format of the reference tests.
+type Fixture struct {
+	Info          map[string]string  `json:"_info,omitempty"`
+	Blocks        []*FixtureBlock    `json:"blocks"`
+	Genesis       *FixtureHeader     `json:"genesisBlockHeader"`
+	GenesisRLP    hexutil.Bytes      `json:"genesisRLP"`
+	LastBlockHash common.Hash        `json:"lastblockhash"`
+	Network       string             `json:"network"`
+	Pre           types.GenesisAlloc `json:"pre"`
+	Post          types.GenesisAlloc `json:"postState"`
+	SealEngine    string             `json:"sealEngine"`
+}
+
+// FixtureBlock is a block of a fixture. Invalid blocks have no header, only
+// their encoding and the expected exception.
+type FixtureBlock struct {
+	BlockHeader     *FixtureHeader `json:"blockHeader,omitempty"`
+	RLP             hexutil.Bytes  `json:"rlp"`
+	ExpectException string         `json:"expectException,omitempty"`
+
+	reasons []string // parts of the errors go-ethereum may reject the block with
+}
+
+// FixtureHeader is a block header in the fixture encoding.
+type FixtureHeader struct {
+	ParentHash            common.Hash           `json:"parentHash"`
+	UncleHash             common.Hash           `json:"uncleHash"`
+	Coinbase              common.Address        `json:"coinbase"`
+	StateRoot             common.Hash           `json:"stateRoot"`
+	TransactionsTrie      common.Hash           `json:"transactionsTrie"`
+	ReceiptTrie           common.Hash           `json:"receiptTrie"`
+	Bloom                 types.Bloom           `json:"bloom"`
+	Difficulty            *math.HexOrDecimal256 `json:"difficulty"`
+	Number                *math.HexOrDecimal256 `json:"number"`
+	GasLimit              math.HexOrDecimal64   `json:"gasLimit"`
+	GasUsed               math.HexOrDecimal64   `json:"gasUsed"`
+	Timestamp             math.HexOrDecimal64   `json:"timestamp"`
+	ExtraData             hexutil.Bytes         `json:"extraData"`
+	MixHash               common.Hash           `json:"mixHash"`
+	Nonce                 types.BlockNonce      `json:"nonce"`
+	BaseFeePerGas         *math.HexOrDecimal256 `json:"baseFeePerGas,omitempty"`
+	WithdrawalsRoot       *common.Hash          `json:"withdrawalsRoot,omitempty"`
+	BlobGasUsed           *math.HexOrDecimal64  `json:"blobGasUsed,omitempty"`
+	ExcessBlobGas         *math.HexOrDecimal64  `json:"excessBlobGas,omitempty"`
+	ParentBeaconBlockRoot *common.Hash          `json:"parentBeaconBlockRoot,omitempty"`
+	RequestsHash          *common.Hash          `json:"requestsHash,omitempty"`
+	Hash                  common.Hash           `json:"hash"`
+}
+
+func newFixtureHeader(h *types.Header) *FixtureHeader {
+	fh := &FixtureHeader{
+		ParentHash:            h.ParentHash,
+		UncleHash:             h.UncleHash,
+		Coinbase:              h.Coinbase,
+		StateRoot:             h.Root,
+		TransactionsTrie:      h.TxHash,
+		ReceiptTrie:           h.ReceiptHash,
+		Bloom:                 h.Bloom,
+		Difficulty:            (*math.HexOrDecimal256)(h.Difficulty),
+		Number:                (*math.HexOrDecimal256)(h.Number),
+		GasLimit:              math.HexOrDecimal64(h.GasLimit),
+		GasUsed:               math.HexOrDecimal64(h.GasUsed),
+		Timestamp:             math.HexOrDecimal64(h.Time),
+		ExtraData:             h.Extra,
+		MixHash:               h.MixDigest,
+		Nonce:                 h.Nonce,
+		BaseFeePerGas:         (*math.HexOrDecimal256)(h.BaseFee),
+		WithdrawalsRoot:       h.WithdrawalsHash,
+		ParentBeaconBlockRoot: h.ParentBeaconRoot,
+		RequestsHash:          h.RequestsHash,
+		Hash:                  h.Hash(),
+	}
+	if h.BlobGasUsed != nil {
+		fh.BlobGasUsed = (*math.HexOrDecimal64)(h.BlobGasUsed)
+	}
+	if h.ExcessBlobGas != nil {
+		fh.ExcessBlobGas = (*math.HexOrDecimal64)(h.ExcessBlobGas)
+	}
+	return fh
+}
+
+func encodeBlock(block *types.Block) (hexutil.Bytes, error) {
+	return rlp.EncodeToBytes(block)
+}
+
+// verify imports the blocks of the fixture into a new chain, checks that
+// exactly the blocks without a header are rejected and fills in the post-state
+// of the resulting head.
+func (f *Fixture) verify(gspec *core.Genesis, engine consensus.Engine) error {
+	config := core.DefaultConfig()
+	config.Preimages = true // needed to dump the post-state
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), gspec, engine, config)
+	if err != nil {
+		return err
+	}
+	defer chain.Stop()
+
+	for i, fb := range f.Blocks {
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(fb.RLP, block); err != nil {
+			return fmt.Errorf("block %d: %v", i, err)
+		}
+		_, err := chain.InsertChain(types.Blocks{block})
+		switch {
+		case err != nil && fb.BlockHeader != nil:
+			return fmt.Errorf("block %d rejected: %v", i, err)
+		case err == nil && fb.BlockHeader == nil:
+			return fmt.Errorf("block %d (%s) accepted", i, fb.ExpectException)
+		case err != nil && len(fb.reasons) > 0 && !slices.ContainsFunc(fb.reasons, func(r string) bool { return strings.Contains(err.Error(), r) }):
+			return fmt.Errorf("block %d (%s) rejected for another reason: %v", i, fb.ExpectException, err)
+		}
+	}
+	if head := chain.CurrentBlock().Hash(); head != f.LastBlockHash {
+		return fmt.Errorf("chain head %x, expected %x", head, f.LastBlockHash)
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		return err
+	}
+	f.Post, err = dumpAlloc(statedb)
+	return err
+}
+
+// dumpAlloc returns all accounts of the state.
+func dumpAlloc(statedb *state.StateDB) (types.GenesisAlloc, error) {
+	dump := statedb.RawDump(&state.DumpConfig{OnlyWithAddresses: true})
+	alloc := make(types.GenesisAlloc, len(dump.Accounts))
+	for key, account := range dump.Accounts {
+		balance, ok := new(big.Int).SetString(account.Balance, 10)
+		if !ok {
+			return nil, fmt.Errorf("account %s: invalid balance %q", key, account.Balance)
+		}
+		ga := types.Account{Balance: balance, Nonce: account.Nonce, Code: account.Code}
+		if len(account.Storage) > 0 {
+			ga.Storage = make(map[common.Hash]common.Hash, len(account.Storage))
+			for slot, value := range account.Storage {
+				ga.Storage[slot] = common.HexToHash(value)
+			}
+		}
+		alloc[common.HexToAddress(key)] = ga
+	}
+	return alloc, nil
+}
@@ -0,0 +1,229 @@
+package blocktest
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Mutation turns a valid block into an invalid one by corrupting a field of
+// its header, tagged with the exception the specs reject the block with.
+type Mutation struct {
+	Name        string
+	Description string
+	Corruption  Corruption
+	Exception   string
+
+	// reasons are parts of go-ethereum's errors rejecting the block, one of
+	// which must match, making sure the block violates the intended rule and
+	// no other first.
+	reasons []string
+}
+
+// MutationNames lists the names of the mutations in the order Mutations
+// returns them.
+var MutationNames = []string{
+	"state_root", "gas_used", "base_fee", "excess_blob_gas", "blob_gas_used",
+	"withdrawals_root", "receipts_root", "logs_bloom", "requests_hash",
+	"extra_data", "gas_limit", "block_number", "timestamp",
+}
+
+// Mutations returns the mutations applicable to a block header with the given
+// parent, skipping those of fields the fork of the block does not have.
+func Mutations(header, parent *types.Header) []*Mutation {
+	var (
+		gasUsed     = header.GasUsed + 1
+		blobGasUsed = uint64(params.BlobTxBlobGasPerBlob)
+	)
+	if header.GasUsed == header.GasLimit {
+		// Stay within the gas limit, that is a different exception.
+		gasUsed = header.GasUsed - 1
+	}
+	if header.BlobGasUsed != nil && *header.BlobGasUsed > 0 {
+		// Stay below the blob gas limit, a multiple of the blob gas per blob.
+		blobGasUsed = *header.BlobGasUsed - params.BlobTxBlobGasPerBlob
+	}
+	all := []*Mutation{
+		{"state_root", "state root not matching the post-state", Corruption{Field: "stateRoot"}, "BlockException.INVALID_STATE_ROOT", []string{"invalid merkle root"}},
+		{"gas_used", "gas used not matching the transactions", Corruption{Field: "gasUsed", Value: strconv.FormatUint(gasUsed, 10)}, "BlockException.INVALID_GAS_USED", []string{"invalid gas used"}},
+		{"base_fee", "base fee off by one wei", Corruption{Field: "baseFeePerGas"}, "BlockException.INVALID_BASEFEE_PER_GAS", []string{"invalid baseFee"}},
+		{"excess_blob_gas", "excess blob gas not following the parent", Corruption{Field: "excessBlobGas"}, "BlockException.INCORRECT_EXCESS_BLOB_GAS", []string{"invalid excessBlobGas"}},
+		{"blob_gas_used", "blob gas used not matching the blob transactions", Corruption{Field: "blobGasUsed", Value: strconv.FormatUint(blobGasUsed, 10)}, "BlockException.INCORRECT_BLOB_GAS_USED", []string{"blob gas used mismatch"}},
+		{"withdrawals_root", "withdrawals root not matching the withdrawals", Corruption{Field: "withdrawalsRoot"}, "BlockException.INVALID_WITHDRAWALS_ROOT", []string{"withdrawals root hash mismatch"}},
+		{"receipts_root", "receipts root not matching the receipts", Corruption{Field: "receiptTrie"}, "BlockException.INVALID_RECEIPTS_ROOT", []string{"invalid receipt root hash"}},
+		{"logs_bloom", "logs bloom not matching the logs", Corruption{Field: "bloom"}, "BlockException.INVALID_LOG_BLOOM", []string{"invalid bloom"}},
+		{"requests_hash", "requests hash not matching the requests", Corruption{Field: "requestsHash"}, "BlockException.INVALID_REQUESTS", []string{"invalid requests hash"}},
+		{"extra_data", "extraData one byte above the maximum", Corruption{Field: "extraData", Value: hexutil.Encode(make([]byte, params.MaximumExtraDataSize+1))}, "BlockException.EXTRA_DATA_TOO_BIG", []string{"extra-data"}},
+		{"gas_limit", "gas limit increased by the parent's gas limit / 1024", Corruption{Field: "gasLimit", Value: strconv.FormatUint(parent.GasLimit+parent.GasLimit/params.GasLimitBoundDivisor, 10)}, "BlockException.INVALID_GASLIMIT", []string{"invalid gas limit"}},
+		// go-ethereum looks the parent up by number and hash, it cannot find it
+		// unless it is the genesis.
+		{"block_number", "block number skipping one", Corruption{Field: "number"}, "BlockException.INVALID_BLOCK_NUMBER", []string{"invalid block number", "unknown ancestor"}},
+		{"timestamp", "timestamp equal to the parent's", Corruption{Field: "timestamp", Value: strconv.FormatUint(parent.Time, 10)}, "BlockException.INVALID_BLOCK_TIMESTAMP_OLDER_THAN_PARENT", []string{"timestamp"}},
+	}
+	present := map[string]bool{
+		"baseFeePerGas":   header.BaseFee != nil,
+		"excessBlobGas":   header.ExcessBlobGas != nil,
+		"blobGasUsed":     header.BlobGasUsed != nil,
+		"withdrawalsRoot": header.WithdrawalsHash != nil,
+		"requestsHash":    header.RequestsHash != nil,
+	}
+	var mutations []*Mutation
+	for _, m := range all {
+		if ok, optional := present[m.Corruption.Field]; optional && !ok {
+			continue
+		}
+		mutations = append(mutations, m)
+	}
+	return mutations
+}
+
+// Variant is an invalid variant of a fixture.
+type Variant struct {
+	Mutation *Mutation
+	Fixture  *Fixture
+}
+
+// Invalid derives an invalid variant of a valid fixture per mutation of one
+// of its blocks, given by index. Each variant keeps the blocks before it and
+// replaces the block by its mutated copy, so the chain ends at its parent.
+// Only the mutations named in the filter are applied, all of them if it is
+// empty.
+//
+// Every variant is imported into go-ethereum, which must reject the mutated
+// block for the reason of the mutation, and its post-state is taken from the
+// imported head.
+func Invalid(f *Fixture, index int, filter []string) ([]*Variant, error) {
+	if f.SealEngine != "NoProof" {
+		return nil, fmt.Errorf("unsupported seal engine %q", f.SealEngine)
+	}
+	if index < 0 || index >= len(f.Blocks) {
+		return nil, fmt.Errorf("block %d out of range, the fixture has %d blocks", index, len(f.Blocks))
+	}
+	if f.Blocks[index].BlockHeader == nil {
+		return nil, fmt.Errorf("block %d is already invalid", index)
+	}
+	gspec, err := f.genesis()
+	if err != nil {
+		return nil, err
+	}
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(f.Blocks[index].RLP, block); err != nil {
+		return nil, fmt.Errorf("block %d: %v", index, err)
+	}
+	parent, err := f.parent(index)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[string]bool)
+	for _, name := range filter {
+		if !slices.Contains(MutationNames, name) {
+			return nil, fmt.Errorf("unknown mutation %q, want one of %s", name, strings.Join(MutationNames, ", "))
+		}
+		selected[name] = true
+	}
+	var (
+		variants []*Variant
+		engine   = beacon.New(ethash.NewFaker())
+	)
+	for _, m := range Mutations(block.Header(), parent) {
+		if len(filter) > 0 && !selected[m.Name] {
+			continue
+		}
+		header := block.Header()
+		if err := corrupt(header, &m.Corruption); err != nil {
+			return nil, fmt.Errorf("%s: %v", m.Name, err)
+		}
+		enc, err := encodeBlock(block.WithSeal(header))
+		if err != nil {
+			return nil, err
+		}
+		variant := *f
+		variant.Info = nil
+		variant.Blocks = append(append([]*FixtureBlock{}, f.Blocks[:index]...), &FixtureBlock{RLP: enc, ExpectException: m.Exception, reasons: m.reasons})
+		variant.LastBlockHash = parent.Hash()
+		if err := variant.verify(gspec, engine); err != nil {
+			return nil, fmt.Errorf("%s: %v", m.Name, err)
+		}
+		variants = append(variants, &Variant{Mutation: m, Fixture: &variant})
+	}
+	return variants, nil
+}
+
+// genesis reconstructs the genesis of the fixture from its network and
+// genesis block.
+func (f *Fixture) genesis() (*core.Genesis, error) {
+	if f.Genesis == nil {
+		return nil, errors.New("missing genesis block header")
+	}
+	config, err := forkChainConfig(f.Network)
+	if err != nil {
+		return nil, err
+	}
+	h := f.Genesis
+	gspec := &core.Genesis{
+		Config:     config,
+		Nonce:      h.Nonce.Uint64(),
+		Timestamp:  uint64(h.Timestamp),
+		ParentHash: h.ParentHash,
+		ExtraData:  h.ExtraData,
+		GasLimit:   uint64(h.GasLimit),
+		GasUsed:    uint64(h.GasUsed),
+		Difficulty: (*big.Int)(h.Difficulty),
+		Mixhash:    h.MixHash,
+		Coinbase:   h.Coinbase,
+		Alloc:      f.Pre,
+		BaseFee:    (*big.Int)(h.BaseFeePerGas),
+	}
+	if h.BlobGasUsed != nil {
+		gspec.BlobGasUsed = (*uint64)(h.BlobGasUsed)
+	}
+	if h.ExcessBlobGas != nil {
+		gspec.ExcessBlobGas = (*uint64)(h.ExcessBlobGas)
+	}
+	if hash := gspec.ToBlock().Hash(); hash != h.Hash {
+		return nil, fmt.Errorf("genesis %x, fixture genesis %x", hash, h.Hash)
+	}
+	return gspec, nil
+}
+
+// parent returns the header of the last valid block before the block at
+// index, or the genesis header.
+func (f *Fixture) parent(index int) (*types.Header, error) {
+	for i := index - 1; i >= 0; i-- {
+		if f.Blocks[i].BlockHeader == nil {
+			continue
+		}
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(f.Blocks[i].RLP, block); err != nil {
+			return nil, fmt.Errorf("block %d: %v", i, err)
+		}
+		return block.Header(), nil
+	}
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(f.GenesisRLP, block); err != nil {
+		return nil, fmt.Errorf("genesis: %v", err)
+	}
+	return block.Header(), nil
+}
+
+// LastValid returns the index of the last valid block of the fixture, or -1
+// if there is none.
+func (f *Fixture) LastValid() int {
+	for i := len(f.Blocks) - 1; i >= 0; i-- {
+		if f.Blocks[i].BlockHeader != nil {
+			return i
+		}
+	}
+	return -1
+}
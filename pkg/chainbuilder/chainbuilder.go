@@ -0,0 +1,235 @@
+// Package chainbuilder extends a post-merge genesis with a chain of valid
+// blocks, empty or carrying a few value transfers, for sync and pruning tests
+// which need long chains without running a network.
+//
+// Every block is assembled the way a block builder would: the header fields
+// follow from the parent and the fork rules at the block's own timestamp (the
+// base fee, the blob gas fields, the beacon root and the requests hash), the
+// pre-execution system calls of EIP-4788 and EIP-2935 and the request system
+// calls of EIP-7002 and EIP-7251 are applied, and the roots are derived from
+// the resulting state and body. Each block is imported into a go-ethereum
+// chain before it is handed out, so a block which would be rejected fails the
+// build.
+package chainbuilder
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	gen "github.com/ethereum/execution-specs/pkg/genesis"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// TransferGas is the gas used by a value transfer to an account without code.
+const TransferGas = params.TxGas
+
+// Options configure the generated chain.
+type Options struct {
+	Blocks    int            // number of blocks after the genesis
+	BlockTime uint64         // seconds between blocks
+	GasLimit  uint64         // gas limit the blocks move towards, 0 for the genesis gas limit
+	Coinbase  common.Address // fee recipient of every block
+	ExtraData []byte         // extraData of every block
+
+	// Transfers is the number of value transfers of one wei from Key to
+	// fresh accounts in every block. The sender must be funded by the
+	// genesis allocation.
+	Transfers int
+	Key       *ecdsa.PrivateKey
+}
+
+// Build generates the chain on top of the genesis and passes its blocks in
+// order to emit, returning the header of the last block.
+func Build(genesis *core.Genesis, opts *Options, emit func(*types.Block) error) (*types.Header, error) {
+	config := genesis.Config
+	switch {
+	case config == nil:
+		return nil, errors.New("missing chain config")
+	case config.TerminalTotalDifficulty == nil || config.TerminalTotalDifficulty.Sign() != 0:
+		return nil, errors.New("the genesis is not post-merge, blocks before the merge need a proof-of-work or Clique seal")
+	case genesis.Difficulty != nil && genesis.Difficulty.Sign() != 0:
+		return nil, fmt.Errorf("post-merge genesis with nonzero difficulty %v", genesis.Difficulty)
+	case opts.BlockTime == 0:
+		return nil, errors.New("zero block time")
+	case opts.Transfers > 0 && opts.Key == nil:
+		return nil, errors.New("transfers need a sender key")
+	}
+	engine := beacon.New(ethash.NewFaker())
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), genesis, engine, core.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	defer chain.Stop()
+
+	b := &builder{opts: opts, chain: chain, config: config}
+	if opts.Key != nil {
+		b.sender = crypto.PubkeyToAddress(opts.Key.PublicKey)
+	}
+	for i := 0; i < opts.Blocks; i++ {
+		block, err := b.next(chain.CurrentBlock())
+		if err != nil {
+			return nil, err
+		}
+		if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+			return nil, fmt.Errorf("block %d rejected: %v", block.NumberU64(), err)
+		}
+		if err := emit(block); err != nil {
+			return nil, err
+		}
+	}
+	return chain.CurrentBlock(), nil
+}
+
+// builder assembles the blocks on top of the head of chain.
+type builder struct {
+	opts   *Options
+	chain  *core.BlockChain
+	config *params.ChainConfig
+	sender common.Address
+}
+
+// next assembles the child of parent.
+func (b *builder) next(parent *types.Header) (*types.Block, error) {
+	config := b.config
+	gasLimit := b.opts.GasLimit
+	if gasLimit == 0 {
+		gasLimit = parent.GasLimit
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   b.opts.Coinbase,
+		Difficulty: new(big.Int),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   core.CalcGasLimit(parent.GasLimit, gasLimit),
+		Time:       parent.Time + b.opts.BlockTime,
+		Extra:      b.opts.ExtraData,
+	}
+	if config.IsLondon(header.Number) {
+		header.BaseFee = eip1559.CalcBaseFee(config, parent)
+		if !config.IsLondon(parent.Number) {
+			// The gas target of the fork block is the parent's gas limit.
+			parentGasLimit := parent.GasLimit * config.ElasticityMultiplier()
+			header.GasLimit = core.CalcGasLimit(parentGasLimit, parentGasLimit)
+		}
+	}
+	if config.IsCancun(header.Number, header.Time) {
+		excessBlobGas := eip4844.CalcExcessBlobGas(config, parent, header.Time)
+		header.ExcessBlobGas = &excessBlobGas
+		header.BlobGasUsed = new(uint64)
+		header.ParentBeaconRoot = new(common.Hash)
+	}
+	statedb, err := b.chain.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.checkSystemContracts(header, statedb); err != nil {
+		return nil, err
+	}
+	if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(header.Number) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	evm := vm.NewEVM(core.NewEVMBlockContext(header, b.chain, &header.Coinbase), statedb, config, vm.Config{})
+	if header.ParentBeaconRoot != nil {
+		core.ProcessBeaconBlockRoot(*header.ParentBeaconRoot, evm)
+	}
+	if config.IsPrague(header.Number, header.Time) {
+		core.ProcessParentBlockHash(header.ParentHash, evm)
+	}
+
+	var (
+		body     types.Body
+		receipts []*types.Receipt
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+	)
+	for i := 0; i < b.opts.Transfers; i++ {
+		tx, err := b.transfer(header, statedb.GetNonce(b.sender), i)
+		if err != nil {
+			return nil, err
+		}
+		statedb.SetTxContext(tx.Hash(), i)
+		receipt, err := core.ApplyTransaction(evm, gp, statedb, header, tx, &header.GasUsed)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: transfer %d: %v", header.Number, i, err)
+		}
+		body.Transactions = append(body.Transactions, tx)
+		receipts = append(receipts, receipt)
+	}
+	if config.IsPrague(header.Number, header.Time) {
+		requests := [][]byte{}
+		var logs []*types.Log
+		for _, r := range receipts {
+			logs = append(logs, r.Logs...)
+		}
+		if err := core.ParseDepositLogs(&requests, logs, config); err != nil {
+			return nil, fmt.Errorf("block %d: %v", header.Number, err)
+		}
+		if err := core.ProcessWithdrawalQueue(&requests, evm); err != nil {
+			return nil, fmt.Errorf("block %d: %v", header.Number, err)
+		}
+		if err := core.ProcessConsolidationQueue(&requests, evm); err != nil {
+			return nil, fmt.Errorf("block %d: %v", header.Number, err)
+		}
+		hash := types.CalcRequestsHash(requests)
+		header.RequestsHash = &hash
+	}
+	return b.chain.Engine().FinalizeAndAssemble(b.chain, header, statedb, &body, receipts)
+}
+
+// checkSystemContracts checks that the system contracts of the forks active
+// at the block are deployed. go-ethereum skips system calls into empty
+// accounts while the specs treat them as invalid blocks.
+func (b *builder) checkSystemContracts(header *types.Header, statedb *state.StateDB) error {
+	for _, contract := range gen.SystemContracts {
+		time := *forks.FindField(contract.Field).Time(b.config)
+		if time == nil || *time > header.Time {
+			continue
+		}
+		if statedb.GetCodeSize(contract.Address) == 0 {
+			return fmt.Errorf("block %d: missing %s contract required by %s", header.Number, contract.Name, contract.Field)
+		}
+	}
+	return nil
+}
+
+// transfer signs the i-th value transfer of the block, sending one wei to an
+// account derived from the block number and i.
+func (b *builder) transfer(header *types.Header, nonce uint64, i int) (*types.Transaction, error) {
+	var seed [16]byte
+	binary.BigEndian.PutUint64(seed[:8], header.Number.Uint64())
+	binary.BigEndian.PutUint64(seed[8:], uint64(i))
+	to := common.BytesToAddress(crypto.Keccak256(seed[:]))
+
+	var data types.TxData
+	if header.BaseFee != nil {
+		tip := big.NewInt(params.GWei)
+		data = &types.DynamicFeeTx{
+			ChainID:   b.config.ChainID,
+			Nonce:     nonce,
+			GasTipCap: tip,
+			GasFeeCap: new(big.Int).Add(new(big.Int).Mul(header.BaseFee, common.Big2), tip),
+			Gas:       TransferGas,
+			To:        &to,
+			Value:     common.Big1,
+		}
+	} else {
+		data = &types.LegacyTx{Nonce: nonce, GasPrice: big.NewInt(params.GWei), Gas: TransferGas, To: &to, Value: common.Big1}
+	}
+	signer := types.MakeSigner(b.config, header.Number, header.Time)
+	return types.SignNewTx(b.opts.Key, signer, data)
+}
@@ -0,0 +1,148 @@
+// Package compress reads and writes files which are transparently compressed
+// with gzip or zstd, for genesis and allocation files of mainnet scale that
+// run into gigabytes of JSON. Files are compressed when written based on
+// their extension, .gz or .zst, and detected by their magic bytes when read,
+// whatever their name.
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Ext returns the compression extension of the path, ".gz", ".zst" or "".
+func Ext(path string) string {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".gz", ".zst":
+		return ext
+	}
+	return ""
+}
+
+// TrimExt returns the path without its compression extension, so that the
+// extension of the content, such as .json or .csv, can be inspected.
+func TrimExt(path string) string {
+	return path[:len(path)-len(Ext(path))]
+}
+
+// Open opens a file for reading, decompressing it if it starts with the
+// magic bytes of gzip or zstd.
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &readCloser{Reader: r, closers: []io.Closer{r, f}}, nil
+}
+
+// NewReader returns a reader decompressing r if it is gzip or zstd
+// compressed, and reading it unchanged otherwise.
+func NewReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return io.NopCloser(br), nil
+}
+
+// ReadFile reads a file like os.ReadFile, decompressing it if needed.
+func ReadFile(path string) ([]byte, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Create creates a file for writing, compressed with gzip if the path ends in
+// .gz and with zstd if it ends in .zst. The file is complete once the writer
+// is closed without error.
+func Create(path string) (io.WriteCloser, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	var w io.WriteCloser
+	switch Ext(path) {
+	case ".gz":
+		w = gzip.NewWriter(f)
+	case ".zst":
+		if w, err = zstd.NewWriter(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+	default:
+		return f, nil
+	}
+	return &writeCloser{Writer: w, closers: []io.Closer{w, f}}, nil
+}
+
+// WriteFile writes data to a file like os.WriteFile, compressing it based on
+// the extension of the path.
+func WriteFile(path string, data []byte) error {
+	w, err := Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// readCloser closes the decompressor and the file underneath it.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *readCloser) Close() error {
+	return closeAll(r.closers)
+}
+
+// writeCloser flushes the compressor and closes the file underneath it.
+type writeCloser struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (w *writeCloser) Close() error {
+	return closeAll(w.closers)
+}
+
+func closeAll(closers []io.Closer) error {
+	var first error
+	for _, c := range closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,244 @@
+// Package eof parses and validates EOF (EVM Object Format) containers as
+// specified by EIP-3540 (container format), EIP-3670 (code validation),
+// EIP-4750 (functions), EIP-5450 (stack validation) and EIP-7620 (contract
+// creation).
+//
+// Validation errors correspond to the exceptions of the execution spec
+// tests, so that the results can be written as EOF validation fixtures.
+package eof
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Container format constants.
+const (
+	Version = 1
+
+	kindTypes     = 0x01
+	kindCode      = 0x02
+	kindContainer = 0x03
+	kindData      = 0xff
+	terminator    = 0x00
+
+	MaxCodeSections  = 1024
+	MaxContainers    = 256
+	MaxContainerSize = 49152 // MAX_INITCODE_SIZE
+
+	// NonReturning is the outputs value of code sections which never return
+	// to their caller.
+	NonReturning = 0x80
+
+	maxInputsOutputs    = 0x7f
+	maxStackIncrease    = 0x3ff
+	stackLimit          = 1024
+	functionMetadataLen = 4
+)
+
+// Magic is the prefix of EOF containers.
+var Magic = []byte{0xef, 0x00}
+
+// FunctionMetadata is an entry of the types section, describing the stack
+// behaviour of a code section.
+type FunctionMetadata struct {
+	Inputs           uint8
+	Outputs          uint8 // NonReturning for sections that never return
+	MaxStackIncrease uint16
+}
+
+// Container is a parsed EOF container.
+type Container struct {
+	Types      []FunctionMetadata
+	Code       [][]byte
+	Containers [][]byte
+	Data       []byte
+
+	// DataSize is the data section size declared in the header. It exceeds
+	// the length of Data if the container is truncated, which is allowed for
+	// subcontainers deployed by RETURNCODE, where the data is appended
+	// during deployment.
+	DataSize int
+}
+
+// Truncated reports whether the data section is shorter than declared.
+func (c *Container) Truncated() bool {
+	return len(c.Data) < c.DataSize
+}
+
+// MarshalBinary encodes the container. The declared data size is DataSize,
+// or the length of Data if larger.
+func (c *Container) MarshalBinary() ([]byte, error) {
+	if len(c.Types) != len(c.Code) {
+		return nil, fmt.Errorf("%d types for %d code sections", len(c.Types), len(c.Code))
+	}
+	var b bytes.Buffer
+	b.Write(Magic)
+	b.WriteByte(Version)
+	b.WriteByte(kindTypes)
+	b.Write(binary.BigEndian.AppendUint16(nil, uint16(len(c.Types)*functionMetadataLen)))
+	b.WriteByte(kindCode)
+	b.Write(binary.BigEndian.AppendUint16(nil, uint16(len(c.Code))))
+	for _, code := range c.Code {
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(len(code))))
+	}
+	if len(c.Containers) > 0 {
+		b.WriteByte(kindContainer)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(len(c.Containers))))
+		for _, container := range c.Containers {
+			b.Write(binary.BigEndian.AppendUint32(nil, uint32(len(container))))
+		}
+	}
+	b.WriteByte(kindData)
+	b.Write(binary.BigEndian.AppendUint16(nil, uint16(max(c.DataSize, len(c.Data)))))
+	b.WriteByte(terminator)
+	for _, t := range c.Types {
+		b.Write([]byte{t.Inputs, t.Outputs})
+		b.Write(binary.BigEndian.AppendUint16(nil, t.MaxStackIncrease))
+	}
+	for _, code := range c.Code {
+		b.Write(code)
+	}
+	for _, container := range c.Containers {
+		b.Write(container)
+	}
+	b.Write(c.Data)
+	return b.Bytes(), nil
+}
+
+// header is a cursor over the header of a container.
+type header struct {
+	b   []byte
+	pos int
+}
+
+// kind consumes the section kind byte if it equals want.
+func (h *header) kind(want byte) bool {
+	if h.pos >= len(h.b) || h.b[h.pos] != want {
+		return false
+	}
+	h.pos++
+	return true
+}
+
+// uint reads a big endian integer of n bytes, failing with err if the header
+// is too short.
+func (h *header) uint(n int, err error) (int, error) {
+	if h.pos+n > len(h.b) {
+		return 0, err
+	}
+	var v int
+	for _, b := range h.b[h.pos : h.pos+n] {
+		v = v<<8 | int(b)
+	}
+	h.pos += n
+	return v, nil
+}
+
+// sizes reads the section number and the section sizes of a code or
+// container header.
+func (h *header) sizes(sizeLen, limit int, tooMany error) ([]int, error) {
+	n, err := h.uint(2, ErrIncompleteSectionNumber)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrZeroSectionSize
+	}
+	if n > limit {
+		return nil, tooMany
+	}
+	sizes := make([]int, n)
+	for i := range sizes {
+		if sizes[i], err = h.uint(sizeLen, ErrIncompleteSectionSize); err != nil {
+			return nil, err
+		}
+		if sizes[i] == 0 {
+			return nil, ErrZeroSectionSize
+		}
+	}
+	return sizes, nil
+}
+
+// Parse decodes the header and the sections of a container. Only the format
+// is checked: the contents of the sections are left to Validate. A data
+// section shorter than declared is accepted, see Container.DataSize.
+func Parse(b []byte) (*Container, error) {
+	if !bytes.HasPrefix(b, Magic) {
+		return nil, ErrInvalidMagic
+	}
+	if len(b) < 3 || b[2] != Version {
+		return nil, ErrInvalidVersion
+	}
+	h := &header{b: b, pos: 3}
+	if !h.kind(kindTypes) {
+		return nil, ErrMissingTypeHeader
+	}
+	typesSize, err := h.uint(2, ErrIncompleteSectionSize)
+	if err != nil {
+		return nil, err
+	}
+	if typesSize == 0 {
+		return nil, ErrZeroSectionSize
+	}
+	if !h.kind(kindCode) {
+		return nil, ErrMissingCodeHeader
+	}
+	codeSizes, err := h.sizes(2, MaxCodeSections, ErrTooManyCodeSections)
+	if err != nil {
+		return nil, err
+	}
+	if typesSize != len(codeSizes)*functionMetadataLen {
+		return nil, ErrInvalidTypeSectionSize
+	}
+	var containerSizes []int
+	if h.kind(kindContainer) {
+		if containerSizes, err = h.sizes(4, MaxContainers, ErrTooManyContainers); err != nil {
+			return nil, err
+		}
+	}
+	if !h.kind(kindData) {
+		return nil, ErrMissingDataHeader
+	}
+	dataSize, err := h.uint(2, ErrIncompleteSectionSize)
+	if err != nil {
+		return nil, err
+	}
+	if !h.kind(terminator) {
+		return nil, ErrMissingTerminator
+	}
+
+	// The sections preceding the data section have to be complete, the data
+	// section may be truncated.
+	body := b[h.pos:]
+	need := typesSize
+	for _, size := range codeSizes {
+		need += size
+	}
+	for _, size := range containerSizes {
+		need += size
+	}
+	if len(body) < need || len(body) > need+dataSize {
+		return nil, ErrInvalidSectionBodiesSize
+	}
+	c := &Container{DataSize: dataSize}
+	for i := 0; i < typesSize; i += functionMetadataLen {
+		c.Types = append(c.Types, FunctionMetadata{
+			Inputs:           body[i],
+			Outputs:          body[i+1],
+			MaxStackIncrease: binary.BigEndian.Uint16(body[i+2:]),
+		})
+	}
+	pos := typesSize
+	for _, size := range codeSizes {
+		c.Code = append(c.Code, body[pos:pos+size])
+		pos += size
+	}
+	for _, size := range containerSizes {
+		c.Containers = append(c.Containers, body[pos:pos+size])
+		pos += size
+	}
+	c.Data = body[pos:]
+	return c, nil
+}
@@ -0,0 +1,101 @@
+package eof
+
+import "errors"
+
+// Validation errors. Each of them corresponds to one of the exceptions the
+// execution spec tests expect, see Exception.
+var (
+	ErrInvalidMagic                 = errors.New("invalid magic")
+	ErrInvalidVersion               = errors.New("invalid version")
+	ErrMissingTypeHeader            = errors.New("missing type header")
+	ErrMissingCodeHeader            = errors.New("missing code header")
+	ErrMissingDataHeader            = errors.New("missing data header")
+	ErrMissingTerminator            = errors.New("missing header terminator")
+	ErrIncompleteSectionNumber      = errors.New("incomplete section number")
+	ErrIncompleteSectionSize        = errors.New("incomplete section size")
+	ErrZeroSectionSize              = errors.New("zero section size")
+	ErrTooManyCodeSections          = errors.New("too many code sections")
+	ErrTooManyContainers            = errors.New("too many container sections")
+	ErrInvalidTypeSectionSize       = errors.New("invalid type section size")
+	ErrInvalidSectionBodiesSize     = errors.New("invalid section bodies size")
+	ErrTopLevelTruncated            = errors.New("truncated data section in top level container")
+	ErrContainerSizeAboveLimit      = errors.New("container size above limit")
+	ErrInvalidFirstSectionType      = errors.New("invalid type of the first code section")
+	ErrInputsOutputsAboveLimit      = errors.New("number of inputs or outputs above limit")
+	ErrMaxStackIncreaseAboveLimit   = errors.New("max stack increase above limit")
+	ErrUndefinedInstruction         = errors.New("undefined instruction")
+	ErrTruncatedInstruction         = errors.New("truncated instruction")
+	ErrInvalidRjumpDestination      = errors.New("invalid relative jump destination")
+	ErrInvalidCodeSectionIndex      = errors.New("invalid code section index")
+	ErrInvalidContainerSectionIndex = errors.New("invalid container section index")
+	ErrInvalidDataloadnIndex        = errors.New("invalid DATALOADN index")
+	ErrCallfToNonReturning          = errors.New("CALLF to non-returning section")
+	ErrJumpfIncompatibleOutputs     = errors.New("JUMPF destination with incompatible outputs")
+	ErrInvalidNonReturningFlag      = errors.New("invalid non-returning flag")
+	ErrMissingStopOpcode            = errors.New("code does not end with a terminating instruction")
+	ErrUnreachableInstructions      = errors.New("unreachable instructions")
+	ErrUnreachableCodeSections      = errors.New("unreachable code sections")
+	ErrStackUnderflow               = errors.New("stack underflow")
+	ErrStackOverflow                = errors.New("stack overflow")
+	ErrStackHeightMismatch          = errors.New("stack height mismatch")
+	ErrInvalidMaxStackIncrease      = errors.New("invalid max stack increase")
+	ErrOrphanSubcontainer           = errors.New("orphan subcontainer")
+	ErrIncompatibleContainerKind    = errors.New("incompatible container kind")
+	ErrEofcreateTruncatedContainer  = errors.New("EOFCREATE with truncated container")
+)
+
+// exceptions maps the validation errors onto the names of the EOFException
+// enumeration of the execution spec tests.
+var exceptions = []struct {
+	err  error
+	name string
+}{
+	{ErrInvalidMagic, "INVALID_MAGIC"},
+	{ErrInvalidVersion, "INVALID_VERSION"},
+	{ErrMissingTypeHeader, "MISSING_TYPE_HEADER"},
+	{ErrMissingCodeHeader, "MISSING_CODE_HEADER"},
+	{ErrMissingDataHeader, "MISSING_DATA_SECTION"},
+	{ErrMissingTerminator, "MISSING_TERMINATOR"},
+	{ErrIncompleteSectionNumber, "INCOMPLETE_SECTION_NUMBER"},
+	{ErrIncompleteSectionSize, "INCOMPLETE_SECTION_SIZE"},
+	{ErrZeroSectionSize, "ZERO_SECTION_SIZE"},
+	{ErrTooManyCodeSections, "TOO_MANY_CODE_SECTIONS"},
+	{ErrTooManyContainers, "TOO_MANY_CONTAINERS"},
+	{ErrInvalidTypeSectionSize, "INVALID_TYPE_SECTION_SIZE"},
+	{ErrInvalidSectionBodiesSize, "INVALID_SECTION_BODIES_SIZE"},
+	{ErrTopLevelTruncated, "TOPLEVEL_CONTAINER_TRUNCATED"},
+	{ErrContainerSizeAboveLimit, "CONTAINER_SIZE_ABOVE_LIMIT"},
+	{ErrInvalidFirstSectionType, "INVALID_FIRST_SECTION_TYPE"},
+	{ErrInputsOutputsAboveLimit, "INPUTS_OUTPUTS_NUM_ABOVE_LIMIT"},
+	{ErrMaxStackIncreaseAboveLimit, "MAX_STACK_INCREASE_ABOVE_LIMIT"},
+	{ErrUndefinedInstruction, "UNDEFINED_INSTRUCTION"},
+	{ErrTruncatedInstruction, "TRUNCATED_INSTRUCTION"},
+	{ErrInvalidRjumpDestination, "INVALID_RJUMP_DESTINATION"},
+	{ErrInvalidCodeSectionIndex, "INVALID_CODE_SECTION_INDEX"},
+	{ErrInvalidContainerSectionIndex, "INVALID_CONTAINER_SECTION_INDEX"},
+	{ErrInvalidDataloadnIndex, "INVALID_DATALOADN_INDEX"},
+	{ErrCallfToNonReturning, "CALLF_TO_NON_RETURNING"},
+	{ErrJumpfIncompatibleOutputs, "JUMPF_DESTINATION_INCOMPATIBLE_OUTPUTS"},
+	{ErrInvalidNonReturningFlag, "INVALID_NON_RETURNING_FLAG"},
+	{ErrMissingStopOpcode, "MISSING_STOP_OPCODE"},
+	{ErrUnreachableInstructions, "UNREACHABLE_INSTRUCTIONS"},
+	{ErrUnreachableCodeSections, "UNREACHABLE_CODE_SECTIONS"},
+	{ErrStackUnderflow, "STACK_UNDERFLOW"},
+	{ErrStackOverflow, "STACK_OVERFLOW"},
+	{ErrStackHeightMismatch, "STACK_HEIGHT_MISMATCH"},
+	{ErrInvalidMaxStackIncrease, "INVALID_MAX_STACK_INCREASE"},
+	{ErrOrphanSubcontainer, "ORPHAN_SUBCONTAINER"},
+	{ErrIncompatibleContainerKind, "INCOMPATIBLE_CONTAINER_KIND"},
+	{ErrEofcreateTruncatedContainer, "EOFCREATE_WITH_TRUNCATED_CONTAINER"},
+}
+
+// Exception returns the execution spec test exception of a validation error,
+// in the form "EOFException.NAME", or "" if err is not a validation error.
+func Exception(err error) string {
+	for _, e := range exceptions {
+		if errors.Is(err, e.err) {
+			return "EOFException." + e.name
+		}
+	}
+	return ""
+}
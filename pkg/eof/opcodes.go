@@ -0,0 +1,151 @@
+package eof
+
+import "fmt"
+
+// Opcodes with special meaning in EOF code.
+const (
+	STOP       = 0x00
+	PUSH1      = 0x60
+	PUSH32     = 0x7f
+	DUP1       = 0x80
+	SWAP1      = 0x90
+	DATALOADN  = 0xd1
+	RJUMP      = 0xe0
+	RJUMPI     = 0xe1
+	RJUMPV     = 0xe2
+	CALLF      = 0xe3
+	RETF       = 0xe4
+	JUMPF      = 0xe5
+	DUPN       = 0xe6
+	SWAPN      = 0xe7
+	EXCHANGE   = 0xe8
+	EOFCREATE  = 0xec
+	RETURNCODE = 0xee
+	RETURN     = 0xf3
+	REVERT     = 0xfd
+	INVALID    = 0xfe
+)
+
+// opInfo describes an instruction that is valid in EOF code. The stack inputs
+// and outputs of the instructions depending on their immediates (CALLF,
+// JUMPF, DUPN, SWAPN, EXCHANGE) are computed during validation.
+type opInfo struct {
+	name        string
+	valid       bool
+	inputs      int
+	outputs     int
+	immediate   int  // size of the immediate, RJUMPV has a variable one
+	terminating bool // ends the control flow of the code section
+}
+
+// opcodes is the EOFv1 instruction set. The legacy instructions inspecting
+// or jumping within the code, the gas observing ones and the legacy calls
+// and creates are undefined in EOF code.
+var opcodes [256]opInfo
+
+func define(op byte, name string, inputs, outputs, immediate int) {
+	opcodes[op] = opInfo{name: name, valid: true, inputs: inputs, outputs: outputs, immediate: immediate}
+}
+
+func init() {
+	define(0x00, "STOP", 0, 0, 0)
+	for op, name := range []string{"ADD", "MUL", "SUB", "DIV", "SDIV", "MOD", "SMOD"} {
+		define(byte(0x01+op), name, 2, 1, 0)
+	}
+	define(0x08, "ADDMOD", 3, 1, 0)
+	define(0x09, "MULMOD", 3, 1, 0)
+	define(0x0a, "EXP", 2, 1, 0)
+	define(0x0b, "SIGNEXTEND", 2, 1, 0)
+	for op, name := range []string{"LT", "GT", "SLT", "SGT", "EQ"} {
+		define(byte(0x10+op), name, 2, 1, 0)
+	}
+	define(0x15, "ISZERO", 1, 1, 0)
+	define(0x16, "AND", 2, 1, 0)
+	define(0x17, "OR", 2, 1, 0)
+	define(0x18, "XOR", 2, 1, 0)
+	define(0x19, "NOT", 1, 1, 0)
+	define(0x1a, "BYTE", 2, 1, 0)
+	define(0x1b, "SHL", 2, 1, 0)
+	define(0x1c, "SHR", 2, 1, 0)
+	define(0x1d, "SAR", 2, 1, 0)
+	define(0x1e, "CLZ", 1, 1, 0)
+	define(0x20, "KECCAK256", 2, 1, 0)
+	define(0x30, "ADDRESS", 0, 1, 0)
+	define(0x31, "BALANCE", 1, 1, 0)
+	define(0x32, "ORIGIN", 0, 1, 0)
+	define(0x33, "CALLER", 0, 1, 0)
+	define(0x34, "CALLVALUE", 0, 1, 0)
+	define(0x35, "CALLDATALOAD", 1, 1, 0)
+	define(0x36, "CALLDATASIZE", 0, 1, 0)
+	define(0x37, "CALLDATACOPY", 3, 0, 0)
+	define(0x3a, "GASPRICE", 0, 1, 0)
+	define(0x3d, "RETURNDATASIZE", 0, 1, 0)
+	define(0x3e, "RETURNDATACOPY", 3, 0, 0)
+	define(0x40, "BLOCKHASH", 1, 1, 0)
+	for op, name := range []string{"COINBASE", "TIMESTAMP", "NUMBER", "PREVRANDAO", "GASLIMIT", "CHAINID", "SELFBALANCE", "BASEFEE"} {
+		define(byte(0x41+op), name, 0, 1, 0)
+	}
+	define(0x49, "BLOBHASH", 1, 1, 0)
+	define(0x4a, "BLOBBASEFEE", 0, 1, 0)
+	define(0x50, "POP", 1, 0, 0)
+	define(0x51, "MLOAD", 1, 1, 0)
+	define(0x52, "MSTORE", 2, 0, 0)
+	define(0x53, "MSTORE8", 2, 0, 0)
+	define(0x54, "SLOAD", 1, 1, 0)
+	define(0x55, "SSTORE", 2, 0, 0)
+	define(0x59, "MSIZE", 0, 1, 0)
+	define(0x5b, "NOP", 0, 0, 0)
+	define(0x5c, "TLOAD", 1, 1, 0)
+	define(0x5d, "TSTORE", 2, 0, 0)
+	define(0x5e, "MCOPY", 3, 0, 0)
+	define(0x5f, "PUSH0", 0, 1, 0)
+	for n := 1; n <= 32; n++ {
+		define(byte(PUSH1+n-1), fmt.Sprintf("PUSH%d", n), 0, 1, n)
+	}
+	for n := 1; n <= 16; n++ {
+		define(byte(DUP1+n-1), fmt.Sprintf("DUP%d", n), n, n+1, 0)
+		define(byte(SWAP1+n-1), fmt.Sprintf("SWAP%d", n), n+1, n+1, 0)
+	}
+	for n := 0; n <= 4; n++ {
+		define(byte(0xa0+n), fmt.Sprintf("LOG%d", n), 2+n, 0, 0)
+	}
+	define(0xd0, "DATALOAD", 1, 1, 0)
+	define(DATALOADN, "DATALOADN", 0, 1, 2)
+	define(0xd2, "DATASIZE", 0, 1, 0)
+	define(0xd3, "DATACOPY", 3, 0, 0)
+	define(RJUMP, "RJUMP", 0, 0, 2)
+	define(RJUMPI, "RJUMPI", 1, 0, 2)
+	define(RJUMPV, "RJUMPV", 1, 0, 1)
+	define(CALLF, "CALLF", 0, 0, 2)
+	define(RETF, "RETF", 0, 0, 0)
+	define(JUMPF, "JUMPF", 0, 0, 2)
+	define(DUPN, "DUPN", 0, 0, 1)
+	define(SWAPN, "SWAPN", 0, 0, 1)
+	define(EXCHANGE, "EXCHANGE", 0, 0, 1)
+	define(EOFCREATE, "EOFCREATE", 4, 1, 1)
+	define(RETURNCODE, "RETURNCODE", 2, 0, 1)
+	define(RETURN, "RETURN", 2, 0, 0)
+	define(0xf7, "RETURNDATALOAD", 1, 1, 0)
+	define(0xf8, "EXTCALL", 4, 1, 0)
+	define(0xf9, "EXTDELEGATECALL", 3, 1, 0)
+	define(0xfb, "EXTSTATICCALL", 3, 1, 0)
+	define(REVERT, "REVERT", 2, 0, 0)
+	define(INVALID, "INVALID", 0, 0, 0)
+
+	for _, op := range []byte{STOP, RETF, JUMPF, RETURNCODE, RETURN, REVERT, INVALID} {
+		opcodes[op].terminating = true
+	}
+}
+
+// Valid reports whether an opcode is defined in EOF code.
+func Valid(op byte) bool {
+	return opcodes[op].valid
+}
+
+// OpName returns the name of an EOF opcode.
+func OpName(op byte) string {
+	if !opcodes[op].valid {
+		return fmt.Sprintf("opcode %#x", op)
+	}
+	return opcodes[op].name
+}
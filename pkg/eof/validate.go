@@ -0,0 +1,342 @@
+package eof
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Kind is the kind of a container, which determines the instructions its code
+// may terminate with.
+type Kind int
+
+const (
+	// Runtime containers are deployed code. They may not use RETURNCODE.
+	Runtime Kind = iota
+
+	// Initcode containers are run by EOFCREATE and creation transactions and
+	// deploy a subcontainer with RETURNCODE. They may not use RETURN or STOP.
+	Initcode
+)
+
+func (k Kind) String() string {
+	if k == Initcode {
+		return "INITCODE"
+	}
+	return "RUNTIME"
+}
+
+// references collects the code sections and subcontainers referenced by the
+// code of a container.
+type references struct {
+	sections  [][]int // callees of each code section, by CALLF and JUMPF
+	eofcreate []bool  // subcontainers created by EOFCREATE
+	deployed  []bool  // subcontainers deployed by RETURNCODE
+}
+
+// Validate parses and validates a top level container of the given kind,
+// including its subcontainers.
+func Validate(code []byte, kind Kind) error {
+	if len(code) > MaxContainerSize {
+		return ErrContainerSizeAboveLimit
+	}
+	c, err := Parse(code)
+	if err != nil {
+		return err
+	}
+	if c.Truncated() {
+		return ErrTopLevelTruncated
+	}
+	return validateContainer(c, kind)
+}
+
+// validateContainer validates the sections of a parsed container and
+// recursively the subcontainers, whose kind is derived from the instructions
+// referencing them.
+func validateContainer(c *Container, kind Kind) error {
+	if err := validateTypes(c.Types); err != nil {
+		return err
+	}
+	refs := &references{
+		sections:  make([][]int, len(c.Code)),
+		eofcreate: make([]bool, len(c.Containers)),
+		deployed:  make([]bool, len(c.Containers)),
+	}
+	for i := range c.Code {
+		if err := validateCode(c, i, kind, refs); err != nil {
+			return fmt.Errorf("code section %d: %w", i, err)
+		}
+	}
+	// Every code section has to be reachable from the first one.
+	reached := make([]bool, len(c.Code))
+	reached[0] = true
+	for queue := []int{0}; len(queue) > 0; queue = queue[1:] {
+		for _, callee := range refs.sections[queue[0]] {
+			if !reached[callee] {
+				reached[callee] = true
+				queue = append(queue, callee)
+			}
+		}
+	}
+	for i := range reached {
+		if !reached[i] {
+			return fmt.Errorf("code section %d: %w", i, ErrUnreachableCodeSections)
+		}
+	}
+	for i, code := range c.Containers {
+		var subKind Kind
+		switch {
+		case refs.eofcreate[i] && refs.deployed[i]:
+			return fmt.Errorf("container %d: %w", i, ErrIncompatibleContainerKind)
+		case refs.eofcreate[i]:
+			subKind = Initcode
+		case refs.deployed[i]:
+			subKind = Runtime
+		default:
+			return fmt.Errorf("container %d: %w", i, ErrOrphanSubcontainer)
+		}
+		sub, err := Parse(code)
+		if err != nil {
+			return fmt.Errorf("container %d: %w", i, err)
+		}
+		if sub.Truncated() && subKind == Initcode {
+			return fmt.Errorf("container %d: %w", i, ErrEofcreateTruncatedContainer)
+		}
+		if err := validateContainer(sub, subKind); err != nil {
+			return fmt.Errorf("container %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateTypes checks the limits of the types section entries.
+func validateTypes(types []FunctionMetadata) error {
+	if types[0].Inputs != 0 || types[0].Outputs != NonReturning {
+		return ErrInvalidFirstSectionType
+	}
+	for i, t := range types {
+		if t.Inputs > maxInputsOutputs || (t.Outputs > maxInputsOutputs && t.Outputs != NonReturning) {
+			return fmt.Errorf("type %d: %w", i, ErrInputsOutputsAboveLimit)
+		}
+		if t.MaxStackIncrease > maxStackIncrease {
+			return fmt.Errorf("type %d: %w", i, ErrMaxStackIncreaseAboveLimit)
+		}
+	}
+	return nil
+}
+
+// instructionSize returns the size of the instruction at pos including its
+// immediate, which may extend past the end of the code.
+func instructionSize(code []byte, pos int) int {
+	size := 1 + opcodes[code[pos]].immediate
+	if code[pos] == RJUMPV && pos+1 < len(code) {
+		size += 2 * (int(code[pos+1]) + 1)
+	}
+	return size
+}
+
+// jumpTargets returns the destinations of the relative jump at pos.
+func jumpTargets(code []byte, pos int) []int {
+	offset := func(at int) int {
+		return int(int16(binary.BigEndian.Uint16(code[at:])))
+	}
+	end := pos + instructionSize(code, pos)
+	if code[pos] != RJUMPV {
+		return []int{end + offset(pos+1)}
+	}
+	targets := make([]int, int(code[pos+1])+1)
+	for i := range targets {
+		targets[i] = end + offset(pos+2+2*i)
+	}
+	return targets
+}
+
+// validateCode checks the instructions of a code section (EIP-3670, EIP-4750)
+// and records the sections and containers they reference, then validates the
+// stack (EIP-5450).
+func validateCode(c *Container, section int, kind Kind, refs *references) error {
+	var (
+		code    = c.Code[section]
+		meta    = c.Types[section]
+		starts  = make([]bool, len(code))
+		jumps   []int
+		returns bool
+		last    int
+	)
+	for pos := 0; pos < len(code); pos += instructionSize(code, pos) {
+		op := code[pos]
+		if !opcodes[op].valid {
+			return fmt.Errorf("%w %#x at %d", ErrUndefinedInstruction, op, pos)
+		}
+		size := instructionSize(code, pos)
+		if pos+size > len(code) {
+			return fmt.Errorf("%w %s at %d", ErrTruncatedInstruction, OpName(op), pos)
+		}
+		starts[pos], last = true, pos
+		imm := code[pos+1 : pos+size]
+
+		switch op {
+		case RJUMP, RJUMPI, RJUMPV:
+			jumps = append(jumps, pos)
+		case CALLF, JUMPF:
+			target := int(binary.BigEndian.Uint16(imm))
+			if target >= len(c.Code) {
+				return fmt.Errorf("%w %d at %d", ErrInvalidCodeSectionIndex, target, pos)
+			}
+			refs.sections[section] = append(refs.sections[section], target)
+			callee := c.Types[target]
+			if callee.Outputs == NonReturning {
+				if op == CALLF {
+					return fmt.Errorf("%w %d at %d", ErrCallfToNonReturning, target, pos)
+				}
+				break
+			}
+			// JUMPF to a returning section returns from this one.
+			if op == JUMPF {
+				returns = true
+				if meta.Outputs != NonReturning && callee.Outputs > meta.Outputs {
+					return fmt.Errorf("%w %d at %d", ErrJumpfIncompatibleOutputs, target, pos)
+				}
+			}
+		case RETF:
+			returns = true
+		case DATALOADN:
+			if offset := int(binary.BigEndian.Uint16(imm)); offset+32 > c.DataSize {
+				return fmt.Errorf("%w %d at %d", ErrInvalidDataloadnIndex, offset, pos)
+			}
+		case EOFCREATE, RETURNCODE:
+			index := int(imm[0])
+			if index >= len(c.Containers) {
+				return fmt.Errorf("%w %d at %d", ErrInvalidContainerSectionIndex, index, pos)
+			}
+			if op == EOFCREATE {
+				refs.eofcreate[index] = true
+			} else {
+				if kind == Runtime {
+					return fmt.Errorf("%w: RETURNCODE in runtime code at %d", ErrIncompatibleContainerKind, pos)
+				}
+				refs.deployed[index] = true
+			}
+		case STOP, RETURN:
+			if kind == Initcode {
+				return fmt.Errorf("%w: %s in initcode at %d", ErrIncompatibleContainerKind, OpName(op), pos)
+			}
+		}
+	}
+	if returns != (meta.Outputs != NonReturning) {
+		return ErrInvalidNonReturningFlag
+	}
+	if op := code[last]; !opcodes[op].terminating && op != RJUMP {
+		return ErrMissingStopOpcode
+	}
+	for _, pos := range jumps {
+		for _, target := range jumpTargets(code, pos) {
+			if target < 0 || target >= len(code) || !starts[target] {
+				return fmt.Errorf("%w %d at %d", ErrInvalidRjumpDestination, target, pos)
+			}
+		}
+	}
+	return validateStack(c, section)
+}
+
+// stackRange is the range of stack heights an instruction may be reached with.
+type stackRange struct {
+	min, max int
+}
+
+// stackEffect returns the number of stack items an instruction takes and
+// leaves on the stack.
+func stackEffect(c *Container, code []byte, pos int) (inputs, outputs int) {
+	op := code[pos]
+	switch op {
+	case CALLF, JUMPF:
+		callee := c.Types[binary.BigEndian.Uint16(code[pos+1:])]
+		if op == JUMPF || callee.Outputs == NonReturning {
+			return int(callee.Inputs), 0
+		}
+		return int(callee.Inputs), int(callee.Outputs)
+	case DUPN:
+		n := int(code[pos+1]) + 1
+		return n, n + 1
+	case SWAPN:
+		n := int(code[pos+1]) + 2
+		return n, n
+	case EXCHANGE:
+		n := int(code[pos+1]>>4) + int(code[pos+1]&0x0f) + 3
+		return n, n
+	}
+	return opcodes[op].inputs, opcodes[op].outputs
+}
+
+// validateStack computes the stack height ranges of the instructions of a code
+// section in a single forward pass. Instructions are only reached forwards or
+// by backward jumps, which have to agree exactly with the heights computed
+// for their destination.
+func validateStack(c *Container, section int) error {
+	var (
+		code    = c.Code[section]
+		meta    = c.Types[section]
+		heights = make([]*stackRange, len(code))
+		highest = int(meta.Inputs)
+	)
+	heights[0] = &stackRange{int(meta.Inputs), int(meta.Inputs)}
+
+	for pos := 0; pos < len(code); pos += instructionSize(code, pos) {
+		h := heights[pos]
+		if h == nil {
+			return fmt.Errorf("%w at %d", ErrUnreachableInstructions, pos)
+		}
+		op := code[pos]
+		inputs, outputs := stackEffect(c, code, pos)
+		if h.min < inputs {
+			return fmt.Errorf("%w: %s at %d needs %d items, has %d", ErrStackUnderflow, OpName(op), pos, inputs, h.min)
+		}
+		switch op {
+		case CALLF, JUMPF:
+			callee := c.Types[binary.BigEndian.Uint16(code[pos+1:])]
+			if h.max+int(callee.MaxStackIncrease) > stackLimit {
+				return fmt.Errorf("%w: %s at %d", ErrStackOverflow, OpName(op), pos)
+			}
+			if op == JUMPF && callee.Outputs != NonReturning {
+				want := int(meta.Outputs) + int(callee.Inputs) - int(callee.Outputs)
+				if h.min != want || h.max != want {
+					return fmt.Errorf("%w: JUMPF at %d with stack height %d-%d, want %d", ErrStackHeightMismatch, pos, h.min, h.max, want)
+				}
+			}
+		case RETF:
+			if h.min != int(meta.Outputs) || h.max != int(meta.Outputs) {
+				return fmt.Errorf("%w: RETF at %d with stack height %d-%d, want %d", ErrStackHeightMismatch, pos, h.min, h.max, meta.Outputs)
+			}
+		}
+		next := stackRange{h.min - inputs + outputs, h.max - inputs + outputs}
+		highest = max(highest, next.max)
+
+		var successors []int
+		if !opcodes[op].terminating && op != RJUMP {
+			successors = append(successors, pos+instructionSize(code, pos))
+		}
+		if op == RJUMP || op == RJUMPI || op == RJUMPV {
+			successors = append(successors, jumpTargets(code, pos)...)
+		}
+		for _, target := range successors {
+			switch {
+			case target <= pos:
+				if *heights[target] != next {
+					return fmt.Errorf("%w: backward jump at %d with stack height %d-%d, target has %d-%d",
+						ErrStackHeightMismatch, pos, next.min, next.max, heights[target].min, heights[target].max)
+				}
+			case heights[target] == nil:
+				heights[target] = &stackRange{next.min, next.max}
+			default:
+				heights[target].min = min(heights[target].min, next.min)
+				heights[target].max = max(heights[target].max, next.max)
+			}
+		}
+	}
+	if highest > stackLimit {
+		return fmt.Errorf("%w: max stack height %d", ErrStackOverflow, highest)
+	}
+	if increase := highest - int(meta.Inputs); increase != int(meta.MaxStackIncrease) {
+		return fmt.Errorf("%w: declared %d, computed %d", ErrInvalidMaxStackIncrease, meta.MaxStackIncrease, increase)
+	}
+	return nil
+}
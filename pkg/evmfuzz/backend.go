@@ -0,0 +1,180 @@
+package evmfuzz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ethereum/execution-specs/pkg/trace"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// DefaultCommands are the statetest commands of the known clients. All of them
+// follow the goevmlab conventions: results on stdout, EIP-3155 traces on
+// stderr when invoked with --json.
+var DefaultCommands = map[string]string{
+	"eels": "ethereum-spec-evm statetest --json",
+	"geth": "evm statetest --json",
+	"besu": "evmtool state-test --json",
+}
+
+// Result is the outcome of a state test on a backend.
+type Result struct {
+	Backend   string
+	StateRoot common.Hash
+	Steps     []trace.Step
+}
+
+// Backend executes state tests and traces them.
+type Backend interface {
+	Name() string
+	// Run executes the first post state of the fork of a state test, given
+	// as its JSON encoding. An error means that the backend failed to
+	// execute it, which is a finding of its own.
+	Run(test json.RawMessage, fork string) (*Result, error)
+}
+
+// ParseBackend parses a backend definition: "go" for go-ethereum in process,
+// NAME for the statetest command of a known client, or NAME=COMMAND.
+func ParseBackend(def string, timeout time.Duration) (Backend, error) {
+	name, command, ok := strings.Cut(def, "=")
+	if !ok {
+		if name == "go" {
+			return GoBackend{}, nil
+		}
+		command, ok = DefaultCommands[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown backend %q, give its command as %s=COMMAND", name, name)
+		}
+	}
+	fields := strings.Fields(command)
+	if name == "" || len(fields) == 0 {
+		return nil, fmt.Errorf("invalid backend %q", def)
+	}
+	return &CommandBackend{name: name, command: fields, timeout: timeout}, nil
+}
+
+// GoBackend executes state tests with go-ethereum in process.
+type GoBackend struct{}
+
+func (GoBackend) Name() string { return "go" }
+
+func (GoBackend) Run(data json.RawMessage, fork string) (result *Result, err error) {
+	var test tests.StateTest
+	if err := json.Unmarshal(data, &test); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	config := vm.Config{Tracer: logger.NewJSONLogger(&logger.Config{}, &out)}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	st, root, _, err := test.RunNoVerify(tests.StateSubtest{Fork: fork}, config, false, rawdb.HashScheme)
+	defer st.Close()
+	switch err.(type) {
+	case nil:
+	case tests.UnsupportedForkError:
+		return nil, err
+	default:
+		// A rejected transaction leaves the pre state, with the coinbase
+		// touched, as the post state.
+		root = st.StateDB.IntermediateRoot(true)
+	}
+	t, err := trace.Parse(&out)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Backend: "go", StateRoot: root, Steps: t.Steps}, nil
+}
+
+// CommandBackend runs a client statetest command on a temporary file holding
+// the state test. The command runs every post state and the first result is
+// taken, so the test should only hold the compared one.
+type CommandBackend struct {
+	name    string
+	command []string
+	timeout time.Duration
+}
+
+func (b *CommandBackend) Name() string { return b.name }
+
+func (b *CommandBackend) Run(test json.RawMessage, fork string) (*Result, error) {
+	data, err := json.Marshal(map[string]json.RawMessage{"evmfuzz": test})
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp("", "evmfuzz-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(data)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, b.command[0], append(b.command[1:], file.Name())...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err = cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("timed out after %v", b.timeout)
+	case err != nil:
+		return nil, fmt.Errorf("%v: %s", err, lastLine(stderr.Bytes()))
+	}
+	t, err := trace.Parse(&stderr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace: %v", err)
+	}
+	root, err := stateRoot(stdout.Bytes(), t)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Backend: b.name, StateRoot: root, Steps: t.Steps}, nil
+}
+
+// stateRoot extracts the post state root from the results a statetest
+// command prints as the last JSON array on stdout, or from the state root
+// reported after the trace.
+func stateRoot(stdout []byte, t *trace.Trace) (common.Hash, error) {
+	var results []struct {
+		StateRoot string `json:"stateRoot"`
+		PostHash  string `json:"postHash"` // besu reports the state root here
+	}
+	start := bytes.LastIndex(stdout, []byte("\n["))
+	if start >= 0 || bytes.HasPrefix(stdout, []byte("[")) {
+		json.Unmarshal(stdout[start+1:], &results)
+	}
+	switch {
+	case len(results) > 0 && results[0].StateRoot != "":
+		return common.HexToHash(results[0].StateRoot), nil
+	case len(results) > 0 && results[0].PostHash != "":
+		return common.HexToHash(results[0].PostHash), nil
+	case len(t.StateRoots) > 0:
+		return common.HexToHash(t.StateRoots[0]), nil
+	}
+	return common.Hash{}, errors.New("no state root reported")
+}
+
+func lastLine(output []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return lines[len(lines)-1]
+}
@@ -0,0 +1,146 @@
+package evmfuzz
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/execution-specs/pkg/trace"
+)
+
+// Finding is a divergence of a backend from the first one.
+type Finding struct {
+	// Signature identifies the kind of divergence independently of the
+	// input, so that findings caused by the same bug can be deduplicated:
+	//
+	//   - "error <backend>" if the backend failed to execute the test,
+	//   - "<first> vs <backend> at <op> <field>" if the traces diverge,
+	//   - "<first> vs <backend> at stateRoot" if the post states differ with
+	//     equal traces.
+	Signature string
+	Detail    string
+	Input     *Input             // set by Execute
+	Fixture   *statetest.Fixture // reproducer annotated with the finding, set by Execute
+}
+
+// Outcome is the result of executing an input on every backend.
+type Outcome struct {
+	Results []*Result // nil for backends which failed
+	Finding *Finding  // first divergence, if any
+}
+
+// Execute runs the input on the fork on every backend and compares their
+// results with those of the first backend. An error is returned if the input
+// cannot be converted into a state test; failures of the backends are
+// findings.
+func Execute(in *Input, fork string, backends []Backend) (*Outcome, error) {
+	f, err := in.StateTest(fork)
+	if err != nil {
+		return nil, err
+	}
+	test, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	outcome := Compare(test, fork, backends)
+	if outcome.Finding != nil {
+		outcome.Finding.Input = in
+		outcome.Finding.Fixture = annotate(f, outcome)
+	}
+	return outcome, nil
+}
+
+// Compare runs the first post state of the fork of a state test on every
+// backend and compares their results with those of the first backend.
+func Compare(test json.RawMessage, fork string, backends []Backend) *Outcome {
+	outcome := &Outcome{Results: make([]*Result, len(backends))}
+	for i, b := range backends {
+		result, err := b.Run(test, fork)
+		if err != nil && outcome.Finding == nil {
+			outcome.Finding = &Finding{Signature: "error " + b.Name(), Detail: fmt.Sprintf("%s: %v", b.Name(), err)}
+		}
+		outcome.Results[i] = result
+	}
+	first := outcome.Results[0]
+	for _, result := range outcome.Results[1:] {
+		if outcome.Finding != nil {
+			break
+		}
+		outcome.Finding = compare(first, result)
+	}
+	return outcome
+}
+
+// CompareResults returns the divergence of the result b of a backend from the
+// result a of another one, or nil if they agree, for callers which compare
+// results computed once against several backends.
+func CompareResults(a, b *Result) *Finding {
+	return compare(a, b)
+}
+
+// compare returns the divergence between two results, or nil.
+func compare(a, b *Result) *Finding {
+	signature := a.Backend + " vs " + b.Backend
+	d := trace.Diff(a.Steps, b.Steps)
+	if d == nil {
+		if a.StateRoot == b.StateRoot {
+			return nil
+		}
+		return &Finding{
+			Signature: signature + " at stateRoot",
+			Detail:    fmt.Sprintf("%s and %s traces match, state roots %x and %x differ", a.Backend, b.Backend, a.StateRoot, b.StateRoot),
+		}
+	}
+	op, aValue, bValue := "end", "end of trace", "end of trace"
+	if d.Index < len(a.Steps) {
+		op, aValue = a.Steps[d.Index].Name(), a.Steps[d.Index].Field(d.Field)
+	}
+	if d.Index < len(b.Steps) {
+		bValue = b.Steps[d.Index].Field(d.Field)
+		if op == "end" {
+			op = b.Steps[d.Index].Name()
+		}
+	}
+	return &Finding{
+		Signature: fmt.Sprintf("%s at %s %s", signature, op, d.Field),
+		Detail:    fmt.Sprintf("%s and %s diverge at step %d (%s): %s vs %s", a.Backend, b.Backend, d.Index, op, aValue, bValue),
+	}
+}
+
+// annotate records the finding and the state root of every backend in the
+// info of a copy of the fixture. The post state stays the one computed by
+// go-ethereum, so that runners of the reproducer fail where they diverge
+// from it.
+func annotate(f *statetest.Fixture, outcome *Outcome) *statetest.Fixture {
+	annotated := *f
+	annotated.Info = make(map[string]string, len(f.Info)+2+len(outcome.Results))
+	for key, value := range f.Info {
+		annotated.Info[key] = value
+	}
+	annotated.Info["signature"] = outcome.Finding.Signature
+	annotated.Info["comment"] = outcome.Finding.Detail
+	for _, result := range outcome.Results {
+		if result != nil {
+			annotated.Info["stateRoot-"+result.Backend] = result.StateRoot.Hex()
+		}
+	}
+	return &annotated
+}
+
+// WriteReproducer writes the reproducer of a finding to the directory as a
+// state test file named after the input and returns its path.
+func WriteReproducer(dir string, finding *Finding) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("evmfuzz_%x", sha256.Sum256(finding.Input.Encode()))[:24]
+	data, err := json.MarshalIndent(map[string]*statetest.Fixture{name: finding.Fixture}, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name+".json")
+	return path, os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,70 @@
+package evmfuzz
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// The environment variables configuring the go-fuzz and libFuzzer harness.
+const (
+	EnvBackend  = "EVMFUZZ_BACKEND"  // backend compared with go-ethereum, as for ParseBackend (default geth)
+	EnvFork     = "EVMFUZZ_FORK"     // fork the inputs are executed on (default Prague)
+	EnvFindings = "EVMFUZZ_FINDINGS" // directory the reproducers are written to (default findings)
+)
+
+// harness is the configuration of Fuzz, read from the environment on first
+// use.
+var harness = sync.OnceValues(func() ([]Backend, error) {
+	def := os.Getenv(EnvBackend)
+	if def == "" {
+		def = "geth"
+	}
+	b, err := ParseBackend(def, time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	return []Backend{GoBackend{}, b}, nil
+})
+
+// Fuzz is the go-fuzz and libFuzzer entry point. It executes the input with
+// go-ethereum in process and with the backend given by $EVMFUZZ_BACKEND. A
+// divergence writes its reproducer to $EVMFUZZ_FINDINGS and panics, so that
+// the fuzzer records the input as a crasher.
+//
+// Inputs whose programs execute are given priority, inputs without a program
+// are not added to the corpus.
+func Fuzz(data []byte) int {
+	backends, err := harness()
+	if err != nil {
+		panic(err)
+	}
+	fork := os.Getenv(EnvFork)
+	if fork == "" {
+		fork = "Prague"
+	}
+	in := Decode(data)
+	if len(in.Program) == 0 {
+		return -1
+	}
+	outcome, err := Execute(in, fork, backends)
+	if err != nil {
+		panic(err)
+	}
+	if f := outcome.Finding; f != nil {
+		dir := os.Getenv(EnvFindings)
+		if dir == "" {
+			dir = "findings"
+		}
+		path, err := WriteReproducer(dir, f)
+		if err != nil {
+			panic(err)
+		}
+		panic(fmt.Sprintf("%s: %s (reproducer %s)", f.Signature, f.Detail, path))
+	}
+	if len(outcome.Results[0].Steps) > 1 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,134 @@
+// Package evmfuzz is a structure-aware differential fuzzer of the EVM. A fuzzer
+// input decodes into a program and the parameters of the transaction calling
+// it. The input is executed on two or more backends, and any difference of
+// their post states or EIP-3155 traces is a finding, which is reproduced as a
+// state test.
+//
+// The package is a go-fuzz harness, which also builds for libFuzzer:
+//
+//	go-fuzz-build github.com/ethereum/execution-specs/pkg/evmfuzz
+//	go-fuzz-build -libfuzzer -o evmfuzz.a github.com/ethereum/execution-specs/pkg/evmfuzz
+//
+// The coverage guiding these fuzzers is the one of the go-ethereum
+// interpreter executing every input in process. cmd/evm-fuzz drives the
+// package without them, guided by the opcode transitions of the traces.
+package evmfuzz
+
+import (
+	"encoding/binary"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// The limits of the decoded transaction parameters.
+const (
+	MaxGas      = 1 << 20 // execution gas, on top of the intrinsic gas
+	MaxCallData = 255     // calldata length
+)
+
+// Opcodes are the instructions programs are composed of: every opcode known
+// to go-ethereum, in numerical order. An instruction is encoded by its index.
+var Opcodes = func() []vm.OpCode {
+	var ops []vm.OpCode
+	for b := 0; b < 256; b++ {
+		if op := vm.OpCode(b); !strings.Contains(op.String(), "not defined") {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}()
+
+// Instruction is an opcode with the immediate of PUSH1 to PUSH32.
+type Instruction struct {
+	Op  vm.OpCode
+	Arg []byte
+}
+
+// Input is a decoded fuzzer input: the program deployed at the called
+// account and the parameters of the transaction.
+type Input struct {
+	Gas      uint64 // execution gas, below MaxGas
+	Value    uint64 // wei transferred, below 256
+	CallData []byte
+	Program  []Instruction
+}
+
+// Decode decodes a fuzzer input. The encoding is
+//
+//	gas (3 bytes) | value (1 byte) | calldata length (1 byte) | calldata | program
+//
+// where the program is a sequence of instructions, each the index of its
+// opcode in Opcodes followed by the immediate of a push. Every byte string
+// decodes: indexes wrap around, values beyond the limits are reduced and
+// truncated fields are padded with zeros.
+func Decode(data []byte) *Input {
+	next := func(n int) []byte {
+		field := make([]byte, n)
+		data = data[copy(field, data):]
+		return field
+	}
+	in := new(Input)
+	in.Gas = uint64(binary.BigEndian.Uint32(append([]byte{0}, next(3)...))) % MaxGas
+	in.Value = uint64(next(1)[0])
+	in.CallData = next(int(next(1)[0]))
+	for len(data) > 0 {
+		op := Opcodes[int(next(1)[0])%len(Opcodes)]
+		in.Program = append(in.Program, Instruction{Op: op, Arg: next(immediateSize(op))})
+	}
+	return in
+}
+
+// Encode returns the encoding of the input, which decodes into it again.
+func (in *Input) Encode() []byte {
+	var gas [4]byte
+	binary.BigEndian.PutUint32(gas[:], uint32(in.Gas%MaxGas))
+	data := append(gas[1:], byte(in.Value), byte(len(in.CallData)))
+	data = append(data, in.CallData...)
+	for _, ins := range in.Program {
+		index, _ := slices.BinarySearch(Opcodes, ins.Op)
+		data = append(data, byte(index))
+		data = append(data, immediate(ins)...)
+	}
+	return data
+}
+
+// Code assembles the program.
+func (in *Input) Code() []byte {
+	var code []byte
+	for _, ins := range in.Program {
+		code = append(code, byte(ins.Op))
+		code = append(code, immediate(ins)...)
+	}
+	return code
+}
+
+// Copy returns a deep copy of the input.
+func (in *Input) Copy() *Input {
+	cpy := *in
+	cpy.CallData = slices.Clone(in.CallData)
+	cpy.Program = make([]Instruction, len(in.Program))
+	for i, ins := range in.Program {
+		cpy.Program[i] = Instruction{Op: ins.Op, Arg: slices.Clone(ins.Arg)}
+	}
+	return &cpy
+}
+
+// immediate returns the immediate of an instruction, zero padded on the left
+// or truncated to the size its opcode takes.
+func immediate(ins Instruction) []byte {
+	size := immediateSize(ins.Op)
+	arg := ins.Arg
+	if len(arg) > size {
+		arg = arg[len(arg)-size:]
+	}
+	return append(make([]byte, size-len(arg)), arg...)
+}
+
+func immediateSize(op vm.OpCode) int {
+	if op >= vm.PUSH1 && op <= vm.PUSH32 {
+		return int(op-vm.PUSH1) + 1
+	}
+	return 0
+}
@@ -0,0 +1,183 @@
+package evmfuzz
+
+import (
+	"math/big"
+	"math/rand"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// MaxProgram is the number of instructions mutations grow programs to.
+const MaxProgram = 512
+
+// interestingWords are push immediates likely to hit edge cases: small
+// numbers, offsets and sizes, the precompiles, the accounts of the state test
+// and the boundaries of signed and unsigned words.
+var interestingWords = func() [][]byte {
+	words := [][]byte{
+		{}, {1}, {2}, {0x1f}, {0x20}, {0x21}, {0x40}, {0xff}, {0x01, 0x00},
+		target.Bytes(), sender.Bytes(), coinbase.Bytes(),
+	}
+	for i := 1; i <= 0x11; i++ {
+		words = append(words, []byte{byte(i)})
+	}
+	for _, n := range []*big.Int{
+		new(big.Int).Lsh(big.NewInt(1), 255),
+		new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(1)),
+		new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+		big.NewInt(1 << 32),
+		big.NewInt(1<<32 - 1),
+		big.NewInt(1<<63 - 1),
+	} {
+		words = append(words, n.Bytes())
+	}
+	return words
+}()
+
+// snippets are instruction sequences performing a meaningful operation with
+// operands pushed before it: calls of the precompiles and of the account
+// itself, storage writes, memory expansions and returns.
+var snippets = [][]Instruction{
+	push(vm.CALL, vm.GAS, 1, 0, 0, 0x80, 0, 0x20),
+	push(vm.STATICCALL, vm.GAS, 4, 0, 0x80, 0, 0x20),
+	push(vm.DELEGATECALL, vm.GAS, 5, 0, 0x80, 0, 0x20),
+	push(vm.CALL, vm.GAS, vm.ADDRESS, 0, 0, 0, 0, 0),
+	push(vm.STATICCALL, vm.GAS, vm.ADDRESS, 0, 0, 0, 0),
+	push(vm.SSTORE, 0, vm.CALLVALUE),
+	push(vm.SSTORE, 0, 0),
+	push(vm.TSTORE, 0, 1),
+	push(vm.MSTORE, 0x20, vm.GAS),
+	push(vm.MCOPY, 0x40, 0, 0x20),
+	push(vm.RETURNDATACOPY, 0, 0, vm.RETURNDATASIZE),
+	push(vm.CREATE2, 0, 0, 0x20, 0),
+	push(vm.RETURN, 0, 0x40),
+	push(vm.REVERT, 0, 0x20),
+}
+
+// push returns the instructions pushing the operands followed by the opcode.
+// The operands are listed from the top of the stack down, as integers pushed
+// with PUSH1 or as opcodes pushing their result.
+func push(op vm.OpCode, operands ...interface{}) []Instruction {
+	var ins []Instruction
+	for i := len(operands) - 1; i >= 0; i-- {
+		switch operand := operands[i].(type) {
+		case int:
+			ins = append(ins, Instruction{Op: vm.PUSH1, Arg: []byte{byte(operand)}})
+		case vm.OpCode:
+			ins = append(ins, Instruction{Op: operand})
+		}
+	}
+	return append(ins, Instruction{Op: op})
+}
+
+// Mutate applies a random structure-aware mutation to the input: it inserts,
+// deletes, replaces or duplicates instructions, inserts snippets, rewrites
+// push immediates with interesting words, or changes the gas, value or
+// calldata of the transaction.
+func Mutate(rng *rand.Rand, in *Input) {
+	n := len(in.Program)
+	pos := rng.Intn(n + 1)
+	switch choice := rng.Intn(10); {
+	case choice == 0 && n < MaxProgram:
+		in.Program = slices.Insert(in.Program, pos, randomInstruction(rng))
+	case choice == 1 && n < MaxProgram:
+		snippet := (&Input{Program: snippets[rng.Intn(len(snippets))]}).Copy().Program
+		in.Program = slices.Insert(in.Program, pos, snippet...)
+	case choice == 2 && n > 0:
+		end := min(pos+1+rng.Intn(4), n)
+		in.Program = append(in.Program[:min(pos, end-1)], in.Program[end:]...)
+	case choice == 3 && n > 0:
+		in.Program[rng.Intn(n)] = randomInstruction(rng)
+	case choice == 4 && n > 0 && n < MaxProgram:
+		start := rng.Intn(n)
+		end := min(start+1+rng.Intn(8), n)
+		dup := (&Input{Program: in.Program[start:end]}).Copy().Program
+		in.Program = slices.Insert(in.Program, pos, dup...)
+	case choice == 5 && n > 0:
+		i := rng.Intn(n)
+		op := vm.OpCode(int(vm.PUSH1) + rng.Intn(32))
+		in.Program[i] = Instruction{Op: op, Arg: interestingWords[rng.Intn(len(interestingWords))]}
+		in.Program[i].Arg = immediate(in.Program[i])
+	case choice == 6:
+		// Jump back or forward by pushing the position of a new JUMPDEST.
+		dest := rng.Intn(n + 1)
+		in.Program = slices.Insert(in.Program, dest, Instruction{Op: vm.JUMPDEST})
+		in.Program = slices.Insert(in.Program, rng.Intn(len(in.Program)+1),
+			Instruction{Op: vm.PUSH2}, Instruction{Op: []vm.OpCode{vm.JUMP, vm.JUMPI}[rng.Intn(2)]})
+		fixJumps(in)
+	case choice == 7:
+		in.Gas = []uint64{0, 100, 2300, 30000, uint64(rng.Intn(MaxGas))}[rng.Intn(5)]
+	case choice == 8:
+		in.Value = uint64(rng.Intn(256))
+	default:
+		mutateCallData(rng, in)
+	}
+}
+
+// fixJumps points every PUSH2 without an immediate followed by a jump at the
+// first JUMPDEST after it, or the last one if there is none, keeping the
+// inserted jumps valid. Skipping forward is preferred so that loops, which
+// burn all of the gas, are not created more often than skips.
+func fixJumps(in *Input) {
+	offsets := make([]int, len(in.Program))
+	var dests []int
+	pc := 0
+	for i, ins := range in.Program {
+		offsets[i] = pc
+		if ins.Op == vm.JUMPDEST {
+			dests = append(dests, pc)
+		}
+		pc += 1 + immediateSize(ins.Op)
+	}
+	if len(dests) == 0 {
+		return
+	}
+	for i := 0; i+1 < len(in.Program); i++ {
+		ins, next := in.Program[i], in.Program[i+1].Op
+		if ins.Op == vm.PUSH2 && ins.Arg == nil && (next == vm.JUMP || next == vm.JUMPI) {
+			dest := dests[len(dests)-1]
+			for _, d := range dests {
+				if d > offsets[i] {
+					dest = d
+					break
+				}
+			}
+			in.Program[i].Arg = []byte{byte(dest >> 8), byte(dest)}
+		}
+	}
+}
+
+func mutateCallData(rng *rand.Rand, in *Input) {
+	n := len(in.CallData)
+	switch choice := rng.Intn(4); {
+	case choice == 0 && n > 0:
+		in.CallData[rng.Intn(n)] = byte(rng.Intn(256))
+	case choice == 1 && n > 0:
+		in.CallData = in.CallData[:rng.Intn(n)]
+	case choice == 2 && n+32 <= MaxCallData:
+		word := interestingWords[rng.Intn(len(interestingWords))]
+		in.CallData = append(in.CallData, common.LeftPadBytes(word, 32)...)
+	default:
+		if n < MaxCallData {
+			in.CallData = append(in.CallData, byte(rng.Intn(256)))
+		}
+	}
+}
+
+// randomInstruction returns an instruction with an opcode drawn from
+// Opcodes, pushes with an interesting word or a random immediate.
+func randomInstruction(rng *rand.Rand) Instruction {
+	ins := Instruction{Op: Opcodes[rng.Intn(len(Opcodes))]}
+	if size := immediateSize(ins.Op); size > 0 {
+		if rng.Intn(2) == 0 {
+			ins.Arg = interestingWords[rng.Intn(len(interestingWords))]
+		} else {
+			ins.Arg = make([]byte, size)
+			rng.Read(ins.Arg)
+		}
+		ins.Arg = immediate(ins)
+	}
+	return ins
+}
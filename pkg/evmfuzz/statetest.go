@@ -0,0 +1,104 @@
+package evmfuzz
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/intrinsic"
+	"github.com/ethereum/execution-specs/pkg/statetest"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/tests"
+)
+
+// The parameters of the test environment and transaction.
+const (
+	blockGasLimit = 30_000_000
+	baseFee       = 7
+	gasPrice      = 10
+)
+
+var (
+	coinbase = common.HexToAddress("0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba")
+	target   = common.HexToAddress("0x00000000000000000000000000000000000c0de0")
+
+	senderKey, _  = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+	sender        = crypto.PubkeyToAddress(senderKey.PublicKey)
+	senderBalance = new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil)
+)
+
+// StateTest returns the state test executing the input on the fork: a legacy
+// transaction from a funded sender calls the account holding the program,
+// with the execution gas of the input on top of the intrinsic gas. The post
+// state is filled in with go-ethereum.
+func (in *Input) StateTest(fork string) (*statetest.Fixture, error) {
+	gas, err := intrinsic.Compute(fork, &intrinsic.Tx{Data: in.CallData})
+	if err != nil {
+		return nil, err
+	}
+	tx, err := types.SignNewTx(senderKey, types.HomesteadSigner{}, &types.LegacyTx{
+		To:       &target,
+		Gas:      gas.Required + in.Gas,
+		GasPrice: big.NewInt(gasPrice),
+		Value:    new(big.Int).SetUint64(in.Value),
+		Data:     in.CallData,
+	})
+	if err != nil {
+		return nil, err
+	}
+	env := &statetest.Env{
+		Coinbase:   coinbase,
+		Difficulty: (*hexutil.Big)(new(big.Int)),
+		GasLimit:   blockGasLimit,
+		Number:     1,
+		Timestamp:  1000,
+		BaseFee:    (*hexutil.Big)(big.NewInt(baseFee)),
+	}
+	pre := types.GenesisAlloc{
+		sender: {Balance: senderBalance},
+		target: {Balance: big.NewInt(1), Code: in.Code()},
+	}
+	f, err := statetest.New(env, pre, tx, senderKey, []string{fork})
+	if err != nil {
+		return nil, err
+	}
+	f.Info = statetest.Info("evmfuzz", "", "")
+	f.Info["input"] = hexutil.Encode(in.Encode())
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	post := f.Post[fork][0]
+	if post.Hash, post.Logs, err = Fill(data, fork); err != nil {
+		// The sender is funded for any gas limit and value within the limits
+		// of the inputs, so the transaction is always valid.
+		return nil, err
+	}
+	return f, nil
+}
+
+// Fill computes the post state root and logs hash of the first post state of
+// the fork of a state test, given as its JSON encoding, with go-ethereum. It
+// fails if the transaction is rejected.
+func Fill(data json.RawMessage, fork string) (root, logs common.Hash, err error) {
+	var test tests.StateTest
+	if err := json.Unmarshal(data, &test); err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+	st, root, _, err := test.RunNoVerify(tests.StateSubtest{Fork: fork}, vm.Config{}, false, rawdb.HashScheme)
+	defer st.Close()
+	switch err.(type) {
+	case nil:
+	case tests.UnsupportedForkError:
+		return common.Hash{}, common.Hash{}, err
+	default:
+		return common.Hash{}, common.Hash{}, fmt.Errorf("transaction rejected: %v", err)
+	}
+	return root, statetest.LogsHash(st.StateDB.Logs()), nil
+}
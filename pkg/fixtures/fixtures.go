@@ -0,0 +1,36 @@
+// Package fixtures locates the fixture files the runners are given on the
+// command line.
+package fixtures
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Collect expands the given paths into a sorted list of fixture files. Files
+// are taken as given, directories are searched for .json files, skipping
+// hidden directories.
+func Collect(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && file != path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && (file == path || filepath.Ext(file) == ".json") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
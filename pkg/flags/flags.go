@@ -0,0 +1,14 @@
+// Package flags holds the flag.Value types shared by the commands.
+package flags
+
+import "strings"
+
+// Strings is a flag.Value collecting every occurrence of a repeated flag.
+type Strings []string
+
+func (s *Strings) String() string { return strings.Join(*s, ",") }
+
+func (s *Strings) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
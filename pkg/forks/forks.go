@@ -0,0 +1,311 @@
+// Package forks edits the fork schedule of chain configs: the fork activation
+// fields, the forks as named by the execution specs, per-fork schedule and
+// blob parameter overrides and the merge configuration of a genesis.
+package forks
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Field is a fork activation field of the chain config. Forks up to the merge
+// are activated by block number, later ones by timestamp.
+type Field struct {
+	Flag  string // command line flag overriding the field
+	Block func(*params.ChainConfig) **big.Int
+	Time  func(*params.ChainConfig) **uint64
+
+	// Forks changing the blob parameters need an entry in the blob schedule.
+	Blob        func(*params.BlobScheduleConfig) **params.BlobConfig
+	DefaultBlob *params.BlobConfig
+}
+
+// Fields lists the fork activation fields in activation order.
+var Fields = []Field{
+	{Flag: "homestead-block", Block: func(c *params.ChainConfig) **big.Int { return &c.HomesteadBlock }},
+	{Flag: "dao-fork-block", Block: func(c *params.ChainConfig) **big.Int { return &c.DAOForkBlock }},
+	{Flag: "eip150-block", Block: func(c *params.ChainConfig) **big.Int { return &c.EIP150Block }},
+	{Flag: "eip155-block", Block: func(c *params.ChainConfig) **big.Int { return &c.EIP155Block }},
+	{Flag: "eip158-block", Block: func(c *params.ChainConfig) **big.Int { return &c.EIP158Block }},
+	{Flag: "byzantium-block", Block: func(c *params.ChainConfig) **big.Int { return &c.ByzantiumBlock }},
+	{Flag: "constantinople-block", Block: func(c *params.ChainConfig) **big.Int { return &c.ConstantinopleBlock }},
+	{Flag: "petersburg-block", Block: func(c *params.ChainConfig) **big.Int { return &c.PetersburgBlock }},
+	{Flag: "istanbul-block", Block: func(c *params.ChainConfig) **big.Int { return &c.IstanbulBlock }},
+	{Flag: "muir-glacier-block", Block: func(c *params.ChainConfig) **big.Int { return &c.MuirGlacierBlock }},
+	{Flag: "berlin-block", Block: func(c *params.ChainConfig) **big.Int { return &c.BerlinBlock }},
+	{Flag: "london-block", Block: func(c *params.ChainConfig) **big.Int { return &c.LondonBlock }},
+	{Flag: "arrow-glacier-block", Block: func(c *params.ChainConfig) **big.Int { return &c.ArrowGlacierBlock }},
+	{Flag: "gray-glacier-block", Block: func(c *params.ChainConfig) **big.Int { return &c.GrayGlacierBlock }},
+	{Flag: "merge-netsplit-block", Block: func(c *params.ChainConfig) **big.Int { return &c.MergeNetsplitBlock }},
+	{Flag: "shanghai-time", Time: func(c *params.ChainConfig) **uint64 { return &c.ShanghaiTime }},
+	{Flag: "cancun-time", Time: func(c *params.ChainConfig) **uint64 { return &c.CancunTime },
+		Blob: func(b *params.BlobScheduleConfig) **params.BlobConfig { return &b.Cancun }, DefaultBlob: params.DefaultCancunBlobConfig},
+	{Flag: "prague-time", Time: func(c *params.ChainConfig) **uint64 { return &c.PragueTime },
+		Blob: func(b *params.BlobScheduleConfig) **params.BlobConfig { return &b.Prague }, DefaultBlob: params.DefaultPragueBlobConfig},
+	{Flag: "osaka-time", Time: func(c *params.ChainConfig) **uint64 { return &c.OsakaTime },
+		Blob: func(b *params.BlobScheduleConfig) **params.BlobConfig { return &b.Osaka }, DefaultBlob: params.DefaultOsakaBlobConfig},
+	{Flag: "bpo1-time", Time: func(c *params.ChainConfig) **uint64 { return &c.BPO1Time },
+		Blob: func(b *params.BlobScheduleConfig) **params.BlobConfig { return &b.BPO1 }, DefaultBlob: params.DefaultBPO1BlobConfig},
+	{Flag: "bpo2-time", Time: func(c *params.ChainConfig) **uint64 { return &c.BPO2Time },
+		Blob: func(b *params.BlobScheduleConfig) **params.BlobConfig { return &b.BPO2 }, DefaultBlob: params.DefaultBPO2BlobConfig},
+	{Flag: "bpo3-time", Time: func(c *params.ChainConfig) **uint64 { return &c.BPO3Time },
+		Blob: func(b *params.BlobScheduleConfig) **params.BlobConfig { return &b.BPO3 }, DefaultBlob: params.DefaultBPO3BlobConfig},
+	{Flag: "bpo4-time", Time: func(c *params.ChainConfig) **uint64 { return &c.BPO4Time },
+		Blob: func(b *params.BlobScheduleConfig) **params.BlobConfig { return &b.BPO4 }, DefaultBlob: params.DefaultBPO4BlobConfig},
+	{Flag: "bpo5-time", Time: func(c *params.ChainConfig) **uint64 { return &c.BPO5Time },
+		Blob: func(b *params.BlobScheduleConfig) **params.BlobConfig { return &b.BPO5 }},
+	{Flag: "amsterdam-time", Time: func(c *params.ChainConfig) **uint64 { return &c.AmsterdamTime },
+		Blob: func(b *params.BlobScheduleConfig) **params.BlobConfig { return &b.Amsterdam }},
+	{Flag: "verkle-time", Time: func(c *params.ChainConfig) **uint64 { return &c.VerkleTime }},
+}
+
+// FindField returns the schedule field overridden by the given flag, or nil if
+// there is none.
+func FindField(flag string) *Field {
+	for i := range Fields {
+		if Fields[i].Flag == flag {
+			return &Fields[i]
+		}
+	}
+	return nil
+}
+
+// eelsForks lists the forks as named by the execution specs in activation
+// order, together with the schedule fields each of them activates and the
+// EIPs it enables. The blob parameter only forks enable the blob parameters
+// of their EIP-7892 blob schedule entry, the contents of Amsterdam are not
+// final yet.
+var eelsForks = []struct {
+	name   string
+	fields []string
+	eips   []int
+}{
+	{name: "Frontier"},
+	{name: "Homestead", fields: []string{"homestead-block"}, eips: []int{2, 7, 8}},
+	{name: "DAOFork", fields: []string{"dao-fork-block"}, eips: []int{779}},
+	{name: "TangerineWhistle", fields: []string{"eip150-block"}, eips: []int{150}},
+	{name: "SpuriousDragon", fields: []string{"eip155-block", "eip158-block"}, eips: []int{155, 160, 161, 170}},
+	{name: "Byzantium", fields: []string{"byzantium-block"}, eips: []int{100, 140, 196, 197, 198, 211, 214, 649, 658}},
+	{name: "Constantinople", fields: []string{"constantinople-block", "petersburg-block"}, eips: []int{145, 1014, 1052, 1234}},
+	{name: "Istanbul", fields: []string{"istanbul-block"}, eips: []int{152, 1108, 1344, 1884, 2028, 2200}},
+	{name: "MuirGlacier", fields: []string{"muir-glacier-block"}, eips: []int{2384}},
+	{name: "Berlin", fields: []string{"berlin-block"}, eips: []int{2565, 2718, 2929, 2930}},
+	{name: "London", fields: []string{"london-block"}, eips: []int{1559, 3198, 3529, 3541, 3554}},
+	{name: "ArrowGlacier", fields: []string{"arrow-glacier-block"}, eips: []int{4345}},
+	{name: "GrayGlacier", fields: []string{"gray-glacier-block"}, eips: []int{5133}},
+	{name: "Paris", eips: []int{3675, 4399}},
+	{name: "Shanghai", fields: []string{"shanghai-time"}, eips: []int{3651, 3855, 3860, 4895}},
+	{name: "Cancun", fields: []string{"cancun-time"}, eips: []int{1153, 4788, 4844, 5656, 6780, 7516}},
+	{name: "Prague", fields: []string{"prague-time"}, eips: []int{2537, 2935, 6110, 7002, 7251, 7549, 7623, 7685, 7691, 7702}},
+	{name: "Osaka", fields: []string{"osaka-time"}, eips: []int{7594, 7823, 7825, 7883, 7918, 7934, 7939, 7951}},
+	{name: "BPO1", fields: []string{"bpo1-time"}, eips: []int{7892}},
+	{name: "BPO2", fields: []string{"bpo2-time"}, eips: []int{7892}},
+	{name: "BPO3", fields: []string{"bpo3-time"}, eips: []int{7892}},
+	{name: "BPO4", fields: []string{"bpo4-time"}, eips: []int{7892}},
+	{name: "BPO5", fields: []string{"bpo5-time"}, eips: []int{7892}},
+	{name: "Amsterdam", fields: []string{"amsterdam-time"}},
+}
+
+// eelsForkAliases maps alternative fork names, including the EIP based names
+// of the legacy test networks, onto the execution specs ones. The specs
+// implement Petersburg under the Constantinople name.
+var eelsForkAliases = map[string]string{
+	"dao":               "DAOFork",
+	"eip150":            "TangerineWhistle",
+	"eip158":            "SpuriousDragon",
+	"petersburg":        "Constantinople",
+	"constantinoplefix": "Constantinople",
+	"merge":             "Paris",
+}
+
+// Names returns the names of the execution specs forks in activation order.
+func Names() []string {
+	names := make([]string, len(eelsForks))
+	for i, fork := range eelsForks {
+		names[i] = fork.name
+	}
+	return names
+}
+
+// Index resolves a (case insensitive) fork name to its position in the
+// activation order of Names.
+func Index(name string) (int, error) {
+	if alias, ok := eelsForkAliases[strings.ToLower(name)]; ok {
+		name = alias
+	}
+	for i, fork := range eelsForks {
+		if strings.EqualFold(fork.name, name) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown fork %q, supported forks: %s", name, strings.Join(Names(), ", "))
+}
+
+// Since reports whether the fork, or the fork a transition network such as
+// "ParisToShanghaiAtTime15k" moves to, includes the named one. It panics if
+// either fork is unknown, callers only compare known forks.
+func Since(network, name string) bool {
+	if _, to, ok := strings.Cut(network, "To"); ok {
+		network, _, _ = strings.Cut(to, "At")
+	}
+	index, err := Index(network)
+	if err != nil {
+		panic(err)
+	}
+	at, err := Index(name)
+	if err != nil {
+		panic(err)
+	}
+	return index >= at
+}
+
+// Package returns the Python package implementing the named fork in the
+// execution specs, such as "ethereum.tangerine_whistle" for TangerineWhistle.
+func Package(name string) (string, error) {
+	index, err := Index(name)
+	if err != nil {
+		return "", err
+	}
+	name = eelsForks[index].name
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		upper := c >= 'A' && c <= 'Z'
+		if upper && i > 0 {
+			prevLower := name[i-1] >= 'a' && name[i-1] <= 'z'
+			nextLower := i+1 < len(name) && name[i+1] >= 'a' && name[i+1] <= 'z'
+			if prevLower || (name[i-1] >= 'A' && name[i-1] <= 'Z' && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteString(strings.ToLower(string(c)))
+	}
+	return "ethereum." + b.String(), nil
+}
+
+// Activate rewrites the fork schedule of the chain config so that the named
+// fork and all of its predecessors are active from genesis, while all later
+// forks are disabled.
+func Activate(config *params.ChainConfig, name string) error {
+	index, err := Index(name)
+	if err != nil {
+		return err
+	}
+	active := make(map[string]bool)
+	for _, fork := range eelsForks[:index+1] {
+		for _, field := range fork.fields {
+			active[field] = true
+		}
+	}
+	for _, field := range Fields {
+		switch {
+		case field.Block != nil && active[field.Flag]:
+			*field.Block(config) = new(big.Int)
+		case field.Block != nil:
+			*field.Block(config) = nil
+		case active[field.Flag]:
+			*field.Time(config) = new(uint64)
+		default:
+			*field.Time(config) = nil
+		}
+	}
+	config.DAOForkSupport = active["dao-fork-block"]
+	config.TerminalTotalDifficulty = nil
+	if paris, _ := Index("Paris"); index >= paris {
+		config.TerminalTotalDifficulty = new(big.Int)
+	}
+	FillBlobSchedule(config, true)
+	return nil
+}
+
+// FillBlobSchedule adds the missing blob schedule entries of the scheduled
+// forks, taking the mainnet defaults or, for forks without defaults, the
+// parameters of the previous fork. If prune is set, the entries of forks that
+// are not scheduled are removed.
+func FillBlobSchedule(config *params.ChainConfig, prune bool) {
+	var (
+		current  = config.BlobScheduleConfig
+		schedule = new(params.BlobScheduleConfig)
+		last     *params.BlobConfig
+		found    bool
+	)
+	if current == nil {
+		current = new(params.BlobScheduleConfig)
+	}
+	for _, field := range Fields {
+		if field.Blob == nil {
+			continue
+		}
+		blob := *field.Blob(current)
+		if *field.Time(config) == nil {
+			if !prune && blob != nil {
+				*field.Blob(schedule), found = blob, true
+			}
+			continue
+		}
+		if blob == nil {
+			blob = field.DefaultBlob
+		}
+		if blob == nil {
+			blob = last
+		}
+		if blob != nil {
+			*field.Blob(schedule), found = blob, true
+		}
+		last = blob
+	}
+	config.BlobScheduleConfig = nil
+	if found {
+		config.BlobScheduleConfig = schedule
+	}
+}
+
+// ValidateOrder checks that the fork schedule follows the canonical fork
+// sequence and that timestamp based forks are only scheduled after the merge.
+func ValidateOrder(config *params.ChainConfig) error {
+	if err := config.CheckConfigForkOrder(); err != nil {
+		return err
+	}
+	if config.TerminalTotalDifficulty == nil {
+		for _, field := range Fields {
+			if field.Time != nil && *field.Time(config) != nil {
+				return fmt.Errorf("%s is scheduled but terminalTotalDifficulty is not set", field.Flag)
+			}
+		}
+	}
+	return nil
+}
+
+// Scheduled reports whether the fork of the given schedule field has an
+// activation point in the chain config.
+func Scheduled(config *params.ChainConfig, flag string) bool {
+	field := FindField(flag)
+	switch {
+	case field == nil:
+		return false
+	case field.Block != nil:
+		return *field.Block(config) != nil
+	default:
+		return *field.Time(config) != nil
+	}
+}
+
+// ActiveAtGenesis reports whether the fork of the given schedule field is
+// already active in the genesis block.
+func ActiveAtGenesis(genesis *core.Genesis, flag string) bool {
+	field := FindField(flag)
+	switch {
+	case field == nil:
+		return false
+	case field.Block != nil:
+		block := *field.Block(genesis.Config)
+		return block != nil && block.Sign() == 0
+	default:
+		time := *field.Time(genesis.Config)
+		return time != nil && *time <= genesis.Timestamp
+	}
+}
@@ -0,0 +1,98 @@
+package forks
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Merge modes of the generated genesis.
+const (
+	MergeTransition = "transition" // proof-of-work genesis transitioning at the terminal total difficulty
+	MergeAtGenesis  = "genesis"    // proof-of-stake from genesis
+)
+
+// ApplyMergeMode configures the genesis for one of the merge modes, setting
+// the terminal total difficulty and the header fields the mode requires.
+//
+// A transition genesis is mined with ethash until the chain reaches the
+// terminal total difficulty, its difficulty is raised to the ethash minimum
+// if necessary. A post-merge genesis activates every fork up to Cancun at
+// genesis, dropping a DAO fork scheduled later, and carries the post-merge
+// header constants: zero difficulty, nonce and mix digest.
+func ApplyMergeMode(genesis *core.Genesis, mode string, ttd *big.Int) error {
+	config := genesis.Config
+	switch mode {
+	case MergeTransition:
+		if ttd == nil || ttd.Sign() <= 0 {
+			return errors.New("the transition mode requires a positive --ttd")
+		}
+		config.TerminalTotalDifficulty = new(big.Int).Set(ttd)
+		config.MergeNetsplitBlock = nil
+		if genesis.Difficulty == nil || genesis.Difficulty.Cmp(params.MinimumDifficulty) < 0 {
+			genesis.Difficulty = new(big.Int).Set(params.MinimumDifficulty)
+		}
+		genesis.Mixhash = common.Hash{}
+	case MergeAtGenesis:
+		if ttd != nil && ttd.Sign() != 0 {
+			return errors.New("a post-merge genesis has a terminal total difficulty of 0")
+		}
+		cancun, _ := Index("Cancun")
+		for _, fork := range eelsForks[:cancun+1] {
+			for _, name := range fork.fields {
+				field := FindField(name)
+				switch {
+				case name == "dao-fork-block":
+					// Optional, a DAO fork after genesis is dropped as it could
+					// not follow the forks moved to the genesis block.
+					if block := *field.Block(config); block != nil && block.Sign() != 0 {
+						*field.Block(config) = nil
+						config.DAOForkSupport = false
+					}
+				case field.Block != nil:
+					if block := *field.Block(config); block == nil || block.Sign() != 0 {
+						*field.Block(config) = new(big.Int)
+					}
+				case *field.Time(config) == nil || **field.Time(config) > genesis.Timestamp:
+					*field.Time(config) = new(uint64)
+				}
+			}
+		}
+		config.TerminalTotalDifficulty = new(big.Int)
+		config.MergeNetsplitBlock = nil
+		genesis.Difficulty = new(big.Int)
+		genesis.Nonce = 0
+		genesis.Mixhash = common.Hash{}
+	default:
+		return fmt.Errorf("unknown merge mode %q, want %s or %s", mode, MergeTransition, MergeAtGenesis)
+	}
+	return nil
+}
+
+// CheckMergeTransition verifies that a genesis below the terminal total
+// difficulty can actually transition: it needs a valid proof-of-work
+// difficulty below the terminal total difficulty, and no timestamp fork may be
+// active before the transition.
+func CheckMergeTransition(genesis *core.Genesis) error {
+	ttd := genesis.Config.TerminalTotalDifficulty
+	difficulty := genesis.Difficulty
+	if difficulty == nil {
+		difficulty = new(big.Int)
+	}
+	if difficulty.Cmp(params.MinimumDifficulty) < 0 {
+		return fmt.Errorf("genesis difficulty %v below the proof-of-work minimum %v", difficulty, params.MinimumDifficulty)
+	}
+	if difficulty.Cmp(ttd) >= 0 {
+		return fmt.Errorf("genesis difficulty %v reaches the terminal total difficulty %v, no block would be mined", difficulty, ttd)
+	}
+	for _, field := range Fields {
+		if field.Time != nil && *field.Time(genesis.Config) != nil && **field.Time(genesis.Config) <= genesis.Timestamp {
+			return fmt.Errorf("%s is active at genesis, timestamp forks can only follow the transition", field.Flag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package forks
+
+import (
+	"flag"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Override is a flag.Value holding an optional activation point. The special
+// value "none" disables the fork, +OFFSET schedules a timestamp fork relative
+// to the genesis time once it is resolved.
+type Override struct {
+	set      bool
+	disabled bool
+	relative bool
+	value    uint64
+}
+
+func (o *Override) String() string {
+	switch {
+	case !o.set:
+		return ""
+	case o.disabled:
+		return "none"
+	case o.relative:
+		return fmt.Sprintf("+%d", o.value)
+	default:
+		return fmt.Sprint(o.value)
+	}
+}
+
+func (o *Override) Set(s string) error {
+	if s == "none" {
+		o.set, o.disabled, o.relative = true, true, false
+		return nil
+	}
+	if offset, ok := strings.CutPrefix(s, "+"); ok {
+		v, err := ParseOffset(offset)
+		if err != nil {
+			return err
+		}
+		o.set, o.disabled, o.relative, o.value = true, false, true, v
+		return nil
+	}
+	v, ok := math.ParseUint64(s)
+	if !ok {
+		return fmt.Errorf("invalid activation point %q", s)
+	}
+	o.set, o.disabled, o.relative, o.value = true, false, false, v
+	return nil
+}
+
+// ParseOffset parses a relative offset, given as a duration (90m, 2h30m, 1d)
+// or a number of seconds.
+func ParseOffset(s string) (uint64, error) {
+	if v, ok := math.ParseUint64(s); ok {
+		return v, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if v, ok := math.ParseUint64(days); ok {
+			return v * 24 * 60 * 60, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	return uint64(d / time.Second), nil
+}
+
+// ActivateAt returns an override activating the fork at the given block
+// number or timestamp.
+func ActivateAt(value uint64) *Override {
+	return &Override{set: true, value: value}
+}
+
+// Disable returns an override removing the fork from the schedule.
+func Disable() *Override {
+	return &Override{set: true, disabled: true}
+}
+
+// Overrides holds the fork schedule flags, keyed by flag name.
+type Overrides map[string]*Override
+
+// RegisterFlags defines a flag for every fork activation field.
+func RegisterFlags(fs *flag.FlagSet) Overrides {
+	overrides := make(Overrides)
+	for _, field := range Fields {
+		o := new(Override)
+		if field.Block != nil {
+			fs.Var(o, field.Flag, "override the activation block of the fork (\"none\" disables it)")
+		} else {
+			fs.Var(o, field.Flag, "override the activation timestamp of the fork (+OFFSET relative to the genesis time, \"none\" disables it)")
+		}
+		overrides[field.Flag] = o
+	}
+	return overrides
+}
+
+// Resolve turns the overrides given relative to the genesis time into
+// absolute timestamps. Block number forks cannot be scheduled relatively.
+func (overrides Overrides) Resolve(genesisTime uint64) error {
+	for _, field := range Fields {
+		o := overrides[field.Flag]
+		if o == nil || !o.relative {
+			continue
+		}
+		if field.Block != nil {
+			return fmt.Errorf("--%s: block numbers cannot be given as offsets", field.Flag)
+		}
+		o.relative, o.value = false, genesisTime+o.value
+	}
+	return nil
+}
+
+// Apply writes the requested overrides into the chain config and verifies
+// that the resulting fork schedule is internally consistent. Relative
+// overrides must have been resolved.
+func (overrides Overrides) Apply(config *params.ChainConfig) error {
+	for _, field := range Fields {
+		o := overrides[field.Flag]
+		if o == nil || !o.set {
+			continue
+		}
+		switch {
+		case o.relative:
+			return fmt.Errorf("--%s is relative to the genesis time, which is not known here", field.Flag)
+		case field.Block != nil && o.disabled:
+			*field.Block(config) = nil
+		case field.Block != nil:
+			*field.Block(config) = new(big.Int).SetUint64(o.value)
+		case o.disabled:
+			*field.Time(config) = nil
+		default:
+			*field.Time(config) = &o.value
+		}
+	}
+	FillBlobSchedule(config, false)
+	return ValidateOrder(config)
+}
+
+// BlobOverride replaces the blob parameters of a fork. A zero update fraction
+// keeps the one of the current entry.
+type BlobOverride struct {
+	field          *Field
+	target, max    int
+	updateFraction uint64
+}
+
+// BlobOverrides is a flag.Value collecting --blob-schedule flags of the form
+// fork=target,max[,baseFeeUpdateFraction].
+type BlobOverrides []BlobOverride
+
+func (o *BlobOverrides) String() string {
+	entries := make([]string, len(*o))
+	for i, entry := range *o {
+		entries[i] = fmt.Sprintf("%s=%d,%d", strings.TrimSuffix(entry.field.Flag, "-time"), entry.target, entry.max)
+		if entry.updateFraction != 0 {
+			entries[i] += fmt.Sprintf(",%d", entry.updateFraction)
+		}
+	}
+	return strings.Join(entries, " ")
+}
+
+func (o *BlobOverrides) Set(s string) error {
+	fork, rest, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("invalid blob schedule %q, want fork=target,max[,baseFeeUpdateFraction]", s)
+	}
+	field := FindField(strings.ToLower(fork) + "-time")
+	if field == nil || field.Blob == nil {
+		return fmt.Errorf("fork %q has no blob schedule entry", fork)
+	}
+	values := strings.Split(rest, ",")
+	if len(values) != 2 && len(values) != 3 {
+		return fmt.Errorf("invalid blob schedule %q, want fork=target,max[,baseFeeUpdateFraction]", s)
+	}
+	var parsed [3]uint64
+	for i, value := range values {
+		v, ok := math.ParseUint64(value)
+		if !ok || v == 0 {
+			return fmt.Errorf("invalid blob parameter %q, must be a positive integer", value)
+		}
+		parsed[i] = v
+	}
+	if parsed[1] < parsed[0] {
+		return fmt.Errorf("blob max %d below target %d", parsed[1], parsed[0])
+	}
+	*o = append(*o, BlobOverride{field: field, target: int(parsed[0]), max: int(parsed[1]), updateFraction: parsed[2]})
+	return nil
+}
+
+// Apply writes the blob parameter overrides into the blob schedule. Only forks
+// which are scheduled can be overridden.
+func (o BlobOverrides) Apply(config *params.ChainConfig) error {
+	if len(o) == 0 {
+		return nil
+	}
+	schedule := new(params.BlobScheduleConfig)
+	if config.BlobScheduleConfig != nil {
+		*schedule = *config.BlobScheduleConfig
+	}
+	for _, entry := range o {
+		name := strings.TrimSuffix(entry.field.Flag, "-time")
+		if *entry.field.Time(config) == nil {
+			return fmt.Errorf("cannot override the blob parameters of unscheduled fork %s", name)
+		}
+		blob := &params.BlobConfig{Target: entry.target, Max: entry.max, UpdateFraction: entry.updateFraction}
+		if blob.UpdateFraction == 0 {
+			current := *entry.field.Blob(schedule)
+			if current == nil {
+				return fmt.Errorf("%s has no blob schedule entry, baseFeeUpdateFraction is required", name)
+			}
+			blob.UpdateFraction = current.UpdateFraction
+		}
+		*entry.field.Blob(schedule) = blob
+	}
+	config.BlobScheduleConfig = schedule
+	return nil
+}
@@ -0,0 +1,90 @@
+package forks
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Activation is a fork scheduled by a chain config. Forks up to the merge
+// activate at a block, Paris at the terminal total difficulty and later forks
+// at a timestamp.
+type Activation struct {
+	Fork                    string             `json:"fork"`
+	Block                   *big.Int           `json:"block,omitempty"`
+	Time                    *uint64            `json:"timestamp,omitempty"`
+	TerminalTotalDifficulty *big.Int           `json:"terminalTotalDifficulty,omitempty"`
+	EIPs                    []int              `json:"eips"`
+	Blob                    *params.BlobConfig `json:"blobSchedule,omitempty"`
+}
+
+// Timeline returns the forks scheduled by the chain config in activation
+// order, named as in the execution specs. A fork spanning several block
+// fields, like Constantinople and Petersburg, activates at the last of them
+// which is set.
+func Timeline(config *params.ChainConfig) []Activation {
+	var timeline []Activation
+	for _, fork := range eelsForks {
+		a := Activation{Fork: fork.name, EIPs: fork.eips}
+		if a.EIPs == nil {
+			a.EIPs = []int{}
+		}
+		switch fork.name {
+		case "Frontier":
+			a.Block = new(big.Int)
+		case "Paris":
+			a.TerminalTotalDifficulty = config.TerminalTotalDifficulty
+		}
+		for _, flag := range fork.fields {
+			field := FindField(flag)
+			switch {
+			case field.Block != nil:
+				if block := *field.Block(config); block != nil && (a.Block == nil || block.Cmp(a.Block) > 0) {
+					a.Block = block
+				}
+			default:
+				a.Time = *field.Time(config)
+			}
+			if field.Blob != nil && config.BlobScheduleConfig != nil {
+				a.Blob = *field.Blob(config.BlobScheduleConfig)
+			}
+		}
+		if a.Block != nil || a.Time != nil || a.TerminalTotalDifficulty != nil {
+			timeline = append(timeline, a)
+		}
+	}
+	return timeline
+}
+
+// At resolves the execution specs fork of a block of the chain config, given
+// by its number and timestamp and whether the chain has reached the terminal
+// total difficulty before it. The timestamp forks, like in go-ethereum, only
+// activate after the merge. Blocks between Constantinople and Petersburg have
+// no execution specs counterpart and resolve to Byzantium.
+func At(config *params.ChainConfig, number *big.Int, time uint64, merged bool) string {
+	fork := "Frontier"
+	for _, a := range Timeline(config) {
+		switch {
+		case a.Block != nil && a.Block.Cmp(number) <= 0,
+			a.TerminalTotalDifficulty != nil && merged,
+			a.Time != nil && *a.Time <= time && merged:
+			fork = a.Fork
+		}
+	}
+	return fork
+}
+
+// ActivationOf returns the activation of the named fork in the chain config,
+// or nil if the fork is not scheduled.
+func ActivationOf(config *params.ChainConfig, name string) (*Activation, error) {
+	index, err := Index(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range Timeline(config) {
+		if a.Fork == eelsForks[index].name {
+			return &a, nil
+		}
+	}
+	return nil, nil
+}
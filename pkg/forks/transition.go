@@ -0,0 +1,97 @@
+package forks
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ActivateNetwork rewrites the fork schedule of the chain config for a network
+// as named by the execution spec tests. A plain fork name is activated from
+// genesis as by Activate. A transition network such as BerlinToLondonAt5,
+// ShanghaiToCancunAtTime15k or ArrowGlacierToParisAtDiffC0000 activates the
+// first fork from genesis and the second one, together with the forks in
+// between, at the given block number or timestamp, or for the merge at the
+// given terminal total difficulty. A k suffix multiplies the number by 1000.
+func ActivateNetwork(config *params.ChainConfig, network string) error {
+	from, transition, ok := strings.Cut(network, "To")
+	if !ok {
+		return Activate(config, network)
+	}
+	to, at, ok := strings.Cut(transition, "At")
+	if !ok {
+		return fmt.Errorf("transition network %q has no activation point", network)
+	}
+	start, err := Index(from)
+	if err != nil {
+		return err
+	}
+	end, err := Index(to)
+	if err != nil {
+		return err
+	}
+	if end <= start {
+		return fmt.Errorf("transition network %q does not move to a later fork", network)
+	}
+	if err := Activate(config, from); err != nil {
+		return err
+	}
+	paris, _ := Index("Paris")
+	difficulty, byDifficulty := strings.CutPrefix(at, "Diff")
+	if byDifficulty != (end == paris) {
+		return fmt.Errorf("transition network %q: the merge and only the merge activates by difficulty", network)
+	}
+	if byDifficulty {
+		if !strings.HasPrefix(difficulty, "0x") {
+			difficulty = "0x" + difficulty
+		}
+		ttd, ok := math.ParseBig256(difficulty)
+		if !ok {
+			return fmt.Errorf("transition network %q: invalid terminal total difficulty", network)
+		}
+		config.TerminalTotalDifficulty = ttd
+		return nil
+	}
+	byTime, unit := false, "block number"
+	if rest, ok := strings.CutPrefix(at, "Time"); ok {
+		at, byTime, unit = rest, true, "timestamp"
+	}
+	value, err := parseActivation(at)
+	if err != nil {
+		return fmt.Errorf("transition network %q: %v", network, err)
+	}
+	overrides := make(Overrides)
+	for _, fork := range eelsForks[start+1 : end+1] {
+		for _, flag := range fork.fields {
+			if field := FindField(flag); (field.Time != nil) != byTime {
+				return fmt.Errorf("transition network %q: %s is not activated by %s", network, fork.name, unit)
+			}
+			overrides[flag] = ActivateAt(value)
+		}
+	}
+	if overrides["dao-fork-block"] != nil {
+		config.DAOForkSupport = true
+	}
+	if end >= paris {
+		config.TerminalTotalDifficulty = new(big.Int)
+	}
+	return overrides.Apply(config)
+}
+
+// parseActivation parses the activation point of a transition network, a
+// decimal number with an optional k suffix.
+func parseActivation(s string) (uint64, error) {
+	scale := uint64(1)
+	if rest, ok := strings.CutSuffix(s, "k"); ok {
+		s, scale = rest, 1000
+	}
+	value, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid activation point %q", s)
+	}
+	return value * scale, nil
+}
@@ -0,0 +1,273 @@
+package genesis
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/eof"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// The kinds of allocated code.
+const (
+	CodeLegacy     = "legacy"
+	CodeEOF        = "eof"        // EOF container, prefixed with 0xEF00
+	CodeDelegation = "delegation" // EIP-7702 delegation designator
+	CodeReserved   = "reserved"   // other code starting with 0xEF, see EIP-3541
+)
+
+// CodeAnalysis is the result of analyzing the code of an allocation against
+// the fork active at genesis.
+type CodeAnalysis struct {
+	Fork      string              `json:"fork"`
+	Contracts []*ContractAnalysis `json:"contracts"`
+	Findings  Findings            `json:"findings"`
+}
+
+// ContractAnalysis is the disassembly summary of an allocated contract. Only
+// the instructions of legacy code which execution can reach are counted:
+// the bytes after a terminating instruction up to the next JUMPDEST, such as
+// the metadata appended by compilers, are dead code.
+type ContractAnalysis struct {
+	Address  common.Address        `json:"address"`
+	Size     int                   `json:"size"`
+	Kind     string                `json:"kind"`
+	Opcodes  map[string]int        `json:"opcodes,omitempty"` // reachable instructions by name
+	DeadCode int                   `json:"deadCode,omitempty"`
+	Invalid  []*InvalidInstruction `json:"invalidInstructions,omitempty"`
+}
+
+// InvalidInstruction is a reachable instruction of legacy code which is not
+// defined at genesis. IntroducedBy names the later fork defining it, if any.
+type InvalidInstruction struct {
+	PC           int    `json:"pc"`
+	Opcode       string `json:"opcode"`
+	IntroducedBy string `json:"introducedBy,omitempty"`
+
+	op vm.OpCode
+}
+
+// AnalyzeCode disassembles every contract of the genesis allocation and
+// checks its instructions against the instruction set of the fork active at
+// genesis, as defined by go-ethereum. Instructions introduced by a later fork
+// are warnings if that fork is scheduled and errors otherwise, and undefined
+// instructions are errors; the designated INVALID instruction is not
+// reported. EOF containers and other code starting with 0xEF, which no
+// scheduled fork executes, are errors. EIP-7702 delegations before Prague are
+// warnings if Prague is scheduled and errors otherwise. The code size is
+// checked as by Validate.
+func AnalyzeCode(genesis *core.Genesis) (*CodeAnalysis, error) {
+	config := genesis.Config
+	if config == nil {
+		return nil, fmt.Errorf("missing chain config")
+	}
+	difficulty := genesis.Difficulty
+	if difficulty == nil {
+		difficulty = new(big.Int)
+	}
+	merged := config.TerminalTotalDifficulty != nil && difficulty.Cmp(config.TerminalTotalDifficulty) >= 0
+	rules := config.Rules(new(big.Int), merged, genesis.Timestamp)
+	jt, err := vm.LookupInstructionSet(rules)
+	if err != nil {
+		return nil, err
+	}
+	analysis := &CodeAnalysis{Fork: forks.At(config, new(big.Int), genesis.Timestamp, merged), Contracts: []*ContractAnalysis{}}
+	later, err := laterInstructionSets(analysis.Fork)
+	if err != nil {
+		return nil, err
+	}
+	f := &analysis.Findings
+	for _, addr := range alloc.SortedAddresses(genesis.Alloc) {
+		code := genesis.Alloc[addr].Code
+		if len(code) == 0 {
+			continue
+		}
+		c := analyzeContract(addr, code, &jt)
+		analysis.Contracts = append(analysis.Contracts, c)
+
+		switch c.Kind {
+		case CodeEOF:
+			detail := "a valid EOFv1 container"
+			if err := eof.Validate(code, eof.Runtime); err != nil {
+				detail = fmt.Sprintf("an invalid EOFv1 container (%v)", err)
+			}
+			f.add("error", "opcodes", &c.Address, "EOF code, %s, which no scheduled fork executes", detail)
+		case CodeReserved:
+			f.add("error", "opcodes", &c.Address, "code starting with the 0xEF byte reserved by EIP-3541")
+		case CodeDelegation:
+			if !rules.IsPrague {
+				severity := "error"
+				if forks.Scheduled(config, "prague-time") {
+					severity = "warning"
+				}
+				f.add(severity, "opcodes", &c.Address, "EIP-7702 delegation to %s before Prague", common.BytesToAddress(code[len(types.DelegationPrefix):]).Hex())
+			}
+		}
+		reportInvalid(f, c, config, analysis.Fork, later)
+	}
+	validateAllocCode(f, genesis)
+	return analysis, nil
+}
+
+// laterFork is the instruction set of a fork after the genesis fork.
+type laterFork struct {
+	name string
+	jt   vm.JumpTable
+}
+
+// laterInstructionSets returns the instruction sets of the forks after the
+// named one, in activation order.
+func laterInstructionSets(fork string) ([]laterFork, error) {
+	index, err := forks.Index(fork)
+	if err != nil {
+		return nil, err
+	}
+	paris, _ := forks.Index("Paris")
+	var sets []laterFork
+	for i, name := range forks.Names()[index+1:] {
+		config := &params.ChainConfig{ChainID: big.NewInt(1)}
+		if err := forks.Activate(config, name); err != nil {
+			return nil, err
+		}
+		jt, err := vm.LookupInstructionSet(config.Rules(new(big.Int), index+1+i >= paris, 0))
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, laterFork{name, jt})
+	}
+	return sets, nil
+}
+
+// defined reports whether the opcode is defined by the instruction set. The
+// undefined entries of go-ethereum's tables are the only ones without a gas
+// cost besides STOP.
+func defined(jt *vm.JumpTable, op vm.OpCode) bool {
+	return op == vm.STOP || jt[op].HasCost()
+}
+
+// opName returns the mnemonic of an opcode, or its hex value if go-ethereum
+// does not know it.
+func opName(op vm.OpCode) string {
+	if name := op.String(); !strings.HasPrefix(name, "opcode ") {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", byte(op))
+}
+
+// analyzeContract classifies the code and disassembles legacy code, counting
+// its reachable instructions and collecting those the instruction set does
+// not define.
+func analyzeContract(addr common.Address, code []byte, jt *vm.JumpTable) *ContractAnalysis {
+	c := &ContractAnalysis{Address: addr, Size: len(code), Kind: CodeLegacy}
+	switch {
+	case len(code) == len(types.DelegationPrefix)+common.AddressLength && bytes.HasPrefix(code, types.DelegationPrefix):
+		c.Kind = CodeDelegation
+		return c
+	case bytes.HasPrefix(code, []byte{0xef, 0x00}):
+		c.Kind = CodeEOF
+		return c
+	case code[0] == 0xef:
+		c.Kind = CodeReserved
+		return c
+	}
+	c.Opcodes = make(map[string]int)
+	reachable := true
+	for pc := 0; pc < len(code); pc++ {
+		op := vm.OpCode(code[pc])
+		if op == vm.JUMPDEST {
+			reachable = true
+		}
+		size := 1
+		if op >= vm.PUSH1 && op <= vm.PUSH32 {
+			// Push data is skipped even in dead code, as the jump destination
+			// analysis does.
+			size += int(op - vm.PUSH0)
+		}
+		if !reachable {
+			c.DeadCode += min(size, len(code)-pc)
+			pc += size - 1
+			continue
+		}
+		c.Opcodes[opName(op)]++
+		if !defined(jt, op) && op != vm.INVALID {
+			c.Invalid = append(c.Invalid, &InvalidInstruction{PC: pc, Opcode: opName(op), op: op})
+		}
+		pc += size - 1
+		switch op {
+		case vm.STOP, vm.JUMP, vm.RETURN, vm.REVERT, vm.INVALID, vm.SELFDESTRUCT:
+			reachable = false
+		default:
+			// Undefined instructions halt execution too.
+			reachable = defined(jt, op)
+		}
+	}
+	return c
+}
+
+// reportInvalid names the fork introducing every invalid instruction of the
+// contract and adds a finding per opcode.
+func reportInvalid(f *Findings, c *ContractAnalysis, config *params.ChainConfig, fork string, later []laterFork) {
+	var (
+		pcs        = make(map[string][]int)
+		introduced = make(map[string]string)
+		order      []string
+	)
+	for _, inv := range c.Invalid {
+		for _, lf := range later {
+			if defined(&lf.jt, inv.op) {
+				inv.IntroducedBy = lf.name
+				break
+			}
+		}
+		if _, ok := pcs[inv.Opcode]; !ok {
+			order = append(order, inv.Opcode)
+		}
+		pcs[inv.Opcode] = append(pcs[inv.Opcode], inv.PC)
+		introduced[inv.Opcode] = inv.IntroducedBy
+	}
+	sort.Strings(order)
+	for _, op := range order {
+		where := fmt.Sprintf("at pc %d", pcs[op][0])
+		if n := len(pcs[op]); n > 1 {
+			where = fmt.Sprintf("at pc %d and %d more", pcs[op][0], n-1)
+		}
+		introducedBy := introduced[op]
+		switch activation := scheduledFork(config, introducedBy); {
+		case introducedBy == "":
+			f.add("error", "opcodes", &c.Address, "undefined opcode %s %s", op, where)
+		case activation != "":
+			f.add("warning", "opcodes", &c.Address, "%s %s is not valid in %s, only from %s at %s", op, where, fork, introducedBy, activation)
+		default:
+			f.add("error", "opcodes", &c.Address, "%s %s is not valid in %s, it is introduced by %s which is not scheduled", op, where, fork, introducedBy)
+		}
+	}
+}
+
+// scheduledFork describes the activation of the named fork in the chain
+// config, or returns the empty string if it is not scheduled.
+func scheduledFork(config *params.ChainConfig, name string) string {
+	if name == "" {
+		return ""
+	}
+	a, err := forks.ActivationOf(config, name)
+	if err != nil || a == nil {
+		return ""
+	}
+	switch {
+	case a.Time != nil:
+		return fmt.Sprintf("timestamp %d", *a.Time)
+	case a.Block != nil:
+		return fmt.Sprintf("block %v", a.Block)
+	default:
+		return fmt.Sprintf("terminal total difficulty %v", a.TerminalTotalDifficulty)
+	}
+}
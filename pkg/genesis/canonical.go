@@ -0,0 +1,151 @@
+package genesis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The canonical encoding of a genesis is the indented JSON encoding in which
+// the keys of every object are sorted lexicographically, hex strings and
+// addresses are lowercase and numbers are plain integers. It only depends on
+// the content of the genesis, so that two encodings of the same genesis are
+// identical byte for byte, whichever tool produced them.
+const canonicalIndent = "    "
+
+// Canonicalize rewrites a JSON genesis, in any of the client formats, into its
+// canonical encoding.
+func Canonicalize(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("trailing data after the genesis object")
+	}
+	enc, err := encodeCanonical(v, "")
+	if err != nil {
+		return nil, err
+	}
+	return append(enc, '\n'), nil
+}
+
+// marshalCanonical returns the canonical encoding of v, with every line after
+// the first one prefixed.
+func marshalCanonical(v interface{}, prefix string) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return encodeCanonical(generic, prefix)
+}
+
+// encodeCanonical encodes a decoded JSON value canonically. The object keys are
+// sorted by the encoder.
+func encodeCanonical(v interface{}, prefix string) ([]byte, error) {
+	v, err := canonicalValue(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(prefix, canonicalIndent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+// canonicalValue normalizes the strings, keys and numbers of a decoded JSON
+// value.
+func canonicalValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			value, err := canonicalValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			canonical := canonicalKey(key)
+			if _, ok := out[canonical]; ok {
+				return nil, fmt.Errorf("duplicate key %s", canonical)
+			}
+			out[canonical] = value
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			value, err := canonicalValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %v", i, err)
+			}
+			out[i] = value
+		}
+		return out, nil
+	case string:
+		if isHex(v) {
+			return strings.ToLower(v), nil
+		}
+		return v, nil
+	case json.Number:
+		return canonicalNumber(v)
+	default:
+		return v, nil
+	}
+}
+
+// canonicalKey lowercases hex object keys, which includes the unprefixed
+// addresses of the geth alloc.
+func canonicalKey(key string) string {
+	if isHex(key) || (len(key) == 2*common.AddressLength && isHex("0x"+key)) {
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+// canonicalNumber rewrites an integral number given with a fraction or an
+// exponent, such as 1.0 or 1e+21, as a plain integer. Genesis files have no
+// fractional fields, other numbers are rejected.
+func canonicalNumber(n json.Number) (json.Number, error) {
+	if !strings.ContainsAny(string(n), ".eE") {
+		if n == "-0" {
+			return "0", nil
+		}
+		return n, nil
+	}
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok || !r.IsInt() {
+		return "", fmt.Errorf("number %s is not an integer", n)
+	}
+	return json.Number(r.Num().String()), nil
+}
+
+// isHex reports whether s is a 0x prefixed, non-empty string of hex digits.
+func isHex(s string) bool {
+	if len(s) <= 2 || (s[:2] != "0x" && s[:2] != "0X") {
+		return false
+	}
+	for _, c := range s[2:] {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
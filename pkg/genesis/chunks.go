@@ -0,0 +1,168 @@
+package genesis
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/compress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ChunkIndex is the index of a genesis whose allocation is split across chunk
+// files. The head is the genesis encoding with an empty allocation under the
+// alloc key, the chunks hold the accounts in address order.
+type ChunkIndex struct {
+	Head     json.RawMessage `json:"genesis"`
+	AllocKey string          `json:"allocKey"`
+	Accounts int             `json:"accounts"`
+	Chunks   []Chunk         `json:"chunks"`
+}
+
+// Chunk is an allocation chunk file: a JSON object of a range of accounts,
+// encoded as in the allocation of the head.
+type Chunk struct {
+	File     string         `json:"file"` // path relative to the index
+	Accounts int            `json:"accounts"`
+	First    common.Address `json:"first"`
+	Last     common.Address `json:"last"`
+	SHA256   hexutil.Bytes  `json:"sha256"` // of the uncompressed chunk
+}
+
+// WriteChunks writes the streamed genesis as an index at the given path and
+// chunk files of at most the given number of accounts next to it, named
+// after the index with the chunk number inserted before the extension. Every
+// file is gzip or zstd compressed if the index path ends in .gz or .zst.
+func (s *Stream) WriteChunks(path string, accounts int) (*ChunkIndex, error) {
+	if accounts <= 0 {
+		return nil, fmt.Errorf("invalid chunk size %d", accounts)
+	}
+	if s.store != nil {
+		return nil, errors.New("a store-backed allocation cannot be written in chunks")
+	}
+	head, err := marshalCanonical(s.head, "")
+	if err != nil {
+		return nil, err
+	}
+	index := &ChunkIndex{Head: head, AllocKey: s.key, Accounts: len(s.alloc)}
+	addrs := alloc.SortedAddresses(s.alloc)
+	for n := 0; n*accounts < len(addrs); n++ {
+		end := (n + 1) * accounts
+		if end > len(addrs) {
+			end = len(addrs)
+		}
+		chunk := Chunk{
+			File:     chunkName(filepath.Base(path), n),
+			Accounts: end - n*accounts,
+			First:    addrs[n*accounts],
+			Last:     addrs[end-1],
+		}
+		if chunk.SHA256, err = s.writeChunk(filepath.Join(filepath.Dir(path), chunk.File), addrs[n*accounts:end]); err != nil {
+			return nil, err
+		}
+		index.Chunks = append(index.Chunks, chunk)
+	}
+	enc, err := marshalCanonical(index, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := compress.WriteFile(path, append(enc, '\n')); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// chunkName inserts the chunk number before the extension of the index name,
+// genesis.json.gz becoming genesis.0000.json.gz.
+func chunkName(index string, n int) string {
+	base := compress.TrimExt(index)
+	ext := filepath.Ext(base) + compress.Ext(index)
+	return fmt.Sprintf("%s.%04d%s", strings.TrimSuffix(base, filepath.Ext(base)), n, ext)
+}
+
+// writeChunk writes the accounts as an allocation object and returns the
+// SHA-256 hash of the encoding.
+func (s *Stream) writeChunk(path string, addrs []common.Address) ([]byte, error) {
+	f, err := compress.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	w := bufio.NewWriter(io.MultiWriter(f, hash))
+	w.WriteByte('{')
+	if err := s.encodeAccounts(w, addrs, ""); err != nil {
+		return nil, err
+	}
+	w.WriteString("}\n")
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), f.Close()
+}
+
+// IsChunkIndex reports whether a JSON document is a chunk index.
+func IsChunkIndex(data []byte) bool {
+	var probe struct {
+		Head   json.RawMessage `json:"genesis"`
+		Chunks json.RawMessage `json:"chunks"`
+	}
+	return json.Unmarshal(data, &probe) == nil && len(probe.Head) > 0 && len(probe.Chunks) > 0
+}
+
+// LoadChunks reads a geth genesis from a chunk index and its chunk files,
+// checking every chunk against its hash and address range.
+func LoadChunks(path string, data []byte) (*core.Genesis, error) {
+	var index ChunkIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	if index.AllocKey != "alloc" {
+		return nil, fmt.Errorf("chunked genesis with accounts under %q, only the geth format is supported", index.AllocKey)
+	}
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(index.Head, genesis); err != nil {
+		return nil, err
+	}
+	genesis.Alloc = make(types.GenesisAlloc, index.Accounts)
+	for _, chunk := range index.Chunks {
+		enc, err := compress.ReadFile(filepath.Join(filepath.Dir(path), chunk.File))
+		if err != nil {
+			return nil, err
+		}
+		if hash := sha256.Sum256(enc); !bytes.Equal(hash[:], chunk.SHA256) {
+			return nil, fmt.Errorf("chunk %s: hash %x, expected %x", chunk.File, hash, []byte(chunk.SHA256))
+		}
+		var accounts types.GenesisAlloc
+		if err := json.Unmarshal(enc, &accounts); err != nil {
+			return nil, fmt.Errorf("chunk %s: %v", chunk.File, err)
+		}
+		if len(accounts) != chunk.Accounts {
+			return nil, fmt.Errorf("chunk %s: %d accounts, expected %d", chunk.File, len(accounts), chunk.Accounts)
+		}
+		for addr, account := range accounts {
+			if bytes.Compare(addr[:], chunk.First[:]) < 0 || bytes.Compare(addr[:], chunk.Last[:]) > 0 {
+				return nil, fmt.Errorf("chunk %s: account %s outside of its range", chunk.File, addr.Hex())
+			}
+			if _, ok := genesis.Alloc[addr]; ok {
+				return nil, fmt.Errorf("chunk %s: account %s in more than one chunk", chunk.File, addr.Hex())
+			}
+			genesis.Alloc[addr] = account
+		}
+	}
+	if len(genesis.Alloc) != index.Accounts {
+		return nil, fmt.Errorf("%d accounts in the chunks, expected %d", len(genesis.Alloc), index.Accounts)
+	}
+	return genesis, nil
+}
@@ -0,0 +1,111 @@
+package genesis
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// The extraData of a Clique genesis is the vanity, the list of initial signers
+// and an empty seal, as the genesis block is not signed.
+const (
+	cliqueVanity = 32
+	cliqueSeal   = 65
+)
+
+// CliqueParams configures a Clique proof-of-authority genesis.
+type CliqueParams struct {
+	Signers []common.Address
+	Vanity  []byte
+	Period  uint64
+	Epoch   uint64
+}
+
+// ParseCliqueSigners parses a comma separated list of signer addresses.
+func ParseCliqueSigners(list string) ([]common.Address, error) {
+	var signers []common.Address
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid signer address %q", s)
+		}
+		signers = append(signers, common.HexToAddress(s))
+	}
+	return signers, nil
+}
+
+// CliqueExtraData assembles the extraData of a Clique genesis. The signers are
+// sorted in ascending order, the order of the signer list of the Clique
+// snapshots, and the vanity is zero padded to 32 bytes.
+func CliqueExtraData(vanity []byte, signers []common.Address) ([]byte, error) {
+	if len(vanity) > cliqueVanity {
+		return nil, fmt.Errorf("vanity of %d bytes exceeds %d bytes", len(vanity), cliqueVanity)
+	}
+	if len(signers) == 0 {
+		return nil, errors.New("no signers")
+	}
+	sorted := make([]common.Address, len(signers))
+	copy(sorted, signers)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i][:], sorted[j][:]) < 0 })
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i] == sorted[i-1] {
+			return nil, fmt.Errorf("duplicate signer %s", sorted[i].Hex())
+		}
+	}
+	extra := make([]byte, cliqueVanity, cliqueVanity+len(sorted)*common.AddressLength+cliqueSeal)
+	copy(extra, vanity)
+	for _, signer := range sorted {
+		extra = append(extra, signer[:]...)
+	}
+	return append(extra, make([]byte, cliqueSeal)...), nil
+}
+
+// CliqueSigners decodes the signers from the extraData of a Clique genesis,
+// checking the 32+20N+65 byte layout.
+func CliqueSigners(extra []byte) ([]common.Address, error) {
+	if len(extra) < cliqueVanity+cliqueSeal {
+		return nil, fmt.Errorf("extraData of %d bytes shorter than a vanity and seal of %d bytes", len(extra), cliqueVanity+cliqueSeal)
+	}
+	list := extra[cliqueVanity : len(extra)-cliqueSeal]
+	if len(list)%common.AddressLength != 0 {
+		return nil, fmt.Errorf("extraData signer list of %d bytes is not a multiple of %d", len(list), common.AddressLength)
+	}
+	if len(list) == 0 {
+		return nil, errors.New("extraData lists no signers")
+	}
+	if !bytes.Equal(extra[len(extra)-cliqueSeal:], make([]byte, cliqueSeal)) {
+		return nil, errors.New("extraData seal of the genesis block is not empty")
+	}
+	signers := make([]common.Address, len(list)/common.AddressLength)
+	for i := range signers {
+		copy(signers[i][:], list[i*common.AddressLength:])
+	}
+	return signers, nil
+}
+
+// ApplyClique turns the genesis into the one of a Clique network sealed by the
+// given signers, replacing the Ethash engine and the difficulty.
+func ApplyClique(genesis *core.Genesis, p CliqueParams) error {
+	if p.Epoch == 0 {
+		return errors.New("epoch must be positive")
+	}
+	extra, err := CliqueExtraData(p.Vanity, p.Signers)
+	if err != nil {
+		return err
+	}
+	config := genesis.Config
+	config.Clique = &params.CliqueConfig{Period: p.Period, Epoch: p.Epoch}
+	config.Ethash = nil
+	genesis.ExtraData = extra
+	genesis.Difficulty = big.NewInt(1)
+	genesis.Nonce = 0
+	genesis.Mixhash = common.Hash{}
+	return nil
+}
@@ -0,0 +1,154 @@
+package genesis
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// farFutureEpoch is the epoch of consensus layer forks that are not scheduled.
+const farFutureEpoch = math.MaxUint64
+
+// clForks maps the timestamp based execution layer forks onto the consensus
+// layer forks activating together with them.
+var clForks = []struct {
+	field string // schedule field of the execution layer fork
+	name  string // prefix of the consensus layer config keys
+}{
+	{"shanghai-time", "CAPELLA"},
+	{"cancun-time", "DENEB"},
+	{"prague-time", "ELECTRA"},
+	{"osaka-time", "FULU"},
+	{"amsterdam-time", "GLOAS"},
+}
+
+// clBlobForks are the blob parameter only forks, which the consensus layer
+// lists in its BLOB_SCHEDULE.
+var clBlobForks = []string{"bpo1-time", "bpo2-time", "bpo3-time", "bpo4-time", "bpo5-time"}
+
+// clPresets maps the consensus layer presets onto their epoch length in slots.
+var clPresets = map[string]uint64{
+	"mainnet": 32,
+	"minimal": 8,
+}
+
+// CLParams configures the consensus layer parameter export.
+type CLParams struct {
+	Preset          string
+	SecondsPerSlot  uint64
+	DepositContract common.Address
+	GenesisHash     common.Hash // hash of the execution layer genesis block
+}
+
+// WriteCLConfig writes the consensus layer parameters matching the genesis as
+// YAML. The values are meant to be merged over the preset's config.yaml.
+func WriteCLConfig(path string, genesis *core.Genesis, p CLParams) error {
+	data, err := EncodeCLConfig(genesis, p)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// EncodeCLConfig derives the consensus layer parameters from the genesis. The
+// beacon chain is assumed to start together with the execution layer, so the
+// genesis times coincide and every timestamp fork has to activate at an epoch
+// boundary.
+func EncodeCLConfig(genesis *core.Genesis, p CLParams) ([]byte, error) {
+	config := genesis.Config
+	slotsPerEpoch, ok := clPresets[p.Preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown consensus layer preset %q", p.Preset)
+	}
+	if p.SecondsPerSlot == 0 {
+		return nil, errors.New("seconds per slot must be positive")
+	}
+	if config.TerminalTotalDifficulty == nil || config.TerminalTotalDifficulty.Sign() != 0 {
+		return nil, errors.New("consensus layer parameters require the merge at genesis (terminalTotalDifficulty 0)")
+	}
+	deposit := p.DepositContract
+	if deposit == (common.Address{}) {
+		deposit = config.DepositContractAddress
+	}
+	if deposit == (common.Address{}) {
+		return nil, errors.New("no deposit contract address in the chain config, set one with --cl-deposit-contract")
+	}
+	epochLength := p.SecondsPerSlot * slotsPerEpoch
+	epochOf := func(flag string) (uint64, error) {
+		at := *forks.FindField(flag).Time(config)
+		switch {
+		case at == nil:
+			return farFutureEpoch, nil
+		case *at <= genesis.Timestamp:
+			return 0, nil
+		case (*at-genesis.Timestamp)%epochLength != 0:
+			return 0, fmt.Errorf("%s %d is not at an epoch boundary (genesis time %d, %d seconds per epoch)", flag, *at, genesis.Timestamp, epochLength)
+		default:
+			return (*at - genesis.Timestamp) / epochLength, nil
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Consensus layer parameters matching the execution layer genesis\n")
+	fmt.Fprintf(&b, "# (block hash %s).\n", p.GenesisHash.Hex())
+	fmt.Fprintf(&b, "PRESET_BASE: '%s'\n", p.Preset)
+	fmt.Fprintf(&b, "\n# Genesis\n")
+	fmt.Fprintf(&b, "MIN_GENESIS_TIME: %d\n", genesis.Timestamp)
+	fmt.Fprintf(&b, "GENESIS_DELAY: 0\n")
+	fmt.Fprintf(&b, "\n# Transition\n")
+	fmt.Fprintf(&b, "TERMINAL_TOTAL_DIFFICULTY: %v\n", config.TerminalTotalDifficulty)
+	fmt.Fprintf(&b, "TERMINAL_BLOCK_HASH: %s\n", common.Hash{}.Hex())
+	fmt.Fprintf(&b, "TERMINAL_BLOCK_HASH_ACTIVATION_EPOCH: %d\n", uint64(farFutureEpoch))
+	fmt.Fprintf(&b, "\n# Forks\n")
+	fmt.Fprintf(&b, "ALTAIR_FORK_EPOCH: 0\n")
+	fmt.Fprintf(&b, "BELLATRIX_FORK_EPOCH: 0\n")
+	for _, fork := range clForks {
+		epoch, err := epochOf(fork.field)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "%s_FORK_EPOCH: %d\n", fork.name, epoch)
+	}
+	fmt.Fprintf(&b, "\n# Time parameters\n")
+	fmt.Fprintf(&b, "SECONDS_PER_SLOT: %d\n", p.SecondsPerSlot)
+	fmt.Fprintf(&b, "\n# Deposit contract\n")
+	fmt.Fprintf(&b, "DEPOSIT_CHAIN_ID: %v\n", config.ChainID)
+	fmt.Fprintf(&b, "DEPOSIT_NETWORK_ID: %v\n", config.ChainID)
+	fmt.Fprintf(&b, "DEPOSIT_CONTRACT_ADDRESS: %s\n", deposit.Hex())
+
+	if schedule := config.BlobScheduleConfig; schedule != nil {
+		fmt.Fprintf(&b, "\n# Blobs\n")
+		if schedule.Cancun != nil {
+			fmt.Fprintf(&b, "MAX_BLOBS_PER_BLOCK: %d\n", schedule.Cancun.Max)
+		}
+		if schedule.Prague != nil {
+			fmt.Fprintf(&b, "MAX_BLOBS_PER_BLOCK_ELECTRA: %d\n", schedule.Prague.Max)
+		}
+		var entries []string
+		for _, flag := range clBlobForks {
+			field := forks.FindField(flag)
+			blob := *field.Blob(schedule)
+			if *field.Time(config) == nil || blob == nil {
+				continue
+			}
+			epoch, err := epochOf(flag)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, fmt.Sprintf("  - EPOCH: %d\n    MAX_BLOBS_PER_BLOCK: %d\n", epoch, blob.Max))
+		}
+		if len(entries) > 0 {
+			fmt.Fprintf(&b, "BLOB_SCHEDULE:\n")
+			for _, entry := range entries {
+				b.WriteString(entry)
+			}
+		}
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,310 @@
+package genesis
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// DecodeConfig decodes the chain config of a genesis file in one of the
+// formats of Formats, the inverse of its encoder. The DAO fork is supported
+// exactly if it is scheduled in the Besu and Nethermind formats, which cannot
+// express opposing it.
+func DecodeConfig(format string, data []byte) (*params.ChainConfig, error) {
+	switch format {
+	case "geth", "erigon", "reth":
+		var genesis struct {
+			Config *params.ChainConfig `json:"config"`
+		}
+		if err := json.Unmarshal(data, &genesis); err != nil {
+			return nil, err
+		}
+		if genesis.Config == nil {
+			return nil, fmt.Errorf("missing chain config")
+		}
+		return genesis.Config, nil
+	case "besu":
+		return decodeBesuConfig(data)
+	case "nethermind":
+		return decodeNethermindConfig(data)
+	}
+	return nil, fmt.Errorf("unknown format %q, supported formats: %s", format, strings.Join(FormatNames(), ", "))
+}
+
+// decodeBesuConfig decodes the chain config of a Besu genesis, renaming its
+// fields back to the geth ones.
+func decodeBesuConfig(data []byte) (*params.ChainConfig, error) {
+	var genesis struct {
+		Config map[string]json.RawMessage `json:"config"`
+	}
+	if err := json.Unmarshal(data, &genesis); err != nil {
+		return nil, err
+	}
+	if genesis.Config == nil {
+		return nil, fmt.Errorf("missing chain config")
+	}
+	fields := genesis.Config
+	if v, ok := fields["mergeNetSplitBlock"]; ok {
+		delete(fields, "mergeNetSplitBlock")
+		fields["mergeNetsplitBlock"] = v
+	}
+	var clique struct {
+		Period uint64 `json:"blockperiodseconds"`
+		Epoch  uint64 `json:"epochlength"`
+	}
+	raw, hasClique := fields["clique"]
+	if hasClique {
+		if err := json.Unmarshal(raw, &clique); err != nil {
+			return nil, fmt.Errorf("clique: %v", err)
+		}
+		delete(fields, "clique")
+	}
+	enc, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	config := new(params.ChainConfig)
+	if err := json.Unmarshal(enc, config); err != nil {
+		return nil, err
+	}
+	config.DAOForkSupport = config.DAOForkBlock != nil
+	if hasClique {
+		config.Clique = &params.CliqueConfig{Period: clique.Period, Epoch: clique.Epoch}
+	}
+	return config, nil
+}
+
+// nethermindChainspecConfig is the part of a Nethermind chainspec holding
+// the chain config.
+type nethermindChainspecConfig struct {
+	Engine struct {
+		Ethash *struct {
+			Params map[string]json.RawMessage `json:"params"`
+		} `json:"Ethash"`
+		Clique *struct {
+			Params struct {
+				Period uint64 `json:"period"`
+				Epoch  uint64 `json:"epoch"`
+			} `json:"params"`
+		} `json:"clique"`
+	} `json:"engine"`
+	Params map[string]json.RawMessage `json:"params"`
+}
+
+// decodeNethermindConfig decodes the chain config of a Nethermind chainspec.
+// A fork is scheduled at the transition of the first chainspec parameter it
+// enables, the blob parameter only forks at their blob schedule entry. The
+// forks only delaying the difficulty bomb are found from the bomb delays of
+// the Ethash engine left over by the other forks.
+func decodeNethermindConfig(data []byte) (*params.ChainConfig, error) {
+	var spec nethermindChainspecConfig
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	if spec.Params == nil {
+		return nil, fmt.Errorf("missing chainspec params")
+	}
+	config := new(params.ChainConfig)
+	chainID, err := nethermindNumber(spec.Params, "chainID")
+	if err != nil {
+		return nil, err
+	}
+	if chainID == nil {
+		if chainID, err = nethermindNumber(spec.Params, "networkID"); err != nil {
+			return nil, err
+		}
+	}
+	config.ChainID = chainID
+
+	var engine map[string]json.RawMessage
+	if spec.Engine.Ethash != nil {
+		config.Ethash = new(params.EthashConfig)
+		engine = spec.Engine.Ethash.Params
+	}
+	if c := spec.Engine.Clique; c != nil {
+		config.Clique = &params.CliqueConfig{Period: c.Params.Period, Epoch: c.Params.Epoch}
+	}
+	for _, field := range forks.Fields {
+		var (
+			at  *big.Int
+			err error
+		)
+		switch {
+		case field.Flag == "homestead-block":
+			at, err = nethermindNumber(engine, "homesteadTransition")
+		case field.Flag == "dao-fork-block":
+			at, err = nethermindNumber(engine, "daoHardforkTransition")
+			config.DAOForkSupport = at != nil
+		case field.Flag == "merge-netsplit-block":
+			at, err = nethermindNumber(spec.Params, "MergeForkIdTransition")
+		default:
+			suffix := "Transition"
+			if field.Time != nil {
+				suffix = "TransitionTimestamp"
+			}
+			if eips := nethermindTransitions[field.Flag]; len(eips) > 0 {
+				at, err = nethermindNumber(spec.Params, eips[0]+suffix)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if at == nil {
+			continue
+		}
+		if field.Block != nil {
+			*field.Block(config) = at
+		} else {
+			if !at.IsUint64() {
+				return nil, fmt.Errorf("%s: timestamp %v out of range", field.Flag, at)
+			}
+			time := at.Uint64()
+			*field.Time(config) = &time
+		}
+	}
+	if err := decodeNethermindBombDelays(config, engine); err != nil {
+		return nil, err
+	}
+	if config.TerminalTotalDifficulty, err = nethermindNumber(spec.Params, "terminalTotalDifficulty"); err != nil {
+		return nil, err
+	}
+	if raw, ok := spec.Params["depositContractAddress"]; ok {
+		if err := json.Unmarshal(raw, &config.DepositContractAddress); err != nil {
+			return nil, fmt.Errorf("depositContractAddress: %v", err)
+		}
+	}
+	if raw, ok := spec.Params["blobSchedule"]; ok {
+		if err := decodeNethermindBlobSchedule(config, raw); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// nethermindNumber decodes a chainspec parameter given as a hex or decimal
+// string or as a JSON number, returning nil if it is missing.
+func nethermindNumber(fields map[string]json.RawMessage, key string) (*big.Int, error) {
+	raw, ok := fields[key]
+	if !ok {
+		return nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		s = string(raw)
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("%s: invalid number %s", key, raw)
+	}
+	return n, nil
+}
+
+// decodeNethermindBombDelays schedules the forks of nethermindBombDelays which
+// have no other chainspec parameter. The delays of the forks already
+// scheduled are taken off the delays of their blocks, and each remaining fork
+// is given the first later block whose delay left covers its own.
+func decodeNethermindBombDelays(config *params.ChainConfig, engine map[string]json.RawMessage) error {
+	raw, ok := engine["difficultyBombDelays"]
+	if !ok {
+		return nil
+	}
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return fmt.Errorf("difficultyBombDelays: %v", err)
+	}
+	type delay struct {
+		block *big.Int
+		left  uint64
+	}
+	var delays []*delay
+	for key := range entries {
+		block, ok := new(big.Int).SetString(key, 0)
+		if !ok {
+			return fmt.Errorf("difficultyBombDelays: invalid block %q", key)
+		}
+		amount, err := nethermindNumber(entries, key)
+		if err != nil {
+			return fmt.Errorf("difficultyBombDelays: %v", err)
+		}
+		if !amount.IsUint64() {
+			return fmt.Errorf("difficultyBombDelays: delay %v out of range", amount)
+		}
+		delays = append(delays, &delay{block: block, left: amount.Uint64()})
+	}
+	sort.Slice(delays, func(i, j int) bool { return delays[i].block.Cmp(delays[j].block) < 0 })
+
+	for _, d := range nethermindBombDelays {
+		if _, ok := nethermindTransitions[d.field]; !ok {
+			continue
+		}
+		if block := *forks.FindField(d.field).Block(config); block != nil {
+			for _, entry := range delays {
+				if entry.block.Cmp(block) == 0 && entry.left >= d.delay {
+					entry.left -= d.delay
+				}
+			}
+		}
+	}
+	var last *big.Int
+	for _, d := range nethermindBombDelays {
+		block := forks.FindField(d.field).Block(config)
+		if _, ok := nethermindTransitions[d.field]; ok {
+			if *block != nil {
+				last = *block
+			}
+			continue
+		}
+		for _, entry := range delays {
+			if (last == nil || entry.block.Cmp(last) >= 0) && entry.left >= d.delay {
+				entry.left -= d.delay
+				*block, last = new(big.Int).Set(entry.block), entry.block
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// decodeNethermindBlobSchedule decodes the list form of the blob schedule,
+// scheduling the blob parameter only forks at the timestamps of their
+// entries.
+func decodeNethermindBlobSchedule(config *params.ChainConfig, raw json.RawMessage) error {
+	var entries []struct {
+		Name                  string          `json:"name"`
+		Timestamp             json.RawMessage `json:"timestamp"`
+		Target                uint64          `json:"target"`
+		Max                   uint64          `json:"max"`
+		BaseFeeUpdateFraction json.RawMessage `json:"baseFeeUpdateFraction"`
+	}
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return fmt.Errorf("blobSchedule: %v", err)
+	}
+	config.BlobScheduleConfig = new(params.BlobScheduleConfig)
+	for _, entry := range entries {
+		field := forks.FindField(strings.ToLower(entry.Name) + "-time")
+		if field == nil || field.Blob == nil {
+			return fmt.Errorf("blobSchedule: unknown fork %q", entry.Name)
+		}
+		fields := map[string]json.RawMessage{"timestamp": entry.Timestamp, "baseFeeUpdateFraction": entry.BaseFeeUpdateFraction}
+		fraction, err := nethermindNumber(fields, "baseFeeUpdateFraction")
+		if err != nil || fraction == nil || !fraction.IsUint64() {
+			return fmt.Errorf("blobSchedule: %s: invalid baseFeeUpdateFraction %s", entry.Name, entry.BaseFeeUpdateFraction)
+		}
+		*field.Blob(config.BlobScheduleConfig) = &params.BlobConfig{Target: int(entry.Target), Max: int(entry.Max), UpdateFraction: fraction.Uint64()}
+
+		if strings.HasPrefix(field.Flag, "bpo") {
+			timestamp, err := nethermindNumber(fields, "timestamp")
+			if err != nil || timestamp == nil || !timestamp.IsUint64() {
+				return fmt.Errorf("blobSchedule: %s: invalid timestamp %s", entry.Name, entry.Timestamp)
+			}
+			time := timestamp.Uint64()
+			*field.Time(config) = &time
+		}
+	}
+	return nil
+}
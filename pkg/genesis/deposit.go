@@ -0,0 +1,218 @@
+package genesis
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/presets"
+	"github.com/ethereum/execution-specs/pkg/ssz"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// The storage layout of the deposit contract: the branch of the incremental
+// merkle tree at slots 0 to 31, the deposit count at slot 32 and the zero
+// hashes of every level of the tree after it.
+const (
+	depositBranchSlot    = 0
+	depositCountSlot     = ssz.DepositTreeDepth
+	depositZeroHashSlot  = ssz.DepositTreeDepth + 1
+	depositMinimumAmount = 1_000_000_000 // in gwei, 1 ether
+)
+
+// Selectors of the getters of the deposit contract.
+var (
+	getDepositRoot  = []byte{0xc5, 0xf2, 0x89, 0x2f}
+	getDepositCount = []byte{0x62, 0x1f, 0xd1, 0x30}
+)
+
+// DefaultDepositContractAddress is the address of the deposit contract on
+// mainnet and Hoodi.
+var DefaultDepositContractAddress = params.MainnetChainConfig.DepositContractAddress
+
+// DepositContractCode returns the runtime code of the beacon chain deposit
+// contract, as embedded in the genesis of Hoodi. It is the code deployed on
+// mainnet; the one of Holesky only differs by its compiler metadata.
+func DepositContractCode() []byte {
+	return presets.Hoodi().Alloc[DefaultDepositContractAddress].Code
+}
+
+// depositFile is an entry of a deposit_data file. Its hex fields have no 0x
+// prefix; the other fields of the CLI are ignored.
+type depositFile struct {
+	Pubkey                string `json:"pubkey"`
+	WithdrawalCredentials string `json:"withdrawal_credentials"`
+	Amount                uint64 `json:"amount"`
+	Signature             string `json:"signature"`
+	DepositDataRoot       string `json:"deposit_data_root"`
+}
+
+// LoadDeposits reads the deposits of a deposit_data file of the staking
+// deposit CLI, a JSON array of deposits in the order they are inserted into
+// the tree. The deposit_data_root of a deposit, if given, must be its hash
+// tree root. The signatures are not verified, which is left to the consensus
+// layer building its genesis state from the same file.
+func LoadDeposits(path string) ([]ssz.DepositData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []depositFile
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	deposits := make([]ssz.DepositData, len(entries))
+	for i, entry := range entries {
+		d := &deposits[i]
+		if err := decodeFixed(d.Pubkey[:], entry.Pubkey); err != nil {
+			return nil, fmt.Errorf("%s: deposit %d: invalid pubkey: %v", path, i, err)
+		}
+		if err := decodeFixed(d.WithdrawalCredentials[:], entry.WithdrawalCredentials); err != nil {
+			return nil, fmt.Errorf("%s: deposit %d: invalid withdrawal_credentials: %v", path, i, err)
+		}
+		if err := decodeFixed(d.Signature[:], entry.Signature); err != nil {
+			return nil, fmt.Errorf("%s: deposit %d: invalid signature: %v", path, i, err)
+		}
+		d.Amount = entry.Amount
+		if entry.DepositDataRoot != "" {
+			var root common.Hash
+			if err := decodeFixed(root[:], entry.DepositDataRoot); err != nil {
+				return nil, fmt.Errorf("%s: deposit %d: invalid deposit_data_root: %v", path, i, err)
+			}
+			if have := ssz.DepositDataRoot(d); have != root {
+				return nil, fmt.Errorf("%s: deposit %d: deposit_data_root %x differs from the hash tree root %x of the deposit", path, i, root, have)
+			}
+		}
+	}
+	return deposits, nil
+}
+
+// decodeFixed decodes a hex string, with or without 0x prefix, of exactly the
+// length of the buffer.
+func decodeFixed(buf []byte, s string) error {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return err
+	}
+	if len(b) != len(buf) {
+		return fmt.Errorf("%d bytes, want %d", len(b), len(buf))
+	}
+	copy(buf, b)
+	return nil
+}
+
+// hashPair returns the SHA-256 hash of two concatenated nodes of the deposit
+// tree.
+func hashPair(a, b common.Hash) common.Hash {
+	return sha256.Sum256(append(a[:], b[:]...))
+}
+
+// depositZeroHashes returns the roots of the empty subtrees of every height
+// of the deposit tree.
+func depositZeroHashes() [ssz.DepositTreeDepth]common.Hash {
+	var zero [ssz.DepositTreeDepth]common.Hash
+	for i := 1; i < ssz.DepositTreeDepth; i++ {
+		zero[i] = hashPair(zero[i-1], zero[i-1])
+	}
+	return zero
+}
+
+// DepositContractAccount returns the account of the deposit contract after
+// the deposits were made in order: its code, the zero hashes of the tree, the
+// branch and count of the deposits and their amounts as its balance. Every
+// amount must be at least 1 ether, the minimum the contract accepts.
+func DepositContractAccount(deposits []ssz.DepositData) (types.Account, error) {
+	if uint64(len(deposits)) >= 1<<ssz.DepositTreeDepth {
+		return types.Account{}, errors.New("the deposit tree is full")
+	}
+	var (
+		zero    = depositZeroHashes()
+		branch  [ssz.DepositTreeDepth]common.Hash
+		balance = new(big.Int)
+		storage = make(map[common.Hash]common.Hash)
+	)
+	for i := 1; i < ssz.DepositTreeDepth; i++ {
+		storage[common.BigToHash(big.NewInt(int64(depositZeroHashSlot+i)))] = zero[i]
+	}
+	for i := range deposits {
+		d := &deposits[i]
+		if d.Amount < depositMinimumAmount {
+			return types.Account{}, fmt.Errorf("deposit %d: amount %d gwei below the minimum deposit of 1 ether", i, d.Amount)
+		}
+		balance.Add(balance, new(big.Int).Mul(new(big.Int).SetUint64(d.Amount), big.NewInt(params.GWei)))
+
+		node, size := ssz.DepositDataRoot(d), uint64(i+1)
+		for height := 0; height < ssz.DepositTreeDepth; height++ {
+			if size&1 == 1 {
+				branch[height] = node
+				break
+			}
+			node = hashPair(branch[height], node)
+			size /= 2
+		}
+	}
+	for height, node := range branch {
+		if node != (common.Hash{}) {
+			storage[common.BigToHash(big.NewInt(int64(depositBranchSlot+height)))] = node
+		}
+	}
+	if len(deposits) > 0 {
+		storage[common.BigToHash(big.NewInt(depositCountSlot))] = common.BigToHash(big.NewInt(int64(len(deposits))))
+	}
+	return types.Account{
+		Code:    DepositContractCode(),
+		Balance: balance,
+		Storage: storage,
+	}, nil
+}
+
+// CheckDepositContract executes get_deposit_root and get_deposit_count of the
+// deposit contract account in the go-ethereum EVM and checks that they return
+// the root of the deposit tree and the number of deposits.
+func CheckDepositContract(account types.Account, deposits []ssz.DepositData) error {
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	if err != nil {
+		return err
+	}
+	addr := DefaultDepositContractAddress
+	statedb.SetBalance(addr, uint256.MustFromBig(account.Balance), tracing.BalanceChangeUnspecified)
+	statedb.SetCode(addr, account.Code, tracing.CodeChangeUnspecified)
+	for key, value := range account.Storage {
+		statedb.SetState(addr, key, value)
+	}
+	cfg := &runtime.Config{State: statedb}
+
+	ret, _, err := runtime.Call(addr, getDepositRoot, cfg)
+	if err != nil {
+		return fmt.Errorf("get_deposit_root: %v", err)
+	}
+	root := ssz.DepositsRoot(deposits)
+	// The root is returned ABI encoded as bytes32.
+	if len(ret) != 32 || !bytes.Equal(ret, root[:]) {
+		return fmt.Errorf("get_deposit_root returns %x, want %x", ret, root)
+	}
+	ret, _, err = runtime.Call(addr, getDepositCount, cfg)
+	if err != nil {
+		return fmt.Errorf("get_deposit_count: %v", err)
+	}
+	// The count is returned ABI encoded as bytes, 8 little endian bytes.
+	var count [8]byte
+	binary.LittleEndian.PutUint64(count[:], uint64(len(deposits)))
+	if len(ret) != 96 || !bytes.Equal(ret[64:72], count[:]) {
+		return fmt.Errorf("get_deposit_count returns %x, want %d deposits", ret, len(deposits))
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package genesis
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// AAVariants lists the experimental native account abstraction proposals
+// prototype clients can be configured for, keyed by the prefix of their
+// activation field.
+var AAVariants = map[string]string{
+	"rip7560": "RIP-7560 native account abstraction",
+	"eip7701": "EIP-7701 native account abstraction",
+}
+
+// AAVariantNames returns the sorted names of the account abstraction variants.
+func AAVariantNames() []string {
+	names := make([]string, 0, len(AAVariants))
+	for name := range AAVariants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExperimentalAA configures the activation of an experimental native account
+// abstraction proposal on a prototype devnet. None of the proposals is part
+// of a scheduled fork, so the activation is not part of the chain config:
+// it is added to the encoded genesis under the name prototype clients read,
+// <variant>Time in the geth style formats and <variant>TransitionTimestamp in
+// Nethermind chainspecs, and ignored by every other client. The zero value
+// disables it.
+type ExperimentalAA struct {
+	Variant string // proposal from AAVariants, empty if disabled
+	Time    uint64 // activation timestamp
+
+	// NonceManager is the RIP-7712 nonce manager predeploy of RIP-7560,
+	// whose code is not final and therefore supplied by the prototype. Nodes
+	// without it only accept transactions using the legacy nonce key.
+	NonceManager     common.Address
+	NonceManagerCode []byte
+}
+
+// Enabled reports whether an account abstraction proposal is activated. A nil
+// configuration is disabled.
+func (aa *ExperimentalAA) Enabled() bool {
+	return aa != nil && aa.Variant != ""
+}
+
+// Apply checks the configuration against the genesis and returns the
+// predeploys the proposal requires.
+func (aa *ExperimentalAA) Apply(genesis *core.Genesis) (types.GenesisAlloc, error) {
+	if !aa.Enabled() {
+		if len(aa.NonceManagerCode) > 0 {
+			return nil, errors.New("nonce manager given without an account abstraction variant")
+		}
+		return nil, nil
+	}
+	if _, ok := AAVariants[aa.Variant]; !ok {
+		return nil, fmt.Errorf("unknown account abstraction variant %q", aa.Variant)
+	}
+	// All proposals pay for the transactions with the EIP-1559 fee fields.
+	if !forks.Scheduled(genesis.Config, "london-block") {
+		return nil, errors.New("account abstraction requires london")
+	}
+	if aa.Time < genesis.Timestamp {
+		return nil, fmt.Errorf("activation time %d precedes the genesis timestamp %d", aa.Time, genesis.Timestamp)
+	}
+	alloc := make(types.GenesisAlloc)
+	if len(aa.NonceManagerCode) > 0 {
+		if aa.Variant != "rip7560" {
+			return nil, fmt.Errorf("%s has no nonce manager predeploy", aa.Variant)
+		}
+		alloc[aa.NonceManager] = types.Account{
+			Nonce:   1,
+			Code:    aa.NonceManagerCode,
+			Balance: new(big.Int),
+		}
+	}
+	return alloc, nil
+}
+
+// Extend adds the activation of the proposal to a genesis encoded in the given
+// format.
+func (aa *ExperimentalAA) Extend(s *Stream, format string) error {
+	if !aa.Enabled() {
+		return nil
+	}
+	config, err := s.config(format)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "nethermind":
+		config[aa.Variant+"TransitionTimestamp"] = hexutil.EncodeUint64(aa.Time)
+	default:
+		config[aa.Variant+"Time"] = aa.Time
+	}
+	return nil
+}
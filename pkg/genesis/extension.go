@@ -0,0 +1,222 @@
+package genesis
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"gopkg.in/yaml.v3"
+)
+
+// Extension adds the chain config fields and predeploys of a downstream
+// chain, such as an L2 or a research fork, to the generated genesis, so that
+// the chain needs no fork of the tool. Extensions are either declared in an
+// extension file or implemented in Go by programs building on this package,
+// for fields computed from the genesis, and registered with
+// RegisterExtension.
+type Extension interface {
+	// Name identifies the extension, in errors among others.
+	Name() string
+
+	// Predeploys returns the predeploys the extension adds to the catalog.
+	// Like the built-in ones they are only inserted on request.
+	Predeploys() []Predeploy
+
+	// ConfigFields returns the fields the extension adds to the chain config
+	// of the genesis encoded in the format, the params of a Nethermind
+	// chainspec. Fields already present in the encoding are rejected, an
+	// extension cannot silently change the meaning of a built-in field.
+	ConfigFields(genesis *core.Genesis, format string) (map[string]interface{}, error)
+}
+
+// extensions are the registered extensions in registration order.
+var extensions []Extension
+
+// RegisterExtension registers an extension, adding its predeploys to the
+// catalog and its config fields to every encoded genesis. The names of the
+// extensions and the names and addresses of the predeploys must be unique.
+func RegisterExtension(ext Extension) error {
+	for _, registered := range extensions {
+		if registered.Name() == ext.Name() {
+			return fmt.Errorf("extension %s is already registered", ext.Name())
+		}
+	}
+	catalog := Predeploys
+	for _, p := range ext.Predeploys() {
+		for _, q := range catalog {
+			switch {
+			case p.Name == q.Name:
+				return fmt.Errorf("extension %s: predeploy %s is already in the catalog", ext.Name(), p.Name)
+			case p.Address == q.Address:
+				return fmt.Errorf("extension %s: predeploy %s has the address of %s", ext.Name(), p.Name, q.Name)
+			}
+		}
+		catalog = append(catalog, p)
+	}
+	Predeploys = catalog
+	extensions = append(extensions, ext)
+	return nil
+}
+
+// Extensions returns the names of the registered extensions.
+func Extensions() []string {
+	names := make([]string, len(extensions))
+	for i, ext := range extensions {
+		names[i] = ext.Name()
+	}
+	return names
+}
+
+// ExtendConfig adds the config fields of the registered extensions to a
+// genesis encoded in the given format.
+func ExtendConfig(s *Stream, genesis *core.Genesis, format string) error {
+	if len(extensions) == 0 {
+		return nil
+	}
+	config, err := s.config(format)
+	if err != nil {
+		return err
+	}
+	for _, ext := range extensions {
+		fields, err := ext.ConfigFields(genesis, format)
+		if err != nil {
+			return fmt.Errorf("extension %s: %v", ext.Name(), err)
+		}
+		for _, name := range sortedKeys(fields) {
+			if _, ok := config[name]; ok {
+				return fmt.Errorf("extension %s: config field %s is already set", ext.Name(), name)
+			}
+			config[name] = fields[name]
+		}
+	}
+	return nil
+}
+
+// LoadExtension reads an extension file and registers its extension.
+func LoadExtension(path string) error {
+	ext, err := LoadExtensionFile(path)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return RegisterExtension(ext)
+}
+
+// ExtensionFile is a declarative extension, a YAML (and therefore also JSON)
+// document of the form
+//
+//	name: example-l2
+//	config:
+//	  exampleBlock: 0
+//	  example:
+//	    sequencer: "0x..."
+//	formats:
+//	  nethermind:
+//	    exampleTransition: "0x0"
+//	predeploys:
+//	  - name: example-bridge
+//	    description: L1 bridge endpoint
+//	    address: "0x..."
+//	    nonce: "1"
+//	    code: "0x..."
+//	    storage:
+//	      "0x00": "0x01"
+//
+// The config fields are added to every format, except to those the formats
+// section gives the fields of instead. The predeploys have the account fields
+// of a template account except for the balance, predeploys have none.
+type ExtensionFile struct {
+	ExtensionName string                            `yaml:"name"`
+	Config        map[string]interface{}            `yaml:"config"`
+	Formats       map[string]map[string]interface{} `yaml:"formats"`
+	Catalog       []ExtensionPredeploy              `yaml:"predeploys"`
+
+	predeploys []Predeploy
+}
+
+// ExtensionPredeploy is a predeploy of an extension file.
+type ExtensionPredeploy struct {
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Address     string            `yaml:"address"`
+	Nonce       string            `yaml:"nonce"`
+	Code        string            `yaml:"code"`
+	Storage     map[string]string `yaml:"storage"`
+}
+
+// LoadExtensionFile reads and checks an extension file.
+func LoadExtensionFile(path string) (*ExtensionFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseExtensionFile(data)
+}
+
+// ParseExtensionFile decodes and checks an extension file.
+func ParseExtensionFile(data []byte) (*ExtensionFile, error) {
+	ext := new(ExtensionFile)
+	if err := yaml.Unmarshal(data, ext); err != nil {
+		return nil, err
+	}
+	if ext.ExtensionName == "" {
+		return nil, errors.New("extension has no name")
+	}
+	for format := range ext.Formats {
+		if _, ok := Formats[format]; !ok {
+			return nil, fmt.Errorf("unknown format %q, supported formats: %s", format, strings.Join(FormatNames(), ", "))
+		}
+	}
+	for _, p := range ext.Catalog {
+		if p.Name == "" {
+			return nil, errors.New("predeploy without a name")
+		}
+		if !common.IsHexAddress(p.Address) {
+			return nil, fmt.Errorf("predeploy %s: invalid address %q", p.Name, p.Address)
+		}
+		account, err := alloc.ParseAccount("", p.Nonce, p.Code, p.Storage)
+		if err != nil {
+			return nil, fmt.Errorf("predeploy %s: %v", p.Name, err)
+		}
+		ext.predeploys = append(ext.predeploys, Predeploy{
+			Name:        p.Name,
+			Description: p.Description,
+			Address:     common.HexToAddress(p.Address),
+			Nonce:       account.Nonce,
+			Code:        account.Code,
+			Storage:     account.Storage,
+		})
+	}
+	return ext, nil
+}
+
+// Name implements Extension.
+func (ext *ExtensionFile) Name() string {
+	return ext.ExtensionName
+}
+
+// Predeploys implements Extension.
+func (ext *ExtensionFile) Predeploys() []Predeploy {
+	return ext.predeploys
+}
+
+// ConfigFields implements Extension.
+func (ext *ExtensionFile) ConfigFields(genesis *core.Genesis, format string) (map[string]interface{}, error) {
+	if fields, ok := ext.Formats[format]; ok {
+		return fields, nil
+	}
+	return ext.Config, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
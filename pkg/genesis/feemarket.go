@@ -0,0 +1,68 @@
+package genesis
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// FeeMarket configures the EIP-1559 fee market of chains deviating from the
+// mainnet parameters, as is common for L2s and appchains. The zero value is
+// the mainnet fee market.
+type FeeMarket struct {
+	ElasticityMultiplier     uint64 // elasticity multiplier, 0 for the mainnet value
+	BaseFeeChangeDenominator uint64 // base fee change denominator, 0 for the mainnet value
+	ZeroBaseFee              bool   // base fee fixed at zero, transactions can pay no fees
+}
+
+// Custom reports whether the fee market deviates from the mainnet parameters.
+// A nil fee market is the mainnet one.
+func (m *FeeMarket) Custom() bool {
+	return m != nil && (m.ElasticityMultiplier != 0 || m.BaseFeeChangeDenominator != 0 || m.ZeroBaseFee)
+}
+
+// Apply checks the fee market parameters and sets the initial base fee of the
+// genesis. The base fee is only part of the genesis header if london is
+// active at genesis, so it can neither be set otherwise.
+func (m *FeeMarket) Apply(genesis *core.Genesis, baseFee *big.Int) error {
+	if m.ElasticityMultiplier == params.DefaultElasticityMultiplier {
+		m.ElasticityMultiplier = 0
+	}
+	if m.BaseFeeChangeDenominator == params.DefaultBaseFeeChangeDenominator {
+		m.BaseFeeChangeDenominator = 0
+	}
+	if m.ZeroBaseFee {
+		if baseFee != nil && baseFee.Sign() != 0 {
+			return fmt.Errorf("initial base fee %v contradicts the zero base fee mode", baseFee)
+		}
+		baseFee = new(big.Int)
+	}
+	if baseFee == nil && !m.Custom() {
+		return nil
+	}
+	if !genesis.Config.IsLondon(new(big.Int).SetUint64(genesis.Number)) {
+		return errors.New("london is not active at genesis")
+	}
+	if baseFee != nil {
+		genesis.BaseFee = baseFee
+	}
+	return nil
+}
+
+// Supported checks that a client format can configure the fee market, given
+// whether it has a zero base fee mode and whether it takes the elasticity
+// multiplier and base fee change denominator.
+func (m *FeeMarket) Supported(format string, zeroBaseFee, parameters bool) error {
+	switch {
+	case !m.Custom():
+		return nil
+	case m.ZeroBaseFee && !zeroBaseFee:
+		return fmt.Errorf("%s has no zero base fee mode", format)
+	case (m.ElasticityMultiplier != 0 || m.BaseFeeChangeDenominator != 0) && !parameters:
+		return fmt.Errorf("%s only supports the mainnet elasticity multiplier and base fee change denominator", format)
+	}
+	return nil
+}
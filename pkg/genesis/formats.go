@@ -0,0 +1,379 @@
+package genesis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Formats maps the supported format names to the encoders converting a
+// genesis into the representation expected by the respective client. The fee
+// market is nil for chains using the mainnet parameters.
+var Formats = map[string]func(*core.Genesis, *FeeMarket) (*Stream, error){
+	"geth":       gethGenesis,
+	"besu":       besuGenesis,
+	"erigon":     func(g *core.Genesis, m *FeeMarket) (*Stream, error) { return mergedGenesis(g, m, "erigon") },
+	"reth":       func(g *core.Genesis, m *FeeMarket) (*Stream, error) { return mergedGenesis(g, m, "reth") },
+	"nethermind": nethermindChainspec,
+}
+
+// FormatNames returns the sorted list of supported output formats.
+func FormatNames() []string {
+	names := make([]string, 0, len(Formats))
+	for name := range Formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Fields returns the geth JSON encoding of the genesis as a generic map,
+// preserving numbers verbatim, so that client specific encoders can rename and
+// extend the fields.
+func Fields(genesis *core.Genesis) (map[string]interface{}, error) {
+	data, err := json.Marshal(genesis)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// gethGenesis encodes the genesis for geth, whose fee market is fixed to the
+// mainnet parameters.
+func gethGenesis(genesis *core.Genesis, market *FeeMarket) (*Stream, error) {
+	if err := market.Supported("geth", false, false); err != nil {
+		return nil, err
+	}
+	return gethAllocStream(WithoutAlloc(genesis), genesis.Alloc), nil
+}
+
+// mergedGenesis encodes the genesis for Erigon and Reth. Both accept the geth
+// format but additionally expect the merge to be flagged explicitly once the
+// terminal total difficulty has been reached at genesis.
+func mergedGenesis(genesis *core.Genesis, market *FeeMarket, format string) (*Stream, error) {
+	if err := market.Supported(format, false, false); err != nil {
+		return nil, err
+	}
+	fields, err := Fields(WithoutAlloc(genesis))
+	if err != nil {
+		return nil, err
+	}
+	config := fields["config"].(map[string]interface{})
+	if ttd := genesis.Config.TerminalTotalDifficulty; ttd != nil && ttd.Cmp(genesisDifficulty(genesis)) <= 0 {
+		config["terminalTotalDifficultyPassed"] = true
+	}
+	return gethAllocStream(fields, genesis.Alloc), nil
+}
+
+// besuGenesis encodes the genesis for Besu, which uses a few differently
+// named chain config fields. Of the fee market parameters Besu only supports
+// the zero base fee mode.
+func besuGenesis(genesis *core.Genesis, market *FeeMarket) (*Stream, error) {
+	if err := market.Supported("besu", true, false); err != nil {
+		return nil, err
+	}
+	fields, err := Fields(WithoutAlloc(genesis))
+	if err != nil {
+		return nil, err
+	}
+	config := fields["config"].(map[string]interface{})
+	if v, ok := config["mergeNetsplitBlock"]; ok {
+		delete(config, "mergeNetsplitBlock")
+		config["mergeNetSplitBlock"] = v
+	}
+	// Besu has no notion of opposing the DAO fork, it's scheduled or not.
+	if !genesis.Config.DAOForkSupport {
+		delete(config, "daoForkBlock")
+	}
+	delete(config, "daoForkSupport")
+
+	if clique := genesis.Config.Clique; clique != nil {
+		config["clique"] = map[string]interface{}{
+			"blockperiodseconds": clique.Period,
+			"epochlength":        clique.Epoch,
+		}
+	}
+	if market.Custom() && market.ZeroBaseFee {
+		config["zeroBaseFee"] = true
+	}
+	return gethAllocStream(fields, genesis.Alloc), nil
+}
+
+// genesisDifficulty returns the difficulty of the genesis block, treating an
+// unset value the same way geth does.
+func genesisDifficulty(genesis *core.Genesis) *big.Int {
+	if genesis.Difficulty == nil {
+		return params.GenesisDifficulty
+	}
+	return genesis.Difficulty
+}
+
+// nethermindTransitions lists the Nethermind chainspec parameters enabled by
+// each schedule field. Block based ones are suffixed with "Transition",
+// timestamp based ones with "TransitionTimestamp".
+var nethermindTransitions = map[string][]string{
+	"eip150-block":         {"eip150"},
+	"eip155-block":         {"eip155"},
+	"eip158-block":         {"eip160", "eip161abc", "eip161d", "maxCodeSize"},
+	"byzantium-block":      {"eip140", "eip211", "eip214", "eip658"},
+	"constantinople-block": {"eip145", "eip1014", "eip1052", "eip1283"},
+	"petersburg-block":     {"eip1283Disable"},
+	"istanbul-block":       {"eip152", "eip1108", "eip1344", "eip1884", "eip2028", "eip2200"},
+	"berlin-block":         {"eip2565", "eip2929", "eip2930"},
+	"london-block":         {"eip1559", "eip3198", "eip3529", "eip3541"},
+	"shanghai-time":        {"eip3651", "eip3855", "eip3860", "eip4895"},
+	"cancun-time":          {"eip1153", "eip4788", "eip4844", "eip5656", "eip6780"},
+	"prague-time":          {"eip2537", "eip2935", "eip6110", "eip7002", "eip7251", "eip7623", "eip7702"},
+	"osaka-time":           {"eip7594", "eip7823", "eip7825", "eip7883", "eip7917", "eip7918", "eip7934", "eip7939", "eip7951"},
+}
+
+// nethermindEngineFields are the schedule fields that are configured in the
+// Ethash engine section of a Nethermind chainspec instead of its parameters.
+var nethermindEngineFields = map[string]bool{
+	"homestead-block":      true,
+	"dao-fork-block":       true,
+	"muir-glacier-block":   true,
+	"arrow-glacier-block":  true,
+	"gray-glacier-block":   true,
+	"merge-netsplit-block": true,
+}
+
+// nethermindBombDelays are the difficulty bomb delays introduced by each fork,
+// relative to the previous delay.
+var nethermindBombDelays = []struct {
+	field string
+	delay uint64
+}{
+	{"byzantium-block", 3000000},
+	{"constantinople-block", 2000000},
+	{"muir-glacier-block", 4000000},
+	{"london-block", 700000},
+	{"arrow-glacier-block", 1000000},
+	{"gray-glacier-block", 700000},
+}
+
+// nethermindChainspec converts the genesis into a Nethermind chainspec.
+func nethermindChainspec(genesis *core.Genesis, market *FeeMarket) (*Stream, error) {
+	if err := market.Supported("nethermind", false, true); err != nil {
+		return nil, err
+	}
+	var (
+		config     = genesis.Config
+		engine     = make(map[string]interface{})
+		specParams = map[string]interface{}{
+			"gasLimitBoundDivisor": "0x400",
+			"accountStartNonce":    "0x0",
+			"maximumExtraDataSize": "0x20",
+			"minGasLimit":          "0x1388",
+			"networkID":            hexutil.EncodeBig(config.ChainID),
+			"chainID":              hexutil.EncodeBig(config.ChainID),
+		}
+	)
+	for _, field := range forks.Fields {
+		var (
+			at     string
+			suffix = "Transition"
+		)
+		if field.Block != nil {
+			block := *field.Block(config)
+			if block == nil {
+				continue
+			}
+			at = hexutil.EncodeBig(block)
+		} else {
+			time := *field.Time(config)
+			if time == nil {
+				continue
+			}
+			at, suffix = hexutil.EncodeUint64(*time), "TransitionTimestamp"
+		}
+		switch {
+		case nethermindEngineFields[field.Flag]:
+			switch field.Flag {
+			case "homestead-block":
+				engine["homesteadTransition"] = at
+			case "dao-fork-block":
+				// The drained DAO accounts are built into Nethermind for
+				// mainnet and not part of the emitted chainspec.
+				if config.DAOForkSupport {
+					engine["daoHardforkTransition"] = at
+				}
+			case "merge-netsplit-block":
+				specParams["MergeForkIdTransition"] = at
+			}
+		case field.Flag == "byzantium-block":
+			engine["eip100bTransition"] = at
+			fallthrough
+		default:
+			eips, ok := nethermindTransitions[field.Flag]
+			if !ok && !strings.HasPrefix(field.Flag, "bpo") {
+				return nil, fmt.Errorf("%s has no nethermind chainspec equivalent", field.Flag)
+			}
+			for _, eip := range eips {
+				specParams[eip+suffix] = at
+			}
+			if field.Flag == "eip158-block" {
+				specParams["maxCodeSize"] = hexutil.EncodeUint64(params.MaxCodeSize)
+			}
+		}
+	}
+	// Assemble the block rewards and bomb delays of the proof-of-work era.
+	rewards := map[string]string{"0x0": hexutil.EncodeBig(new(big.Int).Mul(big.NewInt(5), big.NewInt(params.Ether)))}
+	if config.ByzantiumBlock != nil {
+		rewards[hexutil.EncodeBig(config.ByzantiumBlock)] = hexutil.EncodeBig(new(big.Int).Mul(big.NewInt(3), big.NewInt(params.Ether)))
+	}
+	if config.ConstantinopleBlock != nil {
+		rewards[hexutil.EncodeBig(config.ConstantinopleBlock)] = hexutil.EncodeBig(new(big.Int).Mul(big.NewInt(2), big.NewInt(params.Ether)))
+	}
+	var (
+		delays = make(map[string]uint64) // forks activating together add up
+		blocks = make(map[string]*big.Int)
+	)
+	for _, field := range forks.Fields {
+		if field.Block != nil {
+			blocks[field.Flag] = *field.Block(config)
+		}
+	}
+	for _, d := range nethermindBombDelays {
+		if block := blocks[d.field]; block != nil {
+			delays[hexutil.EncodeBig(block)] += d.delay
+		}
+	}
+	bombDelays := make(map[string]string, len(delays))
+	for block, delay := range delays {
+		bombDelays[block] = hexutil.EncodeUint64(delay)
+	}
+	engine["minimumDifficulty"] = hexutil.EncodeBig(params.MinimumDifficulty)
+	engine["difficultyBoundDivisor"] = hexutil.EncodeBig(params.DifficultyBoundDivisor)
+	engine["durationLimit"] = hexutil.EncodeBig(params.DurationLimit)
+	engine["blockReward"] = rewards
+	engine["difficultyBombDelays"] = bombDelays
+
+	if ttd := config.TerminalTotalDifficulty; ttd != nil {
+		specParams["terminalTotalDifficulty"] = ttd.String()
+	}
+	if config.DepositContractAddress != (common.Address{}) {
+		specParams["depositContractAddress"] = config.DepositContractAddress
+	}
+	if schedule := nethermindBlobSchedule(config); len(schedule) > 0 {
+		specParams["blobSchedule"] = schedule
+	}
+	if market.Custom() {
+		if market.ElasticityMultiplier != 0 {
+			specParams["eip1559ElasticityMultiplier"] = hexutil.EncodeUint64(market.ElasticityMultiplier)
+		}
+		if market.BaseFeeChangeDenominator != 0 {
+			specParams["eip1559BaseFeeMaxChangeDenominator"] = hexutil.EncodeUint64(market.BaseFeeChangeDenominator)
+		}
+	}
+
+	// Assemble the genesis header and the account allocation.
+	header := map[string]interface{}{
+		"seal": map[string]interface{}{
+			"ethereum": map[string]interface{}{
+				"nonce":   types.EncodeNonce(genesis.Nonce),
+				"mixHash": genesis.Mixhash,
+			},
+		},
+		"difficulty": hexutil.EncodeBig(genesisDifficulty(genesis)),
+		"author":     genesis.Coinbase,
+		"timestamp":  hexutil.EncodeUint64(genesis.Timestamp),
+		"parentHash": genesis.ParentHash,
+		"extraData":  hexutil.Bytes(genesis.ExtraData),
+		"gasLimit":   hexutil.EncodeUint64(genesis.GasLimit),
+	}
+	if genesis.BaseFee != nil {
+		header["baseFeePerGas"] = hexutil.EncodeBig(genesis.BaseFee)
+	}
+	if genesis.ExcessBlobGas != nil {
+		header["excessBlobGas"] = hexutil.EncodeUint64(*genesis.ExcessBlobGas)
+	}
+	if genesis.BlobGasUsed != nil {
+		header["blobGasUsed"] = hexutil.EncodeUint64(*genesis.BlobGasUsed)
+	}
+	account := func(addr common.Address, account types.Account) (string, interface{}) {
+		entry := map[string]interface{}{"balance": "0x0"}
+		if account.Balance != nil {
+			entry["balance"] = hexutil.EncodeBig(account.Balance)
+		}
+		if account.Nonce != 0 {
+			entry["nonce"] = hexutil.EncodeUint64(account.Nonce)
+		}
+		if len(account.Code) > 0 {
+			entry["code"] = hexutil.Bytes(account.Code)
+		}
+		if len(account.Storage) > 0 {
+			entry["storage"] = account.Storage
+		}
+		return strings.ToLower(addr.Hex()), entry
+	}
+	name := params.NetworkNames[config.ChainID.String()]
+	if name == "" {
+		name = "devnet"
+	}
+	var engines map[string]interface{}
+	if config.Clique != nil {
+		engines = map[string]interface{}{
+			"clique": map[string]interface{}{
+				"params": map[string]interface{}{"period": config.Clique.Period, "epoch": config.Clique.Epoch},
+			},
+		}
+	} else {
+		engines = map[string]interface{}{"Ethash": map[string]interface{}{"params": engine}}
+	}
+	return &Stream{
+		head: map[string]interface{}{
+			"name":     name,
+			"engine":   engines,
+			"params":   specParams,
+			"genesis":  header,
+			"accounts": map[string]interface{}{},
+		},
+		key:     "accounts",
+		alloc:   genesis.Alloc,
+		account: account,
+	}, nil
+}
+
+// nethermindBlobSchedule converts the blob schedule into the list form used by
+// Nethermind chainspecs, only containing the scheduled forks.
+func nethermindBlobSchedule(config *params.ChainConfig) []map[string]interface{} {
+	if config.BlobScheduleConfig == nil {
+		return nil
+	}
+	var schedule []map[string]interface{}
+	for _, field := range forks.Fields {
+		if field.Blob == nil || *field.Time(config) == nil {
+			continue
+		}
+		blob := *field.Blob(config.BlobScheduleConfig)
+		if blob == nil {
+			continue
+		}
+		schedule = append(schedule, map[string]interface{}{
+			"name":                  strings.TrimSuffix(field.Flag, "-time"),
+			"timestamp":             hexutil.EncodeUint64(**field.Time(config)),
+			"target":                blob.Target,
+			"max":                   blob.Max,
+			"baseFeeUpdateFraction": hexutil.EncodeUint64(blob.UpdateFraction),
+		})
+	}
+	return schedule
+}
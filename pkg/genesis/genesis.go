@@ -0,0 +1,112 @@
+// Package genesis constructs the genesis of Ethereum networks. It derives the
+// genesis of the public networks embedded by the presets package, applies
+// templates, Clique and fee market settings, computes the genesis block
+// without a state database, encodes the genesis for the various execution
+// clients, decodes their chain configs and checks genesis files against the
+// spec invariants.
+package genesis
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/compress"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/execution-specs/pkg/presets"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Networks maps the supported network names to their genesis definitions.
+var Networks = map[string]func() *core.Genesis{
+	"mainnet": presets.Mainnet,
+	"sepolia": presets.Sepolia,
+	"holesky": presets.Holesky,
+	"hoodi":   presets.Hoodi,
+}
+
+// NetworkNames returns the sorted list of supported network names.
+func NetworkNames() []string {
+	names := make([]string, 0, len(Networks))
+	for name := range Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New creates the genesis of the network with a private copy of its chain
+// config. If a fork is given, it is activated at genesis; from Paris on the
+// header then carries the post-merge constants, zero difficulty, nonce and
+// mix digest, instead of those of the proof-of-work genesis of the network.
+func New(network, fork string) (*core.Genesis, error) {
+	makeGenesis, ok := Networks[network]
+	if !ok {
+		return nil, fmt.Errorf("unknown network %q, supported networks: %s", network, strings.Join(NetworkNames(), ", "))
+	}
+	genesis := makeGenesis()
+
+	config := *genesis.Config
+	genesis.Config = &config
+	if fork != "" {
+		if err := forks.Activate(genesis.Config, fork); err != nil {
+			return nil, err
+		}
+		if ttd := genesis.Config.TerminalTotalDifficulty; ttd != nil && ttd.Sign() == 0 {
+			genesis.Difficulty = new(big.Int)
+			genesis.Nonce = 0
+			genesis.Mixhash = common.Hash{}
+		}
+	}
+	return genesis, nil
+}
+
+// Load reads a genesis file in the geth JSON format, optionally gzip or zstd
+// compressed, or a chunk index of one written by Stream.WriteChunks.
+func Load(path string) (*core.Genesis, error) {
+	data, err := compress.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if IsChunkIndex(data) {
+		genesis, err := LoadChunks(path, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		return genesis, nil
+	}
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(data, genesis); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return genesis, nil
+}
+
+// ToBlock assembles the genesis block like core.Genesis.ToBlock, computing
+// the state root with alloc.Root instead of a state database. Verkle genesis
+// states are left to go-ethereum.
+func ToBlock(genesis *core.Genesis, jobs int) (*types.Block, error) {
+	if genesis.IsVerkle() {
+		return genesis.ToBlock(), nil
+	}
+	root, err := alloc.Root(genesis.Alloc, jobs)
+	if err != nil {
+		return nil, err
+	}
+	block := WithoutAlloc(genesis).ToBlock()
+	header := block.Header()
+	header.Root = root
+	return block.WithSeal(header), nil
+}
+
+// WithoutAlloc returns a shallow copy of the genesis with an empty allocation.
+func WithoutAlloc(genesis *core.Genesis) *core.Genesis {
+	head := *genesis
+	head.Alloc = types.GenesisAlloc{}
+	return &head
+}
@@ -0,0 +1,177 @@
+package genesis
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// HeaderFields are the genesis header fields which can be overridden, by their
+// name in the geth genesis format.
+var HeaderFields = []string{"number", "parentHash", "extraData", "nonce", "mixHash", "coinbase", "gasLimit", "timestamp", "baseFeePerGas"}
+
+// HeaderOverrides replaces fields of the genesis header. Nil fields are left
+// unchanged. The base fee is not applied by Apply but passed to
+// FeeMarket.Apply, which checks it against the fee market.
+type HeaderOverrides struct {
+	Number     *uint64
+	ParentHash *common.Hash
+	ExtraData  []byte
+	Nonce      *uint64
+	MixHash    *common.Hash
+	Coinbase   *common.Address
+	GasLimit   *uint64
+	Timestamp  *uint64
+	BaseFee    *big.Int
+}
+
+// ParseHeaderOverrides parses header field values keyed by the names of
+// HeaderFields. Empty values are skipped.
+func ParseHeaderOverrides(fields map[string]string) (*HeaderOverrides, error) {
+	o := new(HeaderOverrides)
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := fields[name]
+		if value == "" {
+			continue
+		}
+		var err error
+		switch name {
+		case "number":
+			o.Number, err = parseUint64(value)
+		case "parentHash":
+			var hash common.Hash
+			if hash, err = alloc.ParseHash(value); err == nil {
+				o.ParentHash = &hash
+			}
+		case "extraData":
+			o.ExtraData, err = hexutil.Decode(value)
+		case "nonce":
+			o.Nonce, err = parseUint64(value)
+		case "mixHash":
+			var hash common.Hash
+			if hash, err = alloc.ParseHash(value); err == nil {
+				o.MixHash = &hash
+			}
+		case "coinbase":
+			if !common.IsHexAddress(value) {
+				err = errors.New("not an address")
+			} else {
+				addr := common.HexToAddress(value)
+				o.Coinbase = &addr
+			}
+		case "gasLimit":
+			o.GasLimit, err = parseUint64(value)
+		case "timestamp":
+			o.Timestamp, err = parseUint64(value)
+		case "baseFeePerGas":
+			var ok bool
+			if o.BaseFee, ok = math.ParseBig256(value); !ok {
+				err = errors.New("not a 256 bit integer")
+			}
+		default:
+			return nil, fmt.Errorf("unknown header field %q, supported fields: %s", name, strings.Join(HeaderFields, ", "))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", name, value, err)
+		}
+	}
+	return o, nil
+}
+
+func parseUint64(s string) (*uint64, error) {
+	n, ok := math.ParseUint64(s)
+	if !ok {
+		return nil, errors.New("not a 64 bit integer")
+	}
+	return &n, nil
+}
+
+// Apply writes the overridden fields, except the base fee, into the genesis.
+// The fields are not checked, Check does once the genesis is complete.
+func (o *HeaderOverrides) Apply(genesis *core.Genesis) {
+	if o.Number != nil {
+		genesis.Number = *o.Number
+	}
+	if o.ParentHash != nil {
+		genesis.ParentHash = *o.ParentHash
+	}
+	if o.ExtraData != nil {
+		genesis.ExtraData = o.ExtraData
+	}
+	if o.Nonce != nil {
+		genesis.Nonce = *o.Nonce
+	}
+	if o.MixHash != nil {
+		genesis.Mixhash = *o.MixHash
+	}
+	if o.Coinbase != nil {
+		genesis.Coinbase = *o.Coinbase
+	}
+	if o.GasLimit != nil {
+		genesis.GasLimit = *o.GasLimit
+	}
+	if o.Timestamp != nil {
+		genesis.Timestamp = *o.Timestamp
+	}
+}
+
+// Check checks the overridden fields of the complete genesis against the
+// rules of its fork and consensus engine:
+//
+//   - the gas limit is within the protocol bounds,
+//   - the base fee requires london at genesis,
+//   - a post-merge genesis has a zero nonce, as required by EIP-3675, and an
+//     extraData of at most 32 bytes, the limit of execution payloads,
+//   - a Clique genesis has a zero nonce, mixHash and coinbase, as its
+//     checkpoint block casts no vote, and an extraData listing the signers.
+//
+// Before the merge the nonce and mixHash are the unchecked proof-of-work seal
+// of the genesis and may hold any value. Fields which are not overridden are
+// the ones of the network and not checked; holesky, for one, has a nonzero
+// nonce although it is post-merge at genesis.
+func (o *HeaderOverrides) Check(genesis *core.Genesis) error {
+	if o.GasLimit != nil && (genesis.GasLimit < params.MinGasLimit || genesis.GasLimit > params.MaxGasLimit) {
+		return fmt.Errorf("gas limit %d outside of [%d, %d]", genesis.GasLimit, params.MinGasLimit, params.MaxGasLimit)
+	}
+	if o.BaseFee != nil && !genesis.Config.IsLondon(new(big.Int).SetUint64(genesis.Number)) {
+		return errors.New("baseFeePerGas set but london is not active at genesis")
+	}
+	if ttd := genesis.Config.TerminalTotalDifficulty; ttd != nil && ttd.Sign() == 0 {
+		if o.Nonce != nil && genesis.Nonce != 0 {
+			return fmt.Errorf("nonzero nonce %#x in a post-merge genesis", genesis.Nonce)
+		}
+		if o.ExtraData != nil && len(genesis.ExtraData) > int(params.MaximumExtraDataSize) {
+			return fmt.Errorf("extraData of %d bytes in a post-merge genesis exceeds %d bytes", len(genesis.ExtraData), params.MaximumExtraDataSize)
+		}
+	}
+	if genesis.Config.Clique != nil {
+		switch {
+		case o.Nonce != nil && genesis.Nonce != 0:
+			return fmt.Errorf("nonzero nonce %#x in a clique genesis", genesis.Nonce)
+		case o.MixHash != nil && genesis.Mixhash != (common.Hash{}):
+			return fmt.Errorf("nonzero mixHash %s in a clique genesis", genesis.Mixhash.Hex())
+		case o.Coinbase != nil && genesis.Coinbase != (common.Address{}):
+			return fmt.Errorf("nonzero coinbase %s in a clique genesis", genesis.Coinbase.Hex())
+		}
+		if o.ExtraData != nil {
+			if _, err := CliqueSigners(genesis.ExtraData); err != nil {
+				return fmt.Errorf("clique genesis: %v", err)
+			}
+		}
+	}
+	return nil
+}
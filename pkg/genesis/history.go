@@ -0,0 +1,123 @@
+package genesis
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// HistoryWindow returns the ancestor blocks of a genesis at the given number
+// whose hashes the EIP-2935 history contract holds: the first one still in
+// the ring buffer after the system call of the next block, and the parent of
+// the genesis. The hash of the genesis itself is stored by the next block. It
+// reports false for a genesis at block 0, which has no ancestors.
+func HistoryWindow(number uint64) (first, last uint64, ok bool) {
+	if number == 0 {
+		return 0, 0, false
+	}
+	if number >= params.HistoryServeWindow {
+		first = number - params.HistoryServeWindow + 1
+	}
+	return first, number - 1, true
+}
+
+// SyntheticHistory returns made up hashes for every ancestor of a genesis at
+// the given number within the history window, for devnets pretending to be
+// at block N without a real chain below. The hash of block k is the keccak256
+// hash of the 8 byte big endian k, so the same number always yields the same
+// history.
+func SyntheticHistory(number uint64) map[uint64]common.Hash {
+	hashes := make(map[uint64]common.Hash)
+	first, last, ok := HistoryWindow(number)
+	if !ok {
+		return hashes
+	}
+	for k := first; ; k++ {
+		var enc [8]byte
+		binary.BigEndian.PutUint64(enc[:], k)
+		hashes[k] = crypto.Keccak256Hash(enc[:])
+		if k == last {
+			break
+		}
+	}
+	return hashes
+}
+
+// LoadHistory reads ancestor block hashes from a JSON object mapping block
+// numbers, decimal or hex, to their hashes, such as exported from the chain
+// being forked.
+func LoadHistory(path string) (map[uint64]common.Hash, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	hashes := make(map[uint64]common.Hash, len(raw))
+	for key, value := range raw {
+		number, ok := math.ParseUint64(key)
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid block number %q", path, key)
+		}
+		hash, err := alloc.ParseHash(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: block %d: %v", path, number, err)
+		}
+		hashes[number] = hash
+	}
+	return hashes, nil
+}
+
+// PrefillHistory writes the ancestor block hashes into the ring buffer of the
+// EIP-2935 history contract of the allocation, block k at slot k modulo the
+// serve window, so that the contract answers for them from the first block
+// after the genesis. Every hash must be of a block within HistoryWindow of the
+// genesis number. The parent hash of the genesis header is set to the hash of
+// its parent block, and must match it if already set.
+func PrefillHistory(genesis *core.Genesis, hashes map[uint64]common.Hash) error {
+	account, ok := genesis.Alloc[params.HistoryStorageAddress]
+	if !ok || len(account.Code) == 0 {
+		return fmt.Errorf("no EIP-2935 history contract at %s in the allocation", params.HistoryStorageAddress.Hex())
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	first, last, ok := HistoryWindow(genesis.Number)
+	if !ok {
+		return fmt.Errorf("a genesis at block 0 has no ancestors")
+	}
+	storage := make(map[common.Hash]common.Hash, len(account.Storage)+len(hashes))
+	for slot, value := range account.Storage {
+		storage[slot] = value
+	}
+	for number, hash := range hashes {
+		if number < first || number > last {
+			return fmt.Errorf("block %d outside of the history window [%d, %d] of a genesis at block %d", number, first, last, genesis.Number)
+		}
+		var slot common.Hash
+		binary.BigEndian.PutUint64(slot[common.HashLength-8:], number%params.HistoryServeWindow)
+		storage[slot] = hash
+	}
+	if parent, ok := hashes[last]; ok {
+		switch genesis.ParentHash {
+		case common.Hash{}:
+			genesis.ParentHash = parent
+		case parent:
+		default:
+			return fmt.Errorf("parent hash %s of the genesis differs from the hash %s of block %d", genesis.ParentHash.Hex(), parent.Hex(), last)
+		}
+	}
+	account.Storage = storage
+	genesis.Alloc[params.HistoryStorageAddress] = account
+	return nil
+}
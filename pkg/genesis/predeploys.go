@@ -0,0 +1,122 @@
+package genesis
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// SystemContract is a predeploy the protocol issues system calls into. All of
+// them are deployed with nonce 1 and start out with empty storage.
+type SystemContract struct {
+	Name    string
+	Field   string // schedule field of the fork requiring the contract
+	Address common.Address
+	Code    []byte
+}
+
+// SystemContracts lists the canonical system contracts in activation order.
+var SystemContracts = []SystemContract{
+	{Name: "EIP-4788 beacon roots", Field: "cancun-time", Address: params.BeaconRootsAddress, Code: params.BeaconRootsCode},
+	{Name: "EIP-2935 history storage", Field: "prague-time", Address: params.HistoryStorageAddress, Code: params.HistoryStorageCode},
+	{Name: "EIP-7002 withdrawal requests", Field: "prague-time", Address: params.WithdrawalQueueAddress, Code: params.WithdrawalQueueCode},
+	{Name: "EIP-7251 consolidation requests", Field: "prague-time", Address: params.ConsolidationQueueAddress, Code: params.ConsolidationQueueCode},
+}
+
+// SystemContractAlloc returns the system contracts required by the forks
+// scheduled in the chain config.
+func SystemContractAlloc(config *params.ChainConfig) types.GenesisAlloc {
+	alloc := make(types.GenesisAlloc)
+	for _, contract := range SystemContracts {
+		if forks.Scheduled(config, contract.Field) {
+			alloc[contract.Address] = types.Account{
+				Nonce:   1,
+				Code:    contract.Code,
+				Balance: new(big.Int),
+			}
+		}
+	}
+	return alloc
+}
+
+// Predeploy is a well-known infrastructure contract which applications expect
+// at its canonical address. Unlike the system contracts they are never
+// required by a fork and only inserted on request.
+type Predeploy struct {
+	Name        string
+	Description string
+	Address     common.Address
+	Nonce       uint64
+	Code        []byte
+	Storage     map[common.Hash]common.Hash
+}
+
+// Predeploys is the catalog of infrastructure predeploys.
+//
+// The deterministic deployment proxy is deployed on mainnet by a keyless
+// transaction, which makes its address independent of the chain but requires
+// a chain without EIP-155 replay protection. It creates the contract of the
+// init code following a 32 byte salt in the calldata with CREATE2 and returns
+// its address. Its code is the one go-ethereum adopts for EIP-7997.
+//
+// Multicall3, at 0xcA11bde05977b3631167028862bE2a173976CA11 on every chain,
+// and WETH9, at 0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2 on mainnet, have
+// canonical runtime code as well. They are added by a follow-up embedding the
+// code deployed on mainnet, as a recompiled variant would not match the code
+// hash applications check for.
+var Predeploys = []Predeploy{
+	{
+		Name:        "create2-deployer",
+		Description: "deterministic deployment proxy (Arachnid)",
+		Address:     common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C"),
+		Nonce:       1,
+		Code:        common.FromHex("0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf3"),
+	},
+}
+
+// PredeployNames returns the names of the catalog predeploys.
+func PredeployNames() []string {
+	names := make([]string, len(Predeploys))
+	for i, p := range Predeploys {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// PredeployAlloc returns the named predeploys of the catalog.
+func PredeployAlloc(names []string) (types.GenesisAlloc, error) {
+	alloc := make(types.GenesisAlloc)
+	for _, name := range names {
+		p, err := lookupPredeploy(name)
+		if err != nil {
+			return nil, err
+		}
+		account := types.Account{
+			Nonce:   p.Nonce,
+			Code:    p.Code,
+			Balance: new(big.Int),
+		}
+		if len(p.Storage) > 0 {
+			account.Storage = make(map[common.Hash]common.Hash, len(p.Storage))
+			for slot, value := range p.Storage {
+				account.Storage[slot] = value
+			}
+		}
+		alloc[p.Address] = account
+	}
+	return alloc, nil
+}
+
+func lookupPredeploy(name string) (*Predeploy, error) {
+	for i := range Predeploys {
+		if Predeploys[i].Name == name {
+			return &Predeploys[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown predeploy %q, supported predeploys: %s", name, strings.Join(PredeployNames(), ", "))
+}
@@ -0,0 +1,163 @@
+package genesis
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// pythonHeader is the head of the Python module, up to the allocation. Its
+// arguments are the summary line, the fork package and the fork name.
+const pythonHeader = `"""
+%s
+
+Generated by the execution-specs genesis tool, do not edit. The module is a
+pytest plugin: listing it in ` + "``pytest_plugins``" + ` provides the
+` + "``genesis_state``" + ` fixture, a fresh state holding the allocation, whose
+` + "``state_root``" + ` is STATE_ROOT.
+"""
+
+from typing import Dict
+
+import pytest
+from ethereum_types.bytes import Bytes, Bytes32
+from ethereum_types.numeric import U64, U256, Uint
+
+from %[2]s.fork_types import Account, Address
+from %[2]s.state import State, set_account, set_storage
+
+FORK = %[3]q
+`
+
+// pythonFooter builds the state from the allocation.
+const pythonFooter = `
+
+def genesis_state() -> State:
+    """
+    Return a new state holding the genesis allocation.
+    """
+    state = State()
+    for address, account in ACCOUNTS.items():
+        set_account(state, address, account)
+    for address, slots in STORAGE.items():
+        for key, value in slots.items():
+            set_storage(state, address, key, value)
+    return state
+
+
+@pytest.fixture(name="genesis_state")
+def genesis_state_fixture() -> State:
+    """
+    Genesis state of the chain.
+    """
+    return genesis_state()
+`
+
+// WritePython writes the genesis as a Python module for the test harness of
+// the execution specs, see EncodePython.
+func WritePython(path string, genesis *core.Genesis, block *types.Block) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := EncodePython(f, genesis, block); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// EncodePython writes the genesis as a Python module: the allocation as typed
+// Account, Address, Bytes32 and U256 values of the execution specs fork
+// active at genesis, the chain id, the genesis header fields and the block
+// hash and state root of the generated block, and a pytest fixture building
+// the genesis state. A single genesis definition so backs both client devnets
+// and Python spec tests.
+func EncodePython(out io.Writer, genesis *core.Genesis, block *types.Block) error {
+	config := genesis.Config
+	if config == nil {
+		return fmt.Errorf("genesis without chain config")
+	}
+	difficulty := new(big.Int)
+	if genesis.Difficulty != nil {
+		difficulty = genesis.Difficulty
+	}
+	ttd := config.TerminalTotalDifficulty
+	fork := forks.At(config, new(big.Int).SetUint64(genesis.Number), genesis.Timestamp, ttd != nil && difficulty.Cmp(ttd) >= 0)
+	pkg, err := forks.Package(fork)
+	if err != nil {
+		return err
+	}
+	name := params.NetworkNames[config.ChainID.String()]
+	if name == "" {
+		name = fmt.Sprintf("chain %d", config.ChainID)
+	}
+	header := block.Header()
+
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, pythonHeader, fmt.Sprintf("Genesis of %s at the %s fork.", name, fork), pkg, fork)
+	fmt.Fprintf(w, "CHAIN_ID = U64(%d)\n", config.ChainID)
+	fmt.Fprintf(w, "GENESIS_HASH = Bytes32(bytes.fromhex(%q))\n", hex.EncodeToString(block.Hash().Bytes()))
+	fmt.Fprintf(w, "STATE_ROOT = Bytes32(bytes.fromhex(%q))\n", hex.EncodeToString(header.Root.Bytes()))
+	fmt.Fprintf(w, "NUMBER = Uint(%d)\n", header.Number)
+	fmt.Fprintf(w, "TIMESTAMP = U256(%d)\n", header.Time)
+	fmt.Fprintf(w, "GAS_LIMIT = Uint(%d)\n", header.GasLimit)
+	fmt.Fprintf(w, "DIFFICULTY = Uint(%d)\n", header.Difficulty)
+	fmt.Fprintf(w, "EXTRA_DATA = Bytes(bytes.fromhex(%q))\n", hex.EncodeToString(header.Extra))
+	fmt.Fprintf(w, "COINBASE = Address(bytes.fromhex(%q))\n", hex.EncodeToString(header.Coinbase.Bytes()))
+	if header.BaseFee != nil {
+		fmt.Fprintf(w, "BASE_FEE_PER_GAS = Uint(%d)\n", header.BaseFee)
+	}
+	if header.ExcessBlobGas != nil {
+		fmt.Fprintf(w, "EXCESS_BLOB_GAS = U64(%d)\n", *header.ExcessBlobGas)
+	}
+
+	addrs := alloc.SortedAddresses(genesis.Alloc)
+	w.WriteString("\nACCOUNTS: Dict[Address, Account] = {\n")
+	for _, addr := range addrs {
+		account := genesis.Alloc[addr]
+		fmt.Fprintf(w, "    %s: Account(\n", pythonAddress(addr))
+		fmt.Fprintf(w, "        nonce=Uint(%d),\n", account.Nonce)
+		fmt.Fprintf(w, "        balance=U256(%d),\n", alloc.Balance(account))
+		fmt.Fprintf(w, "        code=Bytes(bytes.fromhex(%q)),\n", hex.EncodeToString(account.Code))
+		w.WriteString("    ),\n")
+	}
+	w.WriteString("}\n")
+
+	w.WriteString("\nSTORAGE: Dict[Address, Dict[Bytes32, U256]] = {\n")
+	for _, addr := range addrs {
+		storage := genesis.Alloc[addr].Storage
+		if len(storage) == 0 {
+			continue
+		}
+		slots := make([]common.Hash, 0, len(storage))
+		for slot := range storage {
+			slots = append(slots, slot)
+		}
+		sort.Slice(slots, func(i, j int) bool { return slots[i].Cmp(slots[j]) < 0 })
+		fmt.Fprintf(w, "    %s: {\n", pythonAddress(addr))
+		for _, slot := range slots {
+			fmt.Fprintf(w, "        Bytes32(bytes.fromhex(%q)): U256(0x%x),\n", hex.EncodeToString(slot[:]), storage[slot].Big())
+		}
+		w.WriteString("    },\n")
+	}
+	w.WriteString("}\n")
+	w.WriteString(pythonFooter)
+	return w.Flush()
+}
+
+func pythonAddress(addr common.Address) string {
+	return fmt.Sprintf("Address(bytes.fromhex(%q))", hex.EncodeToString(addr[:]))
+}
@@ -0,0 +1,205 @@
+package genesis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/compress"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Stream is a genesis encoding whose allocation is written out one account at
+// a time, so that the encoding of a large allocation is never held in memory
+// as a whole. The head is the encoding with an empty object in place of the
+// allocation.
+type Stream struct {
+	head    interface{}
+	key     string // top-level key of the allocation in the head
+	alloc   types.GenesisAlloc
+	store   *alloc.Store // holds the allocation instead of alloc if set
+	account func(addr common.Address, account types.Account) (string, interface{})
+
+	checksum bool // write the account keys in EIP-55 mixed case
+}
+
+// gethAllocStream streams the allocation in the geth genesis encoding, with
+// the accounts keyed by their unprefixed address.
+func gethAllocStream(head interface{}, alloc types.GenesisAlloc) *Stream {
+	return &Stream{
+		head:  head,
+		key:   "alloc",
+		alloc: alloc,
+		account: func(addr common.Address, account types.Account) (string, interface{}) {
+			return hex.EncodeToString(addr[:]), account
+		},
+	}
+}
+
+// AllocStream streams the allocation in the geth genesis encoding under the
+// alloc key of the head, which must encode it as an empty object.
+func AllocStream(head interface{}, alloc types.GenesisAlloc) *Stream {
+	return gethAllocStream(head, alloc)
+}
+
+// StoreAllocStream streams the allocation held by a store in the geth genesis
+// encoding under the alloc key of the head, as AllocStream does. Streams of
+// stores cannot be written in chunks.
+func StoreAllocStream(head interface{}, store *alloc.Store) *Stream {
+	s := gethAllocStream(head, nil)
+	s.store = store
+	return s
+}
+
+// Checksum makes the stream write the address keys of the accounts with
+// their EIP-55 checksum instead of in lowercase. The encoding is then no
+// longer canonical, but decodes to the same genesis.
+func (s *Stream) Checksum() *Stream {
+	s.checksum = true
+	return s
+}
+
+// config returns the chain config of the head encoded in the given format,
+// the params of a Nethermind chainspec, for the extension of the encoding
+// with fields the encoder does not know. The head is converted into a generic
+// map first if needed.
+func (s *Stream) config(format string) (map[string]interface{}, error) {
+	head, ok := s.head.(map[string]interface{})
+	if !ok {
+		data, err := json.Marshal(s.head)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&head); err != nil {
+			return nil, err
+		}
+		s.head = head
+	}
+	key := "config"
+	if format == "nethermind" {
+		key = "params"
+	}
+	config, ok := head[key].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("encoding has no %s object", key)
+	}
+	return config, nil
+}
+
+// WriteFile writes the streamed genesis to the given path, gzip or zstd
+// compressed if it ends in .gz or .zst.
+func (s *Stream) WriteFile(path string) error {
+	f, err := compress.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := s.Encode(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Encode writes the canonical JSON encoding of the streamed genesis, the same
+// as encoding the head with the allocation in place. The accounts are written
+// in address order, the order of the canonical object keys.
+func (s *Stream) Encode(out io.Writer) error {
+	const indent = canonicalIndent
+
+	head, err := marshalCanonical(s.head, "")
+	if err != nil {
+		return err
+	}
+	placeholder := []byte(fmt.Sprintf("\n%s%q: {}", indent, s.key))
+	at := bytes.Index(head, placeholder)
+	if at < 0 {
+		return fmt.Errorf("encoding has no %s placeholder", s.key)
+	}
+	w := bufio.NewWriter(out)
+	w.Write(head[:at+len(placeholder)-1])
+	if s.store != nil {
+		err = s.encodeStore(w, indent)
+	} else {
+		err = s.encodeAccounts(w, alloc.SortedAddresses(s.alloc), indent)
+	}
+	if err != nil {
+		return err
+	}
+	w.Write(head[at+len(placeholder)-1:])
+	w.WriteByte('\n')
+	return w.Flush()
+}
+
+// encodeAccounts writes the members of the allocation object holding the
+// given accounts, each on a line prefixed by the indentation of the object
+// and one more level. The closing brace is left to the caller.
+func (s *Stream) encodeAccounts(w *bufio.Writer, addrs []common.Address, prefix string) error {
+	for i, addr := range addrs {
+		if err := s.encodeAccount(w, i > 0, addr, s.alloc[addr], prefix); err != nil {
+			return err
+		}
+	}
+	if len(addrs) > 0 {
+		w.WriteString("\n" + prefix)
+	}
+	return nil
+}
+
+// encodeStore writes the members of the allocation object holding the
+// accounts of the store, as encodeAccounts does.
+func (s *Stream) encodeStore(w *bufio.Writer, prefix string) error {
+	var n int
+	err := s.store.Range(func(addr common.Address, account types.Account) error {
+		n++
+		return s.encodeAccount(w, n > 1, addr, account, prefix)
+	})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		w.WriteString("\n" + prefix)
+	}
+	return nil
+}
+
+// encodeAccount writes a member of the allocation object, preceded by a comma
+// unless it is the first.
+func (s *Stream) encodeAccount(w *bufio.Writer, comma bool, addr common.Address, account types.Account, prefix string) error {
+	const indent = canonicalIndent
+
+	key, value := s.account(addr, account)
+	enc, err := marshalCanonical(value, prefix+indent)
+	if err != nil {
+		return fmt.Errorf("account %s: %v", addr.Hex(), err)
+	}
+	if comma {
+		w.WriteByte(',')
+	}
+	key = canonicalKey(key)
+	if s.checksum {
+		key = checksumKey(key, addr)
+	}
+	fmt.Fprintf(w, "\n%s%s%q: ", prefix, indent, key)
+	w.Write(enc)
+	return nil
+}
+
+// checksumKey returns the EIP-55 spelling of an account key holding the
+// address, with or without 0x prefix. Other keys are returned unchanged.
+func checksumKey(key string, addr common.Address) string {
+	switch lower := hex.EncodeToString(addr[:]); key {
+	case lower:
+		return addr.Hex()[2:]
+	case "0x" + lower:
+		return addr.Hex()
+	}
+	return key
+}
@@ -0,0 +1,218 @@
+package genesis
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Template is a declarative genesis description. Templates are YAML (and
+// therefore also JSON) documents in which ${NAME} and ${NAME:-default}
+// placeholders are expanded before decoding.
+type Template struct {
+	Network         string                     `yaml:"network"`
+	Fork            string                     `yaml:"fork"`
+	ChainID         string                     `yaml:"chainId"`
+	GenesisTime     string                     `yaml:"genesisTime"`
+	GasLimit        string                     `yaml:"gasLimit"`
+	ExtraData       string                     `yaml:"extraData"`
+	Nonce           string                     `yaml:"nonce"`
+	MixHash         string                     `yaml:"mixHash"`
+	Coinbase        string                     `yaml:"coinbase"`
+	BaseFeePerGas   string                     `yaml:"baseFeePerGas"`
+	ForkOffsets     map[string]string          `yaml:"forkOffsets"` // seconds after genesisTime, keyed by fork name
+	SystemContracts bool                       `yaml:"systemContracts"`
+	Predeploys      []string                   `yaml:"predeploys"` // names of the catalog predeploys
+	Accounts        map[string]TemplateAccount `yaml:"accounts"`
+}
+
+// TemplateAccount is an account of the template allocation.
+type TemplateAccount struct {
+	Balance string            `yaml:"balance"`
+	Nonce   string            `yaml:"nonce"`
+	Code    string            `yaml:"code"`
+	Storage map[string]string `yaml:"storage"`
+}
+
+// LoadTemplate reads a template file and expands its placeholders. Variables
+// are looked up in vars first, then in the environment, and finally fall back
+// to the inline default.
+func LoadTemplate(path string, vars map[string]string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := ParseTemplate(data, func(name string) (string, bool) {
+		if value, ok := vars[name]; ok {
+			return value, true
+		}
+		return os.LookupEnv(name)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return tmpl, nil
+}
+
+// ParseTemplate expands the placeholders of a template with the variables of
+// the lookup function, falling back to the inline defaults, and decodes it.
+func ParseTemplate(data []byte, lookup func(name string) (string, bool)) (*Template, error) {
+	var missing []string
+	expanded := os.Expand(string(data), func(ref string) string {
+		name, def, hasDefault := strings.Cut(ref, ":-")
+		if value, ok := lookup(name); ok {
+			return value
+		}
+		if !hasDefault {
+			missing = append(missing, name)
+		}
+		return def
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("unresolved template variables: %s", strings.Join(missing, ", "))
+	}
+	tmpl := new(Template)
+	if err := yaml.Unmarshal([]byte(expanded), tmpl); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// ParseVars converts NAME=VALUE assignments into a variable map.
+func ParseVars(assignments []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, assignment := range assignments {
+		name, value, ok := strings.Cut(assignment, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid variable %q, want NAME=VALUE", assignment)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// Apply writes the template settings into the genesis. The network and fork
+// selection is handled by the caller before the genesis is created, the
+// accounts are merged and the header fields overridden separately.
+func (tmpl *Template) Apply(genesis *core.Genesis) error {
+	if tmpl.ChainID != "" {
+		id, ok := math.ParseBig256(tmpl.ChainID)
+		if !ok {
+			return fmt.Errorf("invalid chainId %q", tmpl.ChainID)
+		}
+		genesis.Config.ChainID = id
+	}
+	if tmpl.GenesisTime != "" {
+		time, ok := math.ParseUint64(tmpl.GenesisTime)
+		if !ok {
+			return fmt.Errorf("invalid genesisTime %q", tmpl.GenesisTime)
+		}
+		genesis.Timestamp = time
+	}
+	for name, value := range tmpl.ForkOffsets {
+		offset, ok := math.ParseUint64(value)
+		if !ok {
+			return fmt.Errorf("invalid offset %q for fork %s", value, name)
+		}
+		field := forks.FindField(strings.ToLower(name) + "-time")
+		if field == nil {
+			return fmt.Errorf("fork %s is not scheduled by timestamp", name)
+		}
+		time := genesis.Timestamp + offset
+		*field.Time(genesis.Config) = &time
+	}
+	return nil
+}
+
+// Header returns the header fields overridden by the template, keyed as
+// HeaderFields, for ParseHeaderOverrides. The genesis time is applied by
+// Apply, as the fork offsets are relative to it.
+func (tmpl *Template) Header() map[string]string {
+	return map[string]string{
+		"extraData":     tmpl.ExtraData,
+		"nonce":         tmpl.Nonce,
+		"mixHash":       tmpl.MixHash,
+		"coinbase":      tmpl.Coinbase,
+		"gasLimit":      tmpl.GasLimit,
+		"baseFeePerGas": tmpl.BaseFeePerGas,
+	}
+}
+
+// Alloc returns the accounts defined by the template.
+func (tmpl *Template) Alloc() (types.GenesisAlloc, error) {
+	accounts := make(types.GenesisAlloc, len(tmpl.Accounts))
+	for addr, account := range tmpl.Accounts {
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid account address %q", addr)
+		}
+		parsed, err := alloc.ParseAccount(account.Balance, account.Nonce, account.Code, account.Storage)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %v", addr, err)
+		}
+		accounts[common.HexToAddress(addr)] = parsed
+	}
+	return accounts, nil
+}
+
+// FromTemplate assembles the genesis described by a template, the same way as
+// the genesis command does without further flags. Templates naming no network
+// describe mainnet.
+func FromTemplate(tmpl *Template) (*core.Genesis, error) {
+	network := tmpl.Network
+	if network == "" {
+		network = "mainnet"
+	}
+	genesis, err := New(network, tmpl.Fork)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Apply(genesis); err != nil {
+		return nil, err
+	}
+	if err := (forks.Overrides{}).Apply(genesis.Config); err != nil {
+		return nil, fmt.Errorf("invalid fork schedule: %v", err)
+	}
+	header, err := ParseHeaderOverrides(tmpl.Header())
+	if err != nil {
+		return nil, err
+	}
+	header.Apply(genesis)
+	if err := new(FeeMarket).Apply(genesis, header.BaseFee); err != nil {
+		return nil, err
+	}
+	if err := header.Check(genesis); err != nil {
+		return nil, err
+	}
+	if genesis.Config.IsCancun(new(big.Int).SetUint64(genesis.Number), genesis.Timestamp) {
+		if genesis.ExcessBlobGas == nil {
+			genesis.ExcessBlobGas = new(uint64)
+		}
+		if genesis.BlobGasUsed == nil {
+			genesis.BlobGasUsed = new(uint64)
+		}
+	}
+	origins := make(map[common.Address]string)
+	if tmpl.SystemContracts {
+		alloc.Merge(genesis.Alloc, SystemContractAlloc(genesis.Config), "system-contracts", origins)
+	}
+	predeploys, err := PredeployAlloc(tmpl.Predeploys)
+	if err != nil {
+		return nil, err
+	}
+	alloc.Merge(genesis.Alloc, predeploys, "predeploys", origins)
+	accounts, err := tmpl.Alloc()
+	if err != nil {
+		return nil, err
+	}
+	alloc.Merge(genesis.Alloc, accounts, "template", origins)
+	return genesis, nil
+}
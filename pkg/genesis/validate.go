@@ -0,0 +1,402 @@
+package genesis
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/execution-specs/pkg/alloc"
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Finding is a single result of validating a genesis file.
+type Finding struct {
+	Severity string          `json:"severity"` // "error" or "warning"
+	Check    string          `json:"check"`
+	Message  string          `json:"message"`
+	Address  *common.Address `json:"address,omitempty"`
+}
+
+// Findings accumulates the results of the validation checks.
+type Findings []Finding
+
+func (f *Findings) add(severity, check string, addr *common.Address, format string, args ...interface{}) {
+	*f = append(*f, Finding{Severity: severity, Check: check, Message: fmt.Sprintf(format, args...), Address: addr})
+}
+
+func (f *Findings) errorf(check string, format string, args ...interface{}) {
+	f.add("error", check, nil, format, args...)
+}
+
+func (f *Findings) warnf(check string, format string, args ...interface{}) {
+	f.add("warning", check, nil, format, args...)
+}
+
+// Errors returns the number of error findings.
+func (f Findings) Errors() int {
+	var n int
+	for _, Finding := range f {
+		if Finding.Severity == "error" {
+			n++
+		}
+	}
+	return n
+}
+
+// ValidateJSON decodes a genesis file and validates it. The raw alloc keys are
+// inspected as well since their checksum and repetitions are lost when
+// decoding.
+func ValidateJSON(data []byte) Findings {
+	var (
+		f       Findings
+		genesis = new(core.Genesis)
+		raw     map[string]json.RawMessage
+	)
+	if err := json.Unmarshal(data, genesis); err != nil {
+		f.errorf("decode", "invalid genesis: %v", err)
+		return f
+	}
+	if err := json.Unmarshal(data, &raw); err == nil {
+		if len(raw["alloc"]) > 0 {
+			if keys, err := alloc.JSONKeys(raw["alloc"]); err == nil {
+				checkAddressKeys(&f, keys)
+			}
+		}
+		if genesis.Config != nil {
+			checkHeaderKeys(&f, genesis, raw)
+		}
+	}
+	return append(f, Validate(genesis)...)
+}
+
+// derivedHeaderFields are the genesis header fields geth derives instead of
+// reading them from the genesis file, by their name in the geth format, with
+// the value the genesis block has and the fork introducing them, nil for the
+// fields of every block. A file may still carry them, they must then only
+// appear from their fork on and hold the derived value.
+var derivedHeaderFields = []struct {
+	name  string
+	fork  func(*params.ChainConfig, *big.Int, uint64) bool
+	value common.Hash
+}{
+	{"sha3Uncles", nil, types.EmptyUncleHash},
+	{"transactionsRoot", nil, types.EmptyTxsHash},
+	{"receiptsRoot", nil, types.EmptyReceiptsHash},
+	{"withdrawalsRoot", isShanghai, types.EmptyWithdrawalsHash},
+	{"parentBeaconBlockRoot", isCancun, common.Hash{}},
+	{"requestsHash", isPrague, types.EmptyRequestsHash},
+}
+
+func isShanghai(c *params.ChainConfig, num *big.Int, time uint64) bool {
+	return c.IsShanghai(num, time)
+}
+func isCancun(c *params.ChainConfig, num *big.Int, time uint64) bool { return c.IsCancun(num, time) }
+func isPrague(c *params.ChainConfig, num *big.Int, time uint64) bool { return c.IsPrague(num, time) }
+
+// checkHeaderKeys checks the derived header fields a genesis file carries
+// against the forks active at genesis and their derived values, and that its
+// logs bloom is empty, as the genesis block has no receipts.
+func checkHeaderKeys(f *Findings, genesis *core.Genesis, raw map[string]json.RawMessage) {
+	num := new(big.Int).SetUint64(genesis.Number)
+	for _, field := range derivedHeaderFields {
+		value, ok := raw[field.name]
+		if !ok || string(value) == "null" {
+			continue
+		}
+		if field.fork != nil && !field.fork(genesis.Config, num, genesis.Timestamp) {
+			f.errorf("header-fields", "%s set but its fork is not active at genesis", field.name)
+			continue
+		}
+		var hash common.Hash
+		if err := json.Unmarshal(value, &hash); err != nil {
+			f.errorf("header-fields", "invalid %s: %v", field.name, err)
+			continue
+		}
+		if hash != field.value {
+			f.errorf("header-fields", "%s %s differs from the %s of the genesis block", field.name, hash.Hex(), field.value.Hex())
+		}
+	}
+	if value, ok := raw["logsBloom"]; ok && string(value) != "null" {
+		var bloom types.Bloom
+		if err := json.Unmarshal(value, &bloom); err != nil {
+			f.errorf("header-fields", "invalid logsBloom: %v", err)
+		} else if bloom != (types.Bloom{}) {
+			f.errorf("header-fields", "nonzero logsBloom in a genesis without receipts")
+		}
+	}
+}
+
+// CheckHeader checks that the genesis block header carries exactly the fields
+// of the forks active at genesis, the base fee from London, the withdrawals
+// root from Shanghai, the blob gas fields and parent beacon root from Cancun
+// and the requests hash from Prague, and that the commitments to the empty
+// block body are the ones of an empty body.
+func CheckHeader(config *params.ChainConfig, header *types.Header) error {
+	var (
+		num  = header.Number
+		time = header.Time
+	)
+	presence := []struct {
+		name    string
+		active  bool
+		present bool
+	}{
+		{"baseFeePerGas", config.IsLondon(num), header.BaseFee != nil},
+		{"withdrawalsRoot", config.IsShanghai(num, time), header.WithdrawalsHash != nil},
+		{"blobGasUsed", config.IsCancun(num, time), header.BlobGasUsed != nil},
+		{"excessBlobGas", config.IsCancun(num, time), header.ExcessBlobGas != nil},
+		{"parentBeaconBlockRoot", config.IsCancun(num, time), header.ParentBeaconRoot != nil},
+		{"requestsHash", config.IsPrague(num, time), header.RequestsHash != nil},
+	}
+	for _, field := range presence {
+		switch {
+		case field.active && !field.present:
+			return fmt.Errorf("%s missing although its fork is active at genesis", field.name)
+		case !field.active && field.present:
+			return fmt.Errorf("%s set but its fork is not active at genesis", field.name)
+		}
+	}
+	switch {
+	case header.UncleHash != types.EmptyUncleHash:
+		return fmt.Errorf("sha3Uncles %s is not the empty uncle hash", header.UncleHash.Hex())
+	case header.TxHash != types.EmptyTxsHash:
+		return fmt.Errorf("transactionsRoot %s is not the empty root", header.TxHash.Hex())
+	case header.ReceiptHash != types.EmptyReceiptsHash:
+		return fmt.Errorf("receiptsRoot %s is not the empty root", header.ReceiptHash.Hex())
+	case header.Bloom != (types.Bloom{}):
+		return errors.New("nonzero logsBloom in a genesis without receipts")
+	case header.WithdrawalsHash != nil && *header.WithdrawalsHash != types.EmptyWithdrawalsHash:
+		return fmt.Errorf("withdrawalsRoot %s is not the empty root", header.WithdrawalsHash.Hex())
+	case header.ParentBeaconRoot != nil && *header.ParentBeaconRoot != (common.Hash{}):
+		return fmt.Errorf("nonzero parentBeaconBlockRoot %s", header.ParentBeaconRoot.Hex())
+	case header.RequestsHash != nil && *header.RequestsHash != types.EmptyRequestsHash:
+		return fmt.Errorf("requestsHash %s is not the hash of empty requests", header.RequestsHash.Hex())
+	}
+	return nil
+}
+
+// checkAddressKeys verifies that the alloc keys are well formed addresses with
+// a valid EIP-55 checksum if they use mixed case, and warns of addresses given
+// by more than one key.
+func checkAddressKeys(f *Findings, keys []string) {
+	var (
+		spellings = make(map[common.Address][]string)
+		order     []common.Address
+	)
+	for _, key := range keys {
+		addr, err := alloc.ParseAddress(key)
+		if err != nil {
+			f.errorf("address", "malformed alloc address %q", key)
+			continue
+		}
+		if !alloc.ValidChecksum(key) {
+			f.add("error", "address", &addr, "alloc address %q fails the EIP-55 checksum, expected %s", key, addr.Hex())
+		}
+		if _, ok := spellings[addr]; !ok {
+			order = append(order, addr)
+		}
+		spellings[addr] = append(spellings[addr], key)
+	}
+	for _, addr := range order {
+		if keys := spellings[addr]; len(keys) > 1 {
+			addr := addr
+			f.add("warning", "address", &addr, "alloc address %s is given %d times, the last account is used", addr.Hex(), len(keys))
+		}
+	}
+}
+
+// Validate checks a decoded genesis against the spec invariants.
+func Validate(genesis *core.Genesis) Findings {
+	var f Findings
+	config := genesis.Config
+	if config == nil {
+		f.errorf("config", "missing chain config")
+		return f
+	}
+	if config.ChainID == nil {
+		f.errorf("config", "missing chainId")
+	}
+	if err := forks.ValidateOrder(config); err != nil {
+		f.errorf("fork-order", "%v", err)
+	}
+	validateFeeMarket(&f, genesis)
+	validateMerge(&f, genesis)
+	validateClique(&f, genesis)
+	validateBlobSchedule(&f, config)
+	validateBlobFields(&f, genesis)
+	validateAllocCode(&f, genesis)
+	validateSystemContracts(&f, genesis)
+	return f
+}
+
+// validateFeeMarket checks the gas limit and the EIP-1559 base fee.
+func validateFeeMarket(f *Findings, genesis *core.Genesis) {
+	if genesis.GasLimit < params.MinGasLimit {
+		f.errorf("gas-limit", "gas limit %d below minimum %d", genesis.GasLimit, params.MinGasLimit)
+	}
+	if genesis.GasLimit > params.MaxGasLimit {
+		f.errorf("gas-limit", "gas limit %d above maximum %d", genesis.GasLimit, params.MaxGasLimit)
+	}
+	london := genesis.Config.LondonBlock
+	switch {
+	case london != nil && london.Sign() == 0 && genesis.BaseFee == nil:
+		f.warnf("eip-1559", "london active at genesis without baseFeePerGas, clients default to %d", params.InitialBaseFee)
+	case (london == nil || london.Sign() > 0) && genesis.BaseFee != nil:
+		f.errorf("eip-1559", "baseFeePerGas set but london is not active at genesis")
+	case genesis.BaseFee != nil && genesis.BaseFee.Sign() < 0:
+		f.errorf("eip-1559", "negative baseFeePerGas")
+	}
+}
+
+// validateMerge checks the header fields of the genesis against its position
+// relative to the merge.
+func validateMerge(f *Findings, genesis *core.Genesis) {
+	ttd := genesis.Config.TerminalTotalDifficulty
+	switch {
+	case ttd == nil:
+	case ttd.Sign() == 0:
+		if genesis.Difficulty != nil && genesis.Difficulty.Sign() != 0 {
+			f.errorf("merge", "post-merge genesis with nonzero difficulty %v", genesis.Difficulty)
+		}
+		if genesis.Nonce != 0 {
+			f.warnf("merge", "post-merge genesis with nonzero nonce %#x", genesis.Nonce)
+		}
+	case genesis.Difficulty == nil || genesis.Difficulty.Cmp(ttd) < 0:
+		if err := forks.CheckMergeTransition(genesis); err != nil {
+			f.errorf("merge", "%v", err)
+		}
+	}
+}
+
+// validateClique checks the engine config and the extraData layout of a
+// Clique genesis.
+func validateClique(f *Findings, genesis *core.Genesis) {
+	clique := genesis.Config.Clique
+	if clique == nil {
+		return
+	}
+	if clique.Epoch == 0 {
+		f.errorf("clique", "clique epoch must be positive")
+	}
+	if ttd := genesis.Config.TerminalTotalDifficulty; ttd != nil && ttd.Sign() == 0 {
+		f.errorf("clique", "clique engine configured but the merge is active at genesis")
+	}
+	signers, err := CliqueSigners(genesis.ExtraData)
+	if err != nil {
+		f.errorf("clique", "%v", err)
+		return
+	}
+	seen := make(map[common.Address]bool)
+	for _, signer := range signers {
+		if seen[signer] {
+			signer := signer
+			f.add("error", "clique", &signer, "duplicate signer %s in extraData", signer.Hex())
+		}
+		seen[signer] = true
+	}
+}
+
+// validateBlobSchedule checks that every fork changing the blob parameters has
+// a consistent entry in the blob schedule.
+func validateBlobSchedule(f *Findings, config *params.ChainConfig) {
+	schedule := config.BlobScheduleConfig
+	if schedule == nil {
+		schedule = new(params.BlobScheduleConfig)
+	}
+	for _, field := range forks.Fields {
+		if field.Blob == nil {
+			continue
+		}
+		name := strings.TrimSuffix(field.Flag, "-time")
+		entry := *field.Blob(schedule)
+		scheduled := *field.Time(config) != nil
+		switch {
+		case scheduled && entry == nil:
+			f.errorf("blob-schedule", "%s is scheduled but has no blobSchedule entry", name)
+		case !scheduled && entry != nil:
+			f.warnf("blob-schedule", "blobSchedule entry for unscheduled fork %s", name)
+		}
+		if entry == nil {
+			continue
+		}
+		if entry.Target <= 0 || entry.Max <= 0 {
+			f.errorf("blob-schedule", "%s blob target %d and max %d must be positive", name, entry.Target, entry.Max)
+		}
+		if entry.Max < entry.Target {
+			f.errorf("blob-schedule", "%s blob max %d below target %d", name, entry.Max, entry.Target)
+		}
+		if entry.UpdateFraction == 0 {
+			f.errorf("blob-schedule", "%s baseFeeUpdateFraction must be positive", name)
+		}
+	}
+}
+
+// validateBlobFields checks the EIP-4844 header fields against the blob
+// parameters in effect at genesis.
+func validateBlobFields(f *Findings, genesis *core.Genesis) {
+	if !genesis.Config.IsCancun(new(big.Int).SetUint64(genesis.Number), genesis.Timestamp) {
+		if genesis.ExcessBlobGas != nil || genesis.BlobGasUsed != nil {
+			f.errorf("eip-4844", "excessBlobGas or blobGasUsed set but cancun is not active at genesis")
+		}
+		return
+	}
+	if genesis.ExcessBlobGas == nil || genesis.BlobGasUsed == nil {
+		f.warnf("eip-4844", "cancun active at genesis without excessBlobGas and blobGasUsed, clients default to zero")
+	}
+	if genesis.BlobGasUsed != nil {
+		used := *genesis.BlobGasUsed
+		if used%params.BlobTxBlobGasPerBlob != 0 {
+			f.errorf("eip-4844", "blobGasUsed %d is not a multiple of the blob gas per blob %d", used, params.BlobTxBlobGasPerBlob)
+		}
+		if limit := eip4844.MaxBlobGasPerBlock(genesis.Config, genesis.Timestamp); used > limit {
+			f.errorf("eip-4844", "blobGasUsed %d exceeds the maximum of %d", used, limit)
+		}
+	}
+}
+
+// validateAllocCode checks the allocated code against the EIP-170 size limit.
+// The limit is only binding if Spurious Dragon is active at genesis.
+func validateAllocCode(f *Findings, genesis *core.Genesis) {
+	spuriousDragon := genesis.Config.EIP158Block != nil && genesis.Config.EIP158Block.Sign() == 0
+	for _, addr := range alloc.SortedAddresses(genesis.Alloc) {
+		size := len(genesis.Alloc[addr].Code)
+		if size <= params.MaxCodeSize {
+			continue
+		}
+		severity := "warning"
+		if spuriousDragon {
+			severity = "error"
+		}
+		f.add(severity, "code-size", &addr, "code size %d exceeds the EIP-170 limit of %d bytes", size, params.MaxCodeSize)
+	}
+}
+
+// validateSystemContracts checks that the system contracts required by the
+// scheduled forks are deployed. Contracts of forks activating after genesis
+// may still be deployed by a transaction, their absence only warrants a
+// warning.
+func validateSystemContracts(f *Findings, genesis *core.Genesis) {
+	for _, contract := range SystemContracts {
+		if !forks.Scheduled(genesis.Config, contract.Field) {
+			continue
+		}
+		addr := contract.Address
+		account, ok := genesis.Alloc[addr]
+		switch {
+		case (!ok || len(account.Code) == 0) && forks.ActiveAtGenesis(genesis, contract.Field):
+			f.add("error", "system-contracts", &addr, "missing %s contract required by %s", contract.Name, contract.Field)
+		case !ok || len(account.Code) == 0:
+			f.add("warning", "system-contracts", &addr, "missing %s contract, it must be deployed before %s", contract.Name, contract.Field)
+		case !bytes.Equal(account.Code, contract.Code):
+			f.add("warning", "system-contracts", &addr, "%s contract has non-canonical code", contract.Name)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package header computes the block header fields committing to the block
+// contents: the logs bloom, the transactions root, the receipts root and the
+// withdrawals root, from the raw transactions, receipts and withdrawals.
+//
+// The inputs are checked against the encoding rules of the fork: typed
+// transactions and receipts exist from the fork introducing their type on
+// (EIP-2718), receipts carry the intermediate state root before Byzantium and
+// the status code from it on (EIP-658), and withdrawals exist from Shanghai
+// on (EIP-4895).
+package header
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// typeForks maps the typed transaction and receipt types onto the fork
+// introducing them.
+var typeForks = map[uint8]string{
+	types.AccessListTxType: "Berlin",
+	types.DynamicFeeTxType: "London",
+	types.BlobTxType:       "Cancun",
+	types.SetCodeTxType:    "Prague",
+}
+
+// Inputs are the block contents the header fields are computed from.
+// Transactions are given in their canonical encoding, the RLP list of legacy
+// transactions and the type byte followed by the payload of typed ones.
+// Withdrawals are omitted before Shanghai.
+type Inputs struct {
+	Transactions []hexutil.Bytes     `json:"transactions"`
+	Receipts     []*Receipt          `json:"receipts"`
+	Withdrawals  []*types.Withdrawal `json:"withdrawals,omitempty"`
+}
+
+// Receipt holds the consensus fields of a receipt. Either the intermediate
+// state root or the status is set, depending on the fork.
+type Receipt struct {
+	Type              hexutil.Uint64  `json:"type"`
+	PostState         hexutil.Bytes   `json:"root,omitempty"`
+	Status            *hexutil.Uint64 `json:"status,omitempty"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+	Logs              []*Log          `json:"logs"`
+}
+
+// Log holds the consensus fields of a log.
+type Log struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// Fields are the computed header fields. The withdrawals root is omitted
+// before Shanghai.
+type Fields struct {
+	LogsBloom        types.Bloom  `json:"logsBloom"`
+	TransactionsRoot common.Hash  `json:"transactionsRoot"`
+	ReceiptsRoot     common.Hash  `json:"receiptsRoot"`
+	WithdrawalsRoot  *common.Hash `json:"withdrawalsRoot,omitempty"`
+}
+
+// Compute checks the inputs against the rules of the fork and returns the
+// header fields committing to them.
+func Compute(fork string, in *Inputs) (*Fields, error) {
+	index, err := forks.Index(fork)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]*types.Transaction, len(in.Transactions))
+	for i, enc := range in.Transactions {
+		txs[i] = new(types.Transaction)
+		if err := txs[i].UnmarshalBinary(enc); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		if err := checkType(index, txs[i].Type()); err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+	}
+	receipts := make([]*types.Receipt, len(in.Receipts))
+	for i, r := range in.Receipts {
+		if receipts[i], err = r.convert(index); err != nil {
+			return nil, fmt.Errorf("receipt %d: %v", i, err)
+		}
+	}
+	fields := &Fields{
+		LogsBloom:        LogsBloom(receipts),
+		TransactionsRoot: TransactionsRoot(txs),
+		ReceiptsRoot:     ReceiptsRoot(receipts),
+	}
+	shanghai, _ := forks.Index("Shanghai")
+	switch {
+	case index >= shanghai:
+		root := WithdrawalsRoot(in.Withdrawals)
+		fields.WithdrawalsRoot = &root
+	case in.Withdrawals != nil:
+		return nil, fmt.Errorf("withdrawals before Shanghai")
+	}
+	return fields, nil
+}
+
+// checkType checks that a transaction or receipt type exists at the fork with
+// the given index.
+func checkType(fork int, typ uint8) error {
+	if typ == types.LegacyTxType {
+		return nil
+	}
+	name, ok := typeForks[typ]
+	if !ok {
+		return fmt.Errorf("unknown type %d", typ)
+	}
+	if index, _ := forks.Index(name); fork < index {
+		return fmt.Errorf("type %d before %s", typ, name)
+	}
+	return nil
+}
+
+// convert checks the receipt against the rules of the fork with the given
+// index and returns it as a go-ethereum receipt, with its bloom filled in.
+func (r *Receipt) convert(fork int) (*types.Receipt, error) {
+	if r.Type > 0xff {
+		return nil, fmt.Errorf("unknown type %d", r.Type)
+	}
+	if err := checkType(fork, uint8(r.Type)); err != nil {
+		return nil, err
+	}
+	receipt := &types.Receipt{
+		Type:              uint8(r.Type),
+		CumulativeGasUsed: uint64(r.CumulativeGasUsed),
+	}
+	byzantium, _ := forks.Index("Byzantium")
+	switch {
+	case fork < byzantium && (r.Status != nil || len(r.PostState) != common.HashLength):
+		return nil, errors.New("receipts carry a 32 byte state root before Byzantium")
+	case fork < byzantium:
+		receipt.PostState = r.PostState
+	case r.PostState != nil || r.Status == nil:
+		return nil, errors.New("receipts carry a status since Byzantium")
+	case *r.Status > 1:
+		return nil, fmt.Errorf("invalid status %d", *r.Status)
+	default:
+		receipt.Status = uint64(*r.Status)
+	}
+	for _, log := range r.Logs {
+		receipt.Logs = append(receipt.Logs, &types.Log{Address: log.Address, Topics: log.Topics, Data: log.Data})
+	}
+	receipt.Bloom = types.CreateBloom(receipt)
+	return receipt, nil
+}
+
+// LogsBloom returns the logs bloom of a block, the union of the blooms of its
+// receipts, which must be filled in.
+func LogsBloom(receipts []*types.Receipt) types.Bloom {
+	return types.MergeBloom(receipts)
+}
+
+// TransactionsRoot returns the root of the trie mapping the RLP encoded index
+// of every transaction onto its canonical encoding.
+func TransactionsRoot(txs []*types.Transaction) common.Hash {
+	return types.DeriveSha(types.Transactions(txs), trie.NewStackTrie(nil))
+}
+
+// ReceiptsRoot returns the root of the trie mapping the RLP encoded index of
+// every receipt onto its consensus encoding, prefixed with the type byte for
+// typed receipts.
+func ReceiptsRoot(receipts []*types.Receipt) common.Hash {
+	return types.DeriveSha(types.Receipts(receipts), trie.NewStackTrie(nil))
+}
+
+// WithdrawalsRoot returns the root of the trie mapping the RLP encoded index
+// of every withdrawal onto its RLP encoding.
+func WithdrawalsRoot(withdrawals []*types.Withdrawal) common.Hash {
+	return types.DeriveSha(types.Withdrawals(withdrawals), trie.NewStackTrie(nil))
+}
@@ -0,0 +1,153 @@
+package header
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// vectorKey signs the transactions of the generated vectors.
+var vectorKey, _ = crypto.HexToECDSA("45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8")
+
+// Vector is a conformance vector: the contents of a block and the header
+// fields committing to them under the rules of the fork.
+type Vector struct {
+	Fork     string  `json:"fork"`
+	Inputs   *Inputs `json:"inputs"`
+	Expected *Fields `json:"expected"`
+}
+
+// Vectors generates the conformance vectors of a fork: an empty block, a block
+// with a single item of each kind, one with 130 items, crossing the index 127
+// after which the RLP encoding of the trie keys grows beyond a single byte,
+// and one whose receipts have logs with zero to four topics. The transactions
+// and receipts cycle through every type of the fork.
+func Vectors(fork string) (map[string]*Vector, error) {
+	index, err := forks.Index(fork)
+	if err != nil {
+		return nil, err
+	}
+	fork = forks.Names()[index]
+	txTypes := []uint8{types.LegacyTxType}
+	for _, typ := range []uint8{types.AccessListTxType, types.DynamicFeeTxType, types.BlobTxType, types.SetCodeTxType} {
+		if checkType(index, typ) == nil {
+			txTypes = append(txTypes, typ)
+		}
+	}
+	cases := map[string]struct{ items, logs int }{
+		"empty":  {0, 0},
+		"single": {1, 0},
+		"many":   {130, 0},
+		"logs":   {5, 5},
+	}
+	vectors := make(map[string]*Vector, len(cases))
+	for name, c := range cases {
+		in := &Inputs{Transactions: []hexutil.Bytes{}, Receipts: []*Receipt{}}
+		for i := 0; i < c.items; i++ {
+			typ := txTypes[i%len(txTypes)]
+			enc, err := vectorTransaction(typ, uint64(i))
+			if err != nil {
+				return nil, err
+			}
+			in.Transactions = append(in.Transactions, enc)
+			in.Receipts = append(in.Receipts, vectorReceipt(index, typ, i, c.logs))
+		}
+		if shanghai, _ := forks.Index("Shanghai"); index >= shanghai {
+			in.Withdrawals = []*types.Withdrawal{}
+			for i := 0; i < c.items; i++ {
+				in.Withdrawals = append(in.Withdrawals, &types.Withdrawal{
+					Index:     uint64(i),
+					Validator: uint64(7 * i),
+					Address:   common.BigToAddress(big.NewInt(int64(0x1000 + i))),
+					Amount:    uint64(i) * 1000000000,
+				})
+			}
+		}
+		fields, err := Compute(fork, in)
+		if err != nil {
+			return nil, fmt.Errorf("%s vector %s: %v", fork, name, err)
+		}
+		vectors[name] = &Vector{Fork: fork, Inputs: in, Expected: fields}
+	}
+	return vectors, nil
+}
+
+// vectorTransaction returns the canonical encoding of a signed transaction of
+// the given type.
+func vectorTransaction(typ uint8, nonce uint64) (hexutil.Bytes, error) {
+	var (
+		to        = common.BigToAddress(big.NewInt(int64(0x2000 + nonce)))
+		value     = big.NewInt(int64(nonce))
+		chainID   = big.NewInt(1)
+		accesses  = types.AccessList{{Address: to, StorageKeys: []common.Hash{common.BigToHash(value)}}}
+		blobHash  = common.Hash{0x01}
+		inner     types.TxData
+		signer    = types.LatestSignerForChainID(chainID)
+		gas       = uint64(21000)
+		gasPrice  = big.NewInt(10)
+		gasTipCap = big.NewInt(1)
+	)
+	switch typ {
+	case types.LegacyTxType:
+		inner = &types.LegacyTx{Nonce: nonce, GasPrice: gasPrice, Gas: gas, To: &to, Value: value}
+	case types.AccessListTxType:
+		inner = &types.AccessListTx{ChainID: chainID, Nonce: nonce, GasPrice: gasPrice, Gas: gas, To: &to, Value: value, AccessList: accesses}
+	case types.DynamicFeeTxType:
+		inner = &types.DynamicFeeTx{ChainID: chainID, Nonce: nonce, GasTipCap: gasTipCap, GasFeeCap: gasPrice, Gas: gas, To: &to, Value: value, AccessList: accesses}
+	case types.BlobTxType:
+		inner = &types.BlobTx{
+			ChainID: uint256.MustFromBig(chainID), Nonce: nonce, GasTipCap: uint256.MustFromBig(gasTipCap),
+			GasFeeCap: uint256.MustFromBig(gasPrice), Gas: gas, To: to, Value: uint256.MustFromBig(value),
+			AccessList: accesses, BlobFeeCap: uint256.NewInt(1), BlobHashes: []common.Hash{blobHash},
+		}
+	case types.SetCodeTxType:
+		auth, err := types.SignSetCode(vectorKey, types.SetCodeAuthorization{ChainID: *uint256.MustFromBig(chainID), Address: to, Nonce: nonce + 1})
+		if err != nil {
+			return nil, err
+		}
+		inner = &types.SetCodeTx{
+			ChainID: uint256.MustFromBig(chainID), Nonce: nonce, GasTipCap: uint256.MustFromBig(gasTipCap),
+			GasFeeCap: uint256.MustFromBig(gasPrice), Gas: gas, To: to, Value: uint256.MustFromBig(value),
+			AccessList: accesses, AuthList: []types.SetCodeAuthorization{auth},
+		}
+	}
+	tx, err := types.SignNewTx(vectorKey, signer, inner)
+	if err != nil {
+		return nil, err
+	}
+	return tx.MarshalBinary()
+}
+
+// vectorReceipt returns the i-th receipt of a vector of the fork with the
+// given index, with up to logs logs of an increasing number of topics.
+func vectorReceipt(fork int, typ uint8, i, logs int) *Receipt {
+	r := &Receipt{
+		Type:              hexutil.Uint64(typ),
+		CumulativeGasUsed: hexutil.Uint64(21000 * (i + 1)),
+		Logs:              []*Log{},
+	}
+	if byzantium, _ := forks.Index("Byzantium"); fork < byzantium {
+		r.PostState = crypto.Keccak256(big.NewInt(int64(i)).Bytes())
+	} else {
+		status := hexutil.Uint64(i % 2)
+		r.Status = &status
+	}
+	for j := 0; j < logs; j++ {
+		log := &Log{
+			Address: common.BigToAddress(big.NewInt(int64(0x3000 + i))),
+			Topics:  []common.Hash{},
+			Data:    make([]byte, j*16),
+		}
+		for k := 0; k < j%5; k++ {
+			log.Topics = append(log.Topics, common.BigToHash(big.NewInt(int64(i<<8|j<<4|k))))
+		}
+		r.Logs = append(r.Logs, log)
+	}
+	return r
+}
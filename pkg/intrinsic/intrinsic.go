@@ -0,0 +1,154 @@
+// Package intrinsic computes the intrinsic gas of transactions for each fork
+// as the execution specs define it: the base cost, the calldata cost with the
+// nonzero byte repricing of EIP-2028, the access list costs of EIP-2930, the
+// initcode word cost of EIP-3860, the authorization cost of EIP-7702 and the
+// calldata floor of EIP-7623.
+//
+// The costs are implemented from the specs rather than taken from
+// go-ethereum, so that they can be checked against it.
+package intrinsic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/execution-specs/pkg/forks"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// The intrinsic gas constants of the specs.
+const (
+	TxGas                  = 21000   // base cost of a transaction
+	TxCreateGas            = 53000   // base cost of a contract creation since Homestead (EIP-2)
+	TxDataZeroGas          = 4       // cost per zero byte of calldata
+	TxDataNonZeroFrontier  = 68      // cost per nonzero byte of calldata before Istanbul
+	TxDataNonZeroIstanbul  = 16      // cost per nonzero byte of calldata since Istanbul (EIP-2028)
+	AccessListAddressGas   = 2400    // cost per access list address (EIP-2930)
+	AccessListStorageGas   = 1900    // cost per access list storage key (EIP-2930)
+	InitcodeWordGas        = 2       // cost per 32 byte word of initcode (EIP-3860)
+	AuthorizationGas       = 25000   // cost per authorization, as for an empty account (EIP-7702)
+	TokensPerNonZeroByte   = 4       // calldata tokens of a nonzero byte (EIP-7623)
+	FloorGasPerToken       = 10      // floor cost per calldata token (EIP-7623)
+	MaxInitcodeSize        = 49152   // maximum initcode size of a creation (EIP-3860)
+	MaxTransactionGasLimit = 1 << 24 // maximum gas limit of a transaction since Osaka (EIP-7825)
+)
+
+// Errors of transactions whose gas limit or contents are invalid on a fork.
+var (
+	ErrIntrinsicGas  = errors.New("gas limit below the intrinsic gas")
+	ErrFloorGas      = errors.New("gas limit below the calldata floor")
+	ErrInitcodeSize  = errors.New("initcode exceeds the maximum size")
+	ErrGasLimitCap   = errors.New("gas limit exceeds the transaction maximum")
+	ErrAccessList    = errors.New("access lists are not supported before Berlin")
+	ErrAuthorization = errors.New("authorizations are not supported before Prague")
+	ErrCreateSetCode = errors.New("set code transactions cannot create contracts")
+)
+
+// Tx holds what the intrinsic gas of a transaction depends on.
+type Tx struct {
+	Data                []byte
+	Create              bool
+	AccessListAddresses int
+	AccessListKeys      int
+	Authorizations      int
+}
+
+// FromTransaction returns the intrinsic gas inputs of a transaction.
+func FromTransaction(tx *types.Transaction) *Tx {
+	t := &Tx{
+		Data:           tx.Data(),
+		Create:         tx.To() == nil,
+		Authorizations: len(tx.SetCodeAuthorizations()),
+	}
+	for _, tuple := range tx.AccessList() {
+		t.AccessListAddresses++
+		t.AccessListKeys += len(tuple.StorageKeys)
+	}
+	return t
+}
+
+// Gas is the intrinsic gas of a transaction on a fork, itemized. Required is
+// the lowest valid gas limit: the intrinsic gas, or since Prague the
+// calldata floor if it is higher.
+type Gas struct {
+	Base           uint64 `json:"base"`
+	Calldata       uint64 `json:"calldata"`
+	Initcode       uint64 `json:"initcode"`
+	AccessList     uint64 `json:"accessList"`
+	Authorizations uint64 `json:"authorizations"`
+	Intrinsic      uint64 `json:"intrinsic"`
+	Tokens         uint64 `json:"tokens,omitempty"`
+	Floor          uint64 `json:"floor,omitempty"`
+	Required       uint64 `json:"required"`
+}
+
+// Compute returns the intrinsic gas of the transaction on the named fork. It
+// fails if the transaction uses a feature the fork does not have.
+func Compute(fork string, tx *Tx) (*Gas, error) {
+	if _, err := forks.Index(fork); err != nil {
+		return nil, err
+	}
+	switch {
+	case (tx.AccessListAddresses > 0 || tx.AccessListKeys > 0) && !forks.Since(fork, "Berlin"):
+		return nil, ErrAccessList
+	case tx.Authorizations > 0 && !forks.Since(fork, "Prague"):
+		return nil, ErrAuthorization
+	case tx.Authorizations > 0 && tx.Create:
+		return nil, ErrCreateSetCode
+	}
+	var (
+		zeros    = uint64(bytes.Count(tx.Data, []byte{0}))
+		nonZeros = uint64(len(tx.Data)) - zeros
+		gas      = &Gas{Base: TxGas}
+	)
+	if tx.Create && forks.Since(fork, "Homestead") {
+		gas.Base = TxCreateGas
+	}
+	nonZeroGas := uint64(TxDataNonZeroFrontier)
+	if forks.Since(fork, "Istanbul") {
+		nonZeroGas = TxDataNonZeroIstanbul
+	}
+	gas.Calldata = zeros*TxDataZeroGas + nonZeros*nonZeroGas
+	if tx.Create && forks.Since(fork, "Shanghai") {
+		gas.Initcode = (uint64(len(tx.Data)) + 31) / 32 * InitcodeWordGas
+	}
+	gas.AccessList = uint64(tx.AccessListAddresses)*AccessListAddressGas + uint64(tx.AccessListKeys)*AccessListStorageGas
+	gas.Authorizations = uint64(tx.Authorizations) * AuthorizationGas
+	gas.Intrinsic = gas.Base + gas.Calldata + gas.Initcode + gas.AccessList + gas.Authorizations
+
+	gas.Required = gas.Intrinsic
+	if forks.Since(fork, "Prague") {
+		gas.Tokens = zeros + nonZeros*TokensPerNonZeroByte
+		gas.Floor = TxGas + gas.Tokens*FloorGasPerToken
+		if gas.Floor > gas.Required {
+			gas.Required = gas.Floor
+		}
+	}
+	return gas, nil
+}
+
+// Check validates the gas limit and the initcode size of the transaction on
+// the named fork. Of several failures the first in the order of the specs is
+// reported: the intrinsic gas, the calldata floor, the initcode size and the
+// gas limit cap.
+func Check(fork string, tx *Tx, gasLimit uint64) error {
+	gas, err := Compute(fork, tx)
+	if err != nil {
+		return err
+	}
+	index, _ := forks.Index(fork)
+	shanghai, _ := forks.Index("Shanghai")
+	osaka, _ := forks.Index("Osaka")
+	switch {
+	case gasLimit < gas.Intrinsic:
+		return fmt.Errorf("%w: %d < %d", ErrIntrinsicGas, gasLimit, gas.Intrinsic)
+	case gasLimit < gas.Floor:
+		return fmt.Errorf("%w: %d < %d", ErrFloorGas, gasLimit, gas.Floor)
+	case tx.Create && index >= shanghai && len(tx.Data) > MaxInitcodeSize:
+		return fmt.Errorf("%w: %d > %d", ErrInitcodeSize, len(tx.Data), MaxInitcodeSize)
+	case index >= osaka && gasLimit > MaxTransactionGasLimit:
+		return fmt.Errorf("%w: %d > %d", ErrGasLimitCap, gasLimit, uint64(MaxTransactionGasLimit))
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+// Package kzg embeds the KZG trusted setup of the EIP-4844 ceremony and
+// verifies setups: that their points decode and lie in the prime order
+// subgroups, that they are successive powers of the same secret in G1 and
+// G2, that the Lagrange basis matches the monomial one, and that their digest
+// is the one of the ceremony.
+//
+// go-ethereum's kzg4844 package, which the blob tooling uses, embeds its own
+// copy of the setup. CheckLibrary confirms that it commits to blobs with the
+// ceremony setup, so that no generator runs against a tampered one.
+package kzg
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// The sizes of the EIP-4844 setup: the powers of the secret in G1 up to the
+// blob degree and in G2 up to the 64 field elements of an Osaka cell.
+const (
+	G1Points = params.BlobTxFieldElementsPerBlob
+	G2Points = 65
+)
+
+// CeremonyDigest is the SHA-256 digest of the points of the ceremony setup,
+// as computed by Setup.Digest. The copies of the setup in go-ethereum, c-kzg
+// and go-eth-kzg all have this digest.
+const CeremonyDigest = "753bd011b238fb9b63a35b9b526f7830057ced42b9a9c87368a67105bd8f0566"
+
+// primitiveRoot generates the roots of unity of the blob evaluation domain.
+const primitiveRoot = 7
+
+//go:embed trusted_setup.json
+var setupJSON []byte
+
+// SetupJSON returns the embedded ceremony setup in the JSON format of the
+// consensus specs and go-ethereum.
+func SetupJSON() []byte {
+	return append([]byte(nil), setupJSON...)
+}
+
+// Setup is a KZG trusted setup: the powers of the secret in G1 and G2 and the
+// Lagrange basis over the roots of unity of the blob domain, in their natural
+// order, in G1.
+type Setup struct {
+	G1Monomial []bls.G1Affine
+	G1Lagrange []bls.G1Affine
+	G2Monomial []bls.G2Affine
+}
+
+// setupFile is the JSON encoding of a setup, the points compressed.
+type setupFile struct {
+	G1Monomial []hexutil.Bytes `json:"g1_monomial"`
+	G1Lagrange []hexutil.Bytes `json:"g1_lagrange"`
+	G2Monomial []hexutil.Bytes `json:"g2_monomial"`
+}
+
+var (
+	embedded     *Setup
+	embeddedErr  error
+	embeddedOnce sync.Once
+
+	libraryErr  error
+	libraryOnce sync.Once
+)
+
+// Embedded returns the decoded ceremony setup.
+func Embedded() (*Setup, error) {
+	embeddedOnce.Do(func() {
+		embedded, embeddedErr = ParseSetup(setupJSON)
+	})
+	return embedded, embeddedErr
+}
+
+// ParseSetup decodes a setup file, checking the number of points and that
+// every point is in the prime order subgroup.
+func ParseSetup(data []byte) (*Setup, error) {
+	var file setupFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	switch {
+	case len(file.G1Monomial) != G1Points:
+		return nil, fmt.Errorf("%d g1_monomial points, expected %d", len(file.G1Monomial), G1Points)
+	case len(file.G1Lagrange) != G1Points:
+		return nil, fmt.Errorf("%d g1_lagrange points, expected %d", len(file.G1Lagrange), G1Points)
+	case len(file.G2Monomial) != G2Points:
+		return nil, fmt.Errorf("%d g2_monomial points, expected %d", len(file.G2Monomial), G2Points)
+	}
+	setup := &Setup{
+		G1Monomial: make([]bls.G1Affine, G1Points),
+		G1Lagrange: make([]bls.G1Affine, G1Points),
+		G2Monomial: make([]bls.G2Affine, G2Points),
+	}
+	for i, enc := range file.G1Monomial {
+		if _, err := setup.G1Monomial[i].SetBytes(enc); err != nil {
+			return nil, fmt.Errorf("g1_monomial %d: %v", i, err)
+		}
+	}
+	for i, enc := range file.G1Lagrange {
+		if _, err := setup.G1Lagrange[i].SetBytes(enc); err != nil {
+			return nil, fmt.Errorf("g1_lagrange %d: %v", i, err)
+		}
+	}
+	for i, enc := range file.G2Monomial {
+		if _, err := setup.G2Monomial[i].SetBytes(enc); err != nil {
+			return nil, fmt.Errorf("g2_monomial %d: %v", i, err)
+		}
+	}
+	return setup, nil
+}
+
+// Digest returns the SHA-256 digest of the compressed points of the setup,
+// in the order g1_monomial, g1_lagrange, g2_monomial. Unlike a digest of
+// the file it does not depend on the JSON formatting.
+func (s *Setup) Digest() string {
+	h := sha256.New()
+	for i := range s.G1Monomial {
+		enc := s.G1Monomial[i].Bytes()
+		h.Write(enc[:])
+	}
+	for i := range s.G1Lagrange {
+		enc := s.G1Lagrange[i].Bytes()
+		h.Write(enc[:])
+	}
+	for i := range s.G2Monomial {
+		enc := s.G2Monomial[i].Bytes()
+		h.Write(enc[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify checks that the setup is consistent: the monomial points start at
+// the generators and are successive powers of one secret, the same in G1 and
+// G2, and the Lagrange points commit to any polynomial like the monomial ones.
+// The checks are batched with random coefficients, a failure of any single
+// point passes with negligible probability.
+func (s *Setup) Verify() error {
+	_, _, g1, g2 := bls.Generators()
+	if !s.G1Monomial[0].Equal(&g1) {
+		return errors.New("g1_monomial does not start at the generator")
+	}
+	if !s.G2Monomial[0].Equal(&g2) {
+		return errors.New("g2_monomial does not start at the generator")
+	}
+	// e(sum r_i [s^(i+1)], [1]) = e(sum r_i [s^i], [s]) in G1, with the secret
+	// of G2, and the same with the roles swapped in G2.
+	r, err := randomScalars(G1Points - 1)
+	if err != nil {
+		return err
+	}
+	var shifted, base bls.G1Affine
+	if _, err := shifted.MultiExp(s.G1Monomial[1:], r, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if _, err := base.MultiExp(s.G1Monomial[:G1Points-1], r, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if ok, err := pairingsEqual(&shifted, &s.G2Monomial[0], &base, &s.G2Monomial[1]); err != nil {
+		return err
+	} else if !ok {
+		return errors.New("g1_monomial points are not successive powers of the secret of g2_monomial")
+	}
+	r, err = randomScalars(G2Points - 1)
+	if err != nil {
+		return err
+	}
+	var shifted2, base2 bls.G2Affine
+	if _, err := shifted2.MultiExp(s.G2Monomial[1:], r, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if _, err := base2.MultiExp(s.G2Monomial[:G2Points-1], r, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if ok, err := pairingsEqual(&s.G1Monomial[0], &shifted2, &s.G1Monomial[1], &base2); err != nil {
+		return err
+	} else if !ok {
+		return errors.New("g2_monomial points are not successive powers of the secret of g1_monomial")
+	}
+	// A random polynomial committed to from its coefficients and from its
+	// evaluations at the roots of unity.
+	coefficients, err := randomScalars(G1Points)
+	if err != nil {
+		return err
+	}
+	var fromMonomial, fromLagrange bls.G1Affine
+	if _, err := fromMonomial.MultiExp(s.G1Monomial, coefficients, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if _, err := fromLagrange.MultiExp(s.G1Lagrange, evaluate(coefficients), ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if !fromMonomial.Equal(&fromLagrange) {
+		return errors.New("g1_lagrange is not the Lagrange basis of g1_monomial")
+	}
+	return nil
+}
+
+// Commit computes the KZG commitment of a blob with the Lagrange points. The
+// blob holds the evaluations at the roots of unity in bit reversed order.
+func (s *Setup) Commit(blob *kzg4844.Blob) (kzg4844.Commitment, error) {
+	scalars := make([]fr.Element, G1Points)
+	for i := range scalars {
+		if err := scalars[bitReverse(i)].SetBytesCanonical(blob[i*32 : (i+1)*32]); err != nil {
+			return kzg4844.Commitment{}, fmt.Errorf("field element %d: %v", i, err)
+		}
+	}
+	var commitment bls.G1Affine
+	if _, err := commitment.MultiExp(s.G1Lagrange, scalars, ecc.MultiExpConfig{}); err != nil {
+		return kzg4844.Commitment{}, err
+	}
+	return kzg4844.Commitment(commitment.Bytes()), nil
+}
+
+// CheckLibrary verifies that the embedded setup is the ceremony one and that
+// go-ethereum's kzg4844 package commits to blobs with it. The result is
+// computed once.
+func CheckLibrary() error {
+	libraryOnce.Do(func() {
+		libraryErr = checkLibrary()
+	})
+	return libraryErr
+}
+
+func checkLibrary() error {
+	setup, err := Embedded()
+	if err != nil {
+		return fmt.Errorf("embedded setup: %v", err)
+	}
+	if digest := setup.Digest(); digest != CeremonyDigest {
+		return fmt.Errorf("embedded setup digest %s is not the ceremony digest %s", digest, CeremonyDigest)
+	}
+	var blob kzg4844.Blob
+	scalars, err := randomScalars(G1Points)
+	if err != nil {
+		return err
+	}
+	for i := range scalars {
+		enc := scalars[i].Bytes()
+		copy(blob[i*32:], enc[:])
+	}
+	want, err := setup.Commit(&blob)
+	if err != nil {
+		return err
+	}
+	have, err := kzg4844.BlobToCommitment(&blob)
+	if err != nil {
+		return err
+	}
+	if have != want {
+		return errors.New("go-ethereum's kzg4844 package does not commit with the ceremony setup")
+	}
+	return nil
+}
+
+// pairingsEqual reports whether e(a, b) = e(c, d).
+func pairingsEqual(a *bls.G1Affine, b *bls.G2Affine, c *bls.G1Affine, d *bls.G2Affine) (bool, error) {
+	var negC bls.G1Affine
+	negC.Neg(c)
+	return bls.PairingCheck([]bls.G1Affine{*a, negC}, []bls.G2Affine{*b, *d})
+}
+
+// randomScalars returns n uniformly random field elements, drawn from
+// crypto/rand.
+func randomScalars(n int) ([]fr.Element, error) {
+	scalars := make([]fr.Element, n)
+	for i := range scalars {
+		if _, err := scalars[i].SetRandom(); err != nil {
+			return nil, err
+		}
+	}
+	return scalars, nil
+}
+
+// evaluate returns the evaluations of the polynomial with the coefficients
+// at the roots of unity of the blob domain, in their natural order.
+func evaluate(coefficients []fr.Element) []fr.Element {
+	var root fr.Element
+	exponent := new(big.Int).Sub(fr.Modulus(), big.NewInt(1))
+	exponent.Div(exponent, big.NewInt(G1Points))
+	root.SetUint64(primitiveRoot)
+	root.Exp(root, exponent)
+
+	evaluations := make([]fr.Element, len(coefficients))
+	var x fr.Element
+	x.SetOne()
+	for i := range evaluations {
+		var acc fr.Element
+		for j := len(coefficients) - 1; j >= 0; j-- {
+			acc.Mul(&acc, &x).Add(&acc, &coefficients[j])
+		}
+		evaluations[i] = acc
+		x.Mul(&x, &root)
+	}
+	return evaluations
+}
+
+// bitReverse reverses the bits of an index of the blob domain.
+func bitReverse(i int) int {
+	var r int
+	for bit := 1; bit < G1Points; bit <<= 1 {
+		r <<= 1
+		if i&bit != 0 {
+			r |= 1
+		}
+	}
+	return r
+}
@@ -0,0 +1,439 @@
+// Package layout computes the storage slots of Solidity state variables, so
+// that the storage of genesis contracts can be written as assignments such as
+// balances[0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b] = 100 rather than raw
+// slot and value pairs.
+//
+// Variables are laid out as by the Solidity compiler: value types are packed
+// into slots in declaration order, lower-order aligned, structs and arrays
+// start a new slot and so does the item following them. The entries of a
+// mapping at slot p live at keccak256(key . p), the elements of a dynamic
+// array at keccak256(p) onwards and the data of long strings and byte arrays
+// at keccak256(p) as well.
+package layout
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is the storage encoding of a type.
+type Kind int
+
+const (
+	Value        Kind = iota // elementary value type, stored in place
+	Bytes                    // string or bytes
+	Mapping                  // mapping, its entries are stored at hashed slots
+	DynamicArray             // T[], the length in place and the elements at hashed slots
+	StaticArray              // T[N], stored in place
+	Struct                   // struct, stored in place
+)
+
+// valueKind distinguishes the elementary value types.
+type valueKind int
+
+const (
+	uintValue valueKind = iota
+	intValue
+	addressValue
+	boolValue
+	fixedBytesValue
+)
+
+// Type is a Solidity type with its storage size.
+type Type struct {
+	Kind  Kind
+	Label string // e.g. mapping(address => uint256)
+	Size  int    // bytes occupied in place, a multiple of 32 unless a value type
+
+	value   valueKind
+	key     *Type     // of mappings
+	elem    *Type     // value of mappings, element of arrays
+	length  int       // of static arrays
+	members []*Member // of structs
+}
+
+// Member is a state variable or a struct member and its position relative to
+// the start of its container.
+type Member struct {
+	Name   string `json:"name"`
+	Type   *Type  `json:"-"`
+	Slot   uint64 `json:"slot"`
+	Offset int    `json:"offset"` // byte offset from the lower-order end of the slot
+}
+
+// Layout is the storage layout of a contract.
+type Layout struct {
+	Variables []*Member
+	Slots     uint64 // number of slots occupied in place
+}
+
+// Definition is the declarative description of a layout: the state variables
+// in declaration order and the structs they use, each declared as in Solidity
+// by its type and name, e.g.
+//
+//	structs:
+//	  Position:
+//	    - uint128 amount
+//	    - uint64 since
+//	variables:
+//	  - uint256 totalSupply
+//	  - mapping(address => uint256) balances
+//	  - mapping(address => mapping(address => uint256)) allowances
+//	  - Position[] positions
+type Definition struct {
+	Structs   map[string][]string `yaml:"structs"`
+	Variables []string            `yaml:"variables"`
+}
+
+// Load reads a YAML (or JSON) layout definition and computes the layout.
+func Load(path string) (*Layout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var def Definition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	l, err := New(&def)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return l, nil
+}
+
+// New computes the layout of a definition.
+func New(def *Definition) (*Layout, error) {
+	r := &resolver{defs: def.Structs, structs: make(map[string]*Type), pending: make(map[string][]*Type)}
+	members, err := r.declarations(def.Variables)
+	if err != nil {
+		return nil, err
+	}
+	return &Layout{Variables: members, Slots: place(members)}, nil
+}
+
+// Lookup returns the state variable of the given name.
+func (l *Layout) Lookup(name string) *Member {
+	return lookup(l.Variables, name)
+}
+
+func lookup(members []*Member, name string) *Member {
+	for _, m := range members {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// place assigns the slots and offsets of the members, relative to the start
+// of their container, and returns the number of slots they occupy.
+func place(members []*Member) uint64 {
+	var (
+		slot   uint64
+		offset int
+	)
+	for _, m := range members {
+		if m.Type.Kind == Value {
+			if offset+m.Type.Size > 32 {
+				slot, offset = slot+1, 0
+			}
+			m.Slot, m.Offset = slot, offset
+			offset += m.Type.Size
+			continue
+		}
+		if offset > 0 {
+			slot, offset = slot+1, 0
+		}
+		m.Slot = slot
+		slot += uint64(m.Type.Size / 32)
+	}
+	if offset > 0 {
+		slot++
+	}
+	return slot
+}
+
+// resolver parses the type names of a definition, laying out every struct
+// once.
+type resolver struct {
+	defs    map[string][]string
+	structs map[string]*Type
+	pending map[string][]*Type // placeholders of the structs being laid out
+}
+
+// declarations parses a list of "type name" declarations.
+func (r *resolver) declarations(decls []string) ([]*Member, error) {
+	var members []*Member
+	names := make(map[string]bool)
+	for _, decl := range decls {
+		decl = strings.TrimSuffix(strings.TrimSpace(decl), ";")
+		i := strings.LastIndexAny(decl, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("declaration %q: want type and name", decl)
+		}
+		typ, name := strings.TrimSpace(decl[:i]), decl[i+1:]
+		if !isIdentifier(name) {
+			return nil, fmt.Errorf("declaration %q: invalid name %q", decl, name)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("declaration %q: %s is declared twice", decl, name)
+		}
+		names[name] = true
+		t, err := r.parse(typ)
+		if err != nil {
+			return nil, fmt.Errorf("declaration %q: %v", decl, err)
+		}
+		if t.Size < 0 {
+			return nil, fmt.Errorf("declaration %q: %s contains itself", decl, t.Label)
+		}
+		members = append(members, &Member{Name: name, Type: t})
+	}
+	return members, nil
+}
+
+// parse parses a type name.
+func (r *resolver) parse(s string) (*Type, error) {
+	p := &typeParser{r: r, tokens: tokenize(s)}
+	t, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in type %q", p.tokens[p.pos], s)
+	}
+	return t, nil
+}
+
+// structType lays out the named struct. A struct being laid out is returned
+// as a placeholder of unknown size, which is filled in once its layout is
+// known. It may therefore only be referenced through mappings and dynamic
+// arrays, whose storage size does not depend on their element.
+func (r *resolver) structType(name string) (*Type, error) {
+	if t, ok := r.structs[name]; ok {
+		return t, nil
+	}
+	decls, ok := r.defs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", name)
+	}
+	if placeholders, ok := r.pending[name]; ok {
+		t := &Type{Kind: Struct, Label: "struct " + name, Size: -1}
+		r.pending[name] = append(placeholders, t)
+		return t, nil
+	}
+	if len(decls) == 0 {
+		return nil, fmt.Errorf("struct %s has no members", name)
+	}
+	r.pending[name] = nil
+	members, err := r.declarations(decls)
+	if err != nil {
+		return nil, fmt.Errorf("struct %s: %v", name, err)
+	}
+	t := &Type{Kind: Struct, Label: "struct " + name, members: members}
+	t.Size = int(place(members)) * 32
+	for _, placeholder := range r.pending[name] {
+		*placeholder = *t
+	}
+	delete(r.pending, name)
+	r.structs[name] = t
+	return t, nil
+}
+
+// typeParser is a recursive descent parser of type names.
+type typeParser struct {
+	r      *resolver
+	tokens []string
+	pos    int
+}
+
+func (p *typeParser) next() string {
+	if p.pos == len(p.tokens) {
+		return ""
+	}
+	p.pos++
+	return p.tokens[p.pos-1]
+}
+
+func (p *typeParser) peek() string {
+	if p.pos == len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *typeParser) expect(token string) error {
+	if got := p.next(); got != token {
+		return fmt.Errorf("expected %q, got %q", token, got)
+	}
+	return nil
+}
+
+// parseType parses a type followed by array suffixes.
+func (p *typeParser) parseType() (*Type, error) {
+	var (
+		t   *Type
+		err error
+	)
+	switch name := p.next(); {
+	case name == "mapping":
+		if t, err = p.parseMapping(); err != nil {
+			return nil, err
+		}
+	case isIdentifier(name):
+		if t = elementary(name); t != nil {
+			break
+		}
+		if name == "address" && p.peek() == "payable" {
+			p.next()
+			t = elementary(name)
+			break
+		}
+		if t, err = p.r.structType(name); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unexpected %q", name)
+	}
+	for p.peek() == "[" {
+		p.next()
+		if p.peek() == "]" {
+			p.next()
+			t = &Type{Kind: DynamicArray, Label: t.Label + "[]", Size: 32, elem: t}
+			continue
+		}
+		n, err := strconv.Atoi(p.next())
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid array length in %s", t.Label)
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		if t.Size < 0 {
+			return nil, fmt.Errorf("%s contains itself", t.Label)
+		}
+		t = &Type{Kind: StaticArray, Label: fmt.Sprintf("%s[%d]", t.Label, n), Size: arraySlots(t, n) * 32, elem: t, length: n}
+	}
+	return t, nil
+}
+
+// parseMapping parses the parenthesized key and value of a mapping.
+func (p *typeParser) parseMapping() (*Type, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	key, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if key.Kind != Value && key.Kind != Bytes {
+		return nil, fmt.Errorf("invalid mapping key type %s", key.Label)
+	}
+	// Named mapping parameters are ignored.
+	if isIdentifier(p.peek()) {
+		p.next()
+	}
+	if err := p.expect("=>"); err != nil {
+		return nil, err
+	}
+	value, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if isIdentifier(p.peek()) {
+		p.next()
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return &Type{Kind: Mapping, Label: fmt.Sprintf("mapping(%s => %s)", key.Label, value.Label), Size: 32, key: key, elem: value}, nil
+}
+
+// arraySlots returns the number of slots of a static array. Elements smaller
+// than a slot are packed, as many as fit into one.
+func arraySlots(elem *Type, n int) int {
+	if elem.Kind == Value && elem.Size < 32 {
+		perSlot := 32 / elem.Size
+		return (n + perSlot - 1) / perSlot
+	}
+	return n * (elem.Size / 32)
+}
+
+// elementary returns the elementary type of the given name, or nil.
+func elementary(name string) *Type {
+	switch name {
+	case "address":
+		return &Type{Kind: Value, Label: name, Size: 20, value: addressValue}
+	case "bool":
+		return &Type{Kind: Value, Label: name, Size: 1, value: boolValue}
+	case "string", "bytes":
+		return &Type{Kind: Bytes, Label: name, Size: 32}
+	case "uint", "int":
+		name += "256"
+	}
+	for _, v := range []struct {
+		prefix string
+		kind   valueKind
+	}{{"uint", uintValue}, {"int", intValue}, {"bytes", fixedBytesValue}} {
+		digits, ok := strings.CutPrefix(name, v.prefix)
+		if !ok || digits == "" || digits[0] == '0' {
+			continue
+		}
+		n, err := strconv.Atoi(digits)
+		if err != nil {
+			continue
+		}
+		switch {
+		case v.kind == fixedBytesValue && n >= 1 && n <= 32:
+			return &Type{Kind: Value, Label: name, Size: n, value: v.kind}
+		case v.kind != fixedBytesValue && n >= 8 && n <= 256 && n%8 == 0:
+			return &Type{Kind: Value, Label: name, Size: n / 8, value: v.kind}
+		}
+	}
+	return nil
+}
+
+// tokenize splits a type name into identifiers, numbers and punctuation.
+func tokenize(s string) []string {
+	var tokens []string
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.HasPrefix(s[i:], "=>"):
+			tokens = append(tokens, "=>")
+			i += 2
+		case isIdentChar(c):
+			j := i
+			for j < len(s) && isIdentChar(s[j]) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isIdentifier(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isIdentChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
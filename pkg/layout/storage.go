@@ -0,0 +1,411 @@
+package layout
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// Assignment sets the storage location of a path, such as
+// allowances[0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b][0x8a8eafb1cf62bfbeb1741769dae1a9dd47996192]
+// or positions[2].amount, to a value.
+type Assignment struct {
+	Pos   string // position of the assignment in its file, if any
+	Path  string
+	Value string
+}
+
+// ParseAssignments reads one "path = value" assignment per line from the
+// named file. Blank lines and lines starting with # are skipped, the rest of
+// a line after # is a comment unless it is inside a quoted string.
+func ParseAssignments(r io.Reader, name string) ([]Assignment, error) {
+	var (
+		assignments []Assignment
+		scanner     = bufio.NewScanner(r)
+		line        int
+	)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		a, err := parseAssignment(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", name, line, err)
+		}
+		a.Pos = fmt.Sprintf("%s:%d", name, line)
+		assignments = append(assignments, a)
+	}
+	return assignments, scanner.Err()
+}
+
+// parseAssignment splits an assignment at the first = outside of brackets
+// and quotes and strips trailing comments.
+func parseAssignment(text string) (Assignment, error) {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '"':
+			quoted, err := strconv.QuotedPrefix(text[i:])
+			if err != nil {
+				return Assignment{}, fmt.Errorf("invalid quoted string in %q", text)
+			}
+			i += len(quoted) - 1
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '=':
+			if depth != 0 {
+				continue
+			}
+			path, value := strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:])
+			if strings.HasPrefix(value, `"`) {
+				quoted, err := strconv.QuotedPrefix(value)
+				if err != nil {
+					return Assignment{}, fmt.Errorf("invalid quoted string in %q", text)
+				}
+				if rest := strings.TrimSpace(value[len(quoted):]); rest != "" && !strings.HasPrefix(rest, "#") {
+					return Assignment{}, fmt.Errorf("unexpected %q after the value", rest)
+				}
+				value = quoted
+			} else if i := strings.IndexByte(value, '#'); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+			if path == "" || value == "" {
+				return Assignment{}, fmt.Errorf("want path = value, got %q", text)
+			}
+			return Assignment{Path: path, Value: value}, nil
+		}
+	}
+	return Assignment{}, fmt.Errorf("want path = value, got %q", text)
+}
+
+// Location is the storage position of a path.
+type Location struct {
+	Slot   common.Hash
+	Offset int // byte offset from the lower-order end of the slot
+	Type   *Type
+}
+
+// Storage applies the assignments in order and returns the resulting storage.
+// Assignments to values packed into the same slot are combined, later
+// assignments to the same location win. The length of a dynamic array is
+// raised to cover every assigned element. Slots which end up zero are
+// omitted.
+func (l *Layout) Storage(assignments []Assignment) (map[common.Hash]common.Hash, error) {
+	storage := make(map[common.Hash]common.Hash)
+	for _, a := range assignments {
+		if err := l.Set(storage, a.Path, a.Value); err != nil {
+			if a.Pos != "" {
+				return nil, fmt.Errorf("%s: %s: %v", a.Pos, a.Path, err)
+			}
+			return nil, fmt.Errorf("%s: %v", a.Path, err)
+		}
+	}
+	for slot, value := range storage {
+		if value == (common.Hash{}) {
+			delete(storage, slot)
+		}
+	}
+	return storage, nil
+}
+
+// Set assigns the value to the location of the path in the storage.
+func (l *Layout) Set(storage map[common.Hash]common.Hash, path, value string) error {
+	loc, lengths, err := l.resolve(path)
+	if err != nil {
+		return err
+	}
+	for slot, n := range lengths {
+		if current := new(uint256.Int).SetBytes(storage[slot].Bytes()); current.CmpUint64(n) < 0 {
+			storage[slot] = uint256.NewInt(n).Bytes32()
+		}
+	}
+	switch loc.Type.Kind {
+	case Value:
+		encoded, err := encodeValue(loc.Type, value)
+		if err != nil {
+			return err
+		}
+		word := storage[loc.Slot]
+		copy(word[32-loc.Offset-len(encoded):32-loc.Offset], encoded)
+		storage[loc.Slot] = word
+	case Bytes:
+		data, err := encodeBytes(loc.Type, value)
+		if err != nil {
+			return err
+		}
+		setBytes(storage, loc.Slot, data)
+	default:
+		return fmt.Errorf("cannot assign to %s, assign to its elements instead", loc.Type.Label)
+	}
+	return nil
+}
+
+// setBytes stores a string or byte array at the slot. Up to 31 bytes are
+// stored in the slot itself, left-aligned, with twice the length in the
+// lowest byte. Longer data is stored from keccak256(slot) onwards and the
+// slot holds twice the length plus one. Slots of previously assigned longer
+// data are cleared.
+func setBytes(storage map[common.Hash]common.Hash, slot common.Hash, data []byte) {
+	base := new(uint256.Int).SetBytes(crypto.Keccak256(slot.Bytes()))
+	previous := new(uint256.Int).SetBytes(storage[slot].Bytes())
+	if previous.Uint64()&1 == 1 && previous.IsUint64() {
+		for i := uint64(0); i < (previous.Uint64()/2+31)/32; i++ {
+			delete(storage, new(uint256.Int).AddUint64(base, i).Bytes32())
+		}
+	}
+	if len(data) < 32 {
+		var word common.Hash
+		copy(word[:], data)
+		word[31] = byte(2 * len(data))
+		storage[slot] = word
+		return
+	}
+	storage[slot] = uint256.NewInt(uint64(2*len(data) + 1)).Bytes32()
+	for i := 0; i*32 < len(data); i++ {
+		var word common.Hash
+		copy(word[:], data[i*32:])
+		storage[new(uint256.Int).AddUint64(base, uint64(i)).Bytes32()] = word
+	}
+}
+
+// Resolve returns the storage location of a path.
+func (l *Layout) Resolve(path string) (*Location, error) {
+	loc, _, err := l.resolve(path)
+	return loc, err
+}
+
+// resolve walks the path from its state variable, returning its location and
+// the minimum lengths of the dynamic arrays it indexes, keyed by their slot.
+func (l *Layout) resolve(path string) (*Location, map[common.Hash]uint64, error) {
+	steps, err := splitPath(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	v := l.Lookup(steps[0])
+	if v == nil {
+		return nil, nil, fmt.Errorf("unknown variable %q", steps[0])
+	}
+	var (
+		slot    = uint256.NewInt(v.Slot)
+		offset  = v.Offset
+		t       = v.Type
+		lengths = make(map[common.Hash]uint64)
+	)
+	for _, step := range steps[1:] {
+		if name, ok := strings.CutPrefix(step, "."); ok {
+			if t.Kind != Struct {
+				return nil, nil, fmt.Errorf("%s has no member %s", t.Label, name)
+			}
+			m := lookup(t.members, name)
+			if m == nil {
+				return nil, nil, fmt.Errorf("%s has no member %s", t.Label, name)
+			}
+			slot.AddUint64(slot, m.Slot)
+			offset, t = m.Offset, m.Type
+			continue
+		}
+		key := strings.TrimSpace(step[1 : len(step)-1])
+		switch t.Kind {
+		case Mapping:
+			encoded, err := encodeKey(t.key, key)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid key of %s: %v", t.Label, err)
+			}
+			p := slot.Bytes32()
+			slot.SetBytes(crypto.Keccak256(encoded, p[:]))
+			offset, t = 0, t.elem
+		case DynamicArray, StaticArray:
+			index, ok := math.ParseUint64(key)
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid index %q of %s", key, t.Label)
+			}
+			base := new(uint256.Int).Set(slot)
+			if t.Kind == DynamicArray {
+				if index == ^uint64(0) {
+					return nil, nil, fmt.Errorf("index %d of %s out of range", index, t.Label)
+				}
+				p := slot.Bytes32()
+				if lengths[p] < index+1 {
+					lengths[p] = index + 1
+				}
+				base.SetBytes(crypto.Keccak256(p[:]))
+			} else if index >= uint64(t.length) {
+				return nil, nil, fmt.Errorf("index %d of %s out of range", index, t.Label)
+			}
+			slot, offset = elementLocation(base, t.elem, index)
+			t = t.elem
+		default:
+			return nil, nil, fmt.Errorf("cannot index %s", t.Label)
+		}
+	}
+	return &Location{Slot: slot.Bytes32(), Offset: offset, Type: t}, lengths, nil
+}
+
+// elementLocation returns the slot and offset of an array element. Elements
+// smaller than a slot are packed, as many as fit into one.
+func elementLocation(base *uint256.Int, elem *Type, index uint64) (*uint256.Int, int) {
+	if elem.Kind == Value && elem.Size < 32 {
+		perSlot := uint64(32 / elem.Size)
+		return new(uint256.Int).AddUint64(base, index/perSlot), int(index%perSlot) * elem.Size
+	}
+	slots := new(uint256.Int).Mul(uint256.NewInt(index), uint256.NewInt(uint64(elem.Size/32)))
+	return slots.Add(slots, base), 0
+}
+
+// splitPath splits a path into its variable name followed by .member and
+// [key] steps.
+func splitPath(path string) ([]string, error) {
+	path = strings.TrimSpace(path)
+	end := strings.IndexAny(path, ".[")
+	if end < 0 {
+		end = len(path)
+	}
+	if !isIdentifier(path[:end]) {
+		return nil, fmt.Errorf("invalid path %q", path)
+	}
+	steps := []string{path[:end]}
+	for rest := path[end:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			n := 1
+			for n < len(rest) && isIdentChar(rest[n]) {
+				n++
+			}
+			if !isIdentifier(rest[1:n]) {
+				return nil, fmt.Errorf("invalid member in path %q", path)
+			}
+			steps, rest = append(steps, rest[:n]), rest[n:]
+		case '[':
+			n := 1
+			for n < len(rest) && rest[n] == ' ' {
+				n++
+			}
+			if n < len(rest) && rest[n] == '"' {
+				quoted, err := strconv.QuotedPrefix(rest[n:])
+				if err != nil {
+					return nil, fmt.Errorf("invalid quoted key in path %q", path)
+				}
+				n += len(quoted)
+			}
+			for n < len(rest) && rest[n] != ']' {
+				n++
+			}
+			if n == len(rest) {
+				return nil, fmt.Errorf("unterminated key in path %q", path)
+			}
+			steps, rest = append(steps, rest[:n+1]), rest[n+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in path %q", rest, path)
+		}
+	}
+	return steps, nil
+}
+
+// encodeKey returns the encoding of a mapping key hashed with the slot of the
+// mapping: the 32 byte ABI encoding of value types, the unpadded content
+// of strings and byte arrays.
+func encodeKey(t *Type, key string) ([]byte, error) {
+	if t.Kind == Bytes {
+		return encodeBytes(t, key)
+	}
+	encoded, err := encodeValue(t, key)
+	if err != nil {
+		return nil, err
+	}
+	word := make([]byte, 32)
+	switch {
+	case t.value == fixedBytesValue:
+		copy(word, encoded)
+	case t.value == intValue && encoded[0]&0x80 != 0:
+		for i := range word {
+			word[i] = 0xff
+		}
+		fallthrough
+	default:
+		copy(word[32-len(encoded):], encoded)
+	}
+	return word, nil
+}
+
+// encodeBytes parses the content of a string, given as a quoted string, or a
+// byte array, given in hex or as a quoted string.
+func encodeBytes(t *Type, s string) ([]byte, error) {
+	if strings.HasPrefix(s, `"`) {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s", s)
+		}
+		return []byte(unquoted), nil
+	}
+	if t.Label == "string" {
+		return nil, fmt.Errorf("invalid string %s, want a quoted string", s)
+	}
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytes %s: %v", s, err)
+	}
+	return b, nil
+}
+
+// encodeValue returns the in-place encoding of a value type, big-endian in
+// the size of the type. Integers are given in decimal or hex, signed ones as
+// two's complement, addresses and fixed-size byte arrays in hex.
+func encodeValue(t *Type, s string) ([]byte, error) {
+	switch t.value {
+	case boolValue:
+		switch s {
+		case "true":
+			return []byte{1}, nil
+		case "false":
+			return []byte{0}, nil
+		}
+		return nil, fmt.Errorf("invalid bool %s", s)
+	case addressValue:
+		if !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address %s", s)
+		}
+		return common.HexToAddress(s).Bytes(), nil
+	case fixedBytesValue:
+		b, err := hexutil.Decode(s)
+		if err != nil || len(b) != t.Size {
+			return nil, fmt.Errorf("invalid %s %s, want %d bytes in hex", t.Label, s, t.Size)
+		}
+		return b, nil
+	}
+	negative := t.value == intValue && strings.HasPrefix(s, "-")
+	n, ok := math.ParseBig256(strings.TrimPrefix(s, "-"))
+	if !ok {
+		return nil, fmt.Errorf("invalid %s %s", t.Label, s)
+	}
+	bits := uint(t.Size * 8)
+	limit := new(big.Int).Lsh(big.NewInt(1), bits)
+	if t.value == intValue {
+		limit.Rsh(limit, 1)
+		if negative && n.Cmp(limit) > 0 || !negative && n.Cmp(limit) >= 0 {
+			return nil, fmt.Errorf("%s out of range of %s", s, t.Label)
+		}
+		if negative {
+			n = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bits), n)
+			if n.BitLen() > int(bits) {
+				n.SetInt64(0) // -0
+			}
+		}
+	} else if n.Cmp(limit) >= 0 {
+		return nil, fmt.Errorf("%s out of range of %s", s, t.Label)
+	}
+	return math.PaddedBigBytes(n, t.Size), nil
+}
@@ -0,0 +1,162 @@
+package precompiletest
+
+import (
+	"math/big"
+
+	bls "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	blsfp "github.com/consensys/gnark-crypto/ecc/bls12-381/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// MaxWord is 2^256-1.
+var MaxWord = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+
+// Word encodes v as a 32 byte big endian word.
+func Word(v uint64) []byte {
+	return WordBig(new(big.Int).SetUint64(v))
+}
+
+// WordBig encodes v modulo 2^256 as a 32 byte big endian word.
+func WordBig(v *big.Int) []byte {
+	return math.U256Bytes(new(big.Int).Set(v))
+}
+
+// Concat concatenates the parts of an input.
+func Concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+// BN254 points are encoded as in EIP-196 and EIP-197: 32 byte coordinates,
+// with the imaginary part of G2 coordinates first.
+
+// BNG1 encodes a BN254 G1 point.
+func BNG1(p *bn254.G1Affine) []byte {
+	x, y := p.X.Bytes(), p.Y.Bytes()
+	return Concat(x[:], y[:])
+}
+
+// BNG2 encodes a BN254 G2 point.
+func BNG2(p *bn254.G2Affine) []byte {
+	x1, x0, y1, y0 := p.X.A1.Bytes(), p.X.A0.Bytes(), p.Y.A1.Bytes(), p.Y.A0.Bytes()
+	return Concat(x1[:], x0[:], y1[:], y0[:])
+}
+
+// BNPair encodes a pair of the BN254 pairing check.
+func BNPair(p *bn254.G1Affine, q *bn254.G2Affine) []byte {
+	return Concat(BNG1(p), BNG2(q))
+}
+
+// BNCancellingPairs encodes the pairs e(p, q) and e(-p, q), whose product is
+// one.
+func BNCancellingPairs(p *bn254.G1Affine, q *bn254.G2Affine) []byte {
+	var neg bn254.G1Affine
+	neg.Neg(p)
+	return Concat(BNPair(p, q), BNPair(&neg, q))
+}
+
+// BNG2NotInSubgroup returns a point on the BN254 twist outside the G2
+// subgroup. The twist has a large cofactor, so unlike G1 its points are not
+// in the subgroup by being on the curve.
+func BNG2NotInSubgroup() bn254.G2Affine {
+	// The twist coefficient, recovered from the generator.
+	_, _, _, g2 := bn254.Generators()
+	b, x3 := g2.Y, g2.X
+	b.Square(&g2.Y)
+	x3.Square(&g2.X).Mul(&x3, &g2.X)
+	b.Sub(&b, &x3)
+
+	var p bn254.G2Affine
+	for x := uint64(1); ; x++ {
+		p.X.A0.SetUint64(x)
+		p.X.A1.SetZero()
+		rhs := p.X
+		rhs.Square(&p.X).Mul(&rhs, &p.X).Add(&rhs, &b)
+		if rhs.Legendre() == 1 {
+			p.Y.Sqrt(&rhs)
+			if p.IsOnCurve() && !p.IsInSubGroup() {
+				return p
+			}
+		}
+	}
+}
+
+// BLS12-381 points are encoded as in EIP-2537: coordinates are padded to 64
+// bytes, G2 coordinates are encoded as c0 followed by c1.
+
+// BLSFp encodes a BLS12-381 base field element.
+func BLSFp(e *blsfp.Element) []byte {
+	b := e.Bytes()
+	return Concat(make([]byte, 16), b[:])
+}
+
+// BLSFpBig encodes an integer below 2^384 as a BLS12-381 base field element,
+// which may be out of range.
+func BLSFpBig(v *big.Int) []byte {
+	return Concat(make([]byte, 16), v.FillBytes(make([]byte, 48)))
+}
+
+// BLSG1 encodes a BLS12-381 G1 point.
+func BLSG1(p *bls.G1Affine) []byte {
+	return Concat(BLSFp(&p.X), BLSFp(&p.Y))
+}
+
+// BLSG2 encodes a BLS12-381 G2 point.
+func BLSG2(p *bls.G2Affine) []byte {
+	return Concat(BLSFp(&p.X.A0), BLSFp(&p.X.A1), BLSFp(&p.Y.A0), BLSFp(&p.Y.A1))
+}
+
+// BLSPair encodes a pair of the BLS12-381 pairing check.
+func BLSPair(p *bls.G1Affine, q *bls.G2Affine) []byte {
+	return Concat(BLSG1(p), BLSG2(q))
+}
+
+// BLSCancellingPairs encodes the pairs e(p, q) and e(-p, q), whose product is
+// one.
+func BLSCancellingPairs(p *bls.G1Affine, q *bls.G2Affine) []byte {
+	var neg bls.G1Affine
+	neg.Neg(p)
+	return Concat(BLSPair(p, q), BLSPair(&neg, q))
+}
+
+// BLSG1NotInSubgroup returns a point on the G1 curve outside the subgroup.
+func BLSG1NotInSubgroup() bls.G1Affine {
+	var p bls.G1Affine
+	for x := uint64(1); ; x++ {
+		var rhs, b blsfp.Element
+		p.X.SetUint64(x)
+		rhs.Square(&p.X).Mul(&rhs, &p.X)
+		b.SetUint64(4)
+		rhs.Add(&rhs, &b)
+		if rhs.Legendre() == 1 {
+			p.Y.Sqrt(&rhs)
+			if p.IsOnCurve() && !p.IsInSubGroup() {
+				return p
+			}
+		}
+	}
+}
+
+// BLSG2NotInSubgroup returns a point on the G2 curve outside the subgroup.
+func BLSG2NotInSubgroup() bls.G2Affine {
+	var p bls.G2Affine
+	for x := uint64(1); ; x++ {
+		p.X.A0.SetUint64(x)
+		p.X.A1.SetZero()
+		rhs, b := p.X, p.X
+		rhs.Square(&p.X).Mul(&rhs, &p.X)
+		b.SetOne().MulBybTwistCurveCoeff(&b)
+		rhs.Add(&rhs, &b)
+		if rhs.Legendre() == 1 {
+			p.Y.Sqrt(&rhs)
+			if p.IsOnCurve() && !p.IsInSubGroup() {
+				return p
+			}
+		}
+	}
+}
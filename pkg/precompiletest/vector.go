@@ -0,0 +1,49 @@
+// Package precompiletest encodes the inputs of the precompiled contracts and
+// writes their test vectors, in the format of go-ethereum's
+// core/vm/testdata/precompiles.
+package precompiletest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Vector is a successful precompile invocation.
+type Vector struct {
+	Input       string
+	Expected    string
+	Gas         uint64
+	Name        string
+	NoBenchmark bool
+}
+
+// FailVector is a precompile invocation which fails, consuming all gas.
+type FailVector struct {
+	Input         string
+	ExpectedError string
+	Name          string
+}
+
+// Write writes the vectors of a precompile to <dir>/<name>.json and, if any
+// input is rejected, the failing ones to <dir>/fail-<name>.json.
+func Write(dir, name string, vectors []Vector, failVectors []FailVector) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := writeJSON(filepath.Join(dir, name+".json"), vectors); err != nil {
+		return err
+	}
+	if len(failVectors) > 0 {
+		return writeJSON(filepath.Join(dir, "fail-"+name+".json"), failVectors)
+	}
+	return nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
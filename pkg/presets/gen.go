@@ -0,0 +1,58 @@
+//go:build ignore
+
+// gen writes the network presets from the genesis definitions of the
+// go-ethereum version in go.mod. A preset is only written if the genesis
+// block it decodes to has the canonical hash of the network and its chain
+// config matches the go-ethereum one, so regenerating never changes a
+// network silently.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/ethereum/execution-specs/pkg/presets"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+var sources = map[string]func() *core.Genesis{
+	"mainnet": core.DefaultGenesisBlock,
+	"sepolia": core.DefaultSepoliaGenesisBlock,
+	"holesky": core.DefaultHoleskyGenesisBlock,
+	"hoodi":   core.DefaultHoodiGenesisBlock,
+}
+
+func main() {
+	for _, name := range presets.Names() {
+		source, ok := sources[name]
+		if !ok {
+			fatalf("%s: no go-ethereum genesis", name)
+		}
+		genesis := source()
+		data, err := json.MarshalIndent(genesis, "", "  ")
+		if err != nil {
+			fatalf("%s: %v", name, err)
+		}
+		decoded, err := presets.Decode(data)
+		if err != nil {
+			fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(decoded.Config, genesis.Config) {
+			fatalf("%s: chain config changes in its JSON encoding", name)
+		}
+		if err := presets.Verify(name, decoded); err != nil {
+			fatalf("%v", err)
+		}
+		if err := os.WriteFile(name+".json", append(data, '\n'), 0644); err != nil {
+			fatalf("%v", err)
+		}
+		fmt.Printf("Wrote %s.json, %d accounts\n", name, len(genesis.Alloc))
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "Fatal: "+format+"\n", args...)
+	os.Exit(1)
+}
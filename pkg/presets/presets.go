@@ -0,0 +1,137 @@
+// Package presets embeds the genesis of the public networks: their chain
+// configs, genesis headers and allocations, in the geth genesis format. The
+// definitions are owned by this package rather than taken from the vendored
+// go-ethereum, so the spec tooling changes networks when the presets are
+// regenerated and not whenever go-ethereum is upgraded.
+//
+// Every preset is checked against the canonical hash of its genesis block
+// when it is generated, by go generate, and again the first time it is
+// loaded. A preset whose hash differs is never returned.
+package presets
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+//go:generate go run gen.go
+
+var (
+	//go:embed mainnet.json
+	mainnetJSON []byte
+	//go:embed sepolia.json
+	sepoliaJSON []byte
+	//go:embed holesky.json
+	holeskyJSON []byte
+	//go:embed hoodi.json
+	hoodiJSON []byte
+)
+
+// Hashes are the canonical genesis block hashes of the networks.
+var Hashes = map[string]common.Hash{
+	"mainnet": common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"),
+	"sepolia": common.HexToHash("0x25a5cc106eea7138acab33231d7160d69cb777ee0c2c553fcddf5138993e6dd9"),
+	"holesky": common.HexToHash("0xb5f7f912443c940f21fd611f12828d75b534364ed9e95ca4e307729a4661bde4"),
+	"hoodi":   common.HexToHash("0xbbe312868b376a3001692a646dd2d7d1e4406380dfd86b98aa8a34d1557c971b"),
+}
+
+// preset is an embedded network genesis and the result of checking it.
+type preset struct {
+	data []byte
+	err  error
+	once sync.Once
+}
+
+var presets = map[string]*preset{
+	"mainnet": {data: mainnetJSON},
+	"sepolia": {data: sepoliaJSON},
+	"holesky": {data: holeskyJSON},
+	"hoodi":   {data: hoodiJSON},
+}
+
+// Names returns the sorted names of the networks.
+func Names() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns a private copy of the genesis of the named network, decoded
+// from the embedded preset. The first call for a network verifies the hash
+// of its genesis block.
+func Get(name string) (*core.Genesis, error) {
+	p, ok := presets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown network %q, supported networks: %s", name, strings.Join(Names(), ", "))
+	}
+	p.once.Do(func() {
+		genesis, err := Decode(p.data)
+		if err == nil {
+			err = Verify(name, genesis)
+		}
+		p.err = err
+	})
+	if p.err != nil {
+		return nil, p.err
+	}
+	return Decode(p.data)
+}
+
+// Decode decodes a preset, a genesis in the geth JSON format.
+func Decode(data []byte) (*core.Genesis, error) {
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(data, genesis); err != nil {
+		return nil, err
+	}
+	if genesis.Config == nil {
+		return nil, errors.New("genesis without chain config")
+	}
+	return genesis, nil
+}
+
+// Verify checks that the genesis block of the genesis has the hash of the
+// named network.
+func Verify(name string, genesis *core.Genesis) error {
+	want, ok := Hashes[name]
+	if !ok {
+		return fmt.Errorf("unknown network %q", name)
+	}
+	if hash := genesis.ToBlock().Hash(); hash != want {
+		return fmt.Errorf("%s preset: genesis hash %s, expected %s", name, hash.Hex(), want.Hex())
+	}
+	return nil
+}
+
+// Mainnet returns the genesis of the Ethereum mainnet.
+func Mainnet() *core.Genesis { return mustGet("mainnet") }
+
+// Sepolia returns the genesis of the Sepolia testnet.
+func Sepolia() *core.Genesis { return mustGet("sepolia") }
+
+// Holesky returns the genesis of the Holesky testnet.
+func Holesky() *core.Genesis { return mustGet("holesky") }
+
+// Hoodi returns the genesis of the Hoodi testnet.
+func Hoodi() *core.Genesis { return mustGet("hoodi") }
+
+// mustGet returns the genesis of a network, panicking if its preset is
+// corrupt. The presets are verified when they are generated, so this does not
+// happen in a released tree.
+func mustGet(name string) *core.Genesis {
+	genesis, err := Get(name)
+	if err != nil {
+		panic(err)
+	}
+	return genesis
+}
@@ -0,0 +1,340 @@
+// Package schema derives JSON Schema (draft 2020-12) documents from the Go
+// types files are decoded into, following the rules of encoding/json: the
+// properties of a struct are its exported fields under their json tag names,
+// with embedded structs flattened.
+//
+// Types with a custom JSON encoding, such as the hex encoded hashes and
+// quantities of go-ethereum, are described by a table of known formats. The
+// structs with gencodec generated codecs are described with their field type
+// overrides, and the fields tagged gencodec:"required" are required. A type
+// with a custom encoding which is missing from the tables fails the
+// generation, so that the schemas cannot silently drift from the decoders.
+//
+// Optional fields of pointer, slice and map type also accept null, which is
+// the encoding of their nil value.
+//
+// Unknown properties are ignored by the decoders and therefore allowed.
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Draft is the JSON Schema dialect of the generated documents.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document or subschema.
+type Schema map[string]interface{}
+
+// storageWord is the storage key and value encoding of genesis accounts: up
+// to 32 bytes of hex, optionally 0x prefixed, left padded with zeros.
+type storageWord common.Hash
+
+// formats are the schemas of the types with a custom JSON encoding.
+var formats = map[reflect.Type]Schema{
+	reflect.TypeOf(common.Hash{}):              fixedHex(common.HashLength, false),
+	reflect.TypeOf(common.Address{}):           fixedHex(common.AddressLength, false),
+	reflect.TypeOf(common.UnprefixedAddress{}): fixedHex(common.AddressLength, true),
+	reflect.TypeOf(types.Bloom{}):              fixedHex(types.BloomByteLength, false),
+	reflect.TypeOf(types.BlockNonce{}):         fixedHex(8, false),
+	reflect.TypeOf(hexutil.Bytes{}):            {"type": "string", "pattern": "^0x([0-9a-fA-F]{2})*$"},
+	reflect.TypeOf(hexutil.Big{}):              quantity(256),
+	reflect.TypeOf(hexutil.Uint64(0)):          quantity(64),
+	reflect.TypeOf(hexutil.Uint(0)):            quantity(64),
+	reflect.TypeOf(math.HexOrDecimal64(0)):     hexOrDecimal(64),
+	reflect.TypeOf(math.HexOrDecimal256{}):     hexOrDecimal(256),
+	reflect.TypeOf(big.Int{}):                  {"type": "integer"},
+	reflect.TypeOf(json.RawMessage{}):          {},
+	reflect.TypeOf(storageWord{}):              {"type": "string", "pattern": "^(0x)?([0-9a-fA-F]{2}){0,32}$"},
+	reflect.TypeOf((*interface{})(nil)).Elem(): {},
+}
+
+// aliases map types with a custom JSON encoding onto the type they are
+// encoded as.
+var aliases = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(types.GenesisAlloc{}): reflect.TypeOf(map[common.UnprefixedAddress]types.Account{}),
+}
+
+// codecs map the structs with gencodec generated codecs onto structs holding
+// their field type overrides, mirroring the marshaling types of the codecs.
+var codecs = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(core.Genesis{}): reflect.TypeOf(struct {
+		Nonce         math.HexOrDecimal64
+		Timestamp     math.HexOrDecimal64
+		ExtraData     hexutil.Bytes
+		GasLimit      math.HexOrDecimal64
+		GasUsed       math.HexOrDecimal64
+		Number        math.HexOrDecimal64
+		Difficulty    *math.HexOrDecimal256
+		Alloc         map[common.UnprefixedAddress]types.Account
+		BaseFee       *math.HexOrDecimal256
+		ExcessBlobGas *math.HexOrDecimal64
+		BlobGasUsed   *math.HexOrDecimal64
+	}{}),
+	reflect.TypeOf(types.Account{}): reflect.TypeOf(struct {
+		Code    hexutil.Bytes
+		Balance *math.HexOrDecimal256
+		Nonce   math.HexOrDecimal64
+		Storage map[storageWord]storageWord
+	}{}),
+	reflect.TypeOf(types.Withdrawal{}): reflect.TypeOf(struct {
+		Index     hexutil.Uint64
+		Validator hexutil.Uint64
+		Amount    hexutil.Uint64
+	}{}),
+	reflect.TypeOf(types.AccessTuple{}): reflect.TypeOf(struct{}{}),
+}
+
+var (
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// fixedHex returns the schema of a 0x prefixed hex string of n bytes. The
+// prefix is optional if unprefixed is set.
+func fixedHex(n int, unprefixed bool) Schema {
+	prefix := "0x"
+	if unprefixed {
+		prefix = "(0x)?"
+	}
+	return Schema{"type": "string", "pattern": fmt.Sprintf("^%s[0-9a-fA-F]{%d}$", prefix, 2*n)}
+}
+
+// quantity returns the schema of a hex encoded integer of the given bit size,
+// 0x prefixed without leading zeros.
+func quantity(bits int) Schema {
+	return Schema{"type": "string", "pattern": fmt.Sprintf("^0x(0|[1-9a-fA-F][0-9a-fA-F]{0,%d})$", bits/4-1)}
+}
+
+// hexOrDecimal returns the schema of an integer of the given bit size given
+// as a JSON number, or as a string holding its decimal or 0x prefixed hex
+// form, leading zeros allowed.
+func hexOrDecimal(bits int) Schema {
+	number := Schema{"type": "integer", "minimum": 0}
+	if bits == 64 {
+		number["maximum"] = uint64(1<<64 - 1)
+	}
+	return Schema{"oneOf": []Schema{
+		number,
+		{"type": "string", "pattern": fmt.Sprintf("^(0[xX][0-9a-fA-F]{1,%d}|[0-9]*)$", bits/4)},
+	}}
+}
+
+// generator collects the definitions of the structs of a document.
+type generator struct {
+	defs  Schema
+	names map[reflect.Type]string
+}
+
+// Generate returns the schema document of the JSON encoding of the types of
+// the given values. With several values a document matches any of them.
+func Generate(title string, values ...interface{}) (Schema, error) {
+	g := &generator{defs: make(Schema), names: make(map[reflect.Type]string)}
+	roots := make([]Schema, len(values))
+	for i, v := range values {
+		s, err := g.schema(reflect.TypeOf(v))
+		if err != nil {
+			return nil, err
+		}
+		roots[i] = s
+	}
+	doc := Schema{"$schema": Draft, "title": title}
+	if len(roots) == 1 {
+		for key, value := range roots[0] {
+			doc[key] = value
+		}
+	} else {
+		doc["oneOf"] = roots
+	}
+	if len(g.defs) > 0 {
+		doc["$defs"] = g.defs
+	}
+	return doc, nil
+}
+
+// schema returns the schema of a type, adding the definitions of the structs
+// it refers to.
+func (g *generator) schema(t reflect.Type) (Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if s, ok := formats[t]; ok {
+		return s, nil
+	}
+	if alias, ok := aliases[t]; ok {
+		return g.schema(alias)
+	}
+	overrides, codec := codecs[t]
+	if !codec && (reflect.PointerTo(t).Implements(jsonUnmarshaler) || reflect.PointerTo(t).Implements(textUnmarshaler)) {
+		return nil, fmt.Errorf("no schema for %v, which has a custom JSON encoding", t)
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Schema{"type": "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer", "minimum": 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}, nil
+	case reflect.String:
+		return Schema{"type": "string"}, nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return Schema{"type": "array", "items": items}, nil
+	case reflect.Array:
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return Schema{"type": "array", "items": items, "minItems": t.Len(), "maxItems": t.Len()}, nil
+	case reflect.Map:
+		return g.mapSchema(t)
+	case reflect.Struct:
+		return g.structRef(t, overrides)
+	}
+	return nil, fmt.Errorf("no schema for %v", t)
+}
+
+// mapSchema returns the schema of a map, encoded as an object.
+func (g *generator) mapSchema(t reflect.Type) (Schema, error) {
+	values, err := g.schema(t.Elem())
+	if err != nil {
+		return nil, err
+	}
+	s := Schema{"type": "object", "additionalProperties": values}
+	key := t.Key()
+	switch {
+	case formats[key] != nil:
+		s["propertyNames"] = formats[key]
+	case reflect.PointerTo(key).Implements(textUnmarshaler):
+		return nil, fmt.Errorf("no schema for the map key %v", key)
+	case key.Kind() == reflect.String:
+	case key.Kind() >= reflect.Int && key.Kind() <= reflect.Uint64:
+		s["propertyNames"] = Schema{"pattern": "^-?[0-9]+$"}
+	default:
+		return nil, fmt.Errorf("unsupported map key %v", key)
+	}
+	return s, nil
+}
+
+// structRef returns a reference to the definition of a named struct, adding
+// the definition on first use. Anonymous structs are inlined.
+func (g *generator) structRef(t reflect.Type, overrides reflect.Type) (Schema, error) {
+	if t.Name() == "" {
+		return g.structSchema(t, overrides)
+	}
+	name, ok := g.names[t]
+	if !ok {
+		name = path.Base(t.PkgPath()) + "." + t.Name()
+		if _, taken := g.defs[name]; taken {
+			name = strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + t.Name()
+		}
+		g.names[t] = name
+		g.defs[name] = Schema{} // placeholder for recursive types
+		s, err := g.structSchema(t, overrides)
+		if err != nil {
+			return nil, err
+		}
+		g.defs[name] = s
+	}
+	return Schema{"$ref": "#/$defs/" + name}, nil
+}
+
+// structSchema returns the schema of a struct, encoded as an object.
+func (g *generator) structSchema(t reflect.Type, overrides reflect.Type) (Schema, error) {
+	var (
+		properties = make(Schema)
+		required   []string
+	)
+	if err := g.fields(t, overrides, properties, &required); err != nil {
+		return nil, err
+	}
+	s := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s, nil
+}
+
+// fields adds the properties of the fields of a struct, flattening embedded
+// structs without a json name.
+func (g *generator) fields(t reflect.Type, overrides reflect.Type, properties Schema, required *[]string) error {
+	if overrides != nil {
+		for i := 0; i < overrides.NumField(); i++ {
+			if _, ok := t.FieldByName(overrides.Field(i).Name); !ok {
+				return fmt.Errorf("%v has no field %s to override", t, overrides.Field(i).Name)
+			}
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := g.fields(embedded, codecs[embedded], properties, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		typ := field.Type
+		if overrides != nil {
+			if override, ok := overrides.FieldByName(field.Name); ok {
+				typ = override.Type
+			}
+		}
+		s, err := g.schema(typ)
+		if err != nil {
+			return fmt.Errorf("%v.%s: %v", t, field.Name, err)
+		}
+		if field.Tag.Get("gencodec") == "required" {
+			*required = append(*required, name)
+		} else if nullable(typ) {
+			s = Schema{"oneOf": []Schema{s, {"type": "null"}}}
+		}
+		properties[name] = s
+	}
+	return nil
+}
+
+// nullable reports whether the nil value of a type is encoded as null.
+func nullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+// Package shrink minimizes a state test on which EVM implementations diverge
+// into a small reproducer of the divergence.
+//
+// The first post state on which the backends diverge is selected, with the
+// transaction variants it selects, and reduced step by step: accounts of the
+// pre state are removed, storage slots are zeroed, chunks of code and
+// calldata are cut out, from all of it down to single bytes, and the access
+// list and value of the transaction are dropped. A reduction is only kept if
+// the backends still diverge with the same signature (see evmfuzz.Finding),
+// or with any signature if none is preserved.
+package shrink
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"slices"
+	"sort"
+
+	"github.com/ethereum/execution-specs/pkg/evmfuzz"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// Shrinker reduces a test case step by step, keeping every reduction after
+// which the divergence persists.
+type Shrinker struct {
+	Backends  []evmfuzz.Backend
+	Signature string // divergence to preserve, "" for any
+
+	// Log is called with every reduction kept and the reduced test case,
+	// if set.
+	Log func(step string, c *Case)
+
+	Current *Case // test case being reduced, set by Select
+	Runs    int   // executions of candidates
+	Kept    int   // reductions kept
+}
+
+// diverges runs the test case on the backends and reports whether they
+// diverge with the preserved signature.
+func (s *Shrinker) diverges(c *Case) (*evmfuzz.Finding, bool) {
+	s.Runs++
+	data, err := c.Encode()
+	if err != nil {
+		return nil, false
+	}
+	finding := evmfuzz.Compare(data, c.fork, s.Backends).Finding
+	if finding == nil || (s.Signature != "" && finding.Signature != s.Signature) {
+		return finding, false
+	}
+	return finding, true
+}
+
+// try keeps the candidate if the divergence persists on it.
+func (s *Shrinker) try(candidate *Case, step string) bool {
+	if _, ok := s.diverges(candidate); !ok {
+		return false
+	}
+	s.Current = candidate
+	s.Kept++
+	if s.Log != nil {
+		s.Log(step, candidate)
+	}
+	return true
+}
+
+// Select makes the first post state on which the backends diverge the
+// current test case, trying the tests of a fixture file and their forks in
+// name order, or only the test and the fork given if not empty. It returns
+// the name of the test, the position of the post state and the divergence.
+func (s *Shrinker) Select(file map[string]json.RawMessage, name, fork string) (string, int, *evmfuzz.Finding, error) {
+	names := sortedKeys(file)
+	if name != "" {
+		if file[name] == nil {
+			return "", 0, nil, fmt.Errorf("no test %q", name)
+		}
+		names = []string{name}
+	}
+	for _, n := range names {
+		t, err := decodeStateTest(file[n])
+		if err != nil {
+			if name != "" {
+				return "", 0, nil, fmt.Errorf("test %s: %v", n, err)
+			}
+			continue
+		}
+		forks := sortedKeys(t.post)
+		if fork != "" {
+			forks = []string{fork}
+		}
+		for _, f := range forks {
+			for i := range t.post[f] {
+				c, err := t.isolate(f, i)
+				if err != nil {
+					return "", 0, nil, fmt.Errorf("test %s: %v", n, err)
+				}
+				if finding, ok := s.diverges(c); ok {
+					s.Current = c
+					return n, i, finding, nil
+				}
+			}
+		}
+	}
+	return "", 0, nil, errors.New("the backends agree on every post state")
+}
+
+// Shrink applies the reductions to the current test case until none of them
+// is kept any more.
+func (s *Shrinker) Shrink() {
+	for {
+		kept := s.Kept
+		s.removeAccounts()
+		s.removeStorage()
+		s.shrinkCode()
+		s.shrinkCalldata()
+		s.simplifyTransaction()
+		if s.Kept == kept {
+			return
+		}
+	}
+}
+
+// Reproducer returns the current test case with its post state recomputed by
+// go-ethereum, if it accepts the transaction, and its info recording the
+// divergence.
+func (s *Shrinker) Reproducer() (json.RawMessage, error) {
+	c := s.Current.copy()
+	data, err := c.Encode()
+	if err != nil {
+		return nil, err
+	}
+	if root, logs, err := evmfuzz.Fill(data, c.fork); err == nil {
+		c.post["hash"], _ = json.Marshal(root)
+		c.post["logs"], _ = json.Marshal(logs)
+	}
+	info := make(map[string]interface{})
+	if raw, ok := c.fields["_info"]; ok {
+		json.Unmarshal(raw, &info)
+	}
+	outcome := evmfuzz.Compare(data, c.fork, s.Backends)
+	if f := outcome.Finding; f != nil {
+		info["signature"], info["comment"] = f.Signature, "shrunk reproducer: "+f.Detail
+	}
+	for _, result := range outcome.Results {
+		if result != nil {
+			info["stateRoot-"+result.Backend] = result.StateRoot.Hex()
+		}
+	}
+	c.fields = maps.Clone(c.fields)
+	c.fields["_info"], _ = json.Marshal(info)
+	return c.Encode()
+}
+
+// removeAccounts drops accounts of the pre state.
+func (s *Shrinker) removeAccounts() {
+	for _, addr := range sortedKeys(s.Current.pre) {
+		candidate := s.Current.copy()
+		delete(candidate.pre, addr)
+		s.try(candidate, "removed account "+addr)
+	}
+}
+
+// removeStorage zeroes storage slots, the whole storage of an account first
+// and then ever smaller groups of slots.
+func (s *Shrinker) removeStorage() {
+	for _, addr := range sortedKeys(s.Current.pre) {
+		keys := sortedKeys(s.Current.pre[addr].Storage)
+		shrinkSlice(keys, func(kept []string) bool {
+			candidate := s.Current.copy()
+			storage := candidate.pre[addr].Storage
+			for key := range storage {
+				if !slices.Contains(kept, key) {
+					delete(storage, key)
+				}
+			}
+			return s.try(candidate, fmt.Sprintf("zeroed storage of %s, %d slots left", addr, len(kept)))
+		})
+	}
+}
+
+// shrinkCode removes chunks of the code of the accounts, from the whole code
+// down to single bytes.
+func (s *Shrinker) shrinkCode() {
+	for _, addr := range sortedKeys(s.Current.pre) {
+		shrinkSlice(s.Current.pre[addr].Code, func(code []byte) bool {
+			candidate := s.Current.copy()
+			candidate.pre[addr].Code = code
+			return s.try(candidate, fmt.Sprintf("truncated code of %s to %d bytes", addr, len(code)))
+		})
+	}
+}
+
+// shrinkCalldata removes chunks of the calldata of the transaction.
+func (s *Shrinker) shrinkCalldata() {
+	shrinkSlice(s.Current.data, func(data []byte) bool {
+		candidate := s.Current.copy()
+		candidate.data = data
+		return s.try(candidate, fmt.Sprintf("shrunk calldata to %d bytes", len(data)))
+	})
+}
+
+// simplifyTransaction drops the access list and the value of the transaction.
+func (s *Shrinker) simplifyTransaction() {
+	if s.Current.accessList != nil && string(s.Current.accessList) != "[]" {
+		candidate := s.Current.copy()
+		candidate.accessList = []byte("[]")
+		s.try(candidate, "removed access list")
+	}
+	var value math.HexOrDecimal256
+	if json.Unmarshal(s.Current.value, &value) != nil || (*big.Int)(&value).Sign() != 0 {
+		candidate := s.Current.copy()
+		candidate.value = []byte(`"0x00"`)
+		s.try(candidate, "zeroed value")
+	}
+}
+
+// shrinkSlice removes chunks of items, starting with all of them and halving
+// the chunk size down to single items. A removal is kept if try accepts the
+// remaining items. It returns the items left.
+func shrinkSlice[T any](items []T, try func([]T) bool) []T {
+	for size := len(items); size > 0; size /= 2 {
+		for start := 0; start < len(items); {
+			end := min(start+size, len(items))
+			candidate := append(slices.Clone(items[:start]), items[end:]...)
+			if try(candidate) {
+				items = candidate
+			} else {
+				start = end
+			}
+		}
+	}
+	return items
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,182 @@
+package shrink
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// indexedFields are the transaction fields holding a variant per index of the
+// post states, by the index selecting them.
+var indexedFields = map[string]string{
+	"data":        "data",
+	"gasLimit":    "gas",
+	"value":       "value",
+	"accessLists": "data",
+}
+
+// Case is a state test reduced to a single post state of a single fork, the
+// unit the shrinker works on. The fields it does not reduce are kept
+// verbatim.
+type Case struct {
+	fields map[string]json.RawMessage // env, config, _info and others
+	pre    map[string]*account
+	tx     map[string]json.RawMessage // transaction fields without variants
+
+	data       hexutil.Bytes
+	gasLimit   json.RawMessage
+	value      json.RawMessage
+	accessList json.RawMessage // nil if the transaction has no access lists
+
+	fork string
+	post map[string]json.RawMessage // with the indexes reset to zero
+}
+
+// account is an account of the pre state.
+type account struct {
+	Balance json.RawMessage            `json:"balance"`
+	Nonce   json.RawMessage            `json:"nonce,omitempty"`
+	Code    hexutil.Bytes              `json:"code"`
+	Storage map[string]json.RawMessage `json:"storage"`
+}
+
+// stateTest is a state test of a fixture file, decoded far enough to split it
+// into test cases.
+type stateTest struct {
+	fields map[string]json.RawMessage
+	pre    map[string]*account
+	tx     map[string]json.RawMessage
+	post   map[string][]map[string]json.RawMessage
+}
+
+// decodeStateTest decodes a state test of a fixture file.
+func decodeStateTest(raw json.RawMessage) (*stateTest, error) {
+	t := new(stateTest)
+	if err := json.Unmarshal(raw, &t.fields); err != nil {
+		return nil, err
+	}
+	for key, value := range map[string]interface{}{"pre": &t.pre, "transaction": &t.tx, "post": &t.post} {
+		if t.fields[key] == nil {
+			return nil, fmt.Errorf("not a state test: no %s", key)
+		}
+		if err := json.Unmarshal(t.fields[key], value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+		delete(t.fields, key)
+	}
+	return t, nil
+}
+
+// isolate returns the test case of the n-th post state of the fork, with the
+// transaction variants it selects.
+func (t *stateTest) isolate(fork string, n int) (*Case, error) {
+	post := maps.Clone(t.post[fork][n])
+	var indexes map[string]int
+	if err := json.Unmarshal(post["indexes"], &indexes); err != nil {
+		return nil, fmt.Errorf("invalid indexes: %v", err)
+	}
+	post["indexes"] = json.RawMessage(`{"data":0,"gas":0,"value":0}`)
+	delete(post, "txbytes") // stale as soon as the transaction is reduced
+
+	c := &Case{
+		fields: t.fields,
+		pre:    make(map[string]*account, len(t.pre)),
+		tx:     make(map[string]json.RawMessage),
+		fork:   fork,
+		post:   post,
+	}
+	for addr, acc := range t.pre {
+		c.pre[addr] = acc.copy()
+		if c.pre[addr].Storage == nil {
+			c.pre[addr].Storage = make(map[string]json.RawMessage)
+		}
+	}
+	for key, value := range t.tx {
+		index, ok := indexedFields[key]
+		if !ok {
+			c.tx[key] = value
+			continue
+		}
+		var variants []json.RawMessage
+		if err := json.Unmarshal(value, &variants); err != nil {
+			return nil, fmt.Errorf("invalid transaction %s: %v", key, err)
+		}
+		i := indexes[index]
+		if i < 0 || i >= len(variants) {
+			return nil, fmt.Errorf("transaction %s index %d out of range", key, i)
+		}
+		switch key {
+		case "data":
+			if err := json.Unmarshal(variants[i], &c.data); err != nil {
+				return nil, fmt.Errorf("invalid transaction data: %v", err)
+			}
+		case "gasLimit":
+			c.gasLimit = variants[i]
+		case "value":
+			c.value = variants[i]
+		case "accessLists":
+			c.accessList = variants[i]
+		}
+	}
+	return c, nil
+}
+
+// Encode returns the JSON encoding of the test case as a state test.
+func (c *Case) Encode() (json.RawMessage, error) {
+	tx := maps.Clone(c.tx)
+	for key, value := range map[string]interface{}{
+		"data":     []hexutil.Bytes{c.data},
+		"gasLimit": []json.RawMessage{c.gasLimit},
+		"value":    []json.RawMessage{c.value},
+	} {
+		tx[key], _ = json.Marshal(value)
+	}
+	if c.accessList != nil {
+		tx["accessLists"], _ = json.Marshal([]json.RawMessage{c.accessList})
+	}
+	test := make(map[string]interface{}, len(c.fields)+3)
+	for key, value := range c.fields {
+		test[key] = value
+	}
+	test["pre"] = c.pre
+	test["transaction"] = tx
+	test["post"] = map[string][]map[string]json.RawMessage{c.fork: {c.post}}
+	return json.Marshal(test)
+}
+
+// Fork returns the fork of the post state of the test case.
+func (c *Case) Fork() string { return c.fork }
+
+// copy returns a copy of the test case which can be reduced independently.
+func (c *Case) copy() *Case {
+	cpy := *c
+	cpy.pre = make(map[string]*account, len(c.pre))
+	for addr, acc := range c.pre {
+		cpy.pre[addr] = acc.copy()
+	}
+	cpy.tx = maps.Clone(c.tx)
+	cpy.data = slices.Clone(c.data)
+	cpy.post = maps.Clone(c.post)
+	return &cpy
+}
+
+func (a *account) copy() *account {
+	cpy := *a
+	cpy.Code = slices.Clone(a.Code)
+	cpy.Storage = maps.Clone(a.Storage)
+	return &cpy
+}
+
+// Size summarizes the test case as the number of accounts, storage slots,
+// code bytes and calldata bytes.
+func (c *Case) Size() string {
+	var slots, code int
+	for _, acc := range c.pre {
+		slots += len(acc.Storage)
+		code += len(acc.Code)
+	}
+	return fmt.Sprintf("%d accounts, %d storage slots, %d code bytes, %d calldata bytes", len(c.pre), slots, code, len(c.data))
+}
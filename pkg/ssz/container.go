@@ -0,0 +1,280 @@
+package ssz
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+)
+
+// part is the SSZ encoding of a field of a container together with its hash
+// tree root. Variable-size parts are referenced by an offset in the fixed
+// part of their container.
+type part struct {
+	enc      []byte
+	variable bool
+	root     common.Hash
+}
+
+// encodeParts encodes a container of the given fields: the fixed-size fields
+// and the offsets of the variable-size ones, followed by the variable-size
+// fields. A list of variable-size items is encoded the same way.
+func encodeParts(parts []part) []byte {
+	fixedSize := 0
+	for _, p := range parts {
+		if p.variable {
+			fixedSize += offsetSize
+		} else {
+			fixedSize += len(p.enc)
+		}
+	}
+	var (
+		fixed    = make([]byte, 0, fixedSize)
+		variable []byte
+	)
+	for _, p := range parts {
+		if p.variable {
+			fixed = binary.LittleEndian.AppendUint32(fixed, uint32(fixedSize+len(variable)))
+			variable = append(variable, p.enc...)
+		} else {
+			fixed = append(fixed, p.enc...)
+		}
+	}
+	return append(fixed, variable...)
+}
+
+// decodeParts splits the encoding of a container into its fields, given the
+// size of each fixed-size field and zero for the variable-size ones.
+func decodeParts(enc []byte, sizes []int) ([][]byte, error) {
+	fixedSize := 0
+	for _, size := range sizes {
+		if size == 0 {
+			fixedSize += offsetSize
+		} else {
+			fixedSize += size
+		}
+	}
+	if len(enc) < fixedSize {
+		return nil, fmt.Errorf("container of %d bytes shorter than its fixed part of %d", len(enc), fixedSize)
+	}
+	var (
+		parts    = make([][]byte, len(sizes))
+		variable []int // the indexes of the variable-size fields
+		offsets  []int
+		pos      int
+	)
+	for i, size := range sizes {
+		if size == 0 {
+			variable = append(variable, i)
+			offsets = append(offsets, int(binary.LittleEndian.Uint32(enc[pos:])))
+			pos += offsetSize
+			continue
+		}
+		parts[i] = enc[pos : pos+size]
+		pos += size
+	}
+	if len(variable) == 0 {
+		if len(enc) != fixedSize {
+			return nil, fmt.Errorf("container of %d bytes, want %d", len(enc), fixedSize)
+		}
+		return parts, nil
+	}
+	if offsets[0] != fixedSize {
+		return nil, fmt.Errorf("first offset %d, want %d", offsets[0], fixedSize)
+	}
+	offsets = append(offsets, len(enc))
+	for j, i := range variable {
+		if offsets[j+1] < offsets[j] || offsets[j+1] > len(enc) {
+			return nil, fmt.Errorf("invalid offset %d of field %d", offsets[j+1], i)
+		}
+		parts[i] = enc[offsets[j]:offsets[j+1]]
+	}
+	return parts, nil
+}
+
+// StableContainers, introduced by EIP-7495, are variable-size containers of
+// optional fields. They are encoded as the Bitvector[n] of the fields which
+// are present, followed by the container of those fields, and merkleized as
+// a container of n fields with zero chunks for the absent ones, mixed with
+// the root of the bitvector.
+
+// encodeStable encodes a StableContainer of capacity n, nil fields are
+// absent.
+func encodeStable(n int, fields []*part) []byte {
+	var (
+		active  = make([]byte, (n+7)/8)
+		present []part
+	)
+	for i, f := range fields {
+		if f != nil {
+			active[i/8] |= 1 << (i % 8)
+			present = append(present, *f)
+		}
+	}
+	return append(active, encodeParts(present)...)
+}
+
+// stableRoot returns the hash tree root of a StableContainer of capacity n,
+// nil fields are absent.
+func stableRoot(n int, fields []*part) common.Hash {
+	var (
+		chunks = make([]common.Hash, len(fields))
+		active common.Hash
+	)
+	for i, f := range fields {
+		if f != nil {
+			chunks[i] = f.root
+			active[i/8] |= 1 << (i % 8)
+		}
+	}
+	return hashPair(merkleize(chunks, uint64(n)), active)
+}
+
+// stablePart returns a StableContainer of capacity n as a field of another
+// container.
+func stablePart(n int, fields []*part) part {
+	return part{enc: encodeStable(n, fields), variable: true, root: stableRoot(n, fields)}
+}
+
+// decodeStable splits the encoding of a StableContainer of capacity n into
+// its fields, given the sizes of the fields it defines as for decodeParts.
+// Absent fields are nil.
+func decodeStable(enc []byte, n int, sizes []int) ([][]byte, error) {
+	activeSize := (n + 7) / 8
+	if len(enc) < activeSize {
+		return nil, fmt.Errorf("stable container of %d bytes shorter than its bitvector", len(enc))
+	}
+	active := enc[:activeSize]
+	var present []int
+	for i := 0; i < n; i++ {
+		if active[i/8]&(1<<(i%8)) == 0 {
+			continue
+		}
+		if i >= len(sizes) {
+			return nil, fmt.Errorf("undefined field %d is present", i)
+		}
+		present = append(present, i)
+	}
+	presentSizes := make([]int, len(present))
+	for j, i := range present {
+		presentSizes[j] = sizes[i]
+	}
+	parts, err := decodeParts(enc[activeSize:], presentSizes)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([][]byte, len(sizes))
+	for j, i := range present {
+		fields[i] = parts[j]
+		if fields[i] == nil {
+			fields[i] = []byte{}
+		}
+	}
+	return fields, nil
+}
+
+// listRoot returns the hash tree root of a list of composite items with the
+// given roots.
+func listRoot(roots []common.Hash, limit uint64) common.Hash {
+	return mixInLength(merkleize(roots, limit), uint64(len(roots)))
+}
+
+// splitList splits the encoding of a list of variable-size items.
+func splitList(enc []byte, limit int) ([][]byte, error) {
+	offsets, err := readOffsets(enc)
+	if err != nil {
+		return nil, err
+	}
+	if len(offsets) > limit {
+		return nil, fmt.Errorf("%d items exceed %d", len(offsets), limit)
+	}
+	items := make([][]byte, len(offsets))
+	for i, start := range offsets {
+		end := uint32(len(enc))
+		if i+1 < len(offsets) {
+			end = offsets[i+1]
+		}
+		items[i] = enc[start:end]
+	}
+	return items, nil
+}
+
+// splitFixed splits the encoding of a list of fixed-size items.
+func splitFixed(enc []byte, size, limit int) ([][]byte, error) {
+	if len(enc)%size != 0 {
+		return nil, fmt.Errorf("list of %d bytes is not a multiple of %d", len(enc), size)
+	}
+	if len(enc)/size > limit {
+		return nil, fmt.Errorf("%d items exceed %d", len(enc)/size, limit)
+	}
+	items := make([][]byte, len(enc)/size)
+	for i := range items {
+		items[i] = enc[i*size : (i+1)*size]
+	}
+	return items, nil
+}
+
+// The parts of the basic types and byte vectors.
+
+func uint8Part(n uint8) part {
+	return part{enc: []byte{n}, root: common.Hash{n}}
+}
+
+func uint64Part(n uint64) part {
+	return part{enc: binary.LittleEndian.AppendUint64(nil, n), root: uint64Chunk(n)}
+}
+
+func boolPart(b bool) part {
+	if b {
+		return uint8Part(1)
+	}
+	return uint8Part(0)
+}
+
+func hashPart(h common.Hash) part {
+	return part{enc: h[:], root: h}
+}
+
+func addressPart(a common.Address) part {
+	var chunk common.Hash
+	copy(chunk[:], a[:])
+	return part{enc: a[:], root: chunk}
+}
+
+// uint256Part is a uint256, little endian like the other integers.
+func uint256Part(n *uint256.Int) part {
+	enc := n.Bytes32()
+	for i := 0; i < 16; i++ {
+		enc[i], enc[31-i] = enc[31-i], enc[i]
+	}
+	return part{enc: enc[:], root: enc}
+}
+
+func byteListPart(b []byte, limit uint64) part {
+	return part{enc: b, variable: true, root: byteListRoot(b, limit)}
+}
+
+// The decoders of the basic types, given the part of their size.
+
+func decodeUint64(enc []byte) uint64 {
+	return binary.LittleEndian.Uint64(enc)
+}
+
+func decodeBool(enc []byte) (bool, error) {
+	switch enc[0] {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid boolean %#x", enc[0])
+}
+
+func decodeUint256(enc []byte) *uint256.Int {
+	var be [32]byte
+	for i := range be {
+		be[i] = enc[31-i]
+	}
+	return new(uint256.Int).SetBytes32(be[:])
+}
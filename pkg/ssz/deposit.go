@@ -0,0 +1,38 @@
+package ssz
+
+import "github.com/ethereum/go-ethereum/common"
+
+// DepositTreeDepth is the depth of the deposit tree of the deposit contract,
+// which holds up to 2**32 deposits.
+const DepositTreeDepth = 32
+
+// DepositData is the DepositData container of the consensus specs, a deposit
+// made to the deposit contract. The amount is in gwei.
+type DepositData struct {
+	Pubkey                [48]byte
+	WithdrawalCredentials common.Hash
+	Amount                uint64
+	Signature             [96]byte
+}
+
+// DepositDataRoot returns the hash tree root of a deposit, its leaf in the
+// deposit tree.
+func DepositDataRoot(d *DepositData) common.Hash {
+	return merkleize([]common.Hash{
+		merkleize(packBytes(d.Pubkey[:]), 2),
+		d.WithdrawalCredentials,
+		uint64Chunk(d.Amount),
+		merkleize(packBytes(d.Signature[:]), 4),
+	}, 4)
+}
+
+// DepositsRoot returns the hash tree root of a List[DepositData, 2**32] of
+// deposits, the deposit root the deposit contract reports once they are made
+// in order.
+func DepositsRoot(ds []DepositData) common.Hash {
+	leaves := make([]common.Hash, len(ds))
+	for i := range ds {
+		leaves[i] = DepositDataRoot(&ds[i])
+	}
+	return mixInLength(merkleize(leaves, 1<<DepositTreeDepth), uint64(len(ds)))
+}
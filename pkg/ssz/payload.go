@@ -0,0 +1,360 @@
+package ssz
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/beacon/engine"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// The list limits of the execution payload in the consensus specs.
+const (
+	MaxExtraDataBytes         = 32
+	MaxBytesPerTransaction    = 1 << 30
+	MaxTransactionsPerPayload = 1 << 20
+	MaxWithdrawalsPerPayload  = 1 << 4
+)
+
+// withdrawalSize is the size of an encoded withdrawal: the index, the
+// validator index, the address and the amount in gwei.
+const withdrawalSize = 8 + 8 + common.AddressLength + 8
+
+// Layout is the ExecutionPayload container of a consensus fork. The fields of
+// the later layouts extend the ones of the earlier.
+type Layout int
+
+const (
+	Bellatrix Layout = iota // the merge, Paris
+	Capella                 // withdrawals, Shanghai
+	Deneb                   // blob gas fields, Cancun and later
+)
+
+func (l Layout) String() string {
+	switch l {
+	case Bellatrix:
+		return "bellatrix"
+	case Capella:
+		return "capella"
+	case Deneb:
+		return "deneb"
+	}
+	return fmt.Sprintf("layout %d", int(l))
+}
+
+// fixedSize returns the size of the fixed part of the container.
+func (l Layout) fixedSize() int {
+	size := 32 + common.AddressLength + 32 + 32 + types.BloomByteLength + 32 + 4*8 + offsetSize + 32 + 32 + offsetSize
+	if l >= Capella {
+		size += offsetSize
+	}
+	if l >= Deneb {
+		size += 2 * 8
+	}
+	return size
+}
+
+// PayloadLayout returns the layout of a payload, the one whose optional
+// fields it has, as the engine API versions do.
+func PayloadLayout(p *engine.ExecutableData) (Layout, error) {
+	switch {
+	case p.BlobGasUsed != nil && p.ExcessBlobGas != nil && p.Withdrawals != nil:
+		return Deneb, nil
+	case p.BlobGasUsed != nil || p.ExcessBlobGas != nil:
+		return 0, errors.New("blob gas fields without withdrawals or incomplete")
+	case p.Withdrawals != nil:
+		return Capella, nil
+	}
+	return Bellatrix, nil
+}
+
+// EncodePayload returns the SSZ encoding of the payload in its layout.
+func EncodePayload(p *engine.ExecutableData) ([]byte, error) {
+	layout, err := PayloadLayout(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPayload(p); err != nil {
+		return nil, err
+	}
+	baseFee, err := uint256Bytes(p.BaseFeePerGas)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		fixed    = make([]byte, 0, layout.fixedSize())
+		variable []byte
+		offset   = func(part []byte) {
+			fixed = binary.LittleEndian.AppendUint32(fixed, uint32(layout.fixedSize()+len(variable)))
+			variable = append(variable, part...)
+		}
+	)
+	fixed = append(fixed, p.ParentHash[:]...)
+	fixed = append(fixed, p.FeeRecipient[:]...)
+	fixed = append(fixed, p.StateRoot[:]...)
+	fixed = append(fixed, p.ReceiptsRoot[:]...)
+	fixed = append(fixed, p.LogsBloom...)
+	fixed = append(fixed, p.Random[:]...)
+	for _, n := range []uint64{p.Number, p.GasLimit, p.GasUsed, p.Timestamp} {
+		fixed = binary.LittleEndian.AppendUint64(fixed, n)
+	}
+	offset(p.ExtraData)
+	fixed = append(fixed, baseFee[:]...)
+	fixed = append(fixed, p.BlockHash[:]...)
+	offset(encodeTransactions(p.Transactions))
+	if layout >= Capella {
+		offset(encodeWithdrawals(p.Withdrawals))
+	}
+	if layout >= Deneb {
+		fixed = binary.LittleEndian.AppendUint64(fixed, *p.BlobGasUsed)
+		fixed = binary.LittleEndian.AppendUint64(fixed, *p.ExcessBlobGas)
+	}
+	return append(fixed, variable...), nil
+}
+
+// checkPayload checks the sizes of the payload fields against the container.
+func checkPayload(p *engine.ExecutableData) error {
+	switch {
+	case len(p.LogsBloom) != types.BloomByteLength:
+		return fmt.Errorf("logs bloom of %d bytes, want %d", len(p.LogsBloom), types.BloomByteLength)
+	case len(p.ExtraData) > MaxExtraDataBytes:
+		return fmt.Errorf("extra data of %d bytes exceeds %d", len(p.ExtraData), MaxExtraDataBytes)
+	case len(p.Transactions) > MaxTransactionsPerPayload:
+		return fmt.Errorf("%d transactions exceed %d", len(p.Transactions), MaxTransactionsPerPayload)
+	case len(p.Withdrawals) > MaxWithdrawalsPerPayload:
+		return fmt.Errorf("%d withdrawals exceed %d", len(p.Withdrawals), MaxWithdrawalsPerPayload)
+	}
+	for i, tx := range p.Transactions {
+		if len(tx) > MaxBytesPerTransaction {
+			return fmt.Errorf("transaction %d of %d bytes exceeds %d", i, len(tx), MaxBytesPerTransaction)
+		}
+	}
+	return nil
+}
+
+// uint256Bytes returns the little endian encoding of a uint256.
+func uint256Bytes(n *big.Int) (common.Hash, error) {
+	var enc common.Hash
+	if n == nil || n.Sign() < 0 || n.BitLen() > 256 {
+		return enc, fmt.Errorf("base fee %v is not a uint256", n)
+	}
+	n.FillBytes(enc[:])
+	for i := 0; i < 16; i++ {
+		enc[i], enc[31-i] = enc[31-i], enc[i]
+	}
+	return enc, nil
+}
+
+// encodeTransactions encodes the transactions, a list of variable-size byte
+// lists: the offsets of the transactions followed by their bytes.
+func encodeTransactions(txs [][]byte) []byte {
+	var (
+		offsets = make([]byte, 0, offsetSize*len(txs))
+		data    []byte
+	)
+	for _, tx := range txs {
+		offsets = binary.LittleEndian.AppendUint32(offsets, uint32(offsetSize*len(txs)+len(data)))
+		data = append(data, tx...)
+	}
+	return append(offsets, data...)
+}
+
+func encodeWithdrawals(ws []*types.Withdrawal) []byte {
+	enc := make([]byte, 0, withdrawalSize*len(ws))
+	for _, w := range ws {
+		enc = binary.LittleEndian.AppendUint64(enc, w.Index)
+		enc = binary.LittleEndian.AppendUint64(enc, w.Validator)
+		enc = append(enc, w.Address[:]...)
+		enc = binary.LittleEndian.AppendUint64(enc, w.Amount)
+	}
+	return enc
+}
+
+// DecodePayload decodes an SSZ encoded payload. The layout is recognized by
+// the first offset, the one of the extra data, which is the size of the fixed
+// part of the container.
+func DecodePayload(enc []byte) (*engine.ExecutableData, Layout, error) {
+	if len(enc) < Bellatrix.fixedSize() {
+		return nil, 0, fmt.Errorf("payload of %d bytes shorter than its fixed part", len(enc))
+	}
+	first := int(binary.LittleEndian.Uint32(enc[32+common.AddressLength+32+32+types.BloomByteLength+32+4*8:]))
+	var layout Layout
+	switch first {
+	case Bellatrix.fixedSize():
+		layout = Bellatrix
+	case Capella.fixedSize():
+		layout = Capella
+	case Deneb.fixedSize():
+		layout = Deneb
+	default:
+		return nil, 0, fmt.Errorf("extra data offset %d matches no payload layout", first)
+	}
+	if len(enc) < first {
+		return nil, 0, fmt.Errorf("payload of %d bytes shorter than its fixed part", len(enc))
+	}
+	var (
+		p       = new(engine.ExecutableData)
+		pos     = 0
+		offsets []int
+		read    = func(n int) []byte {
+			pos += n
+			return enc[pos-n : pos]
+		}
+		readUint64 = func() uint64 { return binary.LittleEndian.Uint64(read(8)) }
+		readOffset = func() { offsets = append(offsets, int(binary.LittleEndian.Uint32(read(offsetSize)))) }
+	)
+	copy(p.ParentHash[:], read(32))
+	copy(p.FeeRecipient[:], read(common.AddressLength))
+	copy(p.StateRoot[:], read(32))
+	copy(p.ReceiptsRoot[:], read(32))
+	p.LogsBloom = append([]byte{}, read(types.BloomByteLength)...)
+	copy(p.Random[:], read(32))
+	p.Number, p.GasLimit, p.GasUsed, p.Timestamp = readUint64(), readUint64(), readUint64(), readUint64()
+	readOffset()
+	baseFee := append([]byte{}, read(32)...)
+	for i := 0; i < 16; i++ {
+		baseFee[i], baseFee[31-i] = baseFee[31-i], baseFee[i]
+	}
+	p.BaseFeePerGas = new(big.Int).SetBytes(baseFee)
+	copy(p.BlockHash[:], read(32))
+	readOffset()
+	if layout >= Capella {
+		readOffset()
+	}
+	if layout >= Deneb {
+		blobGasUsed, excessBlobGas := readUint64(), readUint64()
+		p.BlobGasUsed, p.ExcessBlobGas = &blobGasUsed, &excessBlobGas
+	}
+	offsets = append(offsets, len(enc))
+	for i := 1; i < len(offsets); i++ {
+		if offsets[i] < offsets[i-1] {
+			return nil, 0, fmt.Errorf("decreasing offset %d of field %d", offsets[i], i)
+		}
+	}
+	parts := make([][]byte, len(offsets)-1)
+	for i := range parts {
+		parts[i] = enc[offsets[i]:offsets[i+1]]
+	}
+	if len(parts[0]) > MaxExtraDataBytes {
+		return nil, 0, fmt.Errorf("extra data of %d bytes exceeds %d", len(parts[0]), MaxExtraDataBytes)
+	}
+	p.ExtraData = append([]byte{}, parts[0]...)
+	var err error
+	if p.Transactions, err = decodeTransactions(parts[1]); err != nil {
+		return nil, 0, err
+	}
+	if layout >= Capella {
+		if p.Withdrawals, err = decodeWithdrawals(parts[2]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return p, layout, nil
+}
+
+func decodeTransactions(enc []byte) ([][]byte, error) {
+	offsets, err := readOffsets(enc)
+	if err != nil {
+		return nil, fmt.Errorf("transactions: %v", err)
+	}
+	if len(offsets) > MaxTransactionsPerPayload {
+		return nil, fmt.Errorf("%d transactions exceed %d", len(offsets), MaxTransactionsPerPayload)
+	}
+	txs := make([][]byte, len(offsets))
+	for i, start := range offsets {
+		end := uint32(len(enc))
+		if i+1 < len(offsets) {
+			end = offsets[i+1]
+		}
+		if end-start > MaxBytesPerTransaction {
+			return nil, fmt.Errorf("transaction %d of %d bytes exceeds %d", i, end-start, MaxBytesPerTransaction)
+		}
+		txs[i] = append([]byte{}, enc[start:end]...)
+	}
+	return txs, nil
+}
+
+func decodeWithdrawals(enc []byte) ([]*types.Withdrawal, error) {
+	if len(enc)%withdrawalSize != 0 {
+		return nil, fmt.Errorf("withdrawals of %d bytes are not a multiple of %d", len(enc), withdrawalSize)
+	}
+	if len(enc)/withdrawalSize > MaxWithdrawalsPerPayload {
+		return nil, fmt.Errorf("%d withdrawals exceed %d", len(enc)/withdrawalSize, MaxWithdrawalsPerPayload)
+	}
+	ws := make([]*types.Withdrawal, 0, len(enc)/withdrawalSize)
+	for pos := 0; pos < len(enc); pos += withdrawalSize {
+		w := &types.Withdrawal{
+			Index:     binary.LittleEndian.Uint64(enc[pos:]),
+			Validator: binary.LittleEndian.Uint64(enc[pos+8:]),
+			Amount:    binary.LittleEndian.Uint64(enc[pos+16+common.AddressLength:]),
+		}
+		copy(w.Address[:], enc[pos+16:])
+		ws = append(ws, w)
+	}
+	return ws, nil
+}
+
+// PayloadRoot returns the hash tree root of the payload in its layout, the
+// execution payload root the beacon block body commits to.
+func PayloadRoot(p *engine.ExecutableData) (common.Hash, error) {
+	layout, err := PayloadLayout(p)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := checkPayload(p); err != nil {
+		return common.Hash{}, err
+	}
+	baseFee, err := uint256Bytes(p.BaseFeePerGas)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var feeRecipient common.Hash
+	copy(feeRecipient[:], p.FeeRecipient[:])
+	fields := []common.Hash{
+		p.ParentHash,
+		feeRecipient,
+		p.StateRoot,
+		p.ReceiptsRoot,
+		merkleize(packBytes(p.LogsBloom), types.BloomByteLength/32),
+		p.Random,
+		uint64Chunk(p.Number),
+		uint64Chunk(p.GasLimit),
+		uint64Chunk(p.GasUsed),
+		uint64Chunk(p.Timestamp),
+		byteListRoot(p.ExtraData, MaxExtraDataBytes),
+		baseFee,
+		p.BlockHash,
+		TransactionsRoot(p.Transactions),
+	}
+	if layout >= Capella {
+		fields = append(fields, WithdrawalsRoot(p.Withdrawals))
+	}
+	if layout >= Deneb {
+		fields = append(fields, uint64Chunk(*p.BlobGasUsed), uint64Chunk(*p.ExcessBlobGas))
+	}
+	return merkleize(fields, uint64(len(fields))), nil
+}
+
+// TransactionsRoot returns the hash tree root of the transactions of a
+// payload, a list of opaque byte lists.
+func TransactionsRoot(txs [][]byte) common.Hash {
+	roots := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		roots[i] = byteListRoot(tx, MaxBytesPerTransaction)
+	}
+	return mixInLength(merkleize(roots, MaxTransactionsPerPayload), uint64(len(txs)))
+}
+
+// WithdrawalsRoot returns the hash tree root of the withdrawals of a payload,
+// the withdrawals root of EIP-6465.
+func WithdrawalsRoot(ws []*types.Withdrawal) common.Hash {
+	roots := make([]common.Hash, len(ws))
+	for i, w := range ws {
+		var address common.Hash
+		copy(address[:], w.Address[:])
+		roots[i] = merkleize([]common.Hash{uint64Chunk(w.Index), uint64Chunk(w.Validator), address, uint64Chunk(w.Amount)}, 4)
+	}
+	return mixInLength(merkleize(roots, MaxWithdrawalsPerPayload), uint64(len(ws)))
+}
@@ -0,0 +1,244 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// The capacity of the Receipt StableContainer and the list limits of
+// EIP-6466.
+const (
+	MaxReceiptFields  = 32
+	MaxTopicsPerLog   = 4
+	MaxLogDataSize    = 1 << 24
+	MaxLogsPerReceipt = 1 << 21
+)
+
+// The fields of the Receipt container, in order.
+const (
+	receiptRootField = iota
+	receiptGasUsedField
+	receiptContractAddressField
+	receiptLogsField
+	receiptStatusField
+	receiptAuthoritiesField
+	receiptFields
+)
+
+// Receipt is the Receipt container of EIP-6466. Its profile follows from the
+// fields which are present: the post-state root of the receipts before
+// Byzantium, the status of the later ones, and the authorities of set code
+// transactions. Nil fields are absent.
+//
+// Unlike the RLP receipts, it holds the gas used by its own transaction, and
+// the address of the contract it creates.
+type Receipt struct {
+	Root            *common.Hash
+	GasUsed         *uint64
+	ContractAddress *common.Address
+	Logs            []Log
+	Status          *bool
+	Authorities     []common.Address
+}
+
+// Log is the Log container of a receipt.
+type Log struct {
+	Address common.Address
+	Topics  []common.Hash
+	Data    []byte
+}
+
+// NewReceipt converts the RLP receipt of a transaction into its SSZ form. The
+// authorities of a set code transaction are recovered from the signatures of
+// its authorizations, skipping the invalid ones, so whether they were applied
+// is not taken into account.
+func NewReceipt(tx *types.Transaction, r *types.Receipt) (*Receipt, error) {
+	gasUsed := r.GasUsed
+	receipt := &Receipt{GasUsed: &gasUsed, Logs: []Log{}}
+	if len(r.PostState) > 0 {
+		if len(r.PostState) != common.HashLength {
+			return nil, fmt.Errorf("post-state root of %d bytes", len(r.PostState))
+		}
+		root := common.BytesToHash(r.PostState)
+		receipt.Root = &root
+	} else {
+		status := r.Status == types.ReceiptStatusSuccessful
+		receipt.Status = &status
+	}
+	if tx.To() == nil {
+		address := r.ContractAddress
+		receipt.ContractAddress = &address
+	}
+	for _, log := range r.Logs {
+		if len(log.Topics) > MaxTopicsPerLog {
+			return nil, fmt.Errorf("log with %d topics", len(log.Topics))
+		}
+		receipt.Logs = append(receipt.Logs, Log{Address: log.Address, Topics: append([]common.Hash{}, log.Topics...), Data: append([]byte{}, log.Data...)})
+	}
+	if tx.Type() == types.SetCodeTxType {
+		receipt.Authorities = []common.Address{}
+		for _, auth := range tx.SetCodeAuthorizations() {
+			if authority, err := auth.Authority(); err == nil {
+				receipt.Authorities = append(receipt.Authorities, authority)
+			}
+		}
+	}
+	return receipt, nil
+}
+
+// RLPReceipt converts the SSZ form of a receipt back into the RLP receipt of a
+// transaction of the given type, which does not hold the gas used by the
+// transaction but the one of the block up to it.
+func RLPReceipt(r *Receipt, txType uint8, cumulativeGasUsed uint64) (*types.Receipt, error) {
+	if r.GasUsed == nil || r.Logs == nil {
+		return nil, errors.New("receipt without gas used or logs")
+	}
+	if (r.Root == nil) == (r.Status == nil) {
+		return nil, errors.New("receipt needs either a post-state root or a status")
+	}
+	if (r.Authorities != nil) != (txType == types.SetCodeTxType) {
+		return nil, fmt.Errorf("authorities of a type %d transaction", txType)
+	}
+	if r.Root != nil && (txType != types.LegacyTxType || r.Authorities != nil) {
+		return nil, fmt.Errorf("post-state root of a type %d transaction", txType)
+	}
+	receipt := &types.Receipt{Type: txType, CumulativeGasUsed: cumulativeGasUsed, Logs: []*types.Log{}}
+	if r.Root != nil {
+		receipt.PostState = r.Root.Bytes()
+	} else if *r.Status {
+		receipt.Status = types.ReceiptStatusSuccessful
+	}
+	for _, log := range r.Logs {
+		receipt.Logs = append(receipt.Logs, &types.Log{Address: log.Address, Topics: append([]common.Hash{}, log.Topics...), Data: append([]byte{}, log.Data...)})
+	}
+	receipt.Bloom = types.CreateBloom(receipt)
+	return receipt, nil
+}
+
+// parts returns the fields of the receipt.
+func (r *Receipt) parts() []*part {
+	fields := make([]*part, receiptFields)
+	set := func(i int, p part) { fields[i] = &p }
+	if r.Root != nil {
+		set(receiptRootField, hashPart(*r.Root))
+	}
+	if r.GasUsed != nil {
+		set(receiptGasUsedField, uint64Part(*r.GasUsed))
+	}
+	if r.ContractAddress != nil {
+		set(receiptContractAddressField, addressPart(*r.ContractAddress))
+	}
+	if r.Logs != nil {
+		logs := make([]part, len(r.Logs))
+		for i, log := range r.Logs {
+			logs[i] = log.part()
+		}
+		set(receiptLogsField, variableListPart(logs, MaxLogsPerReceipt))
+	}
+	if r.Status != nil {
+		set(receiptStatusField, boolPart(*r.Status))
+	}
+	if r.Authorities != nil {
+		set(receiptAuthoritiesField, addressListPart(r.Authorities, MaxAuthorizationListSize))
+	}
+	return fields
+}
+
+func (l *Log) part() part {
+	return containerPart([]part{addressPart(l.Address), hashListPart(l.Topics, MaxTopicsPerLog), byteListPart(l.Data, MaxLogDataSize)})
+}
+
+// addressListPart returns a list of addresses.
+func addressListPart(addresses []common.Address, limit uint64) part {
+	var (
+		enc   = make([]byte, 0, len(addresses)*common.AddressLength)
+		roots = make([]common.Hash, len(addresses))
+	)
+	for i, a := range addresses {
+		p := addressPart(a)
+		enc = append(enc, p.enc...)
+		roots[i] = p.root
+	}
+	return part{enc: enc, variable: true, root: listRoot(roots, limit)}
+}
+
+// EncodeReceipt returns the SSZ encoding of the Receipt container.
+func EncodeReceipt(r *Receipt) []byte {
+	return encodeStable(MaxReceiptFields, r.parts())
+}
+
+// ReceiptRoot returns the hash tree root of the Receipt container.
+func ReceiptRoot(r *Receipt) common.Hash {
+	return stableRoot(MaxReceiptFields, r.parts())
+}
+
+// DecodeReceipt decodes an SSZ encoded Receipt container.
+func DecodeReceipt(enc []byte) (*Receipt, error) {
+	fields, err := decodeStable(enc, MaxReceiptFields, []int{common.HashLength, 8, common.AddressLength, 0, 1, 0})
+	if err != nil {
+		return nil, err
+	}
+	r := new(Receipt)
+	if f := fields[receiptRootField]; f != nil {
+		root := common.BytesToHash(f)
+		r.Root = &root
+	}
+	if f := fields[receiptGasUsedField]; f != nil {
+		gasUsed := decodeUint64(f)
+		r.GasUsed = &gasUsed
+	}
+	if f := fields[receiptContractAddressField]; f != nil {
+		address := common.BytesToAddress(f)
+		r.ContractAddress = &address
+	}
+	if f := fields[receiptLogsField]; f != nil {
+		items, err := splitList(f, MaxLogsPerReceipt)
+		if err != nil {
+			return nil, fmt.Errorf("logs: %v", err)
+		}
+		r.Logs = make([]Log, len(items))
+		for i, item := range items {
+			if r.Logs[i], err = decodeLog(item); err != nil {
+				return nil, fmt.Errorf("log %d: %v", i, err)
+			}
+		}
+	}
+	if f := fields[receiptStatusField]; f != nil {
+		status, err := decodeBool(f)
+		if err != nil {
+			return nil, fmt.Errorf("status: %v", err)
+		}
+		r.Status = &status
+	}
+	if f := fields[receiptAuthoritiesField]; f != nil {
+		items, err := splitFixed(f, common.AddressLength, MaxAuthorizationListSize)
+		if err != nil {
+			return nil, fmt.Errorf("authorities: %v", err)
+		}
+		r.Authorities = make([]common.Address, len(items))
+		for i, item := range items {
+			r.Authorities[i] = common.BytesToAddress(item)
+		}
+	}
+	return r, nil
+}
+
+func decodeLog(enc []byte) (Log, error) {
+	var l Log
+	parts, err := decodeParts(enc, []int{common.AddressLength, 0, 0})
+	if err != nil {
+		return l, err
+	}
+	l.Address = common.BytesToAddress(parts[0])
+	if l.Topics, err = decodeHashList(parts[1], MaxTopicsPerLog); err != nil {
+		return l, fmt.Errorf("topics: %v", err)
+	}
+	if len(parts[2]) > MaxLogDataSize {
+		return l, fmt.Errorf("data of %d bytes exceeds %d", len(parts[2]), MaxLogDataSize)
+	}
+	l.Data = append([]byte{}, parts[2]...)
+	return l, nil
+}
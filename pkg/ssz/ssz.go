@@ -0,0 +1,123 @@
+// Package ssz encodes execution payloads in the SimpleSerialize (SSZ)
+// representation of the consensus layer, the ExecutionPayload containers of
+// Bellatrix, Capella and Deneb (kept by Electra and Fulu), and computes their
+// hash tree roots, for clients experimenting with SSZ in the execution layer.
+// The withdrawals root of EIP-6465, the hash tree root of the withdrawals
+// list, is provided as well, and so are the hash tree roots of the
+// DepositData of the deposit contract and of its deposit tree.
+//
+// The per-field SSZ transactions and receipts of EIP-6404 and EIP-6466 are
+// implemented in their StableContainer revision, built on EIP-7495, along
+// with their conversion from and to the RLP transactions and receipts. The
+// drafts still change, so their roots are only meant for experiments, and
+// the transactions of a payload remain the opaque RLP encodings defined by
+// the consensus specs.
+//
+// Payloads are the engine.ExecutableData of go-ethereum, so converting them
+// to and from blocks is left to the engine package. Only the encoding, the
+// offsets and the merkleization are implemented here, from the SSZ spec and
+// without reflection.
+package ssz
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// offsetSize is the size of the offsets of variable-size fields and items.
+const offsetSize = 4
+
+// zeroHashes are the roots of the subtrees of zero chunks by their depth.
+var zeroHashes [64]common.Hash
+
+func init() {
+	for i := 1; i < len(zeroHashes); i++ {
+		zeroHashes[i] = hashPair(zeroHashes[i-1], zeroHashes[i-1])
+	}
+}
+
+// hashPair is the SHA-256 hash of two chunks, the node hash of SSZ.
+func hashPair(a, b common.Hash) common.Hash {
+	return sha256.Sum256(append(a[:], b[:]...))
+}
+
+// merkleize returns the root of the binary Merkle tree of the chunks padded
+// with zero chunks to limit chunks, rounded up to a power of two. Chunks
+// beyond the limit are an error of the caller.
+func merkleize(chunks []common.Hash, limit uint64) common.Hash {
+	depth := 0
+	for uint64(1)<<depth < limit {
+		depth++
+	}
+	if len(chunks) == 0 {
+		return zeroHashes[depth]
+	}
+	layer := append([]common.Hash(nil), chunks...)
+	for d := 0; d < depth; d++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zeroHashes[d])
+		}
+		next := make([]common.Hash, len(layer)/2)
+		for i := range next {
+			next[i] = hashPair(layer[2*i], layer[2*i+1])
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+// mixInLength mixes the length of a list into the root of its items.
+func mixInLength(root common.Hash, length uint64) common.Hash {
+	var enc common.Hash
+	binary.LittleEndian.PutUint64(enc[:], length)
+	return hashPair(root, enc)
+}
+
+// packBytes splits bytes into chunks, zero padding the last one.
+func packBytes(b []byte) []common.Hash {
+	chunks := make([]common.Hash, (len(b)+31)/32)
+	for i := range chunks {
+		copy(chunks[i][:], b[i*32:])
+	}
+	return chunks
+}
+
+// uint64Chunk is the chunk of a uint64, little endian and zero padded.
+func uint64Chunk(n uint64) common.Hash {
+	var chunk common.Hash
+	binary.LittleEndian.PutUint64(chunk[:], n)
+	return chunk
+}
+
+// byteListRoot is the hash tree root of a ByteList of the given maximum
+// length.
+func byteListRoot(b []byte, limit uint64) common.Hash {
+	return mixInLength(merkleize(packBytes(b), (limit+31)/32), uint64(len(b)))
+}
+
+// readOffsets decodes the offsets at the start of the variable-size items of
+// a list and checks that they are increasing and within the encoding.
+func readOffsets(enc []byte) ([]uint32, error) {
+	if len(enc) == 0 {
+		return nil, nil
+	}
+	if len(enc) < offsetSize {
+		return nil, errors.New("list shorter than an offset")
+	}
+	first := binary.LittleEndian.Uint32(enc)
+	if first%offsetSize != 0 || first == 0 || int(first) > len(enc) {
+		return nil, fmt.Errorf("invalid first offset %d", first)
+	}
+	offsets := make([]uint32, first/offsetSize)
+	for i := range offsets {
+		offsets[i] = binary.LittleEndian.Uint32(enc[i*offsetSize:])
+		if i > 0 && offsets[i] < offsets[i-1] || int(offsets[i]) > len(enc) {
+			return nil, fmt.Errorf("invalid offset %d of item %d", offsets[i], i)
+		}
+	}
+	return offsets, nil
+}
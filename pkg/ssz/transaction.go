@@ -0,0 +1,637 @@
+package ssz
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// The capacities of the StableContainers and the list limits of EIP-6404.
+const (
+	MaxTransactionPayloadFields   = 32
+	MaxFeesPerGasFields           = 16
+	MaxExecutionSignatureFields   = 8
+	MaxAuthorizationPayloadFields = 16
+	MaxCalldataSize               = 1 << 24
+	MaxAccessListSize             = 1 << 19
+	MaxAccessListStorageKeys      = 1 << 19
+	MaxAuthorizationListSize      = 1 << 16
+	MaxBlobCommitmentsPerBlock    = 4096
+)
+
+// secp256k1SignatureSize is the size of a secp256k1 signature, r and s
+// followed by the y parity.
+const secp256k1SignatureSize = 65
+
+// The fields of the TransactionPayload container, in order.
+const (
+	txTypeField = iota
+	txChainIDField
+	txNonceField
+	txMaxFeesField
+	txGasField
+	txToField
+	txValueField
+	txInputField
+	txAccessListField
+	txMaxPriorityFeesField
+	txBlobHashesField
+	txAuthorizationsField
+	txFields
+)
+
+// txProfiles are the fields of the TransactionPayload of each RLP transaction
+// type, required and optional, and the fields of its FeesPerGas.
+var txProfiles = map[uint8]struct {
+	required, optional []int
+	blobFees           bool
+}{
+	types.LegacyTxType:     {[]int{txTypeField, txNonceField, txMaxFeesField, txGasField, txValueField, txInputField}, []int{txChainIDField, txToField}, false},
+	types.AccessListTxType: {[]int{txTypeField, txChainIDField, txNonceField, txMaxFeesField, txGasField, txValueField, txInputField, txAccessListField}, []int{txToField}, false},
+	types.DynamicFeeTxType: {[]int{txTypeField, txChainIDField, txNonceField, txMaxFeesField, txGasField, txValueField, txInputField, txAccessListField, txMaxPriorityFeesField}, []int{txToField}, false},
+	types.BlobTxType:       {[]int{txTypeField, txChainIDField, txNonceField, txMaxFeesField, txGasField, txToField, txValueField, txInputField, txAccessListField, txMaxPriorityFeesField, txBlobHashesField}, nil, true},
+	types.SetCodeTxType:    {[]int{txTypeField, txChainIDField, txNonceField, txMaxFeesField, txGasField, txToField, txValueField, txInputField, txAccessListField, txMaxPriorityFeesField, txAuthorizationsField}, nil, false},
+}
+
+// Transaction is the Transaction container of EIP-6404, a TransactionPayload
+// and its ExecutionSignature. Nil fields of the StableContainers are absent,
+// so an empty input or list is present only if it is not nil.
+type Transaction struct {
+	Type                  *uint8
+	ChainID               *uint64
+	Nonce                 *uint64
+	MaxFeesPerGas         *FeesPerGas
+	Gas                   *uint64
+	To                    *common.Address
+	Value                 *uint256.Int
+	Input                 []byte
+	AccessList            []AccessTuple
+	MaxPriorityFeesPerGas *FeesPerGas
+	BlobVersionedHashes   []common.Hash
+	AuthorizationList     []Authorization
+	Signature             ExecutionSignature
+}
+
+// FeesPerGas is the FeesPerGas StableContainer, the fees of the regular and
+// the blob gas.
+type FeesPerGas struct {
+	Regular *uint256.Int
+	Blob    *uint256.Int
+}
+
+// AccessTuple is an entry of an access list.
+type AccessTuple struct {
+	Address     common.Address
+	StorageKeys []common.Hash
+}
+
+// Authorization is the Authorization container of a set code transaction,
+// an AuthorizationPayload and its ExecutionSignature.
+type Authorization struct {
+	Magic     *uint8
+	ChainID   *uint64
+	Address   *common.Address
+	Nonce     *uint64
+	Signature ExecutionSignature
+}
+
+// ExecutionSignature is the ExecutionSignature StableContainer.
+type ExecutionSignature struct {
+	Secp256k1 *[secp256k1SignatureSize]byte
+}
+
+// NewTransaction converts a signed RLP transaction into its SSZ form, using
+// the profile of its type. The chain id of legacy transactions is only
+// present if they are replay protected, and so is the one of authorizations
+// which are not valid on any chain.
+func NewTransaction(tx *types.Transaction) (*Transaction, error) {
+	if _, ok := txProfiles[tx.Type()]; !ok {
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type())
+	}
+	var (
+		txType = tx.Type()
+		nonce  = tx.Nonce()
+		gas    = tx.Gas()
+		t      = &Transaction{
+			Type:  &txType,
+			Nonce: &nonce,
+			Gas:   &gas,
+			To:    tx.To(),
+			Input: append([]byte{}, tx.Data()...),
+		}
+		err error
+	)
+	if txType != types.LegacyTxType || tx.Protected() {
+		if t.ChainID, err = uint64Of(tx.ChainId(), "chain id"); err != nil {
+			return nil, err
+		}
+	}
+	if t.Value, err = uint256Of(tx.Value(), "value"); err != nil {
+		return nil, err
+	}
+	t.MaxFeesPerGas = new(FeesPerGas)
+	if t.MaxFeesPerGas.Regular, err = uint256Of(tx.GasFeeCap(), "fee cap"); err != nil {
+		return nil, err
+	}
+	if txType >= types.AccessListTxType {
+		t.AccessList = []AccessTuple{}
+		for _, tuple := range tx.AccessList() {
+			t.AccessList = append(t.AccessList, AccessTuple{Address: tuple.Address, StorageKeys: append([]common.Hash{}, tuple.StorageKeys...)})
+		}
+	}
+	if txType >= types.DynamicFeeTxType {
+		t.MaxPriorityFeesPerGas = new(FeesPerGas)
+		if t.MaxPriorityFeesPerGas.Regular, err = uint256Of(tx.GasTipCap(), "tip cap"); err != nil {
+			return nil, err
+		}
+	}
+	if txType == types.BlobTxType {
+		if t.MaxFeesPerGas.Blob, err = uint256Of(tx.BlobGasFeeCap(), "blob fee cap"); err != nil {
+			return nil, err
+		}
+		// The RLP blob transactions have no blob priority fee.
+		t.MaxPriorityFeesPerGas.Blob = new(uint256.Int)
+		t.BlobVersionedHashes = append([]common.Hash{}, tx.BlobHashes()...)
+	}
+	if txType == types.SetCodeTxType {
+		t.AuthorizationList = []Authorization{}
+		for _, auth := range tx.SetCodeAuthorizations() {
+			t.AuthorizationList = append(t.AuthorizationList, newAuthorization(auth))
+		}
+	}
+	v, r, s := tx.RawSignatureValues()
+	if txType == types.LegacyTxType {
+		// The y parity is folded into v, along with the chain id if the
+		// transaction is replay protected.
+		v = new(big.Int).Sub(v, big.NewInt(27))
+		if tx.Protected() {
+			v.Sub(v, new(big.Int).Add(big.NewInt(8), new(big.Int).Lsh(tx.ChainId(), 1)))
+		}
+	}
+	if t.Signature.Secp256k1, err = secp256k1Signature(v, r, s); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func newAuthorization(auth types.SetCodeAuthorization) Authorization {
+	var (
+		magic   = uint8(0x05)
+		address = auth.Address
+		nonce   = auth.Nonce
+		a       = Authorization{Magic: &magic, Address: &address, Nonce: &nonce}
+		sig     [secp256k1SignatureSize]byte
+	)
+	if !auth.ChainID.IsZero() {
+		chainID := auth.ChainID.Uint64()
+		a.ChainID = &chainID
+	}
+	r, s := auth.R.Bytes32(), auth.S.Bytes32()
+	copy(sig[:32], r[:])
+	copy(sig[32:64], s[:])
+	sig[64] = auth.V
+	a.Signature.Secp256k1 = &sig
+	return a
+}
+
+// secp256k1Signature encodes the signature values of a transaction.
+func secp256k1Signature(yParity, r, s *big.Int) (*[secp256k1SignatureSize]byte, error) {
+	if yParity.Sign() < 0 || yParity.Cmp(big.NewInt(1)) > 0 {
+		return nil, fmt.Errorf("invalid signature y parity %v", yParity)
+	}
+	if r.Sign() < 0 || r.BitLen() > 256 || s.Sign() < 0 || s.BitLen() > 256 {
+		return nil, errors.New("invalid signature values")
+	}
+	var sig [secp256k1SignatureSize]byte
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[64] = byte(yParity.Uint64())
+	return &sig, nil
+}
+
+func uint64Of(n *big.Int, name string) (*uint64, error) {
+	if n.Sign() < 0 || !n.IsUint64() {
+		return nil, fmt.Errorf("%s %v is not a uint64", name, n)
+	}
+	v := n.Uint64()
+	return &v, nil
+}
+
+func uint256Of(n *big.Int, name string) (*uint256.Int, error) {
+	v, overflow := uint256.FromBig(n)
+	if overflow || n.Sign() < 0 {
+		return nil, fmt.Errorf("%s %v is not a uint256", name, n)
+	}
+	return v, nil
+}
+
+// RLPTransaction converts the SSZ form of a transaction back into the RLP
+// transaction it is the profile of.
+func RLPTransaction(t *Transaction) (*types.Transaction, error) {
+	if t.Type == nil {
+		return nil, errors.New("transaction without type")
+	}
+	profile, ok := txProfiles[*t.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported transaction type %d", *t.Type)
+	}
+	present := t.present()
+	for _, i := range profile.required {
+		if !present[i] {
+			return nil, fmt.Errorf("type %d transaction without field %d", *t.Type, i)
+		}
+		present[i] = false
+	}
+	for _, i := range profile.optional {
+		present[i] = false
+	}
+	for i, p := range present {
+		if p {
+			return nil, fmt.Errorf("type %d transaction with field %d", *t.Type, i)
+		}
+	}
+	for _, fees := range []*FeesPerGas{t.MaxFeesPerGas, t.MaxPriorityFeesPerGas} {
+		if fees != nil && (fees.Regular == nil || (fees.Blob != nil) != profile.blobFees) {
+			return nil, fmt.Errorf("type %d transaction with the fees of another type", *t.Type)
+		}
+	}
+	if profile.blobFees && !t.MaxPriorityFeesPerGas.Blob.IsZero() {
+		return nil, errors.New("blob transaction with a blob priority fee")
+	}
+	if t.Signature.Secp256k1 == nil {
+		return nil, errors.New("transaction without secp256k1 signature")
+	}
+	var (
+		sig     = t.Signature.Secp256k1
+		yParity = sig[64]
+		r       = new(big.Int).SetBytes(sig[:32])
+		s       = new(big.Int).SetBytes(sig[32:64])
+	)
+	if yParity > 1 {
+		return nil, fmt.Errorf("invalid signature y parity %d", yParity)
+	}
+	accessList := make(types.AccessList, len(t.AccessList))
+	for i, tuple := range t.AccessList {
+		accessList[i] = types.AccessTuple{Address: tuple.Address, StorageKeys: append([]common.Hash{}, tuple.StorageKeys...)}
+	}
+	var data types.TxData
+	switch *t.Type {
+	case types.LegacyTxType:
+		v := new(big.Int).SetUint64(uint64(yParity) + 27)
+		if t.ChainID != nil {
+			v.Add(v, new(big.Int).SetUint64(8+2**t.ChainID))
+		}
+		data = &types.LegacyTx{Nonce: *t.Nonce, GasPrice: t.MaxFeesPerGas.Regular.ToBig(), Gas: *t.Gas, To: t.To, Value: t.Value.ToBig(), Data: t.Input, V: v, R: r, S: s}
+	case types.AccessListTxType:
+		data = &types.AccessListTx{ChainID: new(big.Int).SetUint64(*t.ChainID), Nonce: *t.Nonce, GasPrice: t.MaxFeesPerGas.Regular.ToBig(), Gas: *t.Gas, To: t.To, Value: t.Value.ToBig(), Data: t.Input, AccessList: accessList,
+			V: big.NewInt(int64(yParity)), R: r, S: s}
+	case types.DynamicFeeTxType:
+		data = &types.DynamicFeeTx{ChainID: new(big.Int).SetUint64(*t.ChainID), Nonce: *t.Nonce, GasTipCap: t.MaxPriorityFeesPerGas.Regular.ToBig(), GasFeeCap: t.MaxFeesPerGas.Regular.ToBig(), Gas: *t.Gas, To: t.To, Value: t.Value.ToBig(), Data: t.Input, AccessList: accessList,
+			V: big.NewInt(int64(yParity)), R: r, S: s}
+	case types.BlobTxType:
+		data = &types.BlobTx{ChainID: uint256.NewInt(*t.ChainID), Nonce: *t.Nonce, GasTipCap: t.MaxPriorityFeesPerGas.Regular, GasFeeCap: t.MaxFeesPerGas.Regular, Gas: *t.Gas, To: *t.To, Value: t.Value, Data: t.Input, AccessList: accessList,
+			BlobFeeCap: t.MaxFeesPerGas.Blob, BlobHashes: t.BlobVersionedHashes, V: uint256.NewInt(uint64(yParity)), R: uint256.MustFromBig(r), S: uint256.MustFromBig(s)}
+	case types.SetCodeTxType:
+		auths := make([]types.SetCodeAuthorization, len(t.AuthorizationList))
+		for i, a := range t.AuthorizationList {
+			auth, err := rlpAuthorization(a)
+			if err != nil {
+				return nil, fmt.Errorf("authorization %d: %v", i, err)
+			}
+			auths[i] = auth
+		}
+		data = &types.SetCodeTx{ChainID: uint256.NewInt(*t.ChainID), Nonce: *t.Nonce, GasTipCap: t.MaxPriorityFeesPerGas.Regular, GasFeeCap: t.MaxFeesPerGas.Regular, Gas: *t.Gas, To: *t.To, Value: t.Value, Data: t.Input, AccessList: accessList,
+			AuthList: auths, V: uint256.NewInt(uint64(yParity)), R: uint256.MustFromBig(r), S: uint256.MustFromBig(s)}
+	}
+	return types.NewTx(data), nil
+}
+
+func rlpAuthorization(a Authorization) (types.SetCodeAuthorization, error) {
+	switch {
+	case a.Magic == nil || *a.Magic != 0x05:
+		return types.SetCodeAuthorization{}, errors.New("missing or invalid magic")
+	case a.Address == nil || a.Nonce == nil:
+		return types.SetCodeAuthorization{}, errors.New("missing address or nonce")
+	case a.Signature.Secp256k1 == nil:
+		return types.SetCodeAuthorization{}, errors.New("missing secp256k1 signature")
+	}
+	sig := a.Signature.Secp256k1
+	auth := types.SetCodeAuthorization{Address: *a.Address, Nonce: *a.Nonce, V: sig[64]}
+	if a.ChainID != nil {
+		auth.ChainID.SetUint64(*a.ChainID)
+	}
+	auth.R.SetBytes32(sig[:32])
+	auth.S.SetBytes32(sig[32:64])
+	return auth, nil
+}
+
+// present reports which fields of the TransactionPayload are present.
+func (t *Transaction) present() [txFields]bool {
+	return [txFields]bool{
+		t.Type != nil, t.ChainID != nil, t.Nonce != nil, t.MaxFeesPerGas != nil, t.Gas != nil, t.To != nil, t.Value != nil,
+		t.Input != nil, t.AccessList != nil, t.MaxPriorityFeesPerGas != nil, t.BlobVersionedHashes != nil, t.AuthorizationList != nil,
+	}
+}
+
+// parts returns the fields of the transaction as the payload and signature
+// of the Transaction container.
+func (t *Transaction) parts() []part {
+	fields := make([]*part, txFields)
+	set := func(i int, p part) { fields[i] = &p }
+	if t.Type != nil {
+		set(txTypeField, uint8Part(*t.Type))
+	}
+	if t.ChainID != nil {
+		set(txChainIDField, uint64Part(*t.ChainID))
+	}
+	if t.Nonce != nil {
+		set(txNonceField, uint64Part(*t.Nonce))
+	}
+	if t.MaxFeesPerGas != nil {
+		set(txMaxFeesField, t.MaxFeesPerGas.part())
+	}
+	if t.Gas != nil {
+		set(txGasField, uint64Part(*t.Gas))
+	}
+	if t.To != nil {
+		set(txToField, addressPart(*t.To))
+	}
+	if t.Value != nil {
+		set(txValueField, uint256Part(t.Value))
+	}
+	if t.Input != nil {
+		set(txInputField, byteListPart(t.Input, MaxCalldataSize))
+	}
+	if t.AccessList != nil {
+		tuples := make([]part, len(t.AccessList))
+		for i, tuple := range t.AccessList {
+			tuples[i] = tuple.part()
+		}
+		set(txAccessListField, variableListPart(tuples, MaxAccessListSize))
+	}
+	if t.MaxPriorityFeesPerGas != nil {
+		set(txMaxPriorityFeesField, t.MaxPriorityFeesPerGas.part())
+	}
+	if t.BlobVersionedHashes != nil {
+		set(txBlobHashesField, hashListPart(t.BlobVersionedHashes, MaxBlobCommitmentsPerBlock))
+	}
+	if t.AuthorizationList != nil {
+		auths := make([]part, len(t.AuthorizationList))
+		for i, auth := range t.AuthorizationList {
+			auths[i] = auth.part()
+		}
+		set(txAuthorizationsField, variableListPart(auths, MaxAuthorizationListSize))
+	}
+	return []part{stablePart(MaxTransactionPayloadFields, fields), t.Signature.part()}
+}
+
+func (f *FeesPerGas) part() part {
+	fields := make([]*part, 2)
+	if f.Regular != nil {
+		p := uint256Part(f.Regular)
+		fields[0] = &p
+	}
+	if f.Blob != nil {
+		p := uint256Part(f.Blob)
+		fields[1] = &p
+	}
+	return stablePart(MaxFeesPerGasFields, fields)
+}
+
+func (a *AccessTuple) part() part {
+	keys := hashListPart(a.StorageKeys, MaxAccessListStorageKeys)
+	return containerPart([]part{addressPart(a.Address), keys})
+}
+
+func (a *Authorization) part() part {
+	fields := make([]*part, 4)
+	set := func(i int, p part) { fields[i] = &p }
+	if a.Magic != nil {
+		set(0, uint8Part(*a.Magic))
+	}
+	if a.ChainID != nil {
+		set(1, uint64Part(*a.ChainID))
+	}
+	if a.Address != nil {
+		set(2, addressPart(*a.Address))
+	}
+	if a.Nonce != nil {
+		set(3, uint64Part(*a.Nonce))
+	}
+	return containerPart([]part{stablePart(MaxAuthorizationPayloadFields, fields), a.Signature.part()})
+}
+
+func (s *ExecutionSignature) part() part {
+	fields := make([]*part, 1)
+	if s.Secp256k1 != nil {
+		fields[0] = &part{enc: s.Secp256k1[:], root: merkleize(packBytes(s.Secp256k1[:]), 3)}
+	}
+	return stablePart(MaxExecutionSignatureFields, fields)
+}
+
+// containerPart returns a container of the fields as a field of another
+// container or an item of a list.
+func containerPart(fields []part) part {
+	roots := make([]common.Hash, len(fields))
+	variable := false
+	for i, f := range fields {
+		roots[i] = f.root
+		variable = variable || f.variable
+	}
+	return part{enc: encodeParts(fields), variable: variable, root: merkleize(roots, uint64(len(fields)))}
+}
+
+// variableListPart returns a list of variable-size items.
+func variableListPart(items []part, limit uint64) part {
+	roots := make([]common.Hash, len(items))
+	for i, item := range items {
+		roots[i] = item.root
+	}
+	return part{enc: encodeParts(items), variable: true, root: listRoot(roots, limit)}
+}
+
+// hashListPart returns a list of 32 byte vectors.
+func hashListPart(hashes []common.Hash, limit uint64) part {
+	enc := make([]byte, 0, len(hashes)*common.HashLength)
+	for _, h := range hashes {
+		enc = append(enc, h[:]...)
+	}
+	return part{enc: enc, variable: true, root: listRoot(hashes, limit)}
+}
+
+// EncodeTransaction returns the SSZ encoding of the Transaction container.
+func EncodeTransaction(t *Transaction) []byte {
+	return encodeParts(t.parts())
+}
+
+// TransactionRoot returns the hash tree root of the Transaction container.
+func TransactionRoot(t *Transaction) common.Hash {
+	return containerPart(t.parts()).root
+}
+
+// DecodeTransaction decodes an SSZ encoded Transaction container. It is not
+// checked against the profiles of the RLP transactions, RLPTransaction does.
+func DecodeTransaction(enc []byte) (*Transaction, error) {
+	parts, err := decodeParts(enc, []int{0, 0})
+	if err != nil {
+		return nil, err
+	}
+	fields, err := decodeStable(parts[0], MaxTransactionPayloadFields, []int{1, 8, 8, 0, 8, common.AddressLength, 32, 0, 0, 0, 0, 0})
+	if err != nil {
+		return nil, fmt.Errorf("payload: %v", err)
+	}
+	t := new(Transaction)
+	if f := fields[txTypeField]; f != nil {
+		t.Type = &f[0]
+	}
+	for _, field := range []struct {
+		index int
+		dst   **uint64
+	}{{txChainIDField, &t.ChainID}, {txNonceField, &t.Nonce}, {txGasField, &t.Gas}} {
+		if f := fields[field.index]; f != nil {
+			v := decodeUint64(f)
+			*field.dst = &v
+		}
+	}
+	if f := fields[txToField]; f != nil {
+		to := common.BytesToAddress(f)
+		t.To = &to
+	}
+	if f := fields[txValueField]; f != nil {
+		t.Value = decodeUint256(f)
+	}
+	if f := fields[txInputField]; f != nil {
+		if len(f) > MaxCalldataSize {
+			return nil, fmt.Errorf("input of %d bytes exceeds %d", len(f), MaxCalldataSize)
+		}
+		t.Input = append([]byte{}, f...)
+	}
+	if f := fields[txMaxFeesField]; f != nil {
+		if t.MaxFeesPerGas, err = decodeFeesPerGas(f); err != nil {
+			return nil, fmt.Errorf("max fees per gas: %v", err)
+		}
+	}
+	if f := fields[txMaxPriorityFeesField]; f != nil {
+		if t.MaxPriorityFeesPerGas, err = decodeFeesPerGas(f); err != nil {
+			return nil, fmt.Errorf("max priority fees per gas: %v", err)
+		}
+	}
+	if f := fields[txAccessListField]; f != nil {
+		if t.AccessList, err = decodeAccessList(f); err != nil {
+			return nil, fmt.Errorf("access list: %v", err)
+		}
+	}
+	if f := fields[txBlobHashesField]; f != nil {
+		if t.BlobVersionedHashes, err = decodeHashList(f, MaxBlobCommitmentsPerBlock); err != nil {
+			return nil, fmt.Errorf("blob versioned hashes: %v", err)
+		}
+	}
+	if f := fields[txAuthorizationsField]; f != nil {
+		items, err := splitList(f, MaxAuthorizationListSize)
+		if err != nil {
+			return nil, fmt.Errorf("authorization list: %v", err)
+		}
+		t.AuthorizationList = make([]Authorization, len(items))
+		for i, item := range items {
+			if t.AuthorizationList[i], err = decodeAuthorization(item); err != nil {
+				return nil, fmt.Errorf("authorization %d: %v", i, err)
+			}
+		}
+	}
+	if t.Signature, err = decodeSignature(parts[1]); err != nil {
+		return nil, fmt.Errorf("signature: %v", err)
+	}
+	return t, nil
+}
+
+func decodeFeesPerGas(enc []byte) (*FeesPerGas, error) {
+	fields, err := decodeStable(enc, MaxFeesPerGasFields, []int{32, 32})
+	if err != nil {
+		return nil, err
+	}
+	fees := new(FeesPerGas)
+	if fields[0] != nil {
+		fees.Regular = decodeUint256(fields[0])
+	}
+	if fields[1] != nil {
+		fees.Blob = decodeUint256(fields[1])
+	}
+	return fees, nil
+}
+
+func decodeAccessList(enc []byte) ([]AccessTuple, error) {
+	items, err := splitList(enc, MaxAccessListSize)
+	if err != nil {
+		return nil, err
+	}
+	tuples := make([]AccessTuple, len(items))
+	for i, item := range items {
+		parts, err := decodeParts(item, []int{common.AddressLength, 0})
+		if err != nil {
+			return nil, fmt.Errorf("tuple %d: %v", i, err)
+		}
+		tuples[i].Address = common.BytesToAddress(parts[0])
+		if tuples[i].StorageKeys, err = decodeHashList(parts[1], MaxAccessListStorageKeys); err != nil {
+			return nil, fmt.Errorf("tuple %d: %v", i, err)
+		}
+	}
+	return tuples, nil
+}
+
+func decodeHashList(enc []byte, limit int) ([]common.Hash, error) {
+	items, err := splitFixed(enc, common.HashLength, limit)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]common.Hash, len(items))
+	for i, item := range items {
+		hashes[i] = common.BytesToHash(item)
+	}
+	return hashes, nil
+}
+
+func decodeAuthorization(enc []byte) (Authorization, error) {
+	var a Authorization
+	parts, err := decodeParts(enc, []int{0, 0})
+	if err != nil {
+		return a, err
+	}
+	fields, err := decodeStable(parts[0], MaxAuthorizationPayloadFields, []int{1, 8, common.AddressLength, 8})
+	if err != nil {
+		return a, err
+	}
+	if f := fields[0]; f != nil {
+		a.Magic = &f[0]
+	}
+	if f := fields[1]; f != nil {
+		chainID := decodeUint64(f)
+		a.ChainID = &chainID
+	}
+	if f := fields[2]; f != nil {
+		address := common.BytesToAddress(f)
+		a.Address = &address
+	}
+	if f := fields[3]; f != nil {
+		nonce := decodeUint64(f)
+		a.Nonce = &nonce
+	}
+	a.Signature, err = decodeSignature(parts[1])
+	return a, err
+}
+
+func decodeSignature(enc []byte) (ExecutionSignature, error) {
+	var s ExecutionSignature
+	fields, err := decodeStable(enc, MaxExecutionSignatureFields, []int{secp256k1SignatureSize})
+	if err != nil {
+		return s, err
+	}
+	if fields[0] != nil {
+		s.Secp256k1 = new([secp256k1SignatureSize]byte)
+		copy(s.Secp256k1[:], fields[0])
+	}
+	return s, nil
+}
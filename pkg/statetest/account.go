@@ -0,0 +1,88 @@
+package statetest
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Account is the expected post-state of an account. Nil fields are not
+// checked, the code of an account without code is empty but not nil.
+type Account struct {
+	Absent  bool                        // the account must not exist
+	Balance *big.Int                    // the exact balance
+	Nonce   *uint64                     // the exact nonce
+	Code    []byte                      // the exact code
+	AnyCode bool                        // the account must have code
+	Storage map[common.Hash]common.Hash // the slots checked, zero if cleared
+}
+
+// CheckAccount verifies the expected post-state of an account. Any account
+// which is not absent must exist.
+func CheckAccount(statedb *state.StateDB, addr common.Address, want Account) error {
+	if want.Absent {
+		if statedb.Exist(addr) {
+			return fmt.Errorf("account %s should not exist", addr.Hex())
+		}
+		return nil
+	}
+	if !statedb.Exist(addr) {
+		return fmt.Errorf("account %s does not exist", addr.Hex())
+	}
+	if want.Balance != nil {
+		if balance := statedb.GetBalance(addr).ToBig(); balance.Cmp(want.Balance) != 0 {
+			return fmt.Errorf("account %s: balance %v, expected %v", addr.Hex(), balance, want.Balance)
+		}
+	}
+	if want.Nonce != nil {
+		if nonce := statedb.GetNonce(addr); nonce != *want.Nonce {
+			return fmt.Errorf("account %s: nonce %d, expected %d", addr.Hex(), nonce, *want.Nonce)
+		}
+	}
+	code := statedb.GetCode(addr)
+	if want.Code != nil && !bytes.Equal(code, want.Code) {
+		return fmt.Errorf("account %s: code %x, expected %x", addr.Hex(), code, want.Code)
+	}
+	if want.AnyCode && len(code) == 0 {
+		return fmt.Errorf("account %s: has no code", addr.Hex())
+	}
+	for slot, value := range want.Storage {
+		if have := statedb.GetState(addr, slot); have != value {
+			return fmt.Errorf("account %s: storage slot %s is %s, expected %s", addr.Hex(), slot.Hex(), have.Hex(), value.Hex())
+		}
+	}
+	return nil
+}
+
+// DumpAccount returns the post-state of an account with the nonzero values of
+// the given storage slots, the only ones the vector writes.
+func DumpAccount(statedb *state.StateDB, addr common.Address, slots ...common.Hash) types.Account {
+	account := types.Account{
+		Balance: statedb.GetBalance(addr).ToBig(),
+		Nonce:   statedb.GetNonce(addr),
+		Code:    statedb.GetCode(addr),
+		Storage: make(map[common.Hash]common.Hash),
+	}
+	for _, slot := range slots {
+		if value := statedb.GetState(addr, slot); value != (common.Hash{}) {
+			account.Storage[slot] = value
+		}
+	}
+	return account
+}
+
+// DumpState returns the post-state of the accounts which exist, with the
+// nonzero values of the given storage slots.
+func DumpState(statedb *state.StateDB, addrs []common.Address, slots ...common.Hash) types.GenesisAlloc {
+	alloc := make(types.GenesisAlloc)
+	for _, addr := range addrs {
+		if statedb.Exist(addr) {
+			alloc[addr] = DumpAccount(statedb, addr, slots...)
+		}
+	}
+	return alloc
+}
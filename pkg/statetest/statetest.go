@@ -0,0 +1,245 @@
+// Package statetest builds the state test fixtures of the vector generators
+// from a pre-state and a single signed transaction.
+//
+// The transaction is executed on every fork by go-ethereum's state test
+// runner, which fills in the state root and logs hash of each post-state. The
+// generator checks the outcome of each fork against the expectations of its
+// vector, from the gas used and the post-state handed to it, before anything
+// is written.
+package statetest
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/execution-specs/pkg/txbuilder"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/tests"
+	"github.com/ethereum/go-ethereum/version"
+)
+
+// Fixture is a state_tests fixture.
+type Fixture struct {
+	Info        map[string]string       `json:"_info"`
+	Env         *Env                    `json:"env"`
+	Pre         types.GenesisAlloc      `json:"pre"`
+	Transaction *Transaction            `json:"transaction"`
+	Post        map[string][]*PostState `json:"post"`
+}
+
+// Env is the block environment of a fixture.
+type Env struct {
+	Coinbase      common.Address `json:"currentCoinbase"`
+	Difficulty    *hexutil.Big   `json:"currentDifficulty"`
+	Random        common.Hash    `json:"currentRandom"`
+	GasLimit      hexutil.Uint64 `json:"currentGasLimit"`
+	Number        hexutil.Uint64 `json:"currentNumber"`
+	Timestamp     hexutil.Uint64 `json:"currentTimestamp"`
+	BaseFee       *hexutil.Big   `json:"currentBaseFee"`
+	ExcessBlobGas hexutil.Uint64 `json:"currentExcessBlobGas"`
+}
+
+// DefaultEnv returns the block environment shared by the generators: block 1
+// at timestamp 1000 with a gas limit of 30M, mined by the coinbase at the
+// given base fee. Each generator passes its own prevrandao.
+func DefaultEnv(coinbase common.Address, random common.Hash, baseFee int64) *Env {
+	return &Env{
+		Coinbase:   coinbase,
+		Difficulty: (*hexutil.Big)(big.NewInt(0x20000)),
+		Random:     random,
+		GasLimit:   30000000,
+		Number:     1,
+		Timestamp:  1000,
+		BaseFee:    (*hexutil.Big)(big.NewInt(baseFee)),
+	}
+}
+
+// Transaction is the transaction of a fixture, with a single variant of each
+// of the data, gas limit and value. The recipient of a creation is empty, and
+// the authorization list is only set for set code transactions.
+type Transaction struct {
+	Nonce                hexutil.Uint64                     `json:"nonce"`
+	To                   string                             `json:"to"`
+	Data                 []hexutil.Bytes                    `json:"data"`
+	GasLimit             []hexutil.Uint64                   `json:"gasLimit"`
+	Value                []*hexutil.Big                     `json:"value"`
+	GasPrice             *hexutil.Big                       `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big                       `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big                       `json:"maxPriorityFeePerGas,omitempty"`
+	AccessLists          []types.AccessList                 `json:"accessLists,omitempty"`
+	AuthorizationList    *[]*txbuilder.FixtureAuthorization `json:"authorizationList,omitempty"`
+	SecretKey            hexutil.Bytes                      `json:"secretKey"`
+	Sender               common.Address                     `json:"sender"`
+}
+
+// PostState is the expected outcome of the transaction on one fork. State
+// holds the accounts touched by the vector and GasUsed the gas used, if the
+// generator declares them.
+type PostState struct {
+	Hash            common.Hash        `json:"hash"`
+	Logs            common.Hash        `json:"logs"`
+	TxBytes         hexutil.Bytes      `json:"txbytes"`
+	Indexes         PostIndexes        `json:"indexes"`
+	State           types.GenesisAlloc `json:"state,omitempty"`
+	GasUsed         hexutil.Uint64     `json:"gasUsed,omitempty"`
+	ExpectException string             `json:"expectException,omitempty"`
+}
+
+// PostIndexes selects the variant of the data, gas limit and value of the
+// transaction a post-state is for.
+type PostIndexes struct {
+	Data  int `json:"data"`
+	Gas   int `json:"gas"`
+	Value int `json:"value"`
+}
+
+// Result is the outcome of the transaction of a fixture on a fork.
+type Result struct {
+	Post    *PostState     // the post-state entry, with its root and logs hash
+	State   *state.StateDB // the post-state
+	GasUsed uint64         // zero for a rejected transaction
+}
+
+// Info returns the _info section of a fixture filled by the named tool from
+// the source, "handcrafted" for the vector generators. An empty source or
+// description is left out.
+func Info(tool, source, description string) map[string]string {
+	info := map[string]string{
+		"filling-tool": fmt.Sprintf("execution-specs %s, go-ethereum v%d.%d.%d", tool, version.Major, version.Minor, version.Patch),
+	}
+	if source != "" {
+		info["source"] = source
+	}
+	if description != "" {
+		info["description"] = description
+	}
+	return info
+}
+
+// New returns the fixture of a transaction signed by the key, with an empty
+// post-state entry for each fork. The info section is left to the caller.
+func New(env *Env, pre types.GenesisAlloc, tx *types.Transaction, key *ecdsa.PrivateKey, forks []string) (*Fixture, error) {
+	transaction, err := NewTransaction(tx, key)
+	if err != nil {
+		return nil, err
+	}
+	txBytes, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	f := &Fixture{
+		Env:         env,
+		Pre:         pre,
+		Transaction: transaction,
+		Post:        make(map[string][]*PostState),
+	}
+	for _, fork := range forks {
+		f.Post[fork] = []*PostState{{TxBytes: txBytes}}
+	}
+	return f, nil
+}
+
+// NewTransaction converts a transaction signed by the key into the fixture
+// representation of state tests.
+func NewTransaction(tx *types.Transaction, key *ecdsa.PrivateKey) (*Transaction, error) {
+	ftx, err := txbuilder.NewFixtureTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	if sender := crypto.PubkeyToAddress(key.PublicKey); ftx.Sender != sender {
+		return nil, fmt.Errorf("transaction signed by %s, not by the key of %s", ftx.Sender.Hex(), sender.Hex())
+	}
+	t := &Transaction{
+		Nonce:                ftx.Nonce,
+		To:                   ftx.To,
+		Data:                 []hexutil.Bytes{ftx.Data},
+		GasLimit:             []hexutil.Uint64{ftx.GasLimit},
+		Value:                []*hexutil.Big{ftx.Value},
+		GasPrice:             ftx.GasPrice,
+		MaxFeePerGas:         ftx.MaxFeePerGas,
+		MaxPriorityFeePerGas: ftx.MaxPriorityFeePerGas,
+		SecretKey:            crypto.FromECDSA(key),
+		Sender:               ftx.Sender,
+	}
+	if ftx.AccessList != nil {
+		t.AccessLists = []types.AccessList{*ftx.AccessList}
+	}
+	if tx.Type() == types.SetCodeTxType {
+		auths := ftx.AuthorizationList
+		if auths == nil {
+			auths = []*txbuilder.FixtureAuthorization{}
+		}
+		t.AuthorizationList = &auths
+	}
+	return t, nil
+}
+
+// Fill executes the transaction of the fixture on each fork of its
+// post-states, in the order of their names. The transaction must be rejected
+// exactly on the forks whose entry expects an exception, leaving the state
+// untouched. Check is then handed the outcome to verify it and add the gas
+// used or touched accounts to the entry.
+func (f *Fixture) Fill(check func(fork string, r *Result) error) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	var test tests.StateTest
+	if err := json.Unmarshal(data, &test); err != nil {
+		return err
+	}
+	forks := make([]string, 0, len(f.Post))
+	for fork := range f.Post {
+		forks = append(forks, fork)
+	}
+	sort.Strings(forks)
+	for _, fork := range forks {
+		if err := run(&test, fork, f.Post[fork][0], check); err != nil {
+			return fmt.Errorf("%s: %v", fork, err)
+		}
+	}
+	return nil
+}
+
+// run executes the state test on a fork, fills in the root and logs hash of
+// the post-state and checks the outcome.
+func run(test *tests.StateTest, fork string, post *PostState, check func(fork string, r *Result) error) error {
+	st, root, gasUsed, err := test.RunNoVerify(tests.StateSubtest{Fork: fork}, vm.Config{}, false, rawdb.HashScheme)
+	defer st.Close()
+
+	switch {
+	case err != nil && post.ExpectException == "":
+		return fmt.Errorf("unexpected exception: %v", err)
+	case err == nil && post.ExpectException != "":
+		return fmt.Errorf("expected exception %s, transaction succeeded", post.ExpectException)
+	case err != nil:
+		root = st.StateDB.IntermediateRoot(true)
+		post.Logs = LogsHash(nil)
+		gasUsed = 0
+	default:
+		post.Logs = LogsHash(st.StateDB.Logs())
+	}
+	post.Hash = root
+
+	statedb, err := state.New(root, st.StateDB.Database())
+	if err != nil {
+		return err
+	}
+	return check(fork, &Result{Post: post, State: statedb, GasUsed: gasUsed})
+}
+
+// LogsHash computes the hash of the RLP encoded logs, as state tests do.
+func LogsHash(logs []*types.Log) common.Hash {
+	data, _ := rlp.EncodeToBytes(logs)
+	return crypto.Keccak256Hash(data)
+}
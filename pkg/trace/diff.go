@@ -0,0 +1,119 @@
+package trace
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Divergence describes the first step at which two traces differ.
+type Divergence struct {
+	Index int    // index of the step, equal to the length of the shorter trace if one ends early
+	Field string // name of the first differing field, or "length"
+}
+
+// Diff compares two normalized traces step by step and returns their first
+// divergence, or nil if they are identical.
+func Diff(a, b []Step) *Divergence {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if field := StepDifference(&a[i], &b[i]); field != "" {
+			return &Divergence{Index: i, Field: field}
+		}
+	}
+	if len(a) != len(b) {
+		return &Divergence{Index: min(len(a), len(b)), Field: "length"}
+	}
+	return nil
+}
+
+// StepDifference names the first field in which two steps differ, checking
+// the fields in the order a mismatch is most telling, or returns "" if they
+// are equal. Stacks are only compared if both steps carry one, so that traces
+// with and without stacks can be compared.
+func StepDifference(a, b *Step) string {
+	switch {
+	case a.Depth != b.Depth:
+		return "depth"
+	case a.Pc != b.Pc:
+		return "pc"
+	case a.Op != b.Op:
+		return "op"
+	case a.Gas != b.Gas:
+		return "gas"
+	case a.GasCost != b.GasCost:
+		return "gasCost"
+	case a.Stack != nil && b.Stack != nil && !slices.Equal(a.Stack, b.Stack):
+		return "stack"
+	case a.Memory != "" && b.Memory != "" && a.Memory != b.Memory:
+		return "memory"
+	case a.ReturnData != "" && b.ReturnData != "" && a.ReturnData != b.ReturnData:
+		return "returnData"
+	case a.Refund != b.Refund:
+		return "refund"
+	case a.Error != b.Error:
+		return "error"
+	}
+	return ""
+}
+
+// Field renders the named field of a step.
+func (s *Step) Field(name string) string {
+	switch name {
+	case "depth":
+		return fmt.Sprint(s.Depth)
+	case "pc":
+		return fmt.Sprint(s.Pc)
+	case "op":
+		return s.Name()
+	case "gas":
+		return fmt.Sprint(s.Gas)
+	case "gasCost":
+		return fmt.Sprint(s.GasCost)
+	case "stack":
+		return "[" + strings.Join(s.Stack, ",") + "]"
+	case "memory":
+		return s.Memory
+	case "returnData":
+		return s.ReturnData
+	case "refund":
+		return fmt.Sprint(s.Refund)
+	case "error":
+		return s.Error
+	}
+	return s.String()
+}
+
+// PrintDiff writes the steps around a divergence side by side, with up to
+// context steps before it. The divergent step is marked with '>'.
+func PrintDiff(w io.Writer, a, b []Step, d *Divergence, context int) {
+	start := max(d.Index-context, 0)
+	end := min(d.Index+1, max(len(a), len(b)))
+
+	lines := make([][2]string, 0, end-start)
+	width := 0
+	for i := start; i < end; i++ {
+		line := [2]string{stepLine(a, i), stepLine(b, i)}
+		width = max(width, len(line[0]))
+		lines = append(lines, line)
+	}
+	for i, line := range lines {
+		marker := " "
+		if start+i == d.Index {
+			marker = ">"
+		}
+		fmt.Fprintf(w, "%s %6d  %-*s | %s\n", marker, start+i, width, line[0], line[1])
+	}
+	if d.Field != "length" {
+		fmt.Fprintf(w, "\n%s differs at step %d: %s vs %s\n", d.Field, d.Index, a[d.Index].Field(d.Field), b[d.Index].Field(d.Field))
+	} else {
+		fmt.Fprintf(w, "\ntraces differ in length: %d vs %d steps\n", len(a), len(b))
+	}
+}
+
+func stepLine(steps []Step, i int) string {
+	if i >= len(steps) {
+		return "(end of trace)"
+	}
+	return steps[i].String()
+}
@@ -0,0 +1,56 @@
+package trace
+
+import (
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// jsonOutStep is the canonical encoding of a step: the number types of
+// EIP-3155, with gas values as hex strings and stack items as minimal hex
+// strings. Fields a step does not carry are omitted.
+type jsonOutStep struct {
+	Pc         uint64         `json:"pc"`
+	Op         uint64         `json:"op"`
+	Gas        hexutil.Uint64 `json:"gas"`
+	GasCost    hexutil.Uint64 `json:"gasCost"`
+	MemSize    uint64         `json:"memSize"`
+	Memory     string         `json:"memory,omitempty"`
+	Stack      *[]string      `json:"stack,omitempty"` // empty stacks are kept
+	ReturnData string         `json:"returnData,omitempty"`
+	Depth      int            `json:"depth"`
+	Refund     uint64         `json:"refund"`
+	OpName     string         `json:"opName,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes the step as a canonical trace line, so that the
+// normalized traces of different clients are byte for byte comparable.
+func (s Step) MarshalJSON() ([]byte, error) {
+	out := jsonOutStep{
+		Pc:         s.Pc,
+		Op:         s.Op,
+		Gas:        hexutil.Uint64(s.Gas),
+		GasCost:    hexutil.Uint64(s.GasCost),
+		MemSize:    s.MemSize,
+		Memory:     s.Memory,
+		ReturnData: s.ReturnData,
+		Depth:      s.Depth,
+		Refund:     s.Refund,
+		OpName:     s.OpName,
+		Error:      s.Error,
+	}
+	if s.Stack != nil {
+		out.Stack = &s.Stack
+	}
+	return json.Marshal(out)
+}
+
+// MarshalJSON encodes the summary as a canonical trace line.
+func (s Summary) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Output  string         `json:"output"`
+		GasUsed hexutil.Uint64 `json:"gasUsed"`
+		Error   string         `json:"error,omitempty"`
+	}{s.Output, hexutil.Uint64(s.GasUsed), s.Error})
+}
@@ -0,0 +1,323 @@
+// Package trace parses, normalizes and compares EIP-3155 execution traces.
+//
+// Clients differ in how they encode the trace fields: gas values are hex
+// strings or plain numbers, stack items may be zero padded, and memory and
+// return data are only included on request. Parsed steps are normalized so
+// that traces of different clients can be compared directly.
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// Step is a single normalized trace line. Numbers are decoded, stack items are
+// minimal hex strings and memory and return data are lowercase hex.
+type Step struct {
+	Pc         uint64
+	Op         uint64
+	OpName     string
+	Gas        uint64
+	GasCost    uint64
+	MemSize    uint64
+	Memory     string
+	Stack      []string
+	ReturnData string
+	Depth      int
+	Refund     uint64
+	Error      string
+}
+
+// Summary is the line a client prints after the last step of a transaction.
+type Summary struct {
+	Output  string
+	GasUsed uint64
+	Error   string
+}
+
+// Trace is a parsed trace, possibly spanning several transactions.
+type Trace struct {
+	Steps      []Step
+	Summaries  []Summary
+	StateRoots []string // reported by statetest runners after each test
+}
+
+// Options selects the fields stripped when normalizing a trace, for comparing
+// against clients that do not report them.
+type Options struct {
+	NoMemory     bool
+	NoReturnData bool
+	NoStack      bool
+	NoRefund     bool
+
+	// CollapseRefunds reports the refund counter of the steps of the
+	// opcodes changing it, SSTORE and SELFDESTRUCT, as it was before them.
+	// Clients differ in whether these steps carry the counter before or
+	// after their refund, which makes the steps, not the refunds, differ.
+	CollapseRefunds bool
+}
+
+// The opcodes changing the refund counter.
+const (
+	sstore       = 0x55
+	selfdestruct = 0xff
+)
+
+// jsonStep is the wire encoding of a trace line.
+type jsonStep struct {
+	Pc         math.HexOrDecimal64 `json:"pc"`
+	Op         json.RawMessage     `json:"op"`
+	OpName     string              `json:"opName"`
+	Gas        math.HexOrDecimal64 `json:"gas"`
+	GasCost    math.HexOrDecimal64 `json:"gasCost"`
+	MemSize    math.HexOrDecimal64 `json:"memSize"`
+	Memory     string              `json:"memory"`
+	Stack      []json.RawMessage   `json:"stack"`
+	ReturnData string              `json:"returnData"`
+	Depth      int                 `json:"depth"`
+	Refund     math.HexOrDecimal64 `json:"refund"`
+	Error      string              `json:"error"`
+
+	// Summary fields.
+	Output    *string              `json:"output"`
+	GasUsed   *math.HexOrDecimal64 `json:"gasUsed"`
+	StateRoot *string              `json:"stateRoot"`
+}
+
+// ParseStep decodes a single trace step.
+func ParseStep(line []byte) (Step, error) {
+	var js jsonStep
+	if err := json.Unmarshal(line, &js); err != nil {
+		return Step{}, err
+	}
+	if js.Op == nil {
+		return Step{}, fmt.Errorf("not a trace step")
+	}
+	return js.step()
+}
+
+func (js *jsonStep) step() (Step, error) {
+	step := Step{
+		Pc:         uint64(js.Pc),
+		OpName:     js.OpName,
+		Gas:        uint64(js.Gas),
+		GasCost:    uint64(js.GasCost),
+		MemSize:    uint64(js.MemSize),
+		Memory:     normalizeBytes(js.Memory),
+		ReturnData: normalizeBytes(js.ReturnData),
+		Depth:      js.Depth,
+		Refund:     uint64(js.Refund),
+		Error:      js.Error,
+	}
+	// The opcode is a number for most clients, but some emit its hex string.
+	var op math.HexOrDecimal64
+	if err := op.UnmarshalJSON(js.Op); err != nil {
+		return Step{}, fmt.Errorf("invalid op %s", js.Op)
+	}
+	step.Op = uint64(op)
+	if js.Stack != nil {
+		step.Stack = make([]string, len(js.Stack))
+		for i, item := range js.Stack {
+			value, err := parseWord(item)
+			if err != nil {
+				return Step{}, fmt.Errorf("invalid stack item %s", item)
+			}
+			step.Stack[i] = value
+		}
+	}
+	return step, nil
+}
+
+// Parse reads a trace from r. Every line holding a JSON object is decoded as
+// a step, a transaction summary or a state root report; other lines, such as
+// client log output, are skipped.
+func Parse(r io.Reader) (*Trace, error) {
+	trace := new(Trace)
+	scanner := NewScanner(r)
+	for scanner.Scan() {
+		if step := scanner.Step(); step != nil {
+			trace.Steps = append(trace.Steps, *step)
+		}
+		if summary := scanner.Summary(); summary != nil {
+			trace.Summaries = append(trace.Summaries, *summary)
+		}
+		if root := scanner.StateRoot(); root != "" {
+			trace.StateRoots = append(trace.StateRoots, root)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// Scanner reads a trace line by line, for traces too large to be held in
+// memory. Lines which are neither steps, summaries nor state root reports
+// are skipped.
+type Scanner struct {
+	scanner *bufio.Scanner
+	line    int
+	err     error
+
+	step      *Step
+	summary   *Summary
+	stateRoot string
+}
+
+// NewScanner returns a scanner reading the trace from r.
+func NewScanner(r io.Reader) *Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	return &Scanner{scanner: scanner}
+}
+
+// Scan advances to the next line holding a step, a summary or a state root,
+// and reports whether there is one.
+func (s *Scanner) Scan() bool {
+	s.step, s.summary, s.stateRoot = nil, nil, ""
+	for s.err == nil && s.scanner.Scan() {
+		s.line++
+		text := bytes.TrimSpace(s.scanner.Bytes())
+		if !bytes.HasPrefix(text, []byte("{")) {
+			continue
+		}
+		var js jsonStep
+		if err := json.Unmarshal(text, &js); err != nil {
+			continue
+		}
+		switch {
+		case js.Op != nil:
+			step, err := js.step()
+			if err != nil {
+				s.err = fmt.Errorf("line %d: %v", s.line, err)
+				return false
+			}
+			s.step = &step
+		case js.Output != nil || js.GasUsed != nil:
+			summary := Summary{Error: js.Error}
+			if js.Output != nil {
+				summary.Output = normalizeBytes(*js.Output)
+			}
+			if js.GasUsed != nil {
+				summary.GasUsed = uint64(*js.GasUsed)
+			}
+			s.summary = &summary
+		}
+		// Some clients report the state root in the summary line, others on
+		// a line of its own.
+		if js.Op == nil && js.StateRoot != nil {
+			s.stateRoot = strings.ToLower(*js.StateRoot)
+		}
+		if s.step != nil || s.summary != nil || s.stateRoot != "" {
+			return true
+		}
+	}
+	if s.err == nil {
+		s.err = s.scanner.Err()
+	}
+	return false
+}
+
+// Step returns the step of the current line, or nil.
+func (s *Scanner) Step() *Step { return s.step }
+
+// Summary returns the transaction summary of the current line, or nil.
+func (s *Scanner) Summary() *Summary { return s.summary }
+
+// StateRoot returns the state root reported on the current line, or "".
+func (s *Scanner) StateRoot() string { return s.stateRoot }
+
+// Err returns the first error encountered while scanning.
+func (s *Scanner) Err() error { return s.err }
+
+// Normalize strips the fields deselected by the options from every step.
+func (t *Trace) Normalize(opts Options) {
+	n := NewNormalizer(opts)
+	for i := range t.Steps {
+		n.Normalize(&t.Steps[i])
+	}
+}
+
+// Normalizer normalizes the steps of a trace one by one, in order.
+type Normalizer struct {
+	opts   Options
+	refund uint64 // refund counter of the previous step
+}
+
+// NewNormalizer returns a normalizer applying the options.
+func NewNormalizer(opts Options) *Normalizer {
+	return &Normalizer{opts: opts}
+}
+
+// Normalize normalizes the next step of the trace.
+func (n *Normalizer) Normalize(s *Step) {
+	if n.opts.CollapseRefunds && (s.Op == sstore || s.Op == selfdestruct) {
+		s.Refund = n.refund
+	}
+	n.refund = s.Refund
+	s.normalize(n.opts)
+}
+
+func (s *Step) normalize(opts Options) {
+	if opts.NoMemory {
+		s.Memory = ""
+	}
+	if opts.NoReturnData {
+		s.ReturnData = ""
+	}
+	if opts.NoStack {
+		s.Stack = nil
+	}
+	if opts.NoRefund {
+		s.Refund = 0
+	}
+}
+
+// Name returns the opcode name, falling back to its hex value if the client
+// did not report names.
+func (s *Step) Name() string {
+	if s.OpName != "" {
+		return s.OpName
+	}
+	return fmt.Sprintf("0x%02x", s.Op)
+}
+
+// String renders the step as a compact single line.
+func (s *Step) String() string {
+	line := fmt.Sprintf("depth=%d pc=%d op=%s gas=%d cost=%d", s.Depth, s.Pc, s.Name(), s.Gas, s.GasCost)
+	if s.Stack != nil {
+		line += " stack=[" + strings.Join(s.Stack, ",") + "]"
+	}
+	if s.Error != "" {
+		line += " error=" + s.Error
+	}
+	return line
+}
+
+// parseWord decodes a stack item, given as a hex string or a JSON number.
+func parseWord(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		s = string(raw)
+	}
+	n, ok := math.ParseBig256(s)
+	if !ok {
+		return "", fmt.Errorf("invalid word %q", s)
+	}
+	return hexutil.EncodeBig(n), nil
+}
+
+// normalizeBytes lowercases a hex byte string and ensures its 0x prefix.
+func normalizeBytes(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "0x" + strings.TrimPrefix(strings.ToLower(s), "0x")
+}
@@ -0,0 +1,192 @@
+package txbuilder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+)
+
+// maxAccessListRuns bounds the executions needed for the access list of a
+// transaction to settle: the list changes the gas available to the calls of
+// the transaction and with it the execution.
+const maxAccessListRuns = 10
+
+// Executor runs transactions against a pre-state, within the block following
+// the genesis.
+type Executor struct {
+	chain   *core.BlockChain
+	header  *types.Header
+	statedb *state.StateDB
+	gasUsed uint64 // gas used by the applied transactions
+	applied int    // number of applied transactions
+}
+
+// AccessListGas is the gas used by a transaction with and without its
+// generated access list.
+type AccessListGas struct {
+	Without uint64 `json:"withoutAccessList"`
+	With    uint64 `json:"withAccessList"`
+}
+
+// Saved returns the gas the access list saves, negative if it costs more than
+// it saves.
+func (g *AccessListGas) Saved() int64 {
+	return int64(g.Without) - int64(g.With)
+}
+
+// NewExecutor creates an executor on top of the genesis state.
+func NewExecutor(genesis *core.Genesis) (*Executor, error) {
+	engine := beacon.New(ethash.NewFaker())
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), genesis, engine, core.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	// Let the chain generator derive the header of the next block, transactions
+	// then run in that block context.
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, engine, 1, nil)
+	statedb, err := chain.State()
+	if err != nil {
+		chain.Stop()
+		return nil, err
+	}
+	return &Executor{chain: chain, header: blocks[0].Header(), statedb: statedb}, nil
+}
+
+// Close releases the resources of the executor.
+func (e *Executor) Close() {
+	e.chain.Stop()
+}
+
+// run executes the transaction on the state, tracing it with the hooks.
+func (e *Executor) run(statedb *state.StateDB, tx *types.Transaction, hooks *tracing.Hooks) (*types.Receipt, error) {
+	var (
+		gp   = new(core.GasPool).AddGas(e.header.GasLimit - e.gasUsed)
+		used = e.gasUsed
+		ctx  = core.NewEVMBlockContext(e.header, e.chain, &e.header.Coinbase)
+		evm  = vm.NewEVM(ctx, statedb, e.chain.Config(), vm.Config{Tracer: hooks})
+	)
+	statedb.SetTxContext(tx.Hash(), e.applied)
+	return core.ApplyTransaction(evm, gp, statedb, e.header, tx, &used)
+}
+
+// Apply executes the transaction and keeps its state changes, so that the
+// following transactions run on top of it.
+func (e *Executor) Apply(tx *types.Transaction) (*types.Receipt, error) {
+	receipt, err := e.run(e.statedb, tx, nil)
+	if err != nil {
+		return nil, err
+	}
+	e.gasUsed += receipt.GasUsed
+	e.applied++
+	return receipt, nil
+}
+
+// AccessList executes the defined transaction without applying it and returns
+// the access list covering the addresses and storage slots it touches, along
+// with the gas used with and without the list. The addresses warm anyway are
+// left out: the sender, the recipient, the precompiles, the coinbase since
+// Shanghai and the authorities of set code transactions. Their storage slots
+// are listed nevertheless.
+func (e *Executor) AccessList(d *Definition) (types.AccessList, *AccessListGas, error) {
+	if d.TxType() == types.LegacyTxType {
+		return nil, nil, errors.New("legacy transactions have no access list")
+	}
+	def := *d
+	def.AccessList = &types.AccessList{}
+	tx, err := Build(&def)
+	if err != nil {
+		return nil, nil, err
+	}
+	exclude, err := e.warmAddresses(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		gas    = new(AccessListGas)
+		tracer = logger.NewAccessListTracer(nil, exclude)
+	)
+	for run := 0; ; run++ {
+		receipt, err := e.run(e.statedb.Copy(), tx, tracer.Hooks())
+		if err != nil {
+			return nil, nil, err
+		}
+		if run == 0 {
+			gas.Without = receipt.GasUsed
+		}
+		gas.With = receipt.GasUsed
+		list := tracer.AccessList()
+		if run > 0 && accessListEqual(list, *def.AccessList) {
+			return *def.AccessList, gas, nil
+		}
+		if run == maxAccessListRuns {
+			return nil, nil, fmt.Errorf("access list did not settle after %d runs", run)
+		}
+		sortAccessList(list)
+		def.AccessList = &list
+		if tx, err = Build(&def); err != nil {
+			return nil, nil, err
+		}
+		tracer = logger.NewAccessListTracer(list, exclude)
+	}
+}
+
+// warmAddresses returns the addresses accessed before the execution of the
+// transaction starts.
+func (e *Executor) warmAddresses(tx *types.Transaction) (map[common.Address]struct{}, error) {
+	config := e.chain.Config()
+	sender, err := types.Sender(types.MakeSigner(config, e.header.Number, e.header.Time), tx)
+	if err != nil {
+		return nil, err
+	}
+	warm := map[common.Address]struct{}{sender: {}}
+	if to := tx.To(); to != nil {
+		warm[*to] = struct{}{}
+	}
+	rules := config.Rules(e.header.Number, true, e.header.Time)
+	for _, addr := range vm.ActivePrecompiles(rules) {
+		warm[addr] = struct{}{}
+	}
+	if rules.IsShanghai {
+		warm[e.header.Coinbase] = struct{}{}
+	}
+	for _, auth := range tx.SetCodeAuthorizations() {
+		if authority, err := auth.Authority(); err == nil {
+			warm[authority] = struct{}{}
+		}
+	}
+	return warm, nil
+}
+
+// sortAccessList orders the entries by address and the keys of each entry.
+func sortAccessList(list types.AccessList) {
+	slices.SortFunc(list, func(a, b types.AccessTuple) int {
+		return bytes.Compare(a.Address[:], b.Address[:])
+	})
+	for _, tuple := range list {
+		slices.SortFunc(tuple.StorageKeys, func(a, b common.Hash) int {
+			return bytes.Compare(a[:], b[:])
+		})
+	}
+}
+
+// accessListEqual compares two access lists regardless of their order.
+func accessListEqual(a, b types.AccessList) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	sortAccessList(a)
+	sortAccessList(b)
+	return slices.EqualFunc(a, b, func(x, y types.AccessTuple) bool {
+		return x.Address == y.Address && slices.Equal(x.StorageKeys, y.StorageKeys)
+	})
+}
@@ -0,0 +1,57 @@
+package txbuilder
+
+import (
+	"fmt"
+
+	"github.com/ethereum/execution-specs/pkg/kzg"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+// NewSidecar computes the commitments and proofs of the blobs, either a proof
+// per blob or, with cellProofs, the cell proofs required since Osaka. The
+// proofs are verified before the sidecar is returned, and kzg4844 is checked
+// to commit with the ceremony setup.
+func NewSidecar(blobs []kzg4844.Blob, cellProofs bool) (*types.BlobTxSidecar, error) {
+	if err := kzg.CheckLibrary(); err != nil {
+		return nil, err
+	}
+	var (
+		commitments = make([]kzg4844.Commitment, len(blobs))
+		proofs      []kzg4844.Proof
+		version     = types.BlobSidecarVersion0
+	)
+	if cellProofs {
+		version = types.BlobSidecarVersion1
+	}
+	for i := range blobs {
+		blob := &blobs[i]
+		commitment, err := kzg4844.BlobToCommitment(blob)
+		if err != nil {
+			return nil, fmt.Errorf("blob %d: %v", i, err)
+		}
+		commitments[i] = commitment
+		if cellProofs {
+			cells, err := kzg4844.ComputeCellProofs(blob)
+			if err != nil {
+				return nil, fmt.Errorf("blob %d: %v", i, err)
+			}
+			proofs = append(proofs, cells...)
+			continue
+		}
+		proof, err := kzg4844.ComputeBlobProof(blob, commitment)
+		if err != nil {
+			return nil, fmt.Errorf("blob %d: %v", i, err)
+		}
+		if err := kzg4844.VerifyBlobProof(blob, commitment, proof); err != nil {
+			return nil, fmt.Errorf("blob %d: %v", i, err)
+		}
+		proofs = append(proofs, proof)
+	}
+	if cellProofs && len(blobs) > 0 {
+		if err := kzg4844.VerifyCellProofs(blobs, commitments, proofs); err != nil {
+			return nil, err
+		}
+	}
+	return types.NewBlobTxSidecar(version, blobs, commitments, proofs), nil
+}
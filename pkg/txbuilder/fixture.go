@@ -0,0 +1,144 @@
+package txbuilder
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// FixtureTransaction is a signed transaction in the JSON representation of
+// the reference tests. Contract creations have an empty "to".
+type FixtureTransaction struct {
+	Type                 hexutil.Uint64          `json:"type"`
+	ChainID              *hexutil.Big            `json:"chainId,omitempty"`
+	Nonce                hexutil.Uint64          `json:"nonce"`
+	GasPrice             *hexutil.Big            `json:"gasPrice,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big            `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerGas         *hexutil.Big            `json:"maxFeePerGas,omitempty"`
+	GasLimit             hexutil.Uint64          `json:"gasLimit"`
+	To                   string                  `json:"to"`
+	Value                *hexutil.Big            `json:"value"`
+	Data                 hexutil.Bytes           `json:"data"`
+	AccessList           *types.AccessList       `json:"accessList,omitempty"`
+	MaxFeePerBlobGas     *hexutil.Big            `json:"maxFeePerBlobGas,omitempty"`
+	BlobVersionedHashes  []common.Hash           `json:"blobVersionedHashes,omitempty"`
+	AuthorizationList    []*FixtureAuthorization `json:"authorizationList,omitempty"`
+	V                    *hexutil.Big            `json:"v"`
+	R                    *hexutil.Big            `json:"r"`
+	S                    *hexutil.Big            `json:"s"`
+	Sender               common.Address          `json:"sender"`
+}
+
+// FixtureAuthorization is a signed authorization of a set code transaction.
+// The signer is left out of authorizations with an invalid signature.
+type FixtureAuthorization struct {
+	ChainID *hexutil.Big    `json:"chainId"`
+	Address common.Address  `json:"address"`
+	Nonce   hexutil.Uint64  `json:"nonce"`
+	V       hexutil.Uint64  `json:"v"`
+	R       *hexutil.Big    `json:"r"`
+	S       *hexutil.Big    `json:"s"`
+	Signer  *common.Address `json:"signer,omitempty"`
+}
+
+// Result is a built transaction in the encodings used by the fixtures.
+type Result struct {
+	Hash common.Hash `json:"hash"`
+
+	// RLP is the canonical encoding, as included in blocks. NetworkRLP is the
+	// encoding with the blob sidecar exchanged between nodes, it is only set
+	// for blob transactions carrying their blobs.
+	RLP        hexutil.Bytes `json:"rlp"`
+	NetworkRLP hexutil.Bytes `json:"networkRlp,omitempty"`
+
+	Transaction *FixtureTransaction `json:"transaction"`
+}
+
+// Encode returns the encodings of a signed transaction.
+func Encode(tx *types.Transaction) (*Result, error) {
+	fixture, err := NewFixtureTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Hash: tx.Hash(), Transaction: fixture}
+	if tx.BlobTxSidecar() != nil {
+		if result.NetworkRLP, err = tx.MarshalBinary(); err != nil {
+			return nil, err
+		}
+		tx = tx.WithoutBlobTxSidecar()
+	}
+	if result.RLP, err = tx.MarshalBinary(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// NewFixtureTransaction converts a signed transaction into its fixture
+// representation.
+func NewFixtureTransaction(tx *types.Transaction) (*FixtureTransaction, error) {
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.LatestSignerForChainID(tx.ChainId())
+	}
+	sender, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %v", err)
+	}
+	v, r, s := tx.RawSignatureValues()
+	ftx := &FixtureTransaction{
+		Type:     hexutil.Uint64(tx.Type()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		GasLimit: hexutil.Uint64(tx.Gas()),
+		Value:    (*hexutil.Big)(tx.Value()),
+		Data:     tx.Data(),
+		V:        (*hexutil.Big)(v),
+		R:        (*hexutil.Big)(r),
+		S:        (*hexutil.Big)(s),
+		Sender:   sender,
+	}
+	if to := tx.To(); to != nil {
+		ftx.To = to.Hex()
+	}
+	if tx.Type() == types.LegacyTxType {
+		ftx.GasPrice = (*hexutil.Big)(tx.GasPrice())
+		if tx.Protected() {
+			ftx.ChainID = (*hexutil.Big)(tx.ChainId())
+		}
+		return ftx, nil
+	}
+	accessList := tx.AccessList()
+	if accessList == nil {
+		accessList = types.AccessList{}
+	}
+	ftx.ChainID = (*hexutil.Big)(tx.ChainId())
+	ftx.AccessList = &accessList
+	if tx.Type() == types.AccessListTxType {
+		ftx.GasPrice = (*hexutil.Big)(tx.GasPrice())
+		return ftx, nil
+	}
+	ftx.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	ftx.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+	switch tx.Type() {
+	case types.BlobTxType:
+		ftx.MaxFeePerBlobGas = (*hexutil.Big)(tx.BlobGasFeeCap())
+		ftx.BlobVersionedHashes = tx.BlobHashes()
+	case types.SetCodeTxType:
+		for _, auth := range tx.SetCodeAuthorizations() {
+			fauth := &FixtureAuthorization{
+				ChainID: (*hexutil.Big)(auth.ChainID.ToBig()),
+				Address: auth.Address,
+				Nonce:   hexutil.Uint64(auth.Nonce),
+				V:       hexutil.Uint64(auth.V),
+				R:       (*hexutil.Big)(auth.R.ToBig()),
+				S:       (*hexutil.Big)(auth.S.ToBig()),
+			}
+			if signer, err := auth.Authority(); err == nil {
+				fauth.Signer = &signer
+			}
+			ftx.AuthorizationList = append(ftx.AuthorizationList, fauth)
+		}
+	}
+	return ftx, nil
+}
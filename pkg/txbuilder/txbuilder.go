@@ -0,0 +1,295 @@
+// Package txbuilder constructs and signs transactions of every type from
+// declarative definitions.
+//
+// A definition lists the fields of a transaction in their JSON form together
+// with the secret key signing it. The transaction type is taken from the
+// definition or derived from the fields present: an authorization list makes
+// a set code transaction, blobs or blob hashes a blob transaction, the
+// EIP-1559 fee fields a dynamic fee transaction and an access list an access
+// list transaction. Blob transactions given the blobs themselves get their
+// commitments, proofs and versioned hashes computed; authorizations are signed
+// with their own keys.
+//
+// Built transactions are returned in both encodings the fixtures use: the
+// binary encoding, with and without the blob sidecar, and the JSON
+// representation of the transactions of the reference tests.
+package txbuilder
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/holiman/uint256"
+)
+
+// Definition describes a transaction. Fields not applying to the type of the
+// transaction have to be left out, omitted numbers default to zero and the
+// chain id to 1.
+type Definition struct {
+	Type                 *math.HexOrDecimal64  `json:"type"`
+	ChainID              *math.HexOrDecimal256 `json:"chainId"`
+	Nonce                math.HexOrDecimal64   `json:"nonce"`
+	GasPrice             *math.HexOrDecimal256 `json:"gasPrice"`
+	MaxPriorityFeePerGas *math.HexOrDecimal256 `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         *math.HexOrDecimal256 `json:"maxFeePerGas"`
+	GasLimit             math.HexOrDecimal64   `json:"gasLimit"`
+	To                   *common.Address       `json:"to"` // nil for contract creation
+	Value                *math.HexOrDecimal256 `json:"value"`
+	Data                 hexutil.Bytes         `json:"data"`
+	AccessList           *types.AccessList     `json:"accessList"`
+
+	// Blob transactions either list the versioned hashes or the blobs, which
+	// are zero padded to the blob size. If both are given they have to match.
+	MaxFeePerBlobGas    *math.HexOrDecimal256 `json:"maxFeePerBlobGas"`
+	BlobVersionedHashes []common.Hash         `json:"blobVersionedHashes"`
+	Blobs               []hexutil.Bytes       `json:"blobs"`
+	CellProofs          bool                  `json:"cellProofs"` // sidecar with cell proofs (Osaka) instead of blob proofs
+
+	AuthorizationList []*Authorization `json:"authorizationList"`
+
+	// Unprotected legacy transactions are signed without the chain id, as
+	// before EIP-155.
+	Unprotected bool        `json:"unprotected"`
+	SecretKey   common.Hash `json:"secretKey"`
+}
+
+// Authorization is an EIP-7702 authorization, signed with its secret key. The
+// chain id defaults to the one of the transaction, 0 authorizes on all chains.
+type Authorization struct {
+	ChainID   *math.HexOrDecimal256 `json:"chainId"`
+	Address   common.Address        `json:"address"`
+	Nonce     math.HexOrDecimal64   `json:"nonce"`
+	SecretKey common.Hash           `json:"secretKey"`
+}
+
+// TxType returns the type of the defined transaction.
+func (d *Definition) TxType() uint8 {
+	switch {
+	case d.Type != nil:
+		return uint8(*d.Type)
+	case d.AuthorizationList != nil:
+		return types.SetCodeTxType
+	case d.Blobs != nil || d.BlobVersionedHashes != nil || d.MaxFeePerBlobGas != nil:
+		return types.BlobTxType
+	case d.MaxFeePerGas != nil || d.MaxPriorityFeePerGas != nil:
+		return types.DynamicFeeTxType
+	case d.AccessList != nil:
+		return types.AccessListTxType
+	default:
+		return types.LegacyTxType
+	}
+}
+
+// Build constructs the transaction of the definition and signs it.
+func Build(d *Definition) (*types.Transaction, error) {
+	key, err := secretKey(d.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	chainID := big.NewInt(1)
+	if d.ChainID != nil {
+		chainID = (*big.Int)(d.ChainID)
+	}
+	if chainID.Sign() <= 0 {
+		return nil, errors.New("chain id must be positive")
+	}
+	txType := d.TxType()
+	if err := d.checkFields(txType); err != nil {
+		return nil, err
+	}
+	var accessList types.AccessList
+	if d.AccessList != nil {
+		accessList = *d.AccessList
+	}
+	// The fields of the newer transaction types are 256 bit integers, the
+	// first one overflowing fails the build.
+	var overflow error
+	u256 := func(name string, v *big.Int) *uint256.Int {
+		x, of := uint256.FromBig(v)
+		if of && overflow == nil {
+			overflow = fmt.Errorf("%s exceeds 256 bits", name)
+		}
+		return x
+	}
+	var data types.TxData
+	switch txType {
+	case types.LegacyTxType:
+		data = &types.LegacyTx{
+			Nonce:    uint64(d.Nonce),
+			GasPrice: bigValue(d.GasPrice),
+			Gas:      uint64(d.GasLimit),
+			To:       d.To,
+			Value:    bigValue(d.Value),
+			Data:     d.Data,
+		}
+	case types.AccessListTxType:
+		data = &types.AccessListTx{
+			ChainID:    chainID,
+			Nonce:      uint64(d.Nonce),
+			GasPrice:   bigValue(d.GasPrice),
+			Gas:        uint64(d.GasLimit),
+			To:         d.To,
+			Value:      bigValue(d.Value),
+			Data:       d.Data,
+			AccessList: accessList,
+		}
+	case types.DynamicFeeTxType:
+		data = &types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      uint64(d.Nonce),
+			GasTipCap:  bigValue(d.MaxPriorityFeePerGas),
+			GasFeeCap:  bigValue(d.MaxFeePerGas),
+			Gas:        uint64(d.GasLimit),
+			To:         d.To,
+			Value:      bigValue(d.Value),
+			Data:       d.Data,
+			AccessList: accessList,
+		}
+	case types.BlobTxType, types.SetCodeTxType:
+		if d.To == nil {
+			return nil, fmt.Errorf("type %d transactions cannot create contracts", txType)
+		}
+		if txType == types.SetCodeTxType {
+			auths, err := signAuthorizations(d.AuthorizationList, chainID)
+			if err != nil {
+				return nil, err
+			}
+			data = &types.SetCodeTx{
+				ChainID:    u256("chainId", chainID),
+				Nonce:      uint64(d.Nonce),
+				GasTipCap:  u256("maxPriorityFeePerGas", bigValue(d.MaxPriorityFeePerGas)),
+				GasFeeCap:  u256("maxFeePerGas", bigValue(d.MaxFeePerGas)),
+				Gas:        uint64(d.GasLimit),
+				To:         *d.To,
+				Value:      u256("value", bigValue(d.Value)),
+				Data:       d.Data,
+				AccessList: accessList,
+				AuthList:   auths,
+			}
+			break
+		}
+		tx := &types.BlobTx{
+			ChainID:    u256("chainId", chainID),
+			Nonce:      uint64(d.Nonce),
+			GasTipCap:  u256("maxPriorityFeePerGas", bigValue(d.MaxPriorityFeePerGas)),
+			GasFeeCap:  u256("maxFeePerGas", bigValue(d.MaxFeePerGas)),
+			Gas:        uint64(d.GasLimit),
+			To:         *d.To,
+			Value:      u256("value", bigValue(d.Value)),
+			Data:       d.Data,
+			AccessList: accessList,
+			BlobFeeCap: u256("maxFeePerBlobGas", bigValue(d.MaxFeePerBlobGas)),
+			BlobHashes: d.BlobVersionedHashes,
+		}
+		if d.Blobs != nil {
+			blobs := make([]kzg4844.Blob, len(d.Blobs))
+			for i, data := range d.Blobs {
+				if len(data) > len(blobs[i]) {
+					return nil, fmt.Errorf("blob %d: %d bytes exceed the blob size of %d", i, len(data), len(blobs[i]))
+				}
+				copy(blobs[i][:], data)
+			}
+			if tx.Sidecar, err = NewSidecar(blobs, d.CellProofs); err != nil {
+				return nil, err
+			}
+			hashes := tx.Sidecar.BlobHashes()
+			if tx.BlobHashes != nil && !slices.Equal(tx.BlobHashes, hashes) {
+				return nil, errors.New("blob versioned hashes do not match the blobs")
+			}
+			tx.BlobHashes = hashes
+		}
+		data = tx
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", txType)
+	}
+	if overflow != nil {
+		return nil, overflow
+	}
+
+	var signer types.Signer
+	if txType == types.LegacyTxType && d.Unprotected {
+		signer = types.HomesteadSigner{}
+	} else {
+		signer = types.LatestSignerForChainID(chainID)
+	}
+	return types.SignNewTx(key, signer, data)
+}
+
+// checkFields rejects fields which do not apply to the transaction type, as
+// they would be silently dropped otherwise.
+func (d *Definition) checkFields(txType uint8) error {
+	var field string
+	switch {
+	case d.GasPrice != nil && txType > types.AccessListTxType:
+		field = "gasPrice"
+	case d.Unprotected && txType != types.LegacyTxType:
+		field = "unprotected"
+	case d.AccessList != nil && txType == types.LegacyTxType:
+		field = "accessList"
+	case d.MaxPriorityFeePerGas != nil && txType < types.DynamicFeeTxType:
+		field = "maxPriorityFeePerGas"
+	case d.MaxFeePerGas != nil && txType < types.DynamicFeeTxType:
+		field = "maxFeePerGas"
+	case d.MaxFeePerBlobGas != nil && txType != types.BlobTxType:
+		field = "maxFeePerBlobGas"
+	case d.BlobVersionedHashes != nil && txType != types.BlobTxType:
+		field = "blobVersionedHashes"
+	case (d.Blobs != nil || d.CellProofs) && txType != types.BlobTxType:
+		field = "blobs"
+	case d.AuthorizationList != nil && txType != types.SetCodeTxType:
+		field = "authorizationList"
+	default:
+		return nil
+	}
+	return fmt.Errorf("field %s does not apply to type %d transactions", field, txType)
+}
+
+// signAuthorizations signs the authorizations of a set code transaction.
+func signAuthorizations(list []*Authorization, chainID *big.Int) ([]types.SetCodeAuthorization, error) {
+	auths := make([]types.SetCodeAuthorization, len(list))
+	for i, a := range list {
+		key, err := secretKey(a.SecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("authorization %d: %v", i, err)
+		}
+		id := chainID
+		if a.ChainID != nil {
+			id = (*big.Int)(a.ChainID)
+		}
+		auth := types.SetCodeAuthorization{Address: a.Address, Nonce: uint64(a.Nonce)}
+		if auth.ChainID.SetFromBig(id) {
+			return nil, fmt.Errorf("authorization %d: chain id exceeds 256 bits", i)
+		}
+		if auths[i], err = types.SignSetCode(key, auth); err != nil {
+			return nil, fmt.Errorf("authorization %d: %v", i, err)
+		}
+	}
+	return auths, nil
+}
+
+func secretKey(k common.Hash) (*ecdsa.PrivateKey, error) {
+	if k == (common.Hash{}) {
+		return nil, errors.New("missing secret key")
+	}
+	key, err := crypto.ToECDSA(k.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret key: %v", err)
+	}
+	return key, nil
+}
+
+func bigValue(v *math.HexOrDecimal256) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return (*big.Int)(v)
+}
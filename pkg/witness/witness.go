@@ -0,0 +1,183 @@
+// Package witness generates and verifies the execution witness of a block:
+// everything a stateless client needs besides the block itself to execute it
+// and derive its post-state.
+//
+// A witness is encoded as JSON with the following fields, all byte strings
+// being 0x-prefixed hex:
+//
+//	config   the chain config of the block, in the geth genesis encoding
+//	block    the RLP encoding of the block
+//	headers  the RLP encoded ancestor headers, starting with the parent, back
+//	         to the oldest block whose hash the execution reads
+//	codes    the bytecodes executed or accessed, in ascending byte order
+//	state    the account and storage trie nodes read or modified, including
+//	         the siblings needed to collapse nodes on deletion, in
+//	         ascending byte order
+//
+// The pre-state root is the root of the parent header. Trie nodes and codes
+// are looked up by their keccak256 hash, so a witness cannot smuggle in data
+// that is not authenticated by that root.
+package witness
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Witness is the execution witness of a block in its JSON encoding.
+type Witness struct {
+	Config  *params.ChainConfig `json:"config"`
+	Block   hexutil.Bytes       `json:"block"`
+	Headers []hexutil.Bytes     `json:"headers"`
+	Codes   []hexutil.Bytes     `json:"codes"`
+	State   []hexutil.Bytes     `json:"state"`
+}
+
+// Generate imports the blocks on top of the genesis and returns the witness
+// of the last one, collected while go-ethereum executes it. The blocks before
+// it only serve to build up its pre-state and ancestors. The witness is
+// verified before it is returned.
+func Generate(genesis *core.Genesis, blocks []*types.Block) (*Witness, error) {
+	if len(blocks) == 0 {
+		return nil, errors.New("no block to generate the witness of")
+	}
+	if genesis.Config == nil {
+		return nil, errors.New("missing chain config")
+	}
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), genesis, beacon.New(ethash.NewFaker()), core.DefaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	defer chain.Stop()
+
+	last := len(blocks) - 1
+	if n, err := chain.InsertChain(blocks[:last]); err != nil {
+		return nil, fmt.Errorf("block %d: %v", blocks[n].Number(), err)
+	}
+	block := blocks[last]
+	collected, err := chain.InsertBlockWithoutSetHead(block, true)
+	if err != nil {
+		return nil, fmt.Errorf("block %d: %v", block.Number(), err)
+	}
+	w, err := encode(genesis.Config, block, collected)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Verify(); err != nil {
+		return nil, fmt.Errorf("generated witness: %v", err)
+	}
+	return w, nil
+}
+
+// encode converts a witness collected by go-ethereum into the JSON encoding.
+func encode(config *params.ChainConfig, block *types.Block, collected *stateless.Witness) (*Witness, error) {
+	enc, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return nil, err
+	}
+	w := &Witness{Config: config, Block: enc}
+	for _, header := range collected.Headers {
+		enc, err := rlp.EncodeToBytes(header)
+		if err != nil {
+			return nil, err
+		}
+		w.Headers = append(w.Headers, enc)
+	}
+	for code := range collected.Codes {
+		w.Codes = append(w.Codes, []byte(code))
+	}
+	for node := range collected.State {
+		w.State = append(w.State, []byte(node))
+	}
+	sortBytes(w.Codes)
+	sortBytes(w.State)
+	return w, nil
+}
+
+// sortBytes sorts byte strings in ascending order.
+func sortBytes(list []hexutil.Bytes) {
+	sort.Slice(list, func(i, j int) bool { return bytes.Compare(list[i], list[j]) < 0 })
+}
+
+// Decode returns the block of the witness and the witness in the go-ethereum
+// representation.
+func (w *Witness) Decode() (*types.Block, *stateless.Witness, error) {
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(w.Block, block); err != nil {
+		return nil, nil, fmt.Errorf("invalid block: %v", err)
+	}
+	if len(w.Headers) == 0 {
+		return nil, nil, errors.New("missing parent header")
+	}
+	ext := &stateless.ExtWitness{Codes: w.Codes, State: w.State}
+	for i, enc := range w.Headers {
+		header := new(types.Header)
+		if err := rlp.DecodeBytes(enc, header); err != nil {
+			return nil, nil, fmt.Errorf("invalid header %d: %v", i, err)
+		}
+		ext.Headers = append(ext.Headers, header)
+	}
+	if parent := ext.Headers[0]; parent.Hash() != block.ParentHash() {
+		return nil, nil, fmt.Errorf("first header %x is not the parent %x of the block", parent.Hash(), block.ParentHash())
+	}
+	for i := 1; i < len(ext.Headers); i++ {
+		if ext.Headers[i].Hash() != ext.Headers[i-1].ParentHash {
+			return nil, nil, fmt.Errorf("header %d is not the parent of header %d", i, i-1)
+		}
+	}
+	// The internal representation can only be populated through its RLP
+	// decoder.
+	enc, err := rlp.EncodeToBytes(ext)
+	if err != nil {
+		return nil, nil, err
+	}
+	decoded := new(stateless.Witness)
+	if err := rlp.DecodeBytes(enc, decoded); err != nil {
+		return nil, nil, err
+	}
+	return block, decoded, nil
+}
+
+// Verify re-executes the block against nothing but the witness and checks
+// that the resulting state and receipts roots match the block header.
+func (w *Witness) Verify() error {
+	if w.Config == nil {
+		return errors.New("missing chain config")
+	}
+	block, witness, err := w.Decode()
+	if err != nil {
+		return err
+	}
+	// The roots are what the stateless execution derives, remove them from
+	// the block so that they are recomputed rather than trusted.
+	header := block.Header()
+	header.Root = common.Hash{}
+	header.ReceiptHash = common.Hash{}
+	task := types.NewBlockWithHeader(header).WithBody(*block.Body())
+
+	root, receipts, err := core.ExecuteStateless(w.Config, vm.Config{}, task, witness)
+	if err != nil {
+		return fmt.Errorf("stateless execution of block %d: %v", block.Number(), err)
+	}
+	if root != block.Root() {
+		return fmt.Errorf("state root mismatch: witness execution %x, block %x", root, block.Root())
+	}
+	if receipts != block.ReceiptHash() {
+		return fmt.Errorf("receipts root mismatch: witness execution %x, block %x", receipts, block.ReceiptHash())
+	}
+	return nil
+}